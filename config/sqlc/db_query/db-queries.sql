@@ -1,26 +1,26 @@
 -- --------------------- AUTHENTICATION ------------------------------
 -- name: GetUserByEmail :one
-SELECT user_id, user_name, email, phone, pass, pss_valid, otp, otp_valid, otp_exp, role 
+SELECT user_id, user_name, email, phone, pass, pss_valid, otp, otp_valid, otp_exp, role, status, created_at 
 FROM common.users 
 WHERE email = $1;
 
 -- name: GetUserByUserName :one
-SELECT user_id, user_name, email, phone, pass, pss_valid, otp, otp_valid, otp_exp, role 
+SELECT user_id, user_name, email, phone, pass, pss_valid, otp, otp_valid, otp_exp, role, status, created_at 
 FROM common.users 
 WHERE user_name = $1;
 
 -- name: GetUserByPhone :one
-SELECT user_id, user_name, email, phone, pass, pss_valid, otp, otp_valid, otp_exp, role 
+SELECT user_id, user_name, email, phone, pass, pss_valid, otp, otp_valid, otp_exp, role, status, created_at 
 FROM common.users 
 WHERE phone = $1;
 
 -- name: GetUserById :one
-SELECT user_id, user_name, email, phone, pass, pss_valid, otp, otp_valid, otp_exp, role 
+SELECT user_id, user_name, email, phone, pass, pss_valid, otp, otp_valid, otp_exp, role, status, created_at 
 FROM common.users 
 WHERE user_id = $1;
 
 -- name: GetUserByLogin :one
-SELECT user_id, user_name, email, phone, pass, pss_valid, otp, otp_valid, otp_exp, role 
+SELECT user_id, user_name, email, phone, pass, pss_valid, otp, otp_valid, otp_exp, role, status, created_at 
 FROM common.users 
 WHERE user_name = $1 OR email = $1 OR phone = $1;
 
@@ -28,6 +28,10 @@ WHERE user_name = $1 OR email = $1 OR phone = $1;
 INSERT INTO common.users(user_name, email, phone, pass, role) 
 VALUES($1, $2, $3, $4, $5);
 
+-- name: ImportUser :exec
+INSERT INTO common.users(user_name, email, phone, pass, pss_valid, role, status)
+VALUES($1, $2, $3, $4, $5, $6, $7);
+
 -- name: UpdatePassword :exec
 UPDATE common.users 
 SET pass = $1, pss_valid = $2 
@@ -38,32 +42,896 @@ UPDATE common.users
 SET user_name = $1, email = $2, phone = $3, role = $4
 WHERE user_id = $5;
 
+-- name: GetUserStatusById :one
+SELECT status
+FROM common.users
+WHERE user_id = $1;
+
+-- name: UpdateUserStatus :exec
+UPDATE common.users
+SET status = $1
+WHERE user_id = $2;
+
+-- name: UpdateUserRole :exec
+UPDATE common.users
+SET role = $1
+WHERE user_id = $2;
+
+-- name: DeleteUser :exec
+DELETE FROM common.users
+WHERE user_id = $1;
+
 -- name: GetAllUsers :many
 SELECT user_id, user_name, email 
 FROM common.users 
 ORDER BY user_id;
 
+-- name: ListUsers :many
+SELECT user_id, user_name, email, phone, role, status, created_at
+FROM common.users
+WHERE (sqlc.narg('search')::text IS NULL
+        OR user_name ILIKE '%' || sqlc.narg('search') || '%' ESCAPE '\'
+        OR email ILIKE '%' || sqlc.narg('search') || '%' ESCAPE '\'
+        OR phone ILIKE '%' || sqlc.narg('search') || '%' ESCAPE '\')
+    AND (sqlc.narg('role')::text IS NULL OR role = sqlc.narg('role'))
+    AND (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status'))
+    AND (sqlc.narg('after_id')::int IS NULL OR user_id > sqlc.narg('after_id'))
+ORDER BY user_id
+LIMIT sqlc.arg('row_limit') OFFSET sqlc.arg('row_offset');
+
+-- name: ListUsersSorted :many
+SELECT user_id, user_name, email, phone, role, status, created_at
+FROM common.users
+WHERE (sqlc.narg('search')::text IS NULL
+        OR user_name ILIKE '%' || sqlc.narg('search') || '%' ESCAPE '\'
+        OR email ILIKE '%' || sqlc.narg('search') || '%' ESCAPE '\'
+        OR phone ILIKE '%' || sqlc.narg('search') || '%' ESCAPE '\')
+    AND (sqlc.narg('role')::text IS NULL OR role = sqlc.narg('role'))
+    AND (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status'))
+    AND (sqlc.narg('after_id')::int IS NULL OR user_id > sqlc.narg('after_id'))
+ORDER BY
+    CASE WHEN sqlc.arg('sort_by')::text = 'name' AND NOT sqlc.arg('sort_desc')::bool THEN user_name END,
+    CASE WHEN sqlc.arg('sort_by')::text = 'name' AND sqlc.arg('sort_desc')::bool THEN user_name END DESC,
+    CASE WHEN sqlc.arg('sort_by')::text = 'email' AND NOT sqlc.arg('sort_desc')::bool THEN email END,
+    CASE WHEN sqlc.arg('sort_by')::text = 'email' AND sqlc.arg('sort_desc')::bool THEN email END DESC,
+    CASE WHEN sqlc.arg('sort_by')::text = 'phone' AND NOT sqlc.arg('sort_desc')::bool THEN phone END,
+    CASE WHEN sqlc.arg('sort_by')::text = 'phone' AND sqlc.arg('sort_desc')::bool THEN phone END DESC,
+    CASE WHEN sqlc.arg('sort_by')::text = 'role' AND NOT sqlc.arg('sort_desc')::bool THEN role END,
+    CASE WHEN sqlc.arg('sort_by')::text = 'role' AND sqlc.arg('sort_desc')::bool THEN role END DESC,
+    CASE WHEN sqlc.arg('sort_by')::text = 'status' AND NOT sqlc.arg('sort_desc')::bool THEN status END,
+    CASE WHEN sqlc.arg('sort_by')::text = 'status' AND sqlc.arg('sort_desc')::bool THEN status END DESC,
+    CASE WHEN sqlc.arg('sort_by')::text = 'created' AND NOT sqlc.arg('sort_desc')::bool THEN created_at END,
+    CASE WHEN sqlc.arg('sort_by')::text = 'created' AND sqlc.arg('sort_desc')::bool THEN created_at END DESC,
+    CASE WHEN sqlc.arg('sort_desc')::bool THEN user_id END DESC,
+    user_id
+LIMIT sqlc.arg('row_limit') OFFSET sqlc.arg('row_offset');
+
+-- name: CountUsers :one
+SELECT count(*)
+FROM common.users
+WHERE (sqlc.narg('search')::text IS NULL
+        OR user_name ILIKE '%' || sqlc.narg('search') || '%' ESCAPE '\'
+        OR email ILIKE '%' || sqlc.narg('search') || '%' ESCAPE '\'
+        OR phone ILIKE '%' || sqlc.narg('search') || '%' ESCAPE '\')
+    AND (sqlc.narg('role')::text IS NULL OR role = sqlc.narg('role'))
+    AND (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status'));
+
+-- name: GetActiveUserEmailsByRole :many
+SELECT email
+FROM common.users
+WHERE role = $1 AND status = 'ACTIVE'
+ORDER BY email;
+
+-- name: ListMentionableUsers :many
+SELECT u.user_id, u.user_name, u.email
+FROM common.users u
+WHERE u.status = 'ACTIVE'
+    AND (lower(u.user_name) = ANY(sqlc.arg('names')::text[]) OR lower(u.email) = ANY(sqlc.arg('names')::text[]))
+    AND (u."role" = 'SUPER_ADMIN' OR EXISTS (
+        SELECT 1
+        FROM common.company_memberships m
+        JOIN common.companies c ON c.id = m.company_id
+        WHERE m.user_id = u.user_id AND c.name = sqlc.arg('company')))
+ORDER BY u.user_id;
+
+-- --------------------- USER IDENTITIES ------------------------------
+-- name: GetUserByIdentity :one
+SELECT u.user_id, u.user_name, u.email, u.phone, u.pass, u.pss_valid, u.otp, u.otp_valid, u.otp_exp, u.role, u.status, u.created_at
+FROM common.users u
+JOIN common.user_identities i ON i.user_id = u.user_id
+WHERE i.provider = sqlc.arg('provider') AND i.subject = sqlc.arg('subject');
+
+-- name: CreateUserIdentity :exec
+INSERT INTO common.user_identities (provider, subject, user_id)
+VALUES (sqlc.arg('provider'), sqlc.arg('subject'), sqlc.arg('user_id'))
+ON CONFLICT (provider, subject) DO UPDATE SET user_id = EXCLUDED.user_id, created_at = now();
+
+-- name: DeleteUserIdentities :exec
+DELETE FROM common.user_identities
+WHERE user_id = $1;
+
+-- name: CreateLoginCode :exec
+INSERT INTO common.login_codes (code_hash, user_id, expires_at)
+VALUES (sqlc.arg('code_hash'), sqlc.arg('user_id'), sqlc.arg('expires_at'));
+
+-- name: ConsumeLoginCode :one
+DELETE FROM common.login_codes
+WHERE code_hash = $1 AND expires_at > now()
+RETURNING user_id;
+
+-- name: DeleteExpiredLoginCodes :exec
+DELETE FROM common.login_codes
+WHERE expires_at <= now();
+
+-- --------------------- COMPANIES ------------------------------
+-- name: CreateCompany :one
+INSERT INTO common.companies(name)
+VALUES($1)
+RETURNING id, name, created_at;
+
+-- name: GetCompanyById :one
+SELECT id, name, created_at
+FROM common.companies
+WHERE id = $1;
+
+-- name: GetCompanyByName :one
+SELECT id, name, created_at
+FROM common.companies
+WHERE name = $1;
+
+-- name: ListCompanies :many
+SELECT id, name, created_at
+FROM common.companies
+ORDER BY name;
+
+-- name: RenameCompany :execrows
+UPDATE common.companies
+SET name = $2
+WHERE id = $1;
+
+-- name: UpsertCompanyMembership :exec
+INSERT INTO common.company_memberships(user_id, company_id, "role")
+VALUES($1, $2, $3)
+ON CONFLICT (user_id, company_id) DO UPDATE SET "role" = EXCLUDED."role";
+
+-- name: DeleteCompanyMembership :execrows
+DELETE FROM common.company_memberships
+WHERE user_id = $1 AND company_id = $2;
+
+-- name: DeleteUserCompanyMemberships :exec
+DELETE FROM common.company_memberships
+WHERE user_id = $1;
+
+-- name: GetCompanyMembership :one
+SELECT m.company_id, c.name AS company_name, m."role"
+FROM common.company_memberships m
+JOIN common.companies c ON c.id = m.company_id
+WHERE m.user_id = $1 AND m.company_id = $2;
+
+-- name: ListUserCompanies :many
+SELECT m.company_id, c.name AS company_name, m."role"
+FROM common.company_memberships m
+JOIN common.companies c ON c.id = m.company_id
+WHERE m.user_id = $1
+ORDER BY c.name;
+
+-- name: ListCompanyMembers :many
+SELECT m.user_id, u.user_name, u.email, m."role", m.created_at
+FROM common.company_memberships m
+JOIN common.users u ON u.user_id = m.user_id
+WHERE m.company_id = $1
+ORDER BY u.user_name;
+
+-- name: GetSatcomCompanyById :one
+SELECT company
+FROM common.satcom_data
+WHERE id = $1;
+
+-- name: ListCompanyApproverEmails :many
+SELECT u.email
+FROM common.company_memberships m
+JOIN common.companies c ON c.id = m.company_id
+JOIN common.users u ON u.user_id = m.user_id
+WHERE c.name = $1 AND m."role" IN ('APPROVER', 'ADMIN') AND u.status = 'ACTIVE'
+ORDER BY u.email;
+
+-- name: ListCompanyMemberEmails :many
+SELECT u.email
+FROM common.company_memberships m
+JOIN common.companies c ON c.id = m.company_id
+JOIN common.users u ON u.user_id = m.user_id
+WHERE c.name = $1 AND m."role" = $2 AND u.status = 'ACTIVE'
+ORDER BY u.email;
+
+-- name: UsersShareCompany :one
+SELECT EXISTS (
+    SELECT 1
+    FROM common.company_memberships a
+    JOIN common.company_memberships b ON b.company_id = a.company_id
+    WHERE a.user_id = sqlc.arg('user_id') AND b.user_id = sqlc.arg('other_user_id')
+) AS shared;
+
 -- --------------------- SATCOM DATA ------------------------------
--- name: CreateSatcomData :exec
-INSERT INTO common.satcom_data(company, category, "type", "date", "time", db_port, ui_port, url, ip, status)
-VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);
+-- name: CreateSatcomData :one
+INSERT INTO common.satcom_data(company, category, "type", recorded_at, db_port, ui_port, url, ip, status, name, labels, custom_fields)
+VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, COALESCE($11::jsonb, '{}'), COALESCE($12::jsonb, '{}'))
+RETURNING id;
 
 -- name: GetSatcomDataById :one
-SELECT id, company, category, "type", "date", "time", db_port, ui_port, url, ip, status
+SELECT id, company, category, "type", recorded_at, db_port, ui_port, url, ip, status, status_override, last_probed_at, version, deleted_at, deleted_by, name, labels, custom_fields
 FROM common.satcom_data
-WHERE id = $1;
+WHERE id = $1 AND deleted_at IS NULL;
 
 -- name: GetAllSatcomData :many
-SELECT id, company, category, "type", "date", "time", db_port, ui_port, url, ip, status
+SELECT id, company, category, "type", recorded_at, db_port, ui_port, url, ip, status, status_override, last_probed_at, version, deleted_at, deleted_by, name, labels, custom_fields
+FROM common.satcom_data
+WHERE deleted_at IS NULL
+ORDER BY id;
+
+-- name: ListSatcomData :many
+SELECT id, company, category, "type", recorded_at, db_port, ui_port, url, ip, status, status_override, last_probed_at, version, deleted_at, deleted_by, name, labels, custom_fields
+FROM common.satcom_data
+WHERE deleted_at IS NULL
+    AND (sqlc.narg('search')::text IS NULL
+        OR company ILIKE '%' || sqlc.narg('search') || '%' ESCAPE '\'
+        OR category ILIKE '%' || sqlc.narg('search') || '%' ESCAPE '\'
+        OR "type" ILIKE '%' || sqlc.narg('search') || '%' ESCAPE '\'
+        OR url ILIKE '%' || sqlc.narg('search') || '%' ESCAPE '\'
+        OR host(ip) ILIKE '%' || sqlc.narg('search') || '%' ESCAPE '\')
+    AND (sqlc.narg('company')::text IS NULL OR company = sqlc.narg('company'))
+    AND (sqlc.narg('category')::text IS NULL OR category = sqlc.narg('category'))
+    AND (sqlc.narg('type')::text IS NULL OR "type" = sqlc.narg('type'))
+    AND (sqlc.narg('status')::bool IS NULL OR status = sqlc.narg('status'))
+    AND (sqlc.narg('ip')::inet IS NULL OR ip <<= sqlc.narg('ip'))
+    AND (sqlc.narg('url')::text IS NULL OR url ILIKE '%' || sqlc.narg('url') || '%' ESCAPE '\')
+    AND (sqlc.narg('label_match')::jsonb IS NULL OR labels @> sqlc.narg('label_match'))
+    AND (sqlc.narg('label_exists')::text[] IS NULL OR labels ?& sqlc.narg('label_exists'))
+    AND (sqlc.narg('label_absent')::text[] IS NULL OR NOT labels ?| sqlc.narg('label_absent'))
+    AND (sqlc.narg('label_not')::jsonb IS NULL OR NOT EXISTS (
+        SELECT 1 FROM jsonb_array_elements(sqlc.narg('label_not')) AS excluded(label) WHERE labels @> excluded.label))
+    AND (sqlc.narg('label_in')::jsonb IS NULL OR NOT EXISTS (
+        SELECT 1 FROM jsonb_array_elements(sqlc.narg('label_in')) AS wanted(choices)
+        WHERE NOT EXISTS (SELECT 1 FROM jsonb_array_elements(wanted.choices) AS choice(label) WHERE labels @> choice.label)))
+    AND (sqlc.narg('after_id')::int IS NULL OR id > sqlc.narg('after_id'))
+ORDER BY id
+LIMIT sqlc.arg('row_limit') OFFSET sqlc.arg('row_offset');
+
+-- name: ListSatcomDataSorted :many
+SELECT id, company, category, "type", recorded_at, db_port, ui_port, url, ip, status, status_override, last_probed_at, version, deleted_at, deleted_by, name, labels, custom_fields
+FROM common.satcom_data
+WHERE deleted_at IS NULL
+    AND (sqlc.narg('search')::text IS NULL
+        OR company ILIKE '%' || sqlc.narg('search') || '%' ESCAPE '\'
+        OR category ILIKE '%' || sqlc.narg('search') || '%' ESCAPE '\'
+        OR "type" ILIKE '%' || sqlc.narg('search') || '%' ESCAPE '\'
+        OR url ILIKE '%' || sqlc.narg('search') || '%' ESCAPE '\'
+        OR host(ip) ILIKE '%' || sqlc.narg('search') || '%' ESCAPE '\')
+    AND (sqlc.narg('company')::text IS NULL OR company = sqlc.narg('company'))
+    AND (sqlc.narg('category')::text IS NULL OR category = sqlc.narg('category'))
+    AND (sqlc.narg('type')::text IS NULL OR "type" = sqlc.narg('type'))
+    AND (sqlc.narg('status')::bool IS NULL OR status = sqlc.narg('status'))
+    AND (sqlc.narg('ip')::inet IS NULL OR ip <<= sqlc.narg('ip'))
+    AND (sqlc.narg('url')::text IS NULL OR url ILIKE '%' || sqlc.narg('url') || '%' ESCAPE '\')
+    AND (sqlc.narg('label_match')::jsonb IS NULL OR labels @> sqlc.narg('label_match'))
+    AND (sqlc.narg('label_exists')::text[] IS NULL OR labels ?& sqlc.narg('label_exists'))
+    AND (sqlc.narg('label_absent')::text[] IS NULL OR NOT labels ?| sqlc.narg('label_absent'))
+    AND (sqlc.narg('label_not')::jsonb IS NULL OR NOT EXISTS (
+        SELECT 1 FROM jsonb_array_elements(sqlc.narg('label_not')) AS excluded(label) WHERE labels @> excluded.label))
+    AND (sqlc.narg('label_in')::jsonb IS NULL OR NOT EXISTS (
+        SELECT 1 FROM jsonb_array_elements(sqlc.narg('label_in')) AS wanted(choices)
+        WHERE NOT EXISTS (SELECT 1 FROM jsonb_array_elements(wanted.choices) AS choice(label) WHERE labels @> choice.label)))
+    AND (sqlc.narg('after_id')::int IS NULL OR id > sqlc.narg('after_id'))
+ORDER BY
+    CASE WHEN sqlc.arg('sort_by')::text = 'company' AND NOT sqlc.arg('sort_desc')::bool THEN company END,
+    CASE WHEN sqlc.arg('sort_by')::text = 'company' AND sqlc.arg('sort_desc')::bool THEN company END DESC,
+    CASE WHEN sqlc.arg('sort_by')::text = 'category' AND NOT sqlc.arg('sort_desc')::bool THEN category END,
+    CASE WHEN sqlc.arg('sort_by')::text = 'category' AND sqlc.arg('sort_desc')::bool THEN category END DESC,
+    CASE WHEN sqlc.arg('sort_by')::text = 'type' AND NOT sqlc.arg('sort_desc')::bool THEN "type" END,
+    CASE WHEN sqlc.arg('sort_by')::text = 'type' AND sqlc.arg('sort_desc')::bool THEN "type" END DESC,
+    CASE WHEN sqlc.arg('sort_by')::text = 'recorded_at' AND NOT sqlc.arg('sort_desc')::bool THEN recorded_at END,
+    CASE WHEN sqlc.arg('sort_by')::text = 'recorded_at' AND sqlc.arg('sort_desc')::bool THEN recorded_at END DESC,
+    CASE WHEN sqlc.arg('sort_by')::text = 'db_port' AND NOT sqlc.arg('sort_desc')::bool THEN db_port END,
+    CASE WHEN sqlc.arg('sort_by')::text = 'db_port' AND sqlc.arg('sort_desc')::bool THEN db_port END DESC,
+    CASE WHEN sqlc.arg('sort_by')::text = 'ui_port' AND NOT sqlc.arg('sort_desc')::bool THEN ui_port END,
+    CASE WHEN sqlc.arg('sort_by')::text = 'ui_port' AND sqlc.arg('sort_desc')::bool THEN ui_port END DESC,
+    CASE WHEN sqlc.arg('sort_by')::text = 'url' AND NOT sqlc.arg('sort_desc')::bool THEN url END,
+    CASE WHEN sqlc.arg('sort_by')::text = 'url' AND sqlc.arg('sort_desc')::bool THEN url END DESC,
+    CASE WHEN sqlc.arg('sort_by')::text = 'ip' AND NOT sqlc.arg('sort_desc')::bool THEN ip END,
+    CASE WHEN sqlc.arg('sort_by')::text = 'ip' AND sqlc.arg('sort_desc')::bool THEN ip END DESC,
+    CASE WHEN sqlc.arg('sort_by')::text = 'status' AND NOT sqlc.arg('sort_desc')::bool THEN status END,
+    CASE WHEN sqlc.arg('sort_by')::text = 'status' AND sqlc.arg('sort_desc')::bool THEN status END DESC,
+    CASE WHEN sqlc.arg('sort_desc')::bool THEN id END DESC,
+    id
+LIMIT sqlc.arg('row_limit') OFFSET sqlc.arg('row_offset');
+
+-- name: CountSatcomData :one
+SELECT count(*)
+FROM common.satcom_data
+WHERE deleted_at IS NULL
+    AND (sqlc.narg('search')::text IS NULL
+        OR company ILIKE '%' || sqlc.narg('search') || '%' ESCAPE '\'
+        OR category ILIKE '%' || sqlc.narg('search') || '%' ESCAPE '\'
+        OR "type" ILIKE '%' || sqlc.narg('search') || '%' ESCAPE '\'
+        OR url ILIKE '%' || sqlc.narg('search') || '%' ESCAPE '\'
+        OR host(ip) ILIKE '%' || sqlc.narg('search') || '%' ESCAPE '\')
+    AND (sqlc.narg('company')::text IS NULL OR company = sqlc.narg('company'))
+    AND (sqlc.narg('category')::text IS NULL OR category = sqlc.narg('category'))
+    AND (sqlc.narg('type')::text IS NULL OR "type" = sqlc.narg('type'))
+    AND (sqlc.narg('status')::bool IS NULL OR status = sqlc.narg('status'))
+    AND (sqlc.narg('ip')::inet IS NULL OR ip <<= sqlc.narg('ip'))
+    AND (sqlc.narg('url')::text IS NULL OR url ILIKE '%' || sqlc.narg('url') || '%' ESCAPE '\')
+    AND (sqlc.narg('label_match')::jsonb IS NULL OR labels @> sqlc.narg('label_match'))
+    AND (sqlc.narg('label_exists')::text[] IS NULL OR labels ?& sqlc.narg('label_exists'))
+    AND (sqlc.narg('label_absent')::text[] IS NULL OR NOT labels ?| sqlc.narg('label_absent'))
+    AND (sqlc.narg('label_not')::jsonb IS NULL OR NOT EXISTS (
+        SELECT 1 FROM jsonb_array_elements(sqlc.narg('label_not')) AS excluded(label) WHERE labels @> excluded.label))
+    AND (sqlc.narg('label_in')::jsonb IS NULL OR NOT EXISTS (
+        SELECT 1 FROM jsonb_array_elements(sqlc.narg('label_in')) AS wanted(choices)
+        WHERE NOT EXISTS (SELECT 1 FROM jsonb_array_elements(wanted.choices) AS choice(label) WHERE labels @> choice.label)));
+
+-- name: UpdateSatcomData :execrows
+UPDATE common.satcom_data
+SET company = sqlc.arg('company'), category = sqlc.arg('category'), "type" = sqlc.arg('type'), recorded_at = sqlc.arg('recorded_at'),
+    db_port = sqlc.arg('db_port'), ui_port = sqlc.arg('ui_port'), url = sqlc.arg('url'), ip = sqlc.arg('ip'), status = sqlc.arg('status'),
+    name = COALESCE(sqlc.narg('name'), name), labels = COALESCE(sqlc.narg('labels')::jsonb, labels),
+    custom_fields = COALESCE(sqlc.narg('custom_fields')::jsonb, custom_fields), version = version + 1
+WHERE id = sqlc.arg('id') AND version = sqlc.arg('expected_version') AND deleted_at IS NULL;
+
+-- name: SoftDeleteSatcomData :execrows
+UPDATE common.satcom_data
+SET deleted_at = now(), deleted_by = $2, version = version + 1
+WHERE id = $1 AND version = $3 AND deleted_at IS NULL;
+
+-- name: ListSatcomConversionIssues :many
+SELECT id, satcom_id, column_name, legacy_value, error, reported_at
+FROM common.satcom_conversion_issues
+ORDER BY satcom_id, id;
+
+-- name: DeleteSatcomConversionIssues :exec
+DELETE FROM common.satcom_conversion_issues
+WHERE satcom_id = $1;
+
+-- name: FindSatcomConflicts :many
+SELECT id, company, url, ip, db_port, ui_port
+FROM common.satcom_data
+WHERE deleted_at IS NULL
+    AND (sqlc.narg('exclude_id')::int IS NULL OR id <> sqlc.narg('exclude_id'))
+    AND ((ip = sqlc.narg('ip')::inet
+            AND (db_port IN (sqlc.narg('db_port')::int, sqlc.narg('ui_port')::int)
+                OR ui_port IN (sqlc.narg('db_port')::int, sqlc.narg('ui_port')::int)))
+        OR lower(rtrim(url, '/')) = lower(rtrim(sqlc.arg('url')::text, '/')))
+ORDER BY company <> sqlc.arg('company')::text, id;
+
+-- name: ListSatcomConflicts :many
+WITH endpoints AS (
+    SELECT id, company, 'port' AS kind, host(ip) || ':' || db_port AS endpoint
+    FROM common.satcom_data
+    WHERE deleted_at IS NULL AND ip IS NOT NULL AND db_port IS NOT NULL
+    UNION
+    SELECT id, company, 'port', host(ip) || ':' || ui_port
+    FROM common.satcom_data
+    WHERE deleted_at IS NULL AND ip IS NOT NULL AND ui_port IS NOT NULL
+    UNION
+    SELECT id, company, 'url', lower(rtrim(url, '/'))
+    FROM common.satcom_data
+    WHERE deleted_at IS NULL AND url <> ''
+)
+SELECT company, kind::text, endpoint::text, array_agg(id ORDER BY id)::int4[] AS satcom_ids
+FROM endpoints
+WHERE (sqlc.narg('company')::text IS NULL OR company = sqlc.narg('company'))
+GROUP BY company, kind, endpoint
+HAVING count(*) > 1
+ORDER BY company, kind, endpoint;
+
+-- name: ListUsedPortsByIp :many
+SELECT db_port::int4 AS port
+FROM common.satcom_data
+WHERE deleted_at IS NULL AND company = $2 AND ip = $1 AND db_port IS NOT NULL
+UNION
+SELECT ui_port::int4
 FROM common.satcom_data
+WHERE deleted_at IS NULL AND company = $2 AND ip = $1 AND ui_port IS NOT NULL
+ORDER BY port;
+
+-- name: ListSatcomDataByUrl :many
+SELECT id, company, category, "type", recorded_at, db_port, ui_port, url, ip, status, status_override, last_probed_at, version, deleted_at, deleted_by, name, labels, custom_fields
+FROM common.satcom_data
+WHERE deleted_at IS NULL AND company = $2 AND lower(rtrim(url, '/')) = lower(rtrim($1::text, '/'))
+ORDER BY id;
+
+-- name: ListSatcomLabelValues :many
+SELECT l.key::text AS key, l.value::text AS value, count(*) AS count
+FROM common.satcom_data s, jsonb_each_text(s.labels) AS l(key, value)
+WHERE s.deleted_at IS NULL AND (sqlc.narg('company')::text IS NULL OR s.company = sqlc.narg('company'))
+GROUP BY l.key, l.value
+ORDER BY l.key, l.value;
+
+-- name: LockSatcomEndpoint :exec
+SELECT pg_advisory_xact_lock(hashtext('satcom_endpoint:' || $1::text));
+
+-- --------------------- SATCOM HISTORY ------------------------------
+-- name: CreateSatcomHistory :exec
+INSERT INTO common.satcom_history(satcom_id, version, operation, changed_by, changed_by_name, actor_id, actor_name, restored_from, snapshot, company_id)
+SELECT s.id, s.version, $2, $3, $4, $5, $6, $7, to_jsonb(s), c.id
+FROM common.satcom_data s
+LEFT JOIN common.companies c ON c.name = s.company
+WHERE s.id = $1;
+
+-- name: ListSatcomHistory :many
+SELECT id, satcom_id, version, operation, changed_at, changed_by, changed_by_name, actor_id, actor_name, restored_from, snapshot, commit_seq, company_id
+FROM common.satcom_history
+WHERE satcom_id = $1
+ORDER BY version DESC;
+
+-- name: GetSatcomHistoryVersion :one
+SELECT id, satcom_id, version, operation, changed_at, changed_by, changed_by_name, actor_id, actor_name, restored_from, snapshot, commit_seq, company_id
+FROM common.satcom_history
+WHERE satcom_id = $1 AND version = $2;
+
+-- name: ListSatcomInventoryAsOf :many
+SELECT id, satcom_id, version, operation, changed_at, changed_by, changed_by_name, actor_id, actor_name, restored_from, snapshot, commit_seq, company_id
+FROM (
+    SELECT DISTINCT ON (satcom_id) id, satcom_id, version, operation, changed_at, changed_by, changed_by_name, actor_id, actor_name, restored_from, snapshot, commit_seq, company_id
+    FROM common.satcom_history
+    WHERE changed_at <= sqlc.arg('changed_at')
+    ORDER BY satcom_id, version DESC
+) latest
+WHERE operation NOT IN ('DELETE', 'PURGE')
+    AND (sqlc.narg('company_id')::int IS NULL OR company_id = sqlc.narg('company_id'))
+ORDER BY satcom_id;
+
+-- name: RestoreSatcomVersion :execrows
+UPDATE common.satcom_data s
+SET company = COALESCE((SELECT c.name FROM common.companies c WHERE c.id = h.company_id), h.snapshot->>'company'),
+    category = h.snapshot->>'category',
+    "type" = h.snapshot->>'type',
+    recorded_at = (h.snapshot->>'recorded_at')::timestamptz,
+    db_port = (h.snapshot->>'db_port')::int4,
+    ui_port = (h.snapshot->>'ui_port')::int4,
+    url = h.snapshot->>'url',
+    ip = (h.snapshot->>'ip')::inet,
+    status = (h.snapshot->>'status')::bool,
+    status_override = (h.snapshot->>'status_override')::bool,
+    labels = COALESCE(h.snapshot->'labels', '{}'),
+    custom_fields = COALESCE(h.snapshot->'custom_fields', '{}'),
+    deleted_at = NULL,
+    deleted_by = NULL,
+    version = s.version + 1
+FROM common.satcom_history h
+WHERE s.id = $1 AND h.satcom_id = s.id AND h.version = $2;
+
+-- name: UndeleteSatcomData :execrows
+UPDATE common.satcom_data
+SET deleted_at = NULL, deleted_by = NULL, version = version + 1
+WHERE id = $1 AND deleted_at IS NOT NULL;
+
+-- name: GetDeletedSatcomDataById :one
+SELECT id, company, category, "type", recorded_at, db_port, ui_port, url, ip, status, status_override, last_probed_at, version, deleted_at, deleted_by, name, labels, custom_fields
+FROM common.satcom_data
+WHERE id = $1 AND deleted_at IS NOT NULL;
+
+-- name: ListDeletedSatcomData :many
+SELECT id, company, category, "type", recorded_at, db_port, ui_port, url, ip, status, status_override, last_probed_at, version, deleted_at, deleted_by, name, labels, custom_fields
+FROM common.satcom_data
+WHERE deleted_at IS NOT NULL AND (sqlc.narg('company')::text IS NULL OR company = sqlc.narg('company'))
+ORDER BY deleted_at DESC, id;
+
+-- name: PurgeSatcomData :many
+WITH purged AS (
+    DELETE FROM common.satcom_data
+    WHERE deleted_at IS NOT NULL
+        AND deleted_at < sqlc.arg('deleted_before')
+        AND (sqlc.narg('id')::int IS NULL OR id = sqlc.narg('id'))
+    RETURNING id, company, category, "type", recorded_at, db_port, ui_port, url, ip, status, status_override, last_probed_at, version, deleted_at, deleted_by, name, labels, custom_fields
+), issues AS (
+    DELETE FROM common.satcom_conversion_issues
+    WHERE satcom_id IN (SELECT id FROM purged)
+), history AS (
+    INSERT INTO common.satcom_history(satcom_id, version, operation, snapshot, company_id)
+    SELECT p.id, p.version + 1, 'PURGE', to_jsonb(p), c.id
+    FROM purged p
+    LEFT JOIN common.companies c ON c.name = p.company
+)
+SELECT id
+FROM purged
 ORDER BY id;
 
--- name: UpdateSatcomData :exec
+-- name: GetSatcomHistoryById :one
+SELECT id, satcom_id, version, operation, changed_at, changed_by, changed_by_name, actor_id, actor_name, restored_from, snapshot, commit_seq, company_id
+FROM common.satcom_history
+WHERE id = $1;
+
+-- name: ListSatcomHistoryAfter :many
+SELECT id, satcom_id, version, operation, changed_at, changed_by, changed_by_name, actor_id, actor_name, restored_from, snapshot, commit_seq, company_id
+FROM common.satcom_history
+WHERE commit_seq > sqlc.arg('after_seq')
+ORDER BY commit_seq
+LIMIT sqlc.arg('row_limit');
+
+-- name: GetLatestSatcomCommitSeq :one
+SELECT COALESCE(max(commit_seq), 0)::bigint AS latest_seq
+FROM common.satcom_history;
+
+-- --------------------- SATCOM PROBES ------------------------------
+-- name: CreateProbeResult :exec
+INSERT INTO common.satcom_probe_results(satcom_id, probed_at, check_type, target, success, latency_ms, status_code, error)
+VALUES($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: UpdateSatcomProbeStatus :execrows
+UPDATE common.satcom_data
+SET status = sqlc.arg('status')::bool, version = version + 1
+WHERE id = sqlc.arg('id') AND NOT status_override AND status <> sqlc.arg('status')::bool AND deleted_at IS NULL;
+
+-- name: SetSatcomStatusOverride :execrows
+UPDATE common.satcom_data
+SET status = COALESCE(sqlc.narg('status')::bool, status), status_override = sqlc.arg('status_override'), version = version + 1
+WHERE id = sqlc.arg('id') AND deleted_at IS NULL;
+
+-- name: GetSatcomUptime :many
+SELECT check_type,
+    count(*)::bigint AS total,
+    count(*) FILTER (WHERE success)::bigint AS up,
+    COALESCE(avg(latency_ms) FILTER (WHERE success), 0)::float8 AS avg_latency_ms,
+    max(probed_at)::timestamptz AS last_probed_at
+FROM common.satcom_probe_results
+WHERE satcom_id = sqlc.arg('satcom_id') AND probed_at >= sqlc.arg('since')
+GROUP BY check_type
+ORDER BY check_type;
+
+-- name: ListProbeResults :many
+SELECT id, satcom_id, probed_at, check_type, target, success, latency_ms, status_code, error
+FROM common.satcom_probe_results
+WHERE satcom_id = sqlc.arg('satcom_id') AND probed_at >= sqlc.arg('since')
+    AND (sqlc.narg('check_type')::text IS NULL OR check_type = sqlc.narg('check_type'))
+ORDER BY probed_at DESC, id DESC
+LIMIT sqlc.arg('row_limit');
+
+-- name: DeleteProbeResultsBefore :execrows
+DELETE FROM common.satcom_probe_results
+WHERE probed_at < $1;
+
+-- name: SetSatcomProbedAt :exec
 UPDATE common.satcom_data
-SET company = $1, category = $2, "type" = $3, "date" = $4, "time" = $5, 
-    db_port = $6, ui_port = $7, url = $8, ip = $9, status = $10
-WHERE id = $11;
+SET last_probed_at = $2
+WHERE id = $1;
+
+-- name: TryLockSatcomProber :one
+SELECT pg_try_advisory_lock(hashtext('satcom_prober'));
+
+-- name: UnlockSatcomProber :one
+SELECT pg_advisory_unlock(hashtext('satcom_prober'));
+
+-- --------------------- SATCOM CERTIFICATES ------------------------------
+-- name: UpsertSatcomCertificate :exec
+INSERT INTO common.satcom_certificates(satcom_id, host, checked_at, subject, issuer, sans, serial_number, not_before, not_after, hostname_match, chain_valid, error)
+VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULL)
+ON CONFLICT (satcom_id) DO UPDATE
+SET host = EXCLUDED.host, checked_at = EXCLUDED.checked_at, subject = EXCLUDED.subject, issuer = EXCLUDED.issuer,
+    sans = EXCLUDED.sans, serial_number = EXCLUDED.serial_number, not_before = EXCLUDED.not_before,
+    not_after = EXCLUDED.not_after, hostname_match = EXCLUDED.hostname_match, chain_valid = EXCLUDED.chain_valid, error = NULL,
+    last_warning_days = CASE WHEN common.satcom_certificates.serial_number IS DISTINCT FROM EXCLUDED.serial_number
+        THEN NULL ELSE common.satcom_certificates.last_warning_days END;
+
+-- name: RecordSatcomCertificateError :exec
+INSERT INTO common.satcom_certificates(satcom_id, host, checked_at, error)
+VALUES($1, $2, $3, $4)
+ON CONFLICT (satcom_id) DO UPDATE
+SET host = EXCLUDED.host, checked_at = EXCLUDED.checked_at, error = EXCLUDED.error;
+
+-- name: GetSatcomCertificate :one
+SELECT satcom_id, host, checked_at, subject, issuer, sans, serial_number, not_before, not_after, hostname_match, chain_valid, error, last_warning_days
+FROM common.satcom_certificates
+WHERE satcom_id = $1;
+
+-- name: ListExpiringCertificates :many
+SELECT c.satcom_id, c.host, c.checked_at, c.subject, c.issuer, c.sans, c.serial_number, c.not_before, c.not_after,
+    c.hostname_match, c.chain_valid, c.error, c.last_warning_days, s.company, s.url
+FROM common.satcom_certificates c
+JOIN common.satcom_data s ON s.id = c.satcom_id
+WHERE c.not_after < sqlc.arg('not_after') AND s.deleted_at IS NULL AND (sqlc.narg('company')::text IS NULL OR s.company = sqlc.narg('company'))
+ORDER BY c.not_after, c.satcom_id;
+
+-- name: SetCertificateWarning :exec
+UPDATE common.satcom_certificates
+SET last_warning_days = $2
+WHERE satcom_id = $1;
+
+-- --------------------- SATCOM RELATIONS ------------------------------
+-- name: LockSatcomRelations :exec
+-- Serializes relation changes so that two concurrent inserts cannot close a cycle
+LOCK TABLE common.satcom_relations IN SHARE ROW EXCLUSIVE MODE;
+
+-- name: CreateSatcomRelation :one
+INSERT INTO common.satcom_relations(source_id, target_id, relation, created_by)
+VALUES($1, $2, $3, $4)
+RETURNING id, source_id, target_id, relation, created_at, created_by;
+
+-- name: GetSatcomRelation :one
+SELECT id, source_id, target_id, relation, created_at, created_by
+FROM common.satcom_relations
+WHERE id = $1;
+
+-- name: DeleteSatcomRelation :execrows
+DELETE FROM common.satcom_relations
+WHERE id = $1;
+
+-- name: ListSatcomRelations :many
+SELECT r.id, r.source_id, r.target_id, r.relation, r.created_at, r.created_by
+FROM common.satcom_relations r
+JOIN common.satcom_data d ON d.id = r.source_id
+WHERE sqlc.narg('company')::text IS NULL OR d.company = sqlc.narg('company')
+ORDER BY r.id;
+
+-- --------------------- SATCOM MAINTENANCE ------------------------------
+-- name: CreateSatcomMaintenanceWindow :one
+INSERT INTO common.satcom_maintenance_windows(company, satcom_id, label_selector, starts_at, ends_at, recurrence, recur_until, timezone, reason, created_by)
+VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING id, company, satcom_id, label_selector, starts_at, ends_at, recurrence, recur_until, timezone, reason, created_at, created_by;
+
+-- name: UpdateSatcomMaintenanceWindow :one
+UPDATE common.satcom_maintenance_windows
+SET company = $2, satcom_id = $3, label_selector = $4, starts_at = $5, ends_at = $6, recurrence = $7, recur_until = $8, timezone = $9, reason = $10
+WHERE id = $1
+RETURNING id, company, satcom_id, label_selector, starts_at, ends_at, recurrence, recur_until, timezone, reason, created_at, created_by;
+
+-- name: GetSatcomMaintenanceWindow :one
+SELECT id, company, satcom_id, label_selector, starts_at, ends_at, recurrence, recur_until, timezone, reason, created_at, created_by
+FROM common.satcom_maintenance_windows
+WHERE id = $1;
+
+-- name: DeleteSatcomMaintenanceWindow :execrows
+DELETE FROM common.satcom_maintenance_windows
+WHERE id = $1;
+
+-- name: ListSatcomMaintenanceWindows :many
+SELECT id, company, satcom_id, label_selector, starts_at, ends_at, recurrence, recur_until, timezone, reason, created_at, created_by
+FROM common.satcom_maintenance_windows
+WHERE (sqlc.narg('company')::text IS NULL OR company = sqlc.narg('company'))
+ORDER BY starts_at, id;
+
+-- --------------------- SATCOM CHANGE REQUESTS ------------------------------
+-- name: CreateSatcomChangeRequest :one
+INSERT INTO common.satcom_change_requests(satcom_id, company, operation, base, proposed, allow_conflicts, "comment", requested_by, requested_by_name, requested_actor_id)
+VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING id, satcom_id, company, operation, status, base, proposed, allow_conflicts, "comment", requested_at, requested_by, requested_by_name, reviewed_at, reviewed_by, reviewed_by_name, review_comment, requested_actor_id;
+
+-- name: GetSatcomChangeRequest :one
+SELECT id, satcom_id, company, operation, status, base, proposed, allow_conflicts, "comment", requested_at, requested_by, requested_by_name, reviewed_at, reviewed_by, reviewed_by_name, review_comment, requested_actor_id
+FROM common.satcom_change_requests
+WHERE id = $1;
+
+-- name: GetPendingSatcomChangeRequest :one
+SELECT id, satcom_id, company, operation, status, base, proposed, allow_conflicts, "comment", requested_at, requested_by, requested_by_name, reviewed_at, reviewed_by, reviewed_by_name, review_comment, requested_actor_id
+FROM common.satcom_change_requests
+WHERE satcom_id = $1 AND status = 'PENDING_APPROVAL';
+
+-- name: ListSatcomChangeRequests :many
+SELECT id, satcom_id, company, operation, status, base, proposed, allow_conflicts, "comment", requested_at, requested_by, requested_by_name, reviewed_at, reviewed_by, reviewed_by_name, review_comment, requested_actor_id
+FROM common.satcom_change_requests
+WHERE (sqlc.narg('company')::text IS NULL OR company = sqlc.narg('company'))
+  AND (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status'))
+  AND (sqlc.narg('satcom_id')::int4 IS NULL OR satcom_id = sqlc.narg('satcom_id'))
+ORDER BY requested_at DESC, id DESC;
+
+-- name: ReviewSatcomChangeRequest :execrows
+UPDATE common.satcom_change_requests
+SET status = $2, reviewed_at = now(), reviewed_by = $3, reviewed_by_name = $4, review_comment = $5
+WHERE id = $1 AND status = 'PENDING_APPROVAL';
+
+-- --------------------- SATCOM SECRETS ------------------------------
+-- name: CreateSatcomSecret :one
+INSERT INTO common.satcom_secrets(satcom_id, name, username, description, rotation_days, created_by)
+VALUES($1, $2, $3, $4, $5, $6)
+RETURNING id, satcom_id, name, username, description, version, rotation_days, rotated_at, reminded_at, created_at, created_by;
+
+-- name: RotateSatcomSecret :one
+UPDATE common.satcom_secrets
+SET version = version + 1, username = $3, description = $4, rotation_days = $5, rotated_at = now(), reminded_at = NULL
+WHERE id = $1 AND satcom_id = $2
+RETURNING id, satcom_id, name, username, description, version, rotation_days, rotated_at, reminded_at, created_at, created_by;
+
+-- name: GetSatcomSecret :one
+SELECT id, satcom_id, name, username, description, version, rotation_days, rotated_at, reminded_at, created_at, created_by
+FROM common.satcom_secrets
+WHERE id = $1 AND satcom_id = $2;
+
+-- name: ListSatcomSecrets :many
+SELECT id, satcom_id, name, username, description, version, rotation_days, rotated_at, reminded_at, created_at, created_by
+FROM common.satcom_secrets
+WHERE satcom_id = $1
+ORDER BY name;
+
+-- name: DeleteSatcomSecret :execrows
+DELETE FROM common.satcom_secrets
+WHERE id = $1 AND satcom_id = $2;
+
+-- name: CreateSatcomSecretVersion :exec
+INSERT INTO common.satcom_secret_versions(secret_id, version, ciphertext, nonce, data_key, key_nonce, key_id, created_by)
+VALUES($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: GetSatcomSecretVersion :one
+SELECT secret_id, version, ciphertext, nonce, data_key, key_nonce, key_id, created_at, created_by
+FROM common.satcom_secret_versions
+WHERE secret_id = $1 AND version = $2;
+
+-- name: ListSatcomSecretVersions :many
+SELECT secret_id, version, ciphertext, nonce, data_key, key_nonce, key_id, created_at, created_by
+FROM common.satcom_secret_versions
+WHERE secret_id = $1
+ORDER BY version DESC;
+
+-- name: ListSatcomSecretsForRotation :many
+SELECT s.id, s.satcom_id, s.name, s.username, s.rotation_days, s.rotated_at, s.reminded_at, d.company
+FROM common.satcom_secrets s
+JOIN common.satcom_data d ON d.id = s.satcom_id
+WHERE d.deleted_at IS NULL AND (sqlc.narg('company')::text IS NULL OR d.company = sqlc.narg('company'))
+ORDER BY s.rotated_at, s.id;
+
+-- name: MarkSatcomSecretReminded :exec
+UPDATE common.satcom_secrets
+SET reminded_at = now()
+WHERE id = $1;
+
+-- name: ListSatcomSecretVersionsByKey :many
+SELECT secret_id, version, ciphertext, nonce, data_key, key_nonce, key_id, created_at, created_by
+FROM common.satcom_secret_versions
+WHERE key_id = $1
+ORDER BY secret_id, version
+FOR UPDATE;
+
+-- name: UpdateSatcomSecretVersionKey :execrows
+UPDATE common.satcom_secret_versions
+SET data_key = sqlc.arg('data_key'), key_nonce = sqlc.arg('key_nonce'), key_id = sqlc.arg('key_id')
+WHERE secret_id = sqlc.arg('secret_id') AND version = sqlc.arg('version') AND key_id = sqlc.arg('old_key_id')::text;
+
+-- --------------------- SATCOM ATTACHMENTS ------------------------------
+-- name: CreateSatcomAttachment :one
+INSERT INTO common.satcom_attachments(satcom_id, object_key, file_name, size_bytes, content_type, description, uploaded_by, uploaded_by_name)
+VALUES($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, satcom_id, object_key, file_name, size_bytes, content_type, description, uploaded_at, uploaded_by, uploaded_by_name;
+
+-- name: GetSatcomAttachment :one
+SELECT id, satcom_id, object_key, file_name, size_bytes, content_type, description, uploaded_at, uploaded_by, uploaded_by_name
+FROM common.satcom_attachments
+WHERE id = $1 AND satcom_id = $2;
+
+-- name: ListSatcomAttachments :many
+SELECT id, satcom_id, object_key, file_name, size_bytes, content_type, description, uploaded_at, uploaded_by, uploaded_by_name
+FROM common.satcom_attachments
+WHERE satcom_id = $1
+ORDER BY uploaded_at DESC, id DESC;
+
+-- name: DeleteSatcomAttachment :execrows
+DELETE FROM common.satcom_attachments
+WHERE id = $1 AND satcom_id = $2;
+
+-- name: RecordSatcomAttachmentCleanup :exec
+INSERT INTO common.satcom_attachment_cleanups(satcom_id, last_error)
+VALUES($1, $2)
+ON CONFLICT (satcom_id) DO UPDATE
+SET attempts = common.satcom_attachment_cleanups.attempts + 1, last_error = EXCLUDED.last_error, failed_at = now();
+
+-- name: ListSatcomAttachmentCleanups :many
+SELECT satcom_id
+FROM common.satcom_attachment_cleanups
+ORDER BY failed_at, satcom_id;
+
+-- name: DeleteSatcomAttachmentCleanup :exec
+DELETE FROM common.satcom_attachment_cleanups
+WHERE satcom_id = $1;
+
+-- --------------------- SATCOM NOTES ------------------------------
+-- name: CreateSatcomNote :one
+INSERT INTO common.satcom_notes(satcom_id, parent_id, body, mentions, created_by, created_by_name)
+VALUES($1, $2, $3, $4, $5, $6)
+RETURNING id, satcom_id, parent_id, body, mentions, pinned, resolved_at, resolved_by, resolved_by_name, created_at, created_by, created_by_name, updated_at;
+
+-- name: GetSatcomNote :one
+SELECT id, satcom_id, parent_id, body, mentions, pinned, resolved_at, resolved_by, resolved_by_name, created_at, created_by, created_by_name, updated_at
+FROM common.satcom_notes
+WHERE id = $1 AND satcom_id = $2;
+
+-- name: ListSatcomNotes :many
+SELECT id, satcom_id, parent_id, body, mentions, pinned, resolved_at, resolved_by, resolved_by_name, created_at, created_by, created_by_name, updated_at
+FROM common.satcom_notes
+WHERE satcom_id = $1
+ORDER BY created_at, id;
+
+-- name: UpdateSatcomNoteBody :one
+UPDATE common.satcom_notes
+SET body = $3, mentions = $4, updated_at = now()
+WHERE id = $1 AND satcom_id = $2
+RETURNING id, satcom_id, parent_id, body, mentions, pinned, resolved_at, resolved_by, resolved_by_name, created_at, created_by, created_by_name, updated_at;
+
+-- name: SetSatcomNotePinned :one
+UPDATE common.satcom_notes
+SET pinned = $3
+WHERE id = $1 AND satcom_id = $2
+RETURNING id, satcom_id, parent_id, body, mentions, pinned, resolved_at, resolved_by, resolved_by_name, created_at, created_by, created_by_name, updated_at;
+
+-- name: SetSatcomNoteResolved :one
+UPDATE common.satcom_notes
+SET resolved_at = CASE WHEN sqlc.arg('resolved')::bool THEN now() END,
+    resolved_by = CASE WHEN sqlc.arg('resolved')::bool THEN sqlc.narg('resolved_by')::int4 END,
+    resolved_by_name = CASE WHEN sqlc.arg('resolved')::bool THEN sqlc.narg('resolved_by_name')::text END
+WHERE id = sqlc.arg('id') AND satcom_id = sqlc.arg('satcom_id')
+RETURNING id, satcom_id, parent_id, body, mentions, pinned, resolved_at, resolved_by, resolved_by_name, created_at, created_by, created_by_name, updated_at;
+
+-- name: DeleteSatcomNote :execrows
+DELETE FROM common.satcom_notes
+WHERE id = $1 AND satcom_id = $2;
+
+-- name: ListSatcomProbeResultsBetween :many
+SELECT id, satcom_id, probed_at, check_type, target, success, latency_ms, status_code, error
+FROM common.satcom_probe_results
+WHERE satcom_id = sqlc.arg('satcom_id') AND probed_at >= sqlc.arg('from_time') AND probed_at < sqlc.arg('to_time')
+ORDER BY probed_at DESC, id DESC
+LIMIT sqlc.arg('row_limit');
+
+-- --------------------- SATCOM STATISTICS ------------------------------
+-- name: CountSatcomDataByDimension :many
+SELECT CASE
+        WHEN GROUPING(company) = 0 THEN 'company'
+        WHEN GROUPING(category) = 0 THEN 'category'
+        WHEN GROUPING("type") = 0 THEN 'type'
+        ELSE 'status'
+    END::text AS dimension,
+    COALESCE(company, category, "type", CASE WHEN status THEN 'up' ELSE 'down' END)::text AS value,
+    count(*) AS total
+FROM common.satcom_data
+WHERE deleted_at IS NULL AND (sqlc.narg('company')::text IS NULL OR company = sqlc.narg('company'))
+GROUP BY GROUPING SETS ((company), (category), ("type"), (status))
+ORDER BY dimension, total DESC, value;
+
+-- name: CountSatcomActivityByWeek :many
+SELECT (date_trunc('week', h.changed_at AT TIME ZONE 'UTC') AT TIME ZONE 'UTC')::timestamptz AS week,
+    count(*) FILTER (WHERE h.operation = 'CREATE') AS added,
+    count(DISTINCT h.satcom_id) FILTER (WHERE h.operation NOT IN ('CREATE', 'STATUS')) AS changed,
+    count(*) FILTER (WHERE h.operation = 'STATUS') AS status_changes
+FROM common.satcom_history h
+JOIN common.satcom_data d ON d.id = h.satcom_id
+WHERE h.changed_at >= sqlc.arg('since') AND (sqlc.narg('company')::text IS NULL OR d.company = sqlc.narg('company'))
+GROUP BY 1
+ORDER BY 1;
+
+-- name: ListDownSatcomData :many
+SELECT id, company, category, "type", recorded_at, db_port, ui_port, url, ip, status, status_override, last_probed_at, version, deleted_at, deleted_by, name, labels, custom_fields
+FROM common.satcom_data
+WHERE deleted_at IS NULL AND NOT status AND (sqlc.narg('company')::text IS NULL OR company = sqlc.narg('company'))
+ORDER BY company, id;
+
+-- name: GetSatcomProbeLatencyStats :many
+SELECT p.check_type,
+    count(*) AS probes,
+    count(*) FILTER (WHERE p.success) AS successes,
+    count(p.latency_ms) FILTER (WHERE p.success) AS measured,
+    COALESCE(avg(p.latency_ms) FILTER (WHERE p.success), 0)::float8 AS avg_latency_ms
+FROM common.satcom_probe_results p
+JOIN common.satcom_data d ON d.id = p.satcom_id
+WHERE p.probed_at >= sqlc.arg('since') AND d.deleted_at IS NULL
+    AND (sqlc.narg('company')::text IS NULL OR d.company = sqlc.narg('company'))
+GROUP BY p.check_type
+ORDER BY p.check_type;
+
+-- --------------------- AUDIT LOG ------------------------------
+-- name: CreateAuditLog :exec
+INSERT INTO common.audit_log(user_id, user_name, actor_id, actor_name, impersonated, "action", "method", "path", status_code, detail)
+VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);
+
+-- name: ListAuditLogs :many
+SELECT id, occurred_at, user_id, user_name, actor_id, actor_name, impersonated, "action", "method", "path", status_code, detail
+FROM common.audit_log
+WHERE (sqlc.narg('user_id')::int IS NULL OR user_id = sqlc.narg('user_id'))
+    AND (sqlc.narg('actor_id')::int IS NULL OR actor_id = sqlc.narg('actor_id'))
+    AND (sqlc.narg('impersonated')::bool IS NULL OR impersonated = sqlc.narg('impersonated'))
+    AND (sqlc.narg('action')::text IS NULL OR "action" = sqlc.narg('action'))
+ORDER BY id DESC
+LIMIT sqlc.arg('row_limit') OFFSET sqlc.arg('row_offset');
 
--- name: DeleteSatcomData :exec
-DELETE FROM common.satcom_data
-WHERE id = $1;
\ No newline at end of file
+-- name: CountAuditLogs :one
+SELECT count(*)
+FROM common.audit_log
+WHERE (sqlc.narg('user_id')::int IS NULL OR user_id = sqlc.narg('user_id'))
+    AND (sqlc.narg('actor_id')::int IS NULL OR actor_id = sqlc.narg('actor_id'))
+    AND (sqlc.narg('impersonated')::bool IS NULL OR impersonated = sqlc.narg('impersonated'))
+    AND (sqlc.narg('action')::text IS NULL OR "action" = sqlc.narg('action'));
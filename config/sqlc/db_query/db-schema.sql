@@ -1,6 +1,8 @@
 -- SCHEMA: hrm
 CREATE SCHEMA common;
 
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
 CREATE TABLE common.users (
 	user_id serial4 NOT NULL,
 	user_name text NOT NULL,
@@ -11,19 +13,379 @@ CREATE TABLE common.users (
 	otp text NULL,
 	otp_valid bool DEFAULT false NOT NULL,
 	otp_exp timestamp NULL,
-	"role" text NOT NULL
+	"role" text NOT NULL,
+	status text DEFAULT 'ACTIVE' NOT NULL,
+	created_at timestamptz DEFAULT now() NOT NULL
 );
 
+CREATE INDEX users_role_idx ON common.users ("role");
+CREATE INDEX users_status_idx ON common.users (status);
+CREATE INDEX users_user_id_idx ON common.users (user_id);
+CREATE INDEX users_user_name_idx ON common.users (user_name, user_id);
+CREATE INDEX users_email_idx ON common.users (email, user_id);
+CREATE INDEX users_created_at_idx ON common.users (created_at, user_id);
+CREATE INDEX users_user_name_trgm_idx ON common.users USING gin (user_name gin_trgm_ops);
+CREATE INDEX users_email_trgm_idx ON common.users USING gin (email gin_trgm_ops);
+CREATE INDEX users_phone_trgm_idx ON common.users USING gin (phone gin_trgm_ops);
+
+-- Federated identities linked to local users
+CREATE TABLE common.user_identities (
+	provider text NOT NULL,
+	subject text NOT NULL,
+	user_id int4 NOT NULL,
+	created_at timestamptz DEFAULT now() NOT NULL,
+	CONSTRAINT user_identities_pkey PRIMARY KEY (provider, subject)
+);
+
+CREATE INDEX user_identities_user_idx ON common.user_identities (user_id);
+
+-- One-time codes redeemed by the frontend for a token after a federated login
+CREATE TABLE common.login_codes (
+	code_hash text NOT NULL,
+	user_id int4 NOT NULL,
+	expires_at timestamptz NOT NULL,
+	CONSTRAINT login_codes_pkey PRIMARY KEY (code_hash)
+);
+
+-- Tenants; satcom entries and user memberships belong to a company
+CREATE TABLE common.companies (
+	id serial4 NOT NULL,
+	name text NOT NULL,
+	created_at timestamptz DEFAULT now() NOT NULL,
+	CONSTRAINT companies_pkey PRIMARY KEY (id),
+	CONSTRAINT companies_name_key UNIQUE (name)
+);
+
+-- Per-company role of a user: VIEWER reads, EDITOR also writes, ADMIN also manages members
+CREATE TABLE common.company_memberships (
+	user_id int4 NOT NULL,
+	company_id int4 NOT NULL,
+	"role" text NOT NULL,
+	created_at timestamptz DEFAULT now() NOT NULL,
+	CONSTRAINT company_memberships_pkey PRIMARY KEY (user_id, company_id),
+	CONSTRAINT company_memberships_company_fk FOREIGN KEY (company_id) REFERENCES common.companies (id) ON DELETE CASCADE,
+	CONSTRAINT company_memberships_role_check CHECK ("role" IN ('VIEWER', 'EDITOR', 'APPROVER', 'ADMIN'))
+);
+
+CREATE INDEX company_memberships_company_idx ON common.company_memberships (company_id);
+
 CREATE TABLE common.satcom_data (
 	id serial4 NOT NULL,
 	company text NOT NULL,
 	category text NOT NULL,
 	"type" text NOT NULL,
-	"date" text NOT NULL,
-	"time" text NOT NULL,
-	db_port text NOT NULL,
-	ui_port text NOT NULL,
+	recorded_at timestamptz NULL,
+	db_port int4 NULL,
+	ui_port int4 NULL,
 	url text NOT NULL,
-	ip text NOT NULL,
-	status bool NOT NULL
-);
\ No newline at end of file
+	ip inet NULL,
+	status bool NOT NULL,
+	status_override bool DEFAULT false NOT NULL,
+	last_probed_at timestamptz NULL,
+	version int4 DEFAULT 1 NOT NULL,
+	deleted_at timestamptz NULL,
+	deleted_by int4 NULL,
+	name text NULL,
+	labels jsonb DEFAULT '{}' NOT NULL,
+	custom_fields jsonb DEFAULT '{}' NOT NULL,
+	CONSTRAINT satcom_data_pkey PRIMARY KEY (id),
+	CONSTRAINT satcom_data_company_fk FOREIGN KEY (company) REFERENCES common.companies (name) ON UPDATE CASCADE,
+	CONSTRAINT satcom_data_db_port_check CHECK (db_port BETWEEN 1 AND 65535),
+	CONSTRAINT satcom_data_ui_port_check CHECK (ui_port BETWEEN 1 AND 65535)
+);
+
+CREATE INDEX satcom_data_company_idx ON common.satcom_data (company);
+CREATE INDEX satcom_data_category_idx ON common.satcom_data (category);
+CREATE INDEX satcom_data_type_idx ON common.satcom_data ("type");
+CREATE INDEX satcom_data_status_idx ON common.satcom_data (status);
+CREATE INDEX satcom_data_ip_idx ON common.satcom_data USING gist (ip inet_ops);
+CREATE INDEX satcom_data_recorded_at_idx ON common.satcom_data (recorded_at);
+CREATE INDEX satcom_data_url_trgm_idx ON common.satcom_data USING gin (url gin_trgm_ops);
+CREATE INDEX satcom_data_url_sort_idx ON common.satcom_data (url, id);
+CREATE INDEX satcom_data_company_trgm_idx ON common.satcom_data USING gin (company gin_trgm_ops);
+CREATE INDEX satcom_data_category_trgm_idx ON common.satcom_data USING gin (category gin_trgm_ops);
+CREATE INDEX satcom_data_type_trgm_idx ON common.satcom_data USING gin ("type" gin_trgm_ops);
+CREATE INDEX satcom_data_host_trgm_idx ON common.satcom_data USING gin (host(ip) gin_trgm_ops);
+CREATE INDEX satcom_data_ip_db_port_idx ON common.satcom_data (ip, db_port);
+CREATE INDEX satcom_data_ip_ui_port_idx ON common.satcom_data (ip, ui_port);
+CREATE INDEX satcom_data_url_norm_idx ON common.satcom_data (lower(rtrim(url, '/')));
+CREATE INDEX satcom_data_deleted_at_idx ON common.satcom_data (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE UNIQUE INDEX satcom_data_company_name_idx ON common.satcom_data (company, name) WHERE deleted_at IS NULL;
+CREATE INDEX satcom_data_labels_idx ON common.satcom_data USING gin (labels);
+
+-- Full row snapshot after every change; soft-deleted and purged entries keep their history
+CREATE TABLE common.satcom_history (
+	id bigserial NOT NULL,
+	satcom_id int4 NOT NULL,
+	version int4 NOT NULL,
+	operation text NOT NULL,
+	changed_at timestamptz DEFAULT now() NOT NULL,
+	changed_by int4 NULL,
+	changed_by_name text NULL,
+	actor_id int4 NULL,
+	actor_name text NULL,
+	restored_from int4 NULL,
+	snapshot jsonb NOT NULL,
+	commit_seq int8 NULL,
+	company_id int4 NULL,
+	CONSTRAINT satcom_history_pkey PRIMARY KEY (id),
+	CONSTRAINT satcom_history_version_key UNIQUE (satcom_id, version),
+	CONSTRAINT satcom_history_company_fk FOREIGN KEY (company_id) REFERENCES common.companies (id) ON DELETE SET NULL
+);
+
+CREATE INDEX satcom_history_changed_at_idx ON common.satcom_history (changed_at);
+CREATE UNIQUE INDEX satcom_history_commit_seq_idx ON common.satcom_history (commit_seq);
+CREATE INDEX satcom_history_company_idx ON common.satcom_history (company_id);
+
+-- Announces every history row to the change feeds (LISTEN satcom_changes)
+CREATE FUNCTION common.notify_satcom_change() RETURNS trigger AS $$
+BEGIN
+	PERFORM pg_notify('satcom_changes', NEW.id::text);
+	RETURN NEW;
+END $$ LANGUAGE plpgsql;
+
+CREATE TRIGGER satcom_history_notify AFTER INSERT ON common.satcom_history
+	FOR EACH ROW EXECUTE FUNCTION common.notify_satcom_change();
+
+-- Numbers history rows in commit order for the change feed resume tokens. The deferred
+-- trigger runs at commit and holds the lock until the commit completes.
+CREATE SEQUENCE common.satcom_history_commit_seq;
+
+CREATE FUNCTION common.assign_satcom_commit_seq() RETURNS trigger AS $$
+BEGIN
+	PERFORM pg_advisory_xact_lock(hashtext('common.satcom_history_commit_seq'));
+	UPDATE common.satcom_history
+	SET commit_seq = nextval('common.satcom_history_commit_seq')
+	WHERE id = NEW.id;
+	RETURN NULL;
+END $$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER satcom_history_commit_seq AFTER INSERT ON common.satcom_history
+	DEFERRABLE INITIALLY DEFERRED
+	FOR EACH ROW EXECUTE FUNCTION common.assign_satcom_commit_seq();
+
+-- Health probe results; one row per check per probing round
+CREATE TABLE common.satcom_probe_results (
+	id bigserial NOT NULL,
+	satcom_id int4 NOT NULL,
+	probed_at timestamptz NOT NULL,
+	check_type text NOT NULL,
+	target text NOT NULL,
+	success bool NOT NULL,
+	latency_ms int4 NULL,
+	status_code int4 NULL,
+	error text NULL,
+	CONSTRAINT satcom_probe_results_pkey PRIMARY KEY (id),
+	CONSTRAINT satcom_probe_results_satcom_fk FOREIGN KEY (satcom_id) REFERENCES common.satcom_data (id) ON DELETE CASCADE
+);
+
+CREATE INDEX satcom_probe_results_satcom_idx ON common.satcom_probe_results (satcom_id, probed_at DESC);
+CREATE INDEX satcom_probe_results_probed_at_idx ON common.satcom_probe_results (probed_at);
+
+-- Last known TLS certificate of each HTTPS url; last_warning_days is the smallest
+-- warning threshold already emailed for the current certificate
+CREATE TABLE common.satcom_certificates (
+	satcom_id int4 NOT NULL,
+	host text NOT NULL,
+	checked_at timestamptz NOT NULL,
+	subject text NULL,
+	issuer text NULL,
+	sans text[] NULL,
+	serial_number text NULL,
+	not_before timestamptz NULL,
+	not_after timestamptz NULL,
+	hostname_match bool NULL,
+	chain_valid bool NULL,
+	error text NULL,
+	last_warning_days int4 NULL,
+	CONSTRAINT satcom_certificates_pkey PRIMARY KEY (satcom_id),
+	CONSTRAINT satcom_certificates_satcom_fk FOREIGN KEY (satcom_id) REFERENCES common.satcom_data (id) ON DELETE CASCADE
+);
+
+CREATE INDEX satcom_certificates_not_after_idx ON common.satcom_certificates (not_after);
+
+-- Typed dependencies between satcom entries: source_id relies on target_id
+CREATE TABLE common.satcom_relations (
+	id serial4 NOT NULL,
+	source_id int4 NOT NULL,
+	target_id int4 NOT NULL,
+	relation text NOT NULL,
+	created_at timestamptz DEFAULT now() NOT NULL,
+	created_by int4 NULL,
+	CONSTRAINT satcom_relations_pkey PRIMARY KEY (id),
+	CONSTRAINT satcom_relations_key UNIQUE (source_id, target_id, relation),
+	CONSTRAINT satcom_relations_source_fk FOREIGN KEY (source_id) REFERENCES common.satcom_data (id) ON DELETE CASCADE,
+	CONSTRAINT satcom_relations_target_fk FOREIGN KEY (target_id) REFERENCES common.satcom_data (id) ON DELETE CASCADE,
+	CONSTRAINT satcom_relations_self_check CHECK (source_id <> target_id),
+	CONSTRAINT satcom_relations_relation_check CHECK (relation IN ('DEPENDS_ON', 'CALLS', 'READS_FROM', 'WRITES_TO', 'HOSTED_ON'))
+);
+
+CREATE INDEX satcom_relations_target_idx ON common.satcom_relations (target_id);
+
+-- Scheduled maintenance of one entry or of the entries matching a label selector
+CREATE TABLE common.satcom_maintenance_windows (
+	id serial4 NOT NULL,
+	company text NOT NULL,
+	satcom_id int4 NULL,
+	label_selector text NULL,
+	starts_at timestamptz NOT NULL,
+	ends_at timestamptz NOT NULL,
+	recurrence text NULL,
+	recur_until timestamptz NULL,
+	timezone text DEFAULT 'UTC' NOT NULL,
+	reason text DEFAULT '' NOT NULL,
+	created_at timestamptz DEFAULT now() NOT NULL,
+	created_by int4 NULL,
+	CONSTRAINT satcom_maintenance_windows_pkey PRIMARY KEY (id),
+	CONSTRAINT satcom_maintenance_windows_company_fk FOREIGN KEY (company) REFERENCES common.companies (name) ON UPDATE CASCADE ON DELETE CASCADE,
+	CONSTRAINT satcom_maintenance_windows_satcom_fk FOREIGN KEY (satcom_id) REFERENCES common.satcom_data (id) ON DELETE CASCADE,
+	CONSTRAINT satcom_maintenance_windows_target_check CHECK ((satcom_id IS NULL) <> (label_selector IS NULL)),
+	CONSTRAINT satcom_maintenance_windows_range_check CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX satcom_maintenance_windows_company_idx ON common.satcom_maintenance_windows (company);
+CREATE INDEX satcom_maintenance_windows_satcom_idx ON common.satcom_maintenance_windows (satcom_id);
+
+-- Requested updates and deletes of production entries, applied once approved
+CREATE TABLE common.satcom_change_requests (
+	id serial4 NOT NULL,
+	satcom_id int4 NOT NULL,
+	company text NOT NULL,
+	operation text NOT NULL,
+	status text DEFAULT 'PENDING_APPROVAL' NOT NULL,
+	base jsonb NOT NULL,
+	proposed jsonb NULL,
+	allow_conflicts bool DEFAULT false NOT NULL,
+	"comment" text DEFAULT '' NOT NULL,
+	requested_at timestamptz DEFAULT now() NOT NULL,
+	requested_by int4 NULL,
+	requested_by_name text NULL,
+	reviewed_at timestamptz NULL,
+	reviewed_by int4 NULL,
+	reviewed_by_name text NULL,
+	review_comment text NULL,
+	requested_actor_id int4 NULL,
+	CONSTRAINT satcom_change_requests_pkey PRIMARY KEY (id),
+	CONSTRAINT satcom_change_requests_satcom_fk FOREIGN KEY (satcom_id) REFERENCES common.satcom_data (id) ON DELETE CASCADE,
+	CONSTRAINT satcom_change_requests_company_fk FOREIGN KEY (company) REFERENCES common.companies (name) ON UPDATE CASCADE ON DELETE CASCADE,
+	CONSTRAINT satcom_change_requests_operation_check CHECK (operation IN ('UPDATE', 'DELETE', 'STATUS')),
+	CONSTRAINT satcom_change_requests_status_check CHECK (status IN ('PENDING_APPROVAL', 'APPROVED', 'RETURNED', 'WITHDRAWN'))
+);
+
+CREATE UNIQUE INDEX satcom_change_requests_pending_idx ON common.satcom_change_requests (satcom_id) WHERE status = 'PENDING_APPROVAL';
+CREATE INDEX satcom_change_requests_company_idx ON common.satcom_change_requests (company, status);
+
+-- Credentials of satcom entries, one encrypted row per version of the value
+CREATE TABLE common.satcom_secrets (
+	id serial4 NOT NULL,
+	satcom_id int4 NOT NULL,
+	name text NOT NULL,
+	username text DEFAULT '' NOT NULL,
+	description text DEFAULT '' NOT NULL,
+	version int4 DEFAULT 1 NOT NULL,
+	rotation_days int4 NULL,
+	rotated_at timestamptz DEFAULT now() NOT NULL,
+	reminded_at timestamptz NULL,
+	created_at timestamptz DEFAULT now() NOT NULL,
+	created_by int4 NULL,
+	CONSTRAINT satcom_secrets_pkey PRIMARY KEY (id),
+	CONSTRAINT satcom_secrets_satcom_fk FOREIGN KEY (satcom_id) REFERENCES common.satcom_data (id) ON DELETE CASCADE,
+	CONSTRAINT satcom_secrets_name_key UNIQUE (satcom_id, name),
+	CONSTRAINT satcom_secrets_rotation_check CHECK (rotation_days IS NULL OR rotation_days >= 0)
+);
+
+CREATE TABLE common.satcom_secret_versions (
+	secret_id int4 NOT NULL,
+	version int4 NOT NULL,
+	ciphertext bytea NOT NULL,
+	nonce bytea NOT NULL,
+	data_key bytea NOT NULL,
+	key_nonce bytea NOT NULL,
+	key_id text NOT NULL,
+	created_at timestamptz DEFAULT now() NOT NULL,
+	created_by int4 NULL,
+	CONSTRAINT satcom_secret_versions_pkey PRIMARY KEY (secret_id, version),
+	CONSTRAINT satcom_secret_versions_secret_fk FOREIGN KEY (secret_id) REFERENCES common.satcom_secrets (id) ON DELETE CASCADE
+);
+
+-- Files attached to satcom entries, stored in S3 under satcom/<satcom_id>/
+CREATE TABLE common.satcom_attachments (
+	id serial4 NOT NULL,
+	satcom_id int4 NOT NULL,
+	object_key text NOT NULL,
+	file_name text NOT NULL,
+	size_bytes int8 NOT NULL,
+	content_type text NOT NULL,
+	description text DEFAULT '' NOT NULL,
+	uploaded_at timestamptz DEFAULT now() NOT NULL,
+	uploaded_by int4 NULL,
+	uploaded_by_name text NULL,
+	CONSTRAINT satcom_attachments_pkey PRIMARY KEY (id),
+	CONSTRAINT satcom_attachments_satcom_fk FOREIGN KEY (satcom_id) REFERENCES common.satcom_data (id) ON DELETE CASCADE,
+	CONSTRAINT satcom_attachments_key UNIQUE (object_key)
+);
+
+CREATE INDEX satcom_attachments_satcom_idx ON common.satcom_attachments (satcom_id);
+
+-- Purged entries whose attachment objects could not be deleted yet
+CREATE TABLE common.satcom_attachment_cleanups (
+	satcom_id int4 NOT NULL,
+	attempts int4 DEFAULT 1 NOT NULL,
+	last_error text NOT NULL,
+	failed_at timestamptz DEFAULT now() NOT NULL,
+	CONSTRAINT satcom_attachment_cleanups_pkey PRIMARY KEY (satcom_id)
+);
+
+-- Threaded notes on satcom entries; replies point at the first note of their thread
+CREATE TABLE common.satcom_notes (
+	id serial4 NOT NULL,
+	satcom_id int4 NOT NULL,
+	parent_id int4 NULL,
+	body text NOT NULL,
+	mentions int4[] DEFAULT '{}' NOT NULL,
+	pinned bool DEFAULT false NOT NULL,
+	resolved_at timestamptz NULL,
+	resolved_by int4 NULL,
+	resolved_by_name text NULL,
+	created_at timestamptz DEFAULT now() NOT NULL,
+	created_by int4 NULL,
+	created_by_name text NULL,
+	updated_at timestamptz NULL,
+	CONSTRAINT satcom_notes_pkey PRIMARY KEY (id),
+	CONSTRAINT satcom_notes_satcom_fk FOREIGN KEY (satcom_id) REFERENCES common.satcom_data (id) ON DELETE CASCADE,
+	CONSTRAINT satcom_notes_parent_fk FOREIGN KEY (parent_id) REFERENCES common.satcom_notes (id) ON DELETE CASCADE
+);
+
+CREATE INDEX satcom_notes_satcom_idx ON common.satcom_notes (satcom_id, created_at);
+
+-- Legacy text values that could not be converted to the typed columns
+CREATE TABLE common.satcom_conversion_issues (
+	id serial4 NOT NULL,
+	satcom_id int4 NOT NULL,
+	column_name text NOT NULL,
+	legacy_value text NULL,
+	error text NOT NULL,
+	reported_at timestamptz DEFAULT now() NOT NULL,
+	CONSTRAINT satcom_conversion_issues_pkey PRIMARY KEY (id)
+);
+
+CREATE INDEX satcom_conversion_issues_satcom_idx ON common.satcom_conversion_issues (satcom_id);
+
+CREATE TABLE common.audit_log (
+	id bigserial NOT NULL,
+	occurred_at timestamptz DEFAULT now() NOT NULL,
+	user_id int4 NULL,
+	user_name text NULL,
+	actor_id int4 NULL,
+	actor_name text NULL,
+	impersonated bool DEFAULT false NOT NULL,
+	"action" text NOT NULL,
+	"method" text NULL,
+	"path" text NULL,
+	status_code int4 NULL,
+	detail jsonb NULL,
+	CONSTRAINT audit_log_pkey PRIMARY KEY (id)
+);
+
+CREATE INDEX audit_log_user_idx ON common.audit_log (user_id, occurred_at);
+CREATE INDEX audit_log_actor_idx ON common.audit_log (actor_id, occurred_at) WHERE actor_id IS NOT NULL;
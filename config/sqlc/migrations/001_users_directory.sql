@@ -0,0 +1,6 @@
+-- User directory: status, creation time and lookup indexes
+ALTER TABLE common.users ADD COLUMN IF NOT EXISTS status text DEFAULT 'ACTIVE' NOT NULL;
+ALTER TABLE common.users ADD COLUMN IF NOT EXISTS created_at timestamptz DEFAULT now() NOT NULL;
+
+CREATE INDEX IF NOT EXISTS users_role_idx ON common.users ("role");
+CREATE INDEX IF NOT EXISTS users_status_idx ON common.users (status);
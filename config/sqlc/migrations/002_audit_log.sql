@@ -0,0 +1,19 @@
+-- Audit trail; impersonated requests carry the real administrator in actor_id
+CREATE TABLE IF NOT EXISTS common.audit_log (
+	id bigserial NOT NULL,
+	occurred_at timestamptz DEFAULT now() NOT NULL,
+	user_id int4 NULL,
+	user_name text NULL,
+	actor_id int4 NULL,
+	actor_name text NULL,
+	impersonated bool DEFAULT false NOT NULL,
+	"action" text NOT NULL,
+	"method" text NULL,
+	"path" text NULL,
+	status_code int4 NULL,
+	detail jsonb NULL,
+	CONSTRAINT audit_log_pkey PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_user_idx ON common.audit_log (user_id, occurred_at);
+CREATE INDEX IF NOT EXISTS audit_log_actor_idx ON common.audit_log (actor_id, occurred_at) WHERE actor_id IS NOT NULL;
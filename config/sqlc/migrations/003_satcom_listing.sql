@@ -0,0 +1,16 @@
+-- Satcom listing: primary key for cursor paging and indexes for the list filters
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'satcom_data_pkey') THEN
+		ALTER TABLE common.satcom_data ADD CONSTRAINT satcom_data_pkey PRIMARY KEY (id);
+	END IF;
+END $$;
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS satcom_data_company_idx ON common.satcom_data (company);
+CREATE INDEX IF NOT EXISTS satcom_data_category_idx ON common.satcom_data (category);
+CREATE INDEX IF NOT EXISTS satcom_data_type_idx ON common.satcom_data ("type");
+CREATE INDEX IF NOT EXISTS satcom_data_status_idx ON common.satcom_data (status);
+CREATE INDEX IF NOT EXISTS satcom_data_ip_idx ON common.satcom_data (ip);
+CREATE INDEX IF NOT EXISTS satcom_data_url_trgm_idx ON common.satcom_data USING gin (url gin_trgm_ops);
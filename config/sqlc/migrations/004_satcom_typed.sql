@@ -0,0 +1,90 @@
+-- Typed satcom records: "date" + "time" become recorded_at timestamptz, ports become
+-- range-checked int4 and ip becomes inet.
+--
+-- Legacy values that cannot be converted are left NULL and listed in
+-- common.satcom_conversion_issues together with the original text so they can be
+-- repaired by hand (GET /api/satcom/conversion-issues). The script only converts a
+-- table that still has the text columns, so re-running it is harmless.
+
+CREATE TABLE IF NOT EXISTS common.satcom_conversion_issues (
+	id serial4 NOT NULL,
+	satcom_id int4 NOT NULL,
+	column_name text NOT NULL,
+	legacy_value text NULL,
+	error text NOT NULL,
+	reported_at timestamptz DEFAULT now() NOT NULL,
+	CONSTRAINT satcom_conversion_issues_pkey PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS satcom_conversion_issues_satcom_idx ON common.satcom_conversion_issues (satcom_id);
+
+CREATE OR REPLACE FUNCTION common.satcom_try_timestamptz(d text, t text, OUT result timestamptz, OUT error text) AS $$
+BEGIN
+	result := (trim(d) || ' ' || coalesce(nullif(trim(t), ''), '00:00:00'))::timestamp AT TIME ZONE 'UTC';
+EXCEPTION WHEN others THEN
+	error := SQLERRM;
+END $$ LANGUAGE plpgsql STABLE;
+
+CREATE OR REPLACE FUNCTION common.satcom_try_port(p text, OUT result int4, OUT error text) AS $$
+BEGIN
+	result := trim(p)::int4;
+	IF result < 1 OR result > 65535 THEN
+		result := NULL;
+		error := 'port out of range 1-65535';
+	END IF;
+EXCEPTION WHEN others THEN
+	error := SQLERRM;
+END $$ LANGUAGE plpgsql STABLE;
+
+CREATE OR REPLACE FUNCTION common.satcom_try_inet(a text, OUT result inet, OUT error text) AS $$
+BEGIN
+	result := trim(a)::inet;
+EXCEPTION WHEN others THEN
+	error := SQLERRM;
+END $$ LANGUAGE plpgsql STABLE;
+
+DO $$
+DECLARE
+	issues int;
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM information_schema.columns
+		WHERE table_schema = 'common' AND table_name = 'satcom_data' AND column_name = 'date') THEN
+		RETURN;
+	END IF;
+
+	INSERT INTO common.satcom_conversion_issues (satcom_id, column_name, legacy_value, error)
+	SELECT id, 'recorded_at', "date" || ' ' || "time", (common.satcom_try_timestamptz("date", "time")).error
+	FROM common.satcom_data WHERE (common.satcom_try_timestamptz("date", "time")).error IS NOT NULL
+	UNION ALL
+	SELECT id, 'db_port', db_port, (common.satcom_try_port(db_port)).error
+	FROM common.satcom_data WHERE (common.satcom_try_port(db_port)).error IS NOT NULL
+	UNION ALL
+	SELECT id, 'ui_port', ui_port, (common.satcom_try_port(ui_port)).error
+	FROM common.satcom_data WHERE (common.satcom_try_port(ui_port)).error IS NOT NULL
+	UNION ALL
+	SELECT id, 'ip', ip, (common.satcom_try_inet(ip)).error
+	FROM common.satcom_data WHERE (common.satcom_try_inet(ip)).error IS NOT NULL;
+	GET DIAGNOSTICS issues = ROW_COUNT;
+
+	ALTER TABLE common.satcom_data ADD COLUMN recorded_at timestamptz NULL;
+	UPDATE common.satcom_data SET recorded_at = (common.satcom_try_timestamptz("date", "time")).result;
+	ALTER TABLE common.satcom_data DROP COLUMN "date", DROP COLUMN "time";
+
+	DROP INDEX IF EXISTS common.satcom_data_ip_idx;
+	ALTER TABLE common.satcom_data
+		ALTER COLUMN db_port DROP NOT NULL,
+		ALTER COLUMN ui_port DROP NOT NULL,
+		ALTER COLUMN ip DROP NOT NULL,
+		ALTER COLUMN db_port TYPE int4 USING (common.satcom_try_port(db_port)).result,
+		ALTER COLUMN ui_port TYPE int4 USING (common.satcom_try_port(ui_port)).result,
+		ALTER COLUMN ip TYPE inet USING (common.satcom_try_inet(ip)).result,
+		ADD CONSTRAINT satcom_data_db_port_check CHECK (db_port BETWEEN 1 AND 65535),
+		ADD CONSTRAINT satcom_data_ui_port_check CHECK (ui_port BETWEEN 1 AND 65535);
+
+	IF issues > 0 THEN
+		RAISE WARNING 'satcom_data: % legacy value(s) could not be converted, see common.satcom_conversion_issues', issues;
+	END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS satcom_data_ip_idx ON common.satcom_data USING gist (ip inet_ops);
+CREATE INDEX IF NOT EXISTS satcom_data_recorded_at_idx ON common.satcom_data (recorded_at);
@@ -0,0 +1,20 @@
+-- Health probing: time-series of probe results and the automatically derived status
+ALTER TABLE common.satcom_data ADD COLUMN IF NOT EXISTS status_override bool DEFAULT false NOT NULL;
+ALTER TABLE common.satcom_data ADD COLUMN IF NOT EXISTS last_probed_at timestamptz NULL;
+
+CREATE TABLE IF NOT EXISTS common.satcom_probe_results (
+	id bigserial NOT NULL,
+	satcom_id int4 NOT NULL,
+	probed_at timestamptz NOT NULL,
+	check_type text NOT NULL,
+	target text NOT NULL,
+	success bool NOT NULL,
+	latency_ms int4 NULL,
+	status_code int4 NULL,
+	error text NULL,
+	CONSTRAINT satcom_probe_results_pkey PRIMARY KEY (id),
+	CONSTRAINT satcom_probe_results_satcom_fk FOREIGN KEY (satcom_id) REFERENCES common.satcom_data (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS satcom_probe_results_satcom_idx ON common.satcom_probe_results (satcom_id, probed_at DESC);
+CREATE INDEX IF NOT EXISTS satcom_probe_results_probed_at_idx ON common.satcom_probe_results (probed_at);
@@ -0,0 +1,20 @@
+-- TLS certificate tracking of HTTPS satcom urls
+CREATE TABLE IF NOT EXISTS common.satcom_certificates (
+	satcom_id int4 NOT NULL,
+	host text NOT NULL,
+	checked_at timestamptz NOT NULL,
+	subject text NULL,
+	issuer text NULL,
+	sans text[] NULL,
+	serial_number text NULL,
+	not_before timestamptz NULL,
+	not_after timestamptz NULL,
+	hostname_match bool NULL,
+	chain_valid bool NULL,
+	error text NULL,
+	last_warning_days int4 NULL,
+	CONSTRAINT satcom_certificates_pkey PRIMARY KEY (satcom_id),
+	CONSTRAINT satcom_certificates_satcom_fk FOREIGN KEY (satcom_id) REFERENCES common.satcom_data (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS satcom_certificates_not_after_idx ON common.satcom_certificates (not_after);
@@ -0,0 +1,4 @@
+-- Lookups used by the satcom conflict checks
+CREATE INDEX IF NOT EXISTS satcom_data_ip_db_port_idx ON common.satcom_data (ip, db_port);
+CREATE INDEX IF NOT EXISTS satcom_data_ip_ui_port_idx ON common.satcom_data (ip, ui_port);
+CREATE INDEX IF NOT EXISTS satcom_data_url_norm_idx ON common.satcom_data (lower(rtrim(url, '/')));
@@ -0,0 +1,30 @@
+-- Versioned history of satcom entries and soft deletes
+ALTER TABLE common.satcom_data ADD COLUMN IF NOT EXISTS version int4 DEFAULT 1 NOT NULL;
+ALTER TABLE common.satcom_data ADD COLUMN IF NOT EXISTS deleted_at timestamptz NULL;
+ALTER TABLE common.satcom_data ADD COLUMN IF NOT EXISTS deleted_by int4 NULL;
+
+CREATE INDEX IF NOT EXISTS satcom_data_deleted_at_idx ON common.satcom_data (deleted_at) WHERE deleted_at IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS common.satcom_history (
+	id bigserial NOT NULL,
+	satcom_id int4 NOT NULL,
+	version int4 NOT NULL,
+	operation text NOT NULL,
+	changed_at timestamptz DEFAULT now() NOT NULL,
+	changed_by int4 NULL,
+	changed_by_name text NULL,
+	actor_id int4 NULL,
+	actor_name text NULL,
+	restored_from int4 NULL,
+	snapshot jsonb NOT NULL,
+	CONSTRAINT satcom_history_pkey PRIMARY KEY (id),
+	CONSTRAINT satcom_history_version_key UNIQUE (satcom_id, version)
+);
+
+CREATE INDEX IF NOT EXISTS satcom_history_changed_at_idx ON common.satcom_history (changed_at);
+
+-- History starts now: every existing entry gets its current state as version 1
+INSERT INTO common.satcom_history(satcom_id, version, operation, snapshot)
+SELECT s.id, s.version, 'CREATE', to_jsonb(s)
+FROM common.satcom_data s
+WHERE NOT EXISTS (SELECT 1 FROM common.satcom_history h WHERE h.satcom_id = s.id);
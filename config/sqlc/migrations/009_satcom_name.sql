@@ -0,0 +1,4 @@
+-- Stable names of satcom entries managed by an inventory manifest
+ALTER TABLE common.satcom_data ADD COLUMN IF NOT EXISTS name text NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS satcom_data_name_idx ON common.satcom_data (name) WHERE deleted_at IS NULL;
@@ -0,0 +1,6 @@
+-- Key/value labels and custom fields of satcom entries
+ALTER TABLE common.satcom_data ADD COLUMN IF NOT EXISTS labels jsonb DEFAULT '{}' NOT NULL;
+ALTER TABLE common.satcom_data ADD COLUMN IF NOT EXISTS custom_fields jsonb DEFAULT '{}' NOT NULL;
+
+-- Serves the containment (@>) and key (?&, ?|) operators of label selectors
+CREATE INDEX IF NOT EXISTS satcom_data_labels_idx ON common.satcom_data USING gin (labels);
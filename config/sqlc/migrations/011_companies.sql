@@ -0,0 +1,38 @@
+-- Tenants: companies, user memberships with per-company roles, and satcom_data.company
+-- turned into a foreign key to the company name
+CREATE TABLE IF NOT EXISTS common.companies (
+	id serial4 NOT NULL,
+	name text NOT NULL,
+	created_at timestamptz DEFAULT now() NOT NULL,
+	CONSTRAINT companies_pkey PRIMARY KEY (id),
+	CONSTRAINT companies_name_key UNIQUE (name)
+);
+
+CREATE TABLE IF NOT EXISTS common.company_memberships (
+	user_id int4 NOT NULL,
+	company_id int4 NOT NULL,
+	"role" text NOT NULL,
+	created_at timestamptz DEFAULT now() NOT NULL,
+	CONSTRAINT company_memberships_pkey PRIMARY KEY (user_id, company_id),
+	CONSTRAINT company_memberships_company_fk FOREIGN KEY (company_id) REFERENCES common.companies (id) ON DELETE CASCADE,
+	CONSTRAINT company_memberships_role_check CHECK ("role" IN ('VIEWER', 'EDITOR', 'ADMIN'))
+);
+
+CREATE INDEX IF NOT EXISTS company_memberships_company_idx ON common.company_memberships (company_id);
+
+-- Every company named by an existing entry, including deleted ones, becomes a tenant
+INSERT INTO common.companies (name)
+SELECT DISTINCT company FROM common.satcom_data
+ON CONFLICT (name) DO NOTHING;
+
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'satcom_data_company_fk') THEN
+		ALTER TABLE common.satcom_data ADD CONSTRAINT satcom_data_company_fk
+			FOREIGN KEY (company) REFERENCES common.companies (name) ON UPDATE CASCADE;
+	END IF;
+END $$;
+
+-- Manifest names only need to be unique within a company
+DROP INDEX IF EXISTS common.satcom_data_name_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS satcom_data_company_name_idx ON common.satcom_data (company, name) WHERE deleted_at IS NULL;
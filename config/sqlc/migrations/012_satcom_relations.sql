@@ -0,0 +1,17 @@
+-- Typed dependencies between satcom entries: source_id relies on target_id
+CREATE TABLE IF NOT EXISTS common.satcom_relations (
+	id serial4 NOT NULL,
+	source_id int4 NOT NULL,
+	target_id int4 NOT NULL,
+	relation text NOT NULL,
+	created_at timestamptz DEFAULT now() NOT NULL,
+	created_by int4 NULL,
+	CONSTRAINT satcom_relations_pkey PRIMARY KEY (id),
+	CONSTRAINT satcom_relations_key UNIQUE (source_id, target_id, relation),
+	CONSTRAINT satcom_relations_source_fk FOREIGN KEY (source_id) REFERENCES common.satcom_data (id) ON DELETE CASCADE,
+	CONSTRAINT satcom_relations_target_fk FOREIGN KEY (target_id) REFERENCES common.satcom_data (id) ON DELETE CASCADE,
+	CONSTRAINT satcom_relations_self_check CHECK (source_id <> target_id),
+	CONSTRAINT satcom_relations_relation_check CHECK (relation IN ('DEPENDS_ON', 'CALLS', 'READS_FROM', 'WRITES_TO', 'HOSTED_ON'))
+);
+
+CREATE INDEX IF NOT EXISTS satcom_relations_target_idx ON common.satcom_relations (target_id);
@@ -0,0 +1,25 @@
+-- Scheduled maintenance of satcom entries, for one entry or for the entries of a company
+-- matching a label selector. Recurring windows repeat at the cron matches of recurrence
+-- (evaluated in timezone) for ends_at - starts_at, from starts_at until recur_until.
+CREATE TABLE IF NOT EXISTS common.satcom_maintenance_windows (
+	id serial4 NOT NULL,
+	company text NOT NULL,
+	satcom_id int4 NULL,
+	label_selector text NULL,
+	starts_at timestamptz NOT NULL,
+	ends_at timestamptz NOT NULL,
+	recurrence text NULL,
+	recur_until timestamptz NULL,
+	timezone text DEFAULT 'UTC' NOT NULL,
+	reason text DEFAULT '' NOT NULL,
+	created_at timestamptz DEFAULT now() NOT NULL,
+	created_by int4 NULL,
+	CONSTRAINT satcom_maintenance_windows_pkey PRIMARY KEY (id),
+	CONSTRAINT satcom_maintenance_windows_company_fk FOREIGN KEY (company) REFERENCES common.companies (name) ON UPDATE CASCADE ON DELETE CASCADE,
+	CONSTRAINT satcom_maintenance_windows_satcom_fk FOREIGN KEY (satcom_id) REFERENCES common.satcom_data (id) ON DELETE CASCADE,
+	CONSTRAINT satcom_maintenance_windows_target_check CHECK ((satcom_id IS NULL) <> (label_selector IS NULL)),
+	CONSTRAINT satcom_maintenance_windows_range_check CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS satcom_maintenance_windows_company_idx ON common.satcom_maintenance_windows (company);
+CREATE INDEX IF NOT EXISTS satcom_maintenance_windows_satcom_idx ON common.satcom_maintenance_windows (satcom_id);
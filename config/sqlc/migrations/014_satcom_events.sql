@@ -0,0 +1,11 @@
+-- Announces every satcom history row on the satcom_changes channel once its transaction
+-- commits, so that the change feeds of all service instances see every change
+CREATE OR REPLACE FUNCTION common.notify_satcom_change() RETURNS trigger AS $$
+BEGIN
+	PERFORM pg_notify('satcom_changes', NEW.id::text);
+	RETURN NEW;
+END $$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS satcom_history_notify ON common.satcom_history;
+CREATE TRIGGER satcom_history_notify AFTER INSERT ON common.satcom_history
+	FOR EACH ROW EXECUTE FUNCTION common.notify_satcom_change();
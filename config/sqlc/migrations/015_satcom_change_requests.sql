@@ -0,0 +1,35 @@
+-- Changes to production satcom entries wait for approval. APPROVER members approve or
+-- return the change requests of their company; ADMIN members can as well.
+ALTER TABLE common.company_memberships DROP CONSTRAINT IF EXISTS company_memberships_role_check;
+ALTER TABLE common.company_memberships ADD CONSTRAINT company_memberships_role_check
+	CHECK ("role" IN ('VIEWER', 'EDITOR', 'APPROVER', 'ADMIN'));
+
+-- A requested update or delete of an entry. base is the entry as it was requested against
+-- and proposed the entry after the update (NULL for deletes).
+CREATE TABLE IF NOT EXISTS common.satcom_change_requests (
+	id serial4 NOT NULL,
+	satcom_id int4 NOT NULL,
+	company text NOT NULL,
+	operation text NOT NULL,
+	status text DEFAULT 'PENDING_APPROVAL' NOT NULL,
+	base jsonb NOT NULL,
+	proposed jsonb NULL,
+	allow_conflicts bool DEFAULT false NOT NULL,
+	"comment" text DEFAULT '' NOT NULL,
+	requested_at timestamptz DEFAULT now() NOT NULL,
+	requested_by int4 NULL,
+	requested_by_name text NULL,
+	reviewed_at timestamptz NULL,
+	reviewed_by int4 NULL,
+	reviewed_by_name text NULL,
+	review_comment text NULL,
+	CONSTRAINT satcom_change_requests_pkey PRIMARY KEY (id),
+	CONSTRAINT satcom_change_requests_satcom_fk FOREIGN KEY (satcom_id) REFERENCES common.satcom_data (id) ON DELETE CASCADE,
+	CONSTRAINT satcom_change_requests_company_fk FOREIGN KEY (company) REFERENCES common.companies (name) ON UPDATE CASCADE ON DELETE CASCADE,
+	CONSTRAINT satcom_change_requests_operation_check CHECK (operation IN ('UPDATE', 'DELETE')),
+	CONSTRAINT satcom_change_requests_status_check CHECK (status IN ('PENDING_APPROVAL', 'APPROVED', 'RETURNED', 'WITHDRAWN'))
+);
+
+-- One pending request per entry
+CREATE UNIQUE INDEX IF NOT EXISTS satcom_change_requests_pending_idx ON common.satcom_change_requests (satcom_id) WHERE status = 'PENDING_APPROVAL';
+CREATE INDEX IF NOT EXISTS satcom_change_requests_company_idx ON common.satcom_change_requests (company, status);
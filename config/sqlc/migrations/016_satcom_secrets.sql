@@ -0,0 +1,33 @@
+-- Credentials of satcom entries. Every version of a value is encrypted with its own data
+-- key (AES-256-GCM), and the data key with the master key identified by key_id.
+CREATE TABLE IF NOT EXISTS common.satcom_secrets (
+	id serial4 NOT NULL,
+	satcom_id int4 NOT NULL,
+	name text NOT NULL,
+	username text DEFAULT '' NOT NULL,
+	description text DEFAULT '' NOT NULL,
+	version int4 DEFAULT 1 NOT NULL,
+	rotation_days int4 NULL,
+	rotated_at timestamptz DEFAULT now() NOT NULL,
+	reminded_at timestamptz NULL,
+	created_at timestamptz DEFAULT now() NOT NULL,
+	created_by int4 NULL,
+	CONSTRAINT satcom_secrets_pkey PRIMARY KEY (id),
+	CONSTRAINT satcom_secrets_satcom_fk FOREIGN KEY (satcom_id) REFERENCES common.satcom_data (id) ON DELETE CASCADE,
+	CONSTRAINT satcom_secrets_name_key UNIQUE (satcom_id, name),
+	CONSTRAINT satcom_secrets_rotation_check CHECK (rotation_days IS NULL OR rotation_days >= 0)
+);
+
+CREATE TABLE IF NOT EXISTS common.satcom_secret_versions (
+	secret_id int4 NOT NULL,
+	version int4 NOT NULL,
+	ciphertext bytea NOT NULL,
+	nonce bytea NOT NULL,
+	data_key bytea NOT NULL,
+	key_nonce bytea NOT NULL,
+	key_id text NOT NULL,
+	created_at timestamptz DEFAULT now() NOT NULL,
+	created_by int4 NULL,
+	CONSTRAINT satcom_secret_versions_pkey PRIMARY KEY (secret_id, version),
+	CONSTRAINT satcom_secret_versions_secret_fk FOREIGN KEY (secret_id) REFERENCES common.satcom_secrets (id) ON DELETE CASCADE
+);
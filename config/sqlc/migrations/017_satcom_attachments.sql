@@ -0,0 +1,19 @@
+-- Files attached to satcom entries. The objects live in the S3 bucket under
+-- satcom/<satcom_id>/; the rows go with the entry when it is purged.
+CREATE TABLE IF NOT EXISTS common.satcom_attachments (
+	id serial4 NOT NULL,
+	satcom_id int4 NOT NULL,
+	object_key text NOT NULL,
+	file_name text NOT NULL,
+	size_bytes int8 NOT NULL,
+	content_type text NOT NULL,
+	description text DEFAULT '' NOT NULL,
+	uploaded_at timestamptz DEFAULT now() NOT NULL,
+	uploaded_by int4 NULL,
+	uploaded_by_name text NULL,
+	CONSTRAINT satcom_attachments_pkey PRIMARY KEY (id),
+	CONSTRAINT satcom_attachments_satcom_fk FOREIGN KEY (satcom_id) REFERENCES common.satcom_data (id) ON DELETE CASCADE,
+	CONSTRAINT satcom_attachments_key UNIQUE (object_key)
+);
+
+CREATE INDEX IF NOT EXISTS satcom_attachments_satcom_idx ON common.satcom_attachments (satcom_id);
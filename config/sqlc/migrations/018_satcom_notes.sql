@@ -0,0 +1,22 @@
+-- Threaded notes on satcom entries. Replies point at the first note of their thread;
+-- only those carry the pinned and resolved states. mentions holds the mentioned user ids.
+CREATE TABLE IF NOT EXISTS common.satcom_notes (
+	id serial4 NOT NULL,
+	satcom_id int4 NOT NULL,
+	parent_id int4 NULL,
+	body text NOT NULL,
+	mentions int4[] DEFAULT '{}' NOT NULL,
+	pinned bool DEFAULT false NOT NULL,
+	resolved_at timestamptz NULL,
+	resolved_by int4 NULL,
+	resolved_by_name text NULL,
+	created_at timestamptz DEFAULT now() NOT NULL,
+	created_by int4 NULL,
+	created_by_name text NULL,
+	updated_at timestamptz NULL,
+	CONSTRAINT satcom_notes_pkey PRIMARY KEY (id),
+	CONSTRAINT satcom_notes_satcom_fk FOREIGN KEY (satcom_id) REFERENCES common.satcom_data (id) ON DELETE CASCADE,
+	CONSTRAINT satcom_notes_parent_fk FOREIGN KEY (parent_id) REFERENCES common.satcom_notes (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS satcom_notes_satcom_idx ON common.satcom_notes (satcom_id, created_at);
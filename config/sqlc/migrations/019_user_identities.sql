@@ -0,0 +1,11 @@
+-- Links federated identities to local users. A provider subject resolves to the
+-- user it was provisioned for or explicitly linked to, never by a matching email alone.
+CREATE TABLE IF NOT EXISTS common.user_identities (
+	provider text NOT NULL,
+	subject text NOT NULL,
+	user_id int4 NOT NULL,
+	created_at timestamptz DEFAULT now() NOT NULL,
+	CONSTRAINT user_identities_pkey PRIMARY KEY (provider, subject)
+);
+
+CREATE INDEX IF NOT EXISTS user_identities_user_idx ON common.user_identities (user_id);
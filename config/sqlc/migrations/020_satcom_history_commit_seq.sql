@@ -0,0 +1,27 @@
+-- Commit-ordered resume tokens for the change feed. History ids are handed out at insert
+-- time, so a transaction that commits late can land below an id a stream already passed.
+-- commit_seq is assigned when the inserting transaction commits, under a lock held until
+-- the commit completes, so sequences become visible in order.
+CREATE SEQUENCE IF NOT EXISTS common.satcom_history_commit_seq;
+
+ALTER TABLE common.satcom_history ADD COLUMN IF NOT EXISTS commit_seq int8 NULL;
+
+-- Existing rows keep their id, so resume tokens issued before the upgrade stay valid
+UPDATE common.satcom_history SET commit_seq = id WHERE commit_seq IS NULL;
+SELECT setval('common.satcom_history_commit_seq', GREATEST((SELECT max(id) FROM common.satcom_history), 1));
+
+CREATE UNIQUE INDEX IF NOT EXISTS satcom_history_commit_seq_idx ON common.satcom_history (commit_seq);
+
+CREATE OR REPLACE FUNCTION common.assign_satcom_commit_seq() RETURNS trigger AS $$
+BEGIN
+	PERFORM pg_advisory_xact_lock(hashtext('common.satcom_history_commit_seq'));
+	UPDATE common.satcom_history
+	SET commit_seq = nextval('common.satcom_history_commit_seq')
+	WHERE id = NEW.id;
+	RETURN NULL;
+END $$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS satcom_history_commit_seq ON common.satcom_history;
+CREATE CONSTRAINT TRIGGER satcom_history_commit_seq AFTER INSERT ON common.satcom_history
+	DEFERRABLE INITIALLY DEFERRED
+	FOR EACH ROW EXECUTE FUNCTION common.assign_satcom_commit_seq();
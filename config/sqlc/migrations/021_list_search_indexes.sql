@@ -0,0 +1,17 @@
+-- List endpoints: trigram indexes for the substring search (q) and btree indexes on the
+-- sort columns, which order by the sort column and then the id
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS users_user_id_idx ON common.users (user_id);
+CREATE INDEX IF NOT EXISTS users_user_name_idx ON common.users (user_name, user_id);
+CREATE INDEX IF NOT EXISTS users_email_idx ON common.users (email, user_id);
+CREATE INDEX IF NOT EXISTS users_created_at_idx ON common.users (created_at, user_id);
+CREATE INDEX IF NOT EXISTS users_user_name_trgm_idx ON common.users USING gin (user_name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS users_email_trgm_idx ON common.users USING gin (email gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS users_phone_trgm_idx ON common.users USING gin (phone gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS satcom_data_url_sort_idx ON common.satcom_data (url, id);
+CREATE INDEX IF NOT EXISTS satcom_data_company_trgm_idx ON common.satcom_data USING gin (company gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS satcom_data_category_trgm_idx ON common.satcom_data USING gin (category gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS satcom_data_type_trgm_idx ON common.satcom_data USING gin ("type" gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS satcom_data_host_trgm_idx ON common.satcom_data USING gin (host(ip) gin_trgm_ops);
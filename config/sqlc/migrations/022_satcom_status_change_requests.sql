@@ -0,0 +1,4 @@
+-- Manual status changes of entries needing approval are change requests as well
+ALTER TABLE common.satcom_change_requests DROP CONSTRAINT IF EXISTS satcom_change_requests_operation_check;
+ALTER TABLE common.satcom_change_requests ADD CONSTRAINT satcom_change_requests_operation_check
+	CHECK (operation IN ('UPDATE', 'DELETE', 'STATUS'));
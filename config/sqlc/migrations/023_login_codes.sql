@@ -0,0 +1,8 @@
+-- One-time codes handed to the frontend after a federated login. The frontend redeems
+-- a code for a token once, so the token never appears in a redirect URL.
+CREATE TABLE IF NOT EXISTS common.login_codes (
+	code_hash text NOT NULL,
+	user_id int4 NOT NULL,
+	expires_at timestamptz NOT NULL,
+	CONSTRAINT login_codes_pkey PRIMARY KEY (code_hash)
+);
@@ -0,0 +1,18 @@
+-- History rows keep the id of the owning company, so renaming a company does not cut
+-- entries off from their earlier versions
+ALTER TABLE common.satcom_history ADD COLUMN IF NOT EXISTS company_id int4 NULL;
+
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'satcom_history_company_fk') THEN
+		ALTER TABLE common.satcom_history ADD CONSTRAINT satcom_history_company_fk
+			FOREIGN KEY (company_id) REFERENCES common.companies (id) ON DELETE SET NULL;
+	END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS satcom_history_company_idx ON common.satcom_history (company_id);
+
+UPDATE common.satcom_history h
+SET company_id = c.id
+FROM common.companies c
+WHERE h.company_id IS NULL AND c.name = h.snapshot->>'company';
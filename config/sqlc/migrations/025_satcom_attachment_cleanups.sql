@@ -0,0 +1,9 @@
+-- Purged entries whose attachment objects could not be deleted; the purge job retries
+-- them on every run until the objects are gone
+CREATE TABLE IF NOT EXISTS common.satcom_attachment_cleanups (
+	satcom_id int4 NOT NULL,
+	attempts int4 DEFAULT 1 NOT NULL,
+	last_error text NOT NULL,
+	failed_at timestamptz DEFAULT now() NOT NULL,
+	CONSTRAINT satcom_attachment_cleanups_pkey PRIMARY KEY (satcom_id)
+);
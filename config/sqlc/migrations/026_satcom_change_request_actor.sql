@@ -0,0 +1,3 @@
+-- The administrator behind an impersonation token that submitted a change request, so
+-- that they cannot approve it under their own name
+ALTER TABLE common.satcom_change_requests ADD COLUMN IF NOT EXISTS requested_actor_id int4 NULL;
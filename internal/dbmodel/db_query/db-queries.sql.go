@@ -7,104 +7,91 @@ package sql
 
 import (
 	"context"
+	"encoding/json"
+	"net/netip"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
-const createSatcomData = `-- name: CreateSatcomData :exec
-INSERT INTO common.satcom_data(company, category, "type", "date", "time", db_port, ui_port, url, ip, status)
-VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+const consumeLoginCode = `-- name: ConsumeLoginCode :one
+DELETE FROM common.login_codes
+WHERE code_hash = $1 AND expires_at > now()
+RETURNING user_id
 `
 
-type CreateSatcomDataParams struct {
-	Company  string `db:"company" json:"company"`
-	Category string `db:"category" json:"category"`
-	Type     string `db:"type" json:"type"`
-	Date     string `db:"date" json:"date"`
-	Time     string `db:"time" json:"time"`
-	DbPort   string `db:"db_port" json:"db_port"`
-	UiPort   string `db:"ui_port" json:"ui_port"`
-	Url      string `db:"url" json:"url"`
-	Ip       string `db:"ip" json:"ip"`
-	Status   bool   `db:"status" json:"status"`
-}
-
-// --------------------- SATCOM DATA ------------------------------
-func (q *Queries) CreateSatcomData(ctx context.Context, arg CreateSatcomDataParams) error {
-	_, err := q.db.Exec(ctx, createSatcomData,
-		arg.Company,
-		arg.Category,
-		arg.Type,
-		arg.Date,
-		arg.Time,
-		arg.DbPort,
-		arg.UiPort,
-		arg.Url,
-		arg.Ip,
-		arg.Status,
-	)
-	return err
+func (q *Queries) ConsumeLoginCode(ctx context.Context, codeHash string) (int32, error) {
+	row := q.db.QueryRow(ctx, consumeLoginCode, codeHash)
+	var userID int32
+	err := row.Scan(&userID)
+	return userID, err
 }
 
-const createUser = `-- name: CreateUser :exec
-INSERT INTO common.users(user_name, email, phone, pass, role) 
-VALUES($1, $2, $3, $4, $5)
+const countAuditLogs = `-- name: CountAuditLogs :one
+SELECT count(*)
+FROM common.audit_log
+WHERE ($1::int IS NULL OR user_id = $1)
+    AND ($2::int IS NULL OR actor_id = $2)
+    AND ($3::bool IS NULL OR impersonated = $3)
+    AND ($4::text IS NULL OR "action" = $4)
 `
 
-type CreateUserParams struct {
-	UserName string `db:"user_name" json:"user_name"`
-	Email    string `db:"email" json:"email"`
-	Phone    string `db:"phone" json:"phone"`
-	Pass     string `db:"pass" json:"pass"`
-	Role     string `db:"role" json:"role"`
+type CountAuditLogsParams struct {
+	UserID       pgtype.Int4 `db:"user_id" json:"user_id"`
+	ActorID      pgtype.Int4 `db:"actor_id" json:"actor_id"`
+	Impersonated pgtype.Bool `db:"impersonated" json:"impersonated"`
+	Action       pgtype.Text `db:"action" json:"action"`
 }
 
-func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) error {
-	_, err := q.db.Exec(ctx, createUser,
-		arg.UserName,
-		arg.Email,
-		arg.Phone,
-		arg.Pass,
-		arg.Role,
+func (q *Queries) CountAuditLogs(ctx context.Context, arg CountAuditLogsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countAuditLogs,
+		arg.UserID,
+		arg.ActorID,
+		arg.Impersonated,
+		arg.Action,
 	)
-	return err
+	var count int64
+	err := row.Scan(&count)
+	return count, err
 }
 
-const deleteSatcomData = `-- name: DeleteSatcomData :exec
-DELETE FROM common.satcom_data
-WHERE id = $1
+const countSatcomActivityByWeek = `-- name: CountSatcomActivityByWeek :many
+SELECT (date_trunc('week', h.changed_at AT TIME ZONE 'UTC') AT TIME ZONE 'UTC')::timestamptz AS week,
+    count(*) FILTER (WHERE h.operation = 'CREATE') AS added,
+    count(DISTINCT h.satcom_id) FILTER (WHERE h.operation NOT IN ('CREATE', 'STATUS')) AS changed,
+    count(*) FILTER (WHERE h.operation = 'STATUS') AS status_changes
+FROM common.satcom_history h
+JOIN common.satcom_data d ON d.id = h.satcom_id
+WHERE h.changed_at >= $1 AND ($2::text IS NULL OR d.company = $2)
+GROUP BY 1
+ORDER BY 1
 `
 
-func (q *Queries) DeleteSatcomData(ctx context.Context, id int32) error {
-	_, err := q.db.Exec(ctx, deleteSatcomData, id)
-	return err
+type CountSatcomActivityByWeekParams struct {
+	Since   pgtype.Timestamptz `db:"since" json:"since"`
+	Company pgtype.Text        `db:"company" json:"company"`
 }
 
-const getAllSatcomData = `-- name: GetAllSatcomData :many
-SELECT id, company, category, "type", "date", "time", db_port, ui_port, url, ip, status
-FROM common.satcom_data
-ORDER BY id
-`
+type CountSatcomActivityByWeekRow struct {
+	Week          pgtype.Timestamptz `db:"week" json:"week"`
+	Added         int64              `db:"added" json:"added"`
+	Changed       int64              `db:"changed" json:"changed"`
+	StatusChanges int64              `db:"status_changes" json:"status_changes"`
+}
 
-func (q *Queries) GetAllSatcomData(ctx context.Context) ([]CommonSatcomDatum, error) {
-	rows, err := q.db.Query(ctx, getAllSatcomData)
+func (q *Queries) CountSatcomActivityByWeek(ctx context.Context, arg CountSatcomActivityByWeekParams) ([]CountSatcomActivityByWeekRow, error) {
+	rows, err := q.db.Query(ctx, countSatcomActivityByWeek, arg.Since, arg.Company)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []CommonSatcomDatum
+	var items []CountSatcomActivityByWeekRow
 	for rows.Next() {
-		var i CommonSatcomDatum
+		var i CountSatcomActivityByWeekRow
 		if err := rows.Scan(
-			&i.ID,
-			&i.Company,
-			&i.Category,
-			&i.Type,
-			&i.Date,
-			&i.Time,
-			&i.DbPort,
-			&i.UiPort,
-			&i.Url,
-			&i.Ip,
-			&i.Status,
+			&i.Week,
+			&i.Added,
+			&i.Changed,
+			&i.StatusChanges,
 		); err != nil {
 			return nil, err
 		}
@@ -116,28 +103,102 @@ func (q *Queries) GetAllSatcomData(ctx context.Context) ([]CommonSatcomDatum, er
 	return items, nil
 }
 
-const getAllUsers = `-- name: GetAllUsers :many
-SELECT user_id, user_name, email 
-FROM common.users 
-ORDER BY user_id
+const countSatcomData = `-- name: CountSatcomData :one
+SELECT count(*)
+FROM common.satcom_data
+WHERE deleted_at IS NULL
+    AND ($1::text IS NULL
+        OR company ILIKE '%' || $1 || '%' ESCAPE '\'
+        OR category ILIKE '%' || $1 || '%' ESCAPE '\'
+        OR "type" ILIKE '%' || $1 || '%' ESCAPE '\'
+        OR url ILIKE '%' || $1 || '%' ESCAPE '\'
+        OR host(ip) ILIKE '%' || $1 || '%' ESCAPE '\')
+    AND ($2::text IS NULL OR company = $2)
+    AND ($3::text IS NULL OR category = $3)
+    AND ($4::text IS NULL OR "type" = $4)
+    AND ($5::bool IS NULL OR status = $5)
+    AND ($6::inet IS NULL OR ip <<= $6)
+    AND ($7::text IS NULL OR url ILIKE '%' || $7 || '%' ESCAPE '\')
+    AND ($8::jsonb IS NULL OR labels @> $8)
+    AND ($9::text[] IS NULL OR labels ?& $9)
+    AND ($10::text[] IS NULL OR NOT labels ?| $10)
+    AND ($11::jsonb IS NULL OR NOT EXISTS (
+        SELECT 1 FROM jsonb_array_elements($11) AS excluded(label) WHERE labels @> excluded.label))
+    AND ($12::jsonb IS NULL OR NOT EXISTS (
+        SELECT 1 FROM jsonb_array_elements($12) AS wanted(choices)
+        WHERE NOT EXISTS (SELECT 1 FROM jsonb_array_elements(wanted.choices) AS choice(label) WHERE labels @> choice.label)))
 `
 
-type GetAllUsersRow struct {
-	UserID   int32  `db:"user_id" json:"user_id"`
-	UserName string `db:"user_name" json:"user_name"`
-	Email    string `db:"email" json:"email"`
+type CountSatcomDataParams struct {
+	Search      pgtype.Text     `db:"search" json:"search"`
+	Company     pgtype.Text     `db:"company" json:"company"`
+	Category    pgtype.Text     `db:"category" json:"category"`
+	Type        pgtype.Text     `db:"type" json:"type"`
+	Status      pgtype.Bool     `db:"status" json:"status"`
+	Ip          *netip.Prefix   `db:"ip" json:"ip"`
+	Url         pgtype.Text     `db:"url" json:"url"`
+	LabelMatch  json.RawMessage `db:"label_match" json:"label_match"`
+	LabelExists []string        `db:"label_exists" json:"label_exists"`
+	LabelAbsent []string        `db:"label_absent" json:"label_absent"`
+	LabelNot    json.RawMessage `db:"label_not" json:"label_not"`
+	LabelIn     json.RawMessage `db:"label_in" json:"label_in"`
 }
 
-func (q *Queries) GetAllUsers(ctx context.Context) ([]GetAllUsersRow, error) {
-	rows, err := q.db.Query(ctx, getAllUsers)
+func (q *Queries) CountSatcomData(ctx context.Context, arg CountSatcomDataParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countSatcomData,
+		arg.Search,
+		arg.Company,
+		arg.Category,
+		arg.Type,
+		arg.Status,
+		arg.Ip,
+		arg.Url,
+		arg.LabelMatch,
+		arg.LabelExists,
+		arg.LabelAbsent,
+		arg.LabelNot,
+		arg.LabelIn,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countSatcomDataByDimension = `-- name: CountSatcomDataByDimension :many
+SELECT CASE
+        WHEN GROUPING(company) = 0 THEN 'company'
+        WHEN GROUPING(category) = 0 THEN 'category'
+        WHEN GROUPING("type") = 0 THEN 'type'
+        ELSE 'status'
+    END::text AS dimension,
+    COALESCE(company, category, "type", CASE WHEN status THEN 'up' ELSE 'down' END)::text AS value,
+    count(*) AS total
+FROM common.satcom_data
+WHERE deleted_at IS NULL AND ($1::text IS NULL OR company = $1)
+GROUP BY GROUPING SETS ((company), (category), ("type"), (status))
+ORDER BY dimension, total DESC, value
+`
+
+type CountSatcomDataByDimensionRow struct {
+	Dimension string `db:"dimension" json:"dimension"`
+	Value     string `db:"value" json:"value"`
+	Total     int64  `db:"total" json:"total"`
+}
+
+func (q *Queries) CountSatcomDataByDimension(ctx context.Context, company pgtype.Text) ([]CountSatcomDataByDimensionRow, error) {
+	rows, err := q.db.Query(ctx, countSatcomDataByDimension, company)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetAllUsersRow
+	var items []CountSatcomDataByDimensionRow
 	for rows.Next() {
-		var i GetAllUsersRow
-		if err := rows.Scan(&i.UserID, &i.UserName, &i.Email); err != nil {
+		var i CountSatcomDataByDimensionRow
+		if err := rows.Scan(
+			&i.Dimension,
+			&i.Value,
+			&i.Total,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -148,228 +209,4083 @@ func (q *Queries) GetAllUsers(ctx context.Context) ([]GetAllUsersRow, error) {
 	return items, nil
 }
 
-const getSatcomDataById = `-- name: GetSatcomDataById :one
-SELECT id, company, category, "type", "date", "time", db_port, ui_port, url, ip, status
-FROM common.satcom_data
-WHERE id = $1
+const countUsers = `-- name: CountUsers :one
+SELECT count(*)
+FROM common.users
+WHERE ($1::text IS NULL
+        OR user_name ILIKE '%' || $1 || '%' ESCAPE '\'
+        OR email ILIKE '%' || $1 || '%' ESCAPE '\'
+        OR phone ILIKE '%' || $1 || '%' ESCAPE '\')
+    AND ($2::text IS NULL OR role = $2)
+    AND ($3::text IS NULL OR status = $3)
 `
 
-func (q *Queries) GetSatcomDataById(ctx context.Context, id int32) (CommonSatcomDatum, error) {
-	row := q.db.QueryRow(ctx, getSatcomDataById, id)
-	var i CommonSatcomDatum
-	err := row.Scan(
-		&i.ID,
-		&i.Company,
-		&i.Category,
-		&i.Type,
-		&i.Date,
-		&i.Time,
-		&i.DbPort,
-		&i.UiPort,
-		&i.Url,
-		&i.Ip,
-		&i.Status,
-	)
-	return i, err
+type CountUsersParams struct {
+	Search pgtype.Text `db:"search" json:"search"`
+	Role   pgtype.Text `db:"role" json:"role"`
+	Status pgtype.Text `db:"status" json:"status"`
 }
 
-const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT user_id, user_name, email, phone, pass, pss_valid, otp, otp_valid, otp_exp, role 
-FROM common.users 
-WHERE email = $1
-`
-
-// --------------------- AUTHENTICATION ------------------------------
-func (q *Queries) GetUserByEmail(ctx context.Context, email string) (CommonUser, error) {
-	row := q.db.QueryRow(ctx, getUserByEmail, email)
-	var i CommonUser
-	err := row.Scan(
-		&i.UserID,
-		&i.UserName,
-		&i.Email,
-		&i.Phone,
-		&i.Pass,
-		&i.PssValid,
-		&i.Otp,
-		&i.OtpValid,
-		&i.OtpExp,
-		&i.Role,
-	)
-	return i, err
+func (q *Queries) CountUsers(ctx context.Context, arg CountUsersParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countUsers, arg.Search, arg.Role, arg.Status)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
 }
 
-const getUserByUserName = `-- name: GetUserByUserName :one
-SELECT user_id, user_name, email, phone, pass, pss_valid, otp, otp_valid, otp_exp, role 
-FROM common.users 
-WHERE user_name = $1
+const createAuditLog = `-- name: CreateAuditLog :exec
+INSERT INTO common.audit_log(user_id, user_name, actor_id, actor_name, impersonated, "action", "method", "path", status_code, detail)
+VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 `
 
-func (q *Queries) GetUserByUserName(ctx context.Context, userName string) (CommonUser, error) {
-	row := q.db.QueryRow(ctx, getUserByUserName, userName)
-	var i CommonUser
-	err := row.Scan(
-		&i.UserID,
-		&i.UserName,
-		&i.Email,
-		&i.Phone,
-		&i.Pass,
-		&i.PssValid,
-		&i.Otp,
-		&i.OtpValid,
-		&i.OtpExp,
-		&i.Role,
+type CreateAuditLogParams struct {
+	UserID       pgtype.Int4 `db:"user_id" json:"user_id"`
+	UserName     pgtype.Text `db:"user_name" json:"user_name"`
+	ActorID      pgtype.Int4 `db:"actor_id" json:"actor_id"`
+	ActorName    pgtype.Text `db:"actor_name" json:"actor_name"`
+	Impersonated bool        `db:"impersonated" json:"impersonated"`
+	Action       string      `db:"action" json:"action"`
+	Method       pgtype.Text `db:"method" json:"method"`
+	Path         pgtype.Text `db:"path" json:"path"`
+	StatusCode   pgtype.Int4 `db:"status_code" json:"status_code"`
+	Detail       []byte      `db:"detail" json:"detail"`
+}
+
+func (q *Queries) CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) error {
+	_, err := q.db.Exec(ctx, createAuditLog,
+		arg.UserID,
+		arg.UserName,
+		arg.ActorID,
+		arg.ActorName,
+		arg.Impersonated,
+		arg.Action,
+		arg.Method,
+		arg.Path,
+		arg.StatusCode,
+		arg.Detail,
 	)
-	return i, err
+	return err
 }
 
-const getUserByPhone = `-- name: GetUserByPhone :one
-SELECT user_id, user_name, email, phone, pass, pss_valid, otp, otp_valid, otp_exp, role 
-FROM common.users 
-WHERE phone = $1
+const createCompany = `-- name: CreateCompany :one
+INSERT INTO common.companies(name)
+VALUES($1)
+RETURNING id, name, created_at
 `
 
-func (q *Queries) GetUserByPhone(ctx context.Context, phone string) (CommonUser, error) {
-	row := q.db.QueryRow(ctx, getUserByPhone, phone)
-	var i CommonUser
+func (q *Queries) CreateCompany(ctx context.Context, name string) (CommonCompany, error) {
+	row := q.db.QueryRow(ctx, createCompany, name)
+	var i CommonCompany
 	err := row.Scan(
-		&i.UserID,
-		&i.UserName,
-		&i.Email,
-		&i.Phone,
-		&i.Pass,
-		&i.PssValid,
-		&i.Otp,
-		&i.OtpValid,
-		&i.OtpExp,
-		&i.Role,
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
 	)
 	return i, err
 }
 
-const getUserById = `-- name: GetUserById :one
-SELECT user_id, user_name, email, phone, pass, pss_valid, otp, otp_valid, otp_exp, role 
-FROM common.users 
-WHERE user_id = $1
+const createLoginCode = `-- name: CreateLoginCode :exec
+INSERT INTO common.login_codes (code_hash, user_id, expires_at)
+VALUES ($1, $2, $3)
 `
 
-func (q *Queries) GetUserById(ctx context.Context, userID int32) (CommonUser, error) {
-	row := q.db.QueryRow(ctx, getUserById, userID)
-	var i CommonUser
-	err := row.Scan(
-		&i.UserID,
-		&i.UserName,
-		&i.Email,
-		&i.Phone,
-		&i.Pass,
-		&i.PssValid,
-		&i.Otp,
-		&i.OtpValid,
-		&i.OtpExp,
-		&i.Role,
-	)
-	return i, err
+type CreateLoginCodeParams struct {
+	CodeHash  string             `db:"code_hash" json:"code_hash"`
+	UserID    int32              `db:"user_id" json:"user_id"`
+	ExpiresAt pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
 }
 
-const getUserByLogin = `-- name: GetUserByLogin :one
-SELECT user_id, user_name, email, phone, pass, pss_valid, otp, otp_valid, otp_exp, role 
-FROM common.users 
-WHERE user_name = $1 OR email = $1 OR phone = $1
+func (q *Queries) CreateLoginCode(ctx context.Context, arg CreateLoginCodeParams) error {
+	_, err := q.db.Exec(ctx, createLoginCode, arg.CodeHash, arg.UserID, arg.ExpiresAt)
+	return err
+}
+
+const createProbeResult = `-- name: CreateProbeResult :exec
+INSERT INTO common.satcom_probe_results(satcom_id, probed_at, check_type, target, success, latency_ms, status_code, error)
+VALUES($1, $2, $3, $4, $5, $6, $7, $8)
 `
 
-func (q *Queries) GetUserByLogin(ctx context.Context, userName string) (CommonUser, error) {
-	row := q.db.QueryRow(ctx, getUserByLogin, userName)
-	var i CommonUser
-	err := row.Scan(
-		&i.UserID,
-		&i.UserName,
-		&i.Email,
-		&i.Phone,
-		&i.Pass,
-		&i.PssValid,
-		&i.Otp,
-		&i.OtpValid,
-		&i.OtpExp,
-		&i.Role,
+type CreateProbeResultParams struct {
+	SatcomID   int32              `db:"satcom_id" json:"satcom_id"`
+	ProbedAt   pgtype.Timestamptz `db:"probed_at" json:"probed_at"`
+	CheckType  string             `db:"check_type" json:"check_type"`
+	Target     string             `db:"target" json:"target"`
+	Success    bool               `db:"success" json:"success"`
+	LatencyMs  pgtype.Int4        `db:"latency_ms" json:"latency_ms"`
+	StatusCode pgtype.Int4        `db:"status_code" json:"status_code"`
+	Error      pgtype.Text        `db:"error" json:"error"`
+}
+
+func (q *Queries) CreateProbeResult(ctx context.Context, arg CreateProbeResultParams) error {
+	_, err := q.db.Exec(ctx, createProbeResult,
+		arg.SatcomID,
+		arg.ProbedAt,
+		arg.CheckType,
+		arg.Target,
+		arg.Success,
+		arg.LatencyMs,
+		arg.StatusCode,
+		arg.Error,
 	)
-	return i, err
+	return err
 }
 
-const updatePassword = `-- name: UpdatePassword :exec
-UPDATE common.users 
-SET pass = $1, pss_valid = $2 
-WHERE email = $3
+const createSatcomAttachment = `-- name: CreateSatcomAttachment :one
+INSERT INTO common.satcom_attachments(satcom_id, object_key, file_name, size_bytes, content_type, description, uploaded_by, uploaded_by_name)
+VALUES($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, satcom_id, object_key, file_name, size_bytes, content_type, description, uploaded_at, uploaded_by, uploaded_by_name
 `
 
-type UpdatePasswordParams struct {
-	Pass     string `db:"pass" json:"pass"`
-	PssValid bool   `db:"pss_valid" json:"pss_valid"`
-	Email    string `db:"email" json:"email"`
+type CreateSatcomAttachmentParams struct {
+	SatcomID       int32       `db:"satcom_id" json:"satcom_id"`
+	ObjectKey      string      `db:"object_key" json:"object_key"`
+	FileName       string      `db:"file_name" json:"file_name"`
+	SizeBytes      int64       `db:"size_bytes" json:"size_bytes"`
+	ContentType    string      `db:"content_type" json:"content_type"`
+	Description    string      `db:"description" json:"description"`
+	UploadedBy     pgtype.Int4 `db:"uploaded_by" json:"uploaded_by"`
+	UploadedByName pgtype.Text `db:"uploaded_by_name" json:"uploaded_by_name"`
 }
 
-func (q *Queries) UpdatePassword(ctx context.Context, arg UpdatePasswordParams) error {
-	_, err := q.db.Exec(ctx, updatePassword, arg.Pass, arg.PssValid, arg.Email)
-	return err
+func (q *Queries) CreateSatcomAttachment(ctx context.Context, arg CreateSatcomAttachmentParams) (CommonSatcomAttachment, error) {
+	row := q.db.QueryRow(ctx, createSatcomAttachment,
+		arg.SatcomID,
+		arg.ObjectKey,
+		arg.FileName,
+		arg.SizeBytes,
+		arg.ContentType,
+		arg.Description,
+		arg.UploadedBy,
+		arg.UploadedByName,
+	)
+	var i CommonSatcomAttachment
+	err := row.Scan(
+		&i.ID,
+		&i.SatcomID,
+		&i.ObjectKey,
+		&i.FileName,
+		&i.SizeBytes,
+		&i.ContentType,
+		&i.Description,
+		&i.UploadedAt,
+		&i.UploadedBy,
+		&i.UploadedByName,
+	)
+	return i, err
 }
 
-const updateUser = `-- name: UpdateUser :exec
-UPDATE common.users 
-SET user_name = $1, email = $2, phone = $3, role = $4
-WHERE user_id = $5
+const createSatcomChangeRequest = `-- name: CreateSatcomChangeRequest :one
+INSERT INTO common.satcom_change_requests(satcom_id, company, operation, base, proposed, allow_conflicts, "comment", requested_by, requested_by_name, requested_actor_id)
+VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING id, satcom_id, company, operation, status, base, proposed, allow_conflicts, "comment", requested_at, requested_by, requested_by_name, reviewed_at, reviewed_by, reviewed_by_name, review_comment, requested_actor_id
 `
 
-type UpdateUserParams struct {
-	UserName string `db:"user_name" json:"user_name"`
-	Email    string `db:"email" json:"email"`
-	Phone    string `db:"phone" json:"phone"`
-	Role     string `db:"role" json:"role"`
-	UserID   int32  `db:"user_id" json:"user_id"`
+type CreateSatcomChangeRequestParams struct {
+	SatcomID         int32       `db:"satcom_id" json:"satcom_id"`
+	Company          string      `db:"company" json:"company"`
+	Operation        string      `db:"operation" json:"operation"`
+	Base             []byte      `db:"base" json:"base"`
+	Proposed         []byte      `db:"proposed" json:"proposed"`
+	AllowConflicts   bool        `db:"allow_conflicts" json:"allow_conflicts"`
+	Comment          string      `db:"comment" json:"comment"`
+	RequestedBy      pgtype.Int4 `db:"requested_by" json:"requested_by"`
+	RequestedByName  pgtype.Text `db:"requested_by_name" json:"requested_by_name"`
+	RequestedActorID pgtype.Int4 `db:"requested_actor_id" json:"requested_actor_id"`
 }
 
-func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) error {
-	_, err := q.db.Exec(ctx, updateUser,
-		arg.UserName,
-		arg.Email,
-		arg.Phone,
-		arg.Role,
-		arg.UserID,
+func (q *Queries) CreateSatcomChangeRequest(ctx context.Context, arg CreateSatcomChangeRequestParams) (CommonSatcomChangeRequest, error) {
+	row := q.db.QueryRow(ctx, createSatcomChangeRequest,
+		arg.SatcomID,
+		arg.Company,
+		arg.Operation,
+		arg.Base,
+		arg.Proposed,
+		arg.AllowConflicts,
+		arg.Comment,
+		arg.RequestedBy,
+		arg.RequestedByName,
+		arg.RequestedActorID,
 	)
-	return err
+	var i CommonSatcomChangeRequest
+	err := row.Scan(
+		&i.ID,
+		&i.SatcomID,
+		&i.Company,
+		&i.Operation,
+		&i.Status,
+		&i.Base,
+		&i.Proposed,
+		&i.AllowConflicts,
+		&i.Comment,
+		&i.RequestedAt,
+		&i.RequestedBy,
+		&i.RequestedByName,
+		&i.ReviewedAt,
+		&i.ReviewedBy,
+		&i.ReviewedByName,
+		&i.ReviewComment,
+		&i.RequestedActorID,
+	)
+	return i, err
 }
 
-const updateSatcomData = `-- name: UpdateSatcomData :exec
-UPDATE common.satcom_data
-SET company = $1, category = $2, "type" = $3, "date" = $4, "time" = $5, 
-    db_port = $6, ui_port = $7, url = $8, ip = $9, status = $10
-WHERE id = $11
+const createSatcomData = `-- name: CreateSatcomData :one
+INSERT INTO common.satcom_data(company, category, "type", recorded_at, db_port, ui_port, url, ip, status, name, labels, custom_fields)
+VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, COALESCE($11::jsonb, '{}'), COALESCE($12::jsonb, '{}'))
+RETURNING id
 `
 
-type UpdateSatcomDataParams struct {
-	Company  string `db:"company" json:"company"`
-	Category string `db:"category" json:"category"`
-	Type     string `db:"type" json:"type"`
-	Date     string `db:"date" json:"date"`
-	Time     string `db:"time" json:"time"`
-	DbPort   string `db:"db_port" json:"db_port"`
-	UiPort   string `db:"ui_port" json:"ui_port"`
-	Url      string `db:"url" json:"url"`
-	Ip       string `db:"ip" json:"ip"`
-	Status   bool   `db:"status" json:"status"`
-	ID       int32  `db:"id" json:"id"`
-}
-
-func (q *Queries) UpdateSatcomData(ctx context.Context, arg UpdateSatcomDataParams) error {
-	_, err := q.db.Exec(ctx, updateSatcomData,
+type CreateSatcomDataParams struct {
+	Company      string             `db:"company" json:"company"`
+	Category     string             `db:"category" json:"category"`
+	Type         string             `db:"type" json:"type"`
+	RecordedAt   pgtype.Timestamptz `db:"recorded_at" json:"recorded_at"`
+	DbPort       pgtype.Int4        `db:"db_port" json:"db_port"`
+	UiPort       pgtype.Int4        `db:"ui_port" json:"ui_port"`
+	Url          string             `db:"url" json:"url"`
+	Ip           *netip.Addr        `db:"ip" json:"ip"`
+	Status       bool               `db:"status" json:"status"`
+	Name         pgtype.Text        `db:"name" json:"name"`
+	Labels       json.RawMessage    `db:"labels" json:"labels"`
+	CustomFields json.RawMessage    `db:"custom_fields" json:"custom_fields"`
+}
+
+func (q *Queries) CreateSatcomData(ctx context.Context, arg CreateSatcomDataParams) (int32, error) {
+	row := q.db.QueryRow(ctx, createSatcomData,
 		arg.Company,
 		arg.Category,
 		arg.Type,
-		arg.Date,
-		arg.Time,
+		arg.RecordedAt,
 		arg.DbPort,
 		arg.UiPort,
 		arg.Url,
 		arg.Ip,
 		arg.Status,
-		arg.ID,
+		arg.Name,
+		arg.Labels,
+		arg.CustomFields,
 	)
-	return err
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createSatcomHistory = `-- name: CreateSatcomHistory :exec
+INSERT INTO common.satcom_history(satcom_id, version, operation, changed_by, changed_by_name, actor_id, actor_name, restored_from, snapshot, company_id)
+SELECT s.id, s.version, $2, $3, $4, $5, $6, $7, to_jsonb(s), c.id
+FROM common.satcom_data s
+LEFT JOIN common.companies c ON c.name = s.company
+WHERE s.id = $1
+`
+
+type CreateSatcomHistoryParams struct {
+	SatcomID      int32       `db:"satcom_id" json:"satcom_id"`
+	Operation     string      `db:"operation" json:"operation"`
+	ChangedBy     pgtype.Int4 `db:"changed_by" json:"changed_by"`
+	ChangedByName pgtype.Text `db:"changed_by_name" json:"changed_by_name"`
+	ActorID       pgtype.Int4 `db:"actor_id" json:"actor_id"`
+	ActorName     pgtype.Text `db:"actor_name" json:"actor_name"`
+	RestoredFrom  pgtype.Int4 `db:"restored_from" json:"restored_from"`
+}
+
+func (q *Queries) CreateSatcomHistory(ctx context.Context, arg CreateSatcomHistoryParams) error {
+	_, err := q.db.Exec(ctx, createSatcomHistory,
+		arg.SatcomID,
+		arg.Operation,
+		arg.ChangedBy,
+		arg.ChangedByName,
+		arg.ActorID,
+		arg.ActorName,
+		arg.RestoredFrom,
+	)
+	return err
+}
+
+const createSatcomMaintenanceWindow = `-- name: CreateSatcomMaintenanceWindow :one
+INSERT INTO common.satcom_maintenance_windows(company, satcom_id, label_selector, starts_at, ends_at, recurrence, recur_until, timezone, reason, created_by)
+VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING id, company, satcom_id, label_selector, starts_at, ends_at, recurrence, recur_until, timezone, reason, created_at, created_by
+`
+
+type CreateSatcomMaintenanceWindowParams struct {
+	Company       string             `db:"company" json:"company"`
+	SatcomID      pgtype.Int4        `db:"satcom_id" json:"satcom_id"`
+	LabelSelector pgtype.Text        `db:"label_selector" json:"label_selector"`
+	StartsAt      pgtype.Timestamptz `db:"starts_at" json:"starts_at"`
+	EndsAt        pgtype.Timestamptz `db:"ends_at" json:"ends_at"`
+	Recurrence    pgtype.Text        `db:"recurrence" json:"recurrence"`
+	RecurUntil    pgtype.Timestamptz `db:"recur_until" json:"recur_until"`
+	Timezone      string             `db:"timezone" json:"timezone"`
+	Reason        string             `db:"reason" json:"reason"`
+	CreatedBy     pgtype.Int4        `db:"created_by" json:"created_by"`
+}
+
+func (q *Queries) CreateSatcomMaintenanceWindow(ctx context.Context, arg CreateSatcomMaintenanceWindowParams) (CommonSatcomMaintenanceWindow, error) {
+	row := q.db.QueryRow(ctx, createSatcomMaintenanceWindow,
+		arg.Company,
+		arg.SatcomID,
+		arg.LabelSelector,
+		arg.StartsAt,
+		arg.EndsAt,
+		arg.Recurrence,
+		arg.RecurUntil,
+		arg.Timezone,
+		arg.Reason,
+		arg.CreatedBy,
+	)
+	var i CommonSatcomMaintenanceWindow
+	err := row.Scan(
+		&i.ID,
+		&i.Company,
+		&i.SatcomID,
+		&i.LabelSelector,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.Recurrence,
+		&i.RecurUntil,
+		&i.Timezone,
+		&i.Reason,
+		&i.CreatedAt,
+		&i.CreatedBy,
+	)
+	return i, err
+}
+
+const createSatcomNote = `-- name: CreateSatcomNote :one
+INSERT INTO common.satcom_notes(satcom_id, parent_id, body, mentions, created_by, created_by_name)
+VALUES($1, $2, $3, $4, $5, $6)
+RETURNING id, satcom_id, parent_id, body, mentions, pinned, resolved_at, resolved_by, resolved_by_name, created_at, created_by, created_by_name, updated_at
+`
+
+type CreateSatcomNoteParams struct {
+	SatcomID      int32       `db:"satcom_id" json:"satcom_id"`
+	ParentID      pgtype.Int4 `db:"parent_id" json:"parent_id"`
+	Body          string      `db:"body" json:"body"`
+	Mentions      []int32     `db:"mentions" json:"mentions"`
+	CreatedBy     pgtype.Int4 `db:"created_by" json:"created_by"`
+	CreatedByName pgtype.Text `db:"created_by_name" json:"created_by_name"`
+}
+
+func (q *Queries) CreateSatcomNote(ctx context.Context, arg CreateSatcomNoteParams) (CommonSatcomNote, error) {
+	row := q.db.QueryRow(ctx, createSatcomNote,
+		arg.SatcomID,
+		arg.ParentID,
+		arg.Body,
+		arg.Mentions,
+		arg.CreatedBy,
+		arg.CreatedByName,
+	)
+	var i CommonSatcomNote
+	err := row.Scan(
+		&i.ID,
+		&i.SatcomID,
+		&i.ParentID,
+		&i.Body,
+		&i.Mentions,
+		&i.Pinned,
+		&i.ResolvedAt,
+		&i.ResolvedBy,
+		&i.ResolvedByName,
+		&i.CreatedAt,
+		&i.CreatedBy,
+		&i.CreatedByName,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createSatcomRelation = `-- name: CreateSatcomRelation :one
+INSERT INTO common.satcom_relations(source_id, target_id, relation, created_by)
+VALUES($1, $2, $3, $4)
+RETURNING id, source_id, target_id, relation, created_at, created_by
+`
+
+type CreateSatcomRelationParams struct {
+	SourceID  int32       `db:"source_id" json:"source_id"`
+	TargetID  int32       `db:"target_id" json:"target_id"`
+	Relation  string      `db:"relation" json:"relation"`
+	CreatedBy pgtype.Int4 `db:"created_by" json:"created_by"`
+}
+
+func (q *Queries) CreateSatcomRelation(ctx context.Context, arg CreateSatcomRelationParams) (CommonSatcomRelation, error) {
+	row := q.db.QueryRow(ctx, createSatcomRelation,
+		arg.SourceID,
+		arg.TargetID,
+		arg.Relation,
+		arg.CreatedBy,
+	)
+	var i CommonSatcomRelation
+	err := row.Scan(
+		&i.ID,
+		&i.SourceID,
+		&i.TargetID,
+		&i.Relation,
+		&i.CreatedAt,
+		&i.CreatedBy,
+	)
+	return i, err
+}
+
+const createSatcomSecret = `-- name: CreateSatcomSecret :one
+INSERT INTO common.satcom_secrets(satcom_id, name, username, description, rotation_days, created_by)
+VALUES($1, $2, $3, $4, $5, $6)
+RETURNING id, satcom_id, name, username, description, version, rotation_days, rotated_at, reminded_at, created_at, created_by
+`
+
+type CreateSatcomSecretParams struct {
+	SatcomID     int32       `db:"satcom_id" json:"satcom_id"`
+	Name         string      `db:"name" json:"name"`
+	Username     string      `db:"username" json:"username"`
+	Description  string      `db:"description" json:"description"`
+	RotationDays pgtype.Int4 `db:"rotation_days" json:"rotation_days"`
+	CreatedBy    pgtype.Int4 `db:"created_by" json:"created_by"`
+}
+
+func (q *Queries) CreateSatcomSecret(ctx context.Context, arg CreateSatcomSecretParams) (CommonSatcomSecret, error) {
+	row := q.db.QueryRow(ctx, createSatcomSecret,
+		arg.SatcomID,
+		arg.Name,
+		arg.Username,
+		arg.Description,
+		arg.RotationDays,
+		arg.CreatedBy,
+	)
+	var i CommonSatcomSecret
+	err := row.Scan(
+		&i.ID,
+		&i.SatcomID,
+		&i.Name,
+		&i.Username,
+		&i.Description,
+		&i.Version,
+		&i.RotationDays,
+		&i.RotatedAt,
+		&i.RemindedAt,
+		&i.CreatedAt,
+		&i.CreatedBy,
+	)
+	return i, err
+}
+
+const createSatcomSecretVersion = `-- name: CreateSatcomSecretVersion :exec
+INSERT INTO common.satcom_secret_versions(secret_id, version, ciphertext, nonce, data_key, key_nonce, key_id, created_by)
+VALUES($1, $2, $3, $4, $5, $6, $7, $8)
+`
+
+type CreateSatcomSecretVersionParams struct {
+	SecretID   int32       `db:"secret_id" json:"secret_id"`
+	Version    int32       `db:"version" json:"version"`
+	Ciphertext []byte      `db:"ciphertext" json:"ciphertext"`
+	Nonce      []byte      `db:"nonce" json:"nonce"`
+	DataKey    []byte      `db:"data_key" json:"data_key"`
+	KeyNonce   []byte      `db:"key_nonce" json:"key_nonce"`
+	KeyID      string      `db:"key_id" json:"key_id"`
+	CreatedBy  pgtype.Int4 `db:"created_by" json:"created_by"`
+}
+
+func (q *Queries) CreateSatcomSecretVersion(ctx context.Context, arg CreateSatcomSecretVersionParams) error {
+	_, err := q.db.Exec(ctx, createSatcomSecretVersion,
+		arg.SecretID,
+		arg.Version,
+		arg.Ciphertext,
+		arg.Nonce,
+		arg.DataKey,
+		arg.KeyNonce,
+		arg.KeyID,
+		arg.CreatedBy,
+	)
+	return err
+}
+
+const createUser = `-- name: CreateUser :exec
+INSERT INTO common.users(user_name, email, phone, pass, role) 
+VALUES($1, $2, $3, $4, $5)
+`
+
+type CreateUserParams struct {
+	UserName string `db:"user_name" json:"user_name"`
+	Email    string `db:"email" json:"email"`
+	Phone    string `db:"phone" json:"phone"`
+	Pass     string `db:"pass" json:"pass"`
+	Role     string `db:"role" json:"role"`
+}
+
+func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) error {
+	_, err := q.db.Exec(ctx, createUser,
+		arg.UserName,
+		arg.Email,
+		arg.Phone,
+		arg.Pass,
+		arg.Role,
+	)
+	return err
+}
+
+const createUserIdentity = `-- name: CreateUserIdentity :exec
+INSERT INTO common.user_identities (provider, subject, user_id)
+VALUES ($1, $2, $3)
+ON CONFLICT (provider, subject) DO UPDATE SET user_id = EXCLUDED.user_id, created_at = now()
+`
+
+type CreateUserIdentityParams struct {
+	Provider string `db:"provider" json:"provider"`
+	Subject  string `db:"subject" json:"subject"`
+	UserID   int32  `db:"user_id" json:"user_id"`
+}
+
+func (q *Queries) CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) error {
+	_, err := q.db.Exec(ctx, createUserIdentity, arg.Provider, arg.Subject, arg.UserID)
+	return err
+}
+
+const deleteCompanyMembership = `-- name: DeleteCompanyMembership :execrows
+DELETE FROM common.company_memberships
+WHERE user_id = $1 AND company_id = $2
+`
+
+type DeleteCompanyMembershipParams struct {
+	UserID    int32 `db:"user_id" json:"user_id"`
+	CompanyID int32 `db:"company_id" json:"company_id"`
+}
+
+func (q *Queries) DeleteCompanyMembership(ctx context.Context, arg DeleteCompanyMembershipParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteCompanyMembership, arg.UserID, arg.CompanyID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteExpiredLoginCodes = `-- name: DeleteExpiredLoginCodes :exec
+DELETE FROM common.login_codes
+WHERE expires_at <= now()
+`
+
+func (q *Queries) DeleteExpiredLoginCodes(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, deleteExpiredLoginCodes)
+	return err
+}
+
+const deleteProbeResultsBefore = `-- name: DeleteProbeResultsBefore :execrows
+DELETE FROM common.satcom_probe_results
+WHERE probed_at < $1
+`
+
+func (q *Queries) DeleteProbeResultsBefore(ctx context.Context, probedAt pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteProbeResultsBefore, probedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteSatcomAttachment = `-- name: DeleteSatcomAttachment :execrows
+DELETE FROM common.satcom_attachments
+WHERE id = $1 AND satcom_id = $2
+`
+
+type DeleteSatcomAttachmentParams struct {
+	ID       int32 `db:"id" json:"id"`
+	SatcomID int32 `db:"satcom_id" json:"satcom_id"`
+}
+
+func (q *Queries) DeleteSatcomAttachment(ctx context.Context, arg DeleteSatcomAttachmentParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteSatcomAttachment, arg.ID, arg.SatcomID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteSatcomAttachmentCleanup = `-- name: DeleteSatcomAttachmentCleanup :exec
+DELETE FROM common.satcom_attachment_cleanups
+WHERE satcom_id = $1
+`
+
+func (q *Queries) DeleteSatcomAttachmentCleanup(ctx context.Context, satcomID int32) error {
+	_, err := q.db.Exec(ctx, deleteSatcomAttachmentCleanup, satcomID)
+	return err
+}
+
+const deleteSatcomConversionIssues = `-- name: DeleteSatcomConversionIssues :exec
+DELETE FROM common.satcom_conversion_issues
+WHERE satcom_id = $1
+`
+
+func (q *Queries) DeleteSatcomConversionIssues(ctx context.Context, satcomID int32) error {
+	_, err := q.db.Exec(ctx, deleteSatcomConversionIssues, satcomID)
+	return err
+}
+
+const deleteSatcomMaintenanceWindow = `-- name: DeleteSatcomMaintenanceWindow :execrows
+DELETE FROM common.satcom_maintenance_windows
+WHERE id = $1
+`
+
+func (q *Queries) DeleteSatcomMaintenanceWindow(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteSatcomMaintenanceWindow, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteSatcomNote = `-- name: DeleteSatcomNote :execrows
+DELETE FROM common.satcom_notes
+WHERE id = $1 AND satcom_id = $2
+`
+
+type DeleteSatcomNoteParams struct {
+	ID       int32 `db:"id" json:"id"`
+	SatcomID int32 `db:"satcom_id" json:"satcom_id"`
+}
+
+func (q *Queries) DeleteSatcomNote(ctx context.Context, arg DeleteSatcomNoteParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteSatcomNote, arg.ID, arg.SatcomID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteSatcomRelation = `-- name: DeleteSatcomRelation :execrows
+DELETE FROM common.satcom_relations
+WHERE id = $1
+`
+
+func (q *Queries) DeleteSatcomRelation(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteSatcomRelation, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteSatcomSecret = `-- name: DeleteSatcomSecret :execrows
+DELETE FROM common.satcom_secrets
+WHERE id = $1 AND satcom_id = $2
+`
+
+type DeleteSatcomSecretParams struct {
+	ID       int32 `db:"id" json:"id"`
+	SatcomID int32 `db:"satcom_id" json:"satcom_id"`
+}
+
+func (q *Queries) DeleteSatcomSecret(ctx context.Context, arg DeleteSatcomSecretParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteSatcomSecret, arg.ID, arg.SatcomID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteUser = `-- name: DeleteUser :exec
+DELETE FROM common.users
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, userID int32) error {
+	_, err := q.db.Exec(ctx, deleteUser, userID)
+	return err
+}
+
+const deleteUserCompanyMemberships = `-- name: DeleteUserCompanyMemberships :exec
+DELETE FROM common.company_memberships
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserCompanyMemberships(ctx context.Context, userID int32) error {
+	_, err := q.db.Exec(ctx, deleteUserCompanyMemberships, userID)
+	return err
+}
+
+const deleteUserIdentities = `-- name: DeleteUserIdentities :exec
+DELETE FROM common.user_identities
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserIdentities(ctx context.Context, userID int32) error {
+	_, err := q.db.Exec(ctx, deleteUserIdentities, userID)
+	return err
+}
+
+const findSatcomConflicts = `-- name: FindSatcomConflicts :many
+SELECT id, company, url, ip, db_port, ui_port
+FROM common.satcom_data
+WHERE deleted_at IS NULL
+    AND ($1::int IS NULL OR id <> $1)
+    AND ((ip = $2::inet
+            AND (db_port IN ($3::int, $4::int)
+                OR ui_port IN ($3::int, $4::int)))
+        OR lower(rtrim(url, '/')) = lower(rtrim($5::text, '/')))
+ORDER BY company <> $6::text, id
+`
+
+type FindSatcomConflictsParams struct {
+	ExcludeID pgtype.Int4 `db:"exclude_id" json:"exclude_id"`
+	Ip        *netip.Addr `db:"ip" json:"ip"`
+	DbPort    pgtype.Int4 `db:"db_port" json:"db_port"`
+	UiPort    pgtype.Int4 `db:"ui_port" json:"ui_port"`
+	Url       string      `db:"url" json:"url"`
+	Company   string      `db:"company" json:"company"`
+}
+
+type FindSatcomConflictsRow struct {
+	ID      int32       `db:"id" json:"id"`
+	Company string      `db:"company" json:"company"`
+	Url     string      `db:"url" json:"url"`
+	Ip      *netip.Addr `db:"ip" json:"ip"`
+	DbPort  pgtype.Int4 `db:"db_port" json:"db_port"`
+	UiPort  pgtype.Int4 `db:"ui_port" json:"ui_port"`
+}
+
+func (q *Queries) FindSatcomConflicts(ctx context.Context, arg FindSatcomConflictsParams) ([]FindSatcomConflictsRow, error) {
+	rows, err := q.db.Query(ctx, findSatcomConflicts,
+		arg.ExcludeID,
+		arg.Ip,
+		arg.DbPort,
+		arg.UiPort,
+		arg.Url,
+		arg.Company,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FindSatcomConflictsRow
+	for rows.Next() {
+		var i FindSatcomConflictsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Company,
+			&i.Url,
+			&i.Ip,
+			&i.DbPort,
+			&i.UiPort,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getActiveUserEmailsByRole = `-- name: GetActiveUserEmailsByRole :many
+SELECT email
+FROM common.users
+WHERE role = $1 AND status = 'ACTIVE'
+ORDER BY email
+`
+
+func (q *Queries) GetActiveUserEmailsByRole(ctx context.Context, role string) ([]string, error) {
+	rows, err := q.db.Query(ctx, getActiveUserEmailsByRole, role)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var email string
+		if err := rows.Scan(&email); err != nil {
+			return nil, err
+		}
+		items = append(items, email)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAllSatcomData = `-- name: GetAllSatcomData :many
+SELECT id, company, category, "type", recorded_at, db_port, ui_port, url, ip, status, status_override, last_probed_at, version, deleted_at, deleted_by, name, labels, custom_fields
+FROM common.satcom_data
+WHERE deleted_at IS NULL
+ORDER BY id
+`
+
+func (q *Queries) GetAllSatcomData(ctx context.Context) ([]CommonSatcomDatum, error) {
+	rows, err := q.db.Query(ctx, getAllSatcomData)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommonSatcomDatum
+	for rows.Next() {
+		var i CommonSatcomDatum
+		if err := rows.Scan(
+			&i.ID,
+			&i.Company,
+			&i.Category,
+			&i.Type,
+			&i.RecordedAt,
+			&i.DbPort,
+			&i.UiPort,
+			&i.Url,
+			&i.Ip,
+			&i.Status,
+			&i.StatusOverride,
+			&i.LastProbedAt,
+			&i.Version,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.Name,
+			&i.Labels,
+			&i.CustomFields,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAllUsers = `-- name: GetAllUsers :many
+SELECT user_id, user_name, email 
+FROM common.users 
+ORDER BY user_id
+`
+
+type GetAllUsersRow struct {
+	UserID   int32  `db:"user_id" json:"user_id"`
+	UserName string `db:"user_name" json:"user_name"`
+	Email    string `db:"email" json:"email"`
+}
+
+func (q *Queries) GetAllUsers(ctx context.Context) ([]GetAllUsersRow, error) {
+	rows, err := q.db.Query(ctx, getAllUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetAllUsersRow
+	for rows.Next() {
+		var i GetAllUsersRow
+		if err := rows.Scan(&i.UserID, &i.UserName, &i.Email); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCompanyById = `-- name: GetCompanyById :one
+SELECT id, name, created_at
+FROM common.companies
+WHERE id = $1
+`
+
+func (q *Queries) GetCompanyById(ctx context.Context, id int32) (CommonCompany, error) {
+	row := q.db.QueryRow(ctx, getCompanyById, id)
+	var i CommonCompany
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getCompanyByName = `-- name: GetCompanyByName :one
+SELECT id, name, created_at
+FROM common.companies
+WHERE name = $1
+`
+
+func (q *Queries) GetCompanyByName(ctx context.Context, name string) (CommonCompany, error) {
+	row := q.db.QueryRow(ctx, getCompanyByName, name)
+	var i CommonCompany
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getCompanyMembership = `-- name: GetCompanyMembership :one
+SELECT m.company_id, c.name AS company_name, m."role"
+FROM common.company_memberships m
+JOIN common.companies c ON c.id = m.company_id
+WHERE m.user_id = $1 AND m.company_id = $2
+`
+
+type GetCompanyMembershipParams struct {
+	UserID    int32 `db:"user_id" json:"user_id"`
+	CompanyID int32 `db:"company_id" json:"company_id"`
+}
+
+type GetCompanyMembershipRow struct {
+	CompanyID   int32  `db:"company_id" json:"company_id"`
+	CompanyName string `db:"company_name" json:"company_name"`
+	Role        string `db:"role" json:"role"`
+}
+
+func (q *Queries) GetCompanyMembership(ctx context.Context, arg GetCompanyMembershipParams) (GetCompanyMembershipRow, error) {
+	row := q.db.QueryRow(ctx, getCompanyMembership, arg.UserID, arg.CompanyID)
+	var i GetCompanyMembershipRow
+	err := row.Scan(
+		&i.CompanyID,
+		&i.CompanyName,
+		&i.Role,
+	)
+	return i, err
+}
+
+const getDeletedSatcomDataById = `-- name: GetDeletedSatcomDataById :one
+SELECT id, company, category, "type", recorded_at, db_port, ui_port, url, ip, status, status_override, last_probed_at, version, deleted_at, deleted_by, name, labels, custom_fields
+FROM common.satcom_data
+WHERE id = $1 AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) GetDeletedSatcomDataById(ctx context.Context, id int32) (CommonSatcomDatum, error) {
+	row := q.db.QueryRow(ctx, getDeletedSatcomDataById, id)
+	var i CommonSatcomDatum
+	err := row.Scan(
+		&i.ID,
+		&i.Company,
+		&i.Category,
+		&i.Type,
+		&i.RecordedAt,
+		&i.DbPort,
+		&i.UiPort,
+		&i.Url,
+		&i.Ip,
+		&i.Status,
+		&i.StatusOverride,
+		&i.LastProbedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.Name,
+		&i.Labels,
+		&i.CustomFields,
+	)
+	return i, err
+}
+
+const getLatestSatcomCommitSeq = `-- name: GetLatestSatcomCommitSeq :one
+SELECT COALESCE(max(commit_seq), 0)::bigint AS latest_seq
+FROM common.satcom_history
+`
+
+func (q *Queries) GetLatestSatcomCommitSeq(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, getLatestSatcomCommitSeq)
+	var latestSeq int64
+	err := row.Scan(&latestSeq)
+	return latestSeq, err
+}
+
+const getPendingSatcomChangeRequest = `-- name: GetPendingSatcomChangeRequest :one
+SELECT id, satcom_id, company, operation, status, base, proposed, allow_conflicts, "comment", requested_at, requested_by, requested_by_name, reviewed_at, reviewed_by, reviewed_by_name, review_comment, requested_actor_id
+FROM common.satcom_change_requests
+WHERE satcom_id = $1 AND status = 'PENDING_APPROVAL'
+`
+
+func (q *Queries) GetPendingSatcomChangeRequest(ctx context.Context, satcomID int32) (CommonSatcomChangeRequest, error) {
+	row := q.db.QueryRow(ctx, getPendingSatcomChangeRequest, satcomID)
+	var i CommonSatcomChangeRequest
+	err := row.Scan(
+		&i.ID,
+		&i.SatcomID,
+		&i.Company,
+		&i.Operation,
+		&i.Status,
+		&i.Base,
+		&i.Proposed,
+		&i.AllowConflicts,
+		&i.Comment,
+		&i.RequestedAt,
+		&i.RequestedBy,
+		&i.RequestedByName,
+		&i.ReviewedAt,
+		&i.ReviewedBy,
+		&i.ReviewedByName,
+		&i.ReviewComment,
+		&i.RequestedActorID,
+	)
+	return i, err
+}
+
+const getSatcomAttachment = `-- name: GetSatcomAttachment :one
+SELECT id, satcom_id, object_key, file_name, size_bytes, content_type, description, uploaded_at, uploaded_by, uploaded_by_name
+FROM common.satcom_attachments
+WHERE id = $1 AND satcom_id = $2
+`
+
+type GetSatcomAttachmentParams struct {
+	ID       int32 `db:"id" json:"id"`
+	SatcomID int32 `db:"satcom_id" json:"satcom_id"`
+}
+
+func (q *Queries) GetSatcomAttachment(ctx context.Context, arg GetSatcomAttachmentParams) (CommonSatcomAttachment, error) {
+	row := q.db.QueryRow(ctx, getSatcomAttachment, arg.ID, arg.SatcomID)
+	var i CommonSatcomAttachment
+	err := row.Scan(
+		&i.ID,
+		&i.SatcomID,
+		&i.ObjectKey,
+		&i.FileName,
+		&i.SizeBytes,
+		&i.ContentType,
+		&i.Description,
+		&i.UploadedAt,
+		&i.UploadedBy,
+		&i.UploadedByName,
+	)
+	return i, err
+}
+
+const getSatcomCertificate = `-- name: GetSatcomCertificate :one
+SELECT satcom_id, host, checked_at, subject, issuer, sans, serial_number, not_before, not_after, hostname_match, chain_valid, error, last_warning_days
+FROM common.satcom_certificates
+WHERE satcom_id = $1
+`
+
+func (q *Queries) GetSatcomCertificate(ctx context.Context, satcomID int32) (CommonSatcomCertificate, error) {
+	row := q.db.QueryRow(ctx, getSatcomCertificate, satcomID)
+	var i CommonSatcomCertificate
+	err := row.Scan(
+		&i.SatcomID,
+		&i.Host,
+		&i.CheckedAt,
+		&i.Subject,
+		&i.Issuer,
+		&i.Sans,
+		&i.SerialNumber,
+		&i.NotBefore,
+		&i.NotAfter,
+		&i.HostnameMatch,
+		&i.ChainValid,
+		&i.Error,
+		&i.LastWarningDays,
+	)
+	return i, err
+}
+
+const getSatcomChangeRequest = `-- name: GetSatcomChangeRequest :one
+SELECT id, satcom_id, company, operation, status, base, proposed, allow_conflicts, "comment", requested_at, requested_by, requested_by_name, reviewed_at, reviewed_by, reviewed_by_name, review_comment, requested_actor_id
+FROM common.satcom_change_requests
+WHERE id = $1
+`
+
+func (q *Queries) GetSatcomChangeRequest(ctx context.Context, id int32) (CommonSatcomChangeRequest, error) {
+	row := q.db.QueryRow(ctx, getSatcomChangeRequest, id)
+	var i CommonSatcomChangeRequest
+	err := row.Scan(
+		&i.ID,
+		&i.SatcomID,
+		&i.Company,
+		&i.Operation,
+		&i.Status,
+		&i.Base,
+		&i.Proposed,
+		&i.AllowConflicts,
+		&i.Comment,
+		&i.RequestedAt,
+		&i.RequestedBy,
+		&i.RequestedByName,
+		&i.ReviewedAt,
+		&i.ReviewedBy,
+		&i.ReviewedByName,
+		&i.ReviewComment,
+		&i.RequestedActorID,
+	)
+	return i, err
+}
+
+const getSatcomCompanyById = `-- name: GetSatcomCompanyById :one
+SELECT company
+FROM common.satcom_data
+WHERE id = $1
+`
+
+func (q *Queries) GetSatcomCompanyById(ctx context.Context, id int32) (string, error) {
+	row := q.db.QueryRow(ctx, getSatcomCompanyById, id)
+	var company string
+	err := row.Scan(&company)
+	return company, err
+}
+
+const getSatcomDataById = `-- name: GetSatcomDataById :one
+SELECT id, company, category, "type", recorded_at, db_port, ui_port, url, ip, status, status_override, last_probed_at, version, deleted_at, deleted_by, name, labels, custom_fields
+FROM common.satcom_data
+WHERE id = $1 AND deleted_at IS NULL
+`
+
+func (q *Queries) GetSatcomDataById(ctx context.Context, id int32) (CommonSatcomDatum, error) {
+	row := q.db.QueryRow(ctx, getSatcomDataById, id)
+	var i CommonSatcomDatum
+	err := row.Scan(
+		&i.ID,
+		&i.Company,
+		&i.Category,
+		&i.Type,
+		&i.RecordedAt,
+		&i.DbPort,
+		&i.UiPort,
+		&i.Url,
+		&i.Ip,
+		&i.Status,
+		&i.StatusOverride,
+		&i.LastProbedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.Name,
+		&i.Labels,
+		&i.CustomFields,
+	)
+	return i, err
+}
+
+const getSatcomHistoryById = `-- name: GetSatcomHistoryById :one
+SELECT id, satcom_id, version, operation, changed_at, changed_by, changed_by_name, actor_id, actor_name, restored_from, snapshot, commit_seq, company_id
+FROM common.satcom_history
+WHERE id = $1
+`
+
+func (q *Queries) GetSatcomHistoryById(ctx context.Context, id int64) (CommonSatcomHistory, error) {
+	row := q.db.QueryRow(ctx, getSatcomHistoryById, id)
+	var i CommonSatcomHistory
+	err := row.Scan(
+		&i.ID,
+		&i.SatcomID,
+		&i.Version,
+		&i.Operation,
+		&i.ChangedAt,
+		&i.ChangedBy,
+		&i.ChangedByName,
+		&i.ActorID,
+		&i.ActorName,
+		&i.RestoredFrom,
+		&i.Snapshot,
+		&i.CommitSeq,
+		&i.CompanyID,
+	)
+	return i, err
+}
+
+const getSatcomHistoryVersion = `-- name: GetSatcomHistoryVersion :one
+SELECT id, satcom_id, version, operation, changed_at, changed_by, changed_by_name, actor_id, actor_name, restored_from, snapshot, commit_seq, company_id
+FROM common.satcom_history
+WHERE satcom_id = $1 AND version = $2
+`
+
+type GetSatcomHistoryVersionParams struct {
+	SatcomID int32 `db:"satcom_id" json:"satcom_id"`
+	Version  int32 `db:"version" json:"version"`
+}
+
+func (q *Queries) GetSatcomHistoryVersion(ctx context.Context, arg GetSatcomHistoryVersionParams) (CommonSatcomHistory, error) {
+	row := q.db.QueryRow(ctx, getSatcomHistoryVersion, arg.SatcomID, arg.Version)
+	var i CommonSatcomHistory
+	err := row.Scan(
+		&i.ID,
+		&i.SatcomID,
+		&i.Version,
+		&i.Operation,
+		&i.ChangedAt,
+		&i.ChangedBy,
+		&i.ChangedByName,
+		&i.ActorID,
+		&i.ActorName,
+		&i.RestoredFrom,
+		&i.Snapshot,
+		&i.CommitSeq,
+		&i.CompanyID,
+	)
+	return i, err
+}
+
+const getSatcomMaintenanceWindow = `-- name: GetSatcomMaintenanceWindow :one
+SELECT id, company, satcom_id, label_selector, starts_at, ends_at, recurrence, recur_until, timezone, reason, created_at, created_by
+FROM common.satcom_maintenance_windows
+WHERE id = $1
+`
+
+func (q *Queries) GetSatcomMaintenanceWindow(ctx context.Context, id int32) (CommonSatcomMaintenanceWindow, error) {
+	row := q.db.QueryRow(ctx, getSatcomMaintenanceWindow, id)
+	var i CommonSatcomMaintenanceWindow
+	err := row.Scan(
+		&i.ID,
+		&i.Company,
+		&i.SatcomID,
+		&i.LabelSelector,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.Recurrence,
+		&i.RecurUntil,
+		&i.Timezone,
+		&i.Reason,
+		&i.CreatedAt,
+		&i.CreatedBy,
+	)
+	return i, err
+}
+
+const getSatcomNote = `-- name: GetSatcomNote :one
+SELECT id, satcom_id, parent_id, body, mentions, pinned, resolved_at, resolved_by, resolved_by_name, created_at, created_by, created_by_name, updated_at
+FROM common.satcom_notes
+WHERE id = $1 AND satcom_id = $2
+`
+
+type GetSatcomNoteParams struct {
+	ID       int32 `db:"id" json:"id"`
+	SatcomID int32 `db:"satcom_id" json:"satcom_id"`
+}
+
+func (q *Queries) GetSatcomNote(ctx context.Context, arg GetSatcomNoteParams) (CommonSatcomNote, error) {
+	row := q.db.QueryRow(ctx, getSatcomNote, arg.ID, arg.SatcomID)
+	var i CommonSatcomNote
+	err := row.Scan(
+		&i.ID,
+		&i.SatcomID,
+		&i.ParentID,
+		&i.Body,
+		&i.Mentions,
+		&i.Pinned,
+		&i.ResolvedAt,
+		&i.ResolvedBy,
+		&i.ResolvedByName,
+		&i.CreatedAt,
+		&i.CreatedBy,
+		&i.CreatedByName,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getSatcomProbeLatencyStats = `-- name: GetSatcomProbeLatencyStats :many
+SELECT p.check_type,
+    count(*) AS probes,
+    count(*) FILTER (WHERE p.success) AS successes,
+    count(p.latency_ms) FILTER (WHERE p.success) AS measured,
+    COALESCE(avg(p.latency_ms) FILTER (WHERE p.success), 0)::float8 AS avg_latency_ms
+FROM common.satcom_probe_results p
+JOIN common.satcom_data d ON d.id = p.satcom_id
+WHERE p.probed_at >= $1 AND d.deleted_at IS NULL
+    AND ($2::text IS NULL OR d.company = $2)
+GROUP BY p.check_type
+ORDER BY p.check_type
+`
+
+type GetSatcomProbeLatencyStatsParams struct {
+	Since   pgtype.Timestamptz `db:"since" json:"since"`
+	Company pgtype.Text        `db:"company" json:"company"`
+}
+
+type GetSatcomProbeLatencyStatsRow struct {
+	CheckType    string  `db:"check_type" json:"check_type"`
+	Probes       int64   `db:"probes" json:"probes"`
+	Successes    int64   `db:"successes" json:"successes"`
+	Measured     int64   `db:"measured" json:"measured"`
+	AvgLatencyMs float64 `db:"avg_latency_ms" json:"avg_latency_ms"`
+}
+
+func (q *Queries) GetSatcomProbeLatencyStats(ctx context.Context, arg GetSatcomProbeLatencyStatsParams) ([]GetSatcomProbeLatencyStatsRow, error) {
+	rows, err := q.db.Query(ctx, getSatcomProbeLatencyStats, arg.Since, arg.Company)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSatcomProbeLatencyStatsRow
+	for rows.Next() {
+		var i GetSatcomProbeLatencyStatsRow
+		if err := rows.Scan(
+			&i.CheckType,
+			&i.Probes,
+			&i.Successes,
+			&i.Measured,
+			&i.AvgLatencyMs,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSatcomRelation = `-- name: GetSatcomRelation :one
+SELECT id, source_id, target_id, relation, created_at, created_by
+FROM common.satcom_relations
+WHERE id = $1
+`
+
+func (q *Queries) GetSatcomRelation(ctx context.Context, id int32) (CommonSatcomRelation, error) {
+	row := q.db.QueryRow(ctx, getSatcomRelation, id)
+	var i CommonSatcomRelation
+	err := row.Scan(
+		&i.ID,
+		&i.SourceID,
+		&i.TargetID,
+		&i.Relation,
+		&i.CreatedAt,
+		&i.CreatedBy,
+	)
+	return i, err
+}
+
+const getSatcomSecret = `-- name: GetSatcomSecret :one
+SELECT id, satcom_id, name, username, description, version, rotation_days, rotated_at, reminded_at, created_at, created_by
+FROM common.satcom_secrets
+WHERE id = $1 AND satcom_id = $2
+`
+
+type GetSatcomSecretParams struct {
+	ID       int32 `db:"id" json:"id"`
+	SatcomID int32 `db:"satcom_id" json:"satcom_id"`
+}
+
+func (q *Queries) GetSatcomSecret(ctx context.Context, arg GetSatcomSecretParams) (CommonSatcomSecret, error) {
+	row := q.db.QueryRow(ctx, getSatcomSecret, arg.ID, arg.SatcomID)
+	var i CommonSatcomSecret
+	err := row.Scan(
+		&i.ID,
+		&i.SatcomID,
+		&i.Name,
+		&i.Username,
+		&i.Description,
+		&i.Version,
+		&i.RotationDays,
+		&i.RotatedAt,
+		&i.RemindedAt,
+		&i.CreatedAt,
+		&i.CreatedBy,
+	)
+	return i, err
+}
+
+const getSatcomSecretVersion = `-- name: GetSatcomSecretVersion :one
+SELECT secret_id, version, ciphertext, nonce, data_key, key_nonce, key_id, created_at, created_by
+FROM common.satcom_secret_versions
+WHERE secret_id = $1 AND version = $2
+`
+
+type GetSatcomSecretVersionParams struct {
+	SecretID int32 `db:"secret_id" json:"secret_id"`
+	Version  int32 `db:"version" json:"version"`
+}
+
+func (q *Queries) GetSatcomSecretVersion(ctx context.Context, arg GetSatcomSecretVersionParams) (CommonSatcomSecretVersion, error) {
+	row := q.db.QueryRow(ctx, getSatcomSecretVersion, arg.SecretID, arg.Version)
+	var i CommonSatcomSecretVersion
+	err := row.Scan(
+		&i.SecretID,
+		&i.Version,
+		&i.Ciphertext,
+		&i.Nonce,
+		&i.DataKey,
+		&i.KeyNonce,
+		&i.KeyID,
+		&i.CreatedAt,
+		&i.CreatedBy,
+	)
+	return i, err
+}
+
+const getSatcomUptime = `-- name: GetSatcomUptime :many
+SELECT check_type,
+    count(*)::bigint AS total,
+    count(*) FILTER (WHERE success)::bigint AS up,
+    COALESCE(avg(latency_ms) FILTER (WHERE success), 0)::float8 AS avg_latency_ms,
+    max(probed_at)::timestamptz AS last_probed_at
+FROM common.satcom_probe_results
+WHERE satcom_id = $1 AND probed_at >= $2
+GROUP BY check_type
+ORDER BY check_type
+`
+
+type GetSatcomUptimeParams struct {
+	SatcomID int32              `db:"satcom_id" json:"satcom_id"`
+	Since    pgtype.Timestamptz `db:"since" json:"since"`
+}
+
+type GetSatcomUptimeRow struct {
+	CheckType    string             `db:"check_type" json:"check_type"`
+	Total        int64              `db:"total" json:"total"`
+	Up           int64              `db:"up" json:"up"`
+	AvgLatencyMs float64            `db:"avg_latency_ms" json:"avg_latency_ms"`
+	LastProbedAt pgtype.Timestamptz `db:"last_probed_at" json:"last_probed_at"`
+}
+
+func (q *Queries) GetSatcomUptime(ctx context.Context, arg GetSatcomUptimeParams) ([]GetSatcomUptimeRow, error) {
+	rows, err := q.db.Query(ctx, getSatcomUptime, arg.SatcomID, arg.Since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSatcomUptimeRow
+	for rows.Next() {
+		var i GetSatcomUptimeRow
+		if err := rows.Scan(
+			&i.CheckType,
+			&i.Total,
+			&i.Up,
+			&i.AvgLatencyMs,
+			&i.LastProbedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserByIdentity = `-- name: GetUserByIdentity :one
+SELECT u.user_id, u.user_name, u.email, u.phone, u.pass, u.pss_valid, u.otp, u.otp_valid, u.otp_exp, u.role, u.status, u.created_at
+FROM common.users u
+JOIN common.user_identities i ON i.user_id = u.user_id
+WHERE i.provider = $1 AND i.subject = $2
+`
+
+type GetUserByIdentityParams struct {
+	Provider string `db:"provider" json:"provider"`
+	Subject  string `db:"subject" json:"subject"`
+}
+
+func (q *Queries) GetUserByIdentity(ctx context.Context, arg GetUserByIdentityParams) (CommonUser, error) {
+	row := q.db.QueryRow(ctx, getUserByIdentity, arg.Provider, arg.Subject)
+	var i CommonUser
+	err := row.Scan(
+		&i.UserID,
+		&i.UserName,
+		&i.Email,
+		&i.Phone,
+		&i.Pass,
+		&i.PssValid,
+		&i.Otp,
+		&i.OtpValid,
+		&i.OtpExp,
+		&i.Role,
+		&i.Status,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getUserStatusById = `-- name: GetUserStatusById :one
+SELECT status
+FROM common.users
+WHERE user_id = $1
+`
+
+func (q *Queries) GetUserStatusById(ctx context.Context, userID int32) (string, error) {
+	row := q.db.QueryRow(ctx, getUserStatusById, userID)
+	var status string
+	err := row.Scan(&status)
+	return status, err
+}
+
+const importUser = `-- name: ImportUser :exec
+INSERT INTO common.users(user_name, email, phone, pass, pss_valid, role, status)
+VALUES($1, $2, $3, $4, $5, $6, $7)
+`
+
+type ImportUserParams struct {
+	UserName string `db:"user_name" json:"user_name"`
+	Email    string `db:"email" json:"email"`
+	Phone    string `db:"phone" json:"phone"`
+	Pass     string `db:"pass" json:"pass"`
+	PssValid bool   `db:"pss_valid" json:"pss_valid"`
+	Role     string `db:"role" json:"role"`
+	Status   string `db:"status" json:"status"`
+}
+
+func (q *Queries) ImportUser(ctx context.Context, arg ImportUserParams) error {
+	_, err := q.db.Exec(ctx, importUser,
+		arg.UserName,
+		arg.Email,
+		arg.Phone,
+		arg.Pass,
+		arg.PssValid,
+		arg.Role,
+		arg.Status,
+	)
+	return err
+}
+
+const listAuditLogs = `-- name: ListAuditLogs :many
+SELECT id, occurred_at, user_id, user_name, actor_id, actor_name, impersonated, "action", "method", "path", status_code, detail
+FROM common.audit_log
+WHERE ($1::int IS NULL OR user_id = $1)
+    AND ($2::int IS NULL OR actor_id = $2)
+    AND ($3::bool IS NULL OR impersonated = $3)
+    AND ($4::text IS NULL OR "action" = $4)
+ORDER BY id DESC
+LIMIT $5 OFFSET $6
+`
+
+type ListAuditLogsParams struct {
+	UserID       pgtype.Int4 `db:"user_id" json:"user_id"`
+	ActorID      pgtype.Int4 `db:"actor_id" json:"actor_id"`
+	Impersonated pgtype.Bool `db:"impersonated" json:"impersonated"`
+	Action       pgtype.Text `db:"action" json:"action"`
+	RowLimit     int32       `db:"row_limit" json:"row_limit"`
+	RowOffset    int32       `db:"row_offset" json:"row_offset"`
+}
+
+func (q *Queries) ListAuditLogs(ctx context.Context, arg ListAuditLogsParams) ([]CommonAuditLog, error) {
+	rows, err := q.db.Query(ctx, listAuditLogs,
+		arg.UserID,
+		arg.ActorID,
+		arg.Impersonated,
+		arg.Action,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommonAuditLog
+	for rows.Next() {
+		var i CommonAuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.OccurredAt,
+			&i.UserID,
+			&i.UserName,
+			&i.ActorID,
+			&i.ActorName,
+			&i.Impersonated,
+			&i.Action,
+			&i.Method,
+			&i.Path,
+			&i.StatusCode,
+			&i.Detail,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCompanies = `-- name: ListCompanies :many
+SELECT id, name, created_at
+FROM common.companies
+ORDER BY name
+`
+
+func (q *Queries) ListCompanies(ctx context.Context) ([]CommonCompany, error) {
+	rows, err := q.db.Query(ctx, listCompanies)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommonCompany
+	for rows.Next() {
+		var i CommonCompany
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCompanyApproverEmails = `-- name: ListCompanyApproverEmails :many
+SELECT u.email
+FROM common.company_memberships m
+JOIN common.companies c ON c.id = m.company_id
+JOIN common.users u ON u.user_id = m.user_id
+WHERE c.name = $1 AND m."role" IN ('APPROVER', 'ADMIN') AND u.status = 'ACTIVE'
+ORDER BY u.email
+`
+
+func (q *Queries) ListCompanyApproverEmails(ctx context.Context, company string) ([]string, error) {
+	rows, err := q.db.Query(ctx, listCompanyApproverEmails, company)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var email string
+		if err := rows.Scan(&email); err != nil {
+			return nil, err
+		}
+		items = append(items, email)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCompanyMemberEmails = `-- name: ListCompanyMemberEmails :many
+SELECT u.email
+FROM common.company_memberships m
+JOIN common.companies c ON c.id = m.company_id
+JOIN common.users u ON u.user_id = m.user_id
+WHERE c.name = $1 AND m."role" = $2 AND u.status = 'ACTIVE'
+ORDER BY u.email
+`
+
+type ListCompanyMemberEmailsParams struct {
+	Company string `db:"company" json:"company"`
+	Role    string `db:"role" json:"role"`
+}
+
+func (q *Queries) ListCompanyMemberEmails(ctx context.Context, arg ListCompanyMemberEmailsParams) ([]string, error) {
+	rows, err := q.db.Query(ctx, listCompanyMemberEmails, arg.Company, arg.Role)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var email string
+		if err := rows.Scan(&email); err != nil {
+			return nil, err
+		}
+		items = append(items, email)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCompanyMembers = `-- name: ListCompanyMembers :many
+SELECT m.user_id, u.user_name, u.email, m."role", m.created_at
+FROM common.company_memberships m
+JOIN common.users u ON u.user_id = m.user_id
+WHERE m.company_id = $1
+ORDER BY u.user_name
+`
+
+type ListCompanyMembersRow struct {
+	UserID    int32              `db:"user_id" json:"user_id"`
+	UserName  string             `db:"user_name" json:"user_name"`
+	Email     string             `db:"email" json:"email"`
+	Role      string             `db:"role" json:"role"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+func (q *Queries) ListCompanyMembers(ctx context.Context, companyID int32) ([]ListCompanyMembersRow, error) {
+	rows, err := q.db.Query(ctx, listCompanyMembers, companyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCompanyMembersRow
+	for rows.Next() {
+		var i ListCompanyMembersRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.UserName,
+			&i.Email,
+			&i.Role,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDeletedSatcomData = `-- name: ListDeletedSatcomData :many
+SELECT id, company, category, "type", recorded_at, db_port, ui_port, url, ip, status, status_override, last_probed_at, version, deleted_at, deleted_by, name, labels, custom_fields
+FROM common.satcom_data
+WHERE deleted_at IS NOT NULL AND ($1::text IS NULL OR company = $1)
+ORDER BY deleted_at DESC, id
+`
+
+func (q *Queries) ListDeletedSatcomData(ctx context.Context, company pgtype.Text) ([]CommonSatcomDatum, error) {
+	rows, err := q.db.Query(ctx, listDeletedSatcomData, company)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommonSatcomDatum
+	for rows.Next() {
+		var i CommonSatcomDatum
+		if err := rows.Scan(
+			&i.ID,
+			&i.Company,
+			&i.Category,
+			&i.Type,
+			&i.RecordedAt,
+			&i.DbPort,
+			&i.UiPort,
+			&i.Url,
+			&i.Ip,
+			&i.Status,
+			&i.StatusOverride,
+			&i.LastProbedAt,
+			&i.Version,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.Name,
+			&i.Labels,
+			&i.CustomFields,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDownSatcomData = `-- name: ListDownSatcomData :many
+SELECT id, company, category, "type", recorded_at, db_port, ui_port, url, ip, status, status_override, last_probed_at, version, deleted_at, deleted_by, name, labels, custom_fields
+FROM common.satcom_data
+WHERE deleted_at IS NULL AND NOT status AND ($1::text IS NULL OR company = $1)
+ORDER BY company, id
+`
+
+func (q *Queries) ListDownSatcomData(ctx context.Context, company pgtype.Text) ([]CommonSatcomDatum, error) {
+	rows, err := q.db.Query(ctx, listDownSatcomData, company)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommonSatcomDatum
+	for rows.Next() {
+		var i CommonSatcomDatum
+		if err := rows.Scan(
+			&i.ID,
+			&i.Company,
+			&i.Category,
+			&i.Type,
+			&i.RecordedAt,
+			&i.DbPort,
+			&i.UiPort,
+			&i.Url,
+			&i.Ip,
+			&i.Status,
+			&i.StatusOverride,
+			&i.LastProbedAt,
+			&i.Version,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.Name,
+			&i.Labels,
+			&i.CustomFields,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExpiringCertificates = `-- name: ListExpiringCertificates :many
+SELECT c.satcom_id, c.host, c.checked_at, c.subject, c.issuer, c.sans, c.serial_number, c.not_before, c.not_after,
+    c.hostname_match, c.chain_valid, c.error, c.last_warning_days, s.company, s.url
+FROM common.satcom_certificates c
+JOIN common.satcom_data s ON s.id = c.satcom_id
+WHERE c.not_after < $1 AND s.deleted_at IS NULL AND ($2::text IS NULL OR s.company = $2)
+ORDER BY c.not_after, c.satcom_id
+`
+
+type ListExpiringCertificatesParams struct {
+	NotAfter pgtype.Timestamptz `db:"not_after" json:"not_after"`
+	Company  pgtype.Text        `db:"company" json:"company"`
+}
+
+type ListExpiringCertificatesRow struct {
+	SatcomID        int32              `db:"satcom_id" json:"satcom_id"`
+	Host            string             `db:"host" json:"host"`
+	CheckedAt       pgtype.Timestamptz `db:"checked_at" json:"checked_at"`
+	Subject         pgtype.Text        `db:"subject" json:"subject"`
+	Issuer          pgtype.Text        `db:"issuer" json:"issuer"`
+	Sans            []string           `db:"sans" json:"sans"`
+	SerialNumber    pgtype.Text        `db:"serial_number" json:"serial_number"`
+	NotBefore       pgtype.Timestamptz `db:"not_before" json:"not_before"`
+	NotAfter        pgtype.Timestamptz `db:"not_after" json:"not_after"`
+	HostnameMatch   pgtype.Bool        `db:"hostname_match" json:"hostname_match"`
+	ChainValid      pgtype.Bool        `db:"chain_valid" json:"chain_valid"`
+	Error           pgtype.Text        `db:"error" json:"error"`
+	LastWarningDays pgtype.Int4        `db:"last_warning_days" json:"last_warning_days"`
+	Company         string             `db:"company" json:"company"`
+	Url             string             `db:"url" json:"url"`
+}
+
+func (q *Queries) ListExpiringCertificates(ctx context.Context, arg ListExpiringCertificatesParams) ([]ListExpiringCertificatesRow, error) {
+	rows, err := q.db.Query(ctx, listExpiringCertificates, arg.NotAfter, arg.Company)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListExpiringCertificatesRow
+	for rows.Next() {
+		var i ListExpiringCertificatesRow
+		if err := rows.Scan(
+			&i.SatcomID,
+			&i.Host,
+			&i.CheckedAt,
+			&i.Subject,
+			&i.Issuer,
+			&i.Sans,
+			&i.SerialNumber,
+			&i.NotBefore,
+			&i.NotAfter,
+			&i.HostnameMatch,
+			&i.ChainValid,
+			&i.Error,
+			&i.LastWarningDays,
+			&i.Company,
+			&i.Url,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMentionableUsers = `-- name: ListMentionableUsers :many
+SELECT u.user_id, u.user_name, u.email
+FROM common.users u
+WHERE u.status = 'ACTIVE'
+    AND (lower(u.user_name) = ANY($1::text[]) OR lower(u.email) = ANY($1::text[]))
+    AND (u."role" = 'SUPER_ADMIN' OR EXISTS (
+        SELECT 1
+        FROM common.company_memberships m
+        JOIN common.companies c ON c.id = m.company_id
+        WHERE m.user_id = u.user_id AND c.name = $2))
+ORDER BY u.user_id
+`
+
+type ListMentionableUsersParams struct {
+	Names   []string `db:"names" json:"names"`
+	Company string   `db:"company" json:"company"`
+}
+
+type ListMentionableUsersRow struct {
+	UserID   int32  `db:"user_id" json:"user_id"`
+	UserName string `db:"user_name" json:"user_name"`
+	Email    string `db:"email" json:"email"`
+}
+
+func (q *Queries) ListMentionableUsers(ctx context.Context, arg ListMentionableUsersParams) ([]ListMentionableUsersRow, error) {
+	rows, err := q.db.Query(ctx, listMentionableUsers, arg.Names, arg.Company)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMentionableUsersRow
+	for rows.Next() {
+		var i ListMentionableUsersRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.UserName,
+			&i.Email,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProbeResults = `-- name: ListProbeResults :many
+SELECT id, satcom_id, probed_at, check_type, target, success, latency_ms, status_code, error
+FROM common.satcom_probe_results
+WHERE satcom_id = $1 AND probed_at >= $2
+    AND ($3::text IS NULL OR check_type = $3)
+ORDER BY probed_at DESC, id DESC
+LIMIT $4
+`
+
+type ListProbeResultsParams struct {
+	SatcomID  int32              `db:"satcom_id" json:"satcom_id"`
+	Since     pgtype.Timestamptz `db:"since" json:"since"`
+	CheckType pgtype.Text        `db:"check_type" json:"check_type"`
+	RowLimit  int32              `db:"row_limit" json:"row_limit"`
+}
+
+func (q *Queries) ListProbeResults(ctx context.Context, arg ListProbeResultsParams) ([]CommonSatcomProbeResult, error) {
+	rows, err := q.db.Query(ctx, listProbeResults,
+		arg.SatcomID,
+		arg.Since,
+		arg.CheckType,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommonSatcomProbeResult
+	for rows.Next() {
+		var i CommonSatcomProbeResult
+		if err := rows.Scan(
+			&i.ID,
+			&i.SatcomID,
+			&i.ProbedAt,
+			&i.CheckType,
+			&i.Target,
+			&i.Success,
+			&i.LatencyMs,
+			&i.StatusCode,
+			&i.Error,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSatcomAttachmentCleanups = `-- name: ListSatcomAttachmentCleanups :many
+SELECT satcom_id
+FROM common.satcom_attachment_cleanups
+ORDER BY failed_at, satcom_id
+`
+
+func (q *Queries) ListSatcomAttachmentCleanups(ctx context.Context) ([]int32, error) {
+	rows, err := q.db.Query(ctx, listSatcomAttachmentCleanups)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var satcomID int32
+		if err := rows.Scan(&satcomID); err != nil {
+			return nil, err
+		}
+		items = append(items, satcomID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSatcomAttachments = `-- name: ListSatcomAttachments :many
+SELECT id, satcom_id, object_key, file_name, size_bytes, content_type, description, uploaded_at, uploaded_by, uploaded_by_name
+FROM common.satcom_attachments
+WHERE satcom_id = $1
+ORDER BY uploaded_at DESC, id DESC
+`
+
+func (q *Queries) ListSatcomAttachments(ctx context.Context, satcomID int32) ([]CommonSatcomAttachment, error) {
+	rows, err := q.db.Query(ctx, listSatcomAttachments, satcomID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommonSatcomAttachment
+	for rows.Next() {
+		var i CommonSatcomAttachment
+		if err := rows.Scan(
+			&i.ID,
+			&i.SatcomID,
+			&i.ObjectKey,
+			&i.FileName,
+			&i.SizeBytes,
+			&i.ContentType,
+			&i.Description,
+			&i.UploadedAt,
+			&i.UploadedBy,
+			&i.UploadedByName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSatcomChangeRequests = `-- name: ListSatcomChangeRequests :many
+SELECT id, satcom_id, company, operation, status, base, proposed, allow_conflicts, "comment", requested_at, requested_by, requested_by_name, reviewed_at, reviewed_by, reviewed_by_name, review_comment, requested_actor_id
+FROM common.satcom_change_requests
+WHERE ($1::text IS NULL OR company = $1)
+  AND ($2::text IS NULL OR status = $2)
+  AND ($3::int4 IS NULL OR satcom_id = $3)
+ORDER BY requested_at DESC, id DESC
+`
+
+type ListSatcomChangeRequestsParams struct {
+	Company  pgtype.Text `db:"company" json:"company"`
+	Status   pgtype.Text `db:"status" json:"status"`
+	SatcomID pgtype.Int4 `db:"satcom_id" json:"satcom_id"`
+}
+
+func (q *Queries) ListSatcomChangeRequests(ctx context.Context, arg ListSatcomChangeRequestsParams) ([]CommonSatcomChangeRequest, error) {
+	rows, err := q.db.Query(ctx, listSatcomChangeRequests, arg.Company, arg.Status, arg.SatcomID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommonSatcomChangeRequest
+	for rows.Next() {
+		var i CommonSatcomChangeRequest
+		if err := rows.Scan(
+			&i.ID,
+			&i.SatcomID,
+			&i.Company,
+			&i.Operation,
+			&i.Status,
+			&i.Base,
+			&i.Proposed,
+			&i.AllowConflicts,
+			&i.Comment,
+			&i.RequestedAt,
+			&i.RequestedBy,
+			&i.RequestedByName,
+			&i.ReviewedAt,
+			&i.ReviewedBy,
+			&i.ReviewedByName,
+			&i.ReviewComment,
+			&i.RequestedActorID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSatcomConflicts = `-- name: ListSatcomConflicts :many
+WITH endpoints AS (
+    SELECT id, company, 'port' AS kind, host(ip) || ':' || db_port AS endpoint
+    FROM common.satcom_data
+    WHERE deleted_at IS NULL AND ip IS NOT NULL AND db_port IS NOT NULL
+    UNION
+    SELECT id, company, 'port', host(ip) || ':' || ui_port
+    FROM common.satcom_data
+    WHERE deleted_at IS NULL AND ip IS NOT NULL AND ui_port IS NOT NULL
+    UNION
+    SELECT id, company, 'url', lower(rtrim(url, '/'))
+    FROM common.satcom_data
+    WHERE deleted_at IS NULL AND url <> ''
+)
+SELECT company, kind::text, endpoint::text, array_agg(id ORDER BY id)::int4[] AS satcom_ids
+FROM endpoints
+WHERE ($1::text IS NULL OR company = $1)
+GROUP BY company, kind, endpoint
+HAVING count(*) > 1
+ORDER BY company, kind, endpoint
+`
+
+type ListSatcomConflictsRow struct {
+	Company   string  `db:"company" json:"company"`
+	Kind      string  `db:"kind" json:"kind"`
+	Endpoint  string  `db:"endpoint" json:"endpoint"`
+	SatcomIds []int32 `db:"satcom_ids" json:"satcom_ids"`
+}
+
+func (q *Queries) ListSatcomConflicts(ctx context.Context, company pgtype.Text) ([]ListSatcomConflictsRow, error) {
+	rows, err := q.db.Query(ctx, listSatcomConflicts, company)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSatcomConflictsRow
+	for rows.Next() {
+		var i ListSatcomConflictsRow
+		if err := rows.Scan(
+			&i.Company,
+			&i.Kind,
+			&i.Endpoint,
+			&i.SatcomIds,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSatcomConversionIssues = `-- name: ListSatcomConversionIssues :many
+SELECT id, satcom_id, column_name, legacy_value, error, reported_at
+FROM common.satcom_conversion_issues
+ORDER BY satcom_id, id
+`
+
+func (q *Queries) ListSatcomConversionIssues(ctx context.Context) ([]CommonSatcomConversionIssue, error) {
+	rows, err := q.db.Query(ctx, listSatcomConversionIssues)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommonSatcomConversionIssue
+	for rows.Next() {
+		var i CommonSatcomConversionIssue
+		if err := rows.Scan(
+			&i.ID,
+			&i.SatcomID,
+			&i.ColumnName,
+			&i.LegacyValue,
+			&i.Error,
+			&i.ReportedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSatcomData = `-- name: ListSatcomData :many
+SELECT id, company, category, "type", recorded_at, db_port, ui_port, url, ip, status, status_override, last_probed_at, version, deleted_at, deleted_by, name, labels, custom_fields
+FROM common.satcom_data
+WHERE deleted_at IS NULL
+    AND ($1::text IS NULL
+        OR company ILIKE '%' || $1 || '%' ESCAPE '\'
+        OR category ILIKE '%' || $1 || '%' ESCAPE '\'
+        OR "type" ILIKE '%' || $1 || '%' ESCAPE '\'
+        OR url ILIKE '%' || $1 || '%' ESCAPE '\'
+        OR host(ip) ILIKE '%' || $1 || '%' ESCAPE '\')
+    AND ($2::text IS NULL OR company = $2)
+    AND ($3::text IS NULL OR category = $3)
+    AND ($4::text IS NULL OR "type" = $4)
+    AND ($5::bool IS NULL OR status = $5)
+    AND ($6::inet IS NULL OR ip <<= $6)
+    AND ($7::text IS NULL OR url ILIKE '%' || $7 || '%' ESCAPE '\')
+    AND ($8::jsonb IS NULL OR labels @> $8)
+    AND ($9::text[] IS NULL OR labels ?& $9)
+    AND ($10::text[] IS NULL OR NOT labels ?| $10)
+    AND ($11::jsonb IS NULL OR NOT EXISTS (
+        SELECT 1 FROM jsonb_array_elements($11) AS excluded(label) WHERE labels @> excluded.label))
+    AND ($12::jsonb IS NULL OR NOT EXISTS (
+        SELECT 1 FROM jsonb_array_elements($12) AS wanted(choices)
+        WHERE NOT EXISTS (SELECT 1 FROM jsonb_array_elements(wanted.choices) AS choice(label) WHERE labels @> choice.label)))
+    AND ($13::int IS NULL OR id > $13)
+ORDER BY id
+LIMIT $14 OFFSET $15
+`
+
+type ListSatcomDataParams struct {
+	Search      pgtype.Text     `db:"search" json:"search"`
+	Company     pgtype.Text     `db:"company" json:"company"`
+	Category    pgtype.Text     `db:"category" json:"category"`
+	Type        pgtype.Text     `db:"type" json:"type"`
+	Status      pgtype.Bool     `db:"status" json:"status"`
+	Ip          *netip.Prefix   `db:"ip" json:"ip"`
+	Url         pgtype.Text     `db:"url" json:"url"`
+	LabelMatch  json.RawMessage `db:"label_match" json:"label_match"`
+	LabelExists []string        `db:"label_exists" json:"label_exists"`
+	LabelAbsent []string        `db:"label_absent" json:"label_absent"`
+	LabelNot    json.RawMessage `db:"label_not" json:"label_not"`
+	LabelIn     json.RawMessage `db:"label_in" json:"label_in"`
+	AfterID     pgtype.Int4     `db:"after_id" json:"after_id"`
+	RowLimit    int32           `db:"row_limit" json:"row_limit"`
+	RowOffset   int32           `db:"row_offset" json:"row_offset"`
+}
+
+func (q *Queries) ListSatcomData(ctx context.Context, arg ListSatcomDataParams) ([]CommonSatcomDatum, error) {
+	rows, err := q.db.Query(ctx, listSatcomData,
+		arg.Search,
+		arg.Company,
+		arg.Category,
+		arg.Type,
+		arg.Status,
+		arg.Ip,
+		arg.Url,
+		arg.LabelMatch,
+		arg.LabelExists,
+		arg.LabelAbsent,
+		arg.LabelNot,
+		arg.LabelIn,
+		arg.AfterID,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommonSatcomDatum
+	for rows.Next() {
+		var i CommonSatcomDatum
+		if err := rows.Scan(
+			&i.ID,
+			&i.Company,
+			&i.Category,
+			&i.Type,
+			&i.RecordedAt,
+			&i.DbPort,
+			&i.UiPort,
+			&i.Url,
+			&i.Ip,
+			&i.Status,
+			&i.StatusOverride,
+			&i.LastProbedAt,
+			&i.Version,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.Name,
+			&i.Labels,
+			&i.CustomFields,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSatcomDataByUrl = `-- name: ListSatcomDataByUrl :many
+SELECT id, company, category, "type", recorded_at, db_port, ui_port, url, ip, status, status_override, last_probed_at, version, deleted_at, deleted_by, name, labels, custom_fields
+FROM common.satcom_data
+WHERE deleted_at IS NULL AND company = $2 AND lower(rtrim(url, '/')) = lower(rtrim($1::text, '/'))
+ORDER BY id
+`
+
+type ListSatcomDataByUrlParams struct {
+	Url     string `db:"url" json:"url"`
+	Company string `db:"company" json:"company"`
+}
+
+func (q *Queries) ListSatcomDataByUrl(ctx context.Context, arg ListSatcomDataByUrlParams) ([]CommonSatcomDatum, error) {
+	rows, err := q.db.Query(ctx, listSatcomDataByUrl, arg.Url, arg.Company)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommonSatcomDatum
+	for rows.Next() {
+		var i CommonSatcomDatum
+		if err := rows.Scan(
+			&i.ID,
+			&i.Company,
+			&i.Category,
+			&i.Type,
+			&i.RecordedAt,
+			&i.DbPort,
+			&i.UiPort,
+			&i.Url,
+			&i.Ip,
+			&i.Status,
+			&i.StatusOverride,
+			&i.LastProbedAt,
+			&i.Version,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.Name,
+			&i.Labels,
+			&i.CustomFields,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSatcomDataSorted = `-- name: ListSatcomDataSorted :many
+SELECT id, company, category, "type", recorded_at, db_port, ui_port, url, ip, status, status_override, last_probed_at, version, deleted_at, deleted_by, name, labels, custom_fields
+FROM common.satcom_data
+WHERE deleted_at IS NULL
+    AND ($1::text IS NULL
+        OR company ILIKE '%' || $1 || '%' ESCAPE '\'
+        OR category ILIKE '%' || $1 || '%' ESCAPE '\'
+        OR "type" ILIKE '%' || $1 || '%' ESCAPE '\'
+        OR url ILIKE '%' || $1 || '%' ESCAPE '\'
+        OR host(ip) ILIKE '%' || $1 || '%' ESCAPE '\')
+    AND ($2::text IS NULL OR company = $2)
+    AND ($3::text IS NULL OR category = $3)
+    AND ($4::text IS NULL OR "type" = $4)
+    AND ($5::bool IS NULL OR status = $5)
+    AND ($6::inet IS NULL OR ip <<= $6)
+    AND ($7::text IS NULL OR url ILIKE '%' || $7 || '%' ESCAPE '\')
+    AND ($8::jsonb IS NULL OR labels @> $8)
+    AND ($9::text[] IS NULL OR labels ?& $9)
+    AND ($10::text[] IS NULL OR NOT labels ?| $10)
+    AND ($11::jsonb IS NULL OR NOT EXISTS (
+        SELECT 1 FROM jsonb_array_elements($11) AS excluded(label) WHERE labels @> excluded.label))
+    AND ($12::jsonb IS NULL OR NOT EXISTS (
+        SELECT 1 FROM jsonb_array_elements($12) AS wanted(choices)
+        WHERE NOT EXISTS (SELECT 1 FROM jsonb_array_elements(wanted.choices) AS choice(label) WHERE labels @> choice.label)))
+    AND ($13::int IS NULL OR id > $13)
+ORDER BY
+    CASE WHEN $14::text = 'company' AND NOT $15::bool THEN company END,
+    CASE WHEN $14::text = 'company' AND $15::bool THEN company END DESC,
+    CASE WHEN $14::text = 'category' AND NOT $15::bool THEN category END,
+    CASE WHEN $14::text = 'category' AND $15::bool THEN category END DESC,
+    CASE WHEN $14::text = 'type' AND NOT $15::bool THEN "type" END,
+    CASE WHEN $14::text = 'type' AND $15::bool THEN "type" END DESC,
+    CASE WHEN $14::text = 'recorded_at' AND NOT $15::bool THEN recorded_at END,
+    CASE WHEN $14::text = 'recorded_at' AND $15::bool THEN recorded_at END DESC,
+    CASE WHEN $14::text = 'db_port' AND NOT $15::bool THEN db_port END,
+    CASE WHEN $14::text = 'db_port' AND $15::bool THEN db_port END DESC,
+    CASE WHEN $14::text = 'ui_port' AND NOT $15::bool THEN ui_port END,
+    CASE WHEN $14::text = 'ui_port' AND $15::bool THEN ui_port END DESC,
+    CASE WHEN $14::text = 'url' AND NOT $15::bool THEN url END,
+    CASE WHEN $14::text = 'url' AND $15::bool THEN url END DESC,
+    CASE WHEN $14::text = 'ip' AND NOT $15::bool THEN ip END,
+    CASE WHEN $14::text = 'ip' AND $15::bool THEN ip END DESC,
+    CASE WHEN $14::text = 'status' AND NOT $15::bool THEN status END,
+    CASE WHEN $14::text = 'status' AND $15::bool THEN status END DESC,
+    CASE WHEN $15::bool THEN id END DESC,
+    id
+LIMIT $16 OFFSET $17
+`
+
+type ListSatcomDataSortedParams struct {
+	Search      pgtype.Text     `db:"search" json:"search"`
+	Company     pgtype.Text     `db:"company" json:"company"`
+	Category    pgtype.Text     `db:"category" json:"category"`
+	Type        pgtype.Text     `db:"type" json:"type"`
+	Status      pgtype.Bool     `db:"status" json:"status"`
+	Ip          *netip.Prefix   `db:"ip" json:"ip"`
+	Url         pgtype.Text     `db:"url" json:"url"`
+	LabelMatch  json.RawMessage `db:"label_match" json:"label_match"`
+	LabelExists []string        `db:"label_exists" json:"label_exists"`
+	LabelAbsent []string        `db:"label_absent" json:"label_absent"`
+	LabelNot    json.RawMessage `db:"label_not" json:"label_not"`
+	LabelIn     json.RawMessage `db:"label_in" json:"label_in"`
+	AfterID     pgtype.Int4     `db:"after_id" json:"after_id"`
+	SortBy      string          `db:"sort_by" json:"sort_by"`
+	SortDesc    bool            `db:"sort_desc" json:"sort_desc"`
+	RowLimit    int32           `db:"row_limit" json:"row_limit"`
+	RowOffset   int32           `db:"row_offset" json:"row_offset"`
+}
+
+func (q *Queries) ListSatcomDataSorted(ctx context.Context, arg ListSatcomDataSortedParams) ([]CommonSatcomDatum, error) {
+	rows, err := q.db.Query(ctx, listSatcomDataSorted,
+		arg.Search,
+		arg.Company,
+		arg.Category,
+		arg.Type,
+		arg.Status,
+		arg.Ip,
+		arg.Url,
+		arg.LabelMatch,
+		arg.LabelExists,
+		arg.LabelAbsent,
+		arg.LabelNot,
+		arg.LabelIn,
+		arg.AfterID,
+		arg.SortBy,
+		arg.SortDesc,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommonSatcomDatum
+	for rows.Next() {
+		var i CommonSatcomDatum
+		if err := rows.Scan(
+			&i.ID,
+			&i.Company,
+			&i.Category,
+			&i.Type,
+			&i.RecordedAt,
+			&i.DbPort,
+			&i.UiPort,
+			&i.Url,
+			&i.Ip,
+			&i.Status,
+			&i.StatusOverride,
+			&i.LastProbedAt,
+			&i.Version,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.Name,
+			&i.Labels,
+			&i.CustomFields,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSatcomHistory = `-- name: ListSatcomHistory :many
+SELECT id, satcom_id, version, operation, changed_at, changed_by, changed_by_name, actor_id, actor_name, restored_from, snapshot, commit_seq, company_id
+FROM common.satcom_history
+WHERE satcom_id = $1
+ORDER BY version DESC
+`
+
+func (q *Queries) ListSatcomHistory(ctx context.Context, satcomID int32) ([]CommonSatcomHistory, error) {
+	rows, err := q.db.Query(ctx, listSatcomHistory, satcomID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommonSatcomHistory
+	for rows.Next() {
+		var i CommonSatcomHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.SatcomID,
+			&i.Version,
+			&i.Operation,
+			&i.ChangedAt,
+			&i.ChangedBy,
+			&i.ChangedByName,
+			&i.ActorID,
+			&i.ActorName,
+			&i.RestoredFrom,
+			&i.Snapshot,
+			&i.CommitSeq,
+			&i.CompanyID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSatcomHistoryAfter = `-- name: ListSatcomHistoryAfter :many
+SELECT id, satcom_id, version, operation, changed_at, changed_by, changed_by_name, actor_id, actor_name, restored_from, snapshot, commit_seq, company_id
+FROM common.satcom_history
+WHERE commit_seq > $1
+ORDER BY commit_seq
+LIMIT $2
+`
+
+type ListSatcomHistoryAfterParams struct {
+	AfterSeq int64 `db:"after_seq" json:"after_seq"`
+	RowLimit int32 `db:"row_limit" json:"row_limit"`
+}
+
+func (q *Queries) ListSatcomHistoryAfter(ctx context.Context, arg ListSatcomHistoryAfterParams) ([]CommonSatcomHistory, error) {
+	rows, err := q.db.Query(ctx, listSatcomHistoryAfter, arg.AfterSeq, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommonSatcomHistory
+	for rows.Next() {
+		var i CommonSatcomHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.SatcomID,
+			&i.Version,
+			&i.Operation,
+			&i.ChangedAt,
+			&i.ChangedBy,
+			&i.ChangedByName,
+			&i.ActorID,
+			&i.ActorName,
+			&i.RestoredFrom,
+			&i.Snapshot,
+			&i.CommitSeq,
+			&i.CompanyID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSatcomInventoryAsOf = `-- name: ListSatcomInventoryAsOf :many
+SELECT id, satcom_id, version, operation, changed_at, changed_by, changed_by_name, actor_id, actor_name, restored_from, snapshot, commit_seq, company_id
+FROM (
+    SELECT DISTINCT ON (satcom_id) id, satcom_id, version, operation, changed_at, changed_by, changed_by_name, actor_id, actor_name, restored_from, snapshot, commit_seq, company_id
+    FROM common.satcom_history
+    WHERE changed_at <= $1
+    ORDER BY satcom_id, version DESC
+) latest
+WHERE operation NOT IN ('DELETE', 'PURGE')
+    AND ($2::int IS NULL OR company_id = $2)
+ORDER BY satcom_id
+`
+
+type ListSatcomInventoryAsOfParams struct {
+	ChangedAt pgtype.Timestamptz `db:"changed_at" json:"changed_at"`
+	CompanyID pgtype.Int4        `db:"company_id" json:"company_id"`
+}
+
+func (q *Queries) ListSatcomInventoryAsOf(ctx context.Context, arg ListSatcomInventoryAsOfParams) ([]CommonSatcomHistory, error) {
+	rows, err := q.db.Query(ctx, listSatcomInventoryAsOf, arg.ChangedAt, arg.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommonSatcomHistory
+	for rows.Next() {
+		var i CommonSatcomHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.SatcomID,
+			&i.Version,
+			&i.Operation,
+			&i.ChangedAt,
+			&i.ChangedBy,
+			&i.ChangedByName,
+			&i.ActorID,
+			&i.ActorName,
+			&i.RestoredFrom,
+			&i.Snapshot,
+			&i.CommitSeq,
+			&i.CompanyID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSatcomLabelValues = `-- name: ListSatcomLabelValues :many
+SELECT l.key::text AS key, l.value::text AS value, count(*) AS count
+FROM common.satcom_data s, jsonb_each_text(s.labels) AS l(key, value)
+WHERE s.deleted_at IS NULL AND ($1::text IS NULL OR s.company = $1)
+GROUP BY l.key, l.value
+ORDER BY l.key, l.value
+`
+
+type ListSatcomLabelValuesRow struct {
+	Key   string `db:"key" json:"key"`
+	Value string `db:"value" json:"value"`
+	Count int64  `db:"count" json:"count"`
+}
+
+func (q *Queries) ListSatcomLabelValues(ctx context.Context, company pgtype.Text) ([]ListSatcomLabelValuesRow, error) {
+	rows, err := q.db.Query(ctx, listSatcomLabelValues, company)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSatcomLabelValuesRow
+	for rows.Next() {
+		var i ListSatcomLabelValuesRow
+		if err := rows.Scan(
+			&i.Key,
+			&i.Value,
+			&i.Count,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSatcomMaintenanceWindows = `-- name: ListSatcomMaintenanceWindows :many
+SELECT id, company, satcom_id, label_selector, starts_at, ends_at, recurrence, recur_until, timezone, reason, created_at, created_by
+FROM common.satcom_maintenance_windows
+WHERE ($1::text IS NULL OR company = $1)
+ORDER BY starts_at, id
+`
+
+func (q *Queries) ListSatcomMaintenanceWindows(ctx context.Context, company pgtype.Text) ([]CommonSatcomMaintenanceWindow, error) {
+	rows, err := q.db.Query(ctx, listSatcomMaintenanceWindows, company)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommonSatcomMaintenanceWindow
+	for rows.Next() {
+		var i CommonSatcomMaintenanceWindow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Company,
+			&i.SatcomID,
+			&i.LabelSelector,
+			&i.StartsAt,
+			&i.EndsAt,
+			&i.Recurrence,
+			&i.RecurUntil,
+			&i.Timezone,
+			&i.Reason,
+			&i.CreatedAt,
+			&i.CreatedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSatcomNotes = `-- name: ListSatcomNotes :many
+SELECT id, satcom_id, parent_id, body, mentions, pinned, resolved_at, resolved_by, resolved_by_name, created_at, created_by, created_by_name, updated_at
+FROM common.satcom_notes
+WHERE satcom_id = $1
+ORDER BY created_at, id
+`
+
+func (q *Queries) ListSatcomNotes(ctx context.Context, satcomID int32) ([]CommonSatcomNote, error) {
+	rows, err := q.db.Query(ctx, listSatcomNotes, satcomID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommonSatcomNote
+	for rows.Next() {
+		var i CommonSatcomNote
+		if err := rows.Scan(
+			&i.ID,
+			&i.SatcomID,
+			&i.ParentID,
+			&i.Body,
+			&i.Mentions,
+			&i.Pinned,
+			&i.ResolvedAt,
+			&i.ResolvedBy,
+			&i.ResolvedByName,
+			&i.CreatedAt,
+			&i.CreatedBy,
+			&i.CreatedByName,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSatcomProbeResultsBetween = `-- name: ListSatcomProbeResultsBetween :many
+SELECT id, satcom_id, probed_at, check_type, target, success, latency_ms, status_code, error
+FROM common.satcom_probe_results
+WHERE satcom_id = $1 AND probed_at >= $2 AND probed_at < $3
+ORDER BY probed_at DESC, id DESC
+LIMIT $4
+`
+
+type ListSatcomProbeResultsBetweenParams struct {
+	SatcomID int32              `db:"satcom_id" json:"satcom_id"`
+	FromTime pgtype.Timestamptz `db:"from_time" json:"from_time"`
+	ToTime   pgtype.Timestamptz `db:"to_time" json:"to_time"`
+	RowLimit int32              `db:"row_limit" json:"row_limit"`
+}
+
+func (q *Queries) ListSatcomProbeResultsBetween(ctx context.Context, arg ListSatcomProbeResultsBetweenParams) ([]CommonSatcomProbeResult, error) {
+	rows, err := q.db.Query(ctx, listSatcomProbeResultsBetween,
+		arg.SatcomID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommonSatcomProbeResult
+	for rows.Next() {
+		var i CommonSatcomProbeResult
+		if err := rows.Scan(
+			&i.ID,
+			&i.SatcomID,
+			&i.ProbedAt,
+			&i.CheckType,
+			&i.Target,
+			&i.Success,
+			&i.LatencyMs,
+			&i.StatusCode,
+			&i.Error,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSatcomRelations = `-- name: ListSatcomRelations :many
+SELECT r.id, r.source_id, r.target_id, r.relation, r.created_at, r.created_by
+FROM common.satcom_relations r
+JOIN common.satcom_data d ON d.id = r.source_id
+WHERE $1::text IS NULL OR d.company = $1
+ORDER BY r.id
+`
+
+func (q *Queries) ListSatcomRelations(ctx context.Context, company pgtype.Text) ([]CommonSatcomRelation, error) {
+	rows, err := q.db.Query(ctx, listSatcomRelations, company)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommonSatcomRelation
+	for rows.Next() {
+		var i CommonSatcomRelation
+		if err := rows.Scan(
+			&i.ID,
+			&i.SourceID,
+			&i.TargetID,
+			&i.Relation,
+			&i.CreatedAt,
+			&i.CreatedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSatcomSecrets = `-- name: ListSatcomSecrets :many
+SELECT id, satcom_id, name, username, description, version, rotation_days, rotated_at, reminded_at, created_at, created_by
+FROM common.satcom_secrets
+WHERE satcom_id = $1
+ORDER BY name
+`
+
+func (q *Queries) ListSatcomSecrets(ctx context.Context, satcomID int32) ([]CommonSatcomSecret, error) {
+	rows, err := q.db.Query(ctx, listSatcomSecrets, satcomID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommonSatcomSecret
+	for rows.Next() {
+		var i CommonSatcomSecret
+		if err := rows.Scan(
+			&i.ID,
+			&i.SatcomID,
+			&i.Name,
+			&i.Username,
+			&i.Description,
+			&i.Version,
+			&i.RotationDays,
+			&i.RotatedAt,
+			&i.RemindedAt,
+			&i.CreatedAt,
+			&i.CreatedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSatcomSecretsForRotation = `-- name: ListSatcomSecretsForRotation :many
+SELECT s.id, s.satcom_id, s.name, s.username, s.rotation_days, s.rotated_at, s.reminded_at, d.company
+FROM common.satcom_secrets s
+JOIN common.satcom_data d ON d.id = s.satcom_id
+WHERE d.deleted_at IS NULL AND ($1::text IS NULL OR d.company = $1)
+ORDER BY s.rotated_at, s.id
+`
+
+type ListSatcomSecretsForRotationRow struct {
+	ID           int32              `db:"id" json:"id"`
+	SatcomID     int32              `db:"satcom_id" json:"satcom_id"`
+	Name         string             `db:"name" json:"name"`
+	Username     string             `db:"username" json:"username"`
+	RotationDays pgtype.Int4        `db:"rotation_days" json:"rotation_days"`
+	RotatedAt    pgtype.Timestamptz `db:"rotated_at" json:"rotated_at"`
+	RemindedAt   pgtype.Timestamptz `db:"reminded_at" json:"reminded_at"`
+	Company      string             `db:"company" json:"company"`
+}
+
+func (q *Queries) ListSatcomSecretsForRotation(ctx context.Context, company pgtype.Text) ([]ListSatcomSecretsForRotationRow, error) {
+	rows, err := q.db.Query(ctx, listSatcomSecretsForRotation, company)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSatcomSecretsForRotationRow
+	for rows.Next() {
+		var i ListSatcomSecretsForRotationRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SatcomID,
+			&i.Name,
+			&i.Username,
+			&i.RotationDays,
+			&i.RotatedAt,
+			&i.RemindedAt,
+			&i.Company,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSatcomSecretVersions = `-- name: ListSatcomSecretVersions :many
+SELECT secret_id, version, ciphertext, nonce, data_key, key_nonce, key_id, created_at, created_by
+FROM common.satcom_secret_versions
+WHERE secret_id = $1
+ORDER BY version DESC
+`
+
+func (q *Queries) ListSatcomSecretVersions(ctx context.Context, secretID int32) ([]CommonSatcomSecretVersion, error) {
+	rows, err := q.db.Query(ctx, listSatcomSecretVersions, secretID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommonSatcomSecretVersion
+	for rows.Next() {
+		var i CommonSatcomSecretVersion
+		if err := rows.Scan(
+			&i.SecretID,
+			&i.Version,
+			&i.Ciphertext,
+			&i.Nonce,
+			&i.DataKey,
+			&i.KeyNonce,
+			&i.KeyID,
+			&i.CreatedAt,
+			&i.CreatedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSatcomSecretVersionsByKey = `-- name: ListSatcomSecretVersionsByKey :many
+SELECT secret_id, version, ciphertext, nonce, data_key, key_nonce, key_id, created_at, created_by
+FROM common.satcom_secret_versions
+WHERE key_id = $1
+ORDER BY secret_id, version
+FOR UPDATE
+`
+
+func (q *Queries) ListSatcomSecretVersionsByKey(ctx context.Context, keyID string) ([]CommonSatcomSecretVersion, error) {
+	rows, err := q.db.Query(ctx, listSatcomSecretVersionsByKey, keyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommonSatcomSecretVersion
+	for rows.Next() {
+		var i CommonSatcomSecretVersion
+		if err := rows.Scan(
+			&i.SecretID,
+			&i.Version,
+			&i.Ciphertext,
+			&i.Nonce,
+			&i.DataKey,
+			&i.KeyNonce,
+			&i.KeyID,
+			&i.CreatedAt,
+			&i.CreatedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsedPortsByIp = `-- name: ListUsedPortsByIp :many
+SELECT db_port::int4 AS port
+FROM common.satcom_data
+WHERE deleted_at IS NULL AND company = $2 AND ip = $1 AND db_port IS NOT NULL
+UNION
+SELECT ui_port::int4
+FROM common.satcom_data
+WHERE deleted_at IS NULL AND company = $2 AND ip = $1 AND ui_port IS NOT NULL
+ORDER BY port
+`
+
+type ListUsedPortsByIpParams struct {
+	Ip      *netip.Addr `db:"ip" json:"ip"`
+	Company string      `db:"company" json:"company"`
+}
+
+func (q *Queries) ListUsedPortsByIp(ctx context.Context, arg ListUsedPortsByIpParams) ([]int32, error) {
+	rows, err := q.db.Query(ctx, listUsedPortsByIp, arg.Ip, arg.Company)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var port int32
+		if err := rows.Scan(&port); err != nil {
+			return nil, err
+		}
+		items = append(items, port)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserCompanies = `-- name: ListUserCompanies :many
+SELECT m.company_id, c.name AS company_name, m."role"
+FROM common.company_memberships m
+JOIN common.companies c ON c.id = m.company_id
+WHERE m.user_id = $1
+ORDER BY c.name
+`
+
+type ListUserCompaniesRow struct {
+	CompanyID   int32  `db:"company_id" json:"company_id"`
+	CompanyName string `db:"company_name" json:"company_name"`
+	Role        string `db:"role" json:"role"`
+}
+
+func (q *Queries) ListUserCompanies(ctx context.Context, userID int32) ([]ListUserCompaniesRow, error) {
+	rows, err := q.db.Query(ctx, listUserCompanies, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserCompaniesRow
+	for rows.Next() {
+		var i ListUserCompaniesRow
+		if err := rows.Scan(
+			&i.CompanyID,
+			&i.CompanyName,
+			&i.Role,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsers = `-- name: ListUsers :many
+SELECT user_id, user_name, email, phone, role, status, created_at
+FROM common.users
+WHERE ($1::text IS NULL
+        OR user_name ILIKE '%' || $1 || '%' ESCAPE '\'
+        OR email ILIKE '%' || $1 || '%' ESCAPE '\'
+        OR phone ILIKE '%' || $1 || '%' ESCAPE '\')
+    AND ($2::text IS NULL OR role = $2)
+    AND ($3::text IS NULL OR status = $3)
+    AND ($4::int IS NULL OR user_id > $4)
+ORDER BY user_id
+LIMIT $5 OFFSET $6
+`
+
+type ListUsersParams struct {
+	Search    pgtype.Text `db:"search" json:"search"`
+	Role      pgtype.Text `db:"role" json:"role"`
+	Status    pgtype.Text `db:"status" json:"status"`
+	AfterID   pgtype.Int4 `db:"after_id" json:"after_id"`
+	RowLimit  int32       `db:"row_limit" json:"row_limit"`
+	RowOffset int32       `db:"row_offset" json:"row_offset"`
+}
+
+type ListUsersRow struct {
+	UserID    int32              `db:"user_id" json:"user_id"`
+	UserName  string             `db:"user_name" json:"user_name"`
+	Email     string             `db:"email" json:"email"`
+	Phone     string             `db:"phone" json:"phone"`
+	Role      string             `db:"role" json:"role"`
+	Status    string             `db:"status" json:"status"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]ListUsersRow, error) {
+	rows, err := q.db.Query(ctx, listUsers,
+		arg.Search,
+		arg.Role,
+		arg.Status,
+		arg.AfterID,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUsersRow
+	for rows.Next() {
+		var i ListUsersRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.UserName,
+			&i.Email,
+			&i.Phone,
+			&i.Role,
+			&i.Status,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT user_id, user_name, email, phone, pass, pss_valid, otp, otp_valid, otp_exp, role, status, created_at 
+FROM common.users 
+WHERE email = $1
+`
+
+// --------------------- AUTHENTICATION ------------------------------
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (CommonUser, error) {
+	row := q.db.QueryRow(ctx, getUserByEmail, email)
+	var i CommonUser
+	err := row.Scan(
+		&i.UserID,
+		&i.UserName,
+		&i.Email,
+		&i.Phone,
+		&i.Pass,
+		&i.PssValid,
+		&i.Otp,
+		&i.OtpValid,
+		&i.OtpExp,
+		&i.Role,
+		&i.Status,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getUserByUserName = `-- name: GetUserByUserName :one
+SELECT user_id, user_name, email, phone, pass, pss_valid, otp, otp_valid, otp_exp, role, status, created_at 
+FROM common.users 
+WHERE user_name = $1
+`
+
+func (q *Queries) GetUserByUserName(ctx context.Context, userName string) (CommonUser, error) {
+	row := q.db.QueryRow(ctx, getUserByUserName, userName)
+	var i CommonUser
+	err := row.Scan(
+		&i.UserID,
+		&i.UserName,
+		&i.Email,
+		&i.Phone,
+		&i.Pass,
+		&i.PssValid,
+		&i.Otp,
+		&i.OtpValid,
+		&i.OtpExp,
+		&i.Role,
+		&i.Status,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getUserByPhone = `-- name: GetUserByPhone :one
+SELECT user_id, user_name, email, phone, pass, pss_valid, otp, otp_valid, otp_exp, role, status, created_at 
+FROM common.users 
+WHERE phone = $1
+`
+
+func (q *Queries) GetUserByPhone(ctx context.Context, phone string) (CommonUser, error) {
+	row := q.db.QueryRow(ctx, getUserByPhone, phone)
+	var i CommonUser
+	err := row.Scan(
+		&i.UserID,
+		&i.UserName,
+		&i.Email,
+		&i.Phone,
+		&i.Pass,
+		&i.PssValid,
+		&i.Otp,
+		&i.OtpValid,
+		&i.OtpExp,
+		&i.Role,
+		&i.Status,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getUserById = `-- name: GetUserById :one
+SELECT user_id, user_name, email, phone, pass, pss_valid, otp, otp_valid, otp_exp, role, status, created_at 
+FROM common.users 
+WHERE user_id = $1
+`
+
+func (q *Queries) GetUserById(ctx context.Context, userID int32) (CommonUser, error) {
+	row := q.db.QueryRow(ctx, getUserById, userID)
+	var i CommonUser
+	err := row.Scan(
+		&i.UserID,
+		&i.UserName,
+		&i.Email,
+		&i.Phone,
+		&i.Pass,
+		&i.PssValid,
+		&i.Otp,
+		&i.OtpValid,
+		&i.OtpExp,
+		&i.Role,
+		&i.Status,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getUserByLogin = `-- name: GetUserByLogin :one
+SELECT user_id, user_name, email, phone, pass, pss_valid, otp, otp_valid, otp_exp, role, status, created_at 
+FROM common.users 
+WHERE user_name = $1 OR email = $1 OR phone = $1
+`
+
+func (q *Queries) GetUserByLogin(ctx context.Context, userName string) (CommonUser, error) {
+	row := q.db.QueryRow(ctx, getUserByLogin, userName)
+	var i CommonUser
+	err := row.Scan(
+		&i.UserID,
+		&i.UserName,
+		&i.Email,
+		&i.Phone,
+		&i.Pass,
+		&i.PssValid,
+		&i.Otp,
+		&i.OtpValid,
+		&i.OtpExp,
+		&i.Role,
+		&i.Status,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listUsersSorted = `-- name: ListUsersSorted :many
+SELECT user_id, user_name, email, phone, role, status, created_at
+FROM common.users
+WHERE ($1::text IS NULL
+        OR user_name ILIKE '%' || $1 || '%' ESCAPE '\'
+        OR email ILIKE '%' || $1 || '%' ESCAPE '\'
+        OR phone ILIKE '%' || $1 || '%' ESCAPE '\')
+    AND ($2::text IS NULL OR role = $2)
+    AND ($3::text IS NULL OR status = $3)
+    AND ($4::int IS NULL OR user_id > $4)
+ORDER BY
+    CASE WHEN $5::text = 'name' AND NOT $6::bool THEN user_name END,
+    CASE WHEN $5::text = 'name' AND $6::bool THEN user_name END DESC,
+    CASE WHEN $5::text = 'email' AND NOT $6::bool THEN email END,
+    CASE WHEN $5::text = 'email' AND $6::bool THEN email END DESC,
+    CASE WHEN $5::text = 'phone' AND NOT $6::bool THEN phone END,
+    CASE WHEN $5::text = 'phone' AND $6::bool THEN phone END DESC,
+    CASE WHEN $5::text = 'role' AND NOT $6::bool THEN role END,
+    CASE WHEN $5::text = 'role' AND $6::bool THEN role END DESC,
+    CASE WHEN $5::text = 'status' AND NOT $6::bool THEN status END,
+    CASE WHEN $5::text = 'status' AND $6::bool THEN status END DESC,
+    CASE WHEN $5::text = 'created' AND NOT $6::bool THEN created_at END,
+    CASE WHEN $5::text = 'created' AND $6::bool THEN created_at END DESC,
+    CASE WHEN $6::bool THEN user_id END DESC,
+    user_id
+LIMIT $7 OFFSET $8
+`
+
+type ListUsersSortedParams struct {
+	Search    pgtype.Text `db:"search" json:"search"`
+	Role      pgtype.Text `db:"role" json:"role"`
+	Status    pgtype.Text `db:"status" json:"status"`
+	AfterID   pgtype.Int4 `db:"after_id" json:"after_id"`
+	SortBy    string      `db:"sort_by" json:"sort_by"`
+	SortDesc  bool        `db:"sort_desc" json:"sort_desc"`
+	RowLimit  int32       `db:"row_limit" json:"row_limit"`
+	RowOffset int32       `db:"row_offset" json:"row_offset"`
+}
+
+type ListUsersSortedRow struct {
+	UserID    int32              `db:"user_id" json:"user_id"`
+	UserName  string             `db:"user_name" json:"user_name"`
+	Email     string             `db:"email" json:"email"`
+	Phone     string             `db:"phone" json:"phone"`
+	Role      string             `db:"role" json:"role"`
+	Status    string             `db:"status" json:"status"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+func (q *Queries) ListUsersSorted(ctx context.Context, arg ListUsersSortedParams) ([]ListUsersSortedRow, error) {
+	rows, err := q.db.Query(ctx, listUsersSorted,
+		arg.Search,
+		arg.Role,
+		arg.Status,
+		arg.AfterID,
+		arg.SortBy,
+		arg.SortDesc,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUsersSortedRow
+	for rows.Next() {
+		var i ListUsersSortedRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.UserName,
+			&i.Email,
+			&i.Phone,
+			&i.Role,
+			&i.Status,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockSatcomEndpoint = `-- name: LockSatcomEndpoint :exec
+SELECT pg_advisory_xact_lock(hashtext('satcom_endpoint:' || $1::text))
+`
+
+func (q *Queries) LockSatcomEndpoint(ctx context.Context, endpoint string) error {
+	_, err := q.db.Exec(ctx, lockSatcomEndpoint, endpoint)
+	return err
+}
+
+const lockSatcomRelations = `-- name: LockSatcomRelations :exec
+-- Serializes relation changes so that two concurrent inserts cannot close a cycle
+LOCK TABLE common.satcom_relations IN SHARE ROW EXCLUSIVE MODE
+`
+
+// Serializes relation changes so that two concurrent inserts cannot close a cycle
+func (q *Queries) LockSatcomRelations(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, lockSatcomRelations)
+	return err
+}
+
+const markSatcomSecretReminded = `-- name: MarkSatcomSecretReminded :exec
+UPDATE common.satcom_secrets
+SET reminded_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) MarkSatcomSecretReminded(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, markSatcomSecretReminded, id)
+	return err
+}
+
+const purgeSatcomData = `-- name: PurgeSatcomData :many
+WITH purged AS (
+    DELETE FROM common.satcom_data
+    WHERE deleted_at IS NOT NULL
+        AND deleted_at < $1
+        AND ($2::int IS NULL OR id = $2)
+    RETURNING id, company, category, "type", recorded_at, db_port, ui_port, url, ip, status, status_override, last_probed_at, version, deleted_at, deleted_by, name, labels, custom_fields
+), issues AS (
+    DELETE FROM common.satcom_conversion_issues
+    WHERE satcom_id IN (SELECT id FROM purged)
+), history AS (
+    INSERT INTO common.satcom_history(satcom_id, version, operation, snapshot, company_id)
+    SELECT p.id, p.version + 1, 'PURGE', to_jsonb(p), c.id
+    FROM purged p
+    LEFT JOIN common.companies c ON c.name = p.company
+)
+SELECT id
+FROM purged
+ORDER BY id
+`
+
+type PurgeSatcomDataParams struct {
+	DeletedBefore pgtype.Timestamptz `db:"deleted_before" json:"deleted_before"`
+	ID            pgtype.Int4        `db:"id" json:"id"`
+}
+
+func (q *Queries) PurgeSatcomData(ctx context.Context, arg PurgeSatcomDataParams) ([]int32, error) {
+	rows, err := q.db.Query(ctx, purgeSatcomData, arg.DeletedBefore, arg.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const recordSatcomAttachmentCleanup = `-- name: RecordSatcomAttachmentCleanup :exec
+INSERT INTO common.satcom_attachment_cleanups(satcom_id, last_error)
+VALUES($1, $2)
+ON CONFLICT (satcom_id) DO UPDATE
+SET attempts = common.satcom_attachment_cleanups.attempts + 1, last_error = EXCLUDED.last_error, failed_at = now()
+`
+
+type RecordSatcomAttachmentCleanupParams struct {
+	SatcomID  int32  `db:"satcom_id" json:"satcom_id"`
+	LastError string `db:"last_error" json:"last_error"`
+}
+
+func (q *Queries) RecordSatcomAttachmentCleanup(ctx context.Context, arg RecordSatcomAttachmentCleanupParams) error {
+	_, err := q.db.Exec(ctx, recordSatcomAttachmentCleanup, arg.SatcomID, arg.LastError)
+	return err
+}
+
+const recordSatcomCertificateError = `-- name: RecordSatcomCertificateError :exec
+INSERT INTO common.satcom_certificates(satcom_id, host, checked_at, error)
+VALUES($1, $2, $3, $4)
+ON CONFLICT (satcom_id) DO UPDATE
+SET host = EXCLUDED.host, checked_at = EXCLUDED.checked_at, error = EXCLUDED.error
+`
+
+type RecordSatcomCertificateErrorParams struct {
+	SatcomID  int32              `db:"satcom_id" json:"satcom_id"`
+	Host      string             `db:"host" json:"host"`
+	CheckedAt pgtype.Timestamptz `db:"checked_at" json:"checked_at"`
+	Error     pgtype.Text        `db:"error" json:"error"`
+}
+
+func (q *Queries) RecordSatcomCertificateError(ctx context.Context, arg RecordSatcomCertificateErrorParams) error {
+	_, err := q.db.Exec(ctx, recordSatcomCertificateError,
+		arg.SatcomID,
+		arg.Host,
+		arg.CheckedAt,
+		arg.Error,
+	)
+	return err
+}
+
+const renameCompany = `-- name: RenameCompany :execrows
+UPDATE common.companies
+SET name = $2
+WHERE id = $1
+`
+
+type RenameCompanyParams struct {
+	ID   int32  `db:"id" json:"id"`
+	Name string `db:"name" json:"name"`
+}
+
+func (q *Queries) RenameCompany(ctx context.Context, arg RenameCompanyParams) (int64, error) {
+	result, err := q.db.Exec(ctx, renameCompany, arg.ID, arg.Name)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const restoreSatcomVersion = `-- name: RestoreSatcomVersion :execrows
+UPDATE common.satcom_data s
+SET company = COALESCE((SELECT c.name FROM common.companies c WHERE c.id = h.company_id), h.snapshot->>'company'),
+    category = h.snapshot->>'category',
+    "type" = h.snapshot->>'type',
+    recorded_at = (h.snapshot->>'recorded_at')::timestamptz,
+    db_port = (h.snapshot->>'db_port')::int4,
+    ui_port = (h.snapshot->>'ui_port')::int4,
+    url = h.snapshot->>'url',
+    ip = (h.snapshot->>'ip')::inet,
+    status = (h.snapshot->>'status')::bool,
+    status_override = (h.snapshot->>'status_override')::bool,
+    labels = COALESCE(h.snapshot->'labels', '{}'),
+    custom_fields = COALESCE(h.snapshot->'custom_fields', '{}'),
+    deleted_at = NULL,
+    deleted_by = NULL,
+    version = s.version + 1
+FROM common.satcom_history h
+WHERE s.id = $1 AND h.satcom_id = s.id AND h.version = $2
+`
+
+type RestoreSatcomVersionParams struct {
+	ID      int32 `db:"id" json:"id"`
+	Version int32 `db:"version" json:"version"`
+}
+
+func (q *Queries) RestoreSatcomVersion(ctx context.Context, arg RestoreSatcomVersionParams) (int64, error) {
+	result, err := q.db.Exec(ctx, restoreSatcomVersion, arg.ID, arg.Version)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const reviewSatcomChangeRequest = `-- name: ReviewSatcomChangeRequest :execrows
+UPDATE common.satcom_change_requests
+SET status = $2, reviewed_at = now(), reviewed_by = $3, reviewed_by_name = $4, review_comment = $5
+WHERE id = $1 AND status = 'PENDING_APPROVAL'
+`
+
+type ReviewSatcomChangeRequestParams struct {
+	ID             int32       `db:"id" json:"id"`
+	Status         string      `db:"status" json:"status"`
+	ReviewedBy     pgtype.Int4 `db:"reviewed_by" json:"reviewed_by"`
+	ReviewedByName pgtype.Text `db:"reviewed_by_name" json:"reviewed_by_name"`
+	ReviewComment  pgtype.Text `db:"review_comment" json:"review_comment"`
+}
+
+func (q *Queries) ReviewSatcomChangeRequest(ctx context.Context, arg ReviewSatcomChangeRequestParams) (int64, error) {
+	result, err := q.db.Exec(ctx, reviewSatcomChangeRequest,
+		arg.ID,
+		arg.Status,
+		arg.ReviewedBy,
+		arg.ReviewedByName,
+		arg.ReviewComment,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const rotateSatcomSecret = `-- name: RotateSatcomSecret :one
+UPDATE common.satcom_secrets
+SET version = version + 1, username = $3, description = $4, rotation_days = $5, rotated_at = now(), reminded_at = NULL
+WHERE id = $1 AND satcom_id = $2
+RETURNING id, satcom_id, name, username, description, version, rotation_days, rotated_at, reminded_at, created_at, created_by
+`
+
+type RotateSatcomSecretParams struct {
+	ID           int32       `db:"id" json:"id"`
+	SatcomID     int32       `db:"satcom_id" json:"satcom_id"`
+	Username     string      `db:"username" json:"username"`
+	Description  string      `db:"description" json:"description"`
+	RotationDays pgtype.Int4 `db:"rotation_days" json:"rotation_days"`
+}
+
+func (q *Queries) RotateSatcomSecret(ctx context.Context, arg RotateSatcomSecretParams) (CommonSatcomSecret, error) {
+	row := q.db.QueryRow(ctx, rotateSatcomSecret,
+		arg.ID,
+		arg.SatcomID,
+		arg.Username,
+		arg.Description,
+		arg.RotationDays,
+	)
+	var i CommonSatcomSecret
+	err := row.Scan(
+		&i.ID,
+		&i.SatcomID,
+		&i.Name,
+		&i.Username,
+		&i.Description,
+		&i.Version,
+		&i.RotationDays,
+		&i.RotatedAt,
+		&i.RemindedAt,
+		&i.CreatedAt,
+		&i.CreatedBy,
+	)
+	return i, err
+}
+
+const setCertificateWarning = `-- name: SetCertificateWarning :exec
+UPDATE common.satcom_certificates
+SET last_warning_days = $2
+WHERE satcom_id = $1
+`
+
+type SetCertificateWarningParams struct {
+	SatcomID        int32       `db:"satcom_id" json:"satcom_id"`
+	LastWarningDays pgtype.Int4 `db:"last_warning_days" json:"last_warning_days"`
+}
+
+func (q *Queries) SetCertificateWarning(ctx context.Context, arg SetCertificateWarningParams) error {
+	_, err := q.db.Exec(ctx, setCertificateWarning, arg.SatcomID, arg.LastWarningDays)
+	return err
+}
+
+const setSatcomNotePinned = `-- name: SetSatcomNotePinned :one
+UPDATE common.satcom_notes
+SET pinned = $3
+WHERE id = $1 AND satcom_id = $2
+RETURNING id, satcom_id, parent_id, body, mentions, pinned, resolved_at, resolved_by, resolved_by_name, created_at, created_by, created_by_name, updated_at
+`
+
+type SetSatcomNotePinnedParams struct {
+	ID       int32 `db:"id" json:"id"`
+	SatcomID int32 `db:"satcom_id" json:"satcom_id"`
+	Pinned   bool  `db:"pinned" json:"pinned"`
+}
+
+func (q *Queries) SetSatcomNotePinned(ctx context.Context, arg SetSatcomNotePinnedParams) (CommonSatcomNote, error) {
+	row := q.db.QueryRow(ctx, setSatcomNotePinned, arg.ID, arg.SatcomID, arg.Pinned)
+	var i CommonSatcomNote
+	err := row.Scan(
+		&i.ID,
+		&i.SatcomID,
+		&i.ParentID,
+		&i.Body,
+		&i.Mentions,
+		&i.Pinned,
+		&i.ResolvedAt,
+		&i.ResolvedBy,
+		&i.ResolvedByName,
+		&i.CreatedAt,
+		&i.CreatedBy,
+		&i.CreatedByName,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const setSatcomNoteResolved = `-- name: SetSatcomNoteResolved :one
+UPDATE common.satcom_notes
+SET resolved_at = CASE WHEN $1::bool THEN now() END,
+    resolved_by = CASE WHEN $1::bool THEN $2::int4 END,
+    resolved_by_name = CASE WHEN $1::bool THEN $3::text END
+WHERE id = $4 AND satcom_id = $5
+RETURNING id, satcom_id, parent_id, body, mentions, pinned, resolved_at, resolved_by, resolved_by_name, created_at, created_by, created_by_name, updated_at
+`
+
+type SetSatcomNoteResolvedParams struct {
+	Resolved       bool        `db:"resolved" json:"resolved"`
+	ResolvedBy     pgtype.Int4 `db:"resolved_by" json:"resolved_by"`
+	ResolvedByName pgtype.Text `db:"resolved_by_name" json:"resolved_by_name"`
+	ID             int32       `db:"id" json:"id"`
+	SatcomID       int32       `db:"satcom_id" json:"satcom_id"`
+}
+
+func (q *Queries) SetSatcomNoteResolved(ctx context.Context, arg SetSatcomNoteResolvedParams) (CommonSatcomNote, error) {
+	row := q.db.QueryRow(ctx, setSatcomNoteResolved,
+		arg.Resolved,
+		arg.ResolvedBy,
+		arg.ResolvedByName,
+		arg.ID,
+		arg.SatcomID,
+	)
+	var i CommonSatcomNote
+	err := row.Scan(
+		&i.ID,
+		&i.SatcomID,
+		&i.ParentID,
+		&i.Body,
+		&i.Mentions,
+		&i.Pinned,
+		&i.ResolvedAt,
+		&i.ResolvedBy,
+		&i.ResolvedByName,
+		&i.CreatedAt,
+		&i.CreatedBy,
+		&i.CreatedByName,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const setSatcomProbedAt = `-- name: SetSatcomProbedAt :exec
+UPDATE common.satcom_data
+SET last_probed_at = $2
+WHERE id = $1
+`
+
+type SetSatcomProbedAtParams struct {
+	ID           int32              `db:"id" json:"id"`
+	LastProbedAt pgtype.Timestamptz `db:"last_probed_at" json:"last_probed_at"`
+}
+
+func (q *Queries) SetSatcomProbedAt(ctx context.Context, arg SetSatcomProbedAtParams) error {
+	_, err := q.db.Exec(ctx, setSatcomProbedAt, arg.ID, arg.LastProbedAt)
+	return err
+}
+
+const setSatcomStatusOverride = `-- name: SetSatcomStatusOverride :execrows
+UPDATE common.satcom_data
+SET status = COALESCE($1::bool, status), status_override = $2, version = version + 1
+WHERE id = $3 AND deleted_at IS NULL
+`
+
+type SetSatcomStatusOverrideParams struct {
+	Status         pgtype.Bool `db:"status" json:"status"`
+	StatusOverride bool        `db:"status_override" json:"status_override"`
+	ID             int32       `db:"id" json:"id"`
+}
+
+func (q *Queries) SetSatcomStatusOverride(ctx context.Context, arg SetSatcomStatusOverrideParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setSatcomStatusOverride, arg.Status, arg.StatusOverride, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const softDeleteSatcomData = `-- name: SoftDeleteSatcomData :execrows
+UPDATE common.satcom_data
+SET deleted_at = now(), deleted_by = $2, version = version + 1
+WHERE id = $1 AND version = $3 AND deleted_at IS NULL
+`
+
+type SoftDeleteSatcomDataParams struct {
+	ID        int32       `db:"id" json:"id"`
+	DeletedBy pgtype.Int4 `db:"deleted_by" json:"deleted_by"`
+	Version   int32       `db:"version" json:"version"`
+}
+
+func (q *Queries) SoftDeleteSatcomData(ctx context.Context, arg SoftDeleteSatcomDataParams) (int64, error) {
+	result, err := q.db.Exec(ctx, softDeleteSatcomData, arg.ID, arg.DeletedBy, arg.Version)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const tryLockSatcomProber = `-- name: TryLockSatcomProber :one
+SELECT pg_try_advisory_lock(hashtext('satcom_prober'))
+`
+
+func (q *Queries) TryLockSatcomProber(ctx context.Context) (bool, error) {
+	row := q.db.QueryRow(ctx, tryLockSatcomProber)
+	var pgTryAdvisoryLock bool
+	err := row.Scan(&pgTryAdvisoryLock)
+	return pgTryAdvisoryLock, err
+}
+
+const undeleteSatcomData = `-- name: UndeleteSatcomData :execrows
+UPDATE common.satcom_data
+SET deleted_at = NULL, deleted_by = NULL, version = version + 1
+WHERE id = $1 AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) UndeleteSatcomData(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, undeleteSatcomData, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const unlockSatcomProber = `-- name: UnlockSatcomProber :one
+SELECT pg_advisory_unlock(hashtext('satcom_prober'))
+`
+
+func (q *Queries) UnlockSatcomProber(ctx context.Context) (bool, error) {
+	row := q.db.QueryRow(ctx, unlockSatcomProber)
+	var pgAdvisoryUnlock bool
+	err := row.Scan(&pgAdvisoryUnlock)
+	return pgAdvisoryUnlock, err
+}
+
+const updatePassword = `-- name: UpdatePassword :exec
+UPDATE common.users 
+SET pass = $1, pss_valid = $2 
+WHERE email = $3
+`
+
+type UpdatePasswordParams struct {
+	Pass     string `db:"pass" json:"pass"`
+	PssValid bool   `db:"pss_valid" json:"pss_valid"`
+	Email    string `db:"email" json:"email"`
+}
+
+func (q *Queries) UpdatePassword(ctx context.Context, arg UpdatePasswordParams) error {
+	_, err := q.db.Exec(ctx, updatePassword, arg.Pass, arg.PssValid, arg.Email)
+	return err
+}
+
+const updateSatcomData = `-- name: UpdateSatcomData :execrows
+UPDATE common.satcom_data
+SET company = $1, category = $2, "type" = $3, recorded_at = $4,
+    db_port = $5, ui_port = $6, url = $7, ip = $8, status = $9,
+    name = COALESCE($10, name), labels = COALESCE($11::jsonb, labels),
+    custom_fields = COALESCE($12::jsonb, custom_fields), version = version + 1
+WHERE id = $13 AND version = $14 AND deleted_at IS NULL
+`
+
+type UpdateSatcomDataParams struct {
+	Company         string             `db:"company" json:"company"`
+	Category        string             `db:"category" json:"category"`
+	Type            string             `db:"type" json:"type"`
+	RecordedAt      pgtype.Timestamptz `db:"recorded_at" json:"recorded_at"`
+	DbPort          pgtype.Int4        `db:"db_port" json:"db_port"`
+	UiPort          pgtype.Int4        `db:"ui_port" json:"ui_port"`
+	Url             string             `db:"url" json:"url"`
+	Ip              *netip.Addr        `db:"ip" json:"ip"`
+	Status          bool               `db:"status" json:"status"`
+	Name            pgtype.Text        `db:"name" json:"name"`
+	Labels          json.RawMessage    `db:"labels" json:"labels"`
+	CustomFields    json.RawMessage    `db:"custom_fields" json:"custom_fields"`
+	ID              int32              `db:"id" json:"id"`
+	ExpectedVersion int32              `db:"expected_version" json:"expected_version"`
+}
+
+func (q *Queries) UpdateSatcomData(ctx context.Context, arg UpdateSatcomDataParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateSatcomData,
+		arg.Company,
+		arg.Category,
+		arg.Type,
+		arg.RecordedAt,
+		arg.DbPort,
+		arg.UiPort,
+		arg.Url,
+		arg.Ip,
+		arg.Status,
+		arg.Name,
+		arg.Labels,
+		arg.CustomFields,
+		arg.ID,
+		arg.ExpectedVersion,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateSatcomMaintenanceWindow = `-- name: UpdateSatcomMaintenanceWindow :one
+UPDATE common.satcom_maintenance_windows
+SET company = $2, satcom_id = $3, label_selector = $4, starts_at = $5, ends_at = $6, recurrence = $7, recur_until = $8, timezone = $9, reason = $10
+WHERE id = $1
+RETURNING id, company, satcom_id, label_selector, starts_at, ends_at, recurrence, recur_until, timezone, reason, created_at, created_by
+`
+
+type UpdateSatcomMaintenanceWindowParams struct {
+	ID            int32              `db:"id" json:"id"`
+	Company       string             `db:"company" json:"company"`
+	SatcomID      pgtype.Int4        `db:"satcom_id" json:"satcom_id"`
+	LabelSelector pgtype.Text        `db:"label_selector" json:"label_selector"`
+	StartsAt      pgtype.Timestamptz `db:"starts_at" json:"starts_at"`
+	EndsAt        pgtype.Timestamptz `db:"ends_at" json:"ends_at"`
+	Recurrence    pgtype.Text        `db:"recurrence" json:"recurrence"`
+	RecurUntil    pgtype.Timestamptz `db:"recur_until" json:"recur_until"`
+	Timezone      string             `db:"timezone" json:"timezone"`
+	Reason        string             `db:"reason" json:"reason"`
+}
+
+func (q *Queries) UpdateSatcomMaintenanceWindow(ctx context.Context, arg UpdateSatcomMaintenanceWindowParams) (CommonSatcomMaintenanceWindow, error) {
+	row := q.db.QueryRow(ctx, updateSatcomMaintenanceWindow,
+		arg.ID,
+		arg.Company,
+		arg.SatcomID,
+		arg.LabelSelector,
+		arg.StartsAt,
+		arg.EndsAt,
+		arg.Recurrence,
+		arg.RecurUntil,
+		arg.Timezone,
+		arg.Reason,
+	)
+	var i CommonSatcomMaintenanceWindow
+	err := row.Scan(
+		&i.ID,
+		&i.Company,
+		&i.SatcomID,
+		&i.LabelSelector,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.Recurrence,
+		&i.RecurUntil,
+		&i.Timezone,
+		&i.Reason,
+		&i.CreatedAt,
+		&i.CreatedBy,
+	)
+	return i, err
+}
+
+const updateSatcomNoteBody = `-- name: UpdateSatcomNoteBody :one
+UPDATE common.satcom_notes
+SET body = $3, mentions = $4, updated_at = now()
+WHERE id = $1 AND satcom_id = $2
+RETURNING id, satcom_id, parent_id, body, mentions, pinned, resolved_at, resolved_by, resolved_by_name, created_at, created_by, created_by_name, updated_at
+`
+
+type UpdateSatcomNoteBodyParams struct {
+	ID       int32   `db:"id" json:"id"`
+	SatcomID int32   `db:"satcom_id" json:"satcom_id"`
+	Body     string  `db:"body" json:"body"`
+	Mentions []int32 `db:"mentions" json:"mentions"`
+}
+
+func (q *Queries) UpdateSatcomNoteBody(ctx context.Context, arg UpdateSatcomNoteBodyParams) (CommonSatcomNote, error) {
+	row := q.db.QueryRow(ctx, updateSatcomNoteBody,
+		arg.ID,
+		arg.SatcomID,
+		arg.Body,
+		arg.Mentions,
+	)
+	var i CommonSatcomNote
+	err := row.Scan(
+		&i.ID,
+		&i.SatcomID,
+		&i.ParentID,
+		&i.Body,
+		&i.Mentions,
+		&i.Pinned,
+		&i.ResolvedAt,
+		&i.ResolvedBy,
+		&i.ResolvedByName,
+		&i.CreatedAt,
+		&i.CreatedBy,
+		&i.CreatedByName,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateSatcomProbeStatus = `-- name: UpdateSatcomProbeStatus :execrows
+UPDATE common.satcom_data
+SET status = $1::bool, version = version + 1
+WHERE id = $2 AND NOT status_override AND status <> $1::bool AND deleted_at IS NULL
+`
+
+type UpdateSatcomProbeStatusParams struct {
+	Status bool  `db:"status" json:"status"`
+	ID     int32 `db:"id" json:"id"`
+}
+
+func (q *Queries) UpdateSatcomProbeStatus(ctx context.Context, arg UpdateSatcomProbeStatusParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateSatcomProbeStatus, arg.Status, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateSatcomSecretVersionKey = `-- name: UpdateSatcomSecretVersionKey :execrows
+UPDATE common.satcom_secret_versions
+SET data_key = $1, key_nonce = $2, key_id = $3
+WHERE secret_id = $4 AND version = $5 AND key_id = $6::text
+`
+
+type UpdateSatcomSecretVersionKeyParams struct {
+	DataKey  []byte `db:"data_key" json:"data_key"`
+	KeyNonce []byte `db:"key_nonce" json:"key_nonce"`
+	KeyID    string `db:"key_id" json:"key_id"`
+	SecretID int32  `db:"secret_id" json:"secret_id"`
+	Version  int32  `db:"version" json:"version"`
+	OldKeyID string `db:"old_key_id" json:"old_key_id"`
+}
+
+func (q *Queries) UpdateSatcomSecretVersionKey(ctx context.Context, arg UpdateSatcomSecretVersionKeyParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateSatcomSecretVersionKey,
+		arg.DataKey,
+		arg.KeyNonce,
+		arg.KeyID,
+		arg.SecretID,
+		arg.Version,
+		arg.OldKeyID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateUser = `-- name: UpdateUser :exec
+UPDATE common.users 
+SET user_name = $1, email = $2, phone = $3, role = $4
+WHERE user_id = $5
+`
+
+type UpdateUserParams struct {
+	UserName string `db:"user_name" json:"user_name"`
+	Email    string `db:"email" json:"email"`
+	Phone    string `db:"phone" json:"phone"`
+	Role     string `db:"role" json:"role"`
+	UserID   int32  `db:"user_id" json:"user_id"`
+}
+
+func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) error {
+	_, err := q.db.Exec(ctx, updateUser,
+		arg.UserName,
+		arg.Email,
+		arg.Phone,
+		arg.Role,
+		arg.UserID,
+	)
+	return err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :exec
+UPDATE common.users
+SET role = $1
+WHERE user_id = $2
+`
+
+type UpdateUserRoleParams struct {
+	Role   string `db:"role" json:"role"`
+	UserID int32  `db:"user_id" json:"user_id"`
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) error {
+	_, err := q.db.Exec(ctx, updateUserRole, arg.Role, arg.UserID)
+	return err
+}
+
+const updateUserStatus = `-- name: UpdateUserStatus :exec
+UPDATE common.users
+SET status = $1
+WHERE user_id = $2
+`
+
+type UpdateUserStatusParams struct {
+	Status string `db:"status" json:"status"`
+	UserID int32  `db:"user_id" json:"user_id"`
+}
+
+func (q *Queries) UpdateUserStatus(ctx context.Context, arg UpdateUserStatusParams) error {
+	_, err := q.db.Exec(ctx, updateUserStatus, arg.Status, arg.UserID)
+	return err
+}
+
+const upsertCompanyMembership = `-- name: UpsertCompanyMembership :exec
+INSERT INTO common.company_memberships(user_id, company_id, "role")
+VALUES($1, $2, $3)
+ON CONFLICT (user_id, company_id) DO UPDATE SET "role" = EXCLUDED."role"
+`
+
+type UpsertCompanyMembershipParams struct {
+	UserID    int32  `db:"user_id" json:"user_id"`
+	CompanyID int32  `db:"company_id" json:"company_id"`
+	Role      string `db:"role" json:"role"`
+}
+
+func (q *Queries) UpsertCompanyMembership(ctx context.Context, arg UpsertCompanyMembershipParams) error {
+	_, err := q.db.Exec(ctx, upsertCompanyMembership, arg.UserID, arg.CompanyID, arg.Role)
+	return err
+}
+
+const upsertSatcomCertificate = `-- name: UpsertSatcomCertificate :exec
+INSERT INTO common.satcom_certificates(satcom_id, host, checked_at, subject, issuer, sans, serial_number, not_before, not_after, hostname_match, chain_valid, error)
+VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULL)
+ON CONFLICT (satcom_id) DO UPDATE
+SET host = EXCLUDED.host, checked_at = EXCLUDED.checked_at, subject = EXCLUDED.subject, issuer = EXCLUDED.issuer,
+    sans = EXCLUDED.sans, serial_number = EXCLUDED.serial_number, not_before = EXCLUDED.not_before,
+    not_after = EXCLUDED.not_after, hostname_match = EXCLUDED.hostname_match, chain_valid = EXCLUDED.chain_valid, error = NULL,
+    last_warning_days = CASE WHEN common.satcom_certificates.serial_number IS DISTINCT FROM EXCLUDED.serial_number
+        THEN NULL ELSE common.satcom_certificates.last_warning_days END
+`
+
+type UpsertSatcomCertificateParams struct {
+	SatcomID      int32              `db:"satcom_id" json:"satcom_id"`
+	Host          string             `db:"host" json:"host"`
+	CheckedAt     pgtype.Timestamptz `db:"checked_at" json:"checked_at"`
+	Subject       pgtype.Text        `db:"subject" json:"subject"`
+	Issuer        pgtype.Text        `db:"issuer" json:"issuer"`
+	Sans          []string           `db:"sans" json:"sans"`
+	SerialNumber  pgtype.Text        `db:"serial_number" json:"serial_number"`
+	NotBefore     pgtype.Timestamptz `db:"not_before" json:"not_before"`
+	NotAfter      pgtype.Timestamptz `db:"not_after" json:"not_after"`
+	HostnameMatch pgtype.Bool        `db:"hostname_match" json:"hostname_match"`
+	ChainValid    pgtype.Bool        `db:"chain_valid" json:"chain_valid"`
+}
+
+func (q *Queries) UpsertSatcomCertificate(ctx context.Context, arg UpsertSatcomCertificateParams) error {
+	_, err := q.db.Exec(ctx, upsertSatcomCertificate,
+		arg.SatcomID,
+		arg.Host,
+		arg.CheckedAt,
+		arg.Subject,
+		arg.Issuer,
+		arg.Sans,
+		arg.SerialNumber,
+		arg.NotBefore,
+		arg.NotAfter,
+		arg.HostnameMatch,
+		arg.ChainValid,
+	)
+	return err
+}
+
+const usersShareCompany = `-- name: UsersShareCompany :one
+SELECT EXISTS (
+    SELECT 1
+    FROM common.company_memberships a
+    JOIN common.company_memberships b ON b.company_id = a.company_id
+    WHERE a.user_id = $1 AND b.user_id = $2
+) AS shared
+`
+
+type UsersShareCompanyParams struct {
+	UserID      int32 `db:"user_id" json:"user_id"`
+	OtherUserID int32 `db:"other_user_id" json:"other_user_id"`
+}
+
+func (q *Queries) UsersShareCompany(ctx context.Context, arg UsersShareCompanyParams) (bool, error) {
+	row := q.db.QueryRow(ctx, usersShareCompany, arg.UserID, arg.OtherUserID)
+	var shared bool
+	err := row.Scan(&shared)
+	return shared, err
 }
@@ -5,32 +5,244 @@
 package sql
 
 import (
+	"encoding/json"
+	"net/netip"
+
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type CommonAuditLog struct {
+	ID           int64              `db:"id" json:"id"`
+	OccurredAt   pgtype.Timestamptz `db:"occurred_at" json:"occurred_at"`
+	UserID       pgtype.Int4        `db:"user_id" json:"user_id"`
+	UserName     pgtype.Text        `db:"user_name" json:"user_name"`
+	ActorID      pgtype.Int4        `db:"actor_id" json:"actor_id"`
+	ActorName    pgtype.Text        `db:"actor_name" json:"actor_name"`
+	Impersonated bool               `db:"impersonated" json:"impersonated"`
+	Action       string             `db:"action" json:"action"`
+	Method       pgtype.Text        `db:"method" json:"method"`
+	Path         pgtype.Text        `db:"path" json:"path"`
+	StatusCode   pgtype.Int4        `db:"status_code" json:"status_code"`
+	Detail       []byte             `db:"detail" json:"detail"`
+}
+
+type CommonCompany struct {
+	ID        int32              `db:"id" json:"id"`
+	Name      string             `db:"name" json:"name"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+type CommonCompanyMembership struct {
+	UserID    int32              `db:"user_id" json:"user_id"`
+	CompanyID int32              `db:"company_id" json:"company_id"`
+	Role      string             `db:"role" json:"role"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+type CommonLoginCode struct {
+	CodeHash  string             `db:"code_hash" json:"code_hash"`
+	UserID    int32              `db:"user_id" json:"user_id"`
+	ExpiresAt pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+}
+
+type CommonSatcomAttachment struct {
+	ID             int32              `db:"id" json:"id"`
+	SatcomID       int32              `db:"satcom_id" json:"satcom_id"`
+	ObjectKey      string             `db:"object_key" json:"object_key"`
+	FileName       string             `db:"file_name" json:"file_name"`
+	SizeBytes      int64              `db:"size_bytes" json:"size_bytes"`
+	ContentType    string             `db:"content_type" json:"content_type"`
+	Description    string             `db:"description" json:"description"`
+	UploadedAt     pgtype.Timestamptz `db:"uploaded_at" json:"uploaded_at"`
+	UploadedBy     pgtype.Int4        `db:"uploaded_by" json:"uploaded_by"`
+	UploadedByName pgtype.Text        `db:"uploaded_by_name" json:"uploaded_by_name"`
+}
+
+type CommonSatcomAttachmentCleanup struct {
+	SatcomID  int32              `db:"satcom_id" json:"satcom_id"`
+	Attempts  int32              `db:"attempts" json:"attempts"`
+	LastError string             `db:"last_error" json:"last_error"`
+	FailedAt  pgtype.Timestamptz `db:"failed_at" json:"failed_at"`
+}
+
+type CommonSatcomCertificate struct {
+	SatcomID        int32              `db:"satcom_id" json:"satcom_id"`
+	Host            string             `db:"host" json:"host"`
+	CheckedAt       pgtype.Timestamptz `db:"checked_at" json:"checked_at"`
+	Subject         pgtype.Text        `db:"subject" json:"subject"`
+	Issuer          pgtype.Text        `db:"issuer" json:"issuer"`
+	Sans            []string           `db:"sans" json:"sans"`
+	SerialNumber    pgtype.Text        `db:"serial_number" json:"serial_number"`
+	NotBefore       pgtype.Timestamptz `db:"not_before" json:"not_before"`
+	NotAfter        pgtype.Timestamptz `db:"not_after" json:"not_after"`
+	HostnameMatch   pgtype.Bool        `db:"hostname_match" json:"hostname_match"`
+	ChainValid      pgtype.Bool        `db:"chain_valid" json:"chain_valid"`
+	Error           pgtype.Text        `db:"error" json:"error"`
+	LastWarningDays pgtype.Int4        `db:"last_warning_days" json:"last_warning_days"`
+}
+
+type CommonSatcomChangeRequest struct {
+	ID               int32              `db:"id" json:"id"`
+	SatcomID         int32              `db:"satcom_id" json:"satcom_id"`
+	Company          string             `db:"company" json:"company"`
+	Operation        string             `db:"operation" json:"operation"`
+	Status           string             `db:"status" json:"status"`
+	Base             []byte             `db:"base" json:"base"`
+	Proposed         []byte             `db:"proposed" json:"proposed"`
+	AllowConflicts   bool               `db:"allow_conflicts" json:"allow_conflicts"`
+	Comment          string             `db:"comment" json:"comment"`
+	RequestedAt      pgtype.Timestamptz `db:"requested_at" json:"requested_at"`
+	RequestedBy      pgtype.Int4        `db:"requested_by" json:"requested_by"`
+	RequestedByName  pgtype.Text        `db:"requested_by_name" json:"requested_by_name"`
+	ReviewedAt       pgtype.Timestamptz `db:"reviewed_at" json:"reviewed_at"`
+	ReviewedBy       pgtype.Int4        `db:"reviewed_by" json:"reviewed_by"`
+	ReviewedByName   pgtype.Text        `db:"reviewed_by_name" json:"reviewed_by_name"`
+	ReviewComment    pgtype.Text        `db:"review_comment" json:"review_comment"`
+	RequestedActorID pgtype.Int4        `db:"requested_actor_id" json:"requested_actor_id"`
+}
+
+type CommonSatcomConversionIssue struct {
+	ID          int32              `db:"id" json:"id"`
+	SatcomID    int32              `db:"satcom_id" json:"satcom_id"`
+	ColumnName  string             `db:"column_name" json:"column_name"`
+	LegacyValue pgtype.Text        `db:"legacy_value" json:"legacy_value"`
+	Error       string             `db:"error" json:"error"`
+	ReportedAt  pgtype.Timestamptz `db:"reported_at" json:"reported_at"`
+}
+
 type CommonSatcomDatum struct {
-	ID       int32  `db:"id" json:"id"`
-	Company  string `db:"company" json:"company"`
-	Category string `db:"category" json:"category"`
-	Type     string `db:"type" json:"type"`
-	Date     string `db:"date" json:"date"`
-	Time     string `db:"time" json:"time"`
-	DbPort   string `db:"db_port" json:"db_port"`
-	UiPort   string `db:"ui_port" json:"ui_port"`
-	Url      string `db:"url" json:"url"`
-	Ip       string `db:"ip" json:"ip"`
-	Status   bool   `db:"status" json:"status"`
+	ID             int32              `db:"id" json:"id"`
+	Company        string             `db:"company" json:"company"`
+	Category       string             `db:"category" json:"category"`
+	Type           string             `db:"type" json:"type"`
+	RecordedAt     pgtype.Timestamptz `db:"recorded_at" json:"recorded_at"`
+	DbPort         pgtype.Int4        `db:"db_port" json:"db_port"`
+	UiPort         pgtype.Int4        `db:"ui_port" json:"ui_port"`
+	Url            string             `db:"url" json:"url"`
+	Ip             *netip.Addr        `db:"ip" json:"ip"`
+	Status         bool               `db:"status" json:"status"`
+	StatusOverride bool               `db:"status_override" json:"status_override"`
+	LastProbedAt   pgtype.Timestamptz `db:"last_probed_at" json:"last_probed_at"`
+	Version        int32              `db:"version" json:"version"`
+	DeletedAt      pgtype.Timestamptz `db:"deleted_at" json:"deleted_at"`
+	DeletedBy      pgtype.Int4        `db:"deleted_by" json:"deleted_by"`
+	Name           pgtype.Text        `db:"name" json:"name"`
+	Labels         json.RawMessage    `db:"labels" json:"labels"`
+	CustomFields   json.RawMessage    `db:"custom_fields" json:"custom_fields"`
+}
+
+type CommonSatcomHistory struct {
+	ID            int64              `db:"id" json:"id"`
+	SatcomID      int32              `db:"satcom_id" json:"satcom_id"`
+	Version       int32              `db:"version" json:"version"`
+	Operation     string             `db:"operation" json:"operation"`
+	ChangedAt     pgtype.Timestamptz `db:"changed_at" json:"changed_at"`
+	ChangedBy     pgtype.Int4        `db:"changed_by" json:"changed_by"`
+	ChangedByName pgtype.Text        `db:"changed_by_name" json:"changed_by_name"`
+	ActorID       pgtype.Int4        `db:"actor_id" json:"actor_id"`
+	ActorName     pgtype.Text        `db:"actor_name" json:"actor_name"`
+	RestoredFrom  pgtype.Int4        `db:"restored_from" json:"restored_from"`
+	Snapshot      []byte             `db:"snapshot" json:"snapshot"`
+	CommitSeq     pgtype.Int8        `db:"commit_seq" json:"commit_seq"`
+	CompanyID     pgtype.Int4        `db:"company_id" json:"company_id"`
+}
+
+type CommonSatcomMaintenanceWindow struct {
+	ID            int32              `db:"id" json:"id"`
+	Company       string             `db:"company" json:"company"`
+	SatcomID      pgtype.Int4        `db:"satcom_id" json:"satcom_id"`
+	LabelSelector pgtype.Text        `db:"label_selector" json:"label_selector"`
+	StartsAt      pgtype.Timestamptz `db:"starts_at" json:"starts_at"`
+	EndsAt        pgtype.Timestamptz `db:"ends_at" json:"ends_at"`
+	Recurrence    pgtype.Text        `db:"recurrence" json:"recurrence"`
+	RecurUntil    pgtype.Timestamptz `db:"recur_until" json:"recur_until"`
+	Timezone      string             `db:"timezone" json:"timezone"`
+	Reason        string             `db:"reason" json:"reason"`
+	CreatedAt     pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	CreatedBy     pgtype.Int4        `db:"created_by" json:"created_by"`
+}
+
+type CommonSatcomNote struct {
+	ID             int32              `db:"id" json:"id"`
+	SatcomID       int32              `db:"satcom_id" json:"satcom_id"`
+	ParentID       pgtype.Int4        `db:"parent_id" json:"parent_id"`
+	Body           string             `db:"body" json:"body"`
+	Mentions       []int32            `db:"mentions" json:"mentions"`
+	Pinned         bool               `db:"pinned" json:"pinned"`
+	ResolvedAt     pgtype.Timestamptz `db:"resolved_at" json:"resolved_at"`
+	ResolvedBy     pgtype.Int4        `db:"resolved_by" json:"resolved_by"`
+	ResolvedByName pgtype.Text        `db:"resolved_by_name" json:"resolved_by_name"`
+	CreatedAt      pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	CreatedBy      pgtype.Int4        `db:"created_by" json:"created_by"`
+	CreatedByName  pgtype.Text        `db:"created_by_name" json:"created_by_name"`
+	UpdatedAt      pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+}
+
+type CommonSatcomProbeResult struct {
+	ID         int64              `db:"id" json:"id"`
+	SatcomID   int32              `db:"satcom_id" json:"satcom_id"`
+	ProbedAt   pgtype.Timestamptz `db:"probed_at" json:"probed_at"`
+	CheckType  string             `db:"check_type" json:"check_type"`
+	Target     string             `db:"target" json:"target"`
+	Success    bool               `db:"success" json:"success"`
+	LatencyMs  pgtype.Int4        `db:"latency_ms" json:"latency_ms"`
+	StatusCode pgtype.Int4        `db:"status_code" json:"status_code"`
+	Error      pgtype.Text        `db:"error" json:"error"`
+}
+
+type CommonSatcomRelation struct {
+	ID        int32              `db:"id" json:"id"`
+	SourceID  int32              `db:"source_id" json:"source_id"`
+	TargetID  int32              `db:"target_id" json:"target_id"`
+	Relation  string             `db:"relation" json:"relation"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	CreatedBy pgtype.Int4        `db:"created_by" json:"created_by"`
+}
+
+type CommonSatcomSecret struct {
+	ID           int32              `db:"id" json:"id"`
+	SatcomID     int32              `db:"satcom_id" json:"satcom_id"`
+	Name         string             `db:"name" json:"name"`
+	Username     string             `db:"username" json:"username"`
+	Description  string             `db:"description" json:"description"`
+	Version      int32              `db:"version" json:"version"`
+	RotationDays pgtype.Int4        `db:"rotation_days" json:"rotation_days"`
+	RotatedAt    pgtype.Timestamptz `db:"rotated_at" json:"rotated_at"`
+	RemindedAt   pgtype.Timestamptz `db:"reminded_at" json:"reminded_at"`
+	CreatedAt    pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	CreatedBy    pgtype.Int4        `db:"created_by" json:"created_by"`
+}
+
+type CommonSatcomSecretVersion struct {
+	SecretID   int32              `db:"secret_id" json:"secret_id"`
+	Version    int32              `db:"version" json:"version"`
+	Ciphertext []byte             `db:"ciphertext" json:"ciphertext"`
+	Nonce      []byte             `db:"nonce" json:"nonce"`
+	DataKey    []byte             `db:"data_key" json:"data_key"`
+	KeyNonce   []byte             `db:"key_nonce" json:"key_nonce"`
+	KeyID      string             `db:"key_id" json:"key_id"`
+	CreatedAt  pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	CreatedBy  pgtype.Int4        `db:"created_by" json:"created_by"`
 }
 
 type CommonUser struct {
-	UserID   int32            `db:"user_id" json:"user_id"`
-	UserName string           `db:"user_name" json:"user_name"`
-	Email    string           `db:"email" json:"email"`
-	Phone    string           `db:"phone" json:"phone"`
-	Pass     string           `db:"pass" json:"pass"`
-	PssValid bool             `db:"pss_valid" json:"pss_valid"`
-	Otp      pgtype.Text      `db:"otp" json:"otp"`
-	OtpValid bool             `db:"otp_valid" json:"otp_valid"`
-	OtpExp   pgtype.Timestamp `db:"otp_exp" json:"otp_exp"`
-	Role     string           `db:"role" json:"role"`
+	UserID    int32              `db:"user_id" json:"user_id"`
+	UserName  string             `db:"user_name" json:"user_name"`
+	Email     string             `db:"email" json:"email"`
+	Phone     string             `db:"phone" json:"phone"`
+	Pass      string             `db:"pass" json:"pass"`
+	PssValid  bool               `db:"pss_valid" json:"pss_valid"`
+	Otp       pgtype.Text        `db:"otp" json:"otp"`
+	OtpValid  bool               `db:"otp_valid" json:"otp_valid"`
+	OtpExp    pgtype.Timestamp   `db:"otp_exp" json:"otp_exp"`
+	Role      string             `db:"role" json:"role"`
+	Status    string             `db:"status" json:"status"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+type CommonUserIdentity struct {
+	Provider  string             `db:"provider" json:"provider"`
+	Subject   string             `db:"subject" json:"subject"`
+	UserID    int32              `db:"user_id" json:"user_id"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
 }
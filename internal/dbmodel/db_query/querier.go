@@ -6,25 +6,145 @@ package sql
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
+	ConsumeLoginCode(ctx context.Context, codeHash string) (int32, error)
+	CountAuditLogs(ctx context.Context, arg CountAuditLogsParams) (int64, error)
+	CountSatcomActivityByWeek(ctx context.Context, arg CountSatcomActivityByWeekParams) ([]CountSatcomActivityByWeekRow, error)
+	CountSatcomData(ctx context.Context, arg CountSatcomDataParams) (int64, error)
+	CountSatcomDataByDimension(ctx context.Context, company pgtype.Text) ([]CountSatcomDataByDimensionRow, error)
+	CountUsers(ctx context.Context, arg CountUsersParams) (int64, error)
+	CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) error
+	CreateCompany(ctx context.Context, name string) (CommonCompany, error)
+	CreateLoginCode(ctx context.Context, arg CreateLoginCodeParams) error
+	CreateProbeResult(ctx context.Context, arg CreateProbeResultParams) error
+	CreateSatcomAttachment(ctx context.Context, arg CreateSatcomAttachmentParams) (CommonSatcomAttachment, error)
+	CreateSatcomChangeRequest(ctx context.Context, arg CreateSatcomChangeRequestParams) (CommonSatcomChangeRequest, error)
+	CreateSatcomData(ctx context.Context, arg CreateSatcomDataParams) (int32, error)
+	CreateSatcomHistory(ctx context.Context, arg CreateSatcomHistoryParams) error
+	CreateSatcomMaintenanceWindow(ctx context.Context, arg CreateSatcomMaintenanceWindowParams) (CommonSatcomMaintenanceWindow, error)
+	CreateSatcomNote(ctx context.Context, arg CreateSatcomNoteParams) (CommonSatcomNote, error)
+	CreateSatcomRelation(ctx context.Context, arg CreateSatcomRelationParams) (CommonSatcomRelation, error)
+	CreateSatcomSecret(ctx context.Context, arg CreateSatcomSecretParams) (CommonSatcomSecret, error)
+	CreateSatcomSecretVersion(ctx context.Context, arg CreateSatcomSecretVersionParams) error
 	// --------------------- SATCOM DATA ------------------------------
-	CreateSatcomData(ctx context.Context, arg CreateSatcomDataParams) error
 	CreateUser(ctx context.Context, arg CreateUserParams) error
-	DeleteSatcomData(ctx context.Context, id int32) error
+	CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) error
+	DeleteCompanyMembership(ctx context.Context, arg DeleteCompanyMembershipParams) (int64, error)
+	DeleteExpiredLoginCodes(ctx context.Context) error
+	DeleteProbeResultsBefore(ctx context.Context, probedAt pgtype.Timestamptz) (int64, error)
+	DeleteSatcomAttachment(ctx context.Context, arg DeleteSatcomAttachmentParams) (int64, error)
+	DeleteSatcomAttachmentCleanup(ctx context.Context, satcomID int32) error
+	DeleteSatcomConversionIssues(ctx context.Context, satcomID int32) error
+	DeleteSatcomMaintenanceWindow(ctx context.Context, id int32) (int64, error)
+	DeleteSatcomNote(ctx context.Context, arg DeleteSatcomNoteParams) (int64, error)
+	DeleteSatcomRelation(ctx context.Context, id int32) (int64, error)
+	DeleteSatcomSecret(ctx context.Context, arg DeleteSatcomSecretParams) (int64, error)
+	DeleteUser(ctx context.Context, userID int32) error
+	DeleteUserCompanyMemberships(ctx context.Context, userID int32) error
+	DeleteUserIdentities(ctx context.Context, userID int32) error
+	FindSatcomConflicts(ctx context.Context, arg FindSatcomConflictsParams) ([]FindSatcomConflictsRow, error)
+	GetActiveUserEmailsByRole(ctx context.Context, role string) ([]string, error)
 	GetAllSatcomData(ctx context.Context) ([]CommonSatcomDatum, error)
 	GetAllUsers(ctx context.Context) ([]GetAllUsersRow, error)
+	GetCompanyById(ctx context.Context, id int32) (CommonCompany, error)
+	GetCompanyByName(ctx context.Context, name string) (CommonCompany, error)
+	GetCompanyMembership(ctx context.Context, arg GetCompanyMembershipParams) (GetCompanyMembershipRow, error)
+	GetDeletedSatcomDataById(ctx context.Context, id int32) (CommonSatcomDatum, error)
+	GetLatestSatcomCommitSeq(ctx context.Context) (int64, error)
+	GetPendingSatcomChangeRequest(ctx context.Context, satcomID int32) (CommonSatcomChangeRequest, error)
+	GetSatcomAttachment(ctx context.Context, arg GetSatcomAttachmentParams) (CommonSatcomAttachment, error)
+	GetSatcomCertificate(ctx context.Context, satcomID int32) (CommonSatcomCertificate, error)
+	GetSatcomChangeRequest(ctx context.Context, id int32) (CommonSatcomChangeRequest, error)
+	GetSatcomCompanyById(ctx context.Context, id int32) (string, error)
 	GetSatcomDataById(ctx context.Context, id int32) (CommonSatcomDatum, error)
+	GetSatcomHistoryById(ctx context.Context, id int64) (CommonSatcomHistory, error)
+	GetSatcomHistoryVersion(ctx context.Context, arg GetSatcomHistoryVersionParams) (CommonSatcomHistory, error)
+	GetSatcomMaintenanceWindow(ctx context.Context, id int32) (CommonSatcomMaintenanceWindow, error)
+	GetSatcomNote(ctx context.Context, arg GetSatcomNoteParams) (CommonSatcomNote, error)
+	GetSatcomProbeLatencyStats(ctx context.Context, arg GetSatcomProbeLatencyStatsParams) ([]GetSatcomProbeLatencyStatsRow, error)
+	GetSatcomRelation(ctx context.Context, id int32) (CommonSatcomRelation, error)
+	GetSatcomSecret(ctx context.Context, arg GetSatcomSecretParams) (CommonSatcomSecret, error)
+	GetSatcomSecretVersion(ctx context.Context, arg GetSatcomSecretVersionParams) (CommonSatcomSecretVersion, error)
+	GetSatcomUptime(ctx context.Context, arg GetSatcomUptimeParams) ([]GetSatcomUptimeRow, error)
 	// --------------------- AUTHENTICATION ------------------------------
 	GetUserByEmail(ctx context.Context, email string) (CommonUser, error)
+	GetUserByIdentity(ctx context.Context, arg GetUserByIdentityParams) (CommonUser, error)
 	GetUserByUserName(ctx context.Context, userName string) (CommonUser, error)
 	GetUserByPhone(ctx context.Context, phone string) (CommonUser, error)
 	GetUserById(ctx context.Context, userID int32) (CommonUser, error)
 	GetUserByLogin(ctx context.Context, userName string) (CommonUser, error)
+	GetUserStatusById(ctx context.Context, userID int32) (string, error)
+	ImportUser(ctx context.Context, arg ImportUserParams) error
+	ListAuditLogs(ctx context.Context, arg ListAuditLogsParams) ([]CommonAuditLog, error)
+	ListCompanies(ctx context.Context) ([]CommonCompany, error)
+	ListCompanyApproverEmails(ctx context.Context, company string) ([]string, error)
+	ListCompanyMemberEmails(ctx context.Context, arg ListCompanyMemberEmailsParams) ([]string, error)
+	ListCompanyMembers(ctx context.Context, companyID int32) ([]ListCompanyMembersRow, error)
+	ListDeletedSatcomData(ctx context.Context, company pgtype.Text) ([]CommonSatcomDatum, error)
+	ListDownSatcomData(ctx context.Context, company pgtype.Text) ([]CommonSatcomDatum, error)
+	ListExpiringCertificates(ctx context.Context, arg ListExpiringCertificatesParams) ([]ListExpiringCertificatesRow, error)
+	ListMentionableUsers(ctx context.Context, arg ListMentionableUsersParams) ([]ListMentionableUsersRow, error)
+	ListProbeResults(ctx context.Context, arg ListProbeResultsParams) ([]CommonSatcomProbeResult, error)
+	ListSatcomAttachmentCleanups(ctx context.Context) ([]int32, error)
+	ListSatcomAttachments(ctx context.Context, satcomID int32) ([]CommonSatcomAttachment, error)
+	ListSatcomChangeRequests(ctx context.Context, arg ListSatcomChangeRequestsParams) ([]CommonSatcomChangeRequest, error)
+	ListSatcomConflicts(ctx context.Context, company pgtype.Text) ([]ListSatcomConflictsRow, error)
+	ListSatcomConversionIssues(ctx context.Context) ([]CommonSatcomConversionIssue, error)
+	ListSatcomData(ctx context.Context, arg ListSatcomDataParams) ([]CommonSatcomDatum, error)
+	ListSatcomDataByUrl(ctx context.Context, arg ListSatcomDataByUrlParams) ([]CommonSatcomDatum, error)
+	ListSatcomDataSorted(ctx context.Context, arg ListSatcomDataSortedParams) ([]CommonSatcomDatum, error)
+	ListSatcomHistory(ctx context.Context, satcomID int32) ([]CommonSatcomHistory, error)
+	ListSatcomHistoryAfter(ctx context.Context, arg ListSatcomHistoryAfterParams) ([]CommonSatcomHistory, error)
+	ListSatcomInventoryAsOf(ctx context.Context, arg ListSatcomInventoryAsOfParams) ([]CommonSatcomHistory, error)
+	ListSatcomLabelValues(ctx context.Context, company pgtype.Text) ([]ListSatcomLabelValuesRow, error)
+	ListSatcomMaintenanceWindows(ctx context.Context, company pgtype.Text) ([]CommonSatcomMaintenanceWindow, error)
+	ListSatcomNotes(ctx context.Context, satcomID int32) ([]CommonSatcomNote, error)
+	ListSatcomProbeResultsBetween(ctx context.Context, arg ListSatcomProbeResultsBetweenParams) ([]CommonSatcomProbeResult, error)
+	ListSatcomRelations(ctx context.Context, company pgtype.Text) ([]CommonSatcomRelation, error)
+	ListSatcomSecrets(ctx context.Context, satcomID int32) ([]CommonSatcomSecret, error)
+	ListSatcomSecretsForRotation(ctx context.Context, company pgtype.Text) ([]ListSatcomSecretsForRotationRow, error)
+	ListSatcomSecretVersions(ctx context.Context, secretID int32) ([]CommonSatcomSecretVersion, error)
+	ListSatcomSecretVersionsByKey(ctx context.Context, keyID string) ([]CommonSatcomSecretVersion, error)
+	ListUsedPortsByIp(ctx context.Context, arg ListUsedPortsByIpParams) ([]int32, error)
+	ListUserCompanies(ctx context.Context, userID int32) ([]ListUserCompaniesRow, error)
+	ListUsers(ctx context.Context, arg ListUsersParams) ([]ListUsersRow, error)
+	ListUsersSorted(ctx context.Context, arg ListUsersSortedParams) ([]ListUsersSortedRow, error)
+	LockSatcomEndpoint(ctx context.Context, endpoint string) error
+	// Serializes relation changes so that two concurrent inserts cannot close a cycle
+	LockSatcomRelations(ctx context.Context) error
+	MarkSatcomSecretReminded(ctx context.Context, id int32) error
+	PurgeSatcomData(ctx context.Context, arg PurgeSatcomDataParams) ([]int32, error)
+	RecordSatcomAttachmentCleanup(ctx context.Context, arg RecordSatcomAttachmentCleanupParams) error
+	RecordSatcomCertificateError(ctx context.Context, arg RecordSatcomCertificateErrorParams) error
+	RenameCompany(ctx context.Context, arg RenameCompanyParams) (int64, error)
+	RestoreSatcomVersion(ctx context.Context, arg RestoreSatcomVersionParams) (int64, error)
+	ReviewSatcomChangeRequest(ctx context.Context, arg ReviewSatcomChangeRequestParams) (int64, error)
+	RotateSatcomSecret(ctx context.Context, arg RotateSatcomSecretParams) (CommonSatcomSecret, error)
+	SetCertificateWarning(ctx context.Context, arg SetCertificateWarningParams) error
+	SetSatcomNotePinned(ctx context.Context, arg SetSatcomNotePinnedParams) (CommonSatcomNote, error)
+	SetSatcomNoteResolved(ctx context.Context, arg SetSatcomNoteResolvedParams) (CommonSatcomNote, error)
+	SetSatcomProbedAt(ctx context.Context, arg SetSatcomProbedAtParams) error
+	SetSatcomStatusOverride(ctx context.Context, arg SetSatcomStatusOverrideParams) (int64, error)
+	SoftDeleteSatcomData(ctx context.Context, arg SoftDeleteSatcomDataParams) (int64, error)
+	TryLockSatcomProber(ctx context.Context) (bool, error)
+	UndeleteSatcomData(ctx context.Context, id int32) (int64, error)
+	UnlockSatcomProber(ctx context.Context) (bool, error)
 	UpdatePassword(ctx context.Context, arg UpdatePasswordParams) error
+	UpdateSatcomData(ctx context.Context, arg UpdateSatcomDataParams) (int64, error)
+	UpdateSatcomMaintenanceWindow(ctx context.Context, arg UpdateSatcomMaintenanceWindowParams) (CommonSatcomMaintenanceWindow, error)
+	UpdateSatcomNoteBody(ctx context.Context, arg UpdateSatcomNoteBodyParams) (CommonSatcomNote, error)
+	UpdateSatcomProbeStatus(ctx context.Context, arg UpdateSatcomProbeStatusParams) (int64, error)
+	UpdateSatcomSecretVersionKey(ctx context.Context, arg UpdateSatcomSecretVersionKeyParams) (int64, error)
 	UpdateUser(ctx context.Context, arg UpdateUserParams) error
-	UpdateSatcomData(ctx context.Context, arg UpdateSatcomDataParams) error
+	UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) error
+	UpdateUserStatus(ctx context.Context, arg UpdateUserStatusParams) error
+	UpsertCompanyMembership(ctx context.Context, arg UpsertCompanyMembershipParams) error
+	UpsertSatcomCertificate(ctx context.Context, arg UpsertSatcomCertificateParams) error
+	UsersShareCompany(ctx context.Context, arg UsersShareCompanyParams) (bool, error)
 }
 
 var _ Querier = (*Queries)(nil)
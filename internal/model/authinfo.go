@@ -2,6 +2,7 @@ package model
 
 import (
 	"encoding/json"
+	"sort"
 
 	"github.com/dgrijalva/jwt-go"
 )
@@ -43,6 +44,22 @@ func IsValidRole(roleMap map[string]interface{}) bool {
 	return true
 }
 
+// IsValidRoleName returns true if the role is one of the known roles
+func IsValidRoleName(role string) bool {
+	_, isFound := _ValidRoles[role]
+	return isFound
+}
+
+// ValidRoleNames returns the known roles in alphabetical order
+func ValidRoleNames() []string {
+	roles := make([]string, 0, len(_ValidRoles))
+	for role := range _ValidRoles {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
+
 // ACLInfo contains entry of acl_info table
 type ACLInfo struct {
 	Action  string `json:"action"`
@@ -76,10 +93,36 @@ type AuthorizationClaims struct {
 	UserID   int32  `json:"user_id"`
 	Email    string `json:"email"`
 	UserName string `json:"user_name"`
+	Role     string `json:"role,omitempty"`
+	// CompanyID and Company name the active company that satcom data is scoped to
+	CompanyID int32  `json:"company_id,omitempty"`
+	Company   string `json:"company,omitempty"`
+	// Act identifies the administrator acting as this user (RFC 8693 actor claim)
+	Act *ActorClaim `json:"act,omitempty"`
 	jwt.StandardClaims
 }
 
+// ActorClaim is the real identity behind an impersonation token
+type ActorClaim struct {
+	UserID   int32  `json:"user_id"`
+	Email    string `json:"email"`
+	UserName string `json:"user_name"`
+}
+
 type AuthServiceConfig struct {
 	JWTKey     *string  `json:"jwtKey"`
 	BypassAuth []string `json:"bypassAuth"`
+	ScimToken  *string  `json:"scimToken"`
+
+	IdentityProviders []IdentityProviderConfig `json:"identityProviders"`
+	Prober            *ProberConfig            `json:"prober"`
+	Certificates      *CertificateConfig       `json:"certificates"`
+	RecycleBin        *RecycleBinConfig        `json:"recycleBin"`
+	SatcomFields      []SatcomFieldDefinition  `json:"satcomFields"`
+	Discovery         *DiscoveryConfig         `json:"discovery"`
+	Approvals         *ApprovalConfig          `json:"approvals"`
+	Vault             *VaultConfig             `json:"vault"`
+	Attachments       *AttachmentConfig        `json:"attachments"`
+	Stats             *StatsConfig             `json:"stats"`
+	Events            *EventsConfig            `json:"events"`
 }
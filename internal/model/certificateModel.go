@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// CertificateConfig configures the scheduled TLS certificate check of HTTPS satcom urls
+type CertificateConfig struct {
+	Enabled         bool `json:"enabled"`
+	IntervalMinutes int  `json:"intervalMinutes"`
+	TimeoutMs       int  `json:"timeoutMs"`
+	// WarningDays are the remaining-validity thresholds that trigger a warning email
+	WarningDays []int `json:"warningDays"`
+	// NotifyEmails receive the warnings; active SUPER_ADMIN users when empty
+	NotifyEmails []string `json:"notifyEmails"`
+}
+
+// CertificateInfo describes the leaf certificate served for a satcom url
+type CertificateInfo struct {
+	SatcomID      int32      `json:"satcomId"`
+	Company       string     `json:"company,omitempty"`
+	URL           string     `json:"url,omitempty"`
+	Host          string     `json:"host"`
+	CheckedAt     time.Time  `json:"checkedAt"`
+	Subject       string     `json:"subject,omitempty"`
+	Issuer        string     `json:"issuer,omitempty"`
+	SANs          []string   `json:"sans,omitempty"`
+	SerialNumber  string     `json:"serialNumber,omitempty"`
+	NotBefore     *time.Time `json:"notBefore,omitempty"`
+	NotAfter      *time.Time `json:"notAfter,omitempty"`
+	DaysRemaining *int       `json:"daysRemaining,omitempty"`
+	HostnameMatch *bool      `json:"hostnameMatch,omitempty"`
+	ChainValid    *bool      `json:"chainValid,omitempty"`
+	Error         string     `json:"error,omitempty"`
+}
@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// CompanyInput creates or renames a company
+type CompanyInput struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// CompanyMembershipInput sets the role of a user in a company: VIEWER, EDITOR, APPROVER or ADMIN
+type CompanyMembershipInput struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// SwitchCompanyInput selects the active company carried by the JWT; 0 clears it
+type SwitchCompanyInput struct {
+	CompanyID int32 `json:"company_id"`
+}
+
+// CompanyResponse is a company, with the caller's role in it when listed for a member
+type CompanyResponse struct {
+	ID        int32      `json:"id"`
+	Name      string     `json:"name"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	Role      string     `json:"role,omitempty"`
+}
+
+// CompanyMember is a user's membership of a company
+type CompanyMember struct {
+	UserID   int32     `json:"user_id"`
+	UserName string    `json:"user_name"`
+	Email    string    `json:"email"`
+	Role     string    `json:"role"`
+	Since    time.Time `json:"since"`
+}
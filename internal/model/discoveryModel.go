@@ -0,0 +1,59 @@
+package model
+
+// DiscoveryConfig configures the service discovery exports of the satcom inventory
+type DiscoveryConfig struct {
+	// NginxTemplate is the path of the Go template rendered by GET /api/satcom/sd/nginx;
+	// the built-in upstream/server template is used when empty
+	NginxTemplate string `json:"nginxTemplate"`
+}
+
+// PrometheusTargetGroup is one element of a Prometheus http_sd_config target list
+type PrometheusTargetGroup struct {
+	Targets []string          `json:"targets"`
+	Labels  map[string]string `json:"labels"`
+}
+
+// AnsibleGroup is a group of an Ansible dynamic inventory
+type AnsibleGroup struct {
+	Hosts    []string `json:"hosts,omitempty"`
+	Children []string `json:"children,omitempty"`
+}
+
+// DiscoveryTemplateData is the data an nginx template is executed with. Params holds
+// the query parameters of the request, e.g. ?domain=example.com.
+type DiscoveryTemplateData struct {
+	Entries []DiscoveryEntry
+	Groups  []DiscoveryGroup
+	Params  map[string]string
+}
+
+// DiscoveryEntry is a satcom entry as seen by templates. Ports are 0 when unset and
+// Address is ip:ui_port, empty when either is missing. EffectiveStatus is up, down or
+// maintenance.
+type DiscoveryEntry struct {
+	ID              int32
+	Name            string
+	Company         string
+	Category        string
+	Type            string
+	URL             string
+	Host            string
+	IP              string
+	DbPort          int32
+	UIPort          int32
+	Address         string
+	Status          bool
+	EffectiveStatus string
+	Labels          map[string]string
+	CustomFields    map[string]interface{}
+}
+
+// DiscoveryGroup holds the entries of one company and category. Name is a slug such as
+// acme_database; Servers are the entries that have an Address.
+type DiscoveryGroup struct {
+	Name     string
+	Company  string
+	Category string
+	Entries  []DiscoveryEntry
+	Servers  []DiscoveryEntry
+}
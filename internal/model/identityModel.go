@@ -0,0 +1,71 @@
+package model
+
+// IdentityProviderConfig configures one entry of the identityProviders list.
+// Providers are tried in the configured order during password login.
+type IdentityProviderConfig struct {
+	Name         string            `json:"name"`
+	Type         string            `json:"type"` // local, ldap or oidc
+	AttributeMap map[string]string `json:"attributeMap,omitempty"`
+	RoleMap      map[string]string `json:"roleMap,omitempty"`
+	// AllowedRoles are the roles the provider may assign besides DefaultRole; never SUPER_ADMIN
+	AllowedRoles    []string `json:"allowedRoles,omitempty"`
+	DefaultRole     string   `json:"defaultRole,omitempty"`
+	JITProvisioning bool     `json:"jitProvisioning"`
+	// LinkByEmail links a first login to the existing user with the same, verified email
+	LinkByEmail    bool `json:"linkByEmail"`
+	SyncAttributes bool `json:"syncAttributes"`
+	// SyncRole lets SyncAttributes overwrite the role of linked users as well
+	SyncRole bool        `json:"syncRole"`
+	LDAP     *LDAPConfig `json:"ldap,omitempty"`
+	OIDC     *OIDCConfig `json:"oidc,omitempty"`
+}
+
+// LDAPConfig configures simple-bind authentication. Either BindDNTemplate
+// (e.g. "uid=%s,ou=people,dc=example,dc=com") binds the user directly, or the
+// service account searches SearchBase for SearchAttribute=login first. ldap:// URLs
+// should set StartTLS, otherwise passwords are sent in the clear; ldaps:// uses TLS throughout.
+type LDAPConfig struct {
+	URL                string `json:"url"`
+	StartTLS           bool   `json:"startTls,omitempty"`
+	BindDNTemplate     string `json:"bindDnTemplate,omitempty"`
+	ServiceDN          string `json:"serviceDn,omitempty"`
+	ServicePassword    string `json:"servicePassword,omitempty"`
+	SearchBase         string `json:"searchBase,omitempty"`
+	SearchAttribute    string `json:"searchAttribute,omitempty"`
+	TimeoutMs          int    `json:"timeoutMs,omitempty"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty"`
+}
+
+// OIDCConfig configures an upstream OpenID Connect provider (authorization code flow).
+// Endpoints left empty are read from the issuer discovery document.
+type OIDCConfig struct {
+	Issuer       string `json:"issuer"`
+	ClientID     string `json:"clientId"`
+	ClientSecret string `json:"clientSecret"`
+	RedirectURL  string `json:"redirectUrl"`
+	// FrontendURL receives the browser after the callback, with a one-time login code
+	// in the code parameter to redeem at /api/auth/oidc/token
+	FrontendURL           string   `json:"frontendUrl,omitempty"`
+	Scopes                []string `json:"scopes,omitempty"`
+	AuthorizationEndpoint string   `json:"authorizationEndpoint,omitempty"`
+	TokenEndpoint         string   `json:"tokenEndpoint,omitempty"`
+	JWKSURI               string   `json:"jwksUri,omitempty"`
+	PasswordGrant         bool     `json:"passwordGrant,omitempty"`
+}
+
+// LoginCodeInput redeems the one-time code of a federated login
+type LoginCodeInput struct {
+	Code string `json:"code"`
+}
+
+// ExternalIdentity is the profile an identity provider vouches for after authentication
+type ExternalIdentity struct {
+	Provider string `json:"provider"`
+	Subject  string `json:"subject"`
+	UserName string `json:"userName"`
+	Email    string `json:"email"`
+	Phone    string `json:"phone"`
+	Role     string `json:"role"`
+	// EmailVerified is set when the provider vouches that the user owns Email
+	EmailVerified bool `json:"emailVerified"`
+}
@@ -0,0 +1,11 @@
+package model
+
+// PageResult wraps one page of a list endpoint together with its paging metadata
+type PageResult struct {
+	Items      interface{} `json:"items"`
+	Total      int64       `json:"total"`
+	Limit      int32       `json:"limit"`
+	Offset     int32       `json:"offset"`
+	Page       int32       `json:"page,omitempty"`
+	NextCursor string      `json:"nextCursor,omitempty"`
+}
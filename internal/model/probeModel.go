@@ -0,0 +1,45 @@
+package model
+
+import "time"
+
+// ProberConfig configures the background health prober of satcom entries
+type ProberConfig struct {
+	Enabled         bool `json:"enabled"`
+	IntervalSeconds int  `json:"intervalSeconds"`
+	TimeoutMs       int  `json:"timeoutMs"`
+	Concurrency     int  `json:"concurrency"`
+	RetentionDays   int  `json:"retentionDays"`
+	// InsecureSkipVerify probes HTTPS endpoints with self-signed certificates
+	InsecureSkipVerify bool `json:"insecureSkipVerify"`
+	// DeniedNetworks are CIDRs, or private, link-local and loopback, the prober never
+	// connects to; unset denies link-local
+	DeniedNetworks []string `json:"deniedNetworks"`
+}
+
+// ProbeResult is the outcome of one check against a satcom entry
+type ProbeResult struct {
+	CheckType  string    `json:"checkType"`
+	Target     string    `json:"target"`
+	Success    bool      `json:"success"`
+	LatencyMs  int32     `json:"latencyMs"`
+	StatusCode int32     `json:"statusCode,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	ProbedAt   time.Time `json:"probedAt"`
+}
+
+// UptimeSummary aggregates the probe results of one check type over a window
+type UptimeSummary struct {
+	CheckType     string     `json:"checkType"`
+	Total         int64      `json:"total"`
+	Up            int64      `json:"up"`
+	UptimePercent float64    `json:"uptimePercent"`
+	AvgLatencyMs  float64    `json:"avgLatencyMs"`
+	LastProbedAt  *time.Time `json:"lastProbedAt,omitempty"`
+}
+
+// SatcomStatusInput sets or clears the manual status override of a satcom entry.
+// With override false the prober derives the status again from the next probe.
+type SatcomStatusInput struct {
+	Status   *bool `json:"status"`
+	Override bool  `json:"override"`
+}
@@ -0,0 +1,42 @@
+package model
+
+import "time"
+
+// ApprovalConfig puts updates and deletes of production satcom entries, those matching
+// LabelSelector, behind a change request that a second user has to approve
+type ApprovalConfig struct {
+	Enabled bool `json:"enabled"`
+	// LabelSelector selects the entries needing approval; "env in (prod,production)" when empty
+	LabelSelector string `json:"labelSelector"`
+	// NotifyEmails receive new change requests; the company's APPROVER and ADMIN members when empty
+	NotifyEmails []string `json:"notifyEmails"`
+}
+
+// SatcomChangeReviewInput carries the approver's comment; returning a request requires one
+type SatcomChangeReviewInput struct {
+	Comment string `json:"comment"`
+}
+
+// SatcomChangeRequest is a requested update, delete or status change of a production entry.
+// Current is the entry the change was requested against, Proposed the entry after an update
+// or status change, and Changes the columns it changes.
+type SatcomChangeRequest struct {
+	ID              int32               `json:"id"`
+	SatcomID        int32               `json:"satcom_id"`
+	Company         string              `json:"company"`
+	Operation       string              `json:"operation"`
+	Status          string              `json:"status"`
+	BaseVersion     int32               `json:"base_version"`
+	AllowConflicts  bool                `json:"allow_conflicts"`
+	Comment         string              `json:"comment,omitempty"`
+	RequestedAt     time.Time           `json:"requested_at"`
+	RequestedBy     *int32              `json:"requested_by"`
+	RequestedByName string              `json:"requested_by_name,omitempty"`
+	ReviewedAt      *time.Time          `json:"reviewed_at,omitempty"`
+	ReviewedBy      *int32              `json:"reviewed_by,omitempty"`
+	ReviewedByName  string              `json:"reviewed_by_name,omitempty"`
+	ReviewComment   string              `json:"review_comment,omitempty"`
+	Current         interface{}         `json:"current"`
+	Proposed        interface{}         `json:"proposed,omitempty"`
+	Changes         []SatcomFieldChange `json:"changes"`
+}
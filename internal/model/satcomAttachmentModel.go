@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// AttachmentConfig enables file attachments on satcom entries. The objects are stored in
+// the bucket of the aws configuration block (s3_bucket, s3_region, s3_folder, keys).
+type AttachmentConfig struct {
+	Enabled bool `json:"enabled"`
+	// MaxSizeMB limits the size of one file; 25 when zero
+	MaxSizeMB int `json:"maxSizeMB"`
+	// DownloadExpiryMinutes is how long a presigned download url stays valid; 5 when zero
+	DownloadExpiryMinutes int `json:"downloadExpiryMinutes"`
+}
+
+// SatcomAttachment describes a file attached to a satcom entry
+type SatcomAttachment struct {
+	ID             int32     `json:"id"`
+	SatcomID       int32     `json:"satcom_id"`
+	FileName       string    `json:"file_name"`
+	SizeBytes      int64     `json:"size_bytes"`
+	ContentType    string    `json:"content_type"`
+	Description    string    `json:"description"`
+	UploadedAt     time.Time `json:"uploaded_at"`
+	UploadedBy     *int32    `json:"uploaded_by"`
+	UploadedByName string    `json:"uploaded_by_name,omitempty"`
+}
+
+// SatcomAttachmentDownload is a presigned url that downloads an attachment until ExpiresAt
+type SatcomAttachmentDownload struct {
+	URL       string    `json:"url"`
+	FileName  string    `json:"file_name"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
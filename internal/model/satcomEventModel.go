@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// EventsConfig configures the change feed
+type EventsConfig struct {
+	// AllowedOrigins lists the origins (scheme://host[:port]) of pages that may open the
+	// WebSocket feed in addition to the API's own host
+	AllowedOrigins []string `json:"allowedOrigins"`
+}
+
+// SatcomEvent is a change of a satcom entry pushed by the change feed. Operation is the
+// history operation (CREATE, UPDATE, DELETE, UNDELETE, RESTORE, STATUS or PURGE) and Data
+// the entry after the change. ID is the resume token: reconnecting with it replays the
+// events that followed. READY events only carry the token the stream starts from.
+type SatcomEvent struct {
+	ID        string      `json:"id"`
+	Operation string      `json:"operation"`
+	SatcomID  int32       `json:"satcom_id,omitempty"`
+	Version   int32       `json:"version,omitempty"`
+	Company   string      `json:"company,omitempty"`
+	ChangedAt *time.Time  `json:"changed_at,omitempty"`
+	ChangedBy *int32      `json:"changed_by,omitempty"`
+	Data      interface{} `json:"data,omitempty"`
+}
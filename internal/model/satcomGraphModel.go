@@ -0,0 +1,74 @@
+package model
+
+import "time"
+
+// SatcomRelationInput relates the entry in the path to target_id. The entry relies on
+// the target, e.g. a UI DEPENDS_ON its API.
+type SatcomRelationInput struct {
+	TargetID int32  `json:"target_id" binding:"required"`
+	Relation string `json:"relation" binding:"required"`
+}
+
+// SatcomRelation is a typed edge of the dependency graph; SourceID relies on TargetID
+type SatcomRelation struct {
+	ID        int32     `json:"id"`
+	SourceID  int32     `json:"source_id"`
+	TargetID  int32     `json:"target_id"`
+	Relation  string    `json:"relation"`
+	CreatedAt time.Time `json:"created_at"`
+	CreatedBy *int32    `json:"created_by"`
+}
+
+// SatcomGraphNode is a satcom entry as a node of the dependency graph
+type SatcomGraphNode struct {
+	ID       int32  `json:"id"`
+	Name     string `json:"name,omitempty"`
+	Company  string `json:"company"`
+	Category string `json:"category"`
+	Type     string `json:"type"`
+	URL      string `json:"url"`
+	Status   bool   `json:"status"`
+}
+
+// SatcomRelatedEntry is a direct relation of an entry together with the entry on its
+// other end
+type SatcomRelatedEntry struct {
+	Relation SatcomRelation  `json:"relation"`
+	Entry    SatcomGraphNode `json:"entry"`
+}
+
+// SatcomEntryRelations lists the direct relations of an entry. Upstream are the entries
+// it relies on, Downstream the entries relying on it.
+type SatcomEntryRelations struct {
+	ID         int32                `json:"id"`
+	Upstream   []SatcomRelatedEntry `json:"upstream"`
+	Downstream []SatcomRelatedEntry `json:"downstream"`
+}
+
+// SatcomDependency is an entry reached through the relations of another entry. Depth
+// counts the hops, Via is the entry it was reached from.
+type SatcomDependency struct {
+	SatcomGraphNode
+	Depth    int    `json:"depth"`
+	Via      int32  `json:"via"`
+	Relation string `json:"relation"`
+}
+
+// SatcomClosure is the transitive upstream or downstream of an entry
+type SatcomClosure struct {
+	ID        int32              `json:"id"`
+	Direction string             `json:"direction"`
+	Entries   []SatcomDependency `json:"entries"`
+}
+
+// SatcomGraph is the dependency graph of the entries in scope
+type SatcomGraph struct {
+	Nodes []SatcomGraphNode `json:"nodes"`
+	Edges []SatcomRelation  `json:"edges"`
+}
+
+// SatcomImpact is a down entry with the downstream entries affected by the outage
+type SatcomImpact struct {
+	Entry    SatcomGraphNode    `json:"entry"`
+	Impacted []SatcomDependency `json:"impacted"`
+}
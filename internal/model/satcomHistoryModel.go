@@ -0,0 +1,47 @@
+package model
+
+import "time"
+
+// RecycleBinConfig configures the job that purges soft-deleted satcom entries
+type RecycleBinConfig struct {
+	Enabled         bool `json:"enabled"`
+	RetentionDays   int  `json:"retentionDays"`
+	IntervalMinutes int  `json:"intervalMinutes"`
+}
+
+// SatcomVersion is one entry of the satcom history. Data holds the full entry as it
+// was after the change, in the format of the requested API version.
+type SatcomVersion struct {
+	Version       int32       `json:"version"`
+	Operation     string      `json:"operation"`
+	ChangedAt     time.Time   `json:"changed_at"`
+	ChangedBy     *int32      `json:"changed_by"`
+	ChangedByName string      `json:"changed_by_name,omitempty"`
+	ActorID       *int32      `json:"actor_id,omitempty"`
+	ActorName     string      `json:"actor_name,omitempty"`
+	RestoredFrom  *int32      `json:"restored_from,omitempty"`
+	Data          interface{} `json:"data"`
+}
+
+// SatcomFieldChange is one column that differs between two versions
+type SatcomFieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// SatcomVersionDiff lists the changed columns between two versions of an entry
+type SatcomVersionDiff struct {
+	ID      int32               `json:"id"`
+	From    int32               `json:"from"`
+	To      int32               `json:"to"`
+	Changes []SatcomFieldChange `json:"changes"`
+}
+
+// DeletedSatcomEntry is an entry in the recycle bin
+type DeletedSatcomEntry struct {
+	Data       interface{} `json:"data"`
+	DeletedAt  time.Time   `json:"deleted_at"`
+	DeletedBy  *int32      `json:"deleted_by"`
+	PurgeAfter *time.Time  `json:"purge_after"`
+}
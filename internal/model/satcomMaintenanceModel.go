@@ -0,0 +1,53 @@
+package model
+
+import "time"
+
+// SatcomMaintenanceInput schedules maintenance for one entry (satcom_id) or for the
+// entries of a company matching label_selector. With a recurrence, a cron expression
+// such as "0 2 * * 0", the window repeats at every match from starts_at on, lasting
+// ends_at - starts_at each time, until recur_until.
+type SatcomMaintenanceInput struct {
+	SatcomID      *int32     `json:"satcom_id"`
+	LabelSelector string     `json:"label_selector"`
+	Company       string     `json:"company"`
+	StartsAt      time.Time  `json:"starts_at" binding:"required"`
+	EndsAt        time.Time  `json:"ends_at" binding:"required"`
+	Recurrence    string     `json:"recurrence"`
+	RecurUntil    *time.Time `json:"recur_until"`
+	Timezone      string     `json:"timezone"`
+	Reason        string     `json:"reason"`
+}
+
+// SatcomMaintenanceWindow is a scheduled maintenance window
+type SatcomMaintenanceWindow struct {
+	ID            int32      `json:"id"`
+	Company       string     `json:"company"`
+	SatcomID      *int32     `json:"satcom_id"`
+	LabelSelector string     `json:"label_selector,omitempty"`
+	StartsAt      time.Time  `json:"starts_at"`
+	EndsAt        time.Time  `json:"ends_at"`
+	Recurrence    string     `json:"recurrence,omitempty"`
+	RecurUntil    *time.Time `json:"recur_until,omitempty"`
+	Timezone      string     `json:"timezone"`
+	Reason        string     `json:"reason"`
+	CreatedAt     time.Time  `json:"created_at"`
+	CreatedBy     *int32     `json:"created_by"`
+}
+
+// SatcomMaintenanceOccurrence is one occurrence of a maintenance window
+type SatcomMaintenanceOccurrence struct {
+	WindowID int32     `json:"window_id"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+	Reason   string    `json:"reason"`
+}
+
+// UpcomingSatcomMaintenance is an occurrence in the upcoming-windows calendar together
+// with the entries its window currently covers
+type UpcomingSatcomMaintenance struct {
+	SatcomMaintenanceOccurrence
+	Company       string  `json:"company"`
+	LabelSelector string  `json:"label_selector,omitempty"`
+	Recurrence    string  `json:"recurrence,omitempty"`
+	SatcomIDs     []int32 `json:"satcom_ids"`
+}
@@ -0,0 +1,39 @@
+package model
+
+// SatcomManifest is the declarative satcom inventory applied by /api/satcom/apply
+type SatcomManifest struct {
+	Entries []SatcomManifestEntry `json:"entries"`
+}
+
+// SatcomManifestEntry is one desired satcom entry, identified by its stable name
+type SatcomManifestEntry struct {
+	Name string `json:"name"`
+	SatcomDataInput
+}
+
+// SatcomPlanAction is one step of a manifest plan. Action is CREATE, UPDATE, DELETE,
+// UNCHANGED, UNMANAGED (an entry missing from the manifest, kept because prune is off) or
+// ERROR. Entry is the position of the manifest entry, starting at 1.
+type SatcomPlanAction struct {
+	Action         string              `json:"action"`
+	Entry          int                 `json:"entry,omitempty"`
+	Name           string              `json:"name,omitempty"`
+	ID             *int32              `json:"id,omitempty"`
+	Adopted        bool                `json:"adopted,omitempty"`
+	Changes        []SatcomFieldChange `json:"changes,omitempty"`
+	Errors         []FieldError        `json:"errors,omitempty"`
+	ConflictingIDs []int32             `json:"conflicting_ids,omitempty"`
+}
+
+// SatcomPlan is the difference between a manifest and the stored inventory
+type SatcomPlan struct {
+	DryRun    bool               `json:"dry_run"`
+	Prune     bool               `json:"prune"`
+	Create    int                `json:"create"`
+	Update    int                `json:"update"`
+	Delete    int                `json:"delete"`
+	Unchanged int                `json:"unchanged"`
+	Unmanaged int                `json:"unmanaged"`
+	Failed    int                `json:"failed"`
+	Actions   []SatcomPlanAction `json:"actions"`
+}
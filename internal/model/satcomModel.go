@@ -1,22 +1,35 @@
 package model
 
-// SatcomDataInput represents the input for creating/updating satcom data
+import (
+	"encoding/json"
+	"time"
+)
+
+// SatcomDataInput represents the input for creating/updating satcom data.
+// recorded_at (RFC 3339) supersedes the legacy date/time pair, which is still accepted.
+// Omitted labels and custom_fields keep their current values on update.
 type SatcomDataInput struct {
-	Company  string `json:"company" binding:"required"`
-	Category string `json:"category" binding:"required"`
-	Type     string `json:"type" binding:"required"`
-	Date     string `json:"date" binding:"required"`
-	Time     string `json:"time" binding:"required"`
-	DbPort   string `json:"db_port" binding:"required"`
-	UiPort   string `json:"ui_port" binding:"required"`
-	URL      string `json:"url" binding:"required"`
-	IP       string `json:"ip" binding:"required"`
-	Status   bool   `json:"status"`
+	Company    string     `json:"company"`
+	Category   string     `json:"category" binding:"required"`
+	Type       string     `json:"type" binding:"required"`
+	RecordedAt string     `json:"recorded_at"`
+	Date       string     `json:"date"`
+	Time       string     `json:"time"`
+	DbPort     FlexString `json:"db_port" binding:"required"`
+	UiPort     FlexString `json:"ui_port" binding:"required"`
+	URL        string     `json:"url" binding:"required"`
+	IP         string     `json:"ip" binding:"required"`
+	Status     bool       `json:"status"`
+
+	Labels       map[string]string      `json:"labels"`
+	CustomFields map[string]interface{} `json:"custom_fields"`
 }
 
-// SatcomDataResponse represents the response model for satcom data
+// SatcomDataResponse represents the response model for satcom data (API version 1,
+// every value rendered as a string)
 type SatcomDataResponse struct {
 	ID       int32  `json:"id"`
+	Name     string `json:"name,omitempty"`
 	Company  string `json:"company"`
 	Category string `json:"category"`
 	Type     string `json:"type"`
@@ -27,5 +40,129 @@ type SatcomDataResponse struct {
 	URL      string `json:"url"`
 	IP       string `json:"ip"`
 	Status   bool   `json:"status"`
+
+	Labels       map[string]string      `json:"labels"`
+	CustomFields map[string]interface{} `json:"custom_fields"`
+	// EffectiveStatus is up, down, or maintenance while Maintenance is open; only live
+	// entries carry it, history versions do not
+	EffectiveStatus string                       `json:"effective_status,omitempty"`
+	Maintenance     *SatcomMaintenanceOccurrence `json:"maintenance,omitempty"`
+}
+
+// SatcomDataResponseV2 is the typed response model for satcom data (API version 2).
+// Values that could not be converted from legacy text are null, and so is the name of
+// entries not managed by an inventory manifest.
+type SatcomDataResponseV2 struct {
+	ID         int32      `json:"id"`
+	Name       *string    `json:"name"`
+	Company    string     `json:"company"`
+	Category   string     `json:"category"`
+	Type       string     `json:"type"`
+	RecordedAt *time.Time `json:"recorded_at"`
+	DbPort     *int32     `json:"db_port"`
+	UiPort     *int32     `json:"ui_port"`
+	URL        string     `json:"url"`
+	IP         *string    `json:"ip"`
+	Status     bool       `json:"status"`
+	// StatusOverride is true while status is set manually instead of by the prober
+	StatusOverride bool       `json:"status_override"`
+	LastProbedAt   *time.Time `json:"last_probed_at"`
+	// Version is the row version; the ETag header carries the same value
+	Version int32 `json:"version"`
+
+	Labels       map[string]string      `json:"labels"`
+	CustomFields map[string]interface{} `json:"custom_fields"`
+	// EffectiveStatus is up, down, or maintenance while Maintenance is open; only live
+	// entries carry it, history versions do not
+	EffectiveStatus string                       `json:"effective_status,omitempty"`
+	Maintenance     *SatcomMaintenanceOccurrence `json:"maintenance,omitempty"`
+}
+
+// SatcomFieldDefinition declares a custom field of satcom entries. Type is string, number,
+// integer, boolean or date (YYYY-MM-DD); Allowed, when set, lists the only accepted values.
+type SatcomFieldDefinition struct {
+	Name        string        `json:"name"`
+	Type        string        `json:"type"`
+	Required    bool          `json:"required"`
+	Allowed     []interface{} `json:"allowed,omitempty"`
+	Description string        `json:"description,omitempty"`
 }
 
+// SatcomLabelValue is a label in use and the number of entries carrying it
+type SatcomLabelValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// SatcomConversionIssue is a legacy value that could not be converted to its typed column
+type SatcomConversionIssue struct {
+	SatcomID    int32     `json:"satcom_id"`
+	Column      string    `json:"column"`
+	LegacyValue string    `json:"legacy_value"`
+	Error       string    `json:"error"`
+	ReportedAt  time.Time `json:"reported_at"`
+}
+
+// SatcomConflict is an existing entry that claims the same address as the submitted one.
+// Fields lists the clashing parts: ip+db_port, ip+ui_port or url.
+type SatcomConflict struct {
+	ID      int32    `json:"id,omitempty"`
+	Company string   `json:"company,omitempty"`
+	URL     string   `json:"url,omitempty"`
+	Fields  []string `json:"fields"`
+	// OtherCompany marks a clash with an entry of another company, which stays anonymous
+	OtherCompany bool `json:"otherCompany,omitempty"`
+}
+
+// SatcomConflictGroup is one endpoint (ip:port or url) claimed by more than one entry of a company
+type SatcomConflictGroup struct {
+	Company   string  `json:"company"`
+	Kind      string  `json:"kind"`
+	Endpoint  string  `json:"endpoint"`
+	SatcomIDs []int32 `json:"satcom_ids"`
+}
+
+// SatcomImportRowResult reports the outcome of one import row. Action is CREATE, UPDATE,
+// UNCHANGED or ERROR; ID is the created or matched entry.
+type SatcomImportRowResult struct {
+	Row            int          `json:"row"`
+	URL            string       `json:"url"`
+	Action         string       `json:"action"`
+	ID             *int32       `json:"id,omitempty"`
+	Errors         []FieldError `json:"errors,omitempty"`
+	ConflictingIDs []int32      `json:"conflicting_ids,omitempty"`
+}
+
+// SatcomImportReport summarizes a satcom import request
+type SatcomImportReport struct {
+	DryRun         bool                    `json:"dry_run"`
+	Mode           string                  `json:"mode"`
+	Total          int                     `json:"total"`
+	Created        int                     `json:"created"`
+	Updated        int                     `json:"updated"`
+	Unchanged      int                     `json:"unchanged"`
+	Failed         int                     `json:"failed"`
+	Columns        map[string]string       `json:"columns"`
+	IgnoredColumns []string                `json:"ignored_columns"`
+	Rows           []SatcomImportRowResult `json:"rows"`
+}
+
+// FlexString accepts either a JSON string or a JSON number, so clients may keep
+// sending ports as "5432" while newer ones send 5432
+type FlexString string
+
+// UnmarshalJSON implements json.Unmarshaler
+func (f *FlexString) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		*f = FlexString(str)
+		return nil
+	}
+	var num json.Number
+	if err := json.Unmarshal(data, &num); err != nil {
+		return err
+	}
+	*f = FlexString(num.String())
+	return nil
+}
@@ -0,0 +1,53 @@
+package model
+
+import "time"
+
+// SatcomNoteInput writes a note. Body is markdown; "@name" or "@email" mentions a member
+// of the entry's company, who is emailed. ParentID replies to the thread of that note.
+type SatcomNoteInput struct {
+	Body     string `json:"body"`
+	ParentID *int32 `json:"parent_id"`
+}
+
+// SatcomNote is a note on a satcom entry. Only the first note of a thread is pinned or
+// resolved; it carries the replies of the thread when listed.
+type SatcomNote struct {
+	ID             int32        `json:"id"`
+	SatcomID       int32        `json:"satcom_id"`
+	ParentID       *int32       `json:"parent_id"`
+	Body           string       `json:"body"`
+	Mentions       []int32      `json:"mentions"`
+	Pinned         bool         `json:"pinned"`
+	Resolved       bool         `json:"resolved"`
+	ResolvedAt     *time.Time   `json:"resolved_at,omitempty"`
+	ResolvedBy     *int32       `json:"resolved_by,omitempty"`
+	ResolvedByName string       `json:"resolved_by_name,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+	CreatedBy      *int32       `json:"created_by"`
+	CreatedByName  string       `json:"created_by_name,omitempty"`
+	UpdatedAt      *time.Time   `json:"updated_at,omitempty"`
+	Replies        []SatcomNote `json:"replies,omitempty"`
+}
+
+// SatcomTimelineEvent is one entry of the activity timeline: a note, a change of the
+// entry (with its version and changed fields), a status change or a probe result
+type SatcomTimelineEvent struct {
+	Type      string              `json:"type"`
+	At        time.Time           `json:"at"`
+	ActorName string              `json:"actor_name,omitempty"`
+	Version   *int32              `json:"version,omitempty"`
+	Operation string              `json:"operation,omitempty"`
+	Changes   []SatcomFieldChange `json:"changes,omitempty"`
+	Note      *SatcomNote         `json:"note,omitempty"`
+	Probe     *ProbeResult        `json:"probe,omitempty"`
+}
+
+// SatcomTimeline lists the events of an entry between From and To in chronological
+// order. Truncated is set when only the latest events of the range were returned.
+type SatcomTimeline struct {
+	SatcomID  int32                 `json:"satcom_id"`
+	From      time.Time             `json:"from"`
+	To        time.Time             `json:"to"`
+	Truncated bool                  `json:"truncated"`
+	Events    []SatcomTimelineEvent `json:"events"`
+}
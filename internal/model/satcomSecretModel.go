@@ -0,0 +1,61 @@
+package model
+
+import "time"
+
+// VaultConfig configures the credential vault of satcom entries. Values are encrypted
+// with a data key per version, which is in turn encrypted with the master key: 32 bytes,
+// base64 or hex encoded, read from MasterKeyFile or else the MasterKeyEnv variable.
+type VaultConfig struct {
+	Enabled       bool   `json:"enabled"`
+	MasterKeyFile string `json:"masterKeyFile"`
+	// MasterKeyEnv names the variable holding the master key; SATCOM_VAULT_KEY when empty
+	MasterKeyEnv string `json:"masterKeyEnv"`
+	// RevealRoles are the company roles allowed to reveal values; ADMIN when empty.
+	// Super admins can always reveal them.
+	RevealRoles []string `json:"revealRoles"`
+	// RotationDays is the default rotation period of a secret; 90 when zero, never when negative
+	RotationDays int `json:"rotationDays"`
+	// ReminderIntervalMinutes is how often due rotations are looked for; a day when zero
+	ReminderIntervalMinutes int `json:"reminderIntervalMinutes"`
+	// RemindEveryDays is the pause between reminders of the same overdue secret; 7 when zero
+	RemindEveryDays int `json:"remindEveryDays"`
+	// NotifyEmails receive the reminders; the company's ADMIN members when empty
+	NotifyEmails []string `json:"notifyEmails"`
+}
+
+// SatcomSecretInput creates a secret or rotates it to a new value. RotationDays
+// overrides the configured rotation period; 0 never reminds.
+type SatcomSecretInput struct {
+	Name         string `json:"name"`
+	Username     string `json:"username"`
+	Description  string `json:"description"`
+	Value        string `json:"value"`
+	RotationDays *int32 `json:"rotation_days"`
+}
+
+// SatcomSecret describes a secret of a satcom entry. Value is masked unless the secret
+// was revealed.
+type SatcomSecret struct {
+	ID           int32      `json:"id"`
+	SatcomID     int32      `json:"satcom_id"`
+	Company      string     `json:"company,omitempty"`
+	Name         string     `json:"name"`
+	Username     string     `json:"username"`
+	Description  string     `json:"description"`
+	Value        string     `json:"value"`
+	Version      int32      `json:"version"`
+	RotationDays *int32     `json:"rotation_days"`
+	RotatedAt    time.Time  `json:"rotated_at"`
+	RotationDue  *time.Time `json:"rotation_due,omitempty"`
+	Overdue      bool       `json:"overdue"`
+	CreatedAt    time.Time  `json:"created_at"`
+	CreatedBy    *int32     `json:"created_by,omitempty"`
+}
+
+// SatcomSecretVersion is one stored version of a secret value
+type SatcomSecretVersion struct {
+	Version   int32     `json:"version"`
+	KeyID     string    `json:"key_id"`
+	CreatedAt time.Time `json:"created_at"`
+	CreatedBy *int32    `json:"created_by"`
+}
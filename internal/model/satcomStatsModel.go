@@ -0,0 +1,72 @@
+package model
+
+import "time"
+
+// StatsConfig configures GET /api/satcom/stats
+type StatsConfig struct {
+	// CacheSeconds keeps computed statistics for this long per company and query; off when zero
+	CacheSeconds int `json:"cacheSeconds"`
+}
+
+// SatcomStats aggregates the live satcom inventory of the caller's companies
+type SatcomStats struct {
+	GeneratedAt time.Time                     `json:"generated_at"`
+	Company     string                        `json:"company,omitempty"`
+	Total       int64                         `json:"total"`
+	Counts      map[string][]SatcomStatsCount `json:"counts"`
+	Weekly      []SatcomWeeklyActivity        `json:"weekly"`
+	Down        SatcomDownStats               `json:"down"`
+	Latency     SatcomLatencyStats            `json:"latency"`
+	Cached      bool                          `json:"cached"`
+}
+
+// SatcomStatsCount is the number of entries with one value of a group-by dimension
+type SatcomStatsCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// SatcomWeeklyActivity counts the entries added and changed, and the status changes,
+// in the week starting at Week
+type SatcomWeeklyActivity struct {
+	Week          time.Time `json:"week"`
+	Added         int64     `json:"added"`
+	Changed       int64     `json:"changed"`
+	StatusChanges int64     `json:"status_changes"`
+}
+
+// SatcomDownStats lists the entries that are currently down; those inside a maintenance
+// window are counted in InMaintenance as well
+type SatcomDownStats struct {
+	Total         int               `json:"total"`
+	InMaintenance int               `json:"in_maintenance"`
+	Entries       []SatcomDownEntry `json:"entries"`
+}
+
+// SatcomDownEntry is an entry that is down
+type SatcomDownEntry struct {
+	ID           int32      `json:"id"`
+	Name         string     `json:"name,omitempty"`
+	Company      string     `json:"company"`
+	Category     string     `json:"category"`
+	Type         string     `json:"type"`
+	URL          string     `json:"url"`
+	LastProbedAt *time.Time `json:"last_probed_at,omitempty"`
+	Maintenance  bool       `json:"maintenance"`
+}
+
+// SatcomLatencyStats averages the latency of successful probes over Window
+type SatcomLatencyStats struct {
+	Window       string                   `json:"window"`
+	Probes       int64                    `json:"probes"`
+	AvgLatencyMs float64                  `json:"avg_latency_ms"`
+	ByCheckType  []SatcomCheckLatencyStat `json:"by_check_type"`
+}
+
+// SatcomCheckLatencyStat is the probe latency of one check type
+type SatcomCheckLatencyStat struct {
+	CheckType     string  `json:"check_type"`
+	Probes        int64   `json:"probes"`
+	UptimePercent float64 `json:"uptime_percent"`
+	AvgLatencyMs  float64 `json:"avg_latency_ms"`
+}
@@ -10,7 +10,6 @@ type CreateUserInput struct {
 	Password string `json:"password"`
 	Phone    string `json:"phone"`
 	UserName string `json:"userName,omitempty"`
-	Role     string `json:"role,omitempty"`
 }
 
 type UpdateUserInput struct {
@@ -19,4 +18,45 @@ type UpdateUserInput struct {
 	Phone    string `json:"phone"`
 	UserName string `json:"userName"`
 	Role     string `json:"role,omitempty"`
-}
\ No newline at end of file
+}
+
+// UserDirectoryFields lists the user attributes that may be requested through
+// the fields query parameter. Credentials (pass, otp) are never exposed.
+var UserDirectoryFields = map[string]bool{
+	"code":      true,
+	"name":      true,
+	"email":     true,
+	"phone":     true,
+	"role":      true,
+	"status":    true,
+	"createdAt": true,
+}
+
+// UserImportRow is one user record of a bulk import file
+type UserImportRow struct {
+	UserName string `json:"userName,omitempty"`
+	Email    string `json:"email"`
+	Phone    string `json:"phone"`
+	Role     string `json:"role,omitempty"`
+	Status   string `json:"status,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// UserImportRowResult reports the validation/creation outcome of one import row
+type UserImportRowResult struct {
+	Row      int      `json:"row"`
+	UserName string   `json:"userName"`
+	Email    string   `json:"email"`
+	Status   string   `json:"status"`
+	Errors   []string `json:"errors,omitempty"`
+	Invited  bool     `json:"invited,omitempty"`
+}
+
+// UserImportReport summarizes a bulk import request
+type UserImportReport struct {
+	DryRun  bool                  `json:"dryRun"`
+	Total   int                   `json:"total"`
+	Created int                   `json:"created"`
+	Failed  int                   `json:"failed"`
+	Rows    []UserImportRowResult `json:"rows"`
+}
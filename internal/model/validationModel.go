@@ -0,0 +1,12 @@
+package model
+
+// FieldError describes why one input field was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors is the payload of a 400 response caused by invalid fields
+type ValidationErrors struct {
+	Errors []FieldError `json:"errors"`
+}
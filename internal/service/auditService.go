@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+)
+
+// recordAudit writes one audit_log row attributed to the caller. When the caller
+// holds an impersonation token the administrator behind it is stored as the actor.
+func (s *RESTService) recordAudit(ctx context.Context, c *gin.Context, action string, detail interface{}) {
+	if err := auth.New(s.dbConn.GetPool()).CreateAuditLog(ctx, s.auditLogParams(c, action, detail)); err != nil {
+		_asLogger.Errorf("Error writing audit log %s: %v", action, err)
+	}
+}
+
+// auditLogParams builds the audit row for the current request, for callers that must write
+// it inside their own transaction
+func (s *RESTService) auditLogParams(c *gin.Context, action string, detail interface{}) auth.CreateAuditLogParams {
+	params := auth.CreateAuditLogParams{Action: action}
+	if claims := s.currentClaims(c); claims != nil {
+		params.UserID = ConvertInt32ToPgInt4(claims.UserID)
+		params.UserName = getSQLString(claims.UserName)
+		if claims.Act != nil {
+			params.ActorID = ConvertInt32ToPgInt4(claims.Act.UserID)
+			params.ActorName = getSQLString(claims.Act.UserName)
+			params.Impersonated = true
+		}
+	}
+	if c != nil {
+		params.Method = getSQLString(c.Request.Method)
+		params.Path = getSQLString(c.Request.URL.Path)
+		if c.Writer.Written() {
+			params.StatusCode = ConvertInt32ToPgInt4(int32(c.Writer.Status()))
+		}
+	}
+	if detail != nil {
+		data, err := json.Marshal(detail)
+		if err != nil {
+			_asLogger.Errorf("Error encoding audit detail for %s: %v", action, err)
+		}
+		params.Detail = data
+	}
+	return params
+}
+
+// /api/audit - browse the audit trail (SUPER_ADMIN only)
+func (s *RESTService) listAuditLogs(c *gin.Context) APIResponse {
+	if !s.hasRole(c, ROLE_SUPER_ADMIN) {
+		return BuildResponse403("Only super admins can view the audit log")
+	}
+	// Newest entries first; the order is fixed
+	pq, err := parsePageQuery(c, nil, "id")
+	if err != nil {
+		return BuildResponse400(err.Error())
+	}
+
+	var filter auth.CountAuditLogsParams
+	if v := c.Query("userId"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return BuildResponse400("Invalid userId")
+		}
+		filter.UserID = ConvertInt32ToPgInt4(int32(id))
+	}
+	if v := c.Query("actorId"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return BuildResponse400("Invalid actorId")
+		}
+		filter.ActorID = ConvertInt32ToPgInt4(int32(id))
+	}
+	if v := c.Query("impersonated"); v != "" {
+		flag, err := strconv.ParseBool(v)
+		if err != nil {
+			return BuildResponse400("Invalid impersonated flag")
+		}
+		filter.Impersonated = pgtype.Bool{Bool: flag, Valid: true}
+	}
+	filter.Action = optionalText(strings.ToUpper(c.Query("action")))
+
+	ctx := context.Background()
+	db := s.dbConn.GetPool()
+	qtx := auth.New(db)
+
+	logs, err := qtx.ListAuditLogs(ctx, auth.ListAuditLogsParams{
+		UserID:       filter.UserID,
+		ActorID:      filter.ActorID,
+		Impersonated: filter.Impersonated,
+		Action:       filter.Action,
+		RowLimit:     pq.Limit,
+		RowOffset:    pq.Offset,
+	})
+	if err != nil {
+		_asLogger.Errorf("Error getting audit log: %v", err)
+		return BuildResponse500("Failed to retrieve audit log", err.Error())
+	}
+	total, err := qtx.CountAuditLogs(ctx, filter)
+	if err != nil {
+		_asLogger.Errorf("Error counting audit log: %v", err)
+		return BuildResponse500("Failed to retrieve audit log", err.Error())
+	}
+
+	items := make([]map[string]interface{}, 0, len(logs))
+	for _, entry := range logs {
+		row := map[string]interface{}{
+			"id":           entry.ID,
+			"occurredAt":   entry.OccurredAt.Time,
+			"userId":       entry.UserID.Int32,
+			"userName":     entry.UserName.String,
+			"impersonated": entry.Impersonated,
+			"action":       entry.Action,
+			"method":       entry.Method.String,
+			"path":         entry.Path.String,
+			"statusCode":   entry.StatusCode.Int32,
+		}
+		if entry.ActorID.Valid {
+			row["actorId"] = entry.ActorID.Int32
+			row["actorName"] = entry.ActorName.String
+		}
+		if len(entry.Detail) > 0 {
+			row["detail"] = json.RawMessage(entry.Detail)
+		}
+		items = append(items, row)
+	}
+
+	return BuildResponse200("Audit log retrieved successfully", model.PageResult{
+		Items:  items,
+		Total:  total,
+		Limit:  pq.Limit,
+		Offset: pq.Offset,
+		Page:   pq.Page,
+	})
+}
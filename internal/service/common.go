@@ -5,19 +5,30 @@ import (
 	"encoding/json"
 
 	"crypto/sha256"
+	"encoding/base64"
 
 	"database/sql"
 
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"math"
+	"net/http"
+	"path/filepath"
 	"reflect"
 	"regexp"
+	"strconv"
+	"strings"
 
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
 	"github.com/jackc/pgx/v5/pgtype"
 
+	"github.com/rest/api/internal/model"
 	"github.com/rest/api/internal/util"
 	"github.com/sirupsen/logrus"
 )
@@ -52,6 +63,214 @@ func parseInput(c *gin.Context, obj interface{}) bool {
 	return true
 }
 
+// validateInput evaluates the binding tags of a parsed request body and returns
+// one error per rejected field, named after its json key
+func validateInput(obj interface{}) []model.FieldError {
+	err := binding.Validator.ValidateStruct(obj)
+	if err == nil {
+		return nil
+	}
+	validationErrs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return []model.FieldError{{Field: "", Message: err.Error()}}
+	}
+	objType := reflect.TypeOf(obj)
+	for objType.Kind() == reflect.Ptr {
+		objType = objType.Elem()
+	}
+	fieldErrs := make([]model.FieldError, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		name := fe.Field()
+		if field, found := objType.FieldByName(fe.StructField()); found {
+			if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag != "" && tag != "-" {
+				name = tag
+			}
+		}
+		msg := fmt.Sprintf("failed on the '%s' rule", fe.Tag())
+		if fe.Tag() == "required" {
+			msg = "is required"
+		}
+		fieldErrs = append(fieldErrs, model.FieldError{Field: name, Message: msg})
+	}
+	return fieldErrs
+}
+
+// requestAPIVersion reads the API version from the X-API-Version header or the
+// api_version query parameter; version 1 is the default
+func requestAPIVersion(c *gin.Context) (int, error) {
+	v := c.GetHeader("X-API-Version")
+	if v == "" {
+		v = c.Query("api_version")
+	}
+	if v == "" {
+		return API_VERSION_1, nil
+	}
+	version, err := strconv.Atoi(strings.TrimPrefix(strings.ToLower(v), "v"))
+	if err != nil || version < API_VERSION_1 || version > API_VERSION_2 {
+		return 0, fmt.Errorf("unsupported API version %s", v)
+	}
+	return version, nil
+}
+
+// pageQuery holds the paging, sorting and cursor parameters of a list request
+type pageQuery struct {
+	Limit    int32
+	Offset   int32
+	Page     int32
+	SortBy   string
+	SortDesc bool
+	AfterID  pgtype.Int4
+}
+
+// parsePageQuery reads limit, offset/page, sort and cursor query parameters.
+// sort accepts a column name optionally prefixed with '-' for descending order.
+func parsePageQuery(c *gin.Context, sortable map[string]bool, defaultSort string) (pageQuery, error) {
+	pq := pageQuery{Limit: DEFAULT_PAGE_LIMIT, SortBy: defaultSort}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return pq, fmt.Errorf("invalid limit")
+		}
+		if limit > MAX_PAGE_LIMIT {
+			limit = MAX_PAGE_LIMIT
+		}
+		pq.Limit = int32(limit)
+	}
+	if v := c.Query("page"); v != "" {
+		page, err := strconv.ParseInt(v, 10, 32)
+		if err != nil || page < 1 {
+			return pq, fmt.Errorf("invalid page")
+		}
+		// The offset is an int4 in the queries
+		if offset := (page - 1) * int64(pq.Limit); offset > math.MaxInt32 {
+			return pq, fmt.Errorf("page out of range")
+		}
+		pq.Page = int32(page)
+		pq.Offset = int32(page-1) * pq.Limit
+	} else if v := c.Query("offset"); v != "" {
+		offset, err := strconv.ParseInt(v, 10, 32)
+		if err != nil || offset < 0 {
+			return pq, fmt.Errorf("invalid offset")
+		}
+		pq.Offset = int32(offset)
+	}
+	if v := c.Query("sort"); v != "" {
+		pq.SortDesc = strings.HasPrefix(v, "-")
+		pq.SortBy = strings.TrimPrefix(v, "-")
+		if !sortable[pq.SortBy] {
+			return pq, fmt.Errorf("unsupported sort field %s", pq.SortBy)
+		}
+	}
+	if v := c.Query("cursor"); v != "" {
+		if pq.SortBy != defaultSort || pq.SortDesc {
+			return pq, fmt.Errorf("cursor pagination is only supported with the default sort order")
+		}
+		if pq.Offset > 0 {
+			return pq, fmt.Errorf("cursor cannot be combined with offset or page")
+		}
+		id, err := decodeCursor(v)
+		if err != nil {
+			return pq, err
+		}
+		pq.AfterID = pgtype.Int4{Int32: id, Valid: true}
+	}
+	return pq, nil
+}
+
+// encodeCursor builds an opaque cursor from the id of the last returned row
+func encodeCursor(id int32) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(int(id))))
+}
+
+func decodeCursor(cursor string) (int32, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	id, err := strconv.Atoi(string(raw))
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	return int32(id), nil
+}
+
+// optionalText converts an empty query value into a NULL parameter
+func optionalText(str string) pgtype.Text {
+	if str == "" {
+		return pgtype.Text{}
+	}
+	return getSQLString(str)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// optionalLikeText is optionalText for search terms matched with ILIKE '%' || term || '%'
+// ESCAPE '\', with the wildcards escaped so that the term matches literally
+func optionalLikeText(str string) pgtype.Text {
+	return optionalText(likeEscaper.Replace(str))
+}
+
+// readUpload returns the uploaded document and its format (csv, json, ...).
+// The document is taken from the multipart field "file" when present, otherwise from the raw body.
+// The format comes from the format query parameter, the file extension or the content type, in that order.
+// Documents larger than MAX_UPLOAD_SIZE are rejected.
+func readUpload(c *gin.Context) ([]byte, string, error) {
+	format := strings.ToLower(c.Query("format"))
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MAX_UPLOAD_SIZE)
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				return nil, "", errUploadTooLarge
+			}
+			return nil, "", fmt.Errorf("multipart field 'file' is required")
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			return nil, "", err
+		}
+		defer file.Close()
+		data, err := readAllLimited(file)
+		if err != nil {
+			return nil, "", err
+		}
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fileHeader.Filename)), ".")
+		}
+		return data, format, nil
+	}
+	data, err := readAllLimited(c.Request.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	if format == "" {
+		switch ct := c.ContentType(); {
+		case strings.Contains(ct, "csv"):
+			format = "csv"
+		case strings.Contains(ct, "json"):
+			format = "json"
+		case strings.Contains(ct, "yaml"):
+			format = "yaml"
+		case strings.Contains(ct, "spreadsheetml"):
+			format = "xlsx"
+		}
+	}
+	return data, format, nil
+}
+
+var errUploadTooLarge = fmt.Errorf("upload exceeds the limit of %d MB", MAX_UPLOAD_SIZE>>20)
+
+// readAllLimited reads r up to MAX_UPLOAD_SIZE and fails beyond it
+func readAllLimited(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MAX_UPLOAD_SIZE+1))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) || len(data) > MAX_UPLOAD_SIZE {
+		return nil, errUploadTooLarge
+	}
+	return data, err
+}
+
 func buildResponse(status int, isOk bool, msg string, payload interface{}) APIResponse {
 	return APIResponse{
 		StatusCode: status,
@@ -81,6 +300,26 @@ func BuildResponse400(msg string) APIResponse {
 	}
 }
 
+// BuildValidationResponse reports field level validation errors
+func BuildValidationResponse(errs []model.FieldError) APIResponse {
+	return APIResponse{
+		StatusCode: 400,
+		IsSuccess:  false,
+		Message:    "Invalid input provided",
+		Payload:    model.ValidationErrors{Errors: errs},
+		ServiceTS:  time.Now().Format("2006-01-02-15:04:05.000"),
+	}
+}
+
+func BuildResponse403(msg string) APIResponse {
+	return APIResponse{
+		StatusCode: 403,
+		IsSuccess:  false,
+		Message:    msg,
+		ServiceTS:  time.Now().Format("2006-01-02-15:04:05.000"),
+	}
+}
+
 func BuildResponse404(msg string, success bool) APIResponse {
 	return APIResponse{
 		StatusCode: 404,
@@ -90,6 +329,15 @@ func BuildResponse404(msg string, success bool) APIResponse {
 	}
 }
 
+func BuildResponse412(msg string) APIResponse {
+	return APIResponse{
+		StatusCode: 412,
+		IsSuccess:  false,
+		Message:    msg,
+		ServiceTS:  time.Now().Format("2006-01-02-15:04:05.000"),
+	}
+}
+
 func BuildResponse200(msg string, payload interface{}) APIResponse {
 	return APIResponse{
 		StatusCode: 200,
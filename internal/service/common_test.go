@@ -0,0 +1,104 @@
+package service
+
+import (
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestOptionalLikeText(t *testing.T) {
+	tests := map[string]string{
+		"acme":      "acme",
+		"50%":       `50\%`,
+		"db_port":   `db\_port`,
+		`c:\temp`:   `c:\\temp`,
+		`%_\`:       `\%\_\\`,
+		"10.0.0.1":  "10.0.0.1",
+		"a-b [x]*?": "a-b [x]*?",
+	}
+	for in, want := range tests {
+		got := optionalLikeText(in)
+		if !got.Valid || got.String != want {
+			t.Errorf("optionalLikeText(%q) = %q, want %q", in, got.String, want)
+		}
+	}
+	if optionalLikeText("").Valid {
+		t.Error("empty search must be NULL")
+	}
+}
+
+func TestListSortFieldsAreOrdered(t *testing.T) {
+	queries, err := os.ReadFile("../../config/sqlc/db_query/db-queries.sql")
+	if err != nil {
+		t.Fatalf("read queries: %v", err)
+	}
+	query := func(name string) string {
+		start := strings.Index(string(queries), "-- name: "+name+" ")
+		if start < 0 {
+			t.Fatalf("query %s not found", name)
+		}
+		end := strings.Index(string(queries[start:]), ";\n")
+		return string(queries[start : start+end])
+	}
+	tests := []struct {
+		query  string
+		fields map[string]bool
+	}{
+		{query("ListSatcomDataSorted"), satcomSortFields},
+		{query("ListUsersSorted"), userSortFields},
+	}
+	for _, tc := range tests {
+		for field := range tc.fields {
+			// id is the tie breaker of every order; date and time are aliases of recorded_at
+			if field == "id" || field == "date" || field == "time" {
+				continue
+			}
+			for _, direction := range []string{"AND NOT sqlc.arg('sort_desc')::bool", "AND sqlc.arg('sort_desc')::bool"} {
+				if !strings.Contains(tc.query, "sqlc.arg('sort_by')::text = '"+field+"' "+direction) {
+					t.Errorf("sort field %s is not ordered by (%s) in\n%s", field, direction, tc.query)
+				}
+			}
+		}
+	}
+}
+
+func TestParsePageQuery(t *testing.T) {
+	tests := []struct {
+		query  string
+		offset int32
+		err    string
+	}{
+		{"", 0, ""},
+		{"page=3&limit=20", 40, ""},
+		{"offset=15", 15, ""},
+		{"page=0", 0, "invalid page"},
+		{"page=2147483647&limit=1", 2147483646, ""},
+		{"page=2147483647&limit=2", 0, "page out of range"},
+		{"page=21474838&limit=100", 0, "page out of range"},
+		{"page=4294967297", 0, "invalid page"},
+		{"offset=2147483648", 0, "invalid offset"},
+		{"offset=-1", 0, "invalid offset"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/?"+tt.query, nil)
+			pq, err := parsePageQuery(c, map[string]bool{"id": true}, "id")
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("err = %v, want %s", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err = %v", err)
+			}
+			if pq.Offset != tt.offset {
+				t.Errorf("offset = %d, want %d", pq.Offset, tt.offset)
+			}
+		})
+	}
+}
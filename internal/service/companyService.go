@@ -0,0 +1,508 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+)
+
+// satcomScope is the tenant a request is limited to. Super admins, and every caller
+// when authentication is disabled, see all companies; everyone else works inside the
+// active company carried by their JWT with the role of their membership.
+type satcomScope struct {
+	all       bool
+	company   string
+	companyID int32
+	role      string
+}
+
+// satcomScope resolves the caller's tenant. The role and membership are looked up on every
+// request so that demoted users, removed members and renamed companies take effect immediately.
+func (s *RESTService) satcomScope(c *gin.Context) (satcomScope, *APIResponse) {
+	if s.jwtSigningKey == nil {
+		return satcomScope{all: true}, nil
+	}
+	claims := s.currentClaims(c)
+	if claims == nil {
+		resp := BuildResponse403("No authenticated user")
+		return satcomScope{}, &resp
+	}
+	qtx := auth.New(s.dbConn.GetPool())
+	if s.isSuperAdmin(context.Background(), qtx, claims) {
+		return satcomScope{all: true}, nil
+	}
+	if claims.CompanyID == 0 {
+		resp := BuildResponse403("No active company; ask an administrator to add you to a company")
+		return satcomScope{}, &resp
+	}
+
+	membership, err := qtx.GetCompanyMembership(context.Background(), auth.GetCompanyMembershipParams{
+		UserID:    claims.UserID,
+		CompanyID: claims.CompanyID,
+	})
+	if err != nil {
+		resp := BuildResponse403("You are not a member of the active company")
+		return satcomScope{}, &resp
+	}
+	return satcomScope{company: membership.CompanyName, companyID: membership.CompanyID, role: membership.Role}, nil
+}
+
+// filter is the company filter for list queries; null matches every company
+func (scope satcomScope) filter() pgtype.Text {
+	if scope.all {
+		return pgtype.Text{}
+	}
+	return optionalText(scope.company)
+}
+
+// contains reports whether rows of the company are visible to the caller
+func (scope satcomScope) contains(company string) bool {
+	return scope.all || company == scope.company
+}
+
+// containsHistory reports whether a history row is visible to the caller. History is
+// keyed on the company id, so versions recorded before a rename stay with the company.
+func (scope satcomScope) containsHistory(row auth.CommonSatcomHistory) bool {
+	return scope.all || historyInCompany(row, scope.companyID, scope.company)
+}
+
+// historyInCompany reports whether a history row belongs to the company. Rows of
+// companies removed since then carry no id and are matched on the snapshot's name.
+func historyInCompany(row auth.CommonSatcomHistory, id int32, name string) bool {
+	if row.CompanyID.Valid {
+		return row.CompanyID.Int32 == id
+	}
+	data, err := satcomDatumFromSnapshot(row.Snapshot)
+	return err == nil && data.Company == name
+}
+
+// filterID resolves a company filter, already narrowed by restrict, to the company id
+// that history rows are keyed on
+func (scope satcomScope) filterID(ctx context.Context, qtx *auth.Queries, filter pgtype.Text) (pgtype.Int4, error) {
+	if !filter.Valid {
+		return pgtype.Int4{}, nil
+	}
+	if !scope.all {
+		return ConvertInt32ToPgInt4(scope.companyID), nil
+	}
+	company, err := qtx.GetCompanyByName(ctx, filter.String)
+	if err != nil {
+		return pgtype.Int4{}, fmt.Errorf("company %s is not registered", filter.String)
+	}
+	return ConvertInt32ToPgInt4(company.ID), nil
+}
+
+// canWrite reports whether the caller may create, change or delete entries
+func (scope satcomScope) canWrite() bool {
+	return scope.all || scope.role == COMPANY_ROLE_EDITOR || scope.role == COMPANY_ROLE_APPROVER || scope.role == COMPANY_ROLE_ADMIN
+}
+
+// canApprove reports whether the caller may approve or return change requests
+func (scope satcomScope) canApprove() bool {
+	return scope.all || scope.role == COMPANY_ROLE_APPROVER || scope.role == COMPANY_ROLE_ADMIN
+}
+
+// restrict narrows a requested company filter to the scope. It fails when the
+// caller asks for a company outside of it.
+func (scope satcomScope) restrict(filter *pgtype.Text) error {
+	if scope.all {
+		return nil
+	}
+	if filter.Valid && filter.String != scope.company {
+		return fmt.Errorf("company filter must be your active company %s", scope.company)
+	}
+	*filter = scope.filter()
+	return nil
+}
+
+// writeDenied answers 403 for callers whose role in the active company is read-only
+func (scope satcomScope) writeDenied() *APIResponse {
+	if scope.canWrite() {
+		return nil
+	}
+	resp := BuildResponse403(fmt.Sprintf("Your role in %s does not allow changes", scope.company))
+	return &resp
+}
+
+// authorizeSatcomID parses the :id parameter and checks that the entry belongs to the
+// caller's scope. Entries of other companies are reported as not found.
+func (s *RESTService) authorizeSatcomID(c *gin.Context, write bool) (int32, satcomScope, *APIResponse) {
+	id, errResp := parseSatcomID(c)
+	if errResp != nil {
+		return 0, satcomScope{}, errResp
+	}
+	scope, errResp := s.satcomScope(c)
+	if errResp != nil {
+		return 0, scope, errResp
+	}
+	if write {
+		if errResp := scope.writeDenied(); errResp != nil {
+			return 0, scope, errResp
+		}
+	}
+	if !scope.all {
+		company, err := auth.New(s.dbConn.GetPool()).GetSatcomCompanyById(context.Background(), id)
+		if err != nil || company != scope.company {
+			resp := BuildResponse404("Satcom data not found", false)
+			return 0, scope, &resp
+		}
+	}
+	return id, scope, nil
+}
+
+// assignSatcomCompany fills in the company of a submitted entry and checks it. An empty
+// company keeps fallback (the stored company on updates) or else the active company.
+func assignSatcomCompany(ctx context.Context, qtx *auth.Queries, scope satcomScope, input *model.SatcomDataInput, fallback string) []model.FieldError {
+	input.Company = strings.TrimSpace(input.Company)
+	if input.Company == "" {
+		input.Company = fallback
+	}
+	if input.Company == "" && !scope.all {
+		input.Company = scope.company
+	}
+	if input.Company == "" {
+		return []model.FieldError{{Field: "company", Message: "is required"}}
+	}
+	if !scope.contains(input.Company) {
+		return []model.FieldError{{Field: "company", Message: fmt.Sprintf("must be your active company %s", scope.company)}}
+	}
+	if _, err := qtx.GetCompanyByName(ctx, input.Company); err != nil {
+		return []model.FieldError{{Field: "company", Message: "is not a registered company"}}
+	}
+	return nil
+}
+
+// defaultCompany picks the active company of a fresh token: the user's first company by name
+func (s *RESTService) defaultCompany(ctx context.Context, userID int32) (int32, string) {
+	companies, err := auth.New(s.dbConn.GetPool()).ListUserCompanies(ctx, userID)
+	if err != nil {
+		_asLogger.Errorf("Error getting companies of user %d: %v", userID, err)
+		return 0, ""
+	}
+	if len(companies) == 0 {
+		return 0, ""
+	}
+	return companies[0].CompanyID, companies[0].CompanyName
+}
+
+// /api/auth/switch-company - reissue the caller's token with another active company
+func (s *RESTService) switchCompany(c *gin.Context) APIResponse {
+	if s.jwtSigningKey == nil {
+		return BuildResponse400("Switching company requires JWT authentication to be enabled")
+	}
+	claims := s.currentClaims(c)
+	if claims == nil {
+		return BuildResponse400("No authenticated user")
+	}
+	var input model.SwitchCompanyInput
+	if !parseInput(c, &input) {
+		return BuildResponse400("Invalid input provided")
+	}
+
+	ctx := context.Background()
+	db := s.dbConn.GetPool()
+	qtx := auth.New(db)
+
+	next := *claims
+	next.CompanyID, next.Company = 0, ""
+	role := ""
+	if input.CompanyID != 0 {
+		if claims.Role == ROLE_SUPER_ADMIN {
+			company, err := qtx.GetCompanyById(ctx, input.CompanyID)
+			if err != nil {
+				return BuildResponse404("Company not found", false)
+			}
+			next.CompanyID, next.Company = company.ID, company.Name
+		} else {
+			membership, err := qtx.GetCompanyMembership(ctx, auth.GetCompanyMembershipParams{
+				UserID:    claims.UserID,
+				CompanyID: input.CompanyID,
+			})
+			if err != nil {
+				return BuildResponse403("You are not a member of this company")
+			}
+			next.CompanyID, next.Company = membership.CompanyID, membership.CompanyName
+			role = membership.Role
+		}
+	} else if claims.Role != ROLE_SUPER_ADMIN {
+		return BuildResponse400("company_id is required")
+	}
+	// The new token keeps the expiry of the one it replaces
+	next.IssuedAt = time.Now().Unix()
+	token := s.signClaims(next)
+	if token == "" {
+		return BuildResponse500("Failed to issue token", nil)
+	}
+
+	response := BuildResponse200("Active company switched", map[string]interface{}{
+		"company_id":   next.CompanyID,
+		"company":      next.Company,
+		"company_role": role,
+		"token":        token,
+	})
+	response.Token = &token
+	return response
+}
+
+// /api/companies - companies visible to the caller: all for super admins, otherwise their memberships
+func (s *RESTService) listCompanies(c *gin.Context) APIResponse {
+	ctx := context.Background()
+	db := s.dbConn.GetPool()
+	qtx := auth.New(db)
+
+	claims := s.currentClaims(c)
+	if s.hasRole(c, ROLE_SUPER_ADMIN) {
+		companies, err := qtx.ListCompanies(ctx)
+		if err != nil {
+			_asLogger.Errorf("Error getting companies: %v", err)
+			return BuildResponse500("Failed to retrieve companies", err.Error())
+		}
+		responseList := make([]model.CompanyResponse, 0, len(companies))
+		for _, company := range companies {
+			responseList = append(responseList, toCompanyResponse(company))
+		}
+		return BuildResponse200("Companies retrieved successfully", responseList)
+	}
+	if claims == nil {
+		return BuildResponse400("No authenticated user")
+	}
+
+	memberships, err := qtx.ListUserCompanies(ctx, claims.UserID)
+	if err != nil {
+		_asLogger.Errorf("Error getting companies of user %d: %v", claims.UserID, err)
+		return BuildResponse500("Failed to retrieve companies", err.Error())
+	}
+	responseList := make([]model.CompanyResponse, 0, len(memberships))
+	for _, membership := range memberships {
+		responseList = append(responseList, model.CompanyResponse{
+			ID:   membership.CompanyID,
+			Name: membership.CompanyName,
+			Role: membership.Role,
+		})
+	}
+	return BuildResponse200("Companies retrieved successfully", responseList)
+}
+
+// /api/companies - register a company (SUPER_ADMIN only)
+func (s *RESTService) createCompany(c *gin.Context) APIResponse {
+	if !s.hasRole(c, ROLE_SUPER_ADMIN) {
+		return BuildResponse403("Only super admins can create companies")
+	}
+	var input model.CompanyInput
+	if !parseInput(c, &input) || strings.TrimSpace(input.Name) == "" {
+		return BuildResponse400("Invalid input provided")
+	}
+
+	ctx := context.Background()
+	db := s.dbConn.GetPool()
+	qtx := auth.New(db)
+
+	name := strings.TrimSpace(input.Name)
+	if _, err := qtx.GetCompanyByName(ctx, name); err == nil {
+		return buildResponse(409, false, "Company already exists", nil)
+	}
+	company, err := qtx.CreateCompany(ctx, name)
+	if err != nil {
+		_asLogger.Errorf("Error creating company %s: %v", name, err)
+		return BuildResponse500("Failed to create company", err.Error())
+	}
+	return BuildResponse200("Company created successfully", toCompanyResponse(company))
+}
+
+// /api/companies/:id - rename a company; its satcom entries follow through the
+// foreign key (SUPER_ADMIN only)
+func (s *RESTService) renameCompany(c *gin.Context) APIResponse {
+	if !s.hasRole(c, ROLE_SUPER_ADMIN) {
+		return BuildResponse403("Only super admins can rename companies")
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return BuildResponse400("Invalid company id")
+	}
+	var input model.CompanyInput
+	if !parseInput(c, &input) || strings.TrimSpace(input.Name) == "" {
+		return BuildResponse400("Invalid input provided")
+	}
+
+	ctx := context.Background()
+	db := s.dbConn.GetPool()
+	qtx := auth.New(db)
+
+	name := strings.TrimSpace(input.Name)
+	if existing, err := qtx.GetCompanyByName(ctx, name); err == nil && existing.ID != int32(id) {
+		return buildResponse(409, false, "Company already exists", nil)
+	}
+	renamed, err := qtx.RenameCompany(ctx, auth.RenameCompanyParams{ID: int32(id), Name: name})
+	if err != nil {
+		_asLogger.Errorf("Error renaming company %d: %v", id, err)
+		return BuildResponse500("Failed to rename company", err.Error())
+	}
+	if renamed == 0 {
+		return BuildResponse404("Company not found", false)
+	}
+	return BuildResponse200("Company renamed successfully", nil)
+}
+
+// /api/companies/:id/members - members of a company (SUPER_ADMIN or company ADMIN)
+func (s *RESTService) listCompanyMembers(c *gin.Context) APIResponse {
+	companyID, errResp := s.authorizeCompanyAdmin(c)
+	if errResp != nil {
+		return *errResp
+	}
+
+	ctx := context.Background()
+	db := s.dbConn.GetPool()
+	qtx := auth.New(db)
+
+	members, err := qtx.ListCompanyMembers(ctx, companyID)
+	if err != nil {
+		_asLogger.Errorf("Error getting members of company %d: %v", companyID, err)
+		return BuildResponse500("Failed to retrieve company members", err.Error())
+	}
+	responseList := make([]model.CompanyMember, 0, len(members))
+	for _, member := range members {
+		responseList = append(responseList, model.CompanyMember{
+			UserID:   member.UserID,
+			UserName: member.UserName,
+			Email:    member.Email,
+			Role:     member.Role,
+			Since:    member.CreatedAt.Time,
+		})
+	}
+	return BuildResponse200("Company members retrieved successfully", responseList)
+}
+
+// /api/companies/:id/members/:userId - add a member or change their role (SUPER_ADMIN or company ADMIN)
+func (s *RESTService) setCompanyMember(c *gin.Context) APIResponse {
+	companyID, errResp := s.authorizeCompanyAdmin(c)
+	if errResp != nil {
+		return *errResp
+	}
+	userID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		return BuildResponse400("Invalid user id")
+	}
+	var input model.CompanyMembershipInput
+	if !parseInput(c, &input) {
+		return BuildResponse400("Invalid input provided")
+	}
+	role := strings.ToUpper(strings.TrimSpace(input.Role))
+	if role != COMPANY_ROLE_VIEWER && role != COMPANY_ROLE_EDITOR && role != COMPANY_ROLE_APPROVER && role != COMPANY_ROLE_ADMIN {
+		return BuildResponse400("role must be VIEWER, EDITOR, APPROVER or ADMIN")
+	}
+
+	ctx := context.Background()
+	db := s.dbConn.GetPool()
+	qtx := auth.New(db)
+
+	if _, err := qtx.GetUserById(ctx, int32(userID)); err != nil {
+		return BuildResponse404("User not found", false)
+	}
+	// Company admins only reach users they already share a company with; anyone else is
+	// added by a super admin
+	if claims := s.currentClaims(c); claims != nil && !s.isSuperAdmin(ctx, qtx, claims) {
+		shared, err := qtx.UsersShareCompany(ctx, auth.UsersShareCompanyParams{UserID: claims.UserID, OtherUserID: int32(userID)})
+		if err != nil {
+			_asLogger.Errorf("Error checking companies of user %d: %v", userID, err)
+			return BuildResponse500("Failed to save company member", err.Error())
+		}
+		if !shared {
+			return BuildResponse404("User not found", false)
+		}
+	}
+	if err := qtx.UpsertCompanyMembership(ctx, auth.UpsertCompanyMembershipParams{
+		UserID:    int32(userID),
+		CompanyID: companyID,
+		Role:      role,
+	}); err != nil {
+		_asLogger.Errorf("Error saving membership of user %d in company %d: %v", userID, companyID, err)
+		return BuildResponse500("Failed to save company member", err.Error())
+	}
+	s.recordAudit(ctx, c, AUDIT_COMPANY_MEMBERSHIP, map[string]interface{}{
+		"companyId":    companyID,
+		"targetUserId": userID,
+		"role":         role,
+	})
+	return BuildResponse200("Company member saved successfully", nil)
+}
+
+// /api/companies/:id/members/:userId - remove a member (SUPER_ADMIN or company ADMIN)
+func (s *RESTService) removeCompanyMember(c *gin.Context) APIResponse {
+	companyID, errResp := s.authorizeCompanyAdmin(c)
+	if errResp != nil {
+		return *errResp
+	}
+	userID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		return BuildResponse400("Invalid user id")
+	}
+
+	ctx := context.Background()
+	db := s.dbConn.GetPool()
+	qtx := auth.New(db)
+
+	removed, err := qtx.DeleteCompanyMembership(ctx, auth.DeleteCompanyMembershipParams{
+		UserID:    int32(userID),
+		CompanyID: companyID,
+	})
+	if err != nil {
+		_asLogger.Errorf("Error removing user %d from company %d: %v", userID, companyID, err)
+		return BuildResponse500("Failed to remove company member", err.Error())
+	}
+	if removed == 0 {
+		return BuildResponse404("Company member not found", false)
+	}
+	s.recordAudit(ctx, c, AUDIT_COMPANY_MEMBERSHIP, map[string]interface{}{
+		"companyId":    companyID,
+		"targetUserId": userID,
+		"removed":      true,
+	})
+	return BuildResponse200("Company member removed successfully", nil)
+}
+
+// authorizeCompanyAdmin parses the :id parameter and checks that the caller is a super
+// admin or holds the ADMIN role in that company
+func (s *RESTService) authorizeCompanyAdmin(c *gin.Context) (int32, *APIResponse) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		resp := BuildResponse400("Invalid company id")
+		return 0, &resp
+	}
+	ctx := context.Background()
+	qtx := auth.New(s.dbConn.GetPool())
+	if _, err := qtx.GetCompanyById(ctx, int32(id)); err != nil {
+		resp := BuildResponse404("Company not found", false)
+		return 0, &resp
+	}
+	if s.hasRole(c, ROLE_SUPER_ADMIN) {
+		return int32(id), nil
+	}
+	claims := s.currentClaims(c)
+	if claims != nil {
+		membership, err := qtx.GetCompanyMembership(ctx, auth.GetCompanyMembershipParams{
+			UserID:    claims.UserID,
+			CompanyID: int32(id),
+		})
+		if err == nil && membership.Role == COMPANY_ROLE_ADMIN {
+			return int32(id), nil
+		}
+	}
+	resp := BuildResponse403("Only super admins and company admins can manage members")
+	return 0, &resp
+}
+
+func toCompanyResponse(company auth.CommonCompany) model.CompanyResponse {
+	createdAt := company.CreatedAt.Time
+	return model.CompanyResponse{
+		ID:        company.ID,
+		Name:      company.Name,
+		CreatedAt: &createdAt,
+	}
+}
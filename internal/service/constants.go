@@ -1,5 +1,7 @@
 package service
 
+import "time"
+
 const ERP_ROUTE = "http://118.67.213.45:8081"
 const COMPANY_LIST_API = ERP_ROUTE + "/api/external/getCompanyIds"
 const UNIT_LIST_API = ERP_ROUTE + "/api/external/getUnitByCompany"
@@ -9,6 +11,8 @@ const ADDL_PAYMENT_API_BASE = "/api/v1/addlpayments"
 const INCOME_TAX_API_BASE = "/api/v1/incometax"
 
 const AUTH_API_BASE = "/api/auth/login"
+const SCIM_API_BASE = "/scim/v2"
+const OIDC_API_BASE = "/api/auth/oidc"
 const UTIL_API_BASE = "/api/v1/utils"
 const REF_API_BASE = "/api/v1/refdata"
 const RPT_API_BASE = "/api/v1/report"
@@ -79,8 +83,159 @@ const EMPINFO_DELETE = "EMPLOYEE_GENERAL_INFO,EMPLOYEE_EMPLOYMENT_INFO,EMPLOYEE_
 const EMP_EXTRA_INFO = "EMPLOYEE_EXTRA_INFO"
 const EMP_GEN_INFO = "EMPLOYEE_GENERAL_INFO"
 
+// Roles and request context keys
+const ROLE_USER = "USER"
+const ROLE_SUPER_ADMIN = "SUPER_ADMIN"
+const CLAIMS_CONTEXT_KEY = "authClaims"
+
+// Impersonation and audit actions
+const IMPERSONATION_TTL = 15 * time.Minute
+const AUDIT_IMPERSONATION_START = "IMPERSONATION_START"
+const AUDIT_IMPERSONATED_REQUEST = "IMPERSONATED_REQUEST"
+const AUDIT_SATCOM_PURGE = "SATCOM_PURGE"
+const AUDIT_SATCOM_IMPORT = "SATCOM_IMPORT"
+const AUDIT_SATCOM_APPLY = "SATCOM_APPLY"
+const AUDIT_COMPANY_MEMBERSHIP = "COMPANY_MEMBERSHIP"
+const AUDIT_SATCOM_SECRET_CHANGE = "SATCOM_SECRET_CHANGE"
+const AUDIT_SATCOM_SECRET_REVEAL = "SATCOM_SECRET_REVEAL"
+
+// Roles of a user within a company
+const COMPANY_ROLE_VIEWER = "VIEWER"
+const COMPANY_ROLE_EDITOR = "EDITOR"
+const COMPANY_ROLE_APPROVER = "APPROVER"
+const COMPANY_ROLE_ADMIN = "ADMIN"
+
 // General constants
 const STATUS_ACTIVE = "ACTIVE"
+const STATUS_INACTIVE = "INACTIVE"
+
+// API versions selectable with the X-API-Version header
+const API_VERSION_1 = 1
+const API_VERSION_2 = 2
+
+// Satcom health probing
+const DEFAULT_PROBE_INTERVAL = 60 * time.Second
+const DEFAULT_PROBE_TIMEOUT = 5 * time.Second
+const DEFAULT_PROBE_CONCURRENCY = 8
+const DEFAULT_UPTIME_WINDOW = 24 * time.Hour
+const PROBE_CHECK_HTTP = "http"
+const PROBE_CHECK_DB_PORT = "db_port"
+const PROBE_CHECK_UI_PORT = "ui_port"
+
+// Named ranges of prober.deniedNetworks
+const PROBE_NETWORK_PRIVATE = "private"
+const PROBE_NETWORK_LINK_LOCAL = "link-local"
+const PROBE_NETWORK_LOOPBACK = "loopback"
+const DEFAULT_CERT_CHECK_INTERVAL = 12 * time.Hour
+const DEFAULT_CERT_EXPIRY_DAYS = 30
+
+// Operations recorded in the satcom history
+const SATCOM_OP_CREATE = "CREATE"
+const SATCOM_OP_UPDATE = "UPDATE"
+const SATCOM_OP_DELETE = "DELETE"
+const SATCOM_OP_UNDELETE = "UNDELETE"
+const SATCOM_OP_RESTORE = "RESTORE"
+const SATCOM_OP_STATUS = "STATUS"
+
+// Soft-deleted satcom entries are purged after the recycle bin retention
+const DEFAULT_RECYCLE_BIN_RETENTION = 30 * 24 * time.Hour
+const DEFAULT_PURGE_INTERVAL = time.Hour
+
+// Lowest port suggested by /api/satcom/next-free-port when no range is given
+const DEFAULT_FREE_PORT_FROM = 1024
+
+// Satcom import modes
+const SATCOM_IMPORT_INSERT = "insert"
+const SATCOM_IMPORT_UPSERT = "upsert"
+const SATCOM_EXPORT_CHUNK = 500
+
+// Largest document accepted by the import and upload endpoints
+const MAX_UPLOAD_SIZE = 16 << 20
+
+// Types of relations between satcom entries; the source relies on the target
+const SATCOM_RELATION_DEPENDS_ON = "DEPENDS_ON"
+const SATCOM_RELATION_CALLS = "CALLS"
+const SATCOM_RELATION_READS_FROM = "READS_FROM"
+const SATCOM_RELATION_WRITES_TO = "WRITES_TO"
+const SATCOM_RELATION_HOSTED_ON = "HOSTED_ON"
+
+// Effective satcom status; maintenance while a maintenance window is open
+const SATCOM_STATUS_UP = "up"
+const SATCOM_STATUS_DOWN = "down"
+const SATCOM_STATUS_MAINTENANCE = "maintenance"
+const DEFAULT_MAINTENANCE_HORIZON = 30 * 24 * time.Hour
+const MAX_MAINTENANCE_HORIZON = 366 * 24 * time.Hour
+const MAX_MAINTENANCE_OCCURRENCES = 500
+
+// Satcom change feed
+const SATCOM_EVENTS_PATH = "/api/satcom/events"
+const SATCOM_FEED_CHANNEL = "satcom_changes"
+const SATCOM_FEED_HEARTBEAT = 25 * time.Second
+const SATCOM_FEED_RETRY = 5 * time.Second
+const SATCOM_FEED_BUFFER = 256
+const SATCOM_FEED_REPLAY_CHUNK = 500
+const SATCOM_EVENT_READY = "READY"
+
+// Notification emails waiting for the mail worker
+const MAIL_QUEUE_SIZE = 1000
+
+// WebSocket clients of the change feed offer this subprotocol together with their JWT as a
+// second subprotocol "bearer.<token>"; the server selects SATCOM_EVENTS_WS_PROTOCOL
+const SATCOM_EVENTS_WS_PROTOCOL = "satcom-events"
+const SATCOM_EVENTS_WS_TOKEN_PREFIX = "bearer."
+
+// Satcom change requests of entries needing approval
+const DEFAULT_APPROVAL_SELECTOR = "env in (prod,production)"
+const SATCOM_CHANGE_PENDING_APPROVAL = "PENDING_APPROVAL"
+const SATCOM_CHANGE_APPROVED = "APPROVED"
+const SATCOM_CHANGE_RETURNED = "RETURNED"
+const SATCOM_CHANGE_WITHDRAWN = "WITHDRAWN"
+
+// Satcom credential vault
+const DEFAULT_VAULT_KEY_ENV = "SATCOM_VAULT_KEY"
+const DEFAULT_SECRET_ROTATION_DAYS = 90
+const DEFAULT_SECRET_REMINDER_INTERVAL = 24 * time.Hour
+const DEFAULT_SECRET_REMIND_EVERY = 7 * 24 * time.Hour
+const SATCOM_SECRET_MASK = "********"
+const MAX_SATCOM_SECRET_VALUE = 16 * 1024
+
+// Satcom attachments
+const SATCOM_ATTACHMENT_PREFIX = "satcom"
+const DEFAULT_ATTACHMENT_MAX_SIZE = 25 << 20
+const DEFAULT_ATTACHMENT_URL_EXPIRY = 5 * time.Minute
+const MAX_ATTACHMENT_FILES = 20
+
+// Satcom notes and activity timeline
+const MAX_SATCOM_NOTE_LENGTH = 20000
+const SATCOM_TIMELINE_NOTE = "note"
+const SATCOM_TIMELINE_CHANGE = "change"
+const SATCOM_TIMELINE_STATUS = "status"
+const SATCOM_TIMELINE_PROBE = "probe"
+const DEFAULT_TIMELINE_RANGE = 30 * 24 * time.Hour
+const DEFAULT_TIMELINE_LIMIT = 200
+const MAX_TIMELINE_LIMIT = 1000
+
+// Satcom inventory statistics
+const DEFAULT_STATS_WEEKS = 12
+const MAX_STATS_WEEKS = 104
+const DEFAULT_STATS_LATENCY_WINDOW = 24 * time.Hour
+
+// Import row and manifest plan actions besides the history operations;
+// UNMANAGED marks entries missing from an applied manifest while prune is off
+const SATCOM_ACTION_UNCHANGED = "UNCHANGED"
+const SATCOM_ACTION_ERROR = "ERROR"
+const SATCOM_ACTION_UNMANAGED = "UNMANAGED"
+
+// Types of satcom custom fields
+const SATCOM_FIELD_STRING = "string"
+const SATCOM_FIELD_NUMBER = "number"
+const SATCOM_FIELD_INTEGER = "integer"
+const SATCOM_FIELD_BOOLEAN = "boolean"
+const SATCOM_FIELD_DATE = "date"
+
+// Paging defaults for list endpoints
+const DEFAULT_PAGE_LIMIT = 50
+const MAX_PAGE_LIMIT = 500
 
 // Cache constants
 const EMP_SALARY_CACHE_KEY = "emp_saraly_info"
@@ -0,0 +1,412 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+	"github.com/rest/api/internal/util"
+)
+
+const oidcStateTTL = 10 * time.Minute
+
+// The state cookie ties a callback to the browser that started the login, and the one-time
+// login code hands the result to the frontend without putting a token into a URL
+const (
+	oidcStateCookie  = "oidc_state"
+	oidcLoginCodeTTL = time.Minute
+)
+
+// initIdentityProviders builds the configured providers; the local database is the default
+func (s *RESTService) initIdentityProviders(confs []model.IdentityProviderConfig) error {
+	if len(confs) == 0 {
+		confs = []model.IdentityProviderConfig{{Name: IDP_TYPE_LOCAL, Type: IDP_TYPE_LOCAL}}
+	}
+	s.identityProviders = make([]IdentityProvider, 0, len(confs))
+	for _, conf := range confs {
+		provider, err := NewIdentityProvider(conf, s.dbConn, s.getHashOf)
+		if err != nil {
+			return err
+		}
+		s.identityProviders = append(s.identityProviders, provider)
+		_asLogger.Infof("Identity provider %s (%s) enabled", provider.Name(), conf.Type)
+	}
+	return nil
+}
+
+func (s *RESTService) findIdentityProvider(name string) IdentityProvider {
+	for _, provider := range s.identityProviders {
+		if provider.Name() == name {
+			return provider
+		}
+	}
+	return nil
+}
+
+// resolveIdentity maps an authenticated identity to its common.users row,
+// provisioning the user just in time when the provider allows it
+func (s *RESTService) resolveIdentity(ctx context.Context, provider IdentityProvider, identity *model.ExternalIdentity) (auth.CommonUser, *APIResponse) {
+	qtx := auth.New(s.dbConn.GetPool())
+	conf := provider.Config()
+
+	if conf.Type == IDP_TYPE_LOCAL {
+		id, _ := strconv.Atoi(identity.Subject)
+		user, err := qtx.GetUserById(ctx, int32(id))
+		if err != nil {
+			resp := BuildResponse404("Invalid login credentials or password", false)
+			return user, &resp
+		}
+		return user, nil
+	}
+
+	if identity.Subject == "" || identity.Email == "" && identity.UserName == "" {
+		_asLogger.Errorf("Identity provider %s returned no subject, email or username for %s", provider.Name(), identity.Subject)
+		resp := BuildResponse400("Identity provider did not supply a subject and an email or username")
+		return auth.CommonUser{}, &resp
+	}
+	if !model.IsValidRoleName(identity.Role) {
+		identity.Role = ROLE_USER
+	}
+
+	user, err := qtx.GetUserByIdentity(ctx, auth.GetUserByIdentityParams{
+		Provider: provider.Name(),
+		Subject:  identity.Subject,
+	})
+	if err == nil {
+		return s.syncIdentity(ctx, qtx, conf, user, identity), nil
+	}
+	if err != pgx.ErrNoRows {
+		_asLogger.Errorf("Error resolving identity %s of %s: %v", identity.Subject, provider.Name(), err)
+		resp := BuildResponse500("Failed to resolve user", err.Error())
+		return user, &resp
+	}
+
+	// A matching email only links an existing user when the provider is trusted to and vouches for it
+	if identity.Email != "" {
+		if user, err = qtx.GetUserByEmail(ctx, identity.Email); err == nil {
+			if !canLinkByEmail(conf, identity) {
+				_asLogger.Errorf("Refusing to link %s of %s to existing user %d by an unverified email", identity.Subject, provider.Name(), user.UserID)
+				resp := BuildResponse403("A user with this email already exists and is not linked to this identity provider")
+				return user, &resp
+			}
+			if err := qtx.CreateUserIdentity(ctx, auth.CreateUserIdentityParams{
+				Provider: provider.Name(),
+				Subject:  identity.Subject,
+				UserID:   user.UserID,
+			}); err != nil {
+				_asLogger.Errorf("Error linking user %d to %s: %v", user.UserID, provider.Name(), err)
+				resp := BuildResponse500("Failed to link user", err.Error())
+				return user, &resp
+			}
+			_asLogger.Infof("Linked user %d to identity %s of %s", user.UserID, identity.Subject, provider.Name())
+			return s.syncIdentity(ctx, qtx, conf, user, identity), nil
+		}
+	}
+
+	if !conf.JITProvisioning {
+		resp := BuildResponse403("User is not provisioned for this service")
+		return user, &resp
+	}
+	if identity.Email == "" {
+		resp := BuildResponse400("Identity provider did not supply an email address")
+		return user, &resp
+	}
+	if _, err := qtx.GetUserByUserName(ctx, identity.UserName); err == nil {
+		resp := BuildResponse400("User with this username already exists")
+		return user, &resp
+	}
+	if identity.Phone != "" {
+		if _, err := qtx.GetUserByPhone(ctx, identity.Phone); err == nil {
+			identity.Phone = ""
+		}
+	}
+
+	tx, err := s.dbConn.GetPool().Begin(ctx)
+	if err != nil {
+		resp := BuildResponse500("Failed to provision user", err.Error())
+		return user, &resp
+	}
+	defer tx.Rollback(ctx)
+	qtx = auth.New(tx)
+	// Federated users never log in with a local password
+	err = qtx.ImportUser(ctx, auth.ImportUserParams{
+		UserName: identity.UserName,
+		Email:    identity.Email,
+		Phone:    identity.Phone,
+		Pass:     s.getHashOf(util.EncodeToString(32)),
+		PssValid: false,
+		Role:     identity.Role,
+		Status:   STATUS_ACTIVE,
+	})
+	if err != nil {
+		_asLogger.Errorf("Error provisioning user %s from %s: %v", identity.Email, provider.Name(), err)
+		resp := BuildResponse500("Failed to provision user", err.Error())
+		return user, &resp
+	}
+	if user, err = qtx.GetUserByEmail(ctx, identity.Email); err == nil {
+		err = qtx.CreateUserIdentity(ctx, auth.CreateUserIdentityParams{
+			Provider: provider.Name(),
+			Subject:  identity.Subject,
+			UserID:   user.UserID,
+		})
+	}
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+	if err != nil {
+		_asLogger.Errorf("Error linking provisioned user %s to %s: %v", identity.Email, provider.Name(), err)
+		resp := BuildResponse500("Failed to provision user", err.Error())
+		return user, &resp
+	}
+	_asLogger.Infof("Provisioned user %s from identity provider %s", identity.Email, provider.Name())
+	return user, nil
+}
+
+// canLinkByEmail reports whether a first login may be linked to the existing user with
+// the same email: the provider must be trusted to link and vouch that the email is verified
+func canLinkByEmail(conf model.IdentityProviderConfig, identity *model.ExternalIdentity) bool {
+	return conf.LinkByEmail && identity.EmailVerified && identity.Email != ""
+}
+
+// syncIdentity refreshes the phone of a linked user, and the role when syncRole allows it.
+// Super admins keep their role since providers cannot grant it back.
+func (s *RESTService) syncIdentity(ctx context.Context, qtx *auth.Queries, conf model.IdentityProviderConfig, user auth.CommonUser, identity *model.ExternalIdentity) auth.CommonUser {
+	if !conf.SyncAttributes {
+		return user
+	}
+	phone, role := user.Phone, user.Role
+	if identity.Phone != "" {
+		phone = identity.Phone
+	}
+	if conf.SyncRole && user.Role != ROLE_SUPER_ADMIN {
+		role = identity.Role
+	}
+	if phone == user.Phone && role == user.Role {
+		return user
+	}
+	err := qtx.UpdateUser(ctx, auth.UpdateUserParams{
+		UserName: user.UserName,
+		Email:    user.Email,
+		Phone:    phone,
+		Role:     role,
+		UserID:   user.UserID,
+	})
+	if err != nil {
+		_asLogger.Errorf("Error syncing user %d from %s: %v", user.UserID, conf.Name, err)
+		return user
+	}
+	user.Phone, user.Role = phone, role
+	return user
+}
+
+// /api/auth/oidc/:provider/login - redirect the browser to the upstream provider
+func (s *RESTService) oidcLogin(c *gin.Context) (string, *APIResponse) {
+	provider, ok := s.findIdentityProvider(c.Param("provider")).(RedirectIdentityProvider)
+	if !ok || provider.Config().OIDC.FrontendURL == "" {
+		resp := BuildResponse404("Identity provider not found", false)
+		return "", &resp
+	}
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		resp := BuildResponse500("Failed to start login", err.Error())
+		return "", &resp
+	}
+	nonceStr := hex.EncodeToString(nonce)
+	redirect, err := provider.AuthCodeURL(context.Background(), s.signOIDCState(provider.Name(), nonceStr), nonceStr)
+	if err != nil {
+		_asLogger.Errorf("Error building authorization url for %s: %v", provider.Name(), err)
+		resp := BuildResponse500("Identity provider unavailable", err.Error())
+		return "", &resp
+	}
+	// Lax rather than Strict: the callback is a cross-site navigation from the provider
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    nonceStr,
+		Path:     OIDC_API_BASE + "/" + provider.Name(),
+		MaxAge:   int(oidcStateTTL / time.Second),
+		Secure:   isSecureRequest(c),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return redirect, nil
+}
+
+// /api/auth/oidc/:provider/callback - redeem the authorization code and send the browser
+// back to the frontend with a one-time login code
+func (s *RESTService) oidcCallback(c *gin.Context) (string, *APIResponse) {
+	provider, ok := s.findIdentityProvider(c.Param("provider")).(RedirectIdentityProvider)
+	if !ok || provider.Config().OIDC.FrontendURL == "" {
+		resp := BuildResponse404("Identity provider not found", false)
+		return "", &resp
+	}
+	if errMsg := c.Query("error"); errMsg != "" {
+		resp := BuildResponse400("Identity provider returned " + errMsg)
+		return "", &resp
+	}
+	nonce, err := s.verifyOIDCState(provider.Name(), c.Query("state"))
+	if err != nil {
+		resp := BuildResponse400(err.Error())
+		return "", &resp
+	}
+	// A state issued to another browser, e.g. an attacker's own login, is refused
+	cookie, err := c.Cookie(oidcStateCookie)
+	if err != nil || !hmac.Equal([]byte(cookie), []byte(nonce)) {
+		resp := BuildResponse400("Login was not started in this browser, please try again")
+		return "", &resp
+	}
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     oidcStateCookie,
+		Path:     OIDC_API_BASE + "/" + provider.Name(),
+		MaxAge:   -1,
+		Secure:   isSecureRequest(c),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	code := c.Query("code")
+	if code == "" {
+		resp := BuildResponse400("Authorization code is required")
+		return "", &resp
+	}
+	ctx := context.Background()
+	identity, err := provider.Exchange(ctx, code, nonce)
+	if err != nil {
+		_asLogger.Errorf("OIDC exchange with %s failed: %v", provider.Name(), err)
+		resp := BuildResponse404("Invalid login credentials or password", false)
+		return "", &resp
+	}
+	user, errResp := s.resolveIdentity(ctx, provider, identity)
+	if errResp != nil {
+		return "", errResp
+	}
+	if user.Status != STATUS_ACTIVE {
+		resp := BuildResponse403("Account is disabled")
+		return "", &resp
+	}
+	loginCode, err := s.issueLoginCode(ctx, user.UserID)
+	if err != nil {
+		_asLogger.Errorf("Error issuing login code for user %d: %v", user.UserID, err)
+		resp := BuildResponse500("Failed to complete login", err.Error())
+		return "", &resp
+	}
+	redirect, err := url.Parse(provider.Config().OIDC.FrontendURL)
+	if err != nil {
+		resp := BuildResponse500("Invalid frontend url", err.Error())
+		return "", &resp
+	}
+	query := redirect.Query()
+	query.Set("code", loginCode)
+	redirect.RawQuery = query.Encode()
+	return redirect.String(), nil
+}
+
+// /api/auth/oidc/token - exchange a one-time login code for a token
+func (s *RESTService) redeemLoginCode(c *gin.Context) APIResponse {
+	var input model.LoginCodeInput
+	if !parseInput(c, &input) || input.Code == "" {
+		return BuildResponse400("Login code is required")
+	}
+	ctx := context.Background()
+	qtx := auth.New(s.dbConn.GetPool())
+	userID, err := qtx.ConsumeLoginCode(ctx, hashLoginCode(input.Code))
+	if err == pgx.ErrNoRows {
+		return BuildResponse404("Login code is invalid or expired", false)
+	}
+	if err != nil {
+		_asLogger.Errorf("Error redeeming login code: %v", err)
+		return BuildResponse500("Failed to complete login", err.Error())
+	}
+	user, err := qtx.GetUserById(ctx, userID)
+	if err != nil {
+		return BuildResponse404("Login code is invalid or expired", false)
+	}
+	return s.buildLoginResponse(user)
+}
+
+// issueLoginCode stores the hash of a fresh one-time code for the user and returns the code
+func (s *RESTService) issueLoginCode(ctx context.Context, userID int32) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	code := base64.RawURLEncoding.EncodeToString(raw)
+	qtx := auth.New(s.dbConn.GetPool())
+	if err := qtx.DeleteExpiredLoginCodes(ctx); err != nil {
+		return "", err
+	}
+	err := qtx.CreateLoginCode(ctx, auth.CreateLoginCodeParams{
+		CodeHash:  hashLoginCode(code),
+		UserID:    userID,
+		ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(oidcLoginCodeTTL), Valid: true},
+	})
+	return code, err
+}
+
+func hashLoginCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// isSecureRequest reports whether the browser reached the service over HTTPS,
+// directly or through a TLS terminating proxy
+func isSecureRequest(c *gin.Context) bool {
+	return c.Request.TLS != nil || strings.EqualFold(c.GetHeader("X-Forwarded-Proto"), "https")
+}
+
+// signOIDCState produces a stateless, tamper-proof state parameter carrying the nonce
+func (s *RESTService) signOIDCState(provider, nonce string) string {
+	payload := fmt.Sprintf("%s|%s|%d", provider, nonce, time.Now().Add(oidcStateTTL).Unix())
+	mac := hmac.New(sha256.New, s.stateKey())
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s *RESTService) verifyOIDCState(provider, state string) (string, error) {
+	parts := strings.SplitN(state, ".", 2)
+	if len(parts) != 2 {
+		return "", fmt.Errorf("invalid state")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", fmt.Errorf("invalid state")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("invalid state")
+	}
+	mac := hmac.New(sha256.New, s.stateKey())
+	mac.Write(payload)
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return "", fmt.Errorf("invalid state")
+	}
+	fields := strings.Split(string(payload), "|")
+	if len(fields) != 3 || fields[0] != provider {
+		return "", fmt.Errorf("invalid state")
+	}
+	expiry, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil || time.Now().Unix() > expiry {
+		return "", fmt.Errorf("login request expired, please try again")
+	}
+	return fields[1], nil
+}
+
+func (s *RESTService) stateKey() []byte {
+	if s.jwtSigningKey != nil {
+		return s.jwtSigningKey
+	}
+	return s.ephemeralKey
+}
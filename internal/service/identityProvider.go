@@ -0,0 +1,565 @@
+package service
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+	"github.com/rest/api/internal/util"
+)
+
+const IDP_TYPE_LOCAL = "local"
+const IDP_TYPE_LDAP = "ldap"
+const IDP_TYPE_OIDC = "oidc"
+
+// ErrInvalidCredentials is returned when a provider does not know the login or rejects the password
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// ErrPasswordExpired is returned by the local provider when the stored password must be reset
+var ErrPasswordExpired = errors.New("password must be reset")
+
+// ErrUnsupportedFlow is returned by providers that cannot verify a password directly
+var ErrUnsupportedFlow = errors.New("authentication flow not supported by provider")
+
+// IdentityProvider verifies login credentials and returns the identity it vouches for
+type IdentityProvider interface {
+	Name() string
+	Config() model.IdentityProviderConfig
+	Authenticate(ctx context.Context, login, password string) (*model.ExternalIdentity, error)
+}
+
+// RedirectIdentityProvider is implemented by providers using a browser redirect (authorization code) flow
+type RedirectIdentityProvider interface {
+	IdentityProvider
+	AuthCodeURL(ctx context.Context, state, nonce string) (string, error)
+	Exchange(ctx context.Context, code, nonce string) (*model.ExternalIdentity, error)
+}
+
+// NewIdentityProvider builds the provider described by conf
+func NewIdentityProvider(conf model.IdentityProviderConfig, dbConnection *util.DBConnectionWrapper, hash func(string) string) (IdentityProvider, error) {
+	if conf.Name == "" {
+		conf.Name = conf.Type
+	}
+	if conf.Type != IDP_TYPE_LOCAL {
+		if err := validateProviderRoles(conf); err != nil {
+			return nil, fmt.Errorf("identity provider %s: %w", conf.Name, err)
+		}
+	}
+	switch conf.Type {
+	case IDP_TYPE_LOCAL:
+		return &localIdentityProvider{conf: conf, dbConn: dbConnection, hash: hash}, nil
+	case IDP_TYPE_LDAP:
+		if conf.LDAP == nil || conf.LDAP.URL == "" {
+			return nil, fmt.Errorf("identity provider %s: ldap.url is required", conf.Name)
+		}
+		if conf.LDAP.BindDNTemplate == "" && conf.LDAP.SearchBase == "" {
+			return nil, fmt.Errorf("identity provider %s: ldap.bindDnTemplate or ldap.searchBase is required", conf.Name)
+		}
+		if conf.LDAP.StartTLS && !strings.HasPrefix(conf.LDAP.URL, "ldap://") {
+			return nil, fmt.Errorf("identity provider %s: ldap.startTls needs an ldap:// url", conf.Name)
+		}
+		if !conf.LDAP.StartTLS && strings.HasPrefix(conf.LDAP.URL, "ldap://") {
+			_asLogger.Warnf("Identity provider %s sends passwords in the clear; use ldaps:// or set ldap.startTls", conf.Name)
+		}
+		return &ldapIdentityProvider{conf: conf}, nil
+	case IDP_TYPE_OIDC:
+		if conf.OIDC == nil || conf.OIDC.Issuer == "" || conf.OIDC.ClientID == "" {
+			return nil, fmt.Errorf("identity provider %s: oidc.issuer and oidc.clientId are required", conf.Name)
+		}
+		if conf.OIDC.RedirectURL != "" && conf.OIDC.FrontendURL == "" {
+			return nil, fmt.Errorf("identity provider %s: oidc.frontendUrl is required for browser logins", conf.Name)
+		}
+		return &oidcIdentityProvider{conf: conf, client: &http.Client{Timeout: 10 * time.Second}}, nil
+	}
+	return nil, fmt.Errorf("identity provider %s: unknown type %s", conf.Name, conf.Type)
+}
+
+// validateProviderRoles rejects role mappings that reach outside allowedRoles. Providers
+// can never grant SUPER_ADMIN; that role is only assigned locally.
+func validateProviderRoles(conf model.IdentityProviderConfig) error {
+	for _, role := range conf.AllowedRoles {
+		if !model.IsValidRoleName(role) || role == ROLE_SUPER_ADMIN {
+			return fmt.Errorf("allowedRoles cannot contain %s", role)
+		}
+	}
+	if conf.DefaultRole != "" && (!model.IsValidRoleName(conf.DefaultRole) || conf.DefaultRole == ROLE_SUPER_ADMIN) {
+		return fmt.Errorf("invalid defaultRole %s", conf.DefaultRole)
+	}
+	for value, role := range conf.RoleMap {
+		if !providerRoleAllowed(conf, role) {
+			return fmt.Errorf("roleMap maps %s to %s which is not in allowedRoles", value, role)
+		}
+	}
+	return nil
+}
+
+func providerRoleAllowed(conf model.IdentityProviderConfig, role string) bool {
+	return role != ROLE_SUPER_ADMIN && containsString(conf.AllowedRoles, role)
+}
+
+// mapIdentity applies the attribute and role mapping of conf to a source profile
+func mapIdentity(conf model.IdentityProviderConfig, defaults map[string]string, subject string, lookup func(string) []string) *model.ExternalIdentity {
+	attr := func(key string) string {
+		name := conf.AttributeMap[key]
+		if name == "" {
+			name = defaults[key]
+		}
+		if name == "" {
+			return ""
+		}
+		if values := lookup(name); len(values) > 0 {
+			return strings.TrimSpace(values[0])
+		}
+		return ""
+	}
+	identity := &model.ExternalIdentity{
+		Provider: conf.Name,
+		Subject:  subject,
+		UserName: attr("userName"),
+		Email:    strings.ToLower(attr("email")),
+		Phone:    attr("phone"),
+		Role:     conf.DefaultRole,
+	}
+	if identity.Role == "" {
+		identity.Role = ROLE_USER
+	}
+	roleAttr := conf.AttributeMap["role"]
+	if roleAttr == "" {
+		roleAttr = defaults["role"]
+	}
+	if roleAttr != "" {
+		for _, value := range lookup(roleAttr) {
+			mapped, isFound := conf.RoleMap[value]
+			if !isFound {
+				mapped = strings.ToUpper(value)
+			}
+			if providerRoleAllowed(conf, mapped) {
+				identity.Role = mapped
+				break
+			}
+		}
+	}
+	if identity.UserName == "" {
+		identity.UserName = identity.Email
+	}
+	return identity
+}
+
+// ---------------------------- local database ----------------------------
+
+type localIdentityProvider struct {
+	conf   model.IdentityProviderConfig
+	dbConn *util.DBConnectionWrapper
+	hash   func(string) string
+}
+
+func (p *localIdentityProvider) Name() string                         { return p.conf.Name }
+func (p *localIdentityProvider) Config() model.IdentityProviderConfig { return p.conf }
+
+// Authenticate checks the SHA-256 hash stored in common.users
+func (p *localIdentityProvider) Authenticate(ctx context.Context, login, password string) (*model.ExternalIdentity, error) {
+	user, err := auth.New(p.dbConn.GetPool()).GetUserByLogin(ctx, login)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	if user.Pass != p.hash(password) {
+		return nil, ErrInvalidCredentials
+	}
+	if !user.PssValid {
+		return nil, ErrPasswordExpired
+	}
+	return &model.ExternalIdentity{
+		Provider: p.conf.Name,
+		Subject:  fmt.Sprintf("%d", user.UserID),
+		UserName: user.UserName,
+		Email:    user.Email,
+		Phone:    user.Phone,
+		Role:     user.Role,
+	}, nil
+}
+
+// ---------------------------- LDAP simple bind ----------------------------
+
+var ldapDefaultAttributes = map[string]string{
+	"userName": "uid",
+	"email":    "mail",
+	"phone":    "telephoneNumber",
+}
+
+type ldapIdentityProvider struct {
+	conf model.IdentityProviderConfig
+}
+
+func (p *ldapIdentityProvider) Name() string                         { return p.conf.Name }
+func (p *ldapIdentityProvider) Config() model.IdentityProviderConfig { return p.conf }
+
+func (p *ldapIdentityProvider) attributes() []string {
+	attrs := make([]string, 0)
+	for key, def := range ldapDefaultAttributes {
+		if name := p.conf.AttributeMap[key]; name != "" {
+			attrs = append(attrs, name)
+		} else {
+			attrs = append(attrs, def)
+		}
+	}
+	if role := p.conf.AttributeMap["role"]; role != "" {
+		attrs = append(attrs, role)
+	}
+	return attrs
+}
+
+// Authenticate binds as the user and reads the user's own entry
+func (p *ldapIdentityProvider) Authenticate(ctx context.Context, login, password string) (*model.ExternalIdentity, error) {
+	// An empty password would be an unauthenticated bind which most servers accept
+	if login == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+	conf := p.conf.LDAP
+	timeout := time.Duration(conf.TimeoutMs) * time.Millisecond
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	conn, err := util.DialLDAP(conf.URL, timeout, conf.InsecureSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if conf.StartTLS {
+		if err := conn.StartTLS(conf.InsecureSkipVerify); err != nil {
+			return nil, err
+		}
+	}
+
+	userDN := ""
+	if conf.BindDNTemplate != "" {
+		userDN = fmt.Sprintf(conf.BindDNTemplate, util.EscapeLDAPDN(login))
+	} else {
+		if conf.ServiceDN != "" {
+			if err := conn.Bind(conf.ServiceDN, conf.ServicePassword); err != nil {
+				return nil, fmt.Errorf("service bind failed: %w", err)
+			}
+		}
+		searchAttr := conf.SearchAttribute
+		if searchAttr == "" {
+			searchAttr = "uid"
+		}
+		entries, err := conn.Search(conf.SearchBase, util.LDAPScopeSubtree, searchAttr, login, []string{"dn"})
+		if err != nil {
+			return nil, err
+		}
+		if len(entries) != 1 {
+			return nil, ErrInvalidCredentials
+		}
+		userDN = entries[0].DN
+	}
+
+	if err := conn.Bind(userDN, password); err != nil {
+		if util.IsLDAPInvalidCredentials(err) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	entries, err := conn.Search(userDN, util.LDAPScopeBase, "objectClass", "", p.attributes())
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("ldap entry %s not readable after bind", userDN)
+	}
+	entry := entries[0]
+	identity := mapIdentity(p.conf, ldapDefaultAttributes, entry.DN, entry.Values)
+	// The mail attribute of a directory entry is maintained by the directory administrators
+	identity.EmailVerified = identity.Email != ""
+	if identity.UserName == "" {
+		identity.UserName = login
+	}
+	return identity, nil
+}
+
+// ---------------------------- upstream OIDC ----------------------------
+
+var oidcDefaultClaims = map[string]string{
+	"userName": "preferred_username",
+	"email":    "email",
+	"phone":    "phone_number",
+}
+
+type oidcIdentityProvider struct {
+	conf   model.IdentityProviderConfig
+	client *http.Client
+
+	mu        sync.Mutex
+	endpoints *oidcEndpoints
+	keys      map[string]*rsa.PublicKey
+	keysAt    time.Time
+}
+
+type oidcEndpoints struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+func (p *oidcIdentityProvider) Name() string                         { return p.conf.Name }
+func (p *oidcIdentityProvider) Config() model.IdentityProviderConfig { return p.conf }
+
+// discover returns the configured endpoints, filling gaps from the discovery document
+func (p *oidcIdentityProvider) discover(ctx context.Context) (*oidcEndpoints, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.endpoints != nil {
+		return p.endpoints, nil
+	}
+	conf := p.conf.OIDC
+	endpoints := &oidcEndpoints{
+		Issuer:                conf.Issuer,
+		AuthorizationEndpoint: conf.AuthorizationEndpoint,
+		TokenEndpoint:         conf.TokenEndpoint,
+		JWKSURI:               conf.JWKSURI,
+	}
+	if endpoints.AuthorizationEndpoint == "" || endpoints.TokenEndpoint == "" || endpoints.JWKSURI == "" {
+		var discovered oidcEndpoints
+		wellKnown := strings.TrimSuffix(conf.Issuer, "/") + "/.well-known/openid-configuration"
+		if err := p.getJSON(ctx, wellKnown, &discovered); err != nil {
+			return nil, fmt.Errorf("oidc discovery failed: %w", err)
+		}
+		if endpoints.AuthorizationEndpoint == "" {
+			endpoints.AuthorizationEndpoint = discovered.AuthorizationEndpoint
+		}
+		if endpoints.TokenEndpoint == "" {
+			endpoints.TokenEndpoint = discovered.TokenEndpoint
+		}
+		if endpoints.JWKSURI == "" {
+			endpoints.JWKSURI = discovered.JWKSURI
+		}
+	}
+	p.endpoints = endpoints
+	return endpoints, nil
+}
+
+func (p *oidcIdentityProvider) getJSON(ctx context.Context, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// publicKey returns the signing key for kid, refreshing the JWKS when the key is unknown
+func (p *oidcIdentityProvider) publicKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	endpoints, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	key, isFound := p.keys[kid]
+	fresh := time.Since(p.keysAt) < time.Minute
+	p.mu.Unlock()
+	if isFound || fresh && p.keys != nil {
+		if !isFound {
+			return nil, fmt.Errorf("unknown signing key %s", kid)
+		}
+		return key, nil
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := p.getJSON(ctx, endpoints.JWKSURI, &jwks); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	p.mu.Lock()
+	p.keys, p.keysAt = keys, time.Now()
+	p.mu.Unlock()
+	if key, isFound = keys[kid]; !isFound {
+		return nil, fmt.Errorf("unknown signing key %s", kid)
+	}
+	return key, nil
+}
+
+// AuthCodeURL builds the authorization request the browser is redirected to
+func (p *oidcIdentityProvider) AuthCodeURL(ctx context.Context, state, nonce string) (string, error) {
+	endpoints, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	scopes := p.conf.OIDC.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "profile", "email", "phone"}
+	}
+	query := url.Values{
+		"response_type": {"code"},
+		"client_id":     {p.conf.OIDC.ClientID},
+		"redirect_uri":  {p.conf.OIDC.RedirectURL},
+		"scope":         {strings.Join(scopes, " ")},
+		"state":         {state},
+		"nonce":         {nonce},
+	}
+	sep := "?"
+	if strings.Contains(endpoints.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return endpoints.AuthorizationEndpoint + sep + query.Encode(), nil
+}
+
+// Exchange redeems an authorization code and verifies the returned ID token
+func (p *oidcIdentityProvider) Exchange(ctx context.Context, code, nonce string) (*model.ExternalIdentity, error) {
+	return p.token(ctx, url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {p.conf.OIDC.RedirectURL},
+	}, nonce)
+}
+
+// Authenticate uses the resource owner password grant when it is enabled for the provider
+func (p *oidcIdentityProvider) Authenticate(ctx context.Context, login, password string) (*model.ExternalIdentity, error) {
+	if !p.conf.OIDC.PasswordGrant {
+		return nil, ErrUnsupportedFlow
+	}
+	scopes := p.conf.OIDC.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "profile", "email", "phone"}
+	}
+	return p.token(ctx, url.Values{
+		"grant_type": {"password"},
+		"username":   {login},
+		"password":   {password},
+		"scope":      {strings.Join(scopes, " ")},
+	}, "")
+}
+
+func (p *oidcIdentityProvider) token(ctx context.Context, form url.Values, nonce string) (*model.ExternalIdentity, error) {
+	endpoints, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	form.Set("client_id", p.conf.OIDC.ClientID)
+	form.Set("client_secret", p.conf.OIDC.ClientSecret)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoints.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var tokenResp struct {
+		IDToken string `json:"id_token"`
+		Error   string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return nil, fmt.Errorf("invalid token response: %w", err)
+	}
+	if tokenResp.Error == "invalid_grant" {
+		return nil, ErrInvalidCredentials
+	}
+	if resp.StatusCode != http.StatusOK || tokenResp.IDToken == "" {
+		return nil, fmt.Errorf("token endpoint returned %d %s", resp.StatusCode, tokenResp.Error)
+	}
+	return p.verifyIDToken(ctx, tokenResp.IDToken, nonce)
+}
+
+func (p *oidcIdentityProvider) verifyIDToken(ctx context.Context, idToken, nonce string) (*model.ExternalIdentity, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		return p.publicKey(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid id token: %w", err)
+	}
+	if !claims.VerifyIssuer(strings.TrimSuffix(p.conf.OIDC.Issuer, "/"), true) &&
+		!claims.VerifyIssuer(p.conf.OIDC.Issuer, true) {
+		return nil, fmt.Errorf("id token issuer mismatch")
+	}
+	if !audienceContains(claims["aud"], p.conf.OIDC.ClientID) {
+		return nil, fmt.Errorf("id token audience mismatch")
+	}
+	if nonce != "" && claims["nonce"] != nonce {
+		return nil, fmt.Errorf("id token nonce mismatch")
+	}
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, fmt.Errorf("id token has no subject")
+	}
+	identity := mapIdentity(p.conf, oidcDefaultClaims, subject, func(name string) []string {
+		return claimValues(claims[name])
+	})
+	// email_verified only speaks for the standard email claim
+	verified := claimValues(claims["email_verified"])
+	if name := p.conf.AttributeMap["email"]; name == "" || name == oidcDefaultClaims["email"] {
+		identity.EmailVerified = identity.Email != "" && len(verified) > 0 && verified[0] == "true"
+	}
+	return identity, nil
+}
+
+func audienceContains(aud interface{}, clientID string) bool {
+	for _, v := range claimValues(aud) {
+		if v == clientID {
+			return true
+		}
+	}
+	return false
+}
+
+func claimValues(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				values = append(values, str)
+			}
+		}
+		return values
+	case float64, bool:
+		return []string{fmt.Sprint(v)}
+	}
+	return nil
+}
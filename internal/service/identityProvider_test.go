@@ -0,0 +1,501 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/rest/api/internal/model"
+)
+
+// ---------------------------- role mapping ----------------------------
+
+func TestMapIdentityRoles(t *testing.T) {
+	conf := model.IdentityProviderConfig{
+		Name:         "corp",
+		Type:         IDP_TYPE_LDAP,
+		AttributeMap: map[string]string{"role": "groups"},
+		RoleMap:      map[string]string{"managers": "EMP_MANAGER"},
+		AllowedRoles: []string{"EMP_MANAGER", "HR"},
+		DefaultRole:  "USER",
+	}
+	tests := []struct {
+		name   string
+		groups []string
+		want   string
+	}{
+		{"mapped value", []string{"managers"}, "EMP_MANAGER"},
+		{"allowed role name", []string{"hr"}, "HR"},
+		{"first allowed value wins", []string{"staff", "hr", "managers"}, "HR"},
+		{"super admin is never mapped", []string{"SUPER_ADMIN"}, "USER"},
+		{"role outside the allow-list", []string{"DEPT_HEAD"}, "USER"},
+		{"unknown value", []string{"staff"}, "USER"},
+		{"no role attribute", nil, "USER"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			identity := mapIdentity(conf, nil, "subject", func(name string) []string {
+				if name == "groups" {
+					return tc.groups
+				}
+				return nil
+			})
+			if identity.Role != tc.want {
+				t.Errorf("role = %s, want %s", identity.Role, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidateProviderRoles(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    model.IdentityProviderConfig
+		wantErr bool
+	}{
+		{"no mapping", model.IdentityProviderConfig{}, false},
+		{"mapping into the allow-list", model.IdentityProviderConfig{
+			AllowedRoles: []string{"HR"}, RoleMap: map[string]string{"people": "HR"}, DefaultRole: "USER",
+		}, false},
+		{"super admin allowed", model.IdentityProviderConfig{AllowedRoles: []string{"SUPER_ADMIN"}}, true},
+		{"mapping to super admin", model.IdentityProviderConfig{RoleMap: map[string]string{"admins": "SUPER_ADMIN"}}, true},
+		{"mapping outside the allow-list", model.IdentityProviderConfig{
+			AllowedRoles: []string{"HR"}, RoleMap: map[string]string{"heads": "DEPT_HEAD"},
+		}, true},
+		{"super admin by default", model.IdentityProviderConfig{DefaultRole: "SUPER_ADMIN"}, true},
+		{"unknown role", model.IdentityProviderConfig{AllowedRoles: []string{"ROOT"}}, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := validateProviderRoles(tc.conf); (err != nil) != tc.wantErr {
+				t.Errorf("validateProviderRoles() = %v, wantErr %t", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestCanLinkByEmail(t *testing.T) {
+	verified := &model.ExternalIdentity{Email: "jdoe@example.com", EmailVerified: true}
+	unverified := &model.ExternalIdentity{Email: "jdoe@example.com"}
+	trusted := model.IdentityProviderConfig{LinkByEmail: true}
+	if !canLinkByEmail(trusted, verified) {
+		t.Error("trusted provider with a verified email should link")
+	}
+	if canLinkByEmail(trusted, unverified) {
+		t.Error("unverified email must not link")
+	}
+	if canLinkByEmail(model.IdentityProviderConfig{}, verified) {
+		t.Error("provider without linkByEmail must not link")
+	}
+}
+
+// ---------------------------- LDAP ----------------------------
+
+// stubLDAP accepts one password for one entry; binds with anything else fail with invalidCredentials
+type stubLDAP struct {
+	listener net.Listener
+	dn       string
+	password string
+	attrs    map[string][]string
+}
+
+func newStubLDAP(t *testing.T, dn, password string, attrs map[string][]string) *stubLDAP {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := &stubLDAP{listener: listener, dn: dn, password: password, attrs: attrs}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go srv.handle(conn)
+		}
+	}()
+	return srv
+}
+
+func (srv *stubLDAP) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		_, msg, err := readTLV(reader)
+		if err != nil {
+			return
+		}
+		parts := splitTLV(msg)
+		if len(parts) < 2 {
+			return
+		}
+		id := parts[0].data
+		op := parts[1]
+		switch op.tag {
+		case 0x60: // bind
+			fields := splitTLV(op.data)
+			code := byte(49)
+			if string(fields[1].data) == srv.dn && string(fields[2].data) == srv.password {
+				code = 0
+			}
+			conn.Write(ldapResultMessage(id, 0x61, code))
+		case 0x63: // search
+			fields := splitTLV(op.data)
+			if string(fields[0].data) == srv.dn {
+				attrs := make([]byte, 0)
+				for name, values := range srv.attrs {
+					set := make([]byte, 0)
+					for _, v := range values {
+						set = append(set, tlv(0x04, []byte(v))...)
+					}
+					attrs = append(attrs, tlv(0x30, append(tlv(0x04, []byte(name)), tlv(0x31, set)...))...)
+				}
+				entry := append(tlv(0x04, []byte(srv.dn)), tlv(0x30, attrs)...)
+				conn.Write(tlv(0x30, append(tlv(0x02, id), tlv(0x64, entry)...)))
+			}
+			conn.Write(ldapResultMessage(id, 0x65, 0))
+		default:
+			return
+		}
+	}
+}
+
+type tlvElement struct {
+	tag  byte
+	data []byte
+}
+
+func readTLV(reader *bufio.Reader) (byte, []byte, error) {
+	tag, err := reader.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	first, err := reader.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length := int(first)
+	if first&0x80 != 0 {
+		length = 0
+		for i := 0; i < int(first&0x7f); i++ {
+			b, err := reader.ReadByte()
+			if err != nil {
+				return 0, nil, err
+			}
+			length = length<<8 | int(b)
+		}
+	}
+	data := make([]byte, length)
+	_, err = io.ReadFull(reader, data)
+	return tag, data, err
+}
+
+func splitTLV(data []byte) []tlvElement {
+	items := make([]tlvElement, 0)
+	reader := bufio.NewReader(bytes.NewReader(data))
+	for {
+		tag, content, err := readTLV(reader)
+		if err != nil {
+			return items
+		}
+		items = append(items, tlvElement{tag: tag, data: content})
+	}
+}
+
+func tlv(tag byte, content []byte) []byte {
+	out := []byte{tag}
+	if n := len(content); n < 0x80 {
+		out = append(out, byte(n))
+	} else {
+		out = append(out, 0x82, byte(n>>8), byte(n))
+	}
+	return append(out, content...)
+}
+
+func ldapResultMessage(id []byte, tag, code byte) []byte {
+	result := append(tlv(0x0a, []byte{code}), tlv(0x04, nil)...)
+	result = append(result, tlv(0x04, []byte("stub"))...)
+	return tlv(0x30, append(tlv(0x02, id), tlv(tag, result)...))
+}
+
+func newTestLDAPProvider(t *testing.T, url string) IdentityProvider {
+	t.Helper()
+	provider, err := NewIdentityProvider(model.IdentityProviderConfig{
+		Name:         "corp-ldap",
+		Type:         IDP_TYPE_LDAP,
+		LDAP:         &model.LDAPConfig{URL: url, BindDNTemplate: "uid=%s,ou=people,dc=example,dc=com", TimeoutMs: 1000},
+		AttributeMap: map[string]string{"role": "employeeType"},
+		RoleMap:      map[string]string{"managers": "EMP_MANAGER"},
+		AllowedRoles: []string{"EMP_MANAGER"},
+	}, nil, nil)
+	if err != nil {
+		t.Fatalf("NewIdentityProvider: %v", err)
+	}
+	return provider
+}
+
+func TestLDAPAuthenticate(t *testing.T) {
+	const dn = "uid=jdoe,ou=people,dc=example,dc=com"
+	srv := newStubLDAP(t, dn, "secret", map[string][]string{
+		"uid":          {"jdoe"},
+		"mail":         {"JDoe@Example.com"},
+		"employeeType": {"managers"},
+	})
+	provider := newTestLDAPProvider(t, "ldap://"+srv.listener.Addr().String())
+	ctx := context.Background()
+
+	identity, err := provider.Authenticate(ctx, "jdoe", "secret")
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if identity.Subject != dn || identity.UserName != "jdoe" || identity.Email != "jdoe@example.com" {
+		t.Errorf("identity = %+v", identity)
+	}
+	if identity.Role != "EMP_MANAGER" || !identity.EmailVerified {
+		t.Errorf("role %s, email verified %t", identity.Role, identity.EmailVerified)
+	}
+
+	if _, err := provider.Authenticate(ctx, "jdoe", "wrong"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("bind failure returned %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := provider.Authenticate(ctx, "nobody", "secret"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("unknown user returned %v, want ErrInvalidCredentials", err)
+	}
+}
+
+func TestLDAPAuthenticateEmptyPassword(t *testing.T) {
+	// Closed listener: an empty password must be refused before anything is dialled
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	listener.Close()
+	provider := newTestLDAPProvider(t, "ldap://"+listener.Addr().String())
+	if _, err := provider.Authenticate(context.Background(), "jdoe", ""); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("empty password returned %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := provider.Authenticate(context.Background(), "", "secret"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("empty login returned %v, want ErrInvalidCredentials", err)
+	}
+}
+
+// ---------------------------- OIDC ----------------------------
+
+// stubIssuer serves discovery, JWKS and a token endpoint that returns claims as an ID token
+type stubIssuer struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	claims jwt.MapClaims
+}
+
+func newStubIssuer(t *testing.T) *stubIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	issuer := &stubIssuer{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 issuer.server.URL,
+			"authorization_endpoint": issuer.server.URL + "/authorize",
+			"token_endpoint":         issuer.server.URL + "/token",
+			"jwks_uri":               issuer.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "k1",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, issuer.claims)
+		token.Header["kid"] = "k1"
+		signed, err := token.SignedString(key)
+		if err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": signed})
+	})
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+func (issuer *stubIssuer) validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            issuer.server.URL,
+		"aud":            "auth-bucket",
+		"sub":            "user-123",
+		"nonce":          "n0nce",
+		"exp":            time.Now().Add(time.Minute).Unix(),
+		"email":          "jdoe@example.com",
+		"email_verified": true,
+		"groups":         []interface{}{"staff", "hr"},
+	}
+}
+
+func TestOIDCExchange(t *testing.T) {
+	issuer := newStubIssuer(t)
+	provider, err := NewIdentityProvider(model.IdentityProviderConfig{
+		Name:         "sso",
+		Type:         IDP_TYPE_OIDC,
+		OIDC:         &model.OIDCConfig{Issuer: issuer.server.URL, ClientID: "auth-bucket", RedirectURL: "http://localhost/callback", FrontendURL: "http://localhost/app"},
+		AttributeMap: map[string]string{"role": "groups"},
+		AllowedRoles: []string{"HR"},
+	}, nil, nil)
+	if err != nil {
+		t.Fatalf("NewIdentityProvider: %v", err)
+	}
+	oidc := provider.(RedirectIdentityProvider)
+
+	tests := []struct {
+		name         string
+		change       func(jwt.MapClaims)
+		wantErr      bool
+		wantVerified bool
+		wantRole     string
+	}{
+		{"valid", func(jwt.MapClaims) {}, false, true, "HR"},
+		{"verified as string", func(c jwt.MapClaims) { c["email_verified"] = "true" }, false, true, "HR"},
+		{"unverified email", func(c jwt.MapClaims) { c["email_verified"] = false }, false, false, "HR"},
+		{"no email_verified claim", func(c jwt.MapClaims) { delete(c, "email_verified") }, false, false, "HR"},
+		{"super admin group", func(c jwt.MapClaims) { c["groups"] = []interface{}{"SUPER_ADMIN"} }, false, true, "USER"},
+		{"wrong issuer", func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }, true, false, ""},
+		{"wrong audience", func(c jwt.MapClaims) { c["aud"] = "other-client" }, true, false, ""},
+		{"audience list", func(c jwt.MapClaims) { c["aud"] = []interface{}{"other-client", "auth-bucket"} }, false, true, "HR"},
+		{"wrong nonce", func(c jwt.MapClaims) { c["nonce"] = "replayed" }, true, false, ""},
+		{"expired", func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }, true, false, ""},
+		{"no subject", func(c jwt.MapClaims) { delete(c, "sub") }, true, false, ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			issuer.claims = issuer.validClaims()
+			tc.change(issuer.claims)
+			identity, err := oidc.Exchange(context.Background(), "code", "n0nce")
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("Exchange succeeded with %+v", identity)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Exchange: %v", err)
+			}
+			if identity.Subject != "user-123" || identity.Email != "jdoe@example.com" {
+				t.Errorf("identity = %+v", identity)
+			}
+			if identity.EmailVerified != tc.wantVerified {
+				t.Errorf("EmailVerified = %t, want %t", identity.EmailVerified, tc.wantVerified)
+			}
+			if identity.Role != tc.wantRole {
+				t.Errorf("role = %s, want %s", identity.Role, tc.wantRole)
+			}
+			if canLinkByEmail(model.IdentityProviderConfig{LinkByEmail: true}, identity) != tc.wantVerified {
+				t.Errorf("canLinkByEmail disagrees with EmailVerified %t", tc.wantVerified)
+			}
+		})
+	}
+}
+
+func TestOIDCStateCookie(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	issuer := newStubIssuer(t)
+	issuer.claims = issuer.validClaims()
+	s := &RESTService{jwtSigningKey: []byte("test")}
+	if err := s.initIdentityProviders([]model.IdentityProviderConfig{{
+		Name: "sso",
+		Type: IDP_TYPE_OIDC,
+		OIDC: &model.OIDCConfig{Issuer: issuer.server.URL, ClientID: "auth-bucket", RedirectURL: "http://localhost/callback", FrontendURL: "http://localhost/app"},
+	}}); err != nil {
+		t.Fatalf("initIdentityProviders: %v", err)
+	}
+	request := func(path string, cookies ...*http.Cookie) (*gin.Context, *httptest.ResponseRecorder) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, path, nil)
+		for _, cookie := range cookies {
+			c.Request.AddCookie(cookie)
+		}
+		c.Params = gin.Params{{Key: "provider", Value: "sso"}}
+		return c, w
+	}
+	login := func() (string, *http.Cookie) {
+		c, w := request(OIDC_API_BASE + "/sso/login")
+		redirect, errResp := s.oidcLogin(c)
+		if errResp != nil {
+			t.Fatalf("oidcLogin: %+v", errResp)
+		}
+		cookies := w.Result().Cookies()
+		if len(cookies) != 1 || cookies[0].Name != oidcStateCookie || !cookies[0].HttpOnly || cookies[0].SameSite != http.SameSiteLaxMode {
+			t.Fatalf("state cookie = %+v", cookies)
+		}
+		u, _ := url.Parse(redirect)
+		return u.Query().Get("state"), cookies[0]
+	}
+
+	state, cookie := login()
+	_, otherCookie := login()
+	callback := OIDC_API_BASE + "/sso/callback?code=abc&state=" + url.QueryEscape(state)
+	tests := []struct {
+		name    string
+		cookies []*http.Cookie
+		want    int
+	}{
+		{"no cookie", nil, http.StatusBadRequest},
+		{"cookie of another login", []*http.Cookie{otherCookie}, http.StatusBadRequest},
+		// The state is accepted; the stub issuer then rejects the nonce of its canned token
+		{"cookie of this login", []*http.Cookie{cookie}, http.StatusNotFound},
+	}
+	for _, tc := range tests {
+		c, _ := request(callback, tc.cookies...)
+		_, errResp := s.oidcCallback(c)
+		if errResp == nil || errResp.StatusCode != tc.want {
+			t.Errorf("%s: %+v, want status %d", tc.name, errResp, tc.want)
+		}
+	}
+}
+
+func TestOIDCRemappedEmailIsNotVerified(t *testing.T) {
+	issuer := newStubIssuer(t)
+	provider, err := NewIdentityProvider(model.IdentityProviderConfig{
+		Name:         "sso",
+		Type:         IDP_TYPE_OIDC,
+		OIDC:         &model.OIDCConfig{Issuer: issuer.server.URL, ClientID: "auth-bucket"},
+		AttributeMap: map[string]string{"email": "upn"},
+	}, nil, nil)
+	if err != nil {
+		t.Fatalf("NewIdentityProvider: %v", err)
+	}
+	issuer.claims = issuer.validClaims()
+	issuer.claims["upn"] = "admin@example.com"
+	identity, err := provider.(RedirectIdentityProvider).Exchange(context.Background(), "code", "n0nce")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if identity.Email != "admin@example.com" || identity.EmailVerified {
+		t.Errorf("email %s verified %t; email_verified must not vouch for another claim", identity.Email, identity.EmailVerified)
+	}
+}
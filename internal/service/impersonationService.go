@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+)
+
+// /api/auth/impersonate/:id - issue a short lived token acting as another user (SUPER_ADMIN only)
+func (s *RESTService) impersonateUser(c *gin.Context) APIResponse {
+	if s.jwtSigningKey == nil {
+		return BuildResponse400("Impersonation requires JWT authentication to be enabled")
+	}
+	claims := s.currentClaims(c)
+	if claims == nil || claims.Role != ROLE_SUPER_ADMIN {
+		return BuildResponse403("Only super admins can impersonate users")
+	}
+	if claims.Act != nil {
+		return BuildResponse403("Impersonation tokens cannot start another impersonation")
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return BuildResponse400("Invalid user id")
+	}
+	if int32(id) == claims.UserID {
+		return BuildResponse400("Cannot impersonate yourself")
+	}
+
+	ctx := context.Background()
+	db := s.dbConn.GetPool()
+	qtx := auth.New(db)
+
+	target, err := qtx.GetUserById(ctx, int32(id))
+	if err != nil {
+		return BuildResponse404("User not found", false)
+	}
+	if target.Role == ROLE_SUPER_ADMIN {
+		return BuildResponse403("Super admins cannot be impersonated")
+	}
+	if target.Status != STATUS_ACTIVE {
+		return BuildResponse400("Cannot impersonate an inactive user")
+	}
+
+	expiresAt := time.Now().Add(IMPERSONATION_TTL)
+	companyID, company := s.defaultCompany(ctx, target.UserID)
+	token := s.signClaims(model.AuthorizationClaims{
+		UserID:    target.UserID,
+		Email:     target.Email,
+		UserName:  target.UserName,
+		Role:      target.Role,
+		CompanyID: companyID,
+		Company:   company,
+		Act: &model.ActorClaim{
+			UserID:   claims.UserID,
+			Email:    claims.Email,
+			UserName: claims.UserName,
+		},
+		StandardClaims: jwt.StandardClaims{
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: expiresAt.Unix(),
+			Issuer:    "Auth Service",
+			Subject:   fmt.Sprintf("%d", target.UserID),
+			Id:        fmt.Sprintf("%d:%d", claims.UserID, target.UserID),
+		},
+	})
+	if token == "" {
+		return BuildResponse500("Failed to issue impersonation token", nil)
+	}
+
+	_asLogger.Warnf("User %s (%d) started impersonating %s (%d)", claims.UserName, claims.UserID, target.UserName, target.UserID)
+	s.recordAudit(ctx, c, AUDIT_IMPERSONATION_START, map[string]interface{}{
+		"targetUserId":   target.UserID,
+		"targetUserName": target.UserName,
+		"expiresAt":      expiresAt,
+	})
+
+	response := BuildResponse200("Impersonation started", map[string]interface{}{
+		"user_id":    target.UserID,
+		"user_name":  target.UserName,
+		"email":      target.Email,
+		"role":       target.Role,
+		"company_id": companyID,
+		"company":    company,
+		"token":      token,
+		"expiresAt":  expiresAt,
+	})
+	response.Token = &token
+	return response
+}
+
+// /api/auth/me - the caller's profile, including an impersonation banner when applicable
+func (s *RESTService) getCurrentUser(c *gin.Context) APIResponse {
+	claims := s.currentClaims(c)
+	if claims == nil {
+		return BuildResponse400("No authenticated user")
+	}
+
+	ctx := context.Background()
+	db := s.dbConn.GetPool()
+	qtx := auth.New(db)
+
+	user, err := qtx.GetUserById(ctx, claims.UserID)
+	if err != nil {
+		return BuildResponse404("User not found", false)
+	}
+
+	payload := map[string]interface{}{
+		"user_id":   user.UserID,
+		"user_name": user.UserName,
+		"email":     user.Email,
+		"phone":     user.Phone,
+		"role":      user.Role,
+		"status":    user.Status,
+		// The active company of the token that satcom data is scoped to
+		"company_id": claims.CompanyID,
+		"company":    claims.Company,
+	}
+	impersonation := map[string]interface{}{"active": false}
+	if claims.Act != nil {
+		impersonation = map[string]interface{}{
+			"active":    true,
+			"actorId":   claims.Act.UserID,
+			"actorName": claims.Act.UserName,
+			"banner":    fmt.Sprintf("%s is signed in as %s", claims.Act.UserName, user.UserName),
+			"expiresAt": time.Unix(claims.ExpiresAt, 0),
+		}
+	}
+	payload["impersonation"] = impersonation
+
+	return BuildResponse200("Current user retrieved successfully", payload)
+}
+
+// impersonationAudit flags every request made with an impersonation token in the
+// log, in the response headers and in the audit trail
+func (s *RESTService) impersonationAudit(c *gin.Context) {
+	claims := s.currentClaims(c)
+	if claims == nil || claims.Act == nil {
+		c.Next()
+		return
+	}
+	_asLogger.Warnf("[impersonated] %s %s by %s (%d) as %s (%d)", c.Request.Method, c.Request.URL.Path,
+		claims.Act.UserName, claims.Act.UserID, claims.UserName, claims.UserID)
+	c.Header("X-Impersonated-By", claims.Act.UserName)
+	c.Next()
+	s.recordAudit(context.Background(), c, AUDIT_IMPERSONATED_REQUEST, nil)
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+)
+
+// fakeUserDB answers the user lookups of claimsStillValid from a directory of users
+type fakeUserDB struct {
+	users map[int32]auth.CommonUser
+}
+
+func (db *fakeUserDB) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, errors.New("unexpected exec")
+}
+
+func (db *fakeUserDB) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return nil, errors.New("unexpected query")
+}
+
+func (db *fakeUserDB) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	user, isFound := db.users[args[0].(int32)]
+	if !isFound {
+		return fakeImportRow{pgx.ErrNoRows}
+	}
+	return fakeUserRow{name: strings.Fields(sql)[2], user: user}
+}
+
+type fakeUserRow struct {
+	name string
+	user auth.CommonUser
+}
+
+func (row fakeUserRow) Scan(dest ...interface{}) error {
+	switch row.name {
+	case "GetUserStatusById":
+		*dest[0].(*string) = row.user.Status
+	case "GetUserById":
+		*dest[0].(*int32) = row.user.UserID
+		*dest[9].(*string) = row.user.Role
+		*dest[10].(*string) = row.user.Status
+	default:
+		return errors.New("unexpected query " + row.name)
+	}
+	return nil
+}
+
+func TestClaimsStillValid(t *testing.T) {
+	db := &fakeUserDB{users: map[int32]auth.CommonUser{
+		1: {UserID: 1, Role: ROLE_SUPER_ADMIN, Status: STATUS_ACTIVE},
+		2: {UserID: 2, Role: ROLE_SUPER_ADMIN, Status: STATUS_INACTIVE},
+		3: {UserID: 3, Role: ROLE_USER, Status: STATUS_ACTIVE},
+		4: {UserID: 4, Role: ROLE_USER, Status: STATUS_ACTIVE},
+		5: {UserID: 5, Role: ROLE_USER, Status: STATUS_INACTIVE},
+	}}
+	qtx := auth.New(db)
+	tests := []struct {
+		name  string
+		user  int32
+		actor int32
+		want  bool
+	}{
+		{"active user", 4, 0, true},
+		{"inactive user", 5, 0, false},
+		{"unknown user", 9, 0, false},
+		{"impersonated by an active super admin", 4, 1, true},
+		{"actor deactivated", 4, 2, false},
+		{"actor no longer a super admin", 4, 3, false},
+		{"actor deleted", 4, 9, false},
+		{"impersonated user deactivated", 5, 1, false},
+	}
+	for _, tc := range tests {
+		claims := &model.AuthorizationClaims{UserID: tc.user}
+		if tc.actor != 0 {
+			claims.Act = &model.ActorClaim{UserID: tc.actor}
+		}
+		if got := claimsStillValid(context.Background(), qtx, claims); got != tc.want {
+			t.Errorf("%s: %t, want %t", tc.name, got, tc.want)
+		}
+	}
+}
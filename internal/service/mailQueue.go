@@ -0,0 +1,77 @@
+package service
+
+import "sync"
+
+// MailQueue sends the notification emails of the service on one background worker.
+// Handlers enqueue their mails once the transaction has committed and answer without
+// waiting for the SMTP server; Stop sends what is left before the service shuts down.
+type MailQueue struct {
+	mailer *SmtpService
+	jobs   chan mailJob
+
+	mu      sync.Mutex
+	stopped bool
+	done    chan struct{}
+}
+
+// mailJob is one queued mail; name describes it in the log
+type mailJob struct {
+	name string
+	send func(mailer *SmtpService) error
+}
+
+// NewMailQueue builds a queue holding up to MAIL_QUEUE_SIZE unsent mails
+func NewMailQueue() *MailQueue {
+	return &MailQueue{
+		mailer: &SmtpService{},
+		jobs:   make(chan mailJob, MAIL_QUEUE_SIZE),
+	}
+}
+
+// Start sends the queued mails in order until Stop is called
+func (q *MailQueue) Start() {
+	q.done = make(chan struct{})
+	go func() {
+		defer close(q.done)
+		for job := range q.jobs {
+			if err := job.send(q.mailer); err != nil {
+				_asLogger.Errorf("Error sending %s: %v", job.name, err)
+				continue
+			}
+			_asLogger.Debugf("Sent %s", job.name)
+		}
+	}()
+}
+
+// Enqueue hands a mail to the worker. It reports false, and the mail is dropped, when
+// the queue is full or stopped.
+func (q *MailQueue) Enqueue(name string, send func(mailer *SmtpService) error) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.stopped {
+		_asLogger.Errorf("Mail queue is stopped; dropping %s", name)
+		return false
+	}
+	select {
+	case q.jobs <- mailJob{name: name, send: send}:
+		return true
+	default:
+		_asLogger.Errorf("Mail queue is full; dropping %s", name)
+		return false
+	}
+}
+
+// Stop refuses new mails and waits until the queued ones are sent
+func (q *MailQueue) Stop() {
+	q.mu.Lock()
+	if q.stopped {
+		q.mu.Unlock()
+		return
+	}
+	q.stopped = true
+	close(q.jobs)
+	q.mu.Unlock()
+	if q.done != nil {
+		<-q.done
+	}
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMailQueue(t *testing.T) {
+	q := NewMailQueue()
+	var sent []string
+	for i := 0; i < 3; i++ {
+		name := fmt.Sprintf("mail %d", i)
+		if !q.Enqueue(name, func(*SmtpService) error {
+			sent = append(sent, name)
+			return nil
+		}) {
+			t.Fatalf("Enqueue(%s) = false", name)
+		}
+	}
+	q.Start()
+	q.Stop()
+	if fmt.Sprint(sent) != "[mail 0 mail 1 mail 2]" {
+		t.Errorf("sent = %v", sent)
+	}
+	if q.Enqueue("late", func(*SmtpService) error { return nil }) {
+		t.Error("Enqueue after Stop = true")
+	}
+	q.Stop()
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rest/api/internal/model"
+)
+
+// RunApplyCommand implements the "apply" subcommand: it sends a satcom manifest to a
+// running server, prints the plan and returns the process exit code
+func RunApplyCommand(args []string) int {
+	flags := flag.NewFlagSet("apply", flag.ExitOnError)
+	file := flags.String("f", "", "Manifest file, YAML or JSON (- reads stdin)")
+	server := flags.String("server", "", "Base URL of the API server (default $API_SERVER or http://localhost:7070)")
+	token := flags.String("token", "", "Bearer token (default $API_TOKEN)")
+	dryRun := flags.Bool("dry-run", false, "Only print the plan")
+	prune := flags.Bool("prune", false, "Move entries missing from the manifest to the recycle bin")
+	allowConflicts := flags.Bool("allow-conflicts", false, "Apply even if entries share an ip:port or url")
+	flags.Parse(args)
+	if *server == "" {
+		*server = envOrDefault("API_SERVER", "http://localhost:7070")
+	}
+	if *token == "" {
+		*token = os.Getenv("API_TOKEN")
+	}
+	if *file == "" {
+		fmt.Fprintln(os.Stderr, "apply: -f is required")
+		flags.Usage()
+		return 2
+	}
+
+	var data []byte
+	var err error
+	if *file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(*file)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Unable to read manifest:", err)
+		return 1
+	}
+	format, contentType := "yaml", "application/yaml"
+	if strings.EqualFold(filepath.Ext(*file), ".json") {
+		format, contentType = "json", "application/json"
+	}
+
+	query := url.Values{
+		"format":         {format},
+		"dryRun":         {strconv.FormatBool(*dryRun)},
+		"prune":          {strconv.FormatBool(*prune)},
+		"allowConflicts": {strconv.FormatBool(*allowConflicts)},
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimRight(*server, "/")+"/api/satcom/apply?"+query.Encode(), bytes.NewReader(data))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid server address:", err)
+		return 1
+	}
+	req.Header.Set("Content-Type", contentType)
+	if *token != "" {
+		req.Header.Set("Authorization", "Bearer "+*token)
+	}
+	client := &http.Client{Timeout: 5 * time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Apply request failed:", err)
+		return 1
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Message   string          `json:"serviceMessage"`
+		IsSuccess bool            `json:"isSuccess"`
+		Payload   json.RawMessage `json:"payload"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		fmt.Fprintf(os.Stderr, "Unexpected response (HTTP %d): %v\n", resp.StatusCode, err)
+		return 1
+	}
+	var plan model.SatcomPlan
+	if json.Unmarshal(result.Payload, &plan) == nil && plan.Actions != nil {
+		printSatcomPlan(os.Stdout, plan)
+	}
+	if !result.IsSuccess {
+		fmt.Fprintf(os.Stderr, "%s (HTTP %d)\n", result.Message, resp.StatusCode)
+		return 1
+	}
+	fmt.Println(result.Message)
+	return 0
+}
+
+// printSatcomPlan writes one line per action, then the totals
+func printSatcomPlan(w io.Writer, plan model.SatcomPlan) {
+	symbols := map[string]string{
+		SATCOM_OP_CREATE:        "+",
+		SATCOM_OP_UPDATE:        "~",
+		SATCOM_OP_DELETE:        "-",
+		SATCOM_ACTION_UNMANAGED: "?",
+		SATCOM_ACTION_ERROR:     "!",
+	}
+	for _, action := range plan.Actions {
+		symbol, isFound := symbols[action.Action]
+		if !isFound {
+			continue
+		}
+		name := action.Name
+		if name == "" && action.ID != nil {
+			name = fmt.Sprintf("#%d", *action.ID)
+		}
+		line := fmt.Sprintf("%s %-9s %s", symbol, strings.ToLower(action.Action), name)
+		if action.Adopted {
+			line += fmt.Sprintf(" (adopts #%d)", *action.ID)
+		}
+		fmt.Fprintln(w, line)
+		for _, change := range action.Changes {
+			fmt.Fprintf(w, "    %s: %v -> %v\n", change.Field, change.From, change.To)
+		}
+		for _, fe := range action.Errors {
+			fmt.Fprintf(w, "    %s %s\n", fe.Field, fe.Message)
+		}
+	}
+	fmt.Fprintf(w, "Plan: %d to create, %d to update, %d to delete, %d unchanged, %d unmanaged\n",
+		plan.Create, plan.Update, plan.Delete, plan.Unchanged, plan.Unmanaged)
+}
+
+func envOrDefault(name, defaultValue string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return defaultValue
+}
@@ -1,8 +1,11 @@
 package service
 
 import (
+	"crypto/rand"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"text/template"
 
 	"github.com/gin-gonic/gin"
 
@@ -19,6 +22,24 @@ type RESTService struct {
 	dbConn        *util.DBConnectionWrapper
 	jwtSigningKey []byte
 	bypassAuth    map[string]bool
+	scimToken     []byte
+	ephemeralKey  []byte
+
+	identityProviders []IdentityProvider
+	prober            *SatcomProber
+	certChecker       *SatcomCertChecker
+	purger            *SatcomPurger
+	secretReminder    *SatcomSecretReminder
+	feed              *SatcomFeed
+	satcomFields      []model.SatcomFieldDefinition
+	nginxTemplate     *template.Template
+	approvalSelector  labelSelector
+	approvalEmails    []string
+	vault             *satcomVault
+	attachments       *satcomAttachmentStore
+	statsCache        *satcomStatsCache
+	eventOrigins      map[string]bool
+	mails             *MailQueue
 }
 
 // NewAuthenticationRESTService returns a new initialized version of the service
@@ -48,7 +69,80 @@ func (s *RESTService) Init(config []byte, dbConnection *util.DBConnectionWrapper
 	}
 	if conf.JWTKey != nil && len(*conf.JWTKey) > 0 {
 		s.jwtSigningKey = []byte(*conf.JWTKey)
+	} else {
+		// Signs short lived values such as the OIDC state when no JWT key is configured
+		s.ephemeralKey = make([]byte, 32)
+		rand.Read(s.ephemeralKey)
+	}
+	if err := s.initIdentityProviders(conf.IdentityProviders); err != nil {
+		_asLogger.Error("Invalid identity provider configuration ", err)
+		return err
+	}
+	if conf.ScimToken != nil && len(*conf.ScimToken) > 0 {
+		s.scimToken = []byte(*conf.ScimToken)
+	}
+	if err := s.initSatcomFields(conf.SatcomFields); err != nil {
+		_asLogger.Error("Invalid satcom field configuration ", err)
+		return err
+	}
+	if err := s.initSatcomApprovals(conf.Approvals); err != nil {
+		_asLogger.Error("Invalid approval configuration ", err)
+		return err
+	}
+	if s.vault, err = newSatcomVault(conf.Vault); err != nil {
+		_asLogger.Error("Invalid vault configuration ", err)
+		return err
+	}
+	if s.attachments, err = newSatcomAttachmentStore(conf.Attachments); err != nil {
+		_asLogger.Error("Invalid attachment configuration ", err)
+		return err
+	}
+	s.statsCache = newSatcomStatsCache(conf.Stats)
+	s.eventOrigins = newSatcomEventOrigins(conf.Events)
+	if s.nginxTemplate, err = parseNginxTemplate(conf.Discovery); err != nil {
+		_asLogger.Error("Invalid nginx template ", err)
+		return err
+	}
+	var proberConf model.ProberConfig
+	if conf.Prober != nil {
+		proberConf = *conf.Prober
+	}
+	if s.prober, err = NewSatcomProber(proberConf, s.dbConn); err != nil {
+		_asLogger.Error("Invalid prober configuration ", err)
+		return err
 	}
+	if proberConf.Enabled {
+		s.prober.Start()
+	}
+	var certConf model.CertificateConfig
+	if conf.Certificates != nil {
+		certConf = *conf.Certificates
+	}
+	s.certChecker = NewSatcomCertChecker(certConf, s.dbConn)
+	if certConf.Enabled {
+		s.certChecker.Start()
+	}
+	var recycleConf model.RecycleBinConfig
+	if conf.RecycleBin != nil {
+		recycleConf = *conf.RecycleBin
+	}
+	s.purger = NewSatcomPurger(recycleConf, s.dbConn)
+	s.purger.attachments = s.attachments
+	if recycleConf.Enabled {
+		s.purger.Start()
+	}
+	var vaultConf model.VaultConfig
+	if conf.Vault != nil {
+		vaultConf = *conf.Vault
+	}
+	s.secretReminder = NewSatcomSecretReminder(vaultConf, s.dbConn)
+	if s.vault != nil {
+		s.secretReminder.Start()
+	}
+	s.feed = NewSatcomFeed(s.dbConn)
+	s.feed.Start()
+	s.mails = NewMailQueue()
+	s.mails.Start()
 	s.bypassAuth = make(map[string]bool)
 	s.bypassAuth["/"] = true
 	if conf.BypassAuth != nil && len(conf.BypassAuth) > 0 {
@@ -60,6 +154,16 @@ func (s *RESTService) Init(config []byte, dbConnection *util.DBConnectionWrapper
 	return nil
 }
 
+// Close stops the background jobs of the service
+func (s *RESTService) Close() {
+	s.prober.Stop()
+	s.certChecker.Stop()
+	s.purger.Stop()
+	s.secretReminder.Stop()
+	s.feed.Stop()
+	s.mails.Stop()
+}
+
 // AddRouters add api end points specific to this service
 func (s *RESTService) AddRouters(apiBase string, router *gin.Engine) {
 	router.POST("/api/auth/create", func(c *gin.Context) {
@@ -77,6 +181,79 @@ func (s *RESTService) AddRouters(apiBase string, router *gin.Engine) {
 		c.JSON(resp.StatusCode, resp)
 	})
 
+	router.GET("/api/auth/me", func(c *gin.Context) {
+		resp := s.getCurrentUser(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.POST("/api/auth/impersonate/:id", func(c *gin.Context) {
+		resp := s.impersonateUser(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.POST("/api/auth/switch-company", func(c *gin.Context) {
+		resp := s.switchCompany(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.GET("/api/companies", func(c *gin.Context) {
+		resp := s.listCompanies(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.POST("/api/companies", func(c *gin.Context) {
+		resp := s.createCompany(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.PUT("/api/companies/:id", func(c *gin.Context) {
+		resp := s.renameCompany(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.GET("/api/companies/:id/members", func(c *gin.Context) {
+		resp := s.listCompanyMembers(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.PUT("/api/companies/:id/members/:userId", func(c *gin.Context) {
+		resp := s.setCompanyMember(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.DELETE("/api/companies/:id/members/:userId", func(c *gin.Context) {
+		resp := s.removeCompanyMember(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.GET("/api/audit", func(c *gin.Context) {
+		resp := s.listAuditLogs(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.GET(OIDC_API_BASE+"/:provider/login", func(c *gin.Context) {
+		redirect, errResp := s.oidcLogin(c)
+		if errResp != nil {
+			c.JSON(errResp.StatusCode, errResp)
+			return
+		}
+		c.Redirect(http.StatusFound, redirect)
+	})
+
+	router.GET(OIDC_API_BASE+"/:provider/callback", func(c *gin.Context) {
+		redirect, errResp := s.oidcCallback(c)
+		if errResp != nil {
+			c.JSON(errResp.StatusCode, errResp)
+			return
+		}
+		c.Redirect(http.StatusFound, redirect)
+	})
+
+	router.POST(OIDC_API_BASE+"/token", func(c *gin.Context) {
+		resp := s.redeemLoginCode(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
 	router.PUT("/api/auth/update", func(c *gin.Context) {
 		resp := s.updateUser(c)
 		c.JSON(resp.StatusCode, resp)
@@ -87,6 +264,15 @@ func (s *RESTService) AddRouters(apiBase string, router *gin.Engine) {
 		c.JSON(resp.StatusCode, resp)
 	})
 
+	router.POST("/api/auth/users/import", func(c *gin.Context) {
+		resp := s.importUsers(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.GET("/api/auth/users/export", func(c *gin.Context) {
+		s.exportUsers(c)
+	})
+
 	// Satcom Data CRUD routes
 	router.POST("/api/satcom", func(c *gin.Context) {
 		resp := s.createSatcomData(c)
@@ -98,8 +284,309 @@ func (s *RESTService) AddRouters(apiBase string, router *gin.Engine) {
 		c.JSON(resp.StatusCode, resp)
 	})
 
+	router.GET("/api/satcom/conversion-issues", func(c *gin.Context) {
+		resp := s.getSatcomConversionIssues(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.GET("/api/satcom/fields", func(c *gin.Context) {
+		resp := s.getSatcomFields(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.GET("/api/satcom/labels", func(c *gin.Context) {
+		resp := s.getSatcomLabels(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.POST("/api/satcom/import", func(c *gin.Context) {
+		resp := s.importSatcomData(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.GET("/api/satcom/export", func(c *gin.Context) {
+		s.exportSatcomData(c)
+	})
+
+	router.POST("/api/satcom/apply", func(c *gin.Context) {
+		resp := s.applySatcomManifest(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.GET("/api/satcom/sd/prometheus", func(c *gin.Context) {
+		s.getPrometheusTargets(c)
+	})
+
+	router.GET("/api/satcom/sd/ansible", func(c *gin.Context) {
+		s.getAnsibleInventory(c)
+	})
+
+	router.GET("/api/satcom/sd/nginx", func(c *gin.Context) {
+		s.renderNginxConfig(c)
+	})
+
+	router.POST("/api/satcom/sd/nginx", func(c *gin.Context) {
+		s.renderNginxConfig(c)
+	})
+
+	router.GET("/api/satcom/maintenance", func(c *gin.Context) {
+		resp := s.listSatcomMaintenance(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.POST("/api/satcom/maintenance", func(c *gin.Context) {
+		resp := s.createSatcomMaintenance(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.GET("/api/satcom/maintenance/upcoming", func(c *gin.Context) {
+		resp := s.getUpcomingSatcomMaintenance(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.GET("/api/satcom/maintenance/:id", func(c *gin.Context) {
+		resp := s.getSatcomMaintenance(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.PUT("/api/satcom/maintenance/:id", func(c *gin.Context) {
+		resp := s.updateSatcomMaintenance(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.DELETE("/api/satcom/maintenance/:id", func(c *gin.Context) {
+		resp := s.deleteSatcomMaintenance(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.GET("/api/satcom/changes", func(c *gin.Context) {
+		resp := s.listSatcomChangeRequests(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.GET("/api/satcom/changes/:id", func(c *gin.Context) {
+		resp := s.getSatcomChangeRequest(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.DELETE("/api/satcom/changes/:id", func(c *gin.Context) {
+		resp := s.withdrawSatcomChangeRequest(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.POST("/api/satcom/changes/:id/approve", func(c *gin.Context) {
+		resp := s.approveSatcomChangeRequest(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.POST("/api/satcom/changes/:id/return", func(c *gin.Context) {
+		resp := s.returnSatcomChangeRequest(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.GET("/api/satcom/events", func(c *gin.Context) {
+		s.streamSatcomEvents(c)
+	})
+
+	router.GET("/api/satcom/events/ws", func(c *gin.Context) {
+		s.streamSatcomEventsWS(c)
+	})
+
+	router.GET("/api/satcom/graph", func(c *gin.Context) {
+		s.getSatcomGraph(c)
+	})
+
+	router.GET("/api/satcom/impact", func(c *gin.Context) {
+		resp := s.getSatcomImpact(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.GET("/api/satcom/conflicts", func(c *gin.Context) {
+		resp := s.getSatcomConflicts(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.GET("/api/satcom/next-free-port", func(c *gin.Context) {
+		resp := s.getNextFreePort(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.GET("/api/satcom/as-of", func(c *gin.Context) {
+		resp := s.getSatcomInventoryAsOf(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.GET("/api/satcom/recycle-bin", func(c *gin.Context) {
+		resp := s.listSatcomRecycleBin(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.POST("/api/satcom/recycle-bin/:id/restore", func(c *gin.Context) {
+		resp := s.undeleteSatcomData(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.DELETE("/api/satcom/recycle-bin/:id", func(c *gin.Context) {
+		resp := s.purgeSatcomData(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.GET("/api/satcom/certificates/expiring", func(c *gin.Context) {
+		resp := s.getExpiringCertificates(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.GET("/api/satcom/stats", func(c *gin.Context) {
+		resp := s.getSatcomStats(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.GET("/api/satcom/secrets/due", func(c *gin.Context) {
+		resp := s.listSatcomSecretsDue(c)
+		c.JSON(resp.StatusCode, resp)
+	})
 	router.GET("/api/satcom/:id", func(c *gin.Context) {
 		resp := s.getSatcomDataById(c)
+		// A 304 must not have a body; the ETag header is already set
+		if resp.StatusCode == http.StatusNotModified {
+			c.Status(http.StatusNotModified)
+			return
+		}
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.GET("/api/satcom/:id/uptime", func(c *gin.Context) {
+		resp := s.getSatcomUptime(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.GET("/api/satcom/:id/latency", func(c *gin.Context) {
+		resp := s.getSatcomLatency(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.POST("/api/satcom/:id/probe", func(c *gin.Context) {
+		resp := s.probeSatcomData(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.GET("/api/satcom/:id/certificate", func(c *gin.Context) {
+		resp := s.getSatcomCertificate(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.POST("/api/satcom/:id/certificate/check", func(c *gin.Context) {
+		resp := s.checkSatcomCertificate(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
+	router.GET("/api/satcom/:id/versions", func(c *gin.Context) {
+		resp := s.listSatcomVersions(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.GET("/api/satcom/:id/versions/:version", func(c *gin.Context) {
+		resp := s.getSatcomVersion(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.POST("/api/satcom/:id/versions/:version/restore", func(c *gin.Context) {
+		resp := s.restoreSatcomVersion(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.GET("/api/satcom/:id/diff", func(c *gin.Context) {
+		resp := s.diffSatcomVersions(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.PUT("/api/satcom/:id/status", func(c *gin.Context) {
+		resp := s.setSatcomStatus(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.GET("/api/satcom/:id/relations", func(c *gin.Context) {
+		resp := s.listSatcomRelations(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.POST("/api/satcom/:id/relations", func(c *gin.Context) {
+		resp := s.createSatcomRelation(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.DELETE("/api/satcom/:id/relations/:relationId", func(c *gin.Context) {
+		resp := s.deleteSatcomRelation(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.GET("/api/satcom/:id/secrets", func(c *gin.Context) {
+		resp := s.listSatcomSecrets(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.POST("/api/satcom/:id/secrets", func(c *gin.Context) {
+		resp := s.createSatcomSecret(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.GET("/api/satcom/:id/secrets/:secretId", func(c *gin.Context) {
+		resp := s.getSatcomSecret(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.PUT("/api/satcom/:id/secrets/:secretId", func(c *gin.Context) {
+		resp := s.rotateSatcomSecret(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.DELETE("/api/satcom/:id/secrets/:secretId", func(c *gin.Context) {
+		resp := s.deleteSatcomSecret(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.POST("/api/satcom/:id/secrets/:secretId/reveal", func(c *gin.Context) {
+		resp := s.revealSatcomSecret(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.GET("/api/satcom/:id/attachments", func(c *gin.Context) {
+		resp := s.listSatcomAttachments(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.POST("/api/satcom/:id/attachments", func(c *gin.Context) {
+		resp := s.uploadSatcomAttachments(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.GET("/api/satcom/:id/attachments/:attachmentId/download", func(c *gin.Context) {
+		resp := s.downloadSatcomAttachment(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.DELETE("/api/satcom/:id/attachments/:attachmentId", func(c *gin.Context) {
+		resp := s.deleteSatcomAttachment(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.GET("/api/satcom/:id/notes", func(c *gin.Context) {
+		resp := s.listSatcomNotes(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.POST("/api/satcom/:id/notes", func(c *gin.Context) {
+		resp := s.createSatcomNote(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.PUT("/api/satcom/:id/notes/:noteId", func(c *gin.Context) {
+		resp := s.updateSatcomNote(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.DELETE("/api/satcom/:id/notes/:noteId", func(c *gin.Context) {
+		resp := s.deleteSatcomNote(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.POST("/api/satcom/:id/notes/:noteId/pin", func(c *gin.Context) {
+		resp := s.pinSatcomNote(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.POST("/api/satcom/:id/notes/:noteId/unpin", func(c *gin.Context) {
+		resp := s.unpinSatcomNote(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.POST("/api/satcom/:id/notes/:noteId/resolve", func(c *gin.Context) {
+		resp := s.resolveSatcomNote(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.POST("/api/satcom/:id/notes/:noteId/reopen", func(c *gin.Context) {
+		resp := s.reopenSatcomNote(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.GET("/api/satcom/:id/timeline", func(c *gin.Context) {
+		resp := s.getSatcomTimeline(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.GET("/api/satcom/:id/upstream", func(c *gin.Context) {
+		resp := s.getSatcomUpstream(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+	router.GET("/api/satcom/:id/downstream", func(c *gin.Context) {
+		resp := s.getSatcomDownstream(c)
 		c.JSON(resp.StatusCode, resp)
 	})
 
@@ -108,8 +595,62 @@ func (s *RESTService) AddRouters(apiBase string, router *gin.Engine) {
 		c.JSON(resp.StatusCode, resp)
 	})
 
+	router.PATCH("/api/satcom/:id", func(c *gin.Context) {
+		resp := s.patchSatcomData(c)
+		c.JSON(resp.StatusCode, resp)
+	})
+
 	router.DELETE("/api/satcom/:id", func(c *gin.Context) {
 		resp := s.deleteSatcomData(c)
 		c.JSON(resp.StatusCode, resp)
 	})
+
+	// SCIM 2.0 provisioning routes, authorized by the provisioning token instead of a JWT
+	router.GET(SCIM_API_BASE+"/Users", func(c *gin.Context) {
+		writeScimResponse(c, s.scimListUsers(c))
+	})
+
+	router.POST(SCIM_API_BASE+"/Users", func(c *gin.Context) {
+		writeScimResponse(c, s.scimCreateUser(c))
+	})
+
+	router.GET(SCIM_API_BASE+"/Users/:id", func(c *gin.Context) {
+		writeScimResponse(c, s.scimGetUser(c))
+	})
+
+	router.PUT(SCIM_API_BASE+"/Users/:id", func(c *gin.Context) {
+		writeScimResponse(c, s.scimReplaceUser(c))
+	})
+
+	router.PATCH(SCIM_API_BASE+"/Users/:id", func(c *gin.Context) {
+		writeScimResponse(c, s.scimPatchUser(c))
+	})
+
+	router.DELETE(SCIM_API_BASE+"/Users/:id", func(c *gin.Context) {
+		writeScimResponse(c, s.scimDeleteUser(c))
+	})
+
+	router.GET(SCIM_API_BASE+"/Groups", func(c *gin.Context) {
+		writeScimResponse(c, s.scimListGroups(c))
+	})
+
+	router.GET(SCIM_API_BASE+"/Groups/:id", func(c *gin.Context) {
+		writeScimResponse(c, s.scimGetGroup(c))
+	})
+
+	router.PUT(SCIM_API_BASE+"/Groups/:id", func(c *gin.Context) {
+		writeScimResponse(c, s.scimModifyGroup(c, true))
+	})
+
+	router.PATCH(SCIM_API_BASE+"/Groups/:id", func(c *gin.Context) {
+		writeScimResponse(c, s.scimModifyGroup(c, false))
+	})
+
+	router.POST(SCIM_API_BASE+"/Groups", func(c *gin.Context) {
+		writeScimResponse(c, scimError(501, "", "Groups map to fixed roles and cannot be created"))
+	})
+
+	router.DELETE(SCIM_API_BASE+"/Groups/:id", func(c *gin.Context) {
+		writeScimResponse(c, scimError(501, "", "Groups map to fixed roles and cannot be deleted"))
+	})
 }
@@ -0,0 +1,547 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+)
+
+// Statuses accepted by the status filter of GET /api/satcom/changes
+var satcomChangeStatuses = map[string]bool{
+	SATCOM_CHANGE_PENDING_APPROVAL: true,
+	SATCOM_CHANGE_APPROVED:         true,
+	SATCOM_CHANGE_RETURNED:         true,
+	SATCOM_CHANGE_WITHDRAWN:        true,
+}
+
+// Snapshot columns that may change while a request is pending without invalidating it;
+// the prober keeps updating the status of production entries
+var satcomChangeDriftIgnored = map[string]bool{
+	"status":          true,
+	"status_override": true,
+}
+
+// initSatcomApprovals parses the selector of the entries whose changes need approval
+func (s *RESTService) initSatcomApprovals(conf *model.ApprovalConfig) error {
+	if conf == nil || !conf.Enabled {
+		return nil
+	}
+	str := strings.TrimSpace(conf.LabelSelector)
+	if str == "" {
+		str = DEFAULT_APPROVAL_SELECTOR
+	}
+	selector, err := parseLabelSelector(str)
+	if err != nil {
+		return fmt.Errorf("approvals.labelSelector: %v", err)
+	}
+	s.approvalSelector = selector
+	s.approvalEmails = conf.NotifyEmails
+	_asLogger.Infof("Changes to satcom entries matching %q need approval", str)
+	return nil
+}
+
+// requiresApproval reports whether updates, deletes and status changes of an entry wait for approval
+func (s *RESTService) requiresApproval(data auth.CommonSatcomDatum) bool {
+	return s.approvalSelector != nil && s.approvalSelector.matches(decodeSatcomLabels(data.Labels))
+}
+
+// submitSatcomChange stores a validated update (proposed) or delete of an entry needing
+// approval as a change request, commits tx and notifies the approvers. An entry has at
+// most one pending request.
+func (s *RESTService) submitSatcomChange(ctx context.Context, tx pgx.Tx, c *gin.Context, operation string, current auth.CommonSatcomDatum, proposed *auth.CommonSatcomDatum, allowConflicts bool) APIResponse {
+	version, err := requestAPIVersion(c)
+	if err != nil {
+		return BuildResponse400(err.Error())
+	}
+	qtx := auth.New(tx)
+	pending, err := qtx.GetPendingSatcomChangeRequest(ctx, current.ID)
+	if err == nil {
+		return buildResponse(409, false, "A change request of this entry is already pending approval", s.toSatcomChangeRequest(pending, version))
+	}
+	if err != pgx.ErrNoRows {
+		_asLogger.Errorf("Error getting pending change request of satcom data %d: %v", current.ID, err)
+		return BuildResponse500("Failed to submit change request", err.Error())
+	}
+
+	params := auth.CreateSatcomChangeRequestParams{
+		SatcomID:       current.ID,
+		Company:        current.Company,
+		Operation:      operation,
+		AllowConflicts: allowConflicts,
+		Comment:        strings.TrimSpace(c.Query("comment")),
+		RequestedBy:    s.currentUserID(c),
+	}
+	if params.Base, err = json.Marshal(current); err != nil {
+		return BuildResponse500("Failed to submit change request", err.Error())
+	}
+	if proposed != nil {
+		if params.Proposed, err = json.Marshal(proposed); err != nil {
+			return BuildResponse500("Failed to submit change request", err.Error())
+		}
+	}
+	requesterEmail := ""
+	if claims := s.currentClaims(c); claims != nil {
+		params.RequestedByName = getSQLString(claims.UserName)
+		requesterEmail = claims.Email
+		if claims.Act != nil {
+			params.RequestedActorID = ConvertInt32ToPgInt4(claims.Act.UserID)
+		}
+	}
+	row, err := qtx.CreateSatcomChangeRequest(ctx, params)
+	if err != nil {
+		_asLogger.Errorf("Error creating change request of satcom data %d: %v", current.ID, err)
+		return BuildResponse500("Failed to submit change request", err.Error())
+	}
+	if err := tx.Commit(ctx); err != nil {
+		_asLogger.Errorf("Error committing change request of satcom data %d: %v", current.ID, err)
+		return BuildResponse500("Failed to submit change request", err.Error())
+	}
+
+	request := s.toSatcomChangeRequest(row, version)
+	s.notifySatcomChangeRequest(request, requesterEmail)
+	return buildResponse(202, true, "Change submitted for approval", request)
+}
+
+// /api/satcom/changes - change requests of the caller's companies, newest first; pending
+// ones unless status says otherwise
+func (s *RESTService) listSatcomChangeRequests(c *gin.Context) APIResponse {
+	version, err := requestAPIVersion(c)
+	if err != nil {
+		return BuildResponse400(err.Error())
+	}
+	scope, errResp := s.satcomScope(c)
+	if errResp != nil {
+		return *errResp
+	}
+	status := strings.ToUpper(strings.TrimSpace(c.DefaultQuery("status", SATCOM_CHANGE_PENDING_APPROVAL)))
+	if !satcomChangeStatuses[status] {
+		return BuildResponse400("status must be one of PENDING_APPROVAL, APPROVED, RETURNED, WITHDRAWN")
+	}
+	satcomID, err := parseOptionalSatcomID(c)
+	if err != nil {
+		return BuildResponse400(err.Error())
+	}
+	params := auth.ListSatcomChangeRequestsParams{
+		Company: optionalText(c.Query("company")),
+		Status:  getSQLString(status),
+	}
+	if err := scope.restrict(&params.Company); err != nil {
+		return BuildResponse403(err.Error())
+	}
+	if satcomID != nil {
+		params.SatcomID = ConvertInt32ToPgInt4(*satcomID)
+	}
+
+	rows, err := auth.New(s.dbConn.GetPool()).ListSatcomChangeRequests(context.Background(), params)
+	if err != nil {
+		_asLogger.Errorf("Error getting satcom change requests: %v", err)
+		return BuildResponse500("Failed to retrieve change requests", err.Error())
+	}
+	requests := make([]model.SatcomChangeRequest, 0, len(rows))
+	for _, row := range rows {
+		requests = append(requests, s.toSatcomChangeRequest(row, version))
+	}
+	return BuildResponse200("Change requests retrieved successfully", requests)
+}
+
+// /api/satcom/changes/:id - one change request with its diff
+func (s *RESTService) getSatcomChangeRequest(c *gin.Context) APIResponse {
+	version, err := requestAPIVersion(c)
+	if err != nil {
+		return BuildResponse400(err.Error())
+	}
+	scope, errResp := s.satcomScope(c)
+	if errResp != nil {
+		return *errResp
+	}
+	row, errResp := loadSatcomChangeRequest(context.Background(), auth.New(s.dbConn.GetPool()), c, scope, false)
+	if errResp != nil {
+		return *errResp
+	}
+	return BuildResponse200("Change request retrieved successfully", s.toSatcomChangeRequest(row, version))
+}
+
+// /api/satcom/changes/:id/approve - apply a pending change request. The entry must not
+// have been changed since the request, apart from its status; the requester cannot
+// approve their own request.
+func (s *RESTService) approveSatcomChangeRequest(c *gin.Context) APIResponse {
+	version, err := requestAPIVersion(c)
+	if err != nil {
+		return BuildResponse400(err.Error())
+	}
+	var input model.SatcomChangeReviewInput
+	if c.Request.ContentLength != 0 && !parseInput(c, &input) {
+		return BuildResponse400("Invalid input provided")
+	}
+	scope, errResp := s.satcomScope(c)
+	if errResp != nil {
+		return *errResp
+	}
+
+	ctx := context.Background()
+	db := s.dbConn.GetPool()
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		_asLogger.Errorf("Error starting transaction: %v", err)
+		return BuildResponse500("Failed to approve change request", err.Error())
+	}
+	defer tx.Rollback(ctx)
+	qtx := auth.New(tx)
+
+	row, errResp := loadSatcomChangeRequest(ctx, qtx, c, scope, true)
+	if errResp != nil {
+		return *errResp
+	}
+	if errResp := s.checkSatcomChangeReviewer(c, scope, row); errResp != nil {
+		return *errResp
+	}
+	current, err := qtx.GetSatcomDataById(ctx, row.SatcomID)
+	if err != nil {
+		return buildResponse(409, false, "Satcom data no longer exists; return the change request", nil)
+	}
+	base, err := satcomDatumFromSnapshot(row.Base)
+	if err != nil {
+		_asLogger.Errorf("Error decoding change request %d: %v", row.ID, err)
+		return BuildResponse500("Failed to approve change request", err.Error())
+	}
+	drift, err := satcomChangeDrift(row.Base, current)
+	if err != nil {
+		_asLogger.Errorf("Error comparing change request %d: %v", row.ID, err)
+		return BuildResponse500("Failed to approve change request", err.Error())
+	}
+	if len(drift) > 0 {
+		return buildResponse(409, false, "Satcom data was changed since the request; return it to be submitted again", map[string]interface{}{
+			"changes": drift,
+		})
+	}
+
+	if row.Operation == SATCOM_OP_DELETE {
+		deleted, err := qtx.SoftDeleteSatcomData(ctx, auth.SoftDeleteSatcomDataParams{
+			ID:        current.ID,
+			DeletedBy: s.currentUserID(c),
+			Version:   current.Version,
+		})
+		if err != nil {
+			_asLogger.Errorf("Error deleting satcom data: %v", err)
+			return BuildResponse500("Failed to approve change request", err.Error())
+		}
+		if deleted == 0 {
+			return BuildResponse412("Satcom data was changed concurrently; retry")
+		}
+	} else if row.Operation == SATCOM_OP_STATUS {
+		proposed, err := satcomDatumFromSnapshot(row.Proposed)
+		if err != nil {
+			_asLogger.Errorf("Error decoding change request %d: %v", row.ID, err)
+			return BuildResponse500("Failed to approve change request", err.Error())
+		}
+		params := auth.SetSatcomStatusOverrideParams{StatusOverride: proposed.StatusOverride, ID: current.ID}
+		// A pinned or changed status is applied; otherwise the prober's latest result stays
+		if proposed.StatusOverride || proposed.Status != base.Status {
+			params.Status = pgtype.Bool{Bool: proposed.Status, Valid: true}
+		}
+		updated, err := qtx.SetSatcomStatusOverride(ctx, params)
+		if err != nil {
+			_asLogger.Errorf("Error setting status of satcom data %d: %v", current.ID, err)
+			return BuildResponse500("Failed to approve change request", err.Error())
+		}
+		if updated == 0 {
+			return BuildResponse412("Satcom data was changed concurrently; retry")
+		}
+	} else {
+		proposed, err := satcomDatumFromSnapshot(row.Proposed)
+		if err != nil {
+			_asLogger.Errorf("Error decoding change request %d: %v", row.ID, err)
+			return BuildResponse500("Failed to approve change request", err.Error())
+		}
+		// A status the request left alone stays with the prober's latest result
+		if proposed.Status == base.Status {
+			proposed.Status = current.Status
+		}
+		if !row.AllowConflicts {
+			if resp := s.checkSatcomConflicts(ctx, qtx, proposed); resp != nil {
+				return *resp
+			}
+		}
+		updated, err := qtx.UpdateSatcomData(ctx, satcomUpdateParams(proposed, current.Version))
+		if err != nil {
+			_asLogger.Errorf("Error updating satcom data: %v", err)
+			return BuildResponse500("Failed to approve change request", err.Error())
+		}
+		if updated == 0 {
+			return BuildResponse412("Satcom data was changed concurrently; retry")
+		}
+		if err := qtx.DeleteSatcomConversionIssues(ctx, current.ID); err != nil {
+			_asLogger.Errorf("Error clearing conversion issues of satcom data %d: %v", current.ID, err)
+			return BuildResponse500("Failed to approve change request", err.Error())
+		}
+	}
+	if err := s.recordSatcomVersion(ctx, qtx, c, current.ID, row.Operation, pgtype.Int4{}); err != nil {
+		_asLogger.Errorf("Error recording history of satcom data %d: %v", current.ID, err)
+		return BuildResponse500("Failed to approve change request", err.Error())
+	}
+
+	reviewed, errResp := s.reviewSatcomChangeRequest(ctx, qtx, c, row, SATCOM_CHANGE_APPROVED, input.Comment)
+	if errResp != nil {
+		return *errResp
+	}
+	if err := tx.Commit(ctx); err != nil {
+		_asLogger.Errorf("Error committing change request %d: %v", row.ID, err)
+		return BuildResponse500("Failed to approve change request", err.Error())
+	}
+
+	request := s.toSatcomChangeRequest(reviewed, version)
+	s.notifySatcomChangeDecision(request)
+	return BuildResponse200("Change request approved and applied", request)
+}
+
+// /api/satcom/changes/:id/return - send a pending change request back to the requester
+// with a comment, without applying it
+func (s *RESTService) returnSatcomChangeRequest(c *gin.Context) APIResponse {
+	var input model.SatcomChangeReviewInput
+	if !parseInput(c, &input) {
+		return BuildResponse400("Invalid input provided")
+	}
+	if strings.TrimSpace(input.Comment) == "" {
+		return BuildValidationResponse([]model.FieldError{{Field: "comment", Message: "is required"}})
+	}
+	return s.closeSatcomChangeRequest(c, SATCOM_CHANGE_RETURNED, input.Comment)
+}
+
+// /api/satcom/changes/:id - withdraw a pending change request (its requester only)
+func (s *RESTService) withdrawSatcomChangeRequest(c *gin.Context) APIResponse {
+	return s.closeSatcomChangeRequest(c, SATCOM_CHANGE_WITHDRAWN, "")
+}
+
+// closeSatcomChangeRequest returns or withdraws a pending change request
+func (s *RESTService) closeSatcomChangeRequest(c *gin.Context, status string, comment string) APIResponse {
+	version, err := requestAPIVersion(c)
+	if err != nil {
+		return BuildResponse400(err.Error())
+	}
+	scope, errResp := s.satcomScope(c)
+	if errResp != nil {
+		return *errResp
+	}
+	ctx := context.Background()
+	qtx := auth.New(s.dbConn.GetPool())
+	row, errResp := loadSatcomChangeRequest(ctx, qtx, c, scope, true)
+	if errResp != nil {
+		return *errResp
+	}
+	if status == SATCOM_CHANGE_WITHDRAWN {
+		if row.RequestedBy != s.currentUserID(c) {
+			return BuildResponse403("Only the requester can withdraw a change request")
+		}
+	} else if errResp := s.checkSatcomChangeReviewer(c, scope, row); errResp != nil {
+		return *errResp
+	}
+
+	reviewed, errResp := s.reviewSatcomChangeRequest(ctx, qtx, c, row, status, comment)
+	if errResp != nil {
+		return *errResp
+	}
+	request := s.toSatcomChangeRequest(reviewed, version)
+	if status == SATCOM_CHANGE_RETURNED {
+		s.notifySatcomChangeDecision(request)
+		return BuildResponse200("Change request returned", request)
+	}
+	return BuildResponse200("Change request withdrawn", request)
+}
+
+// loadSatcomChangeRequest reads the :id change request; requests of other companies are
+// reported as not found. With pending set, closed requests are answered with 409.
+func loadSatcomChangeRequest(ctx context.Context, qtx *auth.Queries, c *gin.Context, scope satcomScope, pending bool) (auth.CommonSatcomChangeRequest, *APIResponse) {
+	var row auth.CommonSatcomChangeRequest
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		resp := BuildResponse400("Invalid ID format")
+		return row, &resp
+	}
+	row, err = qtx.GetSatcomChangeRequest(ctx, int32(id))
+	if err == pgx.ErrNoRows || (err == nil && !scope.contains(row.Company)) {
+		resp := BuildResponse404("Change request not found", false)
+		return row, &resp
+	}
+	if err != nil {
+		_asLogger.Errorf("Error getting satcom change request %d: %v", id, err)
+		resp := BuildResponse500("Failed to retrieve change request", err.Error())
+		return row, &resp
+	}
+	if pending && row.Status != SATCOM_CHANGE_PENDING_APPROVAL {
+		resp := buildResponse(409, false, fmt.Sprintf("Change request is already %s", strings.ToLower(row.Status)), nil)
+		return row, &resp
+	}
+	return row, nil
+}
+
+// checkSatcomChangeReviewer answers 403 unless the caller is an approver of the company
+// other than the requester. Impersonation tokens cannot review, and an administrator who
+// submitted a request while impersonating cannot review it under their own name either.
+func (s *RESTService) checkSatcomChangeReviewer(c *gin.Context, scope satcomScope, row auth.CommonSatcomChangeRequest) *APIResponse {
+	if !scope.canApprove() {
+		resp := BuildResponse403(fmt.Sprintf("Your role in %s does not allow reviewing changes", scope.company))
+		return &resp
+	}
+	if claims := s.currentClaims(c); claims != nil && claims.Act != nil {
+		resp := BuildResponse403("Change requests cannot be reviewed while impersonating")
+		return &resp
+	}
+	reviewer := s.currentUserID(c)
+	if reviewer.Valid && (row.RequestedBy == reviewer || row.RequestedActorID == reviewer) {
+		resp := BuildResponse403("Change requests must be reviewed by another user")
+		return &resp
+	}
+	return nil
+}
+
+// reviewSatcomChangeRequest closes a pending request with the caller as reviewer
+func (s *RESTService) reviewSatcomChangeRequest(ctx context.Context, qtx *auth.Queries, c *gin.Context, row auth.CommonSatcomChangeRequest, status string, comment string) (auth.CommonSatcomChangeRequest, *APIResponse) {
+	params := auth.ReviewSatcomChangeRequestParams{
+		ID:            row.ID,
+		Status:        status,
+		ReviewedBy:    s.currentUserID(c),
+		ReviewComment: optionalText(strings.TrimSpace(comment)),
+	}
+	if claims := s.currentClaims(c); claims != nil {
+		params.ReviewedByName = getSQLString(claims.UserName)
+	}
+	reviewed, err := qtx.ReviewSatcomChangeRequest(ctx, params)
+	if err != nil {
+		_asLogger.Errorf("Error reviewing satcom change request %d: %v", row.ID, err)
+		resp := BuildResponse500("Failed to review change request", err.Error())
+		return row, &resp
+	}
+	if reviewed == 0 {
+		resp := buildResponse(409, false, "Change request was reviewed concurrently", nil)
+		return row, &resp
+	}
+	row, err = qtx.GetSatcomChangeRequest(ctx, row.ID)
+	if err != nil {
+		_asLogger.Errorf("Error getting satcom change request %d: %v", row.ID, err)
+		resp := BuildResponse500("Failed to review change request", err.Error())
+		return row, &resp
+	}
+	return row, nil
+}
+
+// satcomChangeDrift lists the columns changed since a request was made, other than the status
+func satcomChangeDrift(base []byte, current auth.CommonSatcomDatum) ([]model.SatcomFieldChange, error) {
+	now, err := json.Marshal(current)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := diffSnapshots(base, now)
+	if err != nil {
+		return nil, err
+	}
+	drift := make([]model.SatcomFieldChange, 0)
+	for _, change := range changes {
+		if !satcomChangeDriftIgnored[change.Field] {
+			drift = append(drift, change)
+		}
+	}
+	return drift, nil
+}
+
+// toSatcomChangeRequest renders a change request with the entries in the requested API version
+func (s *RESTService) toSatcomChangeRequest(row auth.CommonSatcomChangeRequest, version int) model.SatcomChangeRequest {
+	request := model.SatcomChangeRequest{
+		ID:              row.ID,
+		SatcomID:        row.SatcomID,
+		Company:         row.Company,
+		Operation:       row.Operation,
+		Status:          row.Status,
+		AllowConflicts:  row.AllowConflicts,
+		Comment:         row.Comment,
+		RequestedAt:     row.RequestedAt.Time,
+		RequestedByName: row.RequestedByName.String,
+		ReviewedByName:  row.ReviewedByName.String,
+		ReviewComment:   row.ReviewComment.String,
+		Changes:         make([]model.SatcomFieldChange, 0),
+	}
+	if row.RequestedBy.Valid {
+		request.RequestedBy = &row.RequestedBy.Int32
+	}
+	if row.ReviewedBy.Valid {
+		request.ReviewedBy = &row.ReviewedBy.Int32
+	}
+	if row.ReviewedAt.Valid {
+		reviewedAt := row.ReviewedAt.Time
+		request.ReviewedAt = &reviewedAt
+	}
+	if base, err := satcomDatumFromSnapshot(row.Base); err == nil {
+		request.BaseVersion = base.Version
+		request.Current = toSatcomResponse(base, version)
+	} else {
+		_asLogger.Errorf("Error decoding change request %d: %v", row.ID, err)
+	}
+	if row.Proposed != nil {
+		if proposed, err := satcomDatumFromSnapshot(row.Proposed); err == nil {
+			request.Proposed = toSatcomResponse(proposed, version)
+		}
+		if changes, err := diffSnapshots(row.Base, row.Proposed); err == nil {
+			request.Changes = changes
+		}
+	}
+	return request
+}
+
+// notifySatcomChangeRequest queues the mails of a new change request to the configured
+// recipients, or to the approvers of the company, falling back to the super admins
+func (s *RESTService) notifySatcomChangeRequest(request model.SatcomChangeRequest, requesterEmail string) {
+	ctx := context.Background()
+	qtx := auth.New(s.dbConn.GetPool())
+	recipients := s.approvalEmails
+	if len(recipients) == 0 {
+		emails, err := qtx.ListCompanyApproverEmails(ctx, request.Company)
+		if err != nil {
+			_asLogger.Errorf("Error getting approvers of company %s: %v", request.Company, err)
+			return
+		}
+		recipients = emails
+	}
+	if len(recipients) == 0 {
+		emails, err := qtx.GetActiveUserEmailsByRole(ctx, ROLE_SUPER_ADMIN)
+		if err != nil {
+			_asLogger.Errorf("Error getting super admin emails: %v", err)
+			return
+		}
+		recipients = emails
+	}
+	queued := 0
+	for _, recipient := range recipients {
+		if strings.EqualFold(recipient, requesterEmail) {
+			continue
+		}
+		if s.mails.Enqueue(fmt.Sprintf("change request %d to %s", request.ID, recipient), func(mailer *SmtpService) error {
+			return mailer.SendChangeRequestMail(recipient, request)
+		}) {
+			queued++
+		}
+	}
+	_asLogger.Infof("Queued change request %d for satcom data %d to %d approver(s)", request.ID, request.SatcomID, queued)
+}
+
+// notifySatcomChangeDecision queues a mail telling the requester that their request was
+// approved or returned
+func (s *RESTService) notifySatcomChangeDecision(request model.SatcomChangeRequest) {
+	if request.RequestedBy == nil {
+		return
+	}
+	user, err := auth.New(s.dbConn.GetPool()).GetUserById(context.Background(), *request.RequestedBy)
+	if err != nil {
+		_asLogger.Errorf("Error getting requester of change request %d: %v", request.ID, err)
+		return
+	}
+	s.mails.Enqueue(fmt.Sprintf("decision on change request %d to %s", request.ID, user.Email), func(mailer *SmtpService) error {
+		return mailer.SendChangeDecisionMail(user.Email, request)
+	})
+}
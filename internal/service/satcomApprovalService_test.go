@@ -0,0 +1,76 @@
+package service
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+)
+
+func TestSatcomChangeDrift(t *testing.T) {
+	base := auth.CommonSatcomDatum{
+		ID:       5,
+		Company:  "acme",
+		Category: "database",
+		Type:     "postgres",
+		Url:      "https://db.acme.example",
+		DbPort:   pgtype.Int4{Int32: 5432, Valid: true},
+		Status:   true,
+		Version:  3,
+		Name:     pgtype.Text{String: "orders-db", Valid: true},
+		Labels:   json.RawMessage(`{"env":"prod"}`),
+	}
+	snapshot, err := json.Marshal(base)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	tests := []struct {
+		name   string
+		change func(*auth.CommonSatcomDatum)
+		fields []string
+	}{
+		{"unchanged", func(d *auth.CommonSatcomDatum) {}, nil},
+		{"probed", func(d *auth.CommonSatcomDatum) {
+			d.Status = false
+			d.Version = 4
+			d.LastProbedAt = pgtype.Timestamptz{Time: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), Valid: true}
+		}, nil},
+		{"status pinned", func(d *auth.CommonSatcomDatum) {
+			d.Status = false
+			d.StatusOverride = true
+		}, nil},
+		{"url", func(d *auth.CommonSatcomDatum) { d.Url = "https://db2.acme.example" }, []string{"url"}},
+		{"port cleared", func(d *auth.CommonSatcomDatum) { d.DbPort = pgtype.Int4{} }, []string{"db_port"}},
+		{"labels", func(d *auth.CommonSatcomDatum) { d.Labels = json.RawMessage(`{"env":"dev"}`) }, []string{"labels"}},
+		{"labels reformatted", func(d *auth.CommonSatcomDatum) { d.Labels = json.RawMessage(`{ "env": "prod" }`) }, nil},
+		{"several with status", func(d *auth.CommonSatcomDatum) {
+			d.Status = false
+			d.Type = "mysql"
+			d.Company = "globex"
+		}, []string{"company", "type"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := base
+			tt.change(&current)
+			drift, err := satcomChangeDrift(snapshot, current)
+			if err != nil {
+				t.Fatalf("satcomChangeDrift: %v", err)
+			}
+			var fields []string
+			for _, change := range drift {
+				fields = append(fields, change.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.fields) {
+				t.Errorf("drift = %v, want %v", fields, tt.fields)
+			}
+		})
+	}
+
+	if _, err := satcomChangeDrift([]byte("not json"), base); err == nil {
+		t.Error("an invalid base snapshot was accepted")
+	}
+}
@@ -0,0 +1,338 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+	"github.com/rest/api/internal/util"
+	"github.com/spf13/viper"
+)
+
+// satcomAttachmentStore keeps the files attached to satcom entries in the S3 bucket,
+// every entry under its own prefix so that purging the entry can remove them all
+type satcomAttachmentStore struct {
+	session   *session.Session
+	maxSize   int64
+	urlExpiry time.Duration
+}
+
+// newSatcomAttachmentStore connects to S3; a nil store means attachments are disabled
+func newSatcomAttachmentStore(conf *model.AttachmentConfig) (*satcomAttachmentStore, error) {
+	if conf == nil || !conf.Enabled {
+		return nil, nil
+	}
+	if viper.GetStringMapString("aws")["s3_bucket"] == "" {
+		return nil, fmt.Errorf("attachments need aws.s3_bucket")
+	}
+	sess, err := util.NewS3Session()
+	if err != nil {
+		return nil, err
+	}
+	a := &satcomAttachmentStore{
+		session:   sess,
+		maxSize:   int64(conf.MaxSizeMB) << 20,
+		urlExpiry: time.Duration(conf.DownloadExpiryMinutes) * time.Minute,
+	}
+	if a.maxSize <= 0 {
+		a.maxSize = DEFAULT_ATTACHMENT_MAX_SIZE
+	}
+	if a.urlExpiry <= 0 {
+		a.urlExpiry = DEFAULT_ATTACHMENT_URL_EXPIRY
+	}
+	return a, nil
+}
+
+// prefix is the folder holding the objects of an entry
+func (a *satcomAttachmentStore) prefix(satcomID int32) string {
+	return path.Join(viper.GetStringMapString("aws")["s3_folder"], SATCOM_ATTACHMENT_PREFIX, strconv.Itoa(int(satcomID)))
+}
+
+// objectKey builds a unique key for an uploaded file below the entry's prefix
+func (a *satcomAttachmentStore) objectKey(satcomID int32, fileName string) (string, error) {
+	random := make([]byte, 8)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	safe := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, fileName)
+	return a.prefix(satcomID) + "/" + hex.EncodeToString(random) + "-" + safe, nil
+}
+
+// removeEntries deletes every object of the purged entries. The entries are gone already,
+// so failures are recorded in satcom_attachment_cleanups and retried by the purge job.
+func (a *satcomAttachmentStore) removeEntries(ctx context.Context, qtx *auth.Queries, ids []int32) {
+	for _, id := range ids {
+		if err := a.removeEntry(id); err != nil {
+			_asLogger.Errorf("Error deleting attachments of purged satcom data %d: %v", id, err)
+			if err := qtx.RecordSatcomAttachmentCleanup(ctx, auth.RecordSatcomAttachmentCleanupParams{SatcomID: id, LastError: err.Error()}); err != nil {
+				_asLogger.Errorf("Error recording attachment cleanup of satcom data %d: %v", id, err)
+			}
+			continue
+		}
+		if err := qtx.DeleteSatcomAttachmentCleanup(ctx, id); err != nil {
+			_asLogger.Errorf("Error clearing attachment cleanup of satcom data %d: %v", id, err)
+		}
+	}
+}
+
+// removeEntry deletes the objects below the prefix of one entry
+func (a *satcomAttachmentStore) removeEntry(id int32) error {
+	keys, err := util.GetListOfFileFromS3(a.session, a.prefix(id))
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	_, deleted, errs := util.DeleteFilesFromS3(a.session, keys)
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	_asLogger.Infof("Deleted %d attachment(s) of purged satcom data %d", len(deleted), id)
+	return nil
+}
+
+// /api/satcom/:id/attachments - the files attached to an entry, newest first
+func (s *RESTService) listSatcomAttachments(c *gin.Context) APIResponse {
+	id, _, errResp := s.authorizeSatcomAttachments(c, false)
+	if errResp != nil {
+		return *errResp
+	}
+	rows, err := auth.New(s.dbConn.GetPool()).ListSatcomAttachments(context.Background(), id)
+	if err != nil {
+		_asLogger.Errorf("Error listing attachments of satcom data %d: %v", id, err)
+		return BuildResponse500("Failed to retrieve attachments", err.Error())
+	}
+	attachments := make([]model.SatcomAttachment, 0, len(rows))
+	for _, row := range rows {
+		attachments = append(attachments, toSatcomAttachment(row))
+	}
+	return BuildResponse200("Attachments retrieved successfully", attachments)
+}
+
+// /api/satcom/:id/attachments - attach the files of the multipart field "file", with an
+// optional "description" field. Either all files are stored or none.
+func (s *RESTService) uploadSatcomAttachments(c *gin.Context) APIResponse {
+	id, _, errResp := s.authorizeSatcomAttachments(c, true)
+	if errResp != nil {
+		return *errResp
+	}
+	form, err := c.MultipartForm()
+	if err != nil || len(form.File["file"]) == 0 {
+		return BuildResponse400("multipart field 'file' is required")
+	}
+	files := form.File["file"]
+	if len(files) > MAX_ATTACHMENT_FILES {
+		return BuildResponse400(fmt.Sprintf("At most %d files can be uploaded at once", MAX_ATTACHMENT_FILES))
+	}
+	for _, fileHeader := range files {
+		if fileHeader.Size > s.attachments.maxSize {
+			return buildResponse(http.StatusRequestEntityTooLarge, false, fmt.Sprintf("%s is larger than %d MB", fileHeader.Filename, s.attachments.maxSize>>20), nil)
+		}
+	}
+	description := strings.TrimSpace(c.PostForm("description"))
+
+	ctx := context.Background()
+	db := s.dbConn.GetPool()
+	if _, err := auth.New(db).GetSatcomDataById(ctx, id); err != nil {
+		return BuildResponse404("Satcom data not found", false)
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		_asLogger.Errorf("Error starting transaction: %v", err)
+		return BuildResponse500("Failed to upload attachments", err.Error())
+	}
+	defer tx.Rollback(ctx)
+	qtx := auth.New(tx)
+
+	// Objects stored before a failure are removed again
+	var stored []string
+	cleanup := func() {
+		if len(stored) > 0 {
+			util.DeleteFilesFromS3(s.attachments.session, stored)
+		}
+	}
+	var uploaderName string
+	if claims := s.currentClaims(c); claims != nil {
+		uploaderName = claims.UserName
+	}
+	attachments := make([]model.SatcomAttachment, 0, len(files))
+	for _, fileHeader := range files {
+		name := filepath.Base(strings.ReplaceAll(fileHeader.Filename, "\\", "/"))
+		data, err := readAttachment(fileHeader)
+		if err != nil {
+			cleanup()
+			return BuildResponse400(fmt.Sprintf("Unable to read %s: %v", name, err))
+		}
+		key, err := s.attachments.objectKey(id, name)
+		if err != nil {
+			cleanup()
+			return BuildResponse500("Failed to upload attachments", err.Error())
+		}
+		contentType := fileHeader.Header.Get("Content-Type")
+		if contentType == "" || contentType == "application/octet-stream" {
+			contentType = http.DetectContentType(data)
+		}
+		if err := util.PutObjectToS3(s.attachments.session, key, data, contentType); err != nil {
+			_asLogger.Errorf("Error uploading %s to S3: %v", key, err)
+			cleanup()
+			return BuildResponse500("Failed to upload attachments", err.Error())
+		}
+		stored = append(stored, key)
+		row, err := qtx.CreateSatcomAttachment(ctx, auth.CreateSatcomAttachmentParams{
+			SatcomID:       id,
+			ObjectKey:      key,
+			FileName:       name,
+			SizeBytes:      int64(len(data)),
+			ContentType:    contentType,
+			Description:    description,
+			UploadedBy:     s.currentUserID(c),
+			UploadedByName: optionalText(uploaderName),
+		})
+		if err != nil {
+			_asLogger.Errorf("Error storing attachment of satcom data %d: %v", id, err)
+			cleanup()
+			return BuildResponse500("Failed to upload attachments", err.Error())
+		}
+		attachments = append(attachments, toSatcomAttachment(row))
+	}
+	if err := tx.Commit(ctx); err != nil {
+		_asLogger.Errorf("Error committing attachments of satcom data %d: %v", id, err)
+		cleanup()
+		return BuildResponse500("Failed to upload attachments", err.Error())
+	}
+	return BuildResponse200("Attachments uploaded successfully", attachments)
+}
+
+// /api/satcom/:id/attachments/:attachmentId/download - a short-lived url downloading the file
+func (s *RESTService) downloadSatcomAttachment(c *gin.Context) APIResponse {
+	id, _, errResp := s.authorizeSatcomAttachments(c, false)
+	if errResp != nil {
+		return *errResp
+	}
+	row, errResp := loadSatcomAttachment(context.Background(), auth.New(s.dbConn.GetPool()), c, id)
+	if errResp != nil {
+		return *errResp
+	}
+	expiresAt := time.Now().Add(s.attachments.urlExpiry)
+	url, err := util.PresignGetFromS3(s.attachments.session, row.ObjectKey, row.FileName, s.attachments.urlExpiry)
+	if err != nil {
+		_asLogger.Errorf("Error presigning attachment %d: %v", row.ID, err)
+		return BuildResponse500("Failed to create download url", err.Error())
+	}
+	c.Header("Cache-Control", "no-store")
+	return BuildResponse200("Download url created successfully", model.SatcomAttachmentDownload{
+		URL:       url,
+		FileName:  row.FileName,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// /api/satcom/:id/attachments/:attachmentId - delete an attachment and its object
+func (s *RESTService) deleteSatcomAttachment(c *gin.Context) APIResponse {
+	id, _, errResp := s.authorizeSatcomAttachments(c, true)
+	if errResp != nil {
+		return *errResp
+	}
+	ctx := context.Background()
+	tx, err := s.dbConn.GetPool().Begin(ctx)
+	if err != nil {
+		_asLogger.Errorf("Error starting transaction: %v", err)
+		return BuildResponse500("Failed to delete attachment", err.Error())
+	}
+	defer tx.Rollback(ctx)
+	qtx := auth.New(tx)
+
+	row, errResp := loadSatcomAttachment(ctx, qtx, c, id)
+	if errResp != nil {
+		return *errResp
+	}
+	if _, err := qtx.DeleteSatcomAttachment(ctx, auth.DeleteSatcomAttachmentParams{ID: row.ID, SatcomID: id}); err != nil {
+		_asLogger.Errorf("Error deleting attachment %d: %v", row.ID, err)
+		return BuildResponse500("Failed to delete attachment", err.Error())
+	}
+	// The row is only removed once the object is
+	if _, err := util.DeletefromS3(s.attachments.session, row.ObjectKey); err != nil {
+		_asLogger.Errorf("Error deleting %s from S3: %v", row.ObjectKey, err)
+		return BuildResponse500("Failed to delete attachment", err.Error())
+	}
+	if err := tx.Commit(ctx); err != nil {
+		_asLogger.Errorf("Error committing deletion of attachment %d: %v", row.ID, err)
+		return BuildResponse500("Failed to delete attachment", err.Error())
+	}
+	return BuildResponse200("Attachment deleted successfully", nil)
+}
+
+// authorizeSatcomAttachments checks that attachments are enabled before authorizing the entry
+func (s *RESTService) authorizeSatcomAttachments(c *gin.Context, write bool) (int32, satcomScope, *APIResponse) {
+	if s.attachments == nil {
+		resp := buildResponse(503, false, "Attachments are not enabled", nil)
+		return 0, satcomScope{}, &resp
+	}
+	return s.authorizeSatcomID(c, write)
+}
+
+// loadSatcomAttachment reads the attachment :attachmentId of the entry id
+func loadSatcomAttachment(ctx context.Context, qtx *auth.Queries, c *gin.Context, id int32) (auth.CommonSatcomAttachment, *APIResponse) {
+	attachmentID, err := strconv.ParseInt(c.Param("attachmentId"), 10, 32)
+	if err != nil {
+		resp := BuildResponse400("Invalid attachment ID format")
+		return auth.CommonSatcomAttachment{}, &resp
+	}
+	row, err := qtx.GetSatcomAttachment(ctx, auth.GetSatcomAttachmentParams{ID: int32(attachmentID), SatcomID: id})
+	if err == pgx.ErrNoRows {
+		resp := BuildResponse404("Attachment not found", false)
+		return row, &resp
+	}
+	if err != nil {
+		_asLogger.Errorf("Error getting attachment %d: %v", attachmentID, err)
+		resp := BuildResponse500("Failed to retrieve attachment", err.Error())
+		return row, &resp
+	}
+	return row, nil
+}
+
+func readAttachment(fileHeader *multipart.FileHeader) ([]byte, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+func toSatcomAttachment(row auth.CommonSatcomAttachment) model.SatcomAttachment {
+	attachment := model.SatcomAttachment{
+		ID:             row.ID,
+		SatcomID:       row.SatcomID,
+		FileName:       row.FileName,
+		SizeBytes:      row.SizeBytes,
+		ContentType:    row.ContentType,
+		Description:    row.Description,
+		UploadedAt:     row.UploadedAt.Time,
+		UploadedByName: row.UploadedByName.String,
+	}
+	if row.UploadedBy.Valid {
+		attachment.UploadedBy = &row.UploadedBy.Int32
+	}
+	return attachment
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+)
+
+// /api/satcom/certificates/expiring - entries whose certificate expires within days (default 30), expired ones included
+func (s *RESTService) getExpiringCertificates(c *gin.Context) APIResponse {
+	days := DEFAULT_CERT_EXPIRY_DAYS
+	if v := c.Query("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return BuildResponse400("Invalid days")
+		}
+		days = n
+	}
+	scope, errResp := s.satcomScope(c)
+	if errResp != nil {
+		return *errResp
+	}
+	company := optionalText(c.Query("company"))
+	if err := scope.restrict(&company); err != nil {
+		return BuildResponse403(err.Error())
+	}
+
+	ctx := context.Background()
+	db := s.dbConn.GetPool()
+	qtx := auth.New(db)
+
+	rows, err := qtx.ListExpiringCertificates(ctx, auth.ListExpiringCertificatesParams{
+		NotAfter: pgtype.Timestamptz{Time: time.Now().Add(time.Duration(days) * 24 * time.Hour), Valid: true},
+		Company:  company,
+	})
+	if err != nil {
+		_asLogger.Errorf("Error getting expiring certificates: %v", err)
+		return BuildResponse500("Failed to retrieve certificates", err.Error())
+	}
+
+	certs := make([]model.CertificateInfo, 0, len(rows))
+	for _, row := range rows {
+		info := certificateInfoFrom(auth.CommonSatcomCertificate{
+			SatcomID:        row.SatcomID,
+			Host:            row.Host,
+			CheckedAt:       row.CheckedAt,
+			Subject:         row.Subject,
+			Issuer:          row.Issuer,
+			Sans:            row.Sans,
+			SerialNumber:    row.SerialNumber,
+			NotBefore:       row.NotBefore,
+			NotAfter:        row.NotAfter,
+			HostnameMatch:   row.HostnameMatch,
+			ChainValid:      row.ChainValid,
+			Error:           row.Error,
+			LastWarningDays: row.LastWarningDays,
+		})
+		info.Company, info.URL = row.Company, row.Url
+		certs = append(certs, info)
+	}
+
+	return BuildResponse200("Expiring certificates retrieved successfully", certs)
+}
+
+// /api/satcom/:id/certificate - last recorded certificate of an entry
+func (s *RESTService) getSatcomCertificate(c *gin.Context) APIResponse {
+	id, _, errResp := s.authorizeSatcomID(c, false)
+	if errResp != nil {
+		return *errResp
+	}
+
+	ctx := context.Background()
+	db := s.dbConn.GetPool()
+	qtx := auth.New(db)
+
+	cert, err := qtx.GetSatcomCertificate(ctx, id)
+	if err != nil {
+		return BuildResponse404("No certificate recorded for this entry", false)
+	}
+
+	return BuildResponse200("Certificate retrieved successfully", certificateInfoFrom(cert))
+}
+
+// /api/satcom/:id/certificate/check - fetch and store the certificate of an entry now
+func (s *RESTService) checkSatcomCertificate(c *gin.Context) APIResponse {
+	id, _, errResp := s.authorizeSatcomID(c, true)
+	if errResp != nil {
+		return *errResp
+	}
+
+	ctx := context.Background()
+	db := s.dbConn.GetPool()
+	qtx := auth.New(db)
+
+	data, err := qtx.GetSatcomDataById(ctx, id)
+	if err != nil {
+		return BuildResponse404("Satcom data not found", false)
+	}
+	if !strings.HasPrefix(strings.ToLower(data.Url), "https://") {
+		return BuildResponse400("Only https urls carry a certificate")
+	}
+	info, err := s.certChecker.CheckAndStore(ctx, data)
+	if err != nil {
+		_asLogger.Errorf("Error storing certificate of satcom data %d: %v", id, err)
+		return BuildResponse500("Failed to store certificate", err.Error())
+	}
+
+	return BuildResponse200("Certificate checked successfully", info)
+}
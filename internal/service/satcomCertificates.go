@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+	"github.com/rest/api/internal/util"
+)
+
+// SatcomCertChecker periodically reads the TLS certificate of every HTTPS satcom url,
+// stores it and emails a warning when a certificate crosses an expiry threshold
+type SatcomCertChecker struct {
+	dbConn       *util.DBConnectionWrapper
+	interval     time.Duration
+	timeout      time.Duration
+	warningDays  []int
+	notifyEmails []string
+	mailer       *SmtpService
+	// roots verifies the chain; nil uses the system pool
+	roots *x509.CertPool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewSatcomCertChecker applies the configuration defaults and builds a checker
+func NewSatcomCertChecker(conf model.CertificateConfig, dbConn *util.DBConnectionWrapper) *SatcomCertChecker {
+	c := &SatcomCertChecker{
+		dbConn:       dbConn,
+		interval:     time.Duration(conf.IntervalMinutes) * time.Minute,
+		timeout:      time.Duration(conf.TimeoutMs) * time.Millisecond,
+		warningDays:  append([]int(nil), conf.WarningDays...),
+		notifyEmails: conf.NotifyEmails,
+		mailer:       &SmtpService{},
+	}
+	if c.interval <= 0 {
+		c.interval = DEFAULT_CERT_CHECK_INTERVAL
+	}
+	if c.timeout <= 0 {
+		c.timeout = DEFAULT_PROBE_TIMEOUT
+	}
+	if len(c.warningDays) == 0 {
+		c.warningDays = []int{30, 14, 7, 1}
+	}
+	sort.Ints(c.warningDays)
+	return c
+}
+
+// Start checks all certificates immediately and then on every interval until Stop is called
+func (c *SatcomCertChecker) Start() {
+	c.stop = make(chan struct{})
+	c.done = make(chan struct{})
+	go func() {
+		defer close(c.done)
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+		for {
+			if err := c.RunOnce(context.Background()); err != nil {
+				_asLogger.Errorf("Certificate check round failed: %v", err)
+			}
+			select {
+			case <-c.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	_asLogger.Infof("Certificate checker started (interval %s, warnings at %v days)", c.interval, c.warningDays)
+}
+
+// Stop ends the background loop and waits for the current round to finish
+func (c *SatcomCertChecker) Stop() {
+	if c.stop == nil {
+		return
+	}
+	close(c.stop)
+	<-c.done
+	c.stop = nil
+}
+
+// RunOnce checks the certificate of every entry with an https url
+func (c *SatcomCertChecker) RunOnce(ctx context.Context) error {
+	entries, err := auth.New(c.dbConn.GetPool()).GetAllSatcomData(ctx)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !strings.HasPrefix(strings.ToLower(entry.Url), "https://") {
+			continue
+		}
+		if _, err := c.CheckAndStore(ctx, entry); err != nil {
+			_asLogger.Errorf("Error storing certificate of satcom data %d: %v", entry.ID, err)
+		}
+	}
+	return nil
+}
+
+// CheckAndStore fetches and stores the certificate of one entry and sends a warning
+// email when the certificate crossed a threshold that was not warned about yet
+func (c *SatcomCertChecker) CheckAndStore(ctx context.Context, entry auth.CommonSatcomDatum) (model.CertificateInfo, error) {
+	qtx := auth.New(c.dbConn.GetPool())
+	info, err := FetchCertificate(ctx, entry.Url, c.timeout, c.roots)
+	info.SatcomID = entry.ID
+	checkedAt := pgtype.Timestamptz{Time: info.CheckedAt, Valid: true}
+	if err != nil {
+		info.Error = err.Error()
+		return info, qtx.RecordSatcomCertificateError(ctx, auth.RecordSatcomCertificateErrorParams{
+			SatcomID:  entry.ID,
+			Host:      info.Host,
+			CheckedAt: checkedAt,
+			Error:     getSQLString(info.Error),
+		})
+	}
+
+	err = qtx.UpsertSatcomCertificate(ctx, auth.UpsertSatcomCertificateParams{
+		SatcomID:      entry.ID,
+		Host:          info.Host,
+		CheckedAt:     checkedAt,
+		Subject:       getSQLString(info.Subject),
+		Issuer:        getSQLString(info.Issuer),
+		Sans:          info.SANs,
+		SerialNumber:  getSQLString(info.SerialNumber),
+		NotBefore:     pgtype.Timestamptz{Time: *info.NotBefore, Valid: true},
+		NotAfter:      pgtype.Timestamptz{Time: *info.NotAfter, Valid: true},
+		HostnameMatch: pgtype.Bool{Bool: *info.HostnameMatch, Valid: true},
+		ChainValid:    pgtype.Bool{Bool: *info.ChainValid, Valid: true},
+	})
+	if err != nil {
+		return info, err
+	}
+
+	stored, err := qtx.GetSatcomCertificate(ctx, entry.ID)
+	if err != nil {
+		return info, err
+	}
+	threshold, due := c.warningThreshold(*info.DaysRemaining, stored.LastWarningDays)
+	if !due {
+		return info, nil
+	}
+	// Alerts are suppressed during maintenance; the warning is sent on the first check after it
+	maintenance, err := loadSatcomMaintenance(ctx, qtx, getSQLString(entry.Company))
+	if err != nil {
+		return info, err
+	}
+	if maintenance.active(entry, time.Now()) != nil {
+		_asLogger.Debugf("Holding back certificate warning for satcom data %d during maintenance", entry.ID)
+		return info, nil
+	}
+	info.Company, info.URL = entry.Company, entry.Url
+	if err := c.sendWarning(ctx, info); err != nil {
+		_asLogger.Errorf("Error sending certificate warning for satcom data %d: %v", entry.ID, err)
+		return info, nil
+	}
+	return info, qtx.SetCertificateWarning(ctx, auth.SetCertificateWarningParams{
+		SatcomID:        entry.ID,
+		LastWarningDays: ConvertInt32ToPgInt4(int32(threshold)),
+	})
+}
+
+// warningThreshold returns the smallest threshold the remaining days fall under and
+// whether it is lower than the last threshold already warned about
+func (c *SatcomCertChecker) warningThreshold(daysRemaining int, lastWarned pgtype.Int4) (int, bool) {
+	for _, threshold := range c.warningDays {
+		if daysRemaining <= threshold {
+			return threshold, !lastWarned.Valid || int32(threshold) < lastWarned.Int32
+		}
+	}
+	return 0, false
+}
+
+func (c *SatcomCertChecker) sendWarning(ctx context.Context, info model.CertificateInfo) error {
+	recipients := c.notifyEmails
+	if len(recipients) == 0 {
+		emails, err := auth.New(c.dbConn.GetPool()).GetActiveUserEmailsByRole(ctx, ROLE_SUPER_ADMIN)
+		if err != nil {
+			return err
+		}
+		recipients = emails
+	}
+	for _, recipient := range recipients {
+		if err := c.mailer.SendCertificateWarningMail(recipient, info); err != nil {
+			return err
+		}
+	}
+	_asLogger.Infof("Sent certificate expiry warning for %s (%d days left) to %d recipient(s)", info.Host, *info.DaysRemaining, len(recipients))
+	return nil
+}
+
+// FetchCertificate connects to an https url and describes the leaf certificate it serves.
+// The chain is verified against roots (the system pool when nil) separately from the
+// hostname, so an invalid certificate is still recorded instead of failing the check.
+func FetchCertificate(ctx context.Context, rawURL string, timeout time.Duration, roots *x509.CertPool) (model.CertificateInfo, error) {
+	info := model.CertificateInfo{CheckedAt: time.Now().UTC()}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return info, fmt.Errorf("invalid url %s", rawURL)
+	}
+	info.Host = u.Hostname()
+	port := u.Port()
+	if port == "" {
+		port = "443"
+	}
+
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: timeout},
+		Config:    &tls.Config{ServerName: info.Host, InsecureSkipVerify: true},
+	}
+	dialCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	conn, err := dialer.DialContext(dialCtx, "tcp", net.JoinHostPort(info.Host, port))
+	if err != nil {
+		return info, err
+	}
+	defer conn.Close()
+	certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return info, fmt.Errorf("no certificate presented")
+	}
+
+	leaf := certs[0]
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, chainErr := leaf.Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates})
+	chainValid := chainErr == nil
+	hostnameMatch := leaf.VerifyHostname(info.Host) == nil
+	daysRemaining := int(time.Until(leaf.NotAfter).Hours() / 24)
+
+	info.Subject = leaf.Subject.String()
+	info.Issuer = leaf.Issuer.String()
+	info.SerialNumber = leaf.SerialNumber.Text(16)
+	info.SANs = append([]string{}, leaf.DNSNames...)
+	for _, ip := range leaf.IPAddresses {
+		info.SANs = append(info.SANs, ip.String())
+	}
+	info.NotBefore = &leaf.NotBefore
+	info.NotAfter = &leaf.NotAfter
+	info.DaysRemaining = &daysRemaining
+	info.HostnameMatch = &hostnameMatch
+	info.ChainValid = &chainValid
+	return info, nil
+}
+
+// certificateInfoFrom converts a stored certificate row
+func certificateInfoFrom(cert auth.CommonSatcomCertificate) model.CertificateInfo {
+	info := model.CertificateInfo{
+		SatcomID:     cert.SatcomID,
+		Host:         cert.Host,
+		CheckedAt:    cert.CheckedAt.Time,
+		Subject:      cert.Subject.String,
+		Issuer:       cert.Issuer.String,
+		SANs:         cert.Sans,
+		SerialNumber: cert.SerialNumber.String,
+		Error:        cert.Error.String,
+	}
+	if cert.NotBefore.Valid {
+		info.NotBefore = &cert.NotBefore.Time
+	}
+	if cert.NotAfter.Valid {
+		daysRemaining := int(time.Until(cert.NotAfter.Time).Hours() / 24)
+		info.NotAfter = &cert.NotAfter.Time
+		info.DaysRemaining = &daysRemaining
+	}
+	if cert.HostnameMatch.Valid {
+		info.HostnameMatch = &cert.HostnameMatch.Bool
+	}
+	if cert.ChainValid.Valid {
+		info.ChainValid = &cert.ChainValid.Bool
+	}
+	return info
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+)
+
+// findSatcomConflicts returns the entries, other than excludeID, that already use one of
+// the submitted ip:port pairs or the same url (compared case-insensitively, ignoring a
+// trailing slash). A port clashes whether the other entry uses it as db_port or ui_port.
+// Endpoints are unique across all companies; entries of other companies are reported
+// without their id, company and url.
+//
+// The endpoints are locked until the transaction ends, so that two saves of the same
+// endpoint cannot both pass the check.
+func findSatcomConflicts(ctx context.Context, qtx *auth.Queries, company string, record satcomRecord, url string, excludeID pgtype.Int4) ([]model.SatcomConflict, error) {
+	for _, endpoint := range satcomEndpointKeys(record, url) {
+		if err := qtx.LockSatcomEndpoint(ctx, endpoint); err != nil {
+			return nil, err
+		}
+	}
+	rows, err := qtx.FindSatcomConflicts(ctx, auth.FindSatcomConflictsParams{
+		Company:   company,
+		ExcludeID: excludeID,
+		Ip:        record.Ip,
+		DbPort:    record.DbPort,
+		UiPort:    record.UiPort,
+		Url:       url,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts := make([]model.SatcomConflict, 0, len(rows))
+	for _, row := range rows {
+		conflict := model.SatcomConflict{ID: row.ID, Company: row.Company, URL: row.Url, Fields: []string{}}
+		if row.Company != company {
+			conflict = model.SatcomConflict{OtherCompany: true, Fields: []string{}}
+		}
+		if record.Ip != nil && row.Ip != nil && *record.Ip == *row.Ip {
+			if portInUse(record.DbPort, row.DbPort, row.UiPort) {
+				conflict.Fields = append(conflict.Fields, "ip+db_port")
+			}
+			if portInUse(record.UiPort, row.DbPort, row.UiPort) {
+				conflict.Fields = append(conflict.Fields, "ip+ui_port")
+			}
+		}
+		if normalizeSatcomURL(row.Url) == normalizeSatcomURL(url) {
+			conflict.Fields = append(conflict.Fields, "url")
+		}
+		conflicts = append(conflicts, conflict)
+	}
+	return conflicts, nil
+}
+
+// satcomEndpointKeys lists the ip:port pairs and the url of an entry as advisory lock
+// keys, sorted so that concurrent saves take their locks in the same order
+func satcomEndpointKeys(record satcomRecord, url string) []string {
+	var keys []string
+	if record.Ip != nil {
+		for _, port := range []pgtype.Int4{record.DbPort, record.UiPort} {
+			if port.Valid {
+				keys = append(keys, netip.AddrPortFrom(*record.Ip, uint16(port.Int32)).String())
+			}
+		}
+	}
+	if url := normalizeSatcomURL(url); url != "" {
+		keys = append(keys, url)
+	}
+	sort.Strings(keys)
+	return slices.Compact(keys)
+}
+
+func portInUse(port pgtype.Int4, used ...pgtype.Int4) bool {
+	for _, u := range used {
+		if port.Valid && u.Valid && port.Int32 == u.Int32 {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeSatcomURL(url string) string {
+	return strings.ToLower(strings.TrimRight(url, "/"))
+}
+
+// BuildConflictResponse answers 409 with the conflicting entries and the ids of those
+// in the caller's company
+func BuildConflictResponse(conflicts []model.SatcomConflict) APIResponse {
+	ids := make([]int32, 0, len(conflicts))
+	for _, conflict := range conflicts {
+		if !conflict.OtherCompany {
+			ids = append(ids, conflict.ID)
+		}
+	}
+	return buildResponse(409, false, "Satcom data conflicts with existing entries; set allowConflicts=true to save anyway", map[string]interface{}{
+		"conflictingIds": ids,
+		"conflicts":      conflicts,
+	})
+}
+
+// /api/satcom/conflicts - endpoints (ip:port or url) currently claimed by more than one
+// entry of the same company
+func (s *RESTService) getSatcomConflicts(c *gin.Context) APIResponse {
+	scope, errResp := s.satcomScope(c)
+	if errResp != nil {
+		return *errResp
+	}
+	company := optionalText(c.Query("company"))
+	if err := scope.restrict(&company); err != nil {
+		return BuildResponse403(err.Error())
+	}
+
+	ctx := context.Background()
+	db := s.dbConn.GetPool()
+	qtx := auth.New(db)
+
+	rows, err := qtx.ListSatcomConflicts(ctx, company)
+	if err != nil {
+		_asLogger.Errorf("Error getting satcom conflicts: %v", err)
+		return BuildResponse500("Failed to retrieve satcom conflicts", err.Error())
+	}
+
+	groups := make([]model.SatcomConflictGroup, 0, len(rows))
+	for _, row := range rows {
+		groups = append(groups, model.SatcomConflictGroup{
+			Company:   row.Company,
+			Kind:      row.Kind,
+			Endpoint:  row.Endpoint,
+			SatcomIDs: row.SatcomIds,
+		})
+	}
+
+	return BuildResponse200("Satcom conflicts retrieved successfully", groups)
+}
+
+// /api/satcom/next-free-port?ip=10.0.0.5&from=8000&to=9000 - lowest port in the range
+// that no entry of the company uses as db_port or ui_port on that ip. Super admins
+// name the company with the company parameter.
+func (s *RESTService) getNextFreePort(c *gin.Context) APIResponse {
+	scope, errResp := s.satcomScope(c)
+	if errResp != nil {
+		return *errResp
+	}
+	company := optionalText(c.Query("company"))
+	if err := scope.restrict(&company); err != nil {
+		return BuildResponse403(err.Error())
+	}
+	if !company.Valid {
+		return BuildResponse400("company parameter is required")
+	}
+	ipStr := strings.TrimSpace(c.Query("ip"))
+	if ipStr == "" {
+		return BuildResponse400("ip parameter is required")
+	}
+	prefix, err := parseIPFilter(ipStr)
+	if err != nil || !prefix.IsSingleIP() {
+		return BuildResponse400("ip must be a single IPv4 or IPv6 address")
+	}
+	ip := prefix.Addr()
+	from, err := parsePortQuery(c, "from", DEFAULT_FREE_PORT_FROM)
+	if err != nil {
+		return BuildResponse400(err.Error())
+	}
+	to, err := parsePortQuery(c, "to", 65535)
+	if err != nil {
+		return BuildResponse400(err.Error())
+	}
+	if from > to {
+		return BuildResponse400("from must not be greater than to")
+	}
+
+	ctx := context.Background()
+	db := s.dbConn.GetPool()
+	qtx := auth.New(db)
+
+	used, err := qtx.ListUsedPortsByIp(ctx, auth.ListUsedPortsByIpParams{Ip: &ip, Company: company.String})
+	if err != nil {
+		_asLogger.Errorf("Error getting used ports of %s: %v", ip, err)
+		return BuildResponse500("Failed to retrieve used ports", err.Error())
+	}
+	port := nextFreePort(used, from, to)
+	if port == 0 {
+		return BuildResponse404(fmt.Sprintf("No free port between %d and %d on %s", from, to, ip), false)
+	}
+
+	return BuildResponse200("Free port found", map[string]interface{}{
+		"company":   company.String,
+		"ip":        ip.String(),
+		"port":      port,
+		"usedPorts": used,
+	})
+}
+
+func parsePortQuery(c *gin.Context, name string, defaultPort int32) (int32, error) {
+	v := c.Query(name)
+	if v == "" {
+		return defaultPort, nil
+	}
+	port, err := strconv.Atoi(v)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("%s must be a port between 1 and 65535", name)
+	}
+	return int32(port), nil
+}
+
+// nextFreePort returns the lowest port in [from, to] missing from the sorted used list, or 0
+func nextFreePort(used []int32, from, to int32) int32 {
+	port := from
+	for _, u := range used {
+		if u < port {
+			continue
+		}
+		if u > port {
+			break
+		}
+		port++
+	}
+	if port > to {
+		return 0
+	}
+	return port
+}
@@ -0,0 +1,381 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+)
+
+var discoveryNamePattern = regexp.MustCompile(`[^a-z0-9_]+`)
+
+var prometheusLabelPattern = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// defaultNginxTemplate renders one upstream per company and category, with entries that
+// the prober reports down marked as such, and a server block proxying to it
+const defaultNginxTemplate = `# Generated from the satcom inventory; do not edit
+{{- range .Groups}}{{if .Servers}}
+
+upstream {{.Name}} {
+{{- range .Servers}}
+    server {{.Address}}{{if not .Status}} down{{end}}; # {{if .Name}}{{.Name}}{{else}}satcom {{.ID}}{{end}}
+{{- end}}
+}
+
+server {
+    listen {{or (index $.Params "listen") "80"}};
+    server_name {{replace .Name "_" "-"}}{{with index $.Params "domain"}}.{{.}}{{end}};
+
+    location / {
+        proxy_pass http://{{.Name}};
+    }
+}
+{{- end}}{{end}}
+`
+
+var discoveryTemplateFuncs = template.FuncMap{
+	"slug":    discoverySlug,
+	"lower":   strings.ToLower,
+	"upper":   strings.ToUpper,
+	"join":    strings.Join,
+	"replace": strings.ReplaceAll,
+}
+
+// parseNginxTemplate parses the configured nginx template, or the built-in one
+func parseNginxTemplate(conf *model.DiscoveryConfig) (*template.Template, error) {
+	text := defaultNginxTemplate
+	if conf != nil && conf.NginxTemplate != "" {
+		data, err := os.ReadFile(conf.NginxTemplate)
+		if err != nil {
+			return nil, err
+		}
+		text = string(data)
+	}
+	return template.New("nginx").Funcs(discoveryTemplateFuncs).Parse(text)
+}
+
+// /api/satcom/sd/prometheus - the inventory as a Prometheus http_sd_config target list
+//
+// Accepts the same filters as /api/satcom. target=ui_port (default), db_port or url picks
+// the address scraped; entries without it are left out. Every entry is its own target
+// group labelled with __meta_satcom_* labels for relabelling.
+func (s *RESTService) getPrometheusTargets(c *gin.Context) {
+	target := c.DefaultQuery("target", "ui_port")
+	if target != "ui_port" && target != "db_port" && target != "url" {
+		resp := BuildResponse400("target must be ui_port, db_port or url")
+		c.JSON(resp.StatusCode, resp)
+		return
+	}
+	entries, errResp := s.listDiscoveryEntries(c)
+	if errResp != nil {
+		c.JSON(errResp.StatusCode, errResp)
+		return
+	}
+
+	groups := make([]model.PrometheusTargetGroup, 0, len(entries))
+	for _, entry := range entries {
+		var address string
+		switch target {
+		case "ui_port":
+			address = entry.Address
+		case "db_port":
+			if entry.IP != "" && entry.DbPort != 0 {
+				address = net.JoinHostPort(entry.IP, strconv.Itoa(int(entry.DbPort)))
+			}
+		case "url":
+			address = urlAddress(entry.URL)
+		}
+		if address == "" {
+			continue
+		}
+		labels := map[string]string{
+			"__meta_satcom_id":       strconv.Itoa(int(entry.ID)),
+			"__meta_satcom_company":  entry.Company,
+			"__meta_satcom_category": entry.Category,
+			"__meta_satcom_type":     entry.Type,
+			"__meta_satcom_url":      entry.URL,
+			"__meta_satcom_status":   entry.EffectiveStatus,
+		}
+		if entry.Name != "" {
+			labels["__meta_satcom_name"] = entry.Name
+		}
+		for key, value := range entry.Labels {
+			labels["__meta_satcom_label_"+prometheusLabelPattern.ReplaceAllString(key, "_")] = value
+		}
+		groups = append(groups, model.PrometheusTargetGroup{Targets: []string{address}, Labels: labels})
+	}
+	writeDiscoveryJSON(c, groups)
+}
+
+// /api/satcom/sd/ansible - the inventory as an Ansible dynamic inventory
+//
+// Accepts the same filters as /api/satcom. Hosts are grouped by company and, below it,
+// by company and category (e.g. acme and acme_database). The inventory hostname is the
+// entry name, or satcom-<id> for unnamed entries; ansible_host is the ip, or the url host.
+func (s *RESTService) getAnsibleInventory(c *gin.Context) {
+	entries, errResp := s.listDiscoveryEntries(c)
+	if errResp != nil {
+		c.JSON(errResp.StatusCode, errResp)
+		return
+	}
+
+	inventory := make(map[string]interface{})
+	hostvars := make(map[string]interface{})
+	all := model.AnsibleGroup{Children: []string{}}
+	companies := make(map[string]*model.AnsibleGroup)
+	for _, group := range groupDiscoveryEntries(entries) {
+		companyName := discoverySlug(group.Company)
+		companyGroup, isFound := companies[companyName]
+		if !isFound {
+			companyGroup = &model.AnsibleGroup{}
+			companies[companyName] = companyGroup
+			all.Children = append(all.Children, companyName)
+		}
+		companyGroup.Children = append(companyGroup.Children, group.Name)
+
+		hosts := make([]string, 0, len(group.Entries))
+		for _, entry := range group.Entries {
+			host := entry.Name
+			if host == "" {
+				host = fmt.Sprintf("satcom-%d", entry.ID)
+			}
+			// Names are only unique within a company
+			if _, isFound := hostvars[host]; isFound {
+				host = fmt.Sprintf("%s-%d", host, entry.ID)
+			}
+			vars := map[string]interface{}{
+				"ansible_host":         entry.IP,
+				"satcom_id":            entry.ID,
+				"satcom_company":       entry.Company,
+				"satcom_category":      entry.Category,
+				"satcom_type":          entry.Type,
+				"satcom_url":           entry.URL,
+				"satcom_status":        entry.Status,
+				"satcom_labels":        entry.Labels,
+				"satcom_custom_fields": entry.CustomFields,
+			}
+			if entry.IP == "" {
+				vars["ansible_host"] = entry.Host
+			}
+			if entry.DbPort != 0 {
+				vars["satcom_db_port"] = entry.DbPort
+			}
+			if entry.UIPort != 0 {
+				vars["satcom_ui_port"] = entry.UIPort
+			}
+			hostvars[host] = vars
+			hosts = append(hosts, host)
+		}
+		inventory[group.Name] = model.AnsibleGroup{Hosts: hosts}
+	}
+	for name, group := range companies {
+		// A category group named like a company keeps its hosts
+		if existing, isFound := inventory[name].(model.AnsibleGroup); isFound {
+			group.Hosts = existing.Hosts
+		}
+		inventory[name] = *group
+	}
+	inventory["all"] = all
+	inventory["_meta"] = map[string]interface{}{"hostvars": hostvars}
+	writeDiscoveryJSON(c, inventory)
+}
+
+// /api/satcom/sd/nginx - nginx configuration rendered from the configured template
+// (GET) or from a template sent as the body or multipart file (POST)
+//
+// Accepts the same filters as /api/satcom; the other query parameters are passed to the
+// template as .Params. Template errors answer 400.
+func (s *RESTService) renderNginxConfig(c *gin.Context) {
+	tmpl := s.nginxTemplate
+	if c.Request.Method == "POST" {
+		data, _, err := readUpload(c)
+		if err == nil && len(bytes.TrimSpace(data)) == 0 {
+			err = fmt.Errorf("template is required")
+		}
+		if err == nil {
+			tmpl, err = template.New("nginx").Funcs(discoveryTemplateFuncs).Parse(string(data))
+		}
+		if err != nil {
+			resp := BuildResponse400(fmt.Sprintf("Invalid template: %v", err))
+			c.JSON(resp.StatusCode, resp)
+			return
+		}
+	}
+	entries, errResp := s.listDiscoveryEntries(c)
+	if errResp != nil {
+		c.JSON(errResp.StatusCode, errResp)
+		return
+	}
+
+	params := make(map[string]string)
+	for key, values := range c.Request.URL.Query() {
+		if !satcomFilterParams[key] && len(values) > 0 {
+			params[key] = values[0]
+		}
+	}
+	var out bytes.Buffer
+	err := tmpl.Execute(&out, model.DiscoveryTemplateData{
+		Entries: entries,
+		Groups:  groupDiscoveryEntries(entries),
+		Params:  params,
+	})
+	if err != nil {
+		resp := BuildResponse400(fmt.Sprintf("Unable to render template: %v", err))
+		c.JSON(resp.StatusCode, resp)
+		return
+	}
+	writeDiscovery(c, "text/plain; charset=utf-8", out.Bytes())
+}
+
+// satcomFilterParams are the query parameters read by parseSatcomFilter
+var satcomFilterParams = map[string]bool{
+	"q":             true,
+	"company":       true,
+	"category":      true,
+	"type":          true,
+	"status":        true,
+	"ip":            true,
+	"url":           true,
+	"labelSelector": true,
+}
+
+// listDiscoveryEntries loads every entry in the caller's scope that matches the list filters
+func (s *RESTService) listDiscoveryEntries(c *gin.Context) ([]model.DiscoveryEntry, *APIResponse) {
+	dataList, errResp := s.listScopedSatcomData(c)
+	if errResp != nil {
+		return nil, errResp
+	}
+	// Windows are matched against the company of each entry, so loading all of them is safe
+	maintenance, err := loadSatcomMaintenance(context.Background(), auth.New(s.dbConn.GetPool()), pgtype.Text{})
+	if err != nil {
+		_asLogger.Errorf("Error getting maintenance windows: %v", err)
+		resp := BuildResponse500("Failed to retrieve satcom data", err.Error())
+		return nil, &resp
+	}
+	now := time.Now()
+	entries := make([]model.DiscoveryEntry, 0, len(dataList))
+	for _, data := range dataList {
+		entry := toDiscoveryEntry(data)
+		entry.EffectiveStatus = effectiveSatcomStatus(data.Status, maintenance.active(data, now))
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func toDiscoveryEntry(data auth.CommonSatcomDatum) model.DiscoveryEntry {
+	entry := model.DiscoveryEntry{
+		ID:           data.ID,
+		Name:         data.Name.String,
+		Company:      data.Company,
+		Category:     data.Category,
+		Type:         data.Type,
+		URL:          data.Url,
+		DbPort:       data.DbPort.Int32,
+		UIPort:       data.UiPort.Int32,
+		Status:       data.Status,
+		Labels:       decodeSatcomLabels(data.Labels),
+		CustomFields: decodeSatcomCustomFields(data.CustomFields),
+	}
+	if u, err := url.Parse(data.Url); err == nil {
+		entry.Host = u.Hostname()
+	}
+	if data.Ip != nil {
+		entry.IP = data.Ip.String()
+	}
+	if entry.IP != "" && entry.UIPort != 0 {
+		entry.Address = net.JoinHostPort(entry.IP, strconv.Itoa(int(entry.UIPort)))
+	}
+	return entry
+}
+
+// groupDiscoveryEntries groups the entries by company and category, sorted by group name
+func groupDiscoveryEntries(entries []model.DiscoveryEntry) []model.DiscoveryGroup {
+	byName := make(map[string]*model.DiscoveryGroup)
+	names := make([]string, 0)
+	for _, entry := range entries {
+		name := discoverySlug(entry.Company + "_" + entry.Category)
+		group, isFound := byName[name]
+		if !isFound {
+			group = &model.DiscoveryGroup{Name: name, Company: entry.Company, Category: entry.Category}
+			byName[name] = group
+			names = append(names, name)
+		}
+		group.Entries = append(group.Entries, entry)
+		if entry.Address != "" {
+			group.Servers = append(group.Servers, entry)
+		}
+	}
+	sort.Strings(names)
+	groups := make([]model.DiscoveryGroup, 0, len(names))
+	for _, name := range names {
+		groups = append(groups, *byName[name])
+	}
+	return groups
+}
+
+// discoverySlug turns a name into an identifier usable as an Ansible group or nginx
+// upstream name: lowercase letters, digits and underscores
+func discoverySlug(name string) string {
+	slug := strings.Trim(discoveryNamePattern.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if slug == "" || (slug[0] >= '0' && slug[0] <= '9') {
+		slug = "g_" + slug
+	}
+	return slug
+}
+
+// urlAddress returns host:port of a url, with the default port of its scheme
+func urlAddress(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if strings.EqualFold(u.Scheme, "https") {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+func writeDiscoveryJSON(c *gin.Context, value interface{}) {
+	// Map keys are sorted, so the same inventory always gives the same bytes and ETag
+	data, err := json.Marshal(value)
+	if err != nil {
+		resp := BuildResponse500("Failed to encode discovery data", err.Error())
+		c.JSON(resp.StatusCode, resp)
+		return
+	}
+	writeDiscovery(c, "application/json; charset=utf-8", data)
+}
+
+// writeDiscovery sends a discovery document with an ETag derived from its content. Pollers
+// that send the ETag back in If-None-Match get an empty 304 while nothing changed.
+func writeDiscovery(c *gin.Context, contentType string, data []byte) {
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "no-cache")
+	if ifNoneMatchHits(c, etag) {
+		c.Status(304)
+		return
+	}
+	c.Data(200, contentType, data)
+}
@@ -0,0 +1,325 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+	"golang.org/x/net/websocket"
+)
+
+// satcomEventFilter selects the events a stream receives: the caller's companies, an
+// optional company and label selector, rendered in the requested API version
+type satcomEventFilter struct {
+	claims    *model.AuthorizationClaims
+	scope     satcomScope
+	company   pgtype.Text
+	companyID pgtype.Int4
+	selector  labelSelector
+	version   int
+}
+
+// /api/satcom/events - Server-Sent Events stream of satcom changes
+//
+// Every event carries its resume token as the SSE id; EventSource sends it back as
+// Last-Event-ID when it reconnects, and the events committed in between are replayed.
+func (s *RESTService) streamSatcomEvents(c *gin.Context) {
+	filter, after, errResp := s.parseSatcomEventRequest(c)
+	if errResp != nil {
+		c.JSON(errResp.StatusCode, errResp)
+		return
+	}
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// Keeps nginx from buffering the stream
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	send := func(event model.SatcomEvent) error {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(c.Writer, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, strings.ToLower(event.Operation), data); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	}
+	heartbeat := func() error {
+		if _, err := fmt.Fprint(c.Writer, ": ping\n\n"); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	}
+	s.runSatcomFeed(c.Request.Context(), filter, after, send, heartbeat)
+}
+
+// /api/satcom/events/ws - the same stream over a WebSocket, one JSON event per message.
+// Clients resume by reconnecting with resumeToken set to the id of their last event.
+func (s *RESTService) streamSatcomEventsWS(c *gin.Context) {
+	if !s.satcomEventOriginAllowed(c.Request) {
+		resp := BuildResponse403("Origin is not allowed to open the change feed")
+		c.JSON(resp.StatusCode, resp)
+		return
+	}
+	filter, after, errResp := s.parseSatcomEventRequest(c)
+	if errResp != nil {
+		c.JSON(errResp.StatusCode, errResp)
+		return
+	}
+	server := websocket.Server{
+		// Clients authenticating with a subprotocol token get the feed protocol selected;
+		// the token itself is never echoed back
+		Handshake: func(config *websocket.Config, _ *http.Request) error {
+			if len(config.Protocol) == 0 {
+				return nil
+			}
+			for _, protocol := range config.Protocol {
+				if protocol == SATCOM_EVENTS_WS_PROTOCOL {
+					config.Protocol = []string{SATCOM_EVENTS_WS_PROTOCOL}
+					return nil
+				}
+			}
+			return fmt.Errorf("subprotocol %s is required", SATCOM_EVENTS_WS_PROTOCOL)
+		},
+		Handler: func(ws *websocket.Conn) {
+			ctx, cancel := context.WithCancel(c.Request.Context())
+			defer cancel()
+			// Messages from the client are not used; reading answers pings and notices the close
+			go func() {
+				var msg string
+				for websocket.Message.Receive(ws, &msg) == nil {
+				}
+				cancel()
+			}()
+			send := func(event model.SatcomEvent) error {
+				return websocket.JSON.Send(ws, event)
+			}
+			heartbeat := func() error {
+				ws.PayloadType = websocket.PingFrame
+				defer func() { ws.PayloadType = websocket.TextFrame }()
+				_, err := ws.Write(nil)
+				return err
+			}
+			s.runSatcomFeed(ctx, filter, after, send, heartbeat)
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// newSatcomEventOrigins normalizes the configured WebSocket origins
+func newSatcomEventOrigins(conf *model.EventsConfig) map[string]bool {
+	origins := make(map[string]bool)
+	if conf != nil {
+		for _, origin := range conf.AllowedOrigins {
+			origins[strings.ToLower(strings.TrimRight(strings.TrimSpace(origin), "/"))] = true
+		}
+	}
+	return origins
+}
+
+// satcomEventOriginAllowed checks the Origin of a WebSocket handshake, which browsers
+// always send: pages of the API's own host and of the configured origins may connect.
+// Clients outside a browser send no Origin and are only authorized by their token.
+func (s *RESTService) satcomEventOriginAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host) || s.eventOrigins[strings.ToLower(strings.TrimRight(origin, "/"))]
+}
+
+// websocketProtocolToken returns the JWT offered as a "bearer.<token>" subprotocol
+func websocketProtocolToken(header string) string {
+	for _, protocol := range strings.Split(header, ",") {
+		if token, isFound := strings.CutPrefix(strings.TrimSpace(protocol), SATCOM_EVENTS_WS_TOKEN_PREFIX); isFound {
+			return token
+		}
+	}
+	return ""
+}
+
+// satcomFeedAuthorized re-checks the caller of an open stream before every event and
+// heartbeat: the token must not have expired, its users must still be active and the
+// caller must still belong to the company the stream was opened for
+func (s *RESTService) satcomFeedAuthorized(ctx context.Context, filter satcomEventFilter) bool {
+	if s.jwtSigningKey == nil {
+		return true
+	}
+	claims := filter.claims
+	if claims == nil || !claims.VerifyExpiresAt(time.Now().Unix(), false) {
+		return false
+	}
+	qtx := auth.New(s.dbConn.GetPool())
+	if !claimsStillValid(ctx, qtx, claims) {
+		return false
+	}
+	if filter.scope.all {
+		return s.isSuperAdmin(ctx, qtx, claims)
+	}
+	_, err := qtx.GetCompanyMembership(ctx, auth.GetCompanyMembershipParams{
+		UserID:    claims.UserID,
+		CompanyID: filter.scope.companyID,
+	})
+	return err == nil
+}
+
+// runSatcomFeed streams events until the client goes away, the caller loses access or the
+// feed drops the stream.
+// It subscribes before replaying the events after the resume token, so nothing committed
+// during the replay is missed; live rows at or below the last replayed token were already
+// covered and are not sent twice. Without a token the stream starts with a READY event
+// carrying the current token.
+func (s *RESTService) runSatcomFeed(ctx context.Context, filter satcomEventFilter, after int64, send func(model.SatcomEvent) error, heartbeat func() error) {
+	sub := s.feed.Subscribe()
+	defer s.feed.Unsubscribe(sub)
+
+	qtx := auth.New(s.dbConn.GetPool())
+	if after == 0 {
+		latest, err := qtx.GetLatestSatcomCommitSeq(ctx)
+		if err != nil {
+			_asLogger.Errorf("Error getting the latest satcom history: %v", err)
+			return
+		}
+		if send(model.SatcomEvent{ID: strconv.FormatInt(latest, 10), Operation: SATCOM_EVENT_READY}) != nil {
+			return
+		}
+		after = latest
+	}
+	for {
+		if !s.satcomFeedAuthorized(ctx, filter) {
+			return
+		}
+		rows, err := qtx.ListSatcomHistoryAfter(ctx, auth.ListSatcomHistoryAfterParams{AfterSeq: after, RowLimit: SATCOM_FEED_REPLAY_CHUNK})
+		if err != nil {
+			_asLogger.Errorf("Error replaying satcom history after %d: %v", after, err)
+			return
+		}
+		for _, row := range rows {
+			after = row.CommitSeq.Int64
+			if event, isFound := filter.event(row); isFound {
+				if send(event) != nil {
+					return
+				}
+			}
+		}
+		if len(rows) < SATCOM_FEED_REPLAY_CHUNK {
+			break
+		}
+	}
+
+	ticker := time.NewTicker(SATCOM_FEED_HEARTBEAT)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case row, isOpen := <-sub.events:
+			if !isOpen {
+				return
+			}
+			if row.CommitSeq.Int64 <= after {
+				continue
+			}
+			if event, isFound := filter.event(row); isFound {
+				if !s.satcomFeedAuthorized(ctx, filter) || send(event) != nil {
+					return
+				}
+			}
+		case <-ticker.C:
+			if !s.satcomFeedAuthorized(ctx, filter) || heartbeat() != nil {
+				return
+			}
+		}
+	}
+}
+
+// parseSatcomEventRequest reads the filters and the resume token, taken from the
+// Last-Event-ID header or the resumeToken parameter
+func (s *RESTService) parseSatcomEventRequest(c *gin.Context) (satcomEventFilter, int64, *APIResponse) {
+	var filter satcomEventFilter
+	var err error
+	if filter.version, err = requestAPIVersion(c); err != nil {
+		resp := BuildResponse400(err.Error())
+		return filter, 0, &resp
+	}
+	scope, errResp := s.satcomScope(c)
+	if errResp != nil {
+		return filter, 0, errResp
+	}
+	filter.scope = scope
+	filter.claims = s.currentClaims(c)
+	filter.company = optionalText(c.Query("company"))
+	if err := scope.restrict(&filter.company); err != nil {
+		resp := BuildResponse403(err.Error())
+		return filter, 0, &resp
+	}
+	if filter.companyID, err = scope.filterID(context.Background(), auth.New(s.dbConn.GetPool()), filter.company); err != nil {
+		resp := BuildResponse400(err.Error())
+		return filter, 0, &resp
+	}
+	if str := strings.TrimSpace(c.Query("labelSelector")); str != "" {
+		if filter.selector, err = parseLabelSelector(str); err != nil {
+			resp := BuildResponse400(fmt.Sprintf("Invalid labelSelector: %v", err))
+			return filter, 0, &resp
+		}
+	}
+
+	token := c.GetHeader("Last-Event-ID")
+	if token == "" {
+		token = c.Query("resumeToken")
+	}
+	var after int64
+	if token != "" {
+		if after, err = strconv.ParseInt(token, 10, 64); err != nil || after < 0 {
+			resp := BuildResponse400("Invalid resume token")
+			return filter, 0, &resp
+		}
+	}
+	return filter, after, nil
+}
+
+// event renders a history row, or reports false when the stream does not select it
+func (filter satcomEventFilter) event(row auth.CommonSatcomHistory) (model.SatcomEvent, bool) {
+	data, err := satcomDatumFromSnapshot(row.Snapshot)
+	if err != nil {
+		_asLogger.Errorf("Error decoding satcom history %d: %v", row.ID, err)
+		return model.SatcomEvent{}, false
+	}
+	if !filter.scope.containsHistory(row) || (filter.company.Valid && !historyInCompany(row, filter.companyID.Int32, filter.company.String)) {
+		return model.SatcomEvent{}, false
+	}
+	if filter.selector != nil && !filter.selector.matches(decodeSatcomLabels(data.Labels)) {
+		return model.SatcomEvent{}, false
+	}
+	changedAt := row.ChangedAt.Time
+	event := model.SatcomEvent{
+		ID:        strconv.FormatInt(row.CommitSeq.Int64, 10),
+		Operation: row.Operation,
+		SatcomID:  row.SatcomID,
+		Version:   row.Version,
+		Company:   data.Company,
+		ChangedAt: &changedAt,
+		Data:      toSatcomResponse(data, filter.version),
+	}
+	if row.ChangedBy.Valid {
+		event.ChangedBy = &row.ChangedBy.Int32
+	}
+	return event, true
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/util"
+)
+
+// SatcomFeed fans satcom changes out to the streaming subscribers of this instance. A
+// trigger announces every satcom_history row on the satcom_changes channel when its
+// transaction commits; the feed LISTENs on a dedicated connection, so changes made
+// through any instance, and by the prober and purger, reach every subscriber.
+type SatcomFeed struct {
+	dbConn *util.DBConnectionWrapper
+
+	mu          sync.Mutex
+	subscribers map[*satcomSubscriber]struct{}
+	// lastSeq is the commit sequence of the newest row published, the catch-up point
+	// after a reconnect
+	lastSeq int64
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// satcomSubscriber receives the history rows of one stream. The feed closes events when
+// the subscriber falls too far behind; the client then resumes from its last event.
+type satcomSubscriber struct {
+	events chan auth.CommonSatcomHistory
+}
+
+func NewSatcomFeed(dbConn *util.DBConnectionWrapper) *SatcomFeed {
+	return &SatcomFeed{
+		dbConn:      dbConn,
+		subscribers: make(map[*satcomSubscriber]struct{}),
+	}
+}
+
+// Start listens for changes until Stop is called, reconnecting when the connection drops
+func (f *SatcomFeed) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	f.cancel = cancel
+	f.done = make(chan struct{})
+	go func() {
+		defer close(f.done)
+		for {
+			err := f.listen(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			_asLogger.Errorf("Satcom change feed lost its listener, reconnecting: %v", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(SATCOM_FEED_RETRY):
+			}
+		}
+	}()
+	_asLogger.Infof("Satcom change feed started (channel %s)", SATCOM_FEED_CHANNEL)
+}
+
+// Stop ends the listener and closes every subscription, which ends their streams
+func (f *SatcomFeed) Stop() {
+	if f.cancel == nil {
+		return
+	}
+	f.cancel()
+	<-f.done
+	f.cancel = nil
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for sub := range f.subscribers {
+		close(sub.events)
+		delete(f.subscribers, sub)
+	}
+}
+
+func (f *SatcomFeed) listen(ctx context.Context) error {
+	pooled, err := f.dbConn.GetPool().Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// The listening connection leaves the pool for good; LISTEN state must not leak into it
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+	if _, err := conn.Exec(ctx, "LISTEN "+SATCOM_FEED_CHANNEL); err != nil {
+		return err
+	}
+	if err := f.catchUp(ctx); err != nil {
+		return err
+	}
+
+	qtx := auth.New(f.dbConn.GetPool())
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		id, err := strconv.ParseInt(notification.Payload, 10, 64)
+		if err != nil {
+			continue
+		}
+		row, err := qtx.GetSatcomHistoryById(ctx, id)
+		if err != nil {
+			_asLogger.Errorf("Error getting satcom history %d for the change feed: %v", id, err)
+			continue
+		}
+		f.publish(row)
+	}
+}
+
+// catchUp publishes the changes committed while the listener was down. On the first
+// start there is nothing to catch up; subscribers replay from their own resume tokens.
+// History rows get their commit sequence in commit order, so unlike the row ids no
+// transaction can commit below a sequence that was already seen.
+func (f *SatcomFeed) catchUp(ctx context.Context) error {
+	qtx := auth.New(f.dbConn.GetPool())
+	f.mu.Lock()
+	after := f.lastSeq
+	f.mu.Unlock()
+	if after == 0 {
+		latest, err := qtx.GetLatestSatcomCommitSeq(ctx)
+		if err != nil {
+			return err
+		}
+		f.mu.Lock()
+		f.lastSeq = latest
+		f.mu.Unlock()
+		return nil
+	}
+	for {
+		rows, err := qtx.ListSatcomHistoryAfter(ctx, auth.ListSatcomHistoryAfterParams{AfterSeq: after, RowLimit: SATCOM_FEED_REPLAY_CHUNK})
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			f.publish(row)
+		}
+		if len(rows) < SATCOM_FEED_REPLAY_CHUNK {
+			return nil
+		}
+		after = rows[len(rows)-1].CommitSeq.Int64
+	}
+}
+
+// publish hands a row to every subscriber, dropping those whose buffer is full
+func (f *SatcomFeed) publish(row auth.CommonSatcomHistory) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if row.CommitSeq.Int64 > f.lastSeq {
+		f.lastSeq = row.CommitSeq.Int64
+	}
+	for sub := range f.subscribers {
+		select {
+		case sub.events <- row:
+		default:
+			_asLogger.Warnf("Dropping a satcom change feed subscriber that fell %d events behind", SATCOM_FEED_BUFFER)
+			close(sub.events)
+			delete(f.subscribers, sub)
+		}
+	}
+}
+
+// Subscribe registers a stream for the changes published from now on
+func (f *SatcomFeed) Subscribe() *satcomSubscriber {
+	sub := &satcomSubscriber{events: make(chan auth.CommonSatcomHistory, SATCOM_FEED_BUFFER)}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// Unsubscribe removes a stream; it is safe to call after the feed dropped it
+func (f *SatcomFeed) Unsubscribe(sub *satcomSubscriber) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, isFound := f.subscribers[sub]; isFound {
+		close(sub.events)
+		delete(f.subscribers, sub)
+	}
+}
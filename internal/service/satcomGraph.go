@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+)
+
+// Relation types accepted by POST /api/satcom/:id/relations
+var satcomRelationTypes = map[string]bool{
+	SATCOM_RELATION_DEPENDS_ON: true,
+	SATCOM_RELATION_CALLS:      true,
+	SATCOM_RELATION_READS_FROM: true,
+	SATCOM_RELATION_WRITES_TO:  true,
+	SATCOM_RELATION_HOSTED_ON:  true,
+}
+
+// Relation types that must not form a cycle; calls and data flows may well go both ways
+var satcomAcyclicRelations = map[string]bool{
+	SATCOM_RELATION_DEPENDS_ON: true,
+	SATCOM_RELATION_HOSTED_ON:  true,
+}
+
+// satcomGraph is the dependency graph of a set of entries. Relations are kept only when
+// both ends are in the set, so soft-deleted entries and other companies drop out.
+type satcomGraph struct {
+	order      []int32
+	nodes      map[int32]auth.CommonSatcomDatum
+	edges      []auth.CommonSatcomRelation
+	upstream   map[int32][]auth.CommonSatcomRelation
+	downstream map[int32][]auth.CommonSatcomRelation
+}
+
+func newSatcomGraph(entries []auth.CommonSatcomDatum, relations []auth.CommonSatcomRelation) *satcomGraph {
+	g := &satcomGraph{
+		nodes:      make(map[int32]auth.CommonSatcomDatum, len(entries)),
+		edges:      make([]auth.CommonSatcomRelation, 0),
+		upstream:   make(map[int32][]auth.CommonSatcomRelation),
+		downstream: make(map[int32][]auth.CommonSatcomRelation),
+	}
+	for _, data := range entries {
+		g.order = append(g.order, data.ID)
+		g.nodes[data.ID] = data
+	}
+	for _, rel := range relations {
+		if _, ok := g.nodes[rel.SourceID]; !ok {
+			continue
+		}
+		if _, ok := g.nodes[rel.TargetID]; !ok {
+			continue
+		}
+		g.edges = append(g.edges, rel)
+		g.upstream[rel.SourceID] = append(g.upstream[rel.SourceID], rel)
+		g.downstream[rel.TargetID] = append(g.downstream[rel.TargetID], rel)
+	}
+	return g
+}
+
+// loadSatcomGraph builds the graph of all live entries in the caller's scope
+func loadSatcomGraph(ctx context.Context, qtx *auth.Queries, scope satcomScope) (*satcomGraph, error) {
+	dataList, err := qtx.GetAllSatcomData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	relations, err := qtx.ListSatcomRelations(ctx, scope.filter())
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]auth.CommonSatcomDatum, 0, len(dataList))
+	for _, data := range dataList {
+		if scope.contains(data.Company) {
+			entries = append(entries, data)
+		}
+	}
+	return newSatcomGraph(entries, relations), nil
+}
+
+// closure walks the relations of an entry breadth first: upstream follows what it relies
+// on, downstream what relies on it. Each entry is listed once, at its shortest depth;
+// maxDepth 0 walks the whole graph.
+func (g *satcomGraph) closure(id int32, upstream bool, maxDepth int) []model.SatcomDependency {
+	entries := make([]model.SatcomDependency, 0)
+	seen := map[int32]bool{id: true}
+	level := []int32{id}
+	for depth := 1; len(level) > 0 && (maxDepth == 0 || depth <= maxDepth); depth++ {
+		var next []int32
+		for _, from := range level {
+			relations := g.downstream[from]
+			if upstream {
+				relations = g.upstream[from]
+			}
+			for _, rel := range relations {
+				to := rel.SourceID
+				if upstream {
+					to = rel.TargetID
+				}
+				if seen[to] {
+					continue
+				}
+				seen[to] = true
+				next = append(next, to)
+				entries = append(entries, model.SatcomDependency{
+					SatcomGraphNode: toSatcomGraphNode(g.nodes[to]),
+					Depth:           depth,
+					Via:             from,
+					Relation:        rel.Relation,
+				})
+			}
+		}
+		level = next
+	}
+	return entries
+}
+
+// impact lists every down entry of the graph with the downstream entries it affects.
+// Entries in maintenance are expected to be down and left out.
+func (g *satcomGraph) impact(maintenance *satcomMaintenance, now time.Time) []model.SatcomImpact {
+	impacts := make([]model.SatcomImpact, 0)
+	for _, id := range g.order {
+		data := g.nodes[id]
+		if data.Status || maintenance.active(data, now) != nil {
+			continue
+		}
+		impacts = append(impacts, model.SatcomImpact{
+			Entry:    toSatcomGraphNode(data),
+			Impacted: g.closure(id, false, 0),
+		})
+	}
+	return impacts
+}
+
+func (g *satcomGraph) toModel() model.SatcomGraph {
+	graph := model.SatcomGraph{
+		Nodes: make([]model.SatcomGraphNode, 0, len(g.order)),
+		Edges: make([]model.SatcomRelation, 0, len(g.edges)),
+	}
+	for _, id := range g.order {
+		graph.Nodes = append(graph.Nodes, toSatcomGraphNode(g.nodes[id]))
+	}
+	for _, rel := range g.edges {
+		graph.Edges = append(graph.Edges, toSatcomRelation(rel))
+	}
+	return graph
+}
+
+// dot renders the graph in Graphviz DOT; entries that are down are filled red
+func (g *satcomGraph) dot() string {
+	var b strings.Builder
+	b.WriteString("digraph satcom {\n")
+	b.WriteString("\trankdir=LR;\n")
+	b.WriteString("\tnode [shape=box, style=rounded];\n")
+	for _, id := range g.order {
+		data := g.nodes[id]
+		name := data.Name.String
+		if name == "" {
+			name = fmt.Sprintf("#%d", id)
+		}
+		label := fmt.Sprintf("%s\n%s / %s / %s", name, data.Company, data.Category, data.Type)
+		fmt.Fprintf(&b, "\t%d [label=%s", id, dotQuote(label))
+		if !data.Status {
+			b.WriteString(`, style="rounded,filled", fillcolor="#f4cccc", color="#cc0000"`)
+		}
+		b.WriteString("];\n")
+	}
+	for _, rel := range g.edges {
+		fmt.Fprintf(&b, "\t%d -> %d [label=%s];\n", rel.SourceID, rel.TargetID, dotQuote(rel.Relation))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// dotQuote quotes a DOT string; newlines become \n line breaks of the label
+func dotQuote(str string) string {
+	str = strings.ReplaceAll(str, `\`, `\\`)
+	str = strings.ReplaceAll(str, `"`, `\"`)
+	str = strings.ReplaceAll(str, "\n", `\n`)
+	return `"` + str + `"`
+}
+
+// satcomRelationPath finds a chain of DEPENDS_ON and HOSTED_ON relations leading from
+// one entry to another and returns the entry ids along it, or nil. Relations of
+// soft-deleted entries count too, since a restore would bring them back.
+func satcomRelationPath(relations []auth.CommonSatcomRelation, from, to int32) []int32 {
+	upstream := make(map[int32][]int32)
+	for _, rel := range relations {
+		if !satcomAcyclicRelations[rel.Relation] {
+			continue
+		}
+		upstream[rel.SourceID] = append(upstream[rel.SourceID], rel.TargetID)
+	}
+	previous := map[int32]int32{from: from}
+	queue := []int32{from}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if id == to {
+			path := []int32{id}
+			for id != from {
+				id = previous[id]
+				path = append([]int32{id}, path...)
+			}
+			return path
+		}
+		for _, next := range upstream[id] {
+			if _, seen := previous[next]; !seen {
+				previous[next] = id
+				queue = append(queue, next)
+			}
+		}
+	}
+	return nil
+}
+
+func toSatcomGraphNode(data auth.CommonSatcomDatum) model.SatcomGraphNode {
+	return model.SatcomGraphNode{
+		ID:       data.ID,
+		Name:     data.Name.String,
+		Company:  data.Company,
+		Category: data.Category,
+		Type:     data.Type,
+		URL:      data.Url,
+		Status:   data.Status,
+	}
+}
+
+func toSatcomRelation(rel auth.CommonSatcomRelation) model.SatcomRelation {
+	relation := model.SatcomRelation{
+		ID:        rel.ID,
+		SourceID:  rel.SourceID,
+		TargetID:  rel.TargetID,
+		Relation:  rel.Relation,
+		CreatedAt: rel.CreatedAt.Time,
+	}
+	if rel.CreatedBy.Valid {
+		relation.CreatedBy = &rel.CreatedBy.Int32
+	}
+	return relation
+}
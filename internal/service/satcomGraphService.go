@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+)
+
+// /api/satcom/:id/relations - relate an entry to another entry it relies on
+func (s *RESTService) createSatcomRelation(c *gin.Context) APIResponse {
+	id, scope, errResp := s.authorizeSatcomID(c, true)
+	if errResp != nil {
+		return *errResp
+	}
+	var input model.SatcomRelationInput
+	if !parseInput(c, &input) {
+		return BuildResponse400("Invalid input provided")
+	}
+	input.Relation = strings.ToUpper(strings.TrimSpace(input.Relation))
+	var errs []model.FieldError
+	if !satcomRelationTypes[input.Relation] {
+		errs = append(errs, model.FieldError{Field: "relation", Message: "must be one of DEPENDS_ON, CALLS, READS_FROM, WRITES_TO, HOSTED_ON"})
+	}
+	if input.TargetID == id {
+		errs = append(errs, model.FieldError{Field: "target_id", Message: "must be another entry"})
+	}
+	if len(errs) > 0 {
+		return BuildValidationResponse(errs)
+	}
+
+	ctx := context.Background()
+	db := s.dbConn.GetPool()
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		_asLogger.Errorf("Error starting transaction: %v", err)
+		return BuildResponse500("Failed to create relation", err.Error())
+	}
+	defer tx.Rollback(ctx)
+	qtx := auth.New(tx)
+
+	// Concurrent inserts could close a cycle that neither of them sees
+	if err := qtx.LockSatcomRelations(ctx); err != nil {
+		_asLogger.Errorf("Error locking satcom relations: %v", err)
+		return BuildResponse500("Failed to create relation", err.Error())
+	}
+	source, err := qtx.GetSatcomDataById(ctx, id)
+	if err != nil {
+		return BuildResponse404("Satcom data not found", false)
+	}
+	target, err := qtx.GetSatcomDataById(ctx, input.TargetID)
+	if err != nil || !scope.contains(target.Company) {
+		return BuildValidationResponse([]model.FieldError{{Field: "target_id", Message: "is not a satcom entry"}})
+	}
+	if target.Company != source.Company {
+		return BuildValidationResponse([]model.FieldError{{Field: "target_id", Message: fmt.Sprintf("must be an entry of company %s", source.Company)}})
+	}
+	relations, err := qtx.ListSatcomRelations(ctx, getSQLString(source.Company))
+	if err != nil {
+		_asLogger.Errorf("Error getting satcom relations: %v", err)
+		return BuildResponse500("Failed to create relation", err.Error())
+	}
+	for _, rel := range relations {
+		if rel.SourceID == id && rel.TargetID == input.TargetID && rel.Relation == input.Relation {
+			return buildResponse(409, false, "Relation already exists", toSatcomRelation(rel))
+		}
+	}
+	if satcomAcyclicRelations[input.Relation] {
+		if path := satcomRelationPath(relations, input.TargetID, id); path != nil {
+			return buildResponse(409, false, "Relation would create a dependency cycle", map[string]interface{}{
+				"cycle": append([]int32{id}, path...),
+			})
+		}
+	}
+
+	rel, err := qtx.CreateSatcomRelation(ctx, auth.CreateSatcomRelationParams{
+		SourceID:  id,
+		TargetID:  input.TargetID,
+		Relation:  input.Relation,
+		CreatedBy: s.currentUserID(c),
+	})
+	if err != nil {
+		_asLogger.Errorf("Error creating relation of satcom data %d: %v", id, err)
+		return BuildResponse500("Failed to create relation", err.Error())
+	}
+	if err := tx.Commit(ctx); err != nil {
+		_asLogger.Errorf("Error committing relation of satcom data %d: %v", id, err)
+		return BuildResponse500("Failed to create relation", err.Error())
+	}
+
+	return BuildResponse200("Relation created successfully", toSatcomRelation(rel))
+}
+
+// /api/satcom/:id/relations - the direct relations of an entry in both directions
+func (s *RESTService) listSatcomRelations(c *gin.Context) APIResponse {
+	g, id, errResp := s.loadSatcomEntryGraph(c)
+	if errResp != nil {
+		return *errResp
+	}
+	result := model.SatcomEntryRelations{
+		ID:         id,
+		Upstream:   make([]model.SatcomRelatedEntry, 0),
+		Downstream: make([]model.SatcomRelatedEntry, 0),
+	}
+	for _, rel := range g.upstream[id] {
+		result.Upstream = append(result.Upstream, model.SatcomRelatedEntry{
+			Relation: toSatcomRelation(rel),
+			Entry:    toSatcomGraphNode(g.nodes[rel.TargetID]),
+		})
+	}
+	for _, rel := range g.downstream[id] {
+		result.Downstream = append(result.Downstream, model.SatcomRelatedEntry{
+			Relation: toSatcomRelation(rel),
+			Entry:    toSatcomGraphNode(g.nodes[rel.SourceID]),
+		})
+	}
+	return BuildResponse200("Relations retrieved successfully", result)
+}
+
+// /api/satcom/:id/relations/:relationId - remove a relation from either of its ends
+func (s *RESTService) deleteSatcomRelation(c *gin.Context) APIResponse {
+	id, _, errResp := s.authorizeSatcomID(c, true)
+	if errResp != nil {
+		return *errResp
+	}
+	relationID, err := strconv.ParseInt(c.Param("relationId"), 10, 32)
+	if err != nil {
+		return BuildResponse400("Invalid relation ID format")
+	}
+
+	ctx := context.Background()
+	qtx := auth.New(s.dbConn.GetPool())
+	rel, err := qtx.GetSatcomRelation(ctx, int32(relationID))
+	if err == pgx.ErrNoRows || (err == nil && rel.SourceID != id && rel.TargetID != id) {
+		return BuildResponse404("Relation not found", false)
+	}
+	if err != nil {
+		_asLogger.Errorf("Error getting satcom relation %d: %v", relationID, err)
+		return BuildResponse500("Failed to delete relation", err.Error())
+	}
+	if _, err := qtx.DeleteSatcomRelation(ctx, rel.ID); err != nil {
+		_asLogger.Errorf("Error deleting satcom relation %d: %v", relationID, err)
+		return BuildResponse500("Failed to delete relation", err.Error())
+	}
+	return BuildResponse200("Relation deleted successfully", nil)
+}
+
+// /api/satcom/:id/upstream - everything an entry relies on, transitively
+func (s *RESTService) getSatcomUpstream(c *gin.Context) APIResponse {
+	return s.getSatcomClosure(c, true)
+}
+
+// /api/satcom/:id/downstream - everything relying on an entry, i.e. what an outage of
+// the entry impacts
+func (s *RESTService) getSatcomDownstream(c *gin.Context) APIResponse {
+	return s.getSatcomClosure(c, false)
+}
+
+func (s *RESTService) getSatcomClosure(c *gin.Context, upstream bool) APIResponse {
+	depth := 0
+	if str := c.Query("depth"); str != "" {
+		n, err := strconv.Atoi(str)
+		if err != nil || n < 1 {
+			return BuildResponse400("depth must be a positive number")
+		}
+		depth = n
+	}
+	g, id, errResp := s.loadSatcomEntryGraph(c)
+	if errResp != nil {
+		return *errResp
+	}
+	closure := model.SatcomClosure{ID: id, Direction: "downstream", Entries: g.closure(id, upstream, depth)}
+	if upstream {
+		closure.Direction = "upstream"
+	}
+	return BuildResponse200("Dependencies retrieved successfully", closure)
+}
+
+// /api/satcom/graph - the dependency graph of the entries matching the list filters,
+// as JSON or with format=dot as Graphviz DOT
+func (s *RESTService) getSatcomGraph(c *gin.Context) {
+	format := strings.ToLower(c.DefaultQuery("format", "json"))
+	if format != "json" && format != "dot" {
+		resp := BuildResponse400("Unsupported graph format, expected json or dot")
+		c.JSON(resp.StatusCode, resp)
+		return
+	}
+	entries, errResp := s.listScopedSatcomData(c)
+	if errResp != nil {
+		c.JSON(errResp.StatusCode, errResp)
+		return
+	}
+	scope, errResp := s.satcomScope(c)
+	if errResp != nil {
+		c.JSON(errResp.StatusCode, errResp)
+		return
+	}
+	relations, err := auth.New(s.dbConn.GetPool()).ListSatcomRelations(context.Background(), scope.filter())
+	if err != nil {
+		_asLogger.Errorf("Error getting satcom relations: %v", err)
+		resp := BuildResponse500("Failed to retrieve graph", err.Error())
+		c.JSON(resp.StatusCode, resp)
+		return
+	}
+	g := newSatcomGraph(entries, relations)
+	if format == "dot" {
+		c.Data(200, "text/vnd.graphviz; charset=utf-8", []byte(g.dot()))
+		return
+	}
+	resp := BuildResponse200("Graph retrieved successfully", g.toModel())
+	c.JSON(resp.StatusCode, resp)
+}
+
+// /api/satcom/impact - every entry that is down, outside maintenance, with the downstream
+// entries it impacts
+func (s *RESTService) getSatcomImpact(c *gin.Context) APIResponse {
+	scope, errResp := s.satcomScope(c)
+	if errResp != nil {
+		return *errResp
+	}
+	ctx := context.Background()
+	qtx := auth.New(s.dbConn.GetPool())
+	g, err := loadSatcomGraph(ctx, qtx, scope)
+	if err != nil {
+		_asLogger.Errorf("Error loading satcom graph: %v", err)
+		return BuildResponse500("Failed to retrieve impact", err.Error())
+	}
+	maintenance, err := loadSatcomMaintenance(ctx, qtx, scope.filter())
+	if err != nil {
+		_asLogger.Errorf("Error getting maintenance windows: %v", err)
+		return BuildResponse500("Failed to retrieve impact", err.Error())
+	}
+	return BuildResponse200("Impact retrieved successfully", g.impact(maintenance, time.Now()))
+}
+
+// satcomImpacted lists the downstream entries of an entry that is down, for the
+// responses of status changes and probes
+func (s *RESTService) satcomImpacted(ctx context.Context, scope satcomScope, id int32) []model.SatcomDependency {
+	g, err := loadSatcomGraph(ctx, auth.New(s.dbConn.GetPool()), scope)
+	if err != nil {
+		_asLogger.Errorf("Error loading satcom graph: %v", err)
+		return nil
+	}
+	return g.closure(id, false, 0)
+}
+
+// loadSatcomEntryGraph authorizes the entry in the path and loads the graph it is part of
+func (s *RESTService) loadSatcomEntryGraph(c *gin.Context) (*satcomGraph, int32, *APIResponse) {
+	id, scope, errResp := s.authorizeSatcomID(c, false)
+	if errResp != nil {
+		return nil, 0, errResp
+	}
+	g, err := loadSatcomGraph(context.Background(), auth.New(s.dbConn.GetPool()), scope)
+	if err != nil {
+		_asLogger.Errorf("Error loading satcom graph: %v", err)
+		resp := BuildResponse500("Failed to retrieve relations", err.Error())
+		return nil, 0, &resp
+	}
+	if _, ok := g.nodes[id]; !ok {
+		resp := BuildResponse404("Satcom data not found", false)
+		return nil, 0, &resp
+	}
+	return g, id, nil
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+)
+
+func TestSatcomRelationPath(t *testing.T) {
+	rel := func(source, target int32, relation string) auth.CommonSatcomRelation {
+		return auth.CommonSatcomRelation{SourceID: source, TargetID: target, Relation: relation}
+	}
+	tests := []struct {
+		name      string
+		relations []auth.CommonSatcomRelation
+		from, to  int32
+		want      []int32
+	}{
+		{"no relations", nil, 2, 1, nil},
+		{"direct", []auth.CommonSatcomRelation{rel(2, 1, SATCOM_RELATION_DEPENDS_ON)}, 2, 1, []int32{2, 1}},
+		{"transitive", []auth.CommonSatcomRelation{
+			rel(2, 3, SATCOM_RELATION_DEPENDS_ON),
+			rel(3, 1, SATCOM_RELATION_HOSTED_ON),
+		}, 2, 1, []int32{2, 3, 1}},
+		{"shortest of two", []auth.CommonSatcomRelation{
+			rel(2, 3, SATCOM_RELATION_DEPENDS_ON),
+			rel(3, 4, SATCOM_RELATION_DEPENDS_ON),
+			rel(4, 1, SATCOM_RELATION_DEPENDS_ON),
+			rel(2, 5, SATCOM_RELATION_HOSTED_ON),
+			rel(5, 1, SATCOM_RELATION_DEPENDS_ON),
+		}, 2, 1, []int32{2, 5, 1}},
+		{"wrong direction", []auth.CommonSatcomRelation{rel(1, 2, SATCOM_RELATION_DEPENDS_ON)}, 2, 1, nil},
+		{"calls go both ways", []auth.CommonSatcomRelation{rel(2, 1, SATCOM_RELATION_CALLS)}, 2, 1, nil},
+		{"data flows go both ways", []auth.CommonSatcomRelation{
+			rel(2, 3, SATCOM_RELATION_READS_FROM),
+			rel(3, 1, SATCOM_RELATION_WRITES_TO),
+		}, 2, 1, nil},
+		{"mixed chain broken by a call", []auth.CommonSatcomRelation{
+			rel(2, 3, SATCOM_RELATION_DEPENDS_ON),
+			rel(3, 1, SATCOM_RELATION_CALLS),
+		}, 2, 1, nil},
+		{"existing cycle elsewhere", []auth.CommonSatcomRelation{
+			rel(3, 4, SATCOM_RELATION_DEPENDS_ON),
+			rel(4, 3, SATCOM_RELATION_CALLS),
+			rel(2, 3, SATCOM_RELATION_DEPENDS_ON),
+		}, 2, 1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := satcomRelationPath(tt.relations, tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("satcomRelationPath(%d, %d) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSatcomGraphClosure(t *testing.T) {
+	entries := []auth.CommonSatcomDatum{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}}
+	relations := []auth.CommonSatcomRelation{
+		{SourceID: 2, TargetID: 1, Relation: SATCOM_RELATION_DEPENDS_ON},
+		{SourceID: 3, TargetID: 2, Relation: SATCOM_RELATION_HOSTED_ON},
+		{SourceID: 4, TargetID: 1, Relation: SATCOM_RELATION_CALLS},
+		{SourceID: 1, TargetID: 4, Relation: SATCOM_RELATION_CALLS},
+		// the target is outside the graph, e.g. in the recycle bin
+		{SourceID: 3, TargetID: 9, Relation: SATCOM_RELATION_DEPENDS_ON},
+	}
+	g := newSatcomGraph(entries, relations)
+
+	tests := []struct {
+		id       int32
+		upstream bool
+		depth    int
+		want     []int32
+	}{
+		{1, false, 0, []int32{2, 4, 3}},
+		{1, false, 1, []int32{2, 4}},
+		{3, true, 0, []int32{2, 1, 4}},
+		{4, true, 0, []int32{1}},
+	}
+	for _, tt := range tests {
+		var got []int32
+		for _, dep := range g.closure(tt.id, tt.upstream, tt.depth) {
+			got = append(got, dep.ID)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("closure(%d, upstream %v, depth %d) = %v, want %v", tt.id, tt.upstream, tt.depth, got, tt.want)
+		}
+	}
+}
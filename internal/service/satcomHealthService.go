@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+)
+
+// /api/satcom/:id/uptime - uptime percentage per check over a window (default 24h)
+func (s *RESTService) getSatcomUptime(c *gin.Context) APIResponse {
+	id, _, errResp := s.authorizeSatcomID(c, false)
+	if errResp != nil {
+		return *errResp
+	}
+	window, err := parseWindow(c.Query("window"), DEFAULT_UPTIME_WINDOW)
+	if err != nil {
+		return BuildResponse400(err.Error())
+	}
+
+	ctx := context.Background()
+	db := s.dbConn.GetPool()
+	qtx := auth.New(db)
+
+	if _, err := qtx.GetSatcomDataById(ctx, id); err != nil {
+		return BuildResponse404("Satcom data not found", false)
+	}
+	rows, err := qtx.GetSatcomUptime(ctx, auth.GetSatcomUptimeParams{
+		SatcomID: id,
+		Since:    pgtype.Timestamptz{Time: time.Now().Add(-window), Valid: true},
+	})
+	if err != nil {
+		_asLogger.Errorf("Error getting uptime of satcom data %d: %v", id, err)
+		return BuildResponse500("Failed to retrieve uptime", err.Error())
+	}
+
+	checks := make([]model.UptimeSummary, 0, len(rows))
+	var total, up int64
+	for _, row := range rows {
+		summary := model.UptimeSummary{
+			CheckType:     row.CheckType,
+			Total:         row.Total,
+			Up:            row.Up,
+			UptimePercent: uptimePercent(row.Up, row.Total),
+			AvgLatencyMs:  row.AvgLatencyMs,
+		}
+		if row.LastProbedAt.Valid {
+			summary.LastProbedAt = &row.LastProbedAt.Time
+		}
+		checks = append(checks, summary)
+		total += row.Total
+		up += row.Up
+	}
+
+	return BuildResponse200("Uptime retrieved successfully", map[string]interface{}{
+		"id":            id,
+		"window":        window.String(),
+		"uptimePercent": uptimePercent(up, total),
+		"checks":        checks,
+	})
+}
+
+// /api/satcom/:id/latency - probe history, newest first; filter with check=http|db_port|ui_port
+func (s *RESTService) getSatcomLatency(c *gin.Context) APIResponse {
+	id, _, errResp := s.authorizeSatcomID(c, false)
+	if errResp != nil {
+		return *errResp
+	}
+	window, err := parseWindow(c.Query("window"), DEFAULT_UPTIME_WINDOW)
+	if err != nil {
+		return BuildResponse400(err.Error())
+	}
+	limit := int32(MAX_PAGE_LIMIT)
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return BuildResponse400("invalid limit")
+		}
+		if n < MAX_PAGE_LIMIT {
+			limit = int32(n)
+		}
+	}
+	check := c.Query("check")
+	if check != "" && check != PROBE_CHECK_HTTP && check != PROBE_CHECK_DB_PORT && check != PROBE_CHECK_UI_PORT {
+		return BuildResponse400("check must be one of http, db_port, ui_port")
+	}
+
+	ctx := context.Background()
+	db := s.dbConn.GetPool()
+	qtx := auth.New(db)
+
+	rows, err := qtx.ListProbeResults(ctx, auth.ListProbeResultsParams{
+		SatcomID:  id,
+		Since:     pgtype.Timestamptz{Time: time.Now().Add(-window), Valid: true},
+		CheckType: optionalText(check),
+		RowLimit:  limit,
+	})
+	if err != nil {
+		_asLogger.Errorf("Error getting probe history of satcom data %d: %v", id, err)
+		return BuildResponse500("Failed to retrieve latency history", err.Error())
+	}
+
+	history := make([]model.ProbeResult, 0, len(rows))
+	for _, row := range rows {
+		history = append(history, model.ProbeResult{
+			CheckType:  row.CheckType,
+			Target:     row.Target,
+			Success:    row.Success,
+			LatencyMs:  row.LatencyMs.Int32,
+			StatusCode: row.StatusCode.Int32,
+			Error:      row.Error.String,
+			ProbedAt:   row.ProbedAt.Time,
+		})
+	}
+
+	return BuildResponse200("Latency history retrieved successfully", history)
+}
+
+// /api/satcom/:id/probe - probe one entry now and return the results
+func (s *RESTService) probeSatcomData(c *gin.Context) APIResponse {
+	id, scope, errResp := s.authorizeSatcomID(c, true)
+	if errResp != nil {
+		return *errResp
+	}
+
+	ctx := context.Background()
+	db := s.dbConn.GetPool()
+	qtx := auth.New(db)
+
+	data, err := qtx.GetSatcomDataById(ctx, id)
+	if err != nil {
+		return BuildResponse404("Satcom data not found", false)
+	}
+	results, err := s.prober.ProbeAndStore(ctx, data)
+	if err != nil {
+		_asLogger.Errorf("Error storing probe results of satcom data %d: %v", id, err)
+		return BuildResponse500("Failed to store probe results", err.Error())
+	}
+
+	payload := map[string]interface{}{
+		"id":      id,
+		"status":  deriveProbeStatus(results),
+		"results": results,
+	}
+	if data, err := qtx.GetSatcomDataById(ctx, id); err == nil && !data.Status {
+		payload["impacted"] = s.satcomImpacted(ctx, scope, id)
+	}
+	return BuildResponse200("Satcom data probed successfully", payload)
+}
+
+// /api/satcom/:id/status - set a manual status override, or hand the status back to the prober
+func (s *RESTService) setSatcomStatus(c *gin.Context) APIResponse {
+	id, scope, errResp := s.authorizeSatcomID(c, true)
+	if errResp != nil {
+		return *errResp
+	}
+	var input model.SatcomStatusInput
+	if !parseInput(c, &input) {
+		return BuildResponse400("Invalid input provided")
+	}
+	if input.Override && input.Status == nil {
+		return BuildValidationResponse([]model.FieldError{{Field: "status", Message: "is required when override is true"}})
+	}
+
+	ctx := context.Background()
+	db := s.dbConn.GetPool()
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		_asLogger.Errorf("Error starting transaction: %v", err)
+		return BuildResponse500("Failed to update status", err.Error())
+	}
+	defer tx.Rollback(ctx)
+	qtx := auth.New(tx)
+
+	current, err := qtx.GetSatcomDataById(ctx, id)
+	if err != nil {
+		return BuildResponse404("Satcom data not found", false)
+	}
+	if s.requiresApproval(current) {
+		proposed := current
+		proposed.StatusOverride = input.Override
+		if input.Status != nil {
+			proposed.Status = *input.Status
+		}
+		return s.submitSatcomChange(ctx, tx, c, SATCOM_OP_STATUS, current, &proposed, false)
+	}
+
+	params := auth.SetSatcomStatusOverrideParams{StatusOverride: input.Override, ID: id}
+	if input.Status != nil {
+		params.Status = pgtype.Bool{Bool: *input.Status, Valid: true}
+	}
+	updated, err := qtx.SetSatcomStatusOverride(ctx, params)
+	if err != nil {
+		_asLogger.Errorf("Error setting status of satcom data %d: %v", id, err)
+		return BuildResponse500("Failed to update status", err.Error())
+	}
+	if updated == 0 {
+		return BuildResponse404("Satcom data not found", false)
+	}
+	if err := s.recordSatcomVersion(ctx, qtx, c, id, SATCOM_OP_STATUS, pgtype.Int4{}); err != nil {
+		_asLogger.Errorf("Error recording history of satcom data %d: %v", id, err)
+		return BuildResponse500("Failed to update status", err.Error())
+	}
+	if err := tx.Commit(ctx); err != nil {
+		_asLogger.Errorf("Error committing status of satcom data %d: %v", id, err)
+		return BuildResponse500("Failed to update status", err.Error())
+	}
+
+	// The effective status, which the prober keeps deriving when the override is cleared
+	data, err := auth.New(db).GetSatcomDataById(ctx, id)
+	if err != nil {
+		return BuildResponse200("Satcom status updated successfully", nil)
+	}
+	payload := map[string]interface{}{
+		"id":       id,
+		"status":   data.Status,
+		"override": data.StatusOverride,
+	}
+	if !data.Status {
+		payload["impacted"] = s.satcomImpacted(ctx, scope, id)
+	}
+	return BuildResponse200("Satcom status updated successfully", payload)
+}
+
+// parseSatcomID reads the :id path parameter
+func parseSatcomID(c *gin.Context) (int32, *APIResponse) {
+	var id int32
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &id); err != nil {
+		resp := BuildResponse400("Invalid ID format")
+		return 0, &resp
+	}
+	return id, nil
+}
+
+// parseWindow reads a duration such as 90m, 24h or 7d
+func parseWindow(str string, defaultWindow time.Duration) (time.Duration, error) {
+	if str == "" {
+		return defaultWindow, nil
+	}
+	if days, found := strings.CutSuffix(str, "d"); found {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 1 {
+			return 0, fmt.Errorf("invalid window %s", str)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	window, err := time.ParseDuration(str)
+	if err != nil || window <= 0 {
+		return 0, fmt.Errorf("invalid window %s", str)
+	}
+	return window, nil
+}
+
+func uptimePercent(up, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(up*10000/total) / 100
+}
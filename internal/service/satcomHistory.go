@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+	"github.com/rest/api/internal/util"
+)
+
+// Snapshot columns left out of version diffs; they change on every write or probe
+var satcomDiffIgnored = map[string]bool{
+	"version":        true,
+	"last_probed_at": true,
+}
+
+// recordSatcomVersion stores the current row of an entry as a new history version,
+// attributed to the caller (and the administrator behind an impersonation token).
+// It must run in the same transaction as the change it records.
+func (s *RESTService) recordSatcomVersion(ctx context.Context, qtx *auth.Queries, c *gin.Context, id int32, operation string, restoredFrom pgtype.Int4) error {
+	params := auth.CreateSatcomHistoryParams{
+		SatcomID:     id,
+		Operation:    operation,
+		RestoredFrom: restoredFrom,
+	}
+	if claims := s.currentClaims(c); claims != nil {
+		params.ChangedBy = ConvertInt32ToPgInt4(claims.UserID)
+		params.ChangedByName = getSQLString(claims.UserName)
+		if claims.Act != nil {
+			params.ActorID = ConvertInt32ToPgInt4(claims.Act.UserID)
+			params.ActorName = getSQLString(claims.Act.UserName)
+		}
+	}
+	return qtx.CreateSatcomHistory(ctx, params)
+}
+
+// currentUserID returns the caller's user id, or NULL for unauthenticated calls
+func (s *RESTService) currentUserID(c *gin.Context) pgtype.Int4 {
+	if claims := s.currentClaims(c); claims != nil {
+		return ConvertInt32ToPgInt4(claims.UserID)
+	}
+	return pgtype.Int4{}
+}
+
+// satcomDatumFromSnapshot decodes a history snapshot; its keys are the column names
+func satcomDatumFromSnapshot(snapshot []byte) (auth.CommonSatcomDatum, error) {
+	var data auth.CommonSatcomDatum
+	err := json.Unmarshal(snapshot, &data)
+	return data, err
+}
+
+// toSatcomVersion renders a history row with its snapshot in the requested API version
+func toSatcomVersion(row auth.CommonSatcomHistory, version int) (model.SatcomVersion, error) {
+	data, err := satcomDatumFromSnapshot(row.Snapshot)
+	if err != nil {
+		return model.SatcomVersion{}, err
+	}
+	v := model.SatcomVersion{
+		Version:       row.Version,
+		Operation:     row.Operation,
+		ChangedAt:     row.ChangedAt.Time,
+		ChangedByName: row.ChangedByName.String,
+		ActorName:     row.ActorName.String,
+		Data:          toSatcomResponse(data, version),
+	}
+	if row.ChangedBy.Valid {
+		v.ChangedBy = &row.ChangedBy.Int32
+	}
+	if row.ActorID.Valid {
+		v.ActorID = &row.ActorID.Int32
+	}
+	if row.RestoredFrom.Valid {
+		v.RestoredFrom = &row.RestoredFrom.Int32
+	}
+	return v, nil
+}
+
+// diffSnapshots lists the columns whose values differ between two snapshots, by name
+func diffSnapshots(from, to []byte) ([]model.SatcomFieldChange, error) {
+	var before, after map[string]interface{}
+	if err := json.Unmarshal(from, &before); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(to, &after); err != nil {
+		return nil, err
+	}
+	fields := make(map[string]bool)
+	for field := range before {
+		fields[field] = true
+	}
+	for field := range after {
+		fields[field] = true
+	}
+	names := make([]string, 0, len(fields))
+	for field := range fields {
+		if !satcomDiffIgnored[field] {
+			names = append(names, field)
+		}
+	}
+	sort.Strings(names)
+
+	changes := make([]model.SatcomFieldChange, 0)
+	for _, field := range names {
+		if !reflect.DeepEqual(before[field], after[field]) {
+			changes = append(changes, model.SatcomFieldChange{Field: field, From: before[field], To: after[field]})
+		}
+	}
+	return changes, nil
+}
+
+// SatcomPurger periodically removes entries that stayed in the recycle bin longer
+// than the retention period. Their history is kept.
+type SatcomPurger struct {
+	dbConn    *util.DBConnectionWrapper
+	interval  time.Duration
+	retention time.Duration
+	// attachments removes the files of purged entries; nil when attachments are disabled
+	attachments *satcomAttachmentStore
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewSatcomPurger applies the configuration defaults and builds a purger
+func NewSatcomPurger(conf model.RecycleBinConfig, dbConn *util.DBConnectionWrapper) *SatcomPurger {
+	p := &SatcomPurger{
+		dbConn:    dbConn,
+		interval:  time.Duration(conf.IntervalMinutes) * time.Minute,
+		retention: time.Duration(conf.RetentionDays) * 24 * time.Hour,
+	}
+	if p.interval <= 0 {
+		p.interval = DEFAULT_PURGE_INTERVAL
+	}
+	if p.retention <= 0 {
+		p.retention = DEFAULT_RECYCLE_BIN_RETENTION
+	}
+	return p
+}
+
+// Start purges immediately and then on every interval until Stop is called
+func (p *SatcomPurger) Start() {
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			if _, err := p.RunOnce(context.Background()); err != nil {
+				_asLogger.Errorf("Recycle bin purge failed: %v", err)
+			}
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	_asLogger.Infof("Recycle bin purger started (interval %s, retention %s)", p.interval, p.retention)
+}
+
+// Stop ends the background loop and waits for the current run to finish
+func (p *SatcomPurger) Stop() {
+	if p.stop == nil {
+		return
+	}
+	close(p.stop)
+	<-p.done
+	p.stop = nil
+}
+
+// RunOnce purges every entry deleted before the retention period and returns their ids.
+// Attachment objects left behind by earlier purges are deleted again.
+func (p *SatcomPurger) RunOnce(ctx context.Context) ([]int32, error) {
+	qtx := auth.New(p.dbConn.GetPool())
+	ids, err := qtx.PurgeSatcomData(ctx, auth.PurgeSatcomDataParams{
+		DeletedBefore: pgtype.Timestamptz{Time: time.Now().Add(-p.retention), Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) > 0 {
+		_asLogger.Infof("Purged %d satcom entries from the recycle bin: %v", len(ids), ids)
+	}
+	if p.attachments != nil {
+		pending, err := qtx.ListSatcomAttachmentCleanups(ctx)
+		if err != nil {
+			_asLogger.Errorf("Error listing pending attachment cleanups: %v", err)
+		}
+		p.attachments.removeEntries(ctx, qtx, append(pending, ids...))
+	}
+	return ids, nil
+}
+
+// PurgeAfter returns when an entry deleted at deletedAt becomes due for purging,
+// or nil while the purge job is disabled
+func (p *SatcomPurger) PurgeAfter(deletedAt time.Time) *time.Time {
+	if p.stop == nil {
+		return nil
+	}
+	purgeAfter := deletedAt.Add(p.retention)
+	return &purgeAfter
+}
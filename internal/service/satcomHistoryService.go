@@ -0,0 +1,435 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+)
+
+// /api/satcom/:id/versions - every recorded version of an entry, newest first.
+// Deleted and purged entries keep their history.
+func (s *RESTService) listSatcomVersions(c *gin.Context) APIResponse {
+	id, scope, errResp := s.authorizeSatcomID(c, false)
+	if errResp != nil {
+		return *errResp
+	}
+	version, err := requestAPIVersion(c)
+	if err != nil {
+		return BuildResponse400(err.Error())
+	}
+
+	ctx := context.Background()
+	db := s.dbConn.GetPool()
+	qtx := auth.New(db)
+
+	rows, err := qtx.ListSatcomHistory(ctx, id)
+	if err != nil {
+		_asLogger.Errorf("Error getting history of satcom data %d: %v", id, err)
+		return BuildResponse500("Failed to retrieve satcom history", err.Error())
+	}
+
+	// Versions recorded while the entry belonged to another company stay hidden
+	versions := make([]model.SatcomVersion, 0, len(rows))
+	for _, row := range rows {
+		if !scope.containsHistory(row) {
+			continue
+		}
+		v, err := toSatcomVersion(row, version)
+		if err != nil {
+			_asLogger.Errorf("Error decoding version %d of satcom data %d: %v", row.Version, id, err)
+			return BuildResponse500("Failed to retrieve satcom history", err.Error())
+		}
+		versions = append(versions, v)
+	}
+	if len(versions) == 0 {
+		return BuildResponse404("Satcom data not found", false)
+	}
+
+	return BuildResponse200("Satcom history retrieved successfully", versions)
+}
+
+// /api/satcom/:id/versions/:version - one version of an entry
+func (s *RESTService) getSatcomVersion(c *gin.Context) APIResponse {
+	id, scope, errResp := s.authorizeSatcomID(c, false)
+	if errResp != nil {
+		return *errResp
+	}
+	versionNo, err := parseVersionNumber(c.Param("version"))
+	if err != nil {
+		return BuildResponse400(err.Error())
+	}
+	version, err := requestAPIVersion(c)
+	if err != nil {
+		return BuildResponse400(err.Error())
+	}
+
+	ctx := context.Background()
+	db := s.dbConn.GetPool()
+	qtx := auth.New(db)
+
+	row, err := qtx.GetSatcomHistoryVersion(ctx, auth.GetSatcomHistoryVersionParams{SatcomID: id, Version: versionNo})
+	if err != nil || !scope.containsHistory(row) {
+		return BuildResponse404("Satcom version not found", false)
+	}
+	v, err := toSatcomVersion(row, version)
+	if err != nil {
+		_asLogger.Errorf("Error decoding version %d of satcom data %d: %v", versionNo, id, err)
+		return BuildResponse500("Failed to retrieve satcom version", err.Error())
+	}
+
+	return BuildResponse200("Satcom version retrieved successfully", v)
+}
+
+// /api/satcom/:id/diff?from=2&to=5 - changed fields between two versions. to defaults to
+// the latest version and from to the version before to.
+func (s *RESTService) diffSatcomVersions(c *gin.Context) APIResponse {
+	id, scope, errResp := s.authorizeSatcomID(c, false)
+	if errResp != nil {
+		return *errResp
+	}
+
+	ctx := context.Background()
+	db := s.dbConn.GetPool()
+	qtx := auth.New(db)
+
+	var to int32
+	if v := c.Query("to"); v != "" {
+		n, err := parseVersionNumber(v)
+		if err != nil {
+			return BuildResponse400("Invalid to version")
+		}
+		to = n
+	} else {
+		rows, err := qtx.ListSatcomHistory(ctx, id)
+		if err != nil {
+			_asLogger.Errorf("Error getting history of satcom data %d: %v", id, err)
+			return BuildResponse500("Failed to compare satcom versions", err.Error())
+		}
+		if len(rows) == 0 {
+			return BuildResponse404("Satcom data not found", false)
+		}
+		to = rows[0].Version
+	}
+	from := to - 1
+	if v := c.Query("from"); v != "" {
+		n, err := parseVersionNumber(v)
+		if err != nil {
+			return BuildResponse400("Invalid from version")
+		}
+		from = n
+	}
+	if from < 1 {
+		return BuildResponse400("Entry has a single version; nothing to compare")
+	}
+
+	fromRow, err := qtx.GetSatcomHistoryVersion(ctx, auth.GetSatcomHistoryVersionParams{SatcomID: id, Version: from})
+	if err != nil || !scope.containsHistory(fromRow) {
+		return BuildResponse404("Satcom version not found", false)
+	}
+	toRow, err := qtx.GetSatcomHistoryVersion(ctx, auth.GetSatcomHistoryVersionParams{SatcomID: id, Version: to})
+	if err != nil || !scope.containsHistory(toRow) {
+		return BuildResponse404("Satcom version not found", false)
+	}
+	changes, err := diffSnapshots(fromRow.Snapshot, toRow.Snapshot)
+	if err != nil {
+		_asLogger.Errorf("Error comparing versions of satcom data %d: %v", id, err)
+		return BuildResponse500("Failed to compare satcom versions", err.Error())
+	}
+
+	return BuildResponse200("Satcom versions compared successfully", model.SatcomVersionDiff{
+		ID:      id,
+		From:    from,
+		To:      to,
+		Changes: changes,
+	})
+}
+
+// /api/satcom/:id/versions/:version/restore - make a prior version the current state of
+// the entry; a deleted entry is taken out of the recycle bin
+func (s *RESTService) restoreSatcomVersion(c *gin.Context) APIResponse {
+	id, scope, errResp := s.authorizeSatcomID(c, true)
+	if errResp != nil {
+		return *errResp
+	}
+	versionNo, err := parseVersionNumber(c.Param("version"))
+	if err != nil {
+		return BuildResponse400(err.Error())
+	}
+	allowConflicts, _ := strconv.ParseBool(c.Query("allowConflicts"))
+
+	ctx := context.Background()
+	db := s.dbConn.GetPool()
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		_asLogger.Errorf("Error starting transaction: %v", err)
+		return BuildResponse500("Failed to restore satcom data", err.Error())
+	}
+	defer tx.Rollback(ctx)
+	qtx := auth.New(tx)
+
+	if current, err := qtx.GetSatcomDataById(ctx, id); err != nil {
+		if _, err := qtx.GetDeletedSatcomDataById(ctx, id); err != nil {
+			return BuildResponse404("Satcom data not found; purged entries cannot be restored", false)
+		}
+	} else if s.requiresApproval(current) {
+		return buildResponse(409, false, "Changes to this entry need approval; submit the version with PUT /api/satcom/:id", nil)
+	}
+	row, err := qtx.GetSatcomHistoryVersion(ctx, auth.GetSatcomHistoryVersionParams{SatcomID: id, Version: versionNo})
+	if err != nil {
+		return BuildResponse404("Satcom version not found", false)
+	}
+	data, err := satcomDatumFromSnapshot(row.Snapshot)
+	if err != nil {
+		_asLogger.Errorf("Error decoding version %d of satcom data %d: %v", versionNo, id, err)
+		return BuildResponse500("Failed to restore satcom data", err.Error())
+	}
+	// A version recorded before the entry moved between companies stays out of reach
+	if !scope.containsHistory(row) {
+		return BuildResponse403(fmt.Sprintf("Version %d belongs to company %s", versionNo, data.Company))
+	}
+	// The version is restored into its company under the company's current name
+	var company auth.CommonCompany
+	if row.CompanyID.Valid {
+		company, err = qtx.GetCompanyById(ctx, row.CompanyID.Int32)
+	} else {
+		company, err = qtx.GetCompanyByName(ctx, data.Company)
+	}
+	if err != nil {
+		return BuildResponse400(fmt.Sprintf("Company %s of version %d is no longer registered", data.Company, versionNo))
+	}
+	data.Company = company.Name
+	// The restored row passes the approval gate too, so a deleted entry needing approval
+	// cannot come back with another state and an entry cannot be restored into production
+	if s.requiresApproval(data) {
+		return buildResponse(409, false, "The restored version needs approval; restore the entry from the recycle bin and submit the version with PUT /api/satcom/:id", nil)
+	}
+	if !allowConflicts {
+		if resp := s.checkSatcomConflicts(ctx, qtx, data); resp != nil {
+			return *resp
+		}
+	}
+
+	if _, err := qtx.RestoreSatcomVersion(ctx, auth.RestoreSatcomVersionParams{ID: id, Version: versionNo}); err != nil {
+		_asLogger.Errorf("Error restoring version %d of satcom data %d: %v", versionNo, id, err)
+		return BuildResponse500("Failed to restore satcom data", err.Error())
+	}
+	if err := s.recordSatcomVersion(ctx, qtx, c, id, SATCOM_OP_RESTORE, ConvertInt32ToPgInt4(versionNo)); err != nil {
+		_asLogger.Errorf("Error recording history of satcom data %d: %v", id, err)
+		return BuildResponse500("Failed to restore satcom data", err.Error())
+	}
+	if err := tx.Commit(ctx); err != nil {
+		_asLogger.Errorf("Error committing restore of satcom data %d: %v", id, err)
+		return BuildResponse500("Failed to restore satcom data", err.Error())
+	}
+
+	return BuildResponse200("Satcom data restored successfully", nil)
+}
+
+// /api/satcom/as-of?at=2024-06-01T00:00:00Z - the whole inventory as it was at a point in time
+func (s *RESTService) getSatcomInventoryAsOf(c *gin.Context) APIResponse {
+	atStr := strings.TrimSpace(c.Query("at"))
+	if atStr == "" {
+		return BuildResponse400("at parameter is required")
+	}
+	at, err := time.Parse(time.RFC3339, atStr)
+	if err != nil {
+		return BuildResponse400("at must be an RFC 3339 timestamp")
+	}
+	version, err := requestAPIVersion(c)
+	if err != nil {
+		return BuildResponse400(err.Error())
+	}
+	scope, errResp := s.satcomScope(c)
+	if errResp != nil {
+		return *errResp
+	}
+	company := optionalText(c.Query("company"))
+	if err := scope.restrict(&company); err != nil {
+		return BuildResponse403(err.Error())
+	}
+
+	ctx := context.Background()
+	db := s.dbConn.GetPool()
+	qtx := auth.New(db)
+
+	companyID, err := scope.filterID(ctx, qtx, company)
+	if err != nil {
+		return BuildResponse400(err.Error())
+	}
+	rows, err := qtx.ListSatcomInventoryAsOf(ctx, auth.ListSatcomInventoryAsOfParams{
+		ChangedAt: pgtype.Timestamptz{Time: at, Valid: true},
+		CompanyID: companyID,
+	})
+	if err != nil {
+		_asLogger.Errorf("Error getting satcom inventory as of %s: %v", at, err)
+		return BuildResponse500("Failed to retrieve satcom inventory", err.Error())
+	}
+
+	items := make([]interface{}, 0, len(rows))
+	for _, row := range rows {
+		data, err := satcomDatumFromSnapshot(row.Snapshot)
+		if err != nil {
+			_asLogger.Errorf("Error decoding version %d of satcom data %d: %v", row.Version, row.SatcomID, err)
+			return BuildResponse500("Failed to retrieve satcom inventory", err.Error())
+		}
+		items = append(items, toSatcomResponse(data, version))
+	}
+
+	return BuildResponse200("Satcom inventory retrieved successfully", map[string]interface{}{
+		"asOf":  at.UTC(),
+		"total": len(items),
+		"items": items,
+	})
+}
+
+// /api/satcom/recycle-bin - soft-deleted entries, most recently deleted first
+func (s *RESTService) listSatcomRecycleBin(c *gin.Context) APIResponse {
+	version, err := requestAPIVersion(c)
+	if err != nil {
+		return BuildResponse400(err.Error())
+	}
+	scope, errResp := s.satcomScope(c)
+	if errResp != nil {
+		return *errResp
+	}
+	company := optionalText(c.Query("company"))
+	if err := scope.restrict(&company); err != nil {
+		return BuildResponse403(err.Error())
+	}
+
+	ctx := context.Background()
+	db := s.dbConn.GetPool()
+	qtx := auth.New(db)
+
+	rows, err := qtx.ListDeletedSatcomData(ctx, company)
+	if err != nil {
+		_asLogger.Errorf("Error getting deleted satcom data: %v", err)
+		return BuildResponse500("Failed to retrieve recycle bin", err.Error())
+	}
+
+	entries := make([]model.DeletedSatcomEntry, 0, len(rows))
+	for _, row := range rows {
+		entry := model.DeletedSatcomEntry{
+			Data:       toSatcomResponse(row, version),
+			DeletedAt:  row.DeletedAt.Time,
+			PurgeAfter: s.purger.PurgeAfter(row.DeletedAt.Time),
+		}
+		if row.DeletedBy.Valid {
+			entry.DeletedBy = &row.DeletedBy.Int32
+		}
+		entries = append(entries, entry)
+	}
+
+	return BuildResponse200("Recycle bin retrieved successfully", entries)
+}
+
+// /api/satcom/recycle-bin/:id/restore - take a deleted entry out of the recycle bin
+func (s *RESTService) undeleteSatcomData(c *gin.Context) APIResponse {
+	id, scope, errResp := s.authorizeSatcomID(c, true)
+	if errResp != nil {
+		return *errResp
+	}
+	allowConflicts, _ := strconv.ParseBool(c.Query("allowConflicts"))
+
+	ctx := context.Background()
+	db := s.dbConn.GetPool()
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		_asLogger.Errorf("Error starting transaction: %v", err)
+		return BuildResponse500("Failed to restore satcom data", err.Error())
+	}
+	defer tx.Rollback(ctx)
+	qtx := auth.New(tx)
+
+	data, err := qtx.GetDeletedSatcomDataById(ctx, id)
+	if err != nil {
+		return BuildResponse404("Satcom data not found in the recycle bin", false)
+	}
+	// Entries needing approval come back unchanged, but only by an approver
+	if s.requiresApproval(data) && !scope.canApprove() {
+		return BuildResponse403("Only approvers can restore entries that need approval")
+	}
+	if !allowConflicts {
+		if resp := s.checkSatcomConflicts(ctx, qtx, data); resp != nil {
+			return *resp
+		}
+	}
+	if _, err := qtx.UndeleteSatcomData(ctx, id); err != nil {
+		_asLogger.Errorf("Error restoring satcom data %d: %v", id, err)
+		return BuildResponse500("Failed to restore satcom data", err.Error())
+	}
+	if err := s.recordSatcomVersion(ctx, qtx, c, id, SATCOM_OP_UNDELETE, pgtype.Int4{}); err != nil {
+		_asLogger.Errorf("Error recording history of satcom data %d: %v", id, err)
+		return BuildResponse500("Failed to restore satcom data", err.Error())
+	}
+	if err := tx.Commit(ctx); err != nil {
+		_asLogger.Errorf("Error committing restore of satcom data %d: %v", id, err)
+		return BuildResponse500("Failed to restore satcom data", err.Error())
+	}
+
+	return BuildResponse200("Satcom data restored successfully", nil)
+}
+
+// /api/satcom/recycle-bin/:id - permanently delete an entry from the recycle bin (SUPER_ADMIN only)
+func (s *RESTService) purgeSatcomData(c *gin.Context) APIResponse {
+	if !s.hasRole(c, ROLE_SUPER_ADMIN) {
+		return BuildResponse403("Only super admins can purge satcom data")
+	}
+	id, errResp := parseSatcomID(c)
+	if errResp != nil {
+		return *errResp
+	}
+
+	ctx := context.Background()
+	db := s.dbConn.GetPool()
+	qtx := auth.New(db)
+
+	ids, err := qtx.PurgeSatcomData(ctx, auth.PurgeSatcomDataParams{
+		DeletedBefore: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		ID:            ConvertInt32ToPgInt4(id),
+	})
+	if err != nil {
+		_asLogger.Errorf("Error purging satcom data %d: %v", id, err)
+		return BuildResponse500("Failed to purge satcom data", err.Error())
+	}
+	if len(ids) == 0 {
+		return BuildResponse404("Satcom data not found in the recycle bin", false)
+	}
+	s.recordAudit(ctx, c, AUDIT_SATCOM_PURGE, map[string]interface{}{"satcomId": id})
+	if s.attachments != nil {
+		s.attachments.removeEntries(ctx, qtx, ids)
+	}
+
+	return BuildResponse200("Satcom data purged successfully", nil)
+}
+
+// checkSatcomConflicts answers 409 when bringing back data would clash with another entry
+func (s *RESTService) checkSatcomConflicts(ctx context.Context, qtx *auth.Queries, data auth.CommonSatcomDatum) *APIResponse {
+	record := satcomRecord{RecordedAt: data.RecordedAt, DbPort: data.DbPort, UiPort: data.UiPort, Ip: data.Ip}
+	conflicts, err := findSatcomConflicts(ctx, qtx, data.Company, record, data.Url, ConvertInt32ToPgInt4(data.ID))
+	if err != nil {
+		_asLogger.Errorf("Error checking satcom conflicts: %v", err)
+		resp := BuildResponse500("Failed to check satcom conflicts", err.Error())
+		return &resp
+	}
+	if len(conflicts) > 0 {
+		resp := BuildConflictResponse(conflicts)
+		return &resp
+	}
+	return nil
+}
+
+func parseVersionNumber(str string) (int32, error) {
+	n, err := strconv.Atoi(str)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid version %s", str)
+	}
+	return int32(n), nil
+}
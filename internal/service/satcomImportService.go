@@ -0,0 +1,655 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+	"github.com/rest/api/internal/util"
+)
+
+var satcomExportHeader = []string{"id", "company", "category", "type", "recorded_at", "db_port", "ui_port", "url", "ip", "status", "labels"}
+
+var satcomExportContentTypes = map[string]string{
+	"csv":  "text/csv",
+	"xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"json": "application/json",
+}
+
+// Column headers recognised without a mapping, after normalizeColumnName
+var satcomImportColumns = map[string]string{
+	"company":    "company",
+	"category":   "category",
+	"type":       "type",
+	"recordedat": "recorded_at",
+	"date":       "date",
+	"time":       "time",
+	"dbport":     "db_port",
+	"uiport":     "ui_port",
+	"url":        "url",
+	"ip":         "ip",
+	"ipaddress":  "ip",
+	"status":     "status",
+	"labels":     "labels",
+}
+
+// /api/satcom/import - create or update satcom entries from a CSV, XLSX or JSON document
+//
+// Query parameters: mode=insert (default) only creates entries, mode=upsert updates the
+// entry of the same company with the same url (the natural key) and creates the others; dryRun=true runs the
+// import and rolls it back; allowConflicts=true skips the ip:port/url conflict check;
+// mapping={"Customer":"company",...} maps source columns onto fields.
+// All rows are written in a single transaction; any failing row rejects the whole import.
+// Rows without a company go to the caller's active company.
+func (s *RESTService) importSatcomData(c *gin.Context) APIResponse {
+	scope, errResp := s.satcomScope(c)
+	if errResp != nil {
+		return *errResp
+	}
+	if errResp := scope.writeDenied(); errResp != nil {
+		return *errResp
+	}
+	dryRun, _ := strconv.ParseBool(c.Query("dryRun"))
+	allowConflicts, _ := strconv.ParseBool(c.Query("allowConflicts"))
+	mode := strings.ToLower(c.DefaultQuery("mode", SATCOM_IMPORT_INSERT))
+	if mode != SATCOM_IMPORT_INSERT && mode != SATCOM_IMPORT_UPSERT {
+		return BuildResponse400("mode must be insert or upsert")
+	}
+	mapping, err := parseColumnMapping(c)
+	if err != nil {
+		return BuildResponse400(err.Error())
+	}
+
+	data, format, err := readUpload(c)
+	if err != nil {
+		return BuildResponse400(err.Error())
+	}
+	var table [][]string
+	switch format {
+	case "csv":
+		table, err = readCSVTable(data)
+	case "xlsx":
+		table, err = util.ReadXLSX(data)
+	case "json":
+		table, err = readJSONTable(data)
+	default:
+		return BuildResponse400("Unsupported import format, expected csv, xlsx or json")
+	}
+	if err != nil {
+		return BuildResponse400(fmt.Sprintf("Unable to parse import file: %v", err))
+	}
+	if len(table) < 2 {
+		return BuildResponse400("Import file contains no satcom entries")
+	}
+	fields, report, err := resolveImportColumns(table[0], mapping)
+	if err != nil {
+		return BuildResponse400(err.Error())
+	}
+	report.DryRun = dryRun
+	report.Mode = mode
+
+	ctx := context.Background()
+	tx, err := s.dbConn.GetPool().Begin(ctx)
+	if err != nil {
+		_asLogger.Errorf("Error starting import transaction: %v", err)
+		return BuildResponse500("Failed to import satcom data", err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	seen := make(map[string]int)
+	for i, record := range table[1:] {
+		values := make(map[string]string)
+		for col, value := range record {
+			if col < len(fields) && fields[col] != "" && strings.TrimSpace(value) != "" {
+				values[fields[col]] = util.UnescapeSpreadsheetText(strings.TrimSpace(value))
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+		if format == "xlsx" {
+			convertSerialDates(values)
+		}
+		result, err := s.importSatcomRow(ctx, tx, c, scope, i+1, values, mode, allowConflicts, seen)
+		if err != nil {
+			_asLogger.Errorf("Error importing satcom row %d: %v", i+1, err)
+			return BuildResponse500("Failed to import satcom data", err.Error())
+		}
+		report.Total++
+		switch result.Action {
+		case SATCOM_OP_CREATE:
+			report.Created++
+		case SATCOM_OP_UPDATE:
+			report.Updated++
+		case SATCOM_ACTION_UNCHANGED:
+			report.Unchanged++
+		default:
+			report.Failed++
+		}
+		report.Rows = append(report.Rows, result)
+	}
+
+	if report.Total == 0 {
+		return BuildResponse400("Import file contains no satcom entries")
+	}
+	if report.Failed > 0 {
+		return buildResponse(400, false, "Import rejected, no satcom data was changed", report)
+	}
+	if dryRun {
+		return BuildResponse200("Import validated successfully", report)
+	}
+	if err = tx.Commit(ctx); err != nil {
+		_asLogger.Errorf("Error committing satcom import: %v", err)
+		return BuildResponse500("Failed to import satcom data", err.Error())
+	}
+	s.recordAudit(ctx, c, AUDIT_SATCOM_IMPORT, map[string]interface{}{
+		"mode":      mode,
+		"created":   report.Created,
+		"updated":   report.Updated,
+		"unchanged": report.Unchanged,
+	})
+	return BuildResponse200("Satcom data imported successfully", report)
+}
+
+// importSatcomRow validates one row and writes it inside its own savepoint, so a failed
+// write is reported on the row and the remaining rows are still checked. Rows are written
+// in dry runs too, which lets later rows see conflicts with earlier ones.
+// The error is only set when the database could not be queried at all.
+func (s *RESTService) importSatcomRow(ctx context.Context, tx pgx.Tx, c *gin.Context, scope satcomScope, rowNo int, values map[string]string,
+	mode string, allowConflicts bool, seen map[string]int) (model.SatcomImportRowResult, error) {
+	result := model.SatcomImportRowResult{Row: rowNo, URL: values["url"], Action: SATCOM_ACTION_ERROR}
+	input, fieldErrs := satcomInputFromImport(values)
+	record, errs := s.validateSatcomInput(&input)
+	fieldErrs = append(fieldErrs, errs...)
+	fieldErrs = append(fieldErrs, assignSatcomCompany(ctx, auth.New(tx), scope, &input, "")...)
+	if len(fieldErrs) > 0 {
+		result.Errors = fieldErrs
+		return result, nil
+	}
+	key := companyKey(input.Company, normalizeSatcomURL(input.URL))
+	if prev, isFound := seen[key]; isFound {
+		result.Errors = []model.FieldError{{Field: "url", Message: fmt.Sprintf("duplicates row %d", prev)}}
+		return result, nil
+	}
+	seen[key] = rowNo
+
+	sp, err := tx.Begin(ctx)
+	if err != nil {
+		return result, err
+	}
+	defer sp.Rollback(ctx)
+	qtx := auth.New(sp)
+
+	existing, err := qtx.ListSatcomDataByUrl(ctx, auth.ListSatcomDataByUrlParams{Url: input.URL, Company: input.Company})
+	if err != nil {
+		return result, err
+	}
+	var current *auth.CommonSatcomDatum
+	switch {
+	case len(existing) > 1:
+		ids := make([]int32, 0, len(existing))
+		for _, data := range existing {
+			ids = append(ids, data.ID)
+		}
+		result.ConflictingIDs = ids
+		result.Errors = []model.FieldError{{Field: "url", Message: fmt.Sprintf("matches several entries %v; resolve the conflict first", ids)}}
+		return result, nil
+	case len(existing) == 1 && mode == SATCOM_IMPORT_INSERT:
+		result.ID = &existing[0].ID
+		result.Errors = []model.FieldError{{Field: "url", Message: fmt.Sprintf("is already used by entry %d; import with mode=upsert to update it", existing[0].ID)}}
+		return result, nil
+	case len(existing) == 1:
+		current = &existing[0]
+		result.ID = &current.ID
+		if satcomImportUnchanged(*current, input, record) {
+			result.Action = SATCOM_ACTION_UNCHANGED
+			return result, nil
+		}
+		if s.requiresApproval(*current) {
+			result.Errors = []model.FieldError{{Field: "url", Message: fmt.Sprintf("matches entry %d, whose changes need approval; change it with PUT /api/satcom/%d", current.ID, current.ID)}}
+			return result, nil
+		}
+	}
+	// Custom fields cannot be imported, so new entries fail when some are required
+	if current == nil {
+		if errs := s.validateCustomFields(map[string]interface{}{}); len(errs) > 0 {
+			result.Errors = errs
+			return result, nil
+		}
+	}
+
+	if !allowConflicts {
+		var excludeID pgtype.Int4
+		if current != nil {
+			excludeID = ConvertInt32ToPgInt4(current.ID)
+		}
+		conflicts, err := findSatcomConflicts(ctx, qtx, input.Company, record, input.URL, excludeID)
+		if err != nil {
+			return result, err
+		}
+		if len(conflicts) > 0 {
+			for _, conflict := range conflicts {
+				other := fmt.Sprintf("entry %d", conflict.ID)
+				if conflict.OtherCompany {
+					other = "an entry of another company"
+				} else {
+					result.ConflictingIDs = append(result.ConflictingIDs, conflict.ID)
+				}
+				for _, field := range conflict.Fields {
+					result.Errors = append(result.Errors, model.FieldError{
+						Field:   field,
+						Message: fmt.Sprintf("conflicts with %s; set allowConflicts=true to import anyway", other),
+					})
+				}
+			}
+			return result, nil
+		}
+	}
+
+	var id int32
+	var operation string
+	if current == nil {
+		id, err = qtx.CreateSatcomData(ctx, auth.CreateSatcomDataParams{
+			Company:    input.Company,
+			Category:   input.Category,
+			Type:       input.Type,
+			RecordedAt: record.RecordedAt,
+			DbPort:     record.DbPort,
+			UiPort:     record.UiPort,
+			Url:        input.URL,
+			Ip:         record.Ip,
+			Status:     input.Status,
+			Labels:     record.Labels,
+		})
+		operation = SATCOM_OP_CREATE
+	} else {
+		id, operation = current.ID, SATCOM_OP_UPDATE
+		var updated int64
+		updated, err = qtx.UpdateSatcomData(ctx, auth.UpdateSatcomDataParams{
+			Company:         input.Company,
+			Category:        input.Category,
+			Type:            input.Type,
+			RecordedAt:      record.RecordedAt,
+			DbPort:          record.DbPort,
+			UiPort:          record.UiPort,
+			Url:             input.URL,
+			Ip:              record.Ip,
+			Status:          input.Status,
+			Labels:          record.Labels,
+			ID:              id,
+			ExpectedVersion: current.Version,
+		})
+		if err == nil && updated == 0 {
+			err = fmt.Errorf("entry %d was changed concurrently", id)
+		}
+		if err == nil {
+			err = qtx.DeleteSatcomConversionIssues(ctx, id)
+		}
+	}
+	if err == nil {
+		err = s.recordSatcomVersion(ctx, qtx, c, id, operation, pgtype.Int4{})
+	}
+	if err == nil {
+		err = sp.Commit(ctx)
+	}
+	if err != nil {
+		result.Errors = []model.FieldError{{Field: "row", Message: err.Error()}}
+		return result, nil
+	}
+	result.ID = &id
+	result.Action = operation
+	return result, nil
+}
+
+// /api/satcom/export?format=csv|xlsx|json - stream the satcom inventory
+//
+// Accepts the same filters as /api/satcom. Entries are exported in id order; the JSON
+// format renders them like the list endpoint, honouring the API version.
+func (s *RESTService) exportSatcomData(c *gin.Context) {
+	format := strings.ToLower(c.DefaultQuery("format", "csv"))
+	contentType, isFound := satcomExportContentTypes[format]
+	version, err := requestAPIVersion(c)
+	if err == nil && !isFound {
+		err = fmt.Errorf("Unsupported export format, expected csv, xlsx or json")
+	}
+	var filter auth.CountSatcomDataParams
+	if err == nil {
+		filter, err = parseSatcomFilter(c)
+	}
+	if err != nil {
+		resp := BuildResponse400(err.Error())
+		c.JSON(resp.StatusCode, resp)
+		return
+	}
+	scope, errResp := s.satcomScope(c)
+	if errResp != nil {
+		c.JSON(errResp.StatusCode, errResp)
+		return
+	}
+	if err := scope.restrict(&filter.Company); err != nil {
+		resp := BuildResponse403(err.Error())
+		c.JSON(resp.StatusCode, resp)
+		return
+	}
+	ctx := context.Background()
+	qtx := auth.New(s.dbConn.GetPool())
+	params := auth.ListSatcomDataParams{
+		Search:      filter.Search,
+		Company:     filter.Company,
+		Category:    filter.Category,
+		Type:        filter.Type,
+		Status:      filter.Status,
+		Ip:          filter.Ip,
+		Url:         filter.Url,
+		LabelMatch:  filter.LabelMatch,
+		LabelExists: filter.LabelExists,
+		LabelAbsent: filter.LabelAbsent,
+		LabelNot:    filter.LabelNot,
+		LabelIn:     filter.LabelIn,
+		RowLimit:    SATCOM_EXPORT_CHUNK,
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="satcom.%s"`, format))
+	c.Status(200)
+
+	var writeChunk func([]auth.CommonSatcomDatum) error
+	var finish func() error
+	switch format {
+	case "csv":
+		writer := csv.NewWriter(c.Writer)
+		writer.Write(satcomExportHeader)
+		writeChunk = func(dataList []auth.CommonSatcomDatum) error {
+			for _, data := range dataList {
+				row := make([]string, 0, len(satcomExportHeader))
+				for _, value := range satcomExportRow(data) {
+					switch v := value.(type) {
+					case nil:
+						row = append(row, "")
+					case string:
+						row = append(row, util.SpreadsheetText(v))
+					default:
+						row = append(row, fmt.Sprint(v))
+					}
+				}
+				writer.Write(row)
+			}
+			writer.Flush()
+			return writer.Error()
+		}
+		finish = func() error { return nil }
+	case "xlsx":
+		writer, err := util.NewXLSXWriter(c.Writer, "satcom")
+		if err != nil {
+			_asLogger.Errorf("Error exporting satcom data: %v", err)
+			return
+		}
+		header := make([]interface{}, 0, len(satcomExportHeader))
+		for _, name := range satcomExportHeader {
+			header = append(header, name)
+		}
+		writer.WriteRow(header)
+		writeChunk = func(dataList []auth.CommonSatcomDatum) error {
+			for _, data := range dataList {
+				if err := writer.WriteRow(satcomExportRow(data)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		finish = writer.Close
+	case "json":
+		io.WriteString(c.Writer, "[")
+		first := true
+		writeChunk = func(dataList []auth.CommonSatcomDatum) error {
+			for _, data := range dataList {
+				item, err := json.Marshal(toSatcomResponse(data, version))
+				if err != nil {
+					return err
+				}
+				if !first {
+					io.WriteString(c.Writer, ",\n")
+				}
+				first = false
+				if _, err := c.Writer.Write(item); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		finish = func() error {
+			_, err := io.WriteString(c.Writer, "]\n")
+			return err
+		}
+	}
+
+	for {
+		dataList, err := qtx.ListSatcomData(ctx, params)
+		if err == nil {
+			err = writeChunk(dataList)
+		}
+		if err != nil {
+			// Headers are already sent, the truncated file is the only signal left
+			_asLogger.Errorf("Error exporting satcom data: %v", err)
+			return
+		}
+		c.Writer.Flush()
+		if len(dataList) < SATCOM_EXPORT_CHUNK {
+			break
+		}
+		params.AfterID = ConvertInt32ToPgInt4(dataList[len(dataList)-1].ID)
+	}
+	if err := finish(); err != nil {
+		_asLogger.Errorf("Error exporting satcom data: %v", err)
+	}
+	c.Writer.Flush()
+}
+
+// satcomExportRow returns the values of satcomExportHeader; unknown values are nil
+func satcomExportRow(data auth.CommonSatcomDatum) []interface{} {
+	row := []interface{}{data.ID, data.Company, data.Category, data.Type, nil, nil, nil, data.Url, nil, data.Status, formatLabels(decodeSatcomLabels(data.Labels))}
+	if data.RecordedAt.Valid {
+		row[4] = data.RecordedAt.Time.UTC().Format(time.RFC3339)
+	}
+	if data.DbPort.Valid {
+		row[5] = data.DbPort.Int32
+	}
+	if data.UiPort.Valid {
+		row[6] = data.UiPort.Int32
+	}
+	if data.Ip != nil {
+		row[8] = data.Ip.String()
+	}
+	return row
+}
+
+// parseColumnMapping reads the optional mapping parameter (query or form field), a JSON
+// object from source column to field name
+func parseColumnMapping(c *gin.Context) (map[string]string, error) {
+	raw := c.Query("mapping")
+	if raw == "" {
+		raw = c.PostForm("mapping")
+	}
+	mapping := make(map[string]string)
+	if raw == "" {
+		return mapping, nil
+	}
+	var parsed map[string]string
+	if err := json.Unmarshal([]byte(raw), &parsed); err != nil {
+		return nil, fmt.Errorf("mapping must be a JSON object of column names to fields")
+	}
+	fields := make(map[string]bool)
+	for _, field := range satcomImportColumns {
+		fields[field] = true
+	}
+	for column, field := range parsed {
+		if field != "" && !fields[field] {
+			return nil, fmt.Errorf("mapping of column %s refers to unknown field %s", column, field)
+		}
+		mapping[normalizeColumnName(column)] = field
+	}
+	return mapping, nil
+}
+
+// resolveImportColumns assigns a field to every header column, "" for ignored ones.
+// The mapping takes precedence over the recognised column names.
+func resolveImportColumns(header []string, mapping map[string]string) ([]string, model.SatcomImportReport, error) {
+	report := model.SatcomImportReport{Columns: make(map[string]string), IgnoredColumns: []string{}, Rows: []model.SatcomImportRowResult{}}
+	fields := make([]string, len(header))
+	used := make(map[string]string)
+	for i, name := range header {
+		key := normalizeColumnName(name)
+		field, isFound := mapping[key]
+		if !isFound {
+			field = satcomImportColumns[key]
+		}
+		if field == "" {
+			report.IgnoredColumns = append(report.IgnoredColumns, name)
+			continue
+		}
+		if prev, isFound := used[field]; isFound {
+			return nil, report, fmt.Errorf("columns %s and %s both map to %s", prev, name, field)
+		}
+		used[field] = name
+		fields[i] = field
+		report.Columns[name] = field
+	}
+	if len(used) == 0 {
+		return nil, report, fmt.Errorf("no column of the import file maps to a satcom field")
+	}
+	return fields, report, nil
+}
+
+func normalizeColumnName(name string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", " ", "", "-", "").Replace(strings.TrimSpace(name)))
+}
+
+// readCSVTable returns the records of a CSV document, header row first
+func readCSVTable(data []byte) ([][]string, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	return reader.ReadAll()
+}
+
+// readJSONTable turns a JSON array of objects into a table whose header is the sorted
+// union of their keys. Numbers and booleans are rendered as text.
+func readJSONTable(data []byte) ([][]string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var objects []map[string]interface{}
+	if err := decoder.Decode(&objects); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]bool)
+	for _, object := range objects {
+		for key := range object {
+			keys[key] = true
+		}
+	}
+	header := make([]string, 0, len(keys))
+	for key := range keys {
+		header = append(header, key)
+	}
+	sort.Strings(header)
+
+	table := [][]string{header}
+	for _, object := range objects {
+		row := make([]string, len(header))
+		for i, key := range header {
+			switch v := object[key].(type) {
+			case nil:
+			case string:
+				row[i] = v
+			case json.Number:
+				row[i] = v.String()
+			case bool:
+				row[i] = strconv.FormatBool(v)
+			default:
+				encoded, _ := json.Marshal(v)
+				row[i] = string(encoded)
+			}
+		}
+		table = append(table, row)
+	}
+	return table, nil
+}
+
+// convertSerialDates renders spreadsheet date serials of the date columns as text
+func convertSerialDates(values map[string]string) {
+	layouts := map[string]string{"recorded_at": time.RFC3339, "date": legacyDateFormat, "time": legacyTimeFormat}
+	for field, layout := range layouts {
+		serial, err := strconv.ParseFloat(values[field], 64)
+		if err != nil {
+			continue
+		}
+		values[field] = util.ExcelSerialToTime(serial).Format(layout)
+	}
+}
+
+// satcomInputFromImport builds the input of one import row; status accepts
+// true/false, 1/0, yes/no, up/down and active/inactive and defaults to false
+func satcomInputFromImport(values map[string]string) (model.SatcomDataInput, []model.FieldError) {
+	input := model.SatcomDataInput{
+		Company:    values["company"],
+		Category:   values["category"],
+		Type:       values["type"],
+		RecordedAt: values["recorded_at"],
+		Date:       values["date"],
+		Time:       values["time"],
+		DbPort:     model.FlexString(values["db_port"]),
+		UiPort:     model.FlexString(values["ui_port"]),
+		URL:        values["url"],
+		IP:         values["ip"],
+	}
+	var fieldErrs []model.FieldError
+	if v, isFound := values["labels"]; isFound {
+		labels, err := parseLabelList(v)
+		if err != nil {
+			fieldErrs = append(fieldErrs, model.FieldError{Field: "labels", Message: err.Error()})
+		}
+		input.Labels = labels
+	}
+	if status := strings.ToLower(values["status"]); status != "" {
+		switch status {
+		case "yes", "y", "up", "active":
+			input.Status = true
+		case "no", "n", "down", "inactive":
+			input.Status = false
+		default:
+			flag, err := strconv.ParseBool(status)
+			if err != nil {
+				fieldErrs = append(fieldErrs, model.FieldError{Field: "status", Message: "must be true or false"})
+			}
+			input.Status = flag
+		}
+	}
+	return input, fieldErrs
+}
+
+// satcomImportUnchanged reports whether an upserted row matches the stored entry
+func satcomImportUnchanged(current auth.CommonSatcomDatum, input model.SatcomDataInput, record satcomRecord) bool {
+	return current.Company == input.Company &&
+		current.Category == input.Category &&
+		current.Type == input.Type &&
+		current.Url == input.URL &&
+		current.Status == input.Status &&
+		current.RecordedAt.Valid && current.RecordedAt.Time.Equal(record.RecordedAt.Time) &&
+		current.DbPort == record.DbPort &&
+		current.UiPort == record.UiPort &&
+		current.Ip != nil && record.Ip != nil && *current.Ip == *record.Ip &&
+		(input.Labels == nil || reflect.DeepEqual(decodeSatcomLabels(current.Labels), input.Labels))
+}
@@ -0,0 +1,414 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+)
+
+// Label keys and values follow the Kubernetes rules: a name of at most 63 characters
+// with an optional DNS subdomain prefix (example.com/name); values may be empty
+var labelNamePattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._-]{0,61}[A-Za-z0-9])?$`)
+var labelPrefixPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9.-]{0,251}[a-z0-9])?$`)
+
+var labelSetPattern = regexp.MustCompile(`^(\S+)\s+(in|notin)\s*\((.*)\)$`)
+
+// Label selector operators
+const (
+	labelOpEquals    = "="
+	labelOpNotEquals = "!="
+	labelOpIn        = "in"
+	labelOpNotIn     = "notin"
+	labelOpExists    = "exists"
+	labelOpAbsent    = "!"
+)
+
+// labelRequirement is one comma separated term of a label selector
+type labelRequirement struct {
+	key      string
+	operator string
+	values   []string
+}
+
+// labelSelector selects satcom entries by their labels; every requirement must hold.
+// The syntax is the one of Kubernetes: env=prod, env==prod, env!=prod, env in (a,b),
+// env notin (a,b), env (the key exists) and !env (the key is absent).
+type labelSelector []labelRequirement
+
+func validateLabelKey(key string) error {
+	name := key
+	if i := strings.LastIndex(key, "/"); i >= 0 {
+		if !labelPrefixPattern.MatchString(key[:i]) {
+			return fmt.Errorf("label key %q must have a DNS subdomain prefix", key)
+		}
+		name = key[i+1:]
+	}
+	if !labelNamePattern.MatchString(name) {
+		return fmt.Errorf("label key %q must be at most 63 letters, digits, '-', '_' or '.', starting and ending with a letter or digit", key)
+	}
+	return nil
+}
+
+func validateLabelValue(value string) error {
+	if value != "" && !labelNamePattern.MatchString(value) {
+		return fmt.Errorf("label value %q must be at most 63 letters, digits, '-', '_' or '.', starting and ending with a letter or digit", value)
+	}
+	return nil
+}
+
+// validateLabels checks every key and value of a label set
+func validateLabels(labels map[string]string) []model.FieldError {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var fieldErrs []model.FieldError
+	for _, key := range keys {
+		if err := validateLabelKey(key); err != nil {
+			fieldErrs = append(fieldErrs, model.FieldError{Field: "labels", Message: err.Error()})
+		} else if err := validateLabelValue(labels[key]); err != nil {
+			fieldErrs = append(fieldErrs, model.FieldError{Field: "labels." + key, Message: err.Error()})
+		}
+	}
+	return fieldErrs
+}
+
+// parseLabelSelector parses a selector such as "env=prod,team!=infra,tier in (a,b)"
+func parseLabelSelector(str string) (labelSelector, error) {
+	var selector labelSelector
+	for _, term := range splitSelectorTerms(str) {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			return nil, fmt.Errorf("empty term in label selector %q", str)
+		}
+		var req labelRequirement
+		if m := labelSetPattern.FindStringSubmatch(term); m != nil {
+			req = labelRequirement{key: m[1], operator: m[2]}
+			for _, value := range strings.Split(m[3], ",") {
+				req.values = append(req.values, strings.TrimSpace(value))
+			}
+		} else if i := strings.Index(term, "!="); i >= 0 {
+			req = labelRequirement{key: term[:i], operator: labelOpNotEquals, values: []string{term[i+2:]}}
+		} else if i := strings.Index(term, "=="); i >= 0 {
+			req = labelRequirement{key: term[:i], operator: labelOpEquals, values: []string{term[i+2:]}}
+		} else if i := strings.Index(term, "="); i >= 0 {
+			req = labelRequirement{key: term[:i], operator: labelOpEquals, values: []string{term[i+1:]}}
+		} else if strings.HasPrefix(term, "!") {
+			req = labelRequirement{key: term[1:], operator: labelOpAbsent}
+		} else {
+			req = labelRequirement{key: term, operator: labelOpExists}
+		}
+		req.key = strings.TrimSpace(req.key)
+		if err := validateLabelKey(req.key); err != nil {
+			return nil, err
+		}
+		for i, value := range req.values {
+			req.values[i] = strings.TrimSpace(value)
+			if err := validateLabelValue(req.values[i]); err != nil {
+				return nil, err
+			}
+		}
+		selector = append(selector, req)
+	}
+	return selector, nil
+}
+
+// splitSelectorTerms splits at the commas that are not inside the parentheses of a set
+func splitSelectorTerms(str string) []string {
+	var terms []string
+	depth, start := 0, 0
+	for i, r := range str {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				terms = append(terms, str[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(terms, str[start:])
+}
+
+// matches evaluates the selector against a label set
+func (selector labelSelector) matches(labels map[string]string) bool {
+	for _, req := range selector {
+		value, isFound := labels[req.key]
+		switch req.operator {
+		case labelOpEquals, labelOpIn:
+			if !isFound || !containsString(req.values, value) {
+				return false
+			}
+		case labelOpNotEquals, labelOpNotIn:
+			if isFound && containsString(req.values, value) {
+				return false
+			}
+		case labelOpExists:
+			if !isFound {
+				return false
+			}
+		case labelOpAbsent:
+			if isFound {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func containsString(list []string, str string) bool {
+	for _, item := range list {
+		if item == str {
+			return true
+		}
+	}
+	return false
+}
+
+// applyTo sets the label filters of the list queries. Equality terms become one
+// containment document, which the GIN index on labels serves directly.
+func (selector labelSelector) applyTo(params *auth.CountSatcomDataParams) {
+	match := make(map[string]string)
+	var exclude []map[string]string
+	var choices [][]map[string]string
+	for _, req := range selector {
+		switch req.operator {
+		case labelOpEquals:
+			if prev, isFound := match[req.key]; isFound && prev != req.values[0] {
+				// Contradicts an earlier term, so nothing matches
+				choices = append(choices, []map[string]string{{req.key: req.values[0]}})
+				continue
+			}
+			match[req.key] = req.values[0]
+		case labelOpIn:
+			alternatives := make([]map[string]string, 0, len(req.values))
+			for _, value := range req.values {
+				alternatives = append(alternatives, map[string]string{req.key: value})
+			}
+			choices = append(choices, alternatives)
+		case labelOpNotEquals, labelOpNotIn:
+			for _, value := range req.values {
+				exclude = append(exclude, map[string]string{req.key: value})
+			}
+		case labelOpExists:
+			params.LabelExists = append(params.LabelExists, req.key)
+		case labelOpAbsent:
+			params.LabelAbsent = append(params.LabelAbsent, req.key)
+		}
+	}
+	// Marshalling string maps cannot fail
+	if len(match) > 0 {
+		params.LabelMatch, _ = json.Marshal(match)
+	}
+	if len(exclude) > 0 {
+		params.LabelNot, _ = json.Marshal(exclude)
+	}
+	if len(choices) > 0 {
+		params.LabelIn, _ = json.Marshal(choices)
+	}
+}
+
+// formatLabels renders labels as sorted key=value pairs, the import and export format
+func formatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for key, value := range labels {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// parseLabelList reads labels written by formatLabels, or a JSON object as found in
+// the JSON export
+func parseLabelList(str string) (map[string]string, error) {
+	labels := make(map[string]string)
+	if strings.HasPrefix(strings.TrimSpace(str), "{") {
+		if err := json.Unmarshal([]byte(str), &labels); err != nil {
+			return nil, fmt.Errorf("must be a JSON object of strings or key=value pairs")
+		}
+		return labels, nil
+	}
+	for _, pair := range strings.Split(str, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		key, value, isFound := strings.Cut(pair, "=")
+		if !isFound {
+			return nil, fmt.Errorf("%q is not a key=value pair", strings.TrimSpace(pair))
+		}
+		labels[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return labels, nil
+}
+
+// decodeSatcomLabels returns the labels column as a map, empty for rows without labels
+func decodeSatcomLabels(raw json.RawMessage) map[string]string {
+	labels := make(map[string]string)
+	if len(raw) > 0 {
+		json.Unmarshal(raw, &labels)
+	}
+	return labels
+}
+
+// decodeSatcomCustomFields returns the custom_fields column as a map
+func decodeSatcomCustomFields(raw json.RawMessage) map[string]interface{} {
+	fields := make(map[string]interface{})
+	if len(raw) > 0 {
+		json.Unmarshal(raw, &fields)
+	}
+	return fields
+}
+
+// initSatcomFields checks the custom field definitions of the deployment
+func (s *RESTService) initSatcomFields(defs []model.SatcomFieldDefinition) error {
+	names := make(map[string]bool)
+	for i, def := range defs {
+		if !satcomNamePattern.MatchString(def.Name) {
+			return fmt.Errorf("satcom field %d: name %q must be lowercase letters, digits, '.', '_' or '-'", i+1, def.Name)
+		}
+		if names[def.Name] {
+			return fmt.Errorf("satcom field %s is defined twice", def.Name)
+		}
+		names[def.Name] = true
+		switch def.Type {
+		case SATCOM_FIELD_STRING, SATCOM_FIELD_NUMBER, SATCOM_FIELD_INTEGER, SATCOM_FIELD_BOOLEAN, SATCOM_FIELD_DATE:
+		default:
+			return fmt.Errorf("satcom field %s has unknown type %q", def.Name, def.Type)
+		}
+		for _, allowed := range def.Allowed {
+			if err := checkCustomFieldType(def, allowed); err != nil {
+				return fmt.Errorf("satcom field %s: allowed value %v %v", def.Name, allowed, err)
+			}
+		}
+	}
+	s.satcomFields = defs
+	return nil
+}
+
+// validateCustomFields checks custom field values against the definitions of the
+// deployment. Without definitions any flat set of scalar values is accepted.
+func (s *RESTService) validateCustomFields(fields map[string]interface{}) []model.FieldError {
+	var fieldErrs []model.FieldError
+	defs := make(map[string]model.SatcomFieldDefinition, len(s.satcomFields))
+	for _, def := range s.satcomFields {
+		defs[def.Name] = def
+		if value, isFound := fields[def.Name]; def.Required && (!isFound || value == nil || value == "") {
+			fieldErrs = append(fieldErrs, model.FieldError{Field: "custom_fields." + def.Name, Message: "is required"})
+		}
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := fields[name]
+		def, isFound := defs[name]
+		switch {
+		case len(s.satcomFields) > 0 && !isFound:
+			fieldErrs = append(fieldErrs, model.FieldError{Field: "custom_fields." + name, Message: "is not a defined custom field"})
+		case value == nil:
+			// null clears an optional field
+		case !isFound:
+			switch value.(type) {
+			case map[string]interface{}, []interface{}:
+				fieldErrs = append(fieldErrs, model.FieldError{Field: "custom_fields." + name, Message: "must be a string, number or boolean"})
+			}
+		default:
+			if err := checkCustomFieldType(def, value); err != nil {
+				fieldErrs = append(fieldErrs, model.FieldError{Field: "custom_fields." + name, Message: err.Error()})
+			} else if len(def.Allowed) > 0 && !customFieldAllowed(def.Allowed, value) {
+				fieldErrs = append(fieldErrs, model.FieldError{Field: "custom_fields." + name, Message: fmt.Sprintf("must be one of %v", def.Allowed)})
+			}
+		}
+	}
+	return fieldErrs
+}
+
+func checkCustomFieldType(def model.SatcomFieldDefinition, value interface{}) error {
+	switch def.Type {
+	case SATCOM_FIELD_STRING:
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("must be a string")
+		}
+	case SATCOM_FIELD_NUMBER:
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("must be a number")
+		}
+	case SATCOM_FIELD_INTEGER:
+		if f, ok := value.(float64); !ok || f != math.Trunc(f) {
+			return fmt.Errorf("must be an integer")
+		}
+	case SATCOM_FIELD_BOOLEAN:
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("must be true or false")
+		}
+	case SATCOM_FIELD_DATE:
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("must be a date such as 2024-01-15")
+		}
+		if _, err := time.Parse(legacyDateFormat, str); err != nil {
+			return fmt.Errorf("must be a date such as 2024-01-15")
+		}
+	}
+	return nil
+}
+
+// customFieldAllowed compares by text, so 1 and 1.0 are the same number
+func customFieldAllowed(allowed []interface{}, value interface{}) bool {
+	for _, candidate := range allowed {
+		if fmt.Sprint(candidate) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// /api/satcom/fields - the custom field definitions of this deployment
+func (s *RESTService) getSatcomFields(c *gin.Context) APIResponse {
+	fields := s.satcomFields
+	if fields == nil {
+		fields = []model.SatcomFieldDefinition{}
+	}
+	return BuildResponse200("Satcom fields retrieved successfully", fields)
+}
+
+// /api/satcom/labels - every label key and value in use, with the number of entries
+func (s *RESTService) getSatcomLabels(c *gin.Context) APIResponse {
+	scope, errResp := s.satcomScope(c)
+	if errResp != nil {
+		return *errResp
+	}
+	company := optionalText(c.Query("company"))
+	if err := scope.restrict(&company); err != nil {
+		return BuildResponse403(err.Error())
+	}
+
+	ctx := context.Background()
+	qtx := auth.New(s.dbConn.GetPool())
+
+	rows, err := qtx.ListSatcomLabelValues(ctx, company)
+	if err != nil {
+		_asLogger.Errorf("Error getting satcom labels: %v", err)
+		return BuildResponse500("Failed to retrieve satcom labels", err.Error())
+	}
+	labels := make([]model.SatcomLabelValue, 0, len(rows))
+	for _, row := range rows {
+		labels = append(labels, model.SatcomLabelValue{Key: row.Key, Value: row.Value, Count: row.Count})
+	}
+	return BuildResponse200("Satcom labels retrieved successfully", labels)
+}
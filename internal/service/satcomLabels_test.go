@@ -0,0 +1,98 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitSelectorTerms(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     []string
+	}{
+		{"", []string{""}},
+		{"env=prod", []string{"env=prod"}},
+		{"env=prod,team!=infra", []string{"env=prod", "team!=infra"}},
+		{"tier in (gold,silver),env", []string{"tier in (gold,silver)", "env"}},
+		{"a notin (x, y), b in (z)", []string{"a notin (x, y)", " b in (z)"}},
+		{"env=prod,", []string{"env=prod", ""}},
+	}
+	for _, tt := range tests {
+		if got := splitSelectorTerms(tt.selector); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitSelectorTerms(%q) = %q, want %q", tt.selector, got, tt.want)
+		}
+	}
+}
+
+func TestParseLabelSelector(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     labelSelector
+		wantErr  bool
+	}{
+		{selector: "env=prod", want: labelSelector{{key: "env", operator: labelOpEquals, values: []string{"prod"}}}},
+		{selector: "env==prod", want: labelSelector{{key: "env", operator: labelOpEquals, values: []string{"prod"}}}},
+		{selector: " env != prod ", want: labelSelector{{key: "env", operator: labelOpNotEquals, values: []string{"prod"}}}},
+		{selector: "env!=", want: labelSelector{{key: "env", operator: labelOpNotEquals, values: []string{""}}}},
+		{selector: "tier in (gold, silver)", want: labelSelector{{key: "tier", operator: labelOpIn, values: []string{"gold", "silver"}}}},
+		{selector: "tier notin (bronze)", want: labelSelector{{key: "tier", operator: labelOpNotIn, values: []string{"bronze"}}}},
+		{selector: "example.com/team", want: labelSelector{{key: "example.com/team", operator: labelOpExists}}},
+		{selector: "!deprecated", want: labelSelector{{key: "deprecated", operator: labelOpAbsent}}},
+		{selector: "env=prod,tier in (a,b),!legacy", want: labelSelector{
+			{key: "env", operator: labelOpEquals, values: []string{"prod"}},
+			{key: "tier", operator: labelOpIn, values: []string{"a", "b"}},
+			{key: "legacy", operator: labelOpAbsent},
+		}},
+		{selector: "", wantErr: true},
+		{selector: "env=prod,", wantErr: true},
+		{selector: "=prod", wantErr: true},
+		{selector: "env=-prod", wantErr: true},
+		{selector: "tier in (a,b", wantErr: true},
+		{selector: "Example.com/team=x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			got, err := parseLabelSelector(tt.selector)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseLabelSelector(%q) = %+v, want an error", tt.selector, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseLabelSelector(%q): %v", tt.selector, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseLabelSelector(%q) = %+v, want %+v", tt.selector, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLabelSelectorMatches(t *testing.T) {
+	labels := map[string]string{"env": "prod", "tier": "gold"}
+	tests := []struct {
+		selector string
+		want     bool
+	}{
+		{"env=prod", true},
+		{"env=dev", false},
+		{"env!=dev", true},
+		{"team!=infra", true},
+		{"tier in (gold,silver)", true},
+		{"tier notin (gold)", false},
+		{"env,tier", true},
+		{"team", false},
+		{"!team", true},
+		{"env=prod,!tier", false},
+	}
+	for _, tt := range tests {
+		selector, err := parseLabelSelector(tt.selector)
+		if err != nil {
+			t.Fatalf("parseLabelSelector(%q): %v", tt.selector, err)
+		}
+		if got := selector.matches(labels); got != tt.want {
+			t.Errorf("%q matches %v = %v, want %v", tt.selector, labels, got, tt.want)
+		}
+	}
+}
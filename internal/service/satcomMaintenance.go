@@ -0,0 +1,366 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+)
+
+// cronSchedule is a parsed five-field cron expression (minute hour day-of-month month
+// day-of-week). Each field is a bit set of the values it matches.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// Like cron, day-of-month and day-of-week are combined with OR when both are restricted.
+	// A field is unrestricted when it matches every value, whether written as *, */1 or 1-31
+	domAny, dowAny bool
+}
+
+// Bit sets of every day of the month (1-31) and every day of the week (0-6)
+const (
+	cronDomAll uint64 = (1<<32 - 1) &^ 1
+	cronDowAll uint64 = 1<<7 - 1
+)
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var cronMonthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var cronDayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// parseCron parses a cron expression such as "30 2 * * sun" or "0 22 1-7 * *", or one
+// of the macros @yearly, @monthly, @weekly, @daily and @hourly
+func parseCron(expr string) (*cronSchedule, error) {
+	expr = strings.TrimSpace(strings.ToLower(expr))
+	if macro, isFound := cronMacros[expr]; isFound {
+		expr = macro
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("recurrence must have 5 fields (minute hour day month weekday)")
+	}
+	var err error
+	s := &cronSchedule{}
+	if s.minute, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("invalid minute: %v", err)
+	}
+	if s.hour, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("invalid hour: %v", err)
+	}
+	if s.dom, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("invalid day of month: %v", err)
+	}
+	if s.month, err = parseCronField(fields[3], 1, 12, cronMonthNames); err != nil {
+		return nil, fmt.Errorf("invalid month: %v", err)
+	}
+	if s.dow, err = parseCronField(fields[4], 0, 7, cronDayNames); err != nil {
+		return nil, fmt.Errorf("invalid day of week: %v", err)
+	}
+	// 7 is another name for Sunday
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domAny = s.dom&cronDomAll == cronDomAll
+	s.dowAny = s.dow&cronDowAll == cronDowAll
+	return s, nil
+}
+
+// parseCronField parses a comma separated list of *, n, a-b, */step and a-b/step terms
+func parseCronField(field string, min, max int, names map[string]int) (uint64, error) {
+	var bits uint64
+	for _, term := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(term, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step %q", stepPart)
+			}
+			step = n
+		}
+		low, high := min, max
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = cronValue(from, min, max, names); err != nil {
+				return 0, err
+			}
+			high = low
+			if isRange {
+				if high, err = cronValue(to, min, max, names); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				high = max
+			}
+			if high < low {
+				return 0, fmt.Errorf("invalid range %q", rangePart)
+			}
+		}
+		for v := low; v <= high; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func cronValue(str string, min, max int, names map[string]int) (int, error) {
+	if v, isFound := names[str]; isFound {
+		return v, nil
+	}
+	v, err := strconv.Atoi(str)
+	if err != nil || v < min || v > max {
+		return 0, fmt.Errorf("%q is not between %d and %d", str, min, max)
+	}
+	return v, nil
+}
+
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// next returns the first matching minute strictly after the given time, in its location.
+// Schedules that never match, such as February 30, give up after five years.
+func (s *cronSchedule) next(after time.Time) (time.Time, bool) {
+	loc := after.Location()
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		prev := t
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			// Added as a duration, so that repeated wall-clock hours on DST changes still advance
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t, true
+		}
+		if !t.After(prev) {
+			t = prev.Add(time.Minute)
+		}
+	}
+	return time.Time{}, false
+}
+
+// maintenanceWindow is a stored window with its selector, schedule and time zone parsed
+type maintenanceWindow struct {
+	row      auth.CommonSatcomMaintenanceWindow
+	selector labelSelector
+	schedule *cronSchedule
+	loc      *time.Location
+}
+
+func newMaintenanceWindow(row auth.CommonSatcomMaintenanceWindow) (*maintenanceWindow, error) {
+	w := &maintenanceWindow{row: row}
+	var err error
+	if w.loc, err = time.LoadLocation(row.Timezone); err != nil {
+		return nil, fmt.Errorf("invalid timezone %s", row.Timezone)
+	}
+	if row.LabelSelector.Valid {
+		if w.selector, err = parseLabelSelector(row.LabelSelector.String); err != nil {
+			return nil, err
+		}
+	}
+	if row.Recurrence.Valid {
+		if w.schedule, err = parseCron(row.Recurrence.String); err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// appliesTo reports whether the window covers an entry
+func (w *maintenanceWindow) appliesTo(data auth.CommonSatcomDatum) bool {
+	if w.row.SatcomID.Valid {
+		return w.row.SatcomID.Int32 == data.ID
+	}
+	return w.row.Company == data.Company && w.selector.matches(decodeSatcomLabels(data.Labels))
+}
+
+// occurrences lists the occurrences overlapping [from, to), at most limit of them
+func (w *maintenanceWindow) occurrences(from, to time.Time, limit int) []model.SatcomMaintenanceOccurrence {
+	start, end := w.row.StartsAt.Time, w.row.EndsAt.Time
+	occurrences := make([]model.SatcomMaintenanceOccurrence, 0)
+	if w.schedule == nil {
+		if start.Before(to) && end.After(from) {
+			occurrences = append(occurrences, w.occurrence(start, end))
+		}
+		return occurrences
+	}
+	duration := end.Sub(start)
+	// An occurrence starting at or before from - duration is over by from
+	after := from.Add(-duration)
+	if after.Before(start) {
+		after = start.Add(-time.Nanosecond)
+	}
+	for len(occurrences) < limit {
+		at, isFound := w.schedule.next(after.In(w.loc))
+		if !isFound || !at.Before(to) || (w.row.RecurUntil.Valid && at.After(w.row.RecurUntil.Time)) {
+			break
+		}
+		occurrences = append(occurrences, w.occurrence(at, at.Add(duration)))
+		after = at
+	}
+	return occurrences
+}
+
+func (w *maintenanceWindow) occurrence(start, end time.Time) model.SatcomMaintenanceOccurrence {
+	return model.SatcomMaintenanceOccurrence{
+		WindowID: w.row.ID,
+		StartsAt: start.UTC(),
+		EndsAt:   end.UTC(),
+		Reason:   w.row.Reason,
+	}
+}
+
+// satcomMaintenance holds the maintenance windows of one company, or of all companies
+type satcomMaintenance struct {
+	windows []*maintenanceWindow
+}
+
+// loadSatcomMaintenance loads the windows of a company, or every window when company is NULL
+func loadSatcomMaintenance(ctx context.Context, qtx *auth.Queries, company pgtype.Text) (*satcomMaintenance, error) {
+	rows, err := qtx.ListSatcomMaintenanceWindows(ctx, company)
+	if err != nil {
+		return nil, err
+	}
+	m := &satcomMaintenance{}
+	for _, row := range rows {
+		w, err := newMaintenanceWindow(row)
+		if err != nil {
+			_asLogger.Errorf("Skipping maintenance window %d: %v", row.ID, err)
+			continue
+		}
+		m.windows = append(m.windows, w)
+	}
+	return m, nil
+}
+
+// active returns the open maintenance occurrence of an entry, the one ending last when
+// windows overlap, or nil
+func (m *satcomMaintenance) active(data auth.CommonSatcomDatum, now time.Time) *model.SatcomMaintenanceOccurrence {
+	var current *model.SatcomMaintenanceOccurrence
+	for _, w := range m.windows {
+		if !w.appliesTo(data) {
+			continue
+		}
+		for _, occurrence := range w.occurrences(now, now.Add(time.Nanosecond), 1) {
+			if current == nil || occurrence.EndsAt.After(current.EndsAt) {
+				occurrence := occurrence
+				current = &occurrence
+			}
+		}
+	}
+	return current
+}
+
+// upcoming lists the occurrences overlapping [from, to) by start time, each with the
+// entries its window covers
+func (m *satcomMaintenance) upcoming(entries []auth.CommonSatcomDatum, from, to time.Time) []model.UpcomingSatcomMaintenance {
+	upcoming := make([]model.UpcomingSatcomMaintenance, 0)
+	for _, w := range m.windows {
+		occurrences := w.occurrences(from, to, MAX_MAINTENANCE_OCCURRENCES)
+		if len(occurrences) == 0 {
+			continue
+		}
+		ids := make([]int32, 0)
+		for _, data := range entries {
+			if w.appliesTo(data) {
+				ids = append(ids, data.ID)
+			}
+		}
+		for _, occurrence := range occurrences {
+			upcoming = append(upcoming, model.UpcomingSatcomMaintenance{
+				SatcomMaintenanceOccurrence: occurrence,
+				Company:                     w.row.Company,
+				LabelSelector:               w.row.LabelSelector.String,
+				Recurrence:                  w.row.Recurrence.String,
+				SatcomIDs:                   ids,
+			})
+		}
+	}
+	sort.SliceStable(upcoming, func(i, j int) bool {
+		return upcoming[i].StartsAt.Before(upcoming[j].StartsAt)
+	})
+	return upcoming
+}
+
+// effectiveSatcomStatus is maintenance while a window is open, otherwise up or down
+func effectiveSatcomStatus(status bool, occurrence *model.SatcomMaintenanceOccurrence) string {
+	switch {
+	case occurrence != nil:
+		return SATCOM_STATUS_MAINTENANCE
+	case status:
+		return SATCOM_STATUS_UP
+	default:
+		return SATCOM_STATUS_DOWN
+	}
+}
+
+// withEffectiveStatus adds the effective status and open maintenance to a satcom response
+func withEffectiveStatus(response interface{}, data auth.CommonSatcomDatum, occurrence *model.SatcomMaintenanceOccurrence) interface{} {
+	effective := effectiveSatcomStatus(data.Status, occurrence)
+	switch r := response.(type) {
+	case model.SatcomDataResponse:
+		r.EffectiveStatus, r.Maintenance = effective, occurrence
+		return r
+	case model.SatcomDataResponseV2:
+		r.EffectiveStatus, r.Maintenance = effective, occurrence
+		return r
+	}
+	return response
+}
+
+func toSatcomMaintenanceWindow(row auth.CommonSatcomMaintenanceWindow) model.SatcomMaintenanceWindow {
+	w := model.SatcomMaintenanceWindow{
+		ID:            row.ID,
+		Company:       row.Company,
+		LabelSelector: row.LabelSelector.String,
+		StartsAt:      row.StartsAt.Time.UTC(),
+		EndsAt:        row.EndsAt.Time.UTC(),
+		Recurrence:    row.Recurrence.String,
+		Timezone:      row.Timezone,
+		Reason:        row.Reason,
+		CreatedAt:     row.CreatedAt.Time,
+	}
+	if row.SatcomID.Valid {
+		w.SatcomID = &row.SatcomID.Int32
+	}
+	if row.RecurUntil.Valid {
+		recurUntil := row.RecurUntil.Time.UTC()
+		w.RecurUntil = &recurUntil
+	}
+	if row.CreatedBy.Valid {
+		w.CreatedBy = &row.CreatedBy.Int32
+	}
+	return w
+}
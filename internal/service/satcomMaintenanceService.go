@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+)
+
+// /api/satcom/maintenance - schedule a maintenance window
+func (s *RESTService) createSatcomMaintenance(c *gin.Context) APIResponse {
+	scope, errResp := s.satcomScope(c)
+	if errResp != nil {
+		return *errResp
+	}
+	if errResp := scope.writeDenied(); errResp != nil {
+		return *errResp
+	}
+	var input model.SatcomMaintenanceInput
+	if !parseInput(c, &input) {
+		return BuildResponse400("Invalid input provided")
+	}
+
+	ctx := context.Background()
+	qtx := auth.New(s.dbConn.GetPool())
+	params, errs := buildSatcomMaintenance(ctx, qtx, scope, input)
+	if len(errs) > 0 {
+		return BuildValidationResponse(errs)
+	}
+	params.CreatedBy = s.currentUserID(c)
+	row, err := qtx.CreateSatcomMaintenanceWindow(ctx, params)
+	if err != nil {
+		_asLogger.Errorf("Error creating maintenance window: %v", err)
+		return BuildResponse500("Failed to create maintenance window", err.Error())
+	}
+	return BuildResponse200("Maintenance window created successfully", toSatcomMaintenanceWindow(row))
+}
+
+// /api/satcom/maintenance - the maintenance windows of the active company, optionally
+// only those covering the entry satcomId
+func (s *RESTService) listSatcomMaintenance(c *gin.Context) APIResponse {
+	scope, errResp := s.satcomScope(c)
+	if errResp != nil {
+		return *errResp
+	}
+	satcomID, err := parseOptionalSatcomID(c)
+	if err != nil {
+		return BuildResponse400(err.Error())
+	}
+
+	ctx := context.Background()
+	qtx := auth.New(s.dbConn.GetPool())
+	maintenance, err := loadSatcomMaintenance(ctx, qtx, scope.filter())
+	if err != nil {
+		_asLogger.Errorf("Error getting maintenance windows: %v", err)
+		return BuildResponse500("Failed to retrieve maintenance windows", err.Error())
+	}
+	var entry *auth.CommonSatcomDatum
+	if satcomID != nil {
+		data, err := qtx.GetSatcomDataById(ctx, *satcomID)
+		if err != nil || !scope.contains(data.Company) {
+			return BuildResponse404("Satcom data not found", false)
+		}
+		entry = &data
+	}
+	windows := make([]model.SatcomMaintenanceWindow, 0, len(maintenance.windows))
+	for _, w := range maintenance.windows {
+		if entry == nil || w.appliesTo(*entry) {
+			windows = append(windows, toSatcomMaintenanceWindow(w.row))
+		}
+	}
+	return BuildResponse200("Maintenance windows retrieved successfully", windows)
+}
+
+// /api/satcom/maintenance/:id - one maintenance window
+func (s *RESTService) getSatcomMaintenance(c *gin.Context) APIResponse {
+	row, _, errResp := s.authorizeSatcomMaintenance(c, false)
+	if errResp != nil {
+		return *errResp
+	}
+	return BuildResponse200("Maintenance window retrieved successfully", toSatcomMaintenanceWindow(row))
+}
+
+// /api/satcom/maintenance/:id - reschedule a maintenance window
+func (s *RESTService) updateSatcomMaintenance(c *gin.Context) APIResponse {
+	row, scope, errResp := s.authorizeSatcomMaintenance(c, true)
+	if errResp != nil {
+		return *errResp
+	}
+	var input model.SatcomMaintenanceInput
+	if !parseInput(c, &input) {
+		return BuildResponse400("Invalid input provided")
+	}
+
+	ctx := context.Background()
+	qtx := auth.New(s.dbConn.GetPool())
+	params, errs := buildSatcomMaintenance(ctx, qtx, scope, input)
+	if len(errs) > 0 {
+		return BuildValidationResponse(errs)
+	}
+	updated, err := qtx.UpdateSatcomMaintenanceWindow(ctx, auth.UpdateSatcomMaintenanceWindowParams{
+		ID:            row.ID,
+		Company:       params.Company,
+		SatcomID:      params.SatcomID,
+		LabelSelector: params.LabelSelector,
+		StartsAt:      params.StartsAt,
+		EndsAt:        params.EndsAt,
+		Recurrence:    params.Recurrence,
+		RecurUntil:    params.RecurUntil,
+		Timezone:      params.Timezone,
+		Reason:        params.Reason,
+	})
+	if err != nil {
+		_asLogger.Errorf("Error updating maintenance window %d: %v", row.ID, err)
+		return BuildResponse500("Failed to update maintenance window", err.Error())
+	}
+	return BuildResponse200("Maintenance window updated successfully", toSatcomMaintenanceWindow(updated))
+}
+
+// /api/satcom/maintenance/:id - cancel a maintenance window, ending it when it is open
+func (s *RESTService) deleteSatcomMaintenance(c *gin.Context) APIResponse {
+	row, _, errResp := s.authorizeSatcomMaintenance(c, true)
+	if errResp != nil {
+		return *errResp
+	}
+	if _, err := auth.New(s.dbConn.GetPool()).DeleteSatcomMaintenanceWindow(context.Background(), row.ID); err != nil {
+		_asLogger.Errorf("Error deleting maintenance window %d: %v", row.ID, err)
+		return BuildResponse500("Failed to delete maintenance window", err.Error())
+	}
+	return BuildResponse200("Maintenance window deleted successfully", nil)
+}
+
+// /api/satcom/maintenance/upcoming - occurrences of maintenance windows between from and
+// to (RFC 3339; default now and 30 days later) by start time, for the calendar
+func (s *RESTService) getUpcomingSatcomMaintenance(c *gin.Context) APIResponse {
+	scope, errResp := s.satcomScope(c)
+	if errResp != nil {
+		return *errResp
+	}
+	from, to, err := parseMaintenanceRange(c)
+	if err != nil {
+		return BuildResponse400(err.Error())
+	}
+	satcomID, err := parseOptionalSatcomID(c)
+	if err != nil {
+		return BuildResponse400(err.Error())
+	}
+
+	ctx := context.Background()
+	qtx := auth.New(s.dbConn.GetPool())
+	maintenance, err := loadSatcomMaintenance(ctx, qtx, scope.filter())
+	if err != nil {
+		_asLogger.Errorf("Error getting maintenance windows: %v", err)
+		return BuildResponse500("Failed to retrieve maintenance windows", err.Error())
+	}
+	dataList, err := qtx.GetAllSatcomData(ctx)
+	if err != nil {
+		_asLogger.Errorf("Error getting satcom data: %v", err)
+		return BuildResponse500("Failed to retrieve maintenance windows", err.Error())
+	}
+	entries := make([]auth.CommonSatcomDatum, 0, len(dataList))
+	for _, data := range dataList {
+		if scope.contains(data.Company) {
+			entries = append(entries, data)
+		}
+	}
+
+	upcoming := maintenance.upcoming(entries, from, to)
+	if satcomID != nil {
+		selected := make([]model.UpcomingSatcomMaintenance, 0)
+		for _, occurrence := range upcoming {
+			for _, id := range occurrence.SatcomIDs {
+				if id == *satcomID {
+					selected = append(selected, occurrence)
+					break
+				}
+			}
+		}
+		upcoming = selected
+	}
+	return BuildResponse200("Upcoming maintenance retrieved successfully", upcoming)
+}
+
+// authorizeSatcomMaintenance loads the window in the :id path parameter; windows of
+// other companies answer 404
+func (s *RESTService) authorizeSatcomMaintenance(c *gin.Context, write bool) (auth.CommonSatcomMaintenanceWindow, satcomScope, *APIResponse) {
+	var row auth.CommonSatcomMaintenanceWindow
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		resp := BuildResponse400("Invalid ID format")
+		return row, satcomScope{}, &resp
+	}
+	scope, errResp := s.satcomScope(c)
+	if errResp != nil {
+		return row, scope, errResp
+	}
+	if write {
+		if errResp := scope.writeDenied(); errResp != nil {
+			return row, scope, errResp
+		}
+	}
+	row, err = auth.New(s.dbConn.GetPool()).GetSatcomMaintenanceWindow(context.Background(), int32(id))
+	if err == pgx.ErrNoRows || (err == nil && !scope.contains(row.Company)) {
+		resp := BuildResponse404("Maintenance window not found", false)
+		return row, scope, &resp
+	}
+	if err != nil {
+		_asLogger.Errorf("Error getting maintenance window %d: %v", id, err)
+		resp := BuildResponse500("Failed to retrieve maintenance window", err.Error())
+		return row, scope, &resp
+	}
+	return row, scope, nil
+}
+
+// buildSatcomMaintenance validates a window and resolves its company: the company of
+// the entry, or for label selectors the given company, by default the active one
+func buildSatcomMaintenance(ctx context.Context, qtx *auth.Queries, scope satcomScope, input model.SatcomMaintenanceInput) (auth.CreateSatcomMaintenanceWindowParams, []model.FieldError) {
+	var errs []model.FieldError
+	input.LabelSelector = strings.TrimSpace(input.LabelSelector)
+	input.Company = strings.TrimSpace(input.Company)
+	params := auth.CreateSatcomMaintenanceWindowParams{
+		StartsAt: pgtype.Timestamptz{Time: input.StartsAt, Valid: true},
+		EndsAt:   pgtype.Timestamptz{Time: input.EndsAt, Valid: true},
+		Timezone: strings.TrimSpace(input.Timezone),
+		Reason:   strings.TrimSpace(input.Reason),
+	}
+
+	switch {
+	case (input.SatcomID == nil) == (input.LabelSelector == ""):
+		errs = append(errs, model.FieldError{Field: "satcom_id", Message: "exactly one of satcom_id and label_selector is required"})
+	case input.SatcomID != nil:
+		data, err := qtx.GetSatcomDataById(ctx, *input.SatcomID)
+		if err != nil || !scope.contains(data.Company) {
+			errs = append(errs, model.FieldError{Field: "satcom_id", Message: "is not a satcom entry"})
+		} else if input.Company != "" && input.Company != data.Company {
+			errs = append(errs, model.FieldError{Field: "company", Message: fmt.Sprintf("must be the company of the entry, %s", data.Company)})
+		} else {
+			params.SatcomID = ConvertInt32ToPgInt4(data.ID)
+			params.Company = data.Company
+		}
+	default:
+		if _, err := parseLabelSelector(input.LabelSelector); err != nil {
+			errs = append(errs, model.FieldError{Field: "label_selector", Message: err.Error()})
+		}
+		params.LabelSelector = getSQLString(input.LabelSelector)
+		params.Company = input.Company
+		if params.Company == "" && !scope.all {
+			params.Company = scope.company
+		}
+		if params.Company == "" {
+			errs = append(errs, model.FieldError{Field: "company", Message: "is required with label_selector"})
+		} else if !scope.contains(params.Company) {
+			errs = append(errs, model.FieldError{Field: "company", Message: fmt.Sprintf("must be your active company %s", scope.company)})
+		} else if _, err := qtx.GetCompanyByName(ctx, params.Company); err != nil {
+			errs = append(errs, model.FieldError{Field: "company", Message: "is not a registered company"})
+		}
+	}
+
+	if !input.EndsAt.After(input.StartsAt) {
+		errs = append(errs, model.FieldError{Field: "ends_at", Message: "must be after starts_at"})
+	}
+	if params.Timezone == "" {
+		params.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(params.Timezone); err != nil {
+		errs = append(errs, model.FieldError{Field: "timezone", Message: "must be an IANA time zone such as Europe/Berlin"})
+	}
+	if recurrence := strings.TrimSpace(input.Recurrence); recurrence != "" {
+		if _, err := parseCron(recurrence); err != nil {
+			errs = append(errs, model.FieldError{Field: "recurrence", Message: err.Error()})
+		}
+		params.Recurrence = getSQLString(recurrence)
+	}
+	if input.RecurUntil != nil {
+		if !params.Recurrence.Valid {
+			errs = append(errs, model.FieldError{Field: "recur_until", Message: "requires a recurrence"})
+		} else if input.RecurUntil.Before(input.StartsAt) {
+			errs = append(errs, model.FieldError{Field: "recur_until", Message: "must not be before starts_at"})
+		}
+		params.RecurUntil = pgtype.Timestamptz{Time: *input.RecurUntil, Valid: true}
+	}
+	return params, errs
+}
+
+// parseOptionalSatcomID reads the satcomId query parameter, nil when absent
+func parseOptionalSatcomID(c *gin.Context) (*int32, error) {
+	str := c.Query("satcomId")
+	if str == "" {
+		return nil, nil
+	}
+	id, err := strconv.ParseInt(str, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("satcomId must be a number")
+	}
+	satcomID := int32(id)
+	return &satcomID, nil
+}
+
+// parseMaintenanceRange reads the from and to parameters of the upcoming-windows calendar
+func parseMaintenanceRange(c *gin.Context) (time.Time, time.Time, error) {
+	from := time.Now()
+	if str := strings.TrimSpace(c.Query("from")); str != "" {
+		t, err := time.Parse(time.RFC3339, str)
+		if err != nil {
+			return from, from, fmt.Errorf("from must be an RFC 3339 timestamp")
+		}
+		from = t
+	}
+	to := from.Add(DEFAULT_MAINTENANCE_HORIZON)
+	if str := strings.TrimSpace(c.Query("to")); str != "" {
+		t, err := time.Parse(time.RFC3339, str)
+		if err != nil {
+			return from, to, fmt.Errorf("to must be an RFC 3339 timestamp")
+		}
+		to = t
+	}
+	if !to.After(from) {
+		return from, to, fmt.Errorf("to must be after from")
+	}
+	if to.Sub(from) > MAX_MAINTENANCE_HORIZON {
+		return from, to, fmt.Errorf("from and to may be at most 366 days apart")
+	}
+	return from, to, nil
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func cronBits(values ...int) uint64 {
+	var bits uint64
+	for _, v := range values {
+		bits |= 1 << uint(v)
+	}
+	return bits
+}
+
+func cronBitRange(low, high, step int) uint64 {
+	var bits uint64
+	for v := low; v <= high; v += step {
+		bits |= 1 << uint(v)
+	}
+	return bits
+}
+
+func TestParseCronField(t *testing.T) {
+	tests := []struct {
+		field    string
+		min, max int
+		names    map[string]int
+		want     uint64
+	}{
+		{"*", 0, 59, nil, cronBitRange(0, 59, 1)},
+		{"5", 0, 59, nil, cronBits(5)},
+		{"1,15,30", 0, 59, nil, cronBits(1, 15, 30)},
+		{"10-14", 0, 59, nil, cronBitRange(10, 14, 1)},
+		{"*/15", 0, 59, nil, cronBits(0, 15, 30, 45)},
+		{"10-30/10", 0, 59, nil, cronBits(10, 20, 30)},
+		// n/step runs from n to the end of the range
+		{"50/4", 0, 59, nil, cronBits(50, 54, 58)},
+		{"1-5,20-22", 1, 31, nil, cronBits(1, 2, 3, 4, 5, 20, 21, 22)},
+		{"jan", 1, 12, cronMonthNames, cronBits(1)},
+		{"jun-aug", 1, 12, cronMonthNames, cronBits(6, 7, 8)},
+		{"mon-fri", 0, 7, cronDayNames, cronBitRange(1, 5, 1)},
+		{"sun,sat", 0, 7, cronDayNames, cronBits(0, 6)},
+		{"*/2", 0, 7, cronDayNames, cronBits(0, 2, 4, 6)},
+	}
+	for _, tc := range tests {
+		got, err := parseCronField(tc.field, tc.min, tc.max, tc.names)
+		if err != nil {
+			t.Errorf("parseCronField(%q): %v", tc.field, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("parseCronField(%q) = %b, want %b", tc.field, got, tc.want)
+		}
+	}
+
+	invalid := []struct {
+		field    string
+		min, max int
+	}{
+		{"60", 0, 59},
+		{"0", 1, 31},
+		{"-1", 0, 59},
+		{"5-1", 0, 59},
+		{"*/0", 0, 59},
+		{"*/x", 0, 59},
+		{"abc", 0, 59},
+		{"1,,2", 0, 59},
+		{"", 0, 59},
+	}
+	for _, tc := range invalid {
+		if _, err := parseCronField(tc.field, tc.min, tc.max, nil); err == nil {
+			t.Errorf("parseCronField(%q) did not fail", tc.field)
+		}
+	}
+}
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		expr           string
+		domAny, dowAny bool
+	}{
+		{"0 0 * * *", true, true},
+		{"@daily", true, true},
+		{"@weekly", true, false},
+		{"@monthly", false, true},
+		{"0 22 1-7 * *", false, true},
+		{"30 2 * * sun", true, false},
+		// Fields covering their whole range are unrestricted however they are written
+		{"0 0 */1 * *", true, true},
+		{"0 0 1-31 * */1", true, true},
+		{"0 0 * * 0-6", true, true},
+		{"0 0 * * 1-7", true, true},
+		{"0 0 * * mon-sat", true, false},
+		{"0 0 1-30 * 1-6", false, false},
+	}
+	for _, tc := range tests {
+		s, err := parseCron(tc.expr)
+		if err != nil {
+			t.Errorf("parseCron(%q): %v", tc.expr, err)
+			continue
+		}
+		if s.domAny != tc.domAny || s.dowAny != tc.dowAny {
+			t.Errorf("parseCron(%q) domAny %t dowAny %t, want %t %t", tc.expr, s.domAny, s.dowAny, tc.domAny, tc.dowAny)
+		}
+	}
+
+	s, err := parseCron("0 0 * * 7")
+	if err != nil || s.dow&1 == 0 {
+		t.Errorf("7 must match Sunday: %b, %v", s.dow, err)
+	}
+	for _, expr := range []string{"", "* * * *", "* * * * * *", "60 * * * *", "* 24 * * *", "* * 32 * *", "* * * 13 *", "* * * * 8", "@often"} {
+		if _, err := parseCron(expr); err == nil {
+			t.Errorf("parseCron(%q) did not fail", expr)
+		}
+	}
+}
+
+func TestCronDayMatches(t *testing.T) {
+	// 2026-06-01 is a Monday
+	monday1 := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	monday8 := time.Date(2026, 6, 8, 0, 0, 0, 0, time.UTC)
+	tuesday2 := time.Date(2026, 6, 2, 0, 0, 0, 0, time.UTC)
+	friday12 := time.Date(2026, 6, 12, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		day  time.Time
+		want bool
+	}{
+		{"0 0 * * *", friday12, true},
+		{"0 0 1 * *", monday1, true},
+		{"0 0 1 * *", tuesday2, false},
+		{"0 0 * * mon", monday8, true},
+		{"0 0 * * mon", tuesday2, false},
+		// Both restricted: either one matching is enough
+		{"0 0 1 * fri", monday1, true},
+		{"0 0 1 * fri", friday12, true},
+		{"0 0 1 * fri", tuesday2, false},
+		// A full-range day of month does not turn on the OR rule
+		{"0 0 */1 * mon", tuesday2, false},
+		{"0 0 1-31 * mon", monday8, true},
+		{"0 0 2 * 0-6", tuesday2, true},
+		{"0 0 2 * 0-6", monday1, false},
+	}
+	for _, tc := range tests {
+		s, err := parseCron(tc.expr)
+		if err != nil {
+			t.Fatalf("parseCron(%q): %v", tc.expr, err)
+		}
+		if got := s.dayMatches(tc.day); got != tc.want {
+			t.Errorf("%q on %s = %t, want %t", tc.expr, tc.day.Format("Mon Jan 2"), got, tc.want)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	utc := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name  string
+		expr  string
+		after time.Time
+		want  time.Time
+	}{
+		{"next minute", "* * * * *", utc(2026, 6, 1, 10, 0), utc(2026, 6, 1, 10, 1)},
+		{"strictly after", "30 2 * * *", utc(2026, 6, 1, 2, 30), utc(2026, 6, 2, 2, 30)},
+		{"seconds are dropped", "30 2 * * *", utc(2026, 6, 1, 2, 29).Add(59 * time.Second), utc(2026, 6, 1, 2, 30)},
+		{"step", "*/20 * * * *", utc(2026, 6, 1, 10, 41), utc(2026, 6, 1, 11, 0)},
+		{"weekday name", "30 2 * * sun", utc(2026, 6, 1, 0, 0), utc(2026, 6, 7, 2, 30)},
+		{"month rollover", "0 0 1 * *", utc(2026, 1, 31, 12, 0), utc(2026, 2, 1, 0, 0)},
+		{"year rollover", "0 0 1 jan *", utc(2026, 12, 31, 23, 59), utc(2027, 1, 1, 0, 0)},
+		{"short month is skipped", "0 0 31 * *", utc(2026, 4, 1, 0, 0), utc(2026, 5, 31, 0, 0)},
+		{"leap day", "0 0 29 feb *", utc(2026, 3, 1, 0, 0), utc(2028, 2, 29, 0, 0)},
+		{"dom or dow", "0 0 13 * fri", utc(2026, 6, 1, 0, 0), utc(2026, 6, 5, 0, 0)},
+		{"full-range dom with dow", "0 0 1-31 * fri", utc(2026, 6, 1, 0, 0), utc(2026, 6, 5, 0, 0)},
+		{"full-range dow with dom", "0 0 13 * */1", utc(2026, 6, 1, 0, 0), utc(2026, 6, 13, 0, 0)},
+		// 02:30 does not exist on 2026-03-08 in New York, so that day has no occurrence
+		{"spring forward", "30 2 * * *", time.Date(2026, 3, 7, 12, 0, 0, 0, newYork), time.Date(2026, 3, 9, 2, 30, 0, 0, newYork)},
+		{"after spring forward", "30 3 * * *", time.Date(2026, 3, 8, 0, 0, 0, 0, newYork), time.Date(2026, 3, 8, 3, 30, 0, 0, newYork)},
+		// 01:30 happens twice on 2026-11-01 in New York, first in EDT and then in EST
+		{"fall back", "30 1 * * *", time.Date(2026, 11, 1, 0, 0, 0, 0, newYork), utc(2026, 11, 1, 5, 30)},
+		{"repeated hour", "30 1 * * *", utc(2026, 11, 1, 5, 30).In(newYork), utc(2026, 11, 1, 6, 30)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := parseCron(tc.expr)
+			if err != nil {
+				t.Fatalf("parseCron(%q): %v", tc.expr, err)
+			}
+			got, ok := s.next(tc.after)
+			if !ok || !got.Equal(tc.want) {
+				t.Errorf("next(%s) = %s, %t, want %s", tc.after, got, ok, tc.want)
+			}
+			if ok && got.Location() != tc.after.Location() {
+				t.Errorf("next returned a time in %s, want %s", got.Location(), tc.after.Location())
+			}
+		})
+	}
+
+	s, err := parseCron("0 0 30 feb *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := s.next(utc(2026, 1, 1, 0, 0)); ok {
+		t.Errorf("February 30 matched %s", got)
+	}
+}
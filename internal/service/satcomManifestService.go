@@ -0,0 +1,422 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+	"github.com/rest/api/internal/util"
+)
+
+var satcomNamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9._-]{0,61}[a-z0-9])?$`)
+
+var errSatcomChanged = errors.New("satcom data was changed concurrently")
+
+// satcomPlanStep is one plan action together with what is needed to carry it out
+type satcomPlanStep struct {
+	action  model.SatcomPlanAction
+	input   model.SatcomDataInput
+	record  satcomRecord
+	current *auth.CommonSatcomDatum
+	id      int32
+}
+
+// /api/satcom/apply - converge the satcom inventory to a YAML or JSON manifest
+//
+// Entries are matched by company and name; the first time, an unnamed entry of the company
+// with the same url is adopted instead of creating a duplicate. Entries without a company
+// belong to the caller's active company, and only the caller's companies are planned. Query parameters: dryRun=true computes the plan
+// and rolls it back, prune=true moves entries missing from the manifest to the recycle bin
+// (SUPER_ADMIN only), allowConflicts=true skips the conflict check of the result.
+// The whole plan is applied in one transaction, attributed to the caller.
+func (s *RESTService) applySatcomManifest(c *gin.Context) APIResponse {
+	scope, errResp := s.satcomScope(c)
+	if errResp != nil {
+		return *errResp
+	}
+	if errResp := scope.writeDenied(); errResp != nil {
+		return *errResp
+	}
+	dryRun, _ := strconv.ParseBool(c.Query("dryRun"))
+	prune, _ := strconv.ParseBool(c.Query("prune"))
+	allowConflicts, _ := strconv.ParseBool(c.Query("allowConflicts"))
+	if prune && !dryRun && !s.hasRole(c, ROLE_SUPER_ADMIN) {
+		return BuildResponse403("Only super admins can prune the satcom inventory")
+	}
+
+	data, format, err := readUpload(c)
+	if err != nil {
+		return BuildResponse400(err.Error())
+	}
+	manifest, err := parseSatcomManifest(data, format)
+	if err != nil {
+		return BuildResponse400(fmt.Sprintf("Unable to parse manifest: %v", err))
+	}
+	if len(manifest.Entries) == 0 {
+		return BuildResponse400("Manifest contains no entries")
+	}
+
+	ctx := context.Background()
+	tx, err := s.dbConn.GetPool().Begin(ctx)
+	if err != nil {
+		_asLogger.Errorf("Error starting transaction: %v", err)
+		return BuildResponse500("Failed to apply manifest", err.Error())
+	}
+	defer tx.Rollback(ctx)
+	qtx := auth.New(tx)
+
+	inventory, err := qtx.GetAllSatcomData(ctx)
+	if err != nil {
+		_asLogger.Errorf("Error getting satcom data: %v", err)
+		return BuildResponse500("Failed to apply manifest", err.Error())
+	}
+	current := make([]auth.CommonSatcomDatum, 0, len(inventory))
+	for _, data := range inventory {
+		if scope.contains(data.Company) {
+			current = append(current, data)
+		}
+	}
+	steps := s.planSatcomManifest(ctx, qtx, scope, manifest.Entries, current, prune)
+	if plan := summarizeSatcomPlan(steps, dryRun, prune); plan.Failed > 0 {
+		return buildResponse(400, false, "Manifest rejected, no satcom data was changed", plan)
+	}
+
+	if err := s.applySatcomPlan(ctx, qtx, c, steps); err != nil {
+		if errors.Is(err, errSatcomChanged) {
+			return BuildResponse412("Satcom data was changed concurrently; retry the apply")
+		}
+		_asLogger.Errorf("Error applying satcom manifest: %v", err)
+		return BuildResponse500("Failed to apply manifest", err.Error())
+	}
+	if !allowConflicts {
+		if err := checkSatcomPlanConflicts(ctx, qtx, steps); err != nil {
+			_asLogger.Errorf("Error checking satcom conflicts: %v", err)
+			return BuildResponse500("Failed to apply manifest", err.Error())
+		}
+	}
+	if dryRun {
+		// Ids of entries created by the rolled back transaction mean nothing
+		for _, step := range steps {
+			if step.action.Action == SATCOM_OP_CREATE {
+				step.action.ID = nil
+			}
+		}
+	}
+	plan := summarizeSatcomPlan(steps, dryRun, prune)
+	if plan.Failed > 0 {
+		return buildResponse(409, false, "Manifest conflicts with existing entries; set allowConflicts=true to apply anyway", plan)
+	}
+	if dryRun {
+		return BuildResponse200("Plan computed successfully", plan)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		_asLogger.Errorf("Error committing satcom manifest: %v", err)
+		return BuildResponse500("Failed to apply manifest", err.Error())
+	}
+	s.recordAudit(ctx, c, AUDIT_SATCOM_APPLY, map[string]interface{}{
+		"prune":   prune,
+		"created": plan.Create,
+		"updated": plan.Update,
+		"deleted": plan.Delete,
+	})
+	return BuildResponse200("Manifest applied successfully", plan)
+}
+
+// parseSatcomManifest decodes a YAML (the default) or JSON manifest; unknown fields are
+// rejected so that typos do not silently drop values
+func parseSatcomManifest(data []byte, format string) (model.SatcomManifest, error) {
+	var manifest model.SatcomManifest
+	switch format {
+	case "", "yaml", "yml":
+		converted, err := util.YAMLToJSON(data)
+		if err != nil {
+			return manifest, err
+		}
+		data = converted
+	case "json":
+	default:
+		return manifest, fmt.Errorf("unsupported format %s, expected yaml or json", format)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&manifest)
+	return manifest, err
+}
+
+// planSatcomManifest matches the manifest entries with the stored inventory. The status
+// of existing entries belongs to the prober and is neither compared nor changed. Labels and
+// custom fields left out of an entry are declared empty. Names and urls are matched
+// within the company of the entry.
+func (s *RESTService) planSatcomManifest(ctx context.Context, qtx *auth.Queries, scope satcomScope, entries []model.SatcomManifestEntry, current []auth.CommonSatcomDatum, prune bool) []*satcomPlanStep {
+	byName := make(map[string]*auth.CommonSatcomDatum)
+	unnamedByURL := make(map[string][]*auth.CommonSatcomDatum)
+	for i := range current {
+		data := &current[i]
+		if data.Name.Valid {
+			byName[companyKey(data.Company, data.Name.String)] = data
+		} else {
+			key := companyKey(data.Company, normalizeSatcomURL(data.Url))
+			unnamedByURL[key] = append(unnamedByURL[key], data)
+		}
+	}
+
+	claimed := make(map[int32]bool)
+	names := make(map[string]int)
+	steps := make([]*satcomPlanStep, 0, len(entries))
+	for i, entry := range entries {
+		step := &satcomPlanStep{
+			action: model.SatcomPlanAction{Entry: i + 1, Name: entry.Name},
+			input:  entry.SatcomDataInput,
+		}
+		steps = append(steps, step)
+		if step.input.Labels == nil {
+			step.input.Labels = map[string]string{}
+		}
+		if step.input.CustomFields == nil {
+			step.input.CustomFields = map[string]interface{}{}
+		}
+
+		fieldErrs := assignSatcomCompany(ctx, qtx, scope, &step.input, "")
+		nameKey := companyKey(step.input.Company, entry.Name)
+		if entry.Name == "" {
+			fieldErrs = append(fieldErrs, model.FieldError{Field: "name", Message: "is required"})
+		} else if !satcomNamePattern.MatchString(entry.Name) {
+			fieldErrs = append(fieldErrs, model.FieldError{Field: "name", Message: "must be at most 63 lowercase letters, digits, '.', '_' or '-'"})
+		} else if prev, isFound := names[nameKey]; isFound {
+			fieldErrs = append(fieldErrs, model.FieldError{Field: "name", Message: fmt.Sprintf("duplicates entry %d", prev)})
+		}
+		names[nameKey] = i + 1
+		step.current = byName[nameKey]
+		if step.current != nil {
+			claimed[step.current.ID] = true
+			step.action.ID = &step.current.ID
+		}
+
+		record, errs := s.validateSatcomInput(&step.input)
+		if fieldErrs = append(fieldErrs, errs...); len(fieldErrs) > 0 {
+			step.action.Action = SATCOM_ACTION_ERROR
+			step.action.Errors = fieldErrs
+			continue
+		}
+		step.record = record
+
+		if step.current == nil {
+			candidates := unnamedByURL[companyKey(step.input.Company, normalizeSatcomURL(step.input.URL))]
+			if len(candidates) == 1 && !claimed[candidates[0].ID] {
+				step.current = candidates[0]
+				step.action.Adopted = true
+				claimed[step.current.ID] = true
+				step.action.ID = &step.current.ID
+			}
+		}
+		if step.current == nil {
+			step.action.Action = SATCOM_OP_CREATE
+			continue
+		}
+		step.id = step.current.ID
+		step.input.Status = step.current.Status
+		step.action.Changes = satcomChanges(*step.current, step.desired())
+		if len(step.action.Changes) == 0 {
+			step.action.Action = SATCOM_ACTION_UNCHANGED
+		} else if s.requiresApproval(*step.current) {
+			step.action.Action = SATCOM_ACTION_ERROR
+			step.action.Errors = []model.FieldError{{Field: "name", Message: fmt.Sprintf("entry %d needs approval to change; change it with PUT /api/satcom/%d", step.id, step.id)}}
+		} else {
+			step.action.Action = SATCOM_OP_UPDATE
+		}
+	}
+
+	for i := range current {
+		data := &current[i]
+		if claimed[data.ID] {
+			continue
+		}
+		step := &satcomPlanStep{current: data, id: data.ID}
+		step.action = model.SatcomPlanAction{Action: SATCOM_ACTION_UNMANAGED, Name: data.Name.String, ID: &data.ID}
+		if prune && s.requiresApproval(*data) {
+			step.action.Action = SATCOM_ACTION_ERROR
+			step.action.Errors = []model.FieldError{{Field: "name", Message: fmt.Sprintf("entry %d needs approval to delete; delete it with DELETE /api/satcom/%d", data.ID, data.ID)}}
+		} else if prune {
+			step.action.Action = SATCOM_OP_DELETE
+		}
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+// companyKey qualifies a name or url with its company, the scope in which they are unique
+func companyKey(company, key string) string {
+	return company + "\x00" + key
+}
+
+// desired returns the stored entry with the values of the manifest entry applied
+func (step *satcomPlanStep) desired() auth.CommonSatcomDatum {
+	data := *step.current
+	data.Name = pgtype.Text{String: step.action.Name, Valid: true}
+	data.Company = step.input.Company
+	data.Category = step.input.Category
+	data.Type = step.input.Type
+	data.RecordedAt = step.record.RecordedAt
+	data.DbPort = step.record.DbPort
+	data.UiPort = step.record.UiPort
+	data.Url = step.input.URL
+	data.Ip = step.record.Ip
+	data.Status = step.input.Status
+	data.Labels = step.record.Labels
+	data.CustomFields = step.record.CustomFields
+	return data
+}
+
+// satcomChanges lists the fields that differ between two versions of an entry
+func satcomChanges(from, to auth.CommonSatcomDatum) []model.SatcomFieldChange {
+	from.RecordedAt.Time = from.RecordedAt.Time.UTC()
+	to.RecordedAt.Time = to.RecordedAt.Time.UTC()
+	// Marshalling sqlc rows and decoding the result cannot fail
+	before, _ := json.Marshal(from)
+	after, _ := json.Marshal(to)
+	changes, _ := diffSnapshots(before, after)
+	return changes
+}
+
+// applySatcomPlan carries out the plan, deletes first so that their names and addresses
+// are free for the updates and creations that follow
+func (s *RESTService) applySatcomPlan(ctx context.Context, qtx *auth.Queries, c *gin.Context, steps []*satcomPlanStep) error {
+	for _, operation := range []string{SATCOM_OP_DELETE, SATCOM_OP_UPDATE, SATCOM_OP_CREATE} {
+		for _, step := range steps {
+			if step.action.Action != operation {
+				continue
+			}
+			var changed int64
+			var err error
+			switch operation {
+			case SATCOM_OP_DELETE:
+				changed, err = qtx.SoftDeleteSatcomData(ctx, auth.SoftDeleteSatcomDataParams{
+					ID:        step.id,
+					DeletedBy: s.currentUserID(c),
+					Version:   step.current.Version,
+				})
+			case SATCOM_OP_UPDATE:
+				changed, err = qtx.UpdateSatcomData(ctx, auth.UpdateSatcomDataParams{
+					Company:         step.input.Company,
+					Category:        step.input.Category,
+					Type:            step.input.Type,
+					RecordedAt:      step.record.RecordedAt,
+					DbPort:          step.record.DbPort,
+					UiPort:          step.record.UiPort,
+					Url:             step.input.URL,
+					Ip:              step.record.Ip,
+					Status:          step.input.Status,
+					Name:            pgtype.Text{String: step.action.Name, Valid: true},
+					Labels:          step.record.Labels,
+					CustomFields:    step.record.CustomFields,
+					ID:              step.id,
+					ExpectedVersion: step.current.Version,
+				})
+				if err == nil && changed > 0 {
+					err = qtx.DeleteSatcomConversionIssues(ctx, step.id)
+				}
+			case SATCOM_OP_CREATE:
+				step.id, err = qtx.CreateSatcomData(ctx, auth.CreateSatcomDataParams{
+					Company:      step.input.Company,
+					Category:     step.input.Category,
+					Type:         step.input.Type,
+					RecordedAt:   step.record.RecordedAt,
+					DbPort:       step.record.DbPort,
+					UiPort:       step.record.UiPort,
+					Url:          step.input.URL,
+					Ip:           step.record.Ip,
+					Status:       step.input.Status,
+					Name:         pgtype.Text{String: step.action.Name, Valid: true},
+					Labels:       step.record.Labels,
+					CustomFields: step.record.CustomFields,
+				})
+				changed = 1
+				step.action.ID = &step.id
+			}
+			if err != nil {
+				return fmt.Errorf("%s %s: %w", operation, step.label(), err)
+			}
+			if changed == 0 {
+				return fmt.Errorf("%s %s: %w", operation, step.label(), errSatcomChanged)
+			}
+			if err := s.recordSatcomVersion(ctx, qtx, c, step.id, operation, pgtype.Int4{}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkSatcomPlanConflicts checks every created or updated entry against the inventory the
+// plan produced and records the clashes on its action
+func checkSatcomPlanConflicts(ctx context.Context, qtx *auth.Queries, steps []*satcomPlanStep) error {
+	labels := make(map[int32]string)
+	for _, step := range steps {
+		if step.id != 0 {
+			labels[step.id] = step.label()
+		}
+	}
+	for _, step := range steps {
+		if step.action.Action != SATCOM_OP_CREATE && step.action.Action != SATCOM_OP_UPDATE {
+			continue
+		}
+		conflicts, err := findSatcomConflicts(ctx, qtx, step.input.Company, step.record, step.input.URL, ConvertInt32ToPgInt4(step.id))
+		if err != nil {
+			return err
+		}
+		for _, conflict := range conflicts {
+			label, isFound := labels[conflict.ID]
+			if conflict.OtherCompany {
+				label = "an entry of another company"
+			} else {
+				if !isFound {
+					label = fmt.Sprintf("entry %d", conflict.ID)
+				}
+				step.action.ConflictingIDs = append(step.action.ConflictingIDs, conflict.ID)
+			}
+			for _, field := range conflict.Fields {
+				step.action.Errors = append(step.action.Errors, model.FieldError{Field: field, Message: "conflicts with " + label})
+			}
+		}
+	}
+	return nil
+}
+
+// label names the entry of a step in messages
+func (step *satcomPlanStep) label() string {
+	if step.action.Name != "" {
+		return step.action.Name
+	}
+	return fmt.Sprintf("entry %d", step.id)
+}
+
+func summarizeSatcomPlan(steps []*satcomPlanStep, dryRun, prune bool) model.SatcomPlan {
+	plan := model.SatcomPlan{DryRun: dryRun, Prune: prune, Actions: make([]model.SatcomPlanAction, 0, len(steps))}
+	for _, step := range steps {
+		switch step.action.Action {
+		case SATCOM_OP_CREATE:
+			plan.Create++
+		case SATCOM_OP_UPDATE:
+			plan.Update++
+		case SATCOM_OP_DELETE:
+			plan.Delete++
+		case SATCOM_ACTION_UNCHANGED:
+			plan.Unchanged++
+		case SATCOM_ACTION_UNMANAGED:
+			plan.Unmanaged++
+		}
+		if len(step.action.Errors) > 0 {
+			plan.Failed++
+		}
+		plan.Actions = append(plan.Actions, step.action)
+	}
+	return plan
+}
@@ -0,0 +1,539 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+)
+
+// satcomMentionPattern finds "@name" and "@user@example.com" mentions that do not
+// continue a word, so plain email addresses in a note are not mentions
+var satcomMentionPattern = regexp.MustCompile(`(?:^|[^\w@.])@([\w.-]+(?:@[\w-]+(?:\.[\w-]+)+)?)`)
+
+var satcomTimelineTypes = map[string]bool{
+	SATCOM_TIMELINE_NOTE:   true,
+	SATCOM_TIMELINE_CHANGE: true,
+	SATCOM_TIMELINE_STATUS: true,
+	SATCOM_TIMELINE_PROBE:  true,
+}
+
+// /api/satcom/:id/notes - the note threads of an entry, pinned threads first and then the
+// most recent; resolved=true|false limits them to resolved or open threads
+func (s *RESTService) listSatcomNotes(c *gin.Context) APIResponse {
+	id, _, errResp := s.authorizeSatcomID(c, false)
+	if errResp != nil {
+		return *errResp
+	}
+	var resolved *bool
+	if v := c.Query("resolved"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return BuildResponse400("resolved must be true or false")
+		}
+		resolved = &b
+	}
+
+	rows, err := auth.New(s.dbConn.GetPool()).ListSatcomNotes(context.Background(), id)
+	if err != nil {
+		_asLogger.Errorf("Error listing notes of satcom data %d: %v", id, err)
+		return BuildResponse500("Failed to retrieve notes", err.Error())
+	}
+	// Rows come oldest first, so every thread starter precedes its replies
+	threads := make([]model.SatcomNote, 0)
+	index := make(map[int32]int)
+	for _, row := range rows {
+		note := toSatcomNote(row)
+		if note.ParentID == nil {
+			index[note.ID] = len(threads)
+			threads = append(threads, note)
+		} else if i, ok := index[*note.ParentID]; ok {
+			threads[i].Replies = append(threads[i].Replies, note)
+		}
+	}
+	result := make([]model.SatcomNote, 0, len(threads))
+	for _, thread := range threads {
+		if resolved == nil || thread.Resolved == *resolved {
+			result = append(result, thread)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Pinned != result[j].Pinned {
+			return result[i].Pinned
+		}
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+	return BuildResponse200("Notes retrieved successfully", result)
+}
+
+// /api/satcom/:id/notes - write a note or reply to a thread. Everyone who sees the entry
+// may write notes; mentioned users are emailed.
+func (s *RESTService) createSatcomNote(c *gin.Context) APIResponse {
+	id, _, errResp := s.authorizeSatcomID(c, false)
+	if errResp != nil {
+		return *errResp
+	}
+	var input model.SatcomNoteInput
+	if !parseInput(c, &input) {
+		return BuildResponse400("Invalid input provided")
+	}
+	if errs := validateSatcomNoteInput(&input); len(errs) > 0 {
+		return BuildValidationResponse(errs)
+	}
+
+	ctx := context.Background()
+	qtx := auth.New(s.dbConn.GetPool())
+	entry, err := qtx.GetSatcomDataById(ctx, id)
+	if err != nil {
+		return BuildResponse404("Satcom data not found", false)
+	}
+	var parentID pgtype.Int4
+	if input.ParentID != nil {
+		parent, err := qtx.GetSatcomNote(ctx, auth.GetSatcomNoteParams{ID: *input.ParentID, SatcomID: id})
+		if err != nil {
+			return BuildValidationResponse([]model.FieldError{{Field: "parent_id", Message: "parent note not found"}})
+		}
+		// Replies to replies join the thread of their parent
+		parentID = ConvertInt32ToPgInt4(parent.ID)
+		if parent.ParentID.Valid {
+			parentID = parent.ParentID
+		}
+	}
+	mentioned, err := resolveSatcomMentions(ctx, qtx, entry.Company, input.Body)
+	if err != nil {
+		_asLogger.Errorf("Error resolving mentions: %v", err)
+		return BuildResponse500("Failed to create note", err.Error())
+	}
+	var authorName string
+	if claims := s.currentClaims(c); claims != nil {
+		authorName = claims.UserName
+	}
+	row, err := qtx.CreateSatcomNote(ctx, auth.CreateSatcomNoteParams{
+		SatcomID:      id,
+		ParentID:      parentID,
+		Body:          input.Body,
+		Mentions:      mentionIDs(mentioned),
+		CreatedBy:     s.currentUserID(c),
+		CreatedByName: optionalText(authorName),
+	})
+	if err != nil {
+		_asLogger.Errorf("Error creating note of satcom data %d: %v", id, err)
+		return BuildResponse500("Failed to create note", err.Error())
+	}
+	note := toSatcomNote(row)
+	go s.notifySatcomNoteMentions(note, entry, mentioned)
+	return BuildResponse200("Note created successfully", note)
+}
+
+// /api/satcom/:id/notes/:noteId - edit the body of a note (author only). Users mentioned
+// for the first time are emailed.
+func (s *RESTService) updateSatcomNote(c *gin.Context) APIResponse {
+	id, _, errResp := s.authorizeSatcomID(c, false)
+	if errResp != nil {
+		return *errResp
+	}
+	var input model.SatcomNoteInput
+	if !parseInput(c, &input) {
+		return BuildResponse400("Invalid input provided")
+	}
+	if errs := validateSatcomNoteInput(&input); len(errs) > 0 {
+		return BuildValidationResponse(errs)
+	}
+
+	ctx := context.Background()
+	qtx := auth.New(s.dbConn.GetPool())
+	note, errResp := loadSatcomNote(ctx, qtx, c, id)
+	if errResp != nil {
+		return *errResp
+	}
+	if !s.isSatcomNoteAuthor(c, note) {
+		return BuildResponse403("Only the author can edit a note")
+	}
+	entry, err := qtx.GetSatcomDataById(ctx, id)
+	if err != nil {
+		return BuildResponse404("Satcom data not found", false)
+	}
+	mentioned, err := resolveSatcomMentions(ctx, qtx, entry.Company, input.Body)
+	if err != nil {
+		_asLogger.Errorf("Error resolving mentions: %v", err)
+		return BuildResponse500("Failed to update note", err.Error())
+	}
+	row, err := qtx.UpdateSatcomNoteBody(ctx, auth.UpdateSatcomNoteBodyParams{
+		ID:       note.ID,
+		SatcomID: id,
+		Body:     input.Body,
+		Mentions: mentionIDs(mentioned),
+	})
+	if err != nil {
+		_asLogger.Errorf("Error updating note %d: %v", note.ID, err)
+		return BuildResponse500("Failed to update note", err.Error())
+	}
+	notified := make(map[int32]bool, len(note.Mentions))
+	for _, userID := range note.Mentions {
+		notified[userID] = true
+	}
+	var added []auth.ListMentionableUsersRow
+	for _, user := range mentioned {
+		if !notified[user.UserID] {
+			added = append(added, user)
+		}
+	}
+	updated := toSatcomNote(row)
+	go s.notifySatcomNoteMentions(updated, entry, added)
+	return BuildResponse200("Note updated successfully", updated)
+}
+
+// /api/satcom/:id/notes/:noteId - delete a note with its replies (author or company ADMIN)
+func (s *RESTService) deleteSatcomNote(c *gin.Context) APIResponse {
+	id, scope, errResp := s.authorizeSatcomID(c, false)
+	if errResp != nil {
+		return *errResp
+	}
+	ctx := context.Background()
+	qtx := auth.New(s.dbConn.GetPool())
+	note, errResp := loadSatcomNote(ctx, qtx, c, id)
+	if errResp != nil {
+		return *errResp
+	}
+	if !s.isSatcomNoteAuthor(c, note) && !scope.all && scope.role != COMPANY_ROLE_ADMIN {
+		return BuildResponse403("Only the author or a company admin can delete a note")
+	}
+	if _, err := qtx.DeleteSatcomNote(ctx, auth.DeleteSatcomNoteParams{ID: note.ID, SatcomID: id}); err != nil {
+		_asLogger.Errorf("Error deleting note %d: %v", note.ID, err)
+		return BuildResponse500("Failed to delete note", err.Error())
+	}
+	return BuildResponse200("Note deleted successfully", nil)
+}
+
+// /api/satcom/:id/notes/:noteId/pin - pin a thread to the top of the list (EDITOR and above)
+func (s *RESTService) pinSatcomNote(c *gin.Context) APIResponse {
+	return s.setSatcomNoteState(c, "pin")
+}
+
+// /api/satcom/:id/notes/:noteId/unpin - unpin a thread
+func (s *RESTService) unpinSatcomNote(c *gin.Context) APIResponse {
+	return s.setSatcomNoteState(c, "unpin")
+}
+
+// /api/satcom/:id/notes/:noteId/resolve - mark a thread as resolved (author or EDITOR and above)
+func (s *RESTService) resolveSatcomNote(c *gin.Context) APIResponse {
+	return s.setSatcomNoteState(c, "resolve")
+}
+
+// /api/satcom/:id/notes/:noteId/reopen - open a resolved thread again
+func (s *RESTService) reopenSatcomNote(c *gin.Context) APIResponse {
+	return s.setSatcomNoteState(c, "reopen")
+}
+
+// setSatcomNoteState pins, unpins, resolves or reopens the first note of a thread
+func (s *RESTService) setSatcomNoteState(c *gin.Context, action string) APIResponse {
+	id, scope, errResp := s.authorizeSatcomID(c, false)
+	if errResp != nil {
+		return *errResp
+	}
+	ctx := context.Background()
+	qtx := auth.New(s.dbConn.GetPool())
+	note, errResp := loadSatcomNote(ctx, qtx, c, id)
+	if errResp != nil {
+		return *errResp
+	}
+	if note.ParentID.Valid {
+		return BuildResponse400("Only the first note of a thread can be pinned or resolved")
+	}
+
+	var row auth.CommonSatcomNote
+	var err error
+	switch action {
+	case "pin", "unpin":
+		if errResp := scope.writeDenied(); errResp != nil {
+			return *errResp
+		}
+		row, err = qtx.SetSatcomNotePinned(ctx, auth.SetSatcomNotePinnedParams{ID: note.ID, SatcomID: id, Pinned: action == "pin"})
+	default:
+		if !s.isSatcomNoteAuthor(c, note) && !scope.canWrite() {
+			return BuildResponse403("Only the author or an editor can resolve a thread")
+		}
+		params := auth.SetSatcomNoteResolvedParams{ID: note.ID, SatcomID: id, Resolved: action == "resolve"}
+		if params.Resolved {
+			params.ResolvedBy = s.currentUserID(c)
+			if claims := s.currentClaims(c); claims != nil {
+				params.ResolvedByName = optionalText(claims.UserName)
+			}
+		}
+		row, err = qtx.SetSatcomNoteResolved(ctx, params)
+	}
+	if err != nil {
+		_asLogger.Errorf("Error updating state of note %d: %v", note.ID, err)
+		return BuildResponse500("Failed to update note", err.Error())
+	}
+	return BuildResponse200("Note updated successfully", toSatcomNote(row))
+}
+
+// /api/satcom/:id/timeline - notes, changes, status changes and probe results of an entry
+// between from and to (RFC 3339; default the last 30 days) in chronological order.
+// types limits the kinds of events; of more than limit events the latest are returned.
+func (s *RESTService) getSatcomTimeline(c *gin.Context) APIResponse {
+	id, _, errResp := s.authorizeSatcomID(c, false)
+	if errResp != nil {
+		return *errResp
+	}
+	to := time.Now()
+	if str := strings.TrimSpace(c.Query("to")); str != "" {
+		t, err := time.Parse(time.RFC3339, str)
+		if err != nil {
+			return BuildResponse400("to must be an RFC 3339 timestamp")
+		}
+		to = t
+	}
+	from := to.Add(-DEFAULT_TIMELINE_RANGE)
+	if str := strings.TrimSpace(c.Query("from")); str != "" {
+		t, err := time.Parse(time.RFC3339, str)
+		if err != nil {
+			return BuildResponse400("from must be an RFC 3339 timestamp")
+		}
+		from = t
+	}
+	if !to.After(from) {
+		return BuildResponse400("to must be after from")
+	}
+	limit := DEFAULT_TIMELINE_LIMIT
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > MAX_TIMELINE_LIMIT {
+			return BuildResponse400(fmt.Sprintf("limit must be between 1 and %d", MAX_TIMELINE_LIMIT))
+		}
+		limit = n
+	}
+	types := satcomTimelineTypes
+	if v := strings.TrimSpace(c.Query("types")); v != "" {
+		types = make(map[string]bool)
+		for _, t := range strings.Split(v, ",") {
+			t = strings.ToLower(strings.TrimSpace(t))
+			if !satcomTimelineTypes[t] {
+				return BuildResponse400("types must be a list of note, change, status and probe")
+			}
+			types[t] = true
+		}
+	}
+
+	ctx := context.Background()
+	qtx := auth.New(s.dbConn.GetPool())
+	history, err := qtx.ListSatcomHistory(ctx, id)
+	if err != nil {
+		_asLogger.Errorf("Error getting history of satcom data %d: %v", id, err)
+		return BuildResponse500("Failed to retrieve timeline", err.Error())
+	}
+	if len(history) == 0 {
+		return BuildResponse404("Satcom data not found", false)
+	}
+	inRange := func(t pgtype.Timestamptz) bool {
+		return !t.Time.Before(from) && t.Time.Before(to)
+	}
+	events := make([]model.SatcomTimelineEvent, 0)
+
+	if types[SATCOM_TIMELINE_CHANGE] || types[SATCOM_TIMELINE_STATUS] {
+		// History comes newest first; every version is compared with the one before it
+		for i := len(history) - 1; i >= 0; i-- {
+			row := history[i]
+			eventType := SATCOM_TIMELINE_CHANGE
+			if row.Operation == SATCOM_OP_STATUS {
+				eventType = SATCOM_TIMELINE_STATUS
+			}
+			if !types[eventType] || !inRange(row.ChangedAt) {
+				continue
+			}
+			event := model.SatcomTimelineEvent{
+				Type:      eventType,
+				At:        row.ChangedAt.Time,
+				ActorName: row.ChangedByName.String,
+				Version:   &history[i].Version,
+				Operation: row.Operation,
+			}
+			if i+1 < len(history) {
+				if event.Changes, err = diffSnapshots(history[i+1].Snapshot, row.Snapshot); err != nil {
+					_asLogger.Errorf("Error comparing versions of satcom data %d: %v", id, err)
+					return BuildResponse500("Failed to retrieve timeline", err.Error())
+				}
+			}
+			events = append(events, event)
+		}
+	}
+	if types[SATCOM_TIMELINE_NOTE] {
+		notes, err := qtx.ListSatcomNotes(ctx, id)
+		if err != nil {
+			_asLogger.Errorf("Error listing notes of satcom data %d: %v", id, err)
+			return BuildResponse500("Failed to retrieve timeline", err.Error())
+		}
+		for _, row := range notes {
+			if !inRange(row.CreatedAt) {
+				continue
+			}
+			note := toSatcomNote(row)
+			events = append(events, model.SatcomTimelineEvent{
+				Type:      SATCOM_TIMELINE_NOTE,
+				At:        note.CreatedAt,
+				ActorName: note.CreatedByName,
+				Note:      &note,
+			})
+		}
+	}
+	if types[SATCOM_TIMELINE_PROBE] {
+		// Probes are by far the most frequent events; only the latest limit can be returned
+		probes, err := qtx.ListSatcomProbeResultsBetween(ctx, auth.ListSatcomProbeResultsBetweenParams{
+			SatcomID: id,
+			FromTime: pgtype.Timestamptz{Time: from, Valid: true},
+			ToTime:   pgtype.Timestamptz{Time: to, Valid: true},
+			RowLimit: int32(limit + 1),
+		})
+		if err != nil {
+			_asLogger.Errorf("Error getting probe results of satcom data %d: %v", id, err)
+			return BuildResponse500("Failed to retrieve timeline", err.Error())
+		}
+		for _, row := range probes {
+			probe := model.ProbeResult{
+				CheckType:  row.CheckType,
+				Target:     row.Target,
+				Success:    row.Success,
+				LatencyMs:  row.LatencyMs.Int32,
+				StatusCode: row.StatusCode.Int32,
+				Error:      row.Error.String,
+				ProbedAt:   row.ProbedAt.Time,
+			}
+			events = append(events, model.SatcomTimelineEvent{Type: SATCOM_TIMELINE_PROBE, At: probe.ProbedAt, Probe: &probe})
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].At.Before(events[j].At)
+	})
+	timeline := model.SatcomTimeline{SatcomID: id, From: from, To: to, Events: events}
+	if len(events) > limit {
+		timeline.Events = events[len(events)-limit:]
+		timeline.Truncated = true
+	}
+	return BuildResponse200("Timeline retrieved successfully", timeline)
+}
+
+// loadSatcomNote reads the note :noteId of the entry id
+func loadSatcomNote(ctx context.Context, qtx *auth.Queries, c *gin.Context, id int32) (auth.CommonSatcomNote, *APIResponse) {
+	noteID, err := strconv.ParseInt(c.Param("noteId"), 10, 32)
+	if err != nil {
+		resp := BuildResponse400("Invalid note ID format")
+		return auth.CommonSatcomNote{}, &resp
+	}
+	row, err := qtx.GetSatcomNote(ctx, auth.GetSatcomNoteParams{ID: int32(noteID), SatcomID: id})
+	if err == pgx.ErrNoRows {
+		resp := BuildResponse404("Note not found", false)
+		return row, &resp
+	}
+	if err != nil {
+		_asLogger.Errorf("Error getting note %d: %v", noteID, err)
+		resp := BuildResponse500("Failed to retrieve note", err.Error())
+		return row, &resp
+	}
+	return row, nil
+}
+
+func (s *RESTService) isSatcomNoteAuthor(c *gin.Context, note auth.CommonSatcomNote) bool {
+	userID := s.currentUserID(c)
+	return userID.Valid && note.CreatedBy.Valid && userID.Int32 == note.CreatedBy.Int32
+}
+
+func validateSatcomNoteInput(input *model.SatcomNoteInput) []model.FieldError {
+	input.Body = strings.TrimSpace(input.Body)
+	if input.Body == "" {
+		return []model.FieldError{{Field: "body", Message: "body is required"}}
+	}
+	if len(input.Body) > MAX_SATCOM_NOTE_LENGTH {
+		return []model.FieldError{{Field: "body", Message: fmt.Sprintf("body must be at most %d characters", MAX_SATCOM_NOTE_LENGTH)}}
+	}
+	return nil
+}
+
+// resolveSatcomMentions looks up the active users mentioned in body by user name or email
+// who can see the entries of company. Unknown names are left as plain text.
+func resolveSatcomMentions(ctx context.Context, qtx *auth.Queries, company string, body string) ([]auth.ListMentionableUsersRow, error) {
+	names := parseSatcomMentions(body)
+	if len(names) == 0 {
+		return nil, nil
+	}
+	return qtx.ListMentionableUsers(ctx, auth.ListMentionableUsersParams{Names: names, Company: company})
+}
+
+// parseSatcomMentions lists the mentioned names of a note in lower case, once each and
+// without the punctuation that ends a sentence
+func parseSatcomMentions(body string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, match := range satcomMentionPattern.FindAllStringSubmatch(body, -1) {
+		name := strings.ToLower(strings.TrimRight(match[1], ".-"))
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func mentionIDs(users []auth.ListMentionableUsersRow) []int32 {
+	ids := make([]int32, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.UserID)
+	}
+	return ids
+}
+
+// notifySatcomNoteMentions emails the users mentioned in a note, except its author
+func (s *RESTService) notifySatcomNoteMentions(note model.SatcomNote, entry auth.CommonSatcomDatum, users []auth.ListMentionableUsersRow) {
+	mailer := &SmtpService{}
+	for _, user := range users {
+		if note.CreatedBy != nil && *note.CreatedBy == user.UserID {
+			continue
+		}
+		if err := mailer.SendNoteMentionMail(user.Email, note, entry.Company, entry.Name.String); err != nil {
+			_asLogger.Errorf("Error sending mention in note %d to %s: %v", note.ID, user.Email, err)
+		}
+	}
+}
+
+func toSatcomNote(row auth.CommonSatcomNote) model.SatcomNote {
+	note := model.SatcomNote{
+		ID:             row.ID,
+		SatcomID:       row.SatcomID,
+		Body:           row.Body,
+		Mentions:       row.Mentions,
+		Pinned:         row.Pinned,
+		Resolved:       row.ResolvedAt.Valid,
+		ResolvedByName: row.ResolvedByName.String,
+		CreatedAt:      row.CreatedAt.Time,
+		CreatedByName:  row.CreatedByName.String,
+	}
+	if note.Mentions == nil {
+		note.Mentions = []int32{}
+	}
+	if row.ParentID.Valid {
+		note.ParentID = &row.ParentID.Int32
+	}
+	if row.ResolvedAt.Valid {
+		note.ResolvedAt = &row.ResolvedAt.Time
+	}
+	if row.ResolvedBy.Valid {
+		note.ResolvedBy = &row.ResolvedBy.Int32
+	}
+	if row.CreatedBy.Valid {
+		note.CreatedBy = &row.CreatedBy.Int32
+	}
+	if row.UpdatedAt.Valid {
+		note.UpdatedAt = &row.UpdatedAt.Time
+	}
+	return note
+}
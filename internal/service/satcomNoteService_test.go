@@ -0,0 +1,35 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSatcomMentions(t *testing.T) {
+	tests := []struct {
+		body string
+		want []string
+	}{
+		{"no mentions here", nil},
+		{"@alice please check", []string{"alice"}},
+		{"ping @Alice and @bob", []string{"alice", "bob"}},
+		{"thanks @alice.", []string{"alice"}},
+		{"@alice, @bob and @ALICE again", []string{"alice", "bob"}},
+		{"(@carol) and [@dave]", []string{"carol", "dave"}},
+		{"@j.doe... see above", []string{"j.doe"}},
+		{"@first.last-name", []string{"first.last-name"}},
+		{"cc @bob@example.com on this", []string{"bob@example.com"}},
+		{"@ops@mail.acme.example.", []string{"ops@mail.acme.example"}},
+		{"mail bob@example.com instead", nil},
+		{"a.@dave", nil},
+		{"@@eve", nil},
+		{"@x@y", []string{"x"}},
+		{"@", nil},
+		{"line one\n@frank on line two", []string{"frank"}},
+	}
+	for _, tt := range tests {
+		if got := parseSatcomMentions(tt.body); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseSatcomMentions(%q) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+)
+
+// Fields of SatcomDataInput that a merge patch may set or remove
+var satcomPatchFields = map[string]bool{
+	"company":       true,
+	"category":      true,
+	"type":          true,
+	"recorded_at":   true,
+	"date":          true,
+	"time":          true,
+	"db_port":       true,
+	"ui_port":       true,
+	"url":           true,
+	"ip":            true,
+	"status":        true,
+	"labels":        true,
+	"custom_fields": true,
+}
+
+// satcomETag is the entity tag of an entry; it changes with every new row version
+func satcomETag(data auth.CommonSatcomDatum) string {
+	return fmt.Sprintf(`"%d"`, data.Version)
+}
+
+// satcomRepresentationETag is the entity tag of the entry as GET returns it. Besides the row
+// version it covers the API version and the effective status with the open maintenance
+// window, which change the body without a new row version. It is weak, since the same
+// representation may be serialized differently.
+func satcomRepresentationETag(data auth.CommonSatcomDatum, apiVersion int, occurrence *model.SatcomMaintenanceOccurrence) string {
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%s", effectiveSatcomStatus(data.Status, occurrence))
+	if occurrence != nil {
+		fmt.Fprintf(h, "|%d|%d|%d|%s", occurrence.WindowID, occurrence.StartsAt.Unix(), occurrence.EndsAt.Unix(), occurrence.Reason)
+	}
+	return fmt.Sprintf(`W/"%d-v%d-%08x"`, data.Version, apiVersion, h.Sum32())
+}
+
+// satcomIfMatchFails is ifMatchFails for entries. Both the plain and the representation
+// entity tags are accepted, matched on the row version they carry.
+func satcomIfMatchFails(c *gin.Context, data auth.CommonSatcomDatum) bool {
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" || ifMatch == "*" {
+		return false
+	}
+	want := strconv.Itoa(int(data.Version))
+	for _, candidate := range strings.Split(ifMatch, ",") {
+		tag := strings.Trim(strings.TrimPrefix(strings.TrimSpace(candidate), "W/"), `"`)
+		if version, _, _ := strings.Cut(tag, "-"); version == want {
+			return false
+		}
+	}
+	return true
+}
+
+// ifNoneMatchHits returns true when the If-None-Match header matches etag (weak comparison)
+func ifNoneMatchHits(c *gin.Context, etag string) bool {
+	header := strings.TrimSpace(c.GetHeader("If-None-Match"))
+	if header == "" {
+		return false
+	}
+	if header == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeSatcomPatch applies a merge patch to the input document of the current row.
+// Patching only the legacy date or time keeps the other half from recorded_at. Labels and
+// custom fields merge key by key; null removes one key, or all of them.
+func mergeSatcomPatch(current auth.CommonSatcomDatum, patch map[string]interface{}) (model.SatcomDataInput, []model.FieldError) {
+	var input model.SatcomDataInput
+	var fieldErrs []model.FieldError
+	fields := make([]string, 0, len(patch))
+	for field := range patch {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		if !satcomPatchFields[field] {
+			fieldErrs = append(fieldErrs, model.FieldError{Field: field, Message: "is not a satcom field"})
+		}
+	}
+	if len(fieldErrs) > 0 {
+		return input, fieldErrs
+	}
+
+	doc := satcomInputDocument(current)
+	_, hasRecordedAt := patch["recorded_at"]
+	_, hasDate := patch["date"]
+	_, hasTime := patch["time"]
+	if (hasDate || hasTime) && !hasRecordedAt {
+		if current.RecordedAt.Valid {
+			doc["date"] = current.RecordedAt.Time.UTC().Format(legacyDateFormat)
+			doc["time"] = current.RecordedAt.Time.UTC().Format(legacyTimeFormat)
+		}
+		delete(doc, "recorded_at")
+	}
+	// Left out of the document unless patched, so the stored values are kept untouched
+	if _, isFound := patch["labels"]; isFound {
+		labels := make(map[string]interface{})
+		for key, value := range decodeSatcomLabels(current.Labels) {
+			labels[key] = value
+		}
+		doc["labels"] = labels
+	}
+	if _, isFound := patch["custom_fields"]; isFound {
+		doc["custom_fields"] = decodeSatcomCustomFields(current.CustomFields)
+	}
+
+	merged, err := json.Marshal(applyMergePatch(doc, patch))
+	if err != nil {
+		return input, []model.FieldError{{Message: err.Error()}}
+	}
+	if err := json.Unmarshal(merged, &input); err != nil {
+		if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+			return input, []model.FieldError{{Field: typeErr.Field, Message: fmt.Sprintf("must not be a %s", typeErr.Value)}}
+		}
+		return input, []model.FieldError{{Message: err.Error()}}
+	}
+	if value, isFound := patch["labels"]; isFound && value == nil {
+		input.Labels = map[string]string{}
+	}
+	if value, isFound := patch["custom_fields"]; isFound && value == nil {
+		input.CustomFields = map[string]interface{}{}
+	}
+	return input, nil
+}
+
+// satcomInputDocument renders a row in the SatcomDataInput format; NULL columns are left out
+func satcomInputDocument(data auth.CommonSatcomDatum) map[string]interface{} {
+	doc := map[string]interface{}{
+		"company":  data.Company,
+		"category": data.Category,
+		"type":     data.Type,
+		"url":      data.Url,
+		"status":   data.Status,
+	}
+	if data.RecordedAt.Valid {
+		doc["recorded_at"] = data.RecordedAt.Time.UTC().Format(time.RFC3339Nano)
+	}
+	if data.DbPort.Valid {
+		doc["db_port"] = data.DbPort.Int32
+	}
+	if data.UiPort.Valid {
+		doc["ui_port"] = data.UiPort.Int32
+	}
+	if data.Ip != nil {
+		doc["ip"] = data.Ip.String()
+	}
+	return doc
+}
+
+// applyMergePatch implements RFC 7396: null removes a member, objects merge recursively
+// and any other value replaces the target
+func applyMergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = make(map[string]interface{})
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = applyMergePatch(targetObj[key], value)
+	}
+	return targetObj
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+)
+
+func TestSatcomRepresentationETag(t *testing.T) {
+	data := auth.CommonSatcomDatum{Version: 7, Status: true}
+	window := &model.SatcomMaintenanceOccurrence{
+		WindowID: 3,
+		StartsAt: time.Date(2026, 6, 1, 22, 0, 0, 0, time.UTC),
+		EndsAt:   time.Date(2026, 6, 2, 2, 0, 0, 0, time.UTC),
+		Reason:   "patching",
+	}
+	base := satcomRepresentationETag(data, API_VERSION_1, nil)
+	if base != satcomRepresentationETag(data, API_VERSION_1, nil) {
+		t.Fatal("entity tag is not stable")
+	}
+	otherWindow := *window
+	otherWindow.EndsAt = otherWindow.EndsAt.Add(time.Hour)
+	newer := data
+	newer.Version++
+	for name, etag := range map[string]string{
+		"api version":     satcomRepresentationETag(data, API_VERSION_2, nil),
+		"maintenance":     satcomRepresentationETag(data, API_VERSION_1, window),
+		"extended window": satcomRepresentationETag(data, API_VERSION_1, &otherWindow),
+		"row version":     satcomRepresentationETag(newer, API_VERSION_1, nil),
+	} {
+		if etag == base {
+			t.Errorf("%s does not change the entity tag %s", name, base)
+		}
+	}
+}
+
+func TestSatcomIfMatchFails(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	data := auth.CommonSatcomDatum{Version: 7}
+	tests := []struct {
+		ifMatch string
+		want    bool
+	}{
+		{"", false},
+		{"*", false},
+		{`"7"`, false},
+		{satcomRepresentationETag(data, API_VERSION_2, nil), false},
+		{`"6", W/"7-v1-00000000"`, false},
+		{`"6"`, true},
+		{`W/"17-v1-00000000"`, true},
+	}
+	for _, tc := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPut, "/api/satcom/1", nil)
+		c.Request.Header.Set("If-Match", tc.ifMatch)
+		if got := satcomIfMatchFails(c, data); got != tc.want {
+			t.Errorf("If-Match %s: %t, want %t", tc.ifMatch, got, tc.want)
+		}
+	}
+}
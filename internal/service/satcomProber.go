@@ -0,0 +1,355 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+	"github.com/rest/api/internal/util"
+)
+
+// SatcomProber periodically checks the url, db port and ui port of every satcom
+// entry, records the results and derives the entry status from them
+type SatcomProber struct {
+	dbConn      *util.DBConnectionWrapper
+	interval    time.Duration
+	timeout     time.Duration
+	concurrency int
+	retention   time.Duration
+	client      *http.Client
+	denied      probeDenyList
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewSatcomProber applies the configuration defaults and builds a prober
+func NewSatcomProber(conf model.ProberConfig, dbConn *util.DBConnectionWrapper) (*SatcomProber, error) {
+	denied := conf.DeniedNetworks
+	if denied == nil {
+		denied = []string{PROBE_NETWORK_LINK_LOCAL}
+	}
+	deniedNets, err := newProbeDenyList(denied)
+	if err != nil {
+		return nil, err
+	}
+	p := &SatcomProber{
+		dbConn:      dbConn,
+		interval:    time.Duration(conf.IntervalSeconds) * time.Second,
+		timeout:     time.Duration(conf.TimeoutMs) * time.Millisecond,
+		concurrency: conf.Concurrency,
+		retention:   time.Duration(conf.RetentionDays) * 24 * time.Hour,
+		denied:      deniedNets,
+	}
+	if p.interval <= 0 {
+		p.interval = DEFAULT_PROBE_INTERVAL
+	}
+	if p.timeout <= 0 {
+		p.timeout = DEFAULT_PROBE_TIMEOUT
+	}
+	if p.concurrency <= 0 {
+		p.concurrency = DEFAULT_PROBE_CONCURRENCY
+	}
+	p.client = &http.Client{
+		Timeout: p.timeout,
+		Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: conf.InsecureSkipVerify},
+			DisableKeepAlives: true,
+			DialContext:       p.dialer().DialContext,
+		},
+		// A redirect still proves the endpoint is serving
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return p, nil
+}
+
+// dialer checks the resolved address of every connection, so neither a host name nor a
+// redirect can point the prober at a denied network
+func (p *SatcomProber) dialer() *net.Dialer {
+	return &net.Dialer{Timeout: p.timeout, Control: p.denied.control}
+}
+
+// Start runs a probing round immediately and then on every interval until Stop is called
+func (p *SatcomProber) Start() {
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			if err := p.RunOnce(context.Background()); err != nil {
+				_asLogger.Errorf("Satcom probing round failed: %v", err)
+			}
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	_asLogger.Infof("Satcom prober started (interval %s, timeout %s, concurrency %d)", p.interval, p.timeout, p.concurrency)
+}
+
+// Stop ends the background loop and waits for the current round to finish
+func (p *SatcomProber) Stop() {
+	if p.stop == nil {
+		return
+	}
+	close(p.stop)
+	<-p.done
+	p.stop = nil
+}
+
+// RunOnce probes every satcom entry once, at most concurrency entries at a time. With
+// several instances only the one holding the prober lock runs the round.
+func (p *SatcomProber) RunOnce(ctx context.Context) error {
+	// The advisory lock belongs to the session, so it is taken and released on one connection
+	conn, err := p.dbConn.GetPool().Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	leader, err := auth.New(conn).TryLockSatcomProber(ctx)
+	if err != nil {
+		return err
+	}
+	if !leader {
+		_asLogger.Debug("Skipping probing round, another instance holds the prober lock")
+		return nil
+	}
+	defer func() {
+		if _, err := auth.New(conn).UnlockSatcomProber(context.Background()); err != nil {
+			// Closing the session is the only other way to release the lock
+			_asLogger.Errorf("Error releasing the prober lock: %v", err)
+			conn.Conn().Close(context.Background())
+		}
+	}()
+
+	qtx := auth.New(p.dbConn.GetPool())
+	entries, err := qtx.GetAllSatcomData(ctx)
+	if err != nil {
+		return err
+	}
+
+	sem := make(chan struct{}, p.concurrency)
+	var wg sync.WaitGroup
+	for _, entry := range entries {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(entry auth.CommonSatcomDatum) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if _, err := p.ProbeAndStore(ctx, entry); err != nil {
+				_asLogger.Errorf("Error storing probe results of satcom data %d: %v", entry.ID, err)
+			}
+		}(entry)
+	}
+	wg.Wait()
+
+	if p.retention > 0 {
+		deleted, err := qtx.DeleteProbeResultsBefore(ctx, pgtype.Timestamptz{Time: time.Now().Add(-p.retention), Valid: true})
+		if err != nil {
+			return err
+		}
+		if deleted > 0 {
+			_asLogger.Debugf("Pruned %d expired probe results", deleted)
+		}
+	}
+	return nil
+}
+
+// ProbeAndStore probes one entry, records every result and updates the derived status
+func (p *SatcomProber) ProbeAndStore(ctx context.Context, entry auth.CommonSatcomDatum) ([]model.ProbeResult, error) {
+	results := p.ProbeEntry(ctx, entry)
+	if len(results) == 0 {
+		return results, nil
+	}
+
+	tx, err := p.dbConn.GetPool().Begin(ctx)
+	if err != nil {
+		return results, err
+	}
+	defer tx.Rollback(ctx)
+	if err := storeProbeResults(ctx, auth.New(tx), entry.ID, results); err != nil {
+		return results, err
+	}
+	return results, tx.Commit(ctx)
+}
+
+// storeProbeResults records one round of results and writes a STATUS history row
+// when the derived status flips the entry
+func storeProbeResults(ctx context.Context, qtx *auth.Queries, id int32, results []model.ProbeResult) error {
+	probedAt := pgtype.Timestamptz{Time: results[0].ProbedAt, Valid: true}
+	for _, result := range results {
+		params := auth.CreateProbeResultParams{
+			SatcomID:  id,
+			ProbedAt:  probedAt,
+			CheckType: result.CheckType,
+			Target:    result.Target,
+			Success:   result.Success,
+			LatencyMs: ConvertInt32ToPgInt4(result.LatencyMs),
+		}
+		if result.StatusCode != 0 {
+			params.StatusCode = ConvertInt32ToPgInt4(result.StatusCode)
+		}
+		if result.Error != "" {
+			params.Error = getSQLString(result.Error)
+		}
+		if err := qtx.CreateProbeResult(ctx, params); err != nil {
+			return err
+		}
+	}
+	if err := qtx.SetSatcomProbedAt(ctx, auth.SetSatcomProbedAtParams{ID: id, LastProbedAt: probedAt}); err != nil {
+		return err
+	}
+	// A status flip is a new version of the entry; overridden statuses are left alone
+	changed, err := qtx.UpdateSatcomProbeStatus(ctx, auth.UpdateSatcomProbeStatusParams{
+		Status: deriveProbeStatus(results),
+		ID:     id,
+	})
+	if err != nil {
+		return err
+	}
+	if changed > 0 {
+		return qtx.CreateSatcomHistory(ctx, auth.CreateSatcomHistoryParams{SatcomID: id, Operation: SATCOM_OP_STATUS})
+	}
+	return nil
+}
+
+// ProbeEntry runs the HTTP check against the url and TCP checks against ip:db_port
+// and ip:ui_port. Checks whose target is missing are skipped.
+func (p *SatcomProber) ProbeEntry(ctx context.Context, entry auth.CommonSatcomDatum) []model.ProbeResult {
+	probedAt := time.Now().UTC()
+	results := make([]model.ProbeResult, 0, 3)
+	if entry.Url != "" {
+		results = append(results, p.probeHTTP(ctx, entry.Url))
+	}
+	if entry.Ip != nil {
+		host := entry.Ip.String()
+		if entry.DbPort.Valid {
+			results = append(results, p.probeTCP(ctx, PROBE_CHECK_DB_PORT, net.JoinHostPort(host, strconv.Itoa(int(entry.DbPort.Int32)))))
+		}
+		if entry.UiPort.Valid {
+			results = append(results, p.probeTCP(ctx, PROBE_CHECK_UI_PORT, net.JoinHostPort(host, strconv.Itoa(int(entry.UiPort.Int32)))))
+		}
+	}
+	for i := range results {
+		results[i].ProbedAt = probedAt
+	}
+	return results
+}
+
+// probeHTTP issues a GET; any response below 500 counts as up
+func (p *SatcomProber) probeHTTP(ctx context.Context, url string) model.ProbeResult {
+	result := model.ProbeResult{CheckType: PROBE_CHECK_HTTP, Target: url}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	start := time.Now()
+	resp, err := p.client.Do(req)
+	result.LatencyMs = int32(time.Since(start).Milliseconds())
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+	result.StatusCode = int32(resp.StatusCode)
+	result.Success = resp.StatusCode < http.StatusInternalServerError
+	if !result.Success {
+		result.Error = fmt.Sprintf("unexpected status %s", resp.Status)
+	}
+	return result
+}
+
+// probeTCP succeeds when a connection can be established within the timeout
+func (p *SatcomProber) probeTCP(ctx context.Context, checkType, address string) model.ProbeResult {
+	result := model.ProbeResult{CheckType: checkType, Target: address}
+	dialer := p.dialer()
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	result.LatencyMs = int32(time.Since(start).Milliseconds())
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	conn.Close()
+	result.Success = true
+	return result
+}
+
+// deriveProbeStatus reports an entry as up only when every check of the round succeeded
+func deriveProbeStatus(results []model.ProbeResult) bool {
+	for _, result := range results {
+		if !result.Success {
+			return false
+		}
+	}
+	return len(results) > 0
+}
+
+// probeDenyList holds the networks the prober must not connect to
+type probeDenyList []*net.IPNet
+
+// newProbeDenyList parses CIDRs and the named ranges private, link-local and loopback
+func newProbeDenyList(entries []string) (probeDenyList, error) {
+	named := map[string][]string{
+		PROBE_NETWORK_PRIVATE:    {"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "100.64.0.0/10", "fc00::/7"},
+		PROBE_NETWORK_LINK_LOCAL: {"169.254.0.0/16", "fe80::/10"},
+		PROBE_NETWORK_LOOPBACK:   {"127.0.0.0/8", "0.0.0.0/8", "::1/128", "::/128"},
+	}
+	var list probeDenyList
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		cidrs, ok := named[entry]
+		if !ok {
+			cidrs = []string{entry}
+		}
+		for _, cidr := range cidrs {
+			_, network, err := net.ParseCIDR(cidr)
+			if err != nil {
+				return nil, fmt.Errorf("prober.deniedNetworks: %q is neither a CIDR nor one of private, link-local, loopback", entry)
+			}
+			list = append(list, network)
+		}
+	}
+	return list, nil
+}
+
+// denies reports whether ip lies in one of the denied networks
+func (d probeDenyList) denies(ip net.IP) bool {
+	for _, network := range d {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// control is a net.Dialer hook refusing connections to denied addresses after resolution
+func (d probeDenyList) control(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip != nil && d.denies(ip) {
+		return fmt.Errorf("%s is in a denied network", host)
+	}
+	return nil
+}
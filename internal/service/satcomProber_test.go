@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+)
+
+func newTestProber() *SatcomProber {
+	p, err := NewSatcomProber(model.ProberConfig{TimeoutMs: 200}, nil)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func TestProbeHTTP(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) { http.NotFound(w, r) })
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) { http.Redirect(w, r, "/ok", http.StatusFound) })
+	mux.HandleFunc("/broken", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusServiceUnavailable) })
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		path       string
+		success    bool
+		statusCode int32
+	}{
+		{"/ok", true, 200},
+		// Anything below 500 proves the endpoint is serving
+		{"/missing", true, 404},
+		{"/moved", true, 302},
+		{"/broken", false, 503},
+	}
+	p := newTestProber()
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			result := p.probeHTTP(context.Background(), server.URL+tc.path)
+			if result.CheckType != PROBE_CHECK_HTTP || result.Target != server.URL+tc.path {
+				t.Errorf("result = %+v", result)
+			}
+			if result.Success != tc.success || result.StatusCode != tc.statusCode {
+				t.Errorf("success %t status %d, want %t %d", result.Success, result.StatusCode, tc.success, tc.statusCode)
+			}
+			if !tc.success && result.Error == "" {
+				t.Error("failed probe without an error message")
+			}
+		})
+	}
+}
+
+func TestProbeHTTPTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	start := time.Now()
+	result := newTestProber().probeHTTP(context.Background(), server.URL)
+	if result.Success {
+		t.Fatal("probe of a hanging server succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("probe took %s, timeout is 200ms", elapsed)
+	}
+}
+
+func TestProbeTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	closedAddr := closed.Addr().String()
+	closed.Close()
+
+	p := newTestProber()
+	if result := p.probeTCP(context.Background(), PROBE_CHECK_DB_PORT, listener.Addr().String()); !result.Success {
+		t.Errorf("open port reported down: %+v", result)
+	}
+	result := p.probeTCP(context.Background(), PROBE_CHECK_UI_PORT, closedAddr)
+	if result.Success || result.Error == "" || result.CheckType != PROBE_CHECK_UI_PORT {
+		t.Errorf("closed port = %+v", result)
+	}
+}
+
+func TestProbeDeniedNetworks(t *testing.T) {
+	tests := []struct {
+		name    string
+		denied  []string
+		ip      string
+		refused bool
+	}{
+		{"default link-local", nil, "169.254.169.254", true},
+		{"default allows private", nil, "10.0.0.5", false},
+		{"default allows loopback", nil, "127.0.0.1", false},
+		{"empty allows link-local", []string{}, "169.254.169.254", false},
+		{"private", []string{"private"}, "192.168.1.10", true},
+		{"private ipv6", []string{"private"}, "fd00::1", true},
+		{"loopback", []string{"Loopback"}, "127.0.0.1", true},
+		{"mapped ipv4", []string{"link-local"}, "::ffff:169.254.169.254", true},
+		{"cidr", []string{"203.0.113.0/24"}, "203.0.113.7", true},
+		{"public", []string{"private", "link-local", "loopback"}, "8.8.8.8", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewSatcomProber(model.ProberConfig{DeniedNetworks: tt.denied}, nil)
+			if err != nil {
+				t.Fatalf("NewSatcomProber: %v", err)
+			}
+			err = p.denied.control("tcp", net.JoinHostPort(tt.ip, "80"), nil)
+			if (err != nil) != tt.refused {
+				t.Errorf("control(%s) = %v, want refused %v", tt.ip, err, tt.refused)
+			}
+		})
+	}
+
+	if _, err := NewSatcomProber(model.ProberConfig{DeniedNetworks: []string{"intranet"}}, nil); err == nil {
+		t.Error("unknown network accepted")
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+	p, _ := NewSatcomProber(model.ProberConfig{TimeoutMs: 200, DeniedNetworks: []string{"loopback"}}, nil)
+	if result := p.probeTCP(context.Background(), PROBE_CHECK_DB_PORT, listener.Addr().String()); result.Success || !strings.Contains(result.Error, "denied network") {
+		t.Errorf("denied port = %+v", result)
+	}
+	if result := p.probeHTTP(context.Background(), "http://"+listener.Addr().String()); result.Success || !strings.Contains(result.Error, "denied network") {
+		t.Errorf("denied url = %+v", result)
+	}
+}
+
+func TestProbeEntry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	closed, _ := net.Listen("tcp", "127.0.0.1:0")
+	closedPort := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+
+	ip := netip.MustParseAddr("127.0.0.1")
+	openPort := listener.Addr().(*net.TCPAddr).Port
+	p := newTestProber()
+
+	up := p.ProbeEntry(context.Background(), auth.CommonSatcomDatum{
+		Url:    server.URL,
+		Ip:     &ip,
+		DbPort: pgtype.Int4{Int32: int32(openPort), Valid: true},
+		UiPort: pgtype.Int4{Int32: int32(openPort), Valid: true},
+	})
+	if len(up) != 3 || !deriveProbeStatus(up) {
+		t.Fatalf("all checks up = %+v", up)
+	}
+	for _, result := range up {
+		if !result.ProbedAt.Equal(up[0].ProbedAt) {
+			t.Error("results of one round must share the probe time")
+		}
+	}
+
+	down := p.ProbeEntry(context.Background(), auth.CommonSatcomDatum{
+		Url:    server.URL,
+		Ip:     &ip,
+		DbPort: pgtype.Int4{Int32: int32(openPort), Valid: true},
+		UiPort: pgtype.Int4{Int32: int32(closedPort), Valid: true},
+	})
+	if len(down) != 3 || deriveProbeStatus(down) {
+		t.Fatalf("one check down = %+v", down)
+	}
+
+	if skipped := p.ProbeEntry(context.Background(), auth.CommonSatcomDatum{}); len(skipped) != 0 || deriveProbeStatus(skipped) {
+		t.Errorf("entry without targets = %+v", skipped)
+	}
+}
+
+// fakeProbeDB stands in for the database in storeProbeResults. It keeps the entry status
+// the way UpdateSatcomProbeStatus does and records the statements it runs.
+type fakeProbeDB struct {
+	status   bool
+	override bool
+	queries  []string
+}
+
+func (db *fakeProbeDB) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	name := strings.Fields(sql)[2]
+	db.queries = append(db.queries, name)
+	if name == "UpdateSatcomProbeStatus" {
+		status := args[0].(bool)
+		if db.override || status == db.status {
+			return pgconn.NewCommandTag("UPDATE 0"), nil
+		}
+		db.status = status
+		return pgconn.NewCommandTag("UPDATE 1"), nil
+	}
+	return pgconn.NewCommandTag("INSERT 0 1"), nil
+}
+
+func (db *fakeProbeDB) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return nil, errors.New("unexpected query")
+}
+
+func (db *fakeProbeDB) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return nil
+}
+
+func (db *fakeProbeDB) count(name string) int {
+	n := 0
+	for _, q := range db.queries {
+		if q == name {
+			n++
+		}
+	}
+	return n
+}
+
+func TestStoreProbeResultsStatusFlip(t *testing.T) {
+	round := func(success ...bool) []model.ProbeResult {
+		results := make([]model.ProbeResult, 0, len(success))
+		for i, ok := range success {
+			results = append(results, model.ProbeResult{CheckType: PROBE_CHECK_HTTP, Target: strconv.Itoa(i), Success: ok, ProbedAt: time.Now()})
+		}
+		return results
+	}
+	db := &fakeProbeDB{status: false}
+	qtx := auth.New(db)
+	ctx := context.Background()
+
+	steps := []struct {
+		name        string
+		results     []model.ProbeResult
+		override    bool
+		wantStatus  bool
+		wantHistory int
+	}{
+		{"down to up", round(true, true), false, true, 1},
+		{"still up", round(true, true), false, true, 1},
+		{"one check fails", round(true, false), false, false, 2},
+		{"still down", round(false, false), false, false, 2},
+		{"overridden", round(true, true), true, false, 2},
+		{"override lifted", round(true, true), false, true, 3},
+	}
+	for _, step := range steps {
+		before := db.count("CreateProbeResult")
+		db.override = step.override
+		if err := storeProbeResults(ctx, qtx, 7, step.results); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if got := db.count("CreateProbeResult") - before; got != len(step.results) {
+			t.Errorf("%s: stored %d results, want %d", step.name, got, len(step.results))
+		}
+		if db.status != step.wantStatus {
+			t.Errorf("%s: status %t, want %t", step.name, db.status, step.wantStatus)
+		}
+		if got := db.count("CreateSatcomHistory"); got != step.wantHistory {
+			t.Errorf("%s: %d STATUS history rows, want %d", step.name, got, step.wantHistory)
+		}
+	}
+	if got, want := db.count("SetSatcomProbedAt"), len(steps); got != want {
+		t.Errorf("probe time set %d times, want %d", got, want)
+	}
+}
@@ -0,0 +1,444 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+)
+
+// /api/satcom/:id/secrets - the secrets of an entry with masked values
+func (s *RESTService) listSatcomSecrets(c *gin.Context) APIResponse {
+	id, _, errResp := s.authorizeSatcomSecrets(c, false)
+	if errResp != nil {
+		return *errResp
+	}
+	rows, err := auth.New(s.dbConn.GetPool()).ListSatcomSecrets(context.Background(), id)
+	if err != nil {
+		_asLogger.Errorf("Error listing secrets of satcom data %d: %v", id, err)
+		return BuildResponse500("Failed to retrieve secrets", err.Error())
+	}
+	now := time.Now()
+	secrets := make([]model.SatcomSecret, 0, len(rows))
+	for _, row := range rows {
+		secrets = append(secrets, s.toSatcomSecret(row, now))
+	}
+	return BuildResponse200("Secrets retrieved successfully", secrets)
+}
+
+// /api/satcom/:id/secrets - store a new secret of an entry
+func (s *RESTService) createSatcomSecret(c *gin.Context) APIResponse {
+	id, _, errResp := s.authorizeSatcomSecrets(c, true)
+	if errResp != nil {
+		return *errResp
+	}
+	var input model.SatcomSecretInput
+	if !parseInput(c, &input) {
+		return BuildResponse400("Invalid input provided")
+	}
+	if errs := validateSatcomSecretInput(&input, true); len(errs) > 0 {
+		return BuildValidationResponse(errs)
+	}
+
+	ctx := context.Background()
+	db := s.dbConn.GetPool()
+	if _, err := auth.New(db).GetSatcomDataById(ctx, id); err != nil {
+		return BuildResponse404("Satcom data not found", false)
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		_asLogger.Errorf("Error starting transaction: %v", err)
+		return BuildResponse500("Failed to create secret", err.Error())
+	}
+	defer tx.Rollback(ctx)
+	qtx := auth.New(tx)
+
+	existing, err := qtx.ListSatcomSecrets(ctx, id)
+	if err != nil {
+		_asLogger.Errorf("Error listing secrets of satcom data %d: %v", id, err)
+		return BuildResponse500("Failed to create secret", err.Error())
+	}
+	for _, row := range existing {
+		if row.Name == input.Name {
+			return buildResponse(409, false, "A secret with this name already exists", s.toSatcomSecret(row, time.Now()))
+		}
+	}
+	row, err := qtx.CreateSatcomSecret(ctx, auth.CreateSatcomSecretParams{
+		SatcomID:     id,
+		Name:         input.Name,
+		Username:     input.Username,
+		Description:  input.Description,
+		RotationDays: optionalInt4(input.RotationDays),
+		CreatedBy:    s.currentUserID(c),
+	})
+	if err != nil {
+		_asLogger.Errorf("Error creating secret of satcom data %d: %v", id, err)
+		return BuildResponse500("Failed to create secret", err.Error())
+	}
+	if errResp := s.storeSatcomSecretVersion(ctx, qtx, c, row, input.Value); errResp != nil {
+		return *errResp
+	}
+	if err := tx.Commit(ctx); err != nil {
+		_asLogger.Errorf("Error committing secret of satcom data %d: %v", id, err)
+		return BuildResponse500("Failed to create secret", err.Error())
+	}
+	s.recordAudit(ctx, c, AUDIT_SATCOM_SECRET_CHANGE, secretAuditDetail(row, SATCOM_OP_CREATE))
+	return BuildResponse200("Secret created successfully", s.toSatcomSecret(row, time.Now()))
+}
+
+// /api/satcom/:id/secrets/:secretId - a secret with its versions; the value stays masked
+func (s *RESTService) getSatcomSecret(c *gin.Context) APIResponse {
+	id, _, errResp := s.authorizeSatcomSecrets(c, false)
+	if errResp != nil {
+		return *errResp
+	}
+	ctx := context.Background()
+	qtx := auth.New(s.dbConn.GetPool())
+	row, errResp := loadSatcomSecret(ctx, qtx, c, id)
+	if errResp != nil {
+		return *errResp
+	}
+	versions, err := qtx.ListSatcomSecretVersions(ctx, row.ID)
+	if err != nil {
+		_asLogger.Errorf("Error listing versions of secret %d: %v", row.ID, err)
+		return BuildResponse500("Failed to retrieve secret", err.Error())
+	}
+	result := struct {
+		model.SatcomSecret
+		Versions []model.SatcomSecretVersion `json:"versions"`
+	}{SatcomSecret: s.toSatcomSecret(row, time.Now()), Versions: make([]model.SatcomSecretVersion, 0, len(versions))}
+	for _, v := range versions {
+		version := model.SatcomSecretVersion{Version: v.Version, KeyID: v.KeyID, CreatedAt: v.CreatedAt.Time}
+		if v.CreatedBy.Valid {
+			version.CreatedBy = &v.CreatedBy.Int32
+		}
+		result.Versions = append(result.Versions, version)
+	}
+	return BuildResponse200("Secret retrieved successfully", result)
+}
+
+// /api/satcom/:id/secrets/:secretId - rotate a secret: store the value as a new version
+// and replace username, description and rotation period. Older versions stay readable.
+func (s *RESTService) rotateSatcomSecret(c *gin.Context) APIResponse {
+	id, _, errResp := s.authorizeSatcomSecrets(c, true)
+	if errResp != nil {
+		return *errResp
+	}
+	var input model.SatcomSecretInput
+	if !parseInput(c, &input) {
+		return BuildResponse400("Invalid input provided")
+	}
+	if errs := validateSatcomSecretInput(&input, false); len(errs) > 0 {
+		return BuildValidationResponse(errs)
+	}
+
+	ctx := context.Background()
+	tx, err := s.dbConn.GetPool().Begin(ctx)
+	if err != nil {
+		_asLogger.Errorf("Error starting transaction: %v", err)
+		return BuildResponse500("Failed to rotate secret", err.Error())
+	}
+	defer tx.Rollback(ctx)
+	qtx := auth.New(tx)
+
+	current, errResp := loadSatcomSecret(ctx, qtx, c, id)
+	if errResp != nil {
+		return *errResp
+	}
+	if input.Name != "" && input.Name != current.Name {
+		return BuildValidationResponse([]model.FieldError{{Field: "name", Message: "name cannot be changed"}})
+	}
+	row, err := qtx.RotateSatcomSecret(ctx, auth.RotateSatcomSecretParams{
+		ID:           current.ID,
+		SatcomID:     id,
+		Username:     input.Username,
+		Description:  input.Description,
+		RotationDays: optionalInt4(input.RotationDays),
+	})
+	if err != nil {
+		_asLogger.Errorf("Error rotating secret %d: %v", current.ID, err)
+		return BuildResponse500("Failed to rotate secret", err.Error())
+	}
+	if errResp := s.storeSatcomSecretVersion(ctx, qtx, c, row, input.Value); errResp != nil {
+		return *errResp
+	}
+	if err := tx.Commit(ctx); err != nil {
+		_asLogger.Errorf("Error committing rotation of secret %d: %v", row.ID, err)
+		return BuildResponse500("Failed to rotate secret", err.Error())
+	}
+	s.recordAudit(ctx, c, AUDIT_SATCOM_SECRET_CHANGE, secretAuditDetail(row, SATCOM_OP_UPDATE))
+	return BuildResponse200("Secret rotated successfully", s.toSatcomSecret(row, time.Now()))
+}
+
+// /api/satcom/:id/secrets/:secretId - delete a secret with all of its versions
+func (s *RESTService) deleteSatcomSecret(c *gin.Context) APIResponse {
+	id, _, errResp := s.authorizeSatcomSecrets(c, true)
+	if errResp != nil {
+		return *errResp
+	}
+	ctx := context.Background()
+	qtx := auth.New(s.dbConn.GetPool())
+	row, errResp := loadSatcomSecret(ctx, qtx, c, id)
+	if errResp != nil {
+		return *errResp
+	}
+	if _, err := qtx.DeleteSatcomSecret(ctx, auth.DeleteSatcomSecretParams{ID: row.ID, SatcomID: id}); err != nil {
+		_asLogger.Errorf("Error deleting secret %d: %v", row.ID, err)
+		return BuildResponse500("Failed to delete secret", err.Error())
+	}
+	s.recordAudit(ctx, c, AUDIT_SATCOM_SECRET_CHANGE, secretAuditDetail(row, SATCOM_OP_DELETE))
+	return BuildResponse200("Secret deleted successfully", nil)
+}
+
+// /api/satcom/:id/secrets/:secretId/reveal - decrypt the current value, or the one of
+// version. Only reveal roles may do so, never through an impersonation token, and the
+// value is returned only once the reveal is in the audit log.
+func (s *RESTService) revealSatcomSecret(c *gin.Context) APIResponse {
+	id, scope, errResp := s.authorizeSatcomSecrets(c, false)
+	if errResp != nil {
+		return *errResp
+	}
+	ctx := context.Background()
+	detail := map[string]interface{}{"satcom_id": id, "secret_id": c.Param("secretId")}
+	// without a signing key every request runs as an anonymous super admin
+	if s.jwtSigningKey == nil {
+		detail["denied"] = true
+		s.recordAudit(ctx, c, AUDIT_SATCOM_SECRET_REVEAL, detail)
+		return BuildResponse403("Secrets cannot be revealed while authentication is disabled")
+	}
+	if !s.vault.canReveal(scope) {
+		detail["denied"] = true
+		s.recordAudit(ctx, c, AUDIT_SATCOM_SECRET_REVEAL, detail)
+		return BuildResponse403("Your role does not allow revealing secrets")
+	}
+	if claims := s.currentClaims(c); claims != nil && claims.Act != nil {
+		detail["denied"] = true
+		s.recordAudit(ctx, c, AUDIT_SATCOM_SECRET_REVEAL, detail)
+		return BuildResponse403("Secrets cannot be revealed while impersonating a user")
+	}
+	var version int32
+	if v := c.Query("version"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 32)
+		if err != nil || n < 1 {
+			return BuildResponse400("Invalid version")
+		}
+		version = int32(n)
+	}
+
+	tx, err := s.dbConn.GetPool().Begin(ctx)
+	if err != nil {
+		_asLogger.Errorf("Error starting transaction: %v", err)
+		return BuildResponse500("Failed to reveal secret", err.Error())
+	}
+	defer tx.Rollback(ctx)
+	qtx := auth.New(tx)
+
+	row, errResp := loadSatcomSecret(ctx, qtx, c, id)
+	if errResp != nil {
+		return *errResp
+	}
+	if version == 0 {
+		version = row.Version
+	}
+	stored, err := qtx.GetSatcomSecretVersion(ctx, auth.GetSatcomSecretVersionParams{SecretID: row.ID, Version: version})
+	if err == pgx.ErrNoRows {
+		return BuildResponse404("Secret version not found", false)
+	}
+	if err != nil {
+		_asLogger.Errorf("Error getting version %d of secret %d: %v", version, row.ID, err)
+		return BuildResponse500("Failed to reveal secret", err.Error())
+	}
+	value, err := s.vault.open(stored)
+	if err != nil {
+		_asLogger.Errorf("Error decrypting secret %d: %v", row.ID, err)
+		return BuildResponse500("Failed to decrypt secret", err.Error())
+	}
+
+	auditDetail := secretAuditDetail(row, "")
+	auditDetail["version"] = version
+	if err := qtx.CreateAuditLog(ctx, s.auditLogParams(c, AUDIT_SATCOM_SECRET_REVEAL, auditDetail)); err != nil {
+		_asLogger.Errorf("Error writing audit log %s: %v", AUDIT_SATCOM_SECRET_REVEAL, err)
+		return BuildResponse500("Failed to reveal secret", err.Error())
+	}
+	if err := tx.Commit(ctx); err != nil {
+		_asLogger.Errorf("Error committing reveal of secret %d: %v", row.ID, err)
+		return BuildResponse500("Failed to reveal secret", err.Error())
+	}
+	secret := s.toSatcomSecret(row, time.Now())
+	secret.Version = version
+	secret.Value = string(value)
+	c.Header("Cache-Control", "no-store")
+	return BuildResponse200("Secret revealed successfully", secret)
+}
+
+// /api/satcom/secrets/due - secrets of the caller's companies that are overdue or due
+// for rotation within withinDays (14 by default)
+func (s *RESTService) listSatcomSecretsDue(c *gin.Context) APIResponse {
+	if s.vault == nil {
+		return vaultDisabledResponse()
+	}
+	scope, errResp := s.satcomScope(c)
+	if errResp != nil {
+		return *errResp
+	}
+	company := optionalText(c.Query("company"))
+	if err := scope.restrict(&company); err != nil {
+		return BuildResponse403(err.Error())
+	}
+	within := 14
+	if v := c.Query("withinDays"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return BuildResponse400("Invalid withinDays")
+		}
+		within = n
+	}
+
+	rows, err := auth.New(s.dbConn.GetPool()).ListSatcomSecretsForRotation(context.Background(), company)
+	if err != nil {
+		_asLogger.Errorf("Error listing secrets for rotation: %v", err)
+		return BuildResponse500("Failed to retrieve secrets due for rotation", err.Error())
+	}
+	now := time.Now()
+	limit := now.AddDate(0, 0, within)
+	secrets := []model.SatcomSecret{}
+	for _, row := range rows {
+		due := secretRotationDue(row.RotationDays, row.RotatedAt, s.vault.rotationDays)
+		if due != nil && !due.After(limit) {
+			secrets = append(secrets, toSatcomSecretDue(row, due, now))
+		}
+	}
+	return BuildResponse200("Secrets due for rotation retrieved successfully", secrets)
+}
+
+// authorizeSatcomSecrets checks that the vault is enabled before authorizing the entry
+func (s *RESTService) authorizeSatcomSecrets(c *gin.Context, write bool) (int32, satcomScope, *APIResponse) {
+	if s.vault == nil {
+		resp := vaultDisabledResponse()
+		return 0, satcomScope{}, &resp
+	}
+	return s.authorizeSatcomID(c, write)
+}
+
+func vaultDisabledResponse() APIResponse {
+	return buildResponse(503, false, "The credential vault is not enabled", nil)
+}
+
+// loadSatcomSecret reads the secret :secretId of the entry id
+func loadSatcomSecret(ctx context.Context, qtx *auth.Queries, c *gin.Context, id int32) (auth.CommonSatcomSecret, *APIResponse) {
+	secretID, err := strconv.ParseInt(c.Param("secretId"), 10, 32)
+	if err != nil {
+		resp := BuildResponse400("Invalid secret ID format")
+		return auth.CommonSatcomSecret{}, &resp
+	}
+	row, err := qtx.GetSatcomSecret(ctx, auth.GetSatcomSecretParams{ID: int32(secretID), SatcomID: id})
+	if err == pgx.ErrNoRows {
+		resp := BuildResponse404("Secret not found", false)
+		return row, &resp
+	}
+	if err != nil {
+		_asLogger.Errorf("Error getting secret %d: %v", secretID, err)
+		resp := BuildResponse500("Failed to retrieve secret", err.Error())
+		return row, &resp
+	}
+	return row, nil
+}
+
+// storeSatcomSecretVersion encrypts value as the current version of the secret
+func (s *RESTService) storeSatcomSecretVersion(ctx context.Context, qtx *auth.Queries, c *gin.Context, row auth.CommonSatcomSecret, value string) *APIResponse {
+	sealed, err := s.vault.seal(row.ID, row.Version, []byte(value))
+	if err != nil {
+		_asLogger.Errorf("Error encrypting secret %d: %v", row.ID, err)
+		resp := BuildResponse500("Failed to encrypt secret", err.Error())
+		return &resp
+	}
+	err = qtx.CreateSatcomSecretVersion(ctx, auth.CreateSatcomSecretVersionParams{
+		SecretID:   row.ID,
+		Version:    row.Version,
+		Ciphertext: sealed.ciphertext,
+		Nonce:      sealed.nonce,
+		DataKey:    sealed.dataKey,
+		KeyNonce:   sealed.keyNonce,
+		KeyID:      sealed.keyID,
+		CreatedBy:  s.currentUserID(c),
+	})
+	if err != nil {
+		_asLogger.Errorf("Error storing version %d of secret %d: %v", row.Version, row.ID, err)
+		resp := BuildResponse500("Failed to store secret", err.Error())
+		return &resp
+	}
+	return nil
+}
+
+// validateSatcomSecretInput trims the input; a name is only required on creation
+func validateSatcomSecretInput(input *model.SatcomSecretInput, create bool) []model.FieldError {
+	var errs []model.FieldError
+	input.Name = strings.TrimSpace(input.Name)
+	input.Username = strings.TrimSpace(input.Username)
+	input.Description = strings.TrimSpace(input.Description)
+	if create && input.Name == "" {
+		errs = append(errs, model.FieldError{Field: "name", Message: "name is required"})
+	} else if len(input.Name) > 100 {
+		errs = append(errs, model.FieldError{Field: "name", Message: "name must be at most 100 characters"})
+	}
+	if input.Value == "" {
+		errs = append(errs, model.FieldError{Field: "value", Message: "value is required"})
+	} else if len(input.Value) > MAX_SATCOM_SECRET_VALUE {
+		errs = append(errs, model.FieldError{Field: "value", Message: "value must be at most " + strconv.Itoa(MAX_SATCOM_SECRET_VALUE) + " bytes"})
+	}
+	if input.RotationDays != nil && *input.RotationDays < 0 {
+		errs = append(errs, model.FieldError{Field: "rotation_days", Message: "rotation_days must not be negative"})
+	}
+	return errs
+}
+
+func optionalInt4(val *int32) pgtype.Int4 {
+	if val == nil {
+		return pgtype.Int4{}
+	}
+	return ConvertInt32ToPgInt4(*val)
+}
+
+func secretAuditDetail(row auth.CommonSatcomSecret, operation string) map[string]interface{} {
+	detail := map[string]interface{}{
+		"satcom_id": row.SatcomID,
+		"secret_id": row.ID,
+		"name":      row.Name,
+		"version":   row.Version,
+	}
+	if operation != "" {
+		detail["operation"] = operation
+	}
+	return detail
+}
+
+// toSatcomSecret describes a secret with its value masked
+func (s *RESTService) toSatcomSecret(row auth.CommonSatcomSecret, now time.Time) model.SatcomSecret {
+	due := secretRotationDue(row.RotationDays, row.RotatedAt, s.vault.rotationDays)
+	secret := model.SatcomSecret{
+		ID:          row.ID,
+		SatcomID:    row.SatcomID,
+		Name:        row.Name,
+		Username:    row.Username,
+		Description: row.Description,
+		Value:       SATCOM_SECRET_MASK,
+		Version:     row.Version,
+		RotatedAt:   row.RotatedAt.Time,
+		RotationDue: due,
+		Overdue:     due != nil && !due.After(now),
+		CreatedAt:   row.CreatedAt.Time,
+	}
+	if row.RotationDays.Valid {
+		secret.RotationDays = &row.RotationDays.Int32
+	}
+	if row.CreatedBy.Valid {
+		secret.CreatedBy = &row.CreatedBy.Int32
+	}
+	return secret
+}
@@ -3,55 +3,107 @@ package service
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
 	auth "github.com/rest/api/internal/dbmodel/db_query"
 	"github.com/rest/api/internal/model"
 )
 
 // CreateSatcomData creates a new satcom data entry
 func (s *RESTService) createSatcomData(c *gin.Context) APIResponse {
+	scope, errResp := s.satcomScope(c)
+	if errResp != nil {
+		return *errResp
+	}
+	if errResp := scope.writeDenied(); errResp != nil {
+		return *errResp
+	}
 	var input model.SatcomDataInput
 	if !parseInput(c, &input) {
 		return BuildResponse400("Invalid input provided")
 	}
+	// New entries are checked for required custom fields even when none are sent
+	if input.CustomFields == nil {
+		input.CustomFields = map[string]interface{}{}
+	}
+	record, fieldErrs := s.validateSatcomInput(&input)
+	if len(fieldErrs) > 0 {
+		return BuildValidationResponse(fieldErrs)
+	}
+
+	allowConflicts, _ := strconv.ParseBool(c.Query("allowConflicts"))
 
 	ctx := context.Background()
 	db := s.dbConn.GetPool()
-	qtx := auth.New(db)
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		_asLogger.Errorf("Error starting transaction: %v", err)
+		return BuildResponse500("Failed to create satcom data", err.Error())
+	}
+	defer tx.Rollback(ctx)
+	qtx := auth.New(tx)
+
+	if fieldErrs := assignSatcomCompany(ctx, qtx, scope, &input, ""); len(fieldErrs) > 0 {
+		return BuildValidationResponse(fieldErrs)
+	}
+	if !allowConflicts {
+		conflicts, err := findSatcomConflicts(ctx, qtx, input.Company, record, input.URL, pgtype.Int4{})
+		if err != nil {
+			_asLogger.Errorf("Error checking satcom conflicts: %v", err)
+			return BuildResponse500("Failed to create satcom data", err.Error())
+		}
+		if len(conflicts) > 0 {
+			return BuildConflictResponse(conflicts)
+		}
+	}
 
 	createParams := auth.CreateSatcomDataParams{
-		Company:  input.Company,
-		Category: input.Category,
-		Type:     input.Type,
-		Date:     input.Date,
-		Time:     input.Time,
-		DbPort:   input.DbPort,
-		UiPort:   input.UiPort,
-		Url:      input.URL,
-		Ip:       input.IP,
-		Status:   input.Status,
+		Company:    input.Company,
+		Category:   input.Category,
+		Type:       input.Type,
+		RecordedAt: record.RecordedAt,
+		DbPort:     record.DbPort,
+		UiPort:     record.UiPort,
+		Url:        input.URL,
+		Ip:         record.Ip,
+		Status:     input.Status,
+
+		Labels:       record.Labels,
+		CustomFields: record.CustomFields,
 	}
 
-	err := qtx.CreateSatcomData(ctx, createParams)
+	id, err := qtx.CreateSatcomData(ctx, createParams)
 	if err != nil {
 		_asLogger.Errorf("Error creating satcom data: %v", err)
 		return BuildResponse500("Failed to create satcom data", err.Error())
 	}
+	if err := s.recordSatcomVersion(ctx, qtx, c, id, SATCOM_OP_CREATE, pgtype.Int4{}); err != nil {
+		_asLogger.Errorf("Error recording history of satcom data %d: %v", id, err)
+		return BuildResponse500("Failed to create satcom data", err.Error())
+	}
+	if err := tx.Commit(ctx); err != nil {
+		_asLogger.Errorf("Error committing satcom data: %v", err)
+		return BuildResponse500("Failed to create satcom data", err.Error())
+	}
 
-	return BuildResponse200("Satcom data created successfully", nil)
+	return BuildResponse200("Satcom data created successfully", map[string]interface{}{"id": id})
 }
 
 // GetSatcomDataById retrieves a satcom data entry by ID
 func (s *RESTService) getSatcomDataById(c *gin.Context) APIResponse {
-	idParam := c.Param("id")
-	if idParam == "" {
-		return BuildResponse400("ID parameter is required")
+	id, _, errResp := s.authorizeSatcomID(c, false)
+	if errResp != nil {
+		return *errResp
 	}
-
-	var id int32
-	if _, err := fmt.Sscanf(idParam, "%d", &id); err != nil {
-		return BuildResponse400("Invalid ID format")
+	version, err := requestAPIVersion(c)
+	if err != nil {
+		return BuildResponse400(err.Error())
 	}
 
 	ctx := context.Background()
@@ -63,67 +115,221 @@ func (s *RESTService) getSatcomDataById(c *gin.Context) APIResponse {
 		_asLogger.Errorf("Error getting satcom data: %v", err)
 		return BuildResponse404("Satcom data not found", false)
 	}
+	maintenance, err := loadSatcomMaintenance(ctx, qtx, getSQLString(data.Company))
+	if err != nil {
+		_asLogger.Errorf("Error getting maintenance windows: %v", err)
+		return BuildResponse500("Failed to retrieve satcom data", err.Error())
+	}
+	occurrence := maintenance.active(data, time.Now())
 
-	response := model.SatcomDataResponse{
-		ID:       data.ID,
-		Company:  data.Company,
-		Category: data.Category,
-		Type:     data.Type,
-		Date:     data.Date,
-		Time:     data.Time,
-		DbPort:   data.DbPort,
-		UiPort:   data.UiPort,
-		URL:      data.Url,
-		IP:       data.Ip,
-		Status:   data.Status,
+	// The body depends on the requested API version, so caches must key on it
+	c.Writer.Header().Add("Vary", "X-API-Version")
+	etag := satcomRepresentationETag(data, version, occurrence)
+	c.Header("ETag", etag)
+	if ifNoneMatchHits(c, etag) {
+		return buildResponse(http.StatusNotModified, true, "Satcom data not modified", nil)
 	}
 
+	response := withEffectiveStatus(toSatcomResponse(data, version), data, occurrence)
 	return BuildResponse200("Satcom data retrieved successfully", response)
 }
 
-// GetAllSatcomData retrieves all satcom data entries
+// GetAllSatcomData retrieves one page of satcom data entries matching the query filters
 func (s *RESTService) getAllSatcomData(c *gin.Context) APIResponse {
+	pq, err := parsePageQuery(c, satcomSortFields, "id")
+	if err != nil {
+		return BuildResponse400(err.Error())
+	}
+	// date and time were merged into recorded_at
+	if pq.SortBy == "date" || pq.SortBy == "time" {
+		pq.SortBy = "recorded_at"
+	}
+	version, err := requestAPIVersion(c)
+	if err != nil {
+		return BuildResponse400(err.Error())
+	}
+	filter, err := parseSatcomFilter(c)
+	if err != nil {
+		return BuildResponse400(err.Error())
+	}
+	scope, errResp := s.satcomScope(c)
+	if errResp != nil {
+		return *errResp
+	}
+	if err := scope.restrict(&filter.Company); err != nil {
+		return BuildResponse403(err.Error())
+	}
+
 	ctx := context.Background()
 	db := s.dbConn.GetPool()
 	qtx := auth.New(db)
 
-	dataList, err := qtx.GetAllSatcomData(ctx)
+	dataList, err := qtx.ListSatcomDataSorted(ctx, auth.ListSatcomDataSortedParams{
+		Search:      filter.Search,
+		Company:     filter.Company,
+		Category:    filter.Category,
+		Type:        filter.Type,
+		Status:      filter.Status,
+		Ip:          filter.Ip,
+		Url:         filter.Url,
+		LabelMatch:  filter.LabelMatch,
+		LabelExists: filter.LabelExists,
+		LabelAbsent: filter.LabelAbsent,
+		LabelNot:    filter.LabelNot,
+		LabelIn:     filter.LabelIn,
+		AfterID:     pq.AfterID,
+		SortBy:      pq.SortBy,
+		SortDesc:    pq.SortDesc,
+		RowLimit:    pq.Limit,
+		RowOffset:   pq.Offset,
+	})
 	if err != nil {
 		_asLogger.Errorf("Error getting all satcom data: %v", err)
 		return BuildResponse500("Failed to retrieve satcom data", err.Error())
 	}
+	total, err := qtx.CountSatcomData(ctx, filter)
+	if err != nil {
+		_asLogger.Errorf("Error counting satcom data: %v", err)
+		return BuildResponse500("Failed to retrieve satcom data", err.Error())
+	}
+
+	maintenance, err := loadSatcomMaintenance(ctx, qtx, filter.Company)
+	if err != nil {
+		_asLogger.Errorf("Error getting maintenance windows: %v", err)
+		return BuildResponse500("Failed to retrieve satcom data", err.Error())
+	}
 
 	// Transform to response format
-	responseList := make([]model.SatcomDataResponse, 0, len(dataList))
+	now := time.Now()
+	responseList := make([]interface{}, 0, len(dataList))
 	for _, data := range dataList {
-		responseList = append(responseList, model.SatcomDataResponse{
-			ID:       data.ID,
-			Company:  data.Company,
-			Category: data.Category,
-			Type:     data.Type,
-			Date:     data.Date,
-			Time:     data.Time,
-			DbPort:   data.DbPort,
-			UiPort:   data.UiPort,
-			URL:      data.Url,
-			IP:       data.Ip,
-			Status:   data.Status,
-		})
+		responseList = append(responseList, withEffectiveStatus(toSatcomResponse(data, version), data, maintenance.active(data, now)))
 	}
 
-	return BuildResponse200("Satcom data retrieved successfully", responseList)
+	result := model.PageResult{
+		Items:  responseList,
+		Total:  total,
+		Limit:  pq.Limit,
+		Offset: pq.Offset,
+		Page:   pq.Page,
+	}
+	if len(dataList) == int(pq.Limit) && pq.SortBy == "id" && !pq.SortDesc {
+		result.NextCursor = encodeCursor(dataList[len(dataList)-1].ID)
+	}
+
+	return BuildResponse200("Satcom data retrieved successfully", result)
 }
 
-// UpdateSatcomData updates an existing satcom data entry
-func (s *RESTService) updateSatcomData(c *gin.Context) APIResponse {
-	idParam := c.Param("id")
-	if idParam == "" {
-		return BuildResponse400("ID parameter is required")
+// parseSatcomFilter reads the q, company, category, type, status, ip, url and
+// labelSelector list filters
+func parseSatcomFilter(c *gin.Context) (auth.CountSatcomDataParams, error) {
+	var status pgtype.Bool
+	if v := c.Query("status"); v != "" {
+		flag, err := strconv.ParseBool(v)
+		if err != nil {
+			return auth.CountSatcomDataParams{}, fmt.Errorf("Invalid status filter")
+		}
+		status = pgtype.Bool{Bool: flag, Valid: true}
+	}
+	// ip matches a single address or every address inside a CIDR block
+	var ipFilter *netip.Prefix
+	if v := c.Query("ip"); v != "" {
+		prefix, err := parseIPFilter(v)
+		if err != nil {
+			return auth.CountSatcomDataParams{}, fmt.Errorf("Invalid ip filter")
+		}
+		ipFilter = &prefix
 	}
+	filter := auth.CountSatcomDataParams{
+		Search:   optionalLikeText(strings.TrimSpace(c.Query("q"))),
+		Company:  optionalText(c.Query("company")),
+		Category: optionalText(c.Query("category")),
+		Type:     optionalText(c.Query("type")),
+		Status:   status,
+		Ip:       ipFilter,
+		Url:      optionalLikeText(c.Query("url")),
+	}
+	if v := strings.TrimSpace(c.Query("labelSelector")); v != "" {
+		selector, err := parseLabelSelector(v)
+		if err != nil {
+			return auth.CountSatcomDataParams{}, fmt.Errorf("Invalid labelSelector: %v", err)
+		}
+		selector.applyTo(&filter)
+	}
+	return filter, nil
+}
+
+// listScopedSatcomData loads every entry in the caller's scope that matches the list
+// filters, in id order
+func (s *RESTService) listScopedSatcomData(c *gin.Context) ([]auth.CommonSatcomDatum, *APIResponse) {
+	filter, err := parseSatcomFilter(c)
+	if err != nil {
+		resp := BuildResponse400(err.Error())
+		return nil, &resp
+	}
+	scope, errResp := s.satcomScope(c)
+	if errResp != nil {
+		return nil, errResp
+	}
+	if err := scope.restrict(&filter.Company); err != nil {
+		resp := BuildResponse403(err.Error())
+		return nil, &resp
+	}
+
+	ctx := context.Background()
+	qtx := auth.New(s.dbConn.GetPool())
+	params := auth.ListSatcomDataParams{
+		Search:      filter.Search,
+		Company:     filter.Company,
+		Category:    filter.Category,
+		Type:        filter.Type,
+		Status:      filter.Status,
+		Ip:          filter.Ip,
+		Url:         filter.Url,
+		LabelMatch:  filter.LabelMatch,
+		LabelExists: filter.LabelExists,
+		LabelAbsent: filter.LabelAbsent,
+		LabelNot:    filter.LabelNot,
+		LabelIn:     filter.LabelIn,
+		RowLimit:    SATCOM_EXPORT_CHUNK,
+	}
+	entries := make([]auth.CommonSatcomDatum, 0)
+	for {
+		dataList, err := qtx.ListSatcomData(ctx, params)
+		if err != nil {
+			_asLogger.Errorf("Error getting satcom data: %v", err)
+			resp := BuildResponse500("Failed to retrieve satcom data", err.Error())
+			return nil, &resp
+		}
+		entries = append(entries, dataList...)
+		if len(dataList) < SATCOM_EXPORT_CHUNK {
+			break
+		}
+		params.AfterID = ConvertInt32ToPgInt4(dataList[len(dataList)-1].ID)
+	}
+	return entries, nil
+}
+
+var satcomSortFields = map[string]bool{
+	"id":          true,
+	"company":     true,
+	"category":    true,
+	"type":        true,
+	"recorded_at": true,
+	"date":        true,
+	"time":        true,
+	"db_port":     true,
+	"ui_port":     true,
+	"url":         true,
+	"ip":          true,
+	"status":      true,
+}
 
-	var id int32
-	if _, err := fmt.Sscanf(idParam, "%d", &id); err != nil {
-		return BuildResponse400("Invalid ID format")
+// UpdateSatcomData replaces an existing satcom data entry
+func (s *RESTService) updateSatcomData(c *gin.Context) APIResponse {
+	id, scope, errResp := s.authorizeSatcomID(c, true)
+	if errResp != nil {
+		return *errResp
 	}
 
 	var input model.SatcomDataInput
@@ -131,68 +337,222 @@ func (s *RESTService) updateSatcomData(c *gin.Context) APIResponse {
 		return BuildResponse400("Invalid input provided")
 	}
 
+	return s.saveSatcomUpdate(c, id, scope, func(current auth.CommonSatcomDatum) (model.SatcomDataInput, []model.FieldError) {
+		return input, nil
+	})
+}
+
+// PatchSatcomData applies a JSON Merge Patch (RFC 7396) to a satcom data entry
+func (s *RESTService) patchSatcomData(c *gin.Context) APIResponse {
+	id, scope, errResp := s.authorizeSatcomID(c, true)
+	if errResp != nil {
+		return *errResp
+	}
+
+	var patch map[string]interface{}
+	if !parseInput(c, &patch) || patch == nil {
+		return BuildResponse400("Invalid input provided; expected a JSON merge patch object")
+	}
+
+	return s.saveSatcomUpdate(c, id, scope, func(current auth.CommonSatcomDatum) (model.SatcomDataInput, []model.FieldError) {
+		return mergeSatcomPatch(current, patch)
+	})
+}
+
+// saveSatcomUpdate loads the entry, checks If-Match, builds the new input from the current
+// row and stores it. Writes are conditional on the version that was read, so a concurrent
+// change between the read and the write is reported as 412 instead of being overwritten.
+// An entry can only be moved to another company inside the caller's scope. Changes to
+// entries needing approval are validated the same way and stored as a change request.
+func (s *RESTService) saveSatcomUpdate(c *gin.Context, id int32, scope satcomScope, buildInput func(current auth.CommonSatcomDatum) (model.SatcomDataInput, []model.FieldError)) APIResponse {
+	allowConflicts, _ := strconv.ParseBool(c.Query("allowConflicts"))
+
 	ctx := context.Background()
 	db := s.dbConn.GetPool()
-	qtx := auth.New(db)
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		_asLogger.Errorf("Error starting transaction: %v", err)
+		return BuildResponse500("Failed to update satcom data", err.Error())
+	}
+	defer tx.Rollback(ctx)
+	qtx := auth.New(tx)
 
 	// Check if record exists
-	_, err := qtx.GetSatcomDataById(ctx, id)
+	current, err := qtx.GetSatcomDataById(ctx, id)
 	if err != nil {
 		_asLogger.Errorf("Error getting satcom data: %v", err)
 		return BuildResponse404("Satcom data not found", false)
 	}
+	if satcomIfMatchFails(c, current) {
+		c.Header("ETag", satcomETag(current))
+		return BuildResponse412("Satcom data was changed since it was read; reload and retry")
+	}
 
-	updateParams := auth.UpdateSatcomDataParams{
-		Company:  input.Company,
-		Category: input.Category,
-		Type:     input.Type,
-		Date:     input.Date,
-		Time:     input.Time,
-		DbPort:   input.DbPort,
-		UiPort:   input.UiPort,
-		Url:      input.URL,
-		Ip:       input.IP,
-		Status:   input.Status,
-		ID:       id,
+	input, fieldErrs := buildInput(current)
+	if len(fieldErrs) > 0 {
+		return BuildValidationResponse(fieldErrs)
+	}
+	record, fieldErrs := s.validateSatcomInput(&input)
+	fieldErrs = append(fieldErrs, assignSatcomCompany(ctx, qtx, scope, &input, current.Company)...)
+	if len(fieldErrs) > 0 {
+		return BuildValidationResponse(fieldErrs)
+	}
+	if !allowConflicts {
+		conflicts, err := findSatcomConflicts(ctx, qtx, input.Company, record, input.URL, ConvertInt32ToPgInt4(id))
+		if err != nil {
+			_asLogger.Errorf("Error checking satcom conflicts: %v", err)
+			return BuildResponse500("Failed to update satcom data", err.Error())
+		}
+		if len(conflicts) > 0 {
+			return BuildConflictResponse(conflicts)
+		}
+	}
+
+	proposed := current
+	proposed.Company = input.Company
+	proposed.Category = input.Category
+	proposed.Type = input.Type
+	proposed.RecordedAt = record.RecordedAt
+	proposed.DbPort = record.DbPort
+	proposed.UiPort = record.UiPort
+	proposed.Url = input.URL
+	proposed.Ip = record.Ip
+	proposed.Status = input.Status
+	if record.Labels != nil {
+		proposed.Labels = record.Labels
+	}
+	if record.CustomFields != nil {
+		proposed.CustomFields = record.CustomFields
+	}
+	if s.requiresApproval(current) {
+		return s.submitSatcomChange(ctx, tx, c, SATCOM_OP_UPDATE, current, &proposed, allowConflicts)
 	}
 
-	err = qtx.UpdateSatcomData(ctx, updateParams)
+	updated, err := qtx.UpdateSatcomData(ctx, satcomUpdateParams(proposed, current.Version))
 	if err != nil {
 		_asLogger.Errorf("Error updating satcom data: %v", err)
 		return BuildResponse500("Failed to update satcom data", err.Error())
 	}
+	if updated == 0 {
+		return BuildResponse412("Satcom data was changed concurrently; reload and retry")
+	}
 
+	if err := s.recordSatcomVersion(ctx, qtx, c, id, SATCOM_OP_UPDATE, pgtype.Int4{}); err != nil {
+		_asLogger.Errorf("Error recording history of satcom data %d: %v", id, err)
+		return BuildResponse500("Failed to update satcom data", err.Error())
+	}
+	// Every typed value is written again, so earlier conversion issues are resolved
+	if err := qtx.DeleteSatcomConversionIssues(ctx, id); err != nil {
+		_asLogger.Errorf("Error clearing conversion issues of satcom data %d: %v", id, err)
+		return BuildResponse500("Failed to update satcom data", err.Error())
+	}
+	if err := tx.Commit(ctx); err != nil {
+		_asLogger.Errorf("Error committing satcom data %d: %v", id, err)
+		return BuildResponse500("Failed to update satcom data", err.Error())
+	}
+
+	current.Version++
+	c.Header("ETag", satcomETag(current))
 	return BuildResponse200("Satcom data updated successfully", nil)
 }
 
-// DeleteSatcomData deletes a satcom data entry by ID
-func (s *RESTService) deleteSatcomData(c *gin.Context) APIResponse {
-	idParam := c.Param("id")
-	if idParam == "" {
-		return BuildResponse400("ID parameter is required")
+// satcomUpdateParams writes the values of data over the entry, if it is still at expectedVersion
+func satcomUpdateParams(data auth.CommonSatcomDatum, expectedVersion int32) auth.UpdateSatcomDataParams {
+	return auth.UpdateSatcomDataParams{
+		Company:         data.Company,
+		Category:        data.Category,
+		Type:            data.Type,
+		RecordedAt:      data.RecordedAt,
+		DbPort:          data.DbPort,
+		UiPort:          data.UiPort,
+		Url:             data.Url,
+		Ip:              data.Ip,
+		Status:          data.Status,
+		Labels:          data.Labels,
+		CustomFields:    data.CustomFields,
+		ID:              data.ID,
+		ExpectedVersion: expectedVersion,
 	}
+}
 
-	var id int32
-	if _, err := fmt.Sscanf(idParam, "%d", &id); err != nil {
-		return BuildResponse400("Invalid ID format")
+// DeleteSatcomData moves a satcom data entry to the recycle bin
+func (s *RESTService) deleteSatcomData(c *gin.Context) APIResponse {
+	id, _, errResp := s.authorizeSatcomID(c, true)
+	if errResp != nil {
+		return *errResp
 	}
 
 	ctx := context.Background()
 	db := s.dbConn.GetPool()
-	qtx := auth.New(db)
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		_asLogger.Errorf("Error starting transaction: %v", err)
+		return BuildResponse500("Failed to delete satcom data", err.Error())
+	}
+	defer tx.Rollback(ctx)
+	qtx := auth.New(tx)
 
-	// Check if record exists
-	_, err := qtx.GetSatcomDataById(ctx, id)
+	current, err := qtx.GetSatcomDataById(ctx, id)
 	if err != nil {
-		_asLogger.Errorf("Error getting satcom data: %v", err)
 		return BuildResponse404("Satcom data not found", false)
 	}
-
-	err = qtx.DeleteSatcomData(ctx, id)
+	if satcomIfMatchFails(c, current) {
+		c.Header("ETag", satcomETag(current))
+		return BuildResponse412("Satcom data was changed since it was read; reload and retry")
+	}
+	if s.requiresApproval(current) {
+		return s.submitSatcomChange(ctx, tx, c, SATCOM_OP_DELETE, current, nil, false)
+	}
+	deleted, err := qtx.SoftDeleteSatcomData(ctx, auth.SoftDeleteSatcomDataParams{
+		ID:        id,
+		DeletedBy: s.currentUserID(c),
+		Version:   current.Version,
+	})
 	if err != nil {
 		_asLogger.Errorf("Error deleting satcom data: %v", err)
 		return BuildResponse500("Failed to delete satcom data", err.Error())
 	}
+	if deleted == 0 {
+		return BuildResponse412("Satcom data was changed concurrently; reload and retry")
+	}
+	if err := s.recordSatcomVersion(ctx, qtx, c, id, SATCOM_OP_DELETE, pgtype.Int4{}); err != nil {
+		_asLogger.Errorf("Error recording history of satcom data %d: %v", id, err)
+		return BuildResponse500("Failed to delete satcom data", err.Error())
+	}
+	if err := tx.Commit(ctx); err != nil {
+		_asLogger.Errorf("Error committing deletion of satcom data %d: %v", id, err)
+		return BuildResponse500("Failed to delete satcom data", err.Error())
+	}
 
 	return BuildResponse200("Satcom data deleted successfully", nil)
 }
+
+// /api/satcom/conversion-issues - legacy values the typed schema migration could not convert (SUPER_ADMIN only)
+func (s *RESTService) getSatcomConversionIssues(c *gin.Context) APIResponse {
+	if !s.hasRole(c, ROLE_SUPER_ADMIN) {
+		return BuildResponse403("Only super admins can view conversion issues")
+	}
+
+	ctx := context.Background()
+	db := s.dbConn.GetPool()
+	qtx := auth.New(db)
+
+	issues, err := qtx.ListSatcomConversionIssues(ctx)
+	if err != nil {
+		_asLogger.Errorf("Error getting satcom conversion issues: %v", err)
+		return BuildResponse500("Failed to retrieve conversion issues", err.Error())
+	}
+
+	responseList := make([]model.SatcomConversionIssue, 0, len(issues))
+	for _, issue := range issues {
+		responseList = append(responseList, model.SatcomConversionIssue{
+			SatcomID:    issue.SatcomID,
+			Column:      issue.ColumnName,
+			LegacyValue: issue.LegacyValue.String,
+			Error:       issue.Error,
+			ReportedAt:  issue.ReportedAt.Time,
+		})
+	}
+
+	return BuildResponse200("Conversion issues retrieved successfully", responseList)
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+)
+
+// satcomStatsDimensions are the group-by dimensions of GET /api/satcom/stats in response order
+var satcomStatsDimensions = []string{"company", "category", "type", "status"}
+
+// satcomStatsCache keeps computed statistics for a short time so that dashboards polling
+// the endpoint do not run the aggregates on every request
+type satcomStatsCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]satcomStatsCacheEntry
+}
+
+type satcomStatsCacheEntry struct {
+	stats   model.SatcomStats
+	expires time.Time
+}
+
+// newSatcomStatsCache returns nil, which disables caching, unless a cache time is configured
+func newSatcomStatsCache(conf *model.StatsConfig) *satcomStatsCache {
+	if conf == nil || conf.CacheSeconds <= 0 {
+		return nil
+	}
+	return &satcomStatsCache{
+		ttl:     time.Duration(conf.CacheSeconds) * time.Second,
+		entries: make(map[string]satcomStatsCacheEntry),
+	}
+}
+
+func (cache *satcomStatsCache) get(key string, now time.Time) (model.SatcomStats, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	entry, ok := cache.entries[key]
+	if !ok || now.After(entry.expires) {
+		return model.SatcomStats{}, false
+	}
+	return entry.stats, true
+}
+
+func (cache *satcomStatsCache) put(key string, stats model.SatcomStats, now time.Time) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	for k, entry := range cache.entries {
+		if now.After(entry.expires) {
+			delete(cache.entries, k)
+		}
+	}
+	cache.entries[key] = satcomStatsCacheEntry{stats: stats, expires: now.Add(cache.ttl)}
+}
+
+// getSatcomStats answers GET /api/satcom/stats with counts of the live inventory grouped by
+// the groupBy dimensions, weekly activity, the entries that are down and the probe latency
+func (s *RESTService) getSatcomStats(c *gin.Context) APIResponse {
+	groups := satcomStatsDimensions
+	if v := strings.TrimSpace(c.Query("groupBy")); v != "" {
+		requested := make(map[string]bool)
+		for _, d := range strings.Split(v, ",") {
+			d = strings.ToLower(strings.TrimSpace(d))
+			if !containsString(satcomStatsDimensions, d) {
+				return BuildResponse400("groupBy must be a list of company, category, type and status")
+			}
+			requested[d] = true
+		}
+		groups = nil
+		for _, d := range satcomStatsDimensions {
+			if requested[d] {
+				groups = append(groups, d)
+			}
+		}
+	}
+	weeks := DEFAULT_STATS_WEEKS
+	if v := c.Query("weeks"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > MAX_STATS_WEEKS {
+			return BuildResponse400(fmt.Sprintf("weeks must be between 1 and %d", MAX_STATS_WEEKS))
+		}
+		weeks = n
+	}
+	window, err := parseWindow(c.Query("latencyWindow"), DEFAULT_STATS_LATENCY_WINDOW)
+	if err != nil {
+		return BuildResponse400(err.Error())
+	}
+	scope, errResp := s.satcomScope(c)
+	if errResp != nil {
+		return *errResp
+	}
+	company := optionalText(c.Query("company"))
+	if err := scope.restrict(&company); err != nil {
+		return BuildResponse403(err.Error())
+	}
+
+	now := time.Now().UTC()
+	key := fmt.Sprintf("%t|%s|%s|%d|%s", company.Valid, company.String, strings.Join(groups, ","), weeks, window)
+	if s.statsCache != nil && c.GetHeader("Cache-Control") != "no-cache" {
+		if stats, ok := s.statsCache.get(key, now); ok {
+			stats.Cached = true
+			return BuildResponse200("Satcom statistics", stats)
+		}
+	}
+
+	stats, err := s.computeSatcomStats(context.Background(), company, groups, weeks, window, now)
+	if err != nil {
+		_asLogger.Errorf("Error computing satcom statistics: %v", err)
+		return BuildResponse500("Failed to compute satcom statistics", err.Error())
+	}
+	if s.statsCache != nil {
+		s.statsCache.put(key, stats, now)
+	}
+	return BuildResponse200("Satcom statistics", stats)
+}
+
+func (s *RESTService) computeSatcomStats(ctx context.Context, company pgtype.Text, groups []string, weeks int, window time.Duration, now time.Time) (model.SatcomStats, error) {
+	qtx := auth.New(s.dbConn.GetPool())
+	stats := model.SatcomStats{
+		GeneratedAt: now,
+		Company:     company.String,
+		Counts:      make(map[string][]model.SatcomStatsCount),
+	}
+	for _, d := range groups {
+		stats.Counts[d] = make([]model.SatcomStatsCount, 0)
+	}
+
+	counts, err := qtx.CountSatcomDataByDimension(ctx, company)
+	if err != nil {
+		return stats, err
+	}
+	for _, row := range counts {
+		// Every entry is either up or down, so the status rows add up to the total
+		if row.Dimension == "status" {
+			stats.Total += row.Total
+		}
+		if list, ok := stats.Counts[row.Dimension]; ok {
+			stats.Counts[row.Dimension] = append(list, model.SatcomStatsCount{Value: row.Value, Count: row.Total})
+		}
+	}
+
+	firstWeek := satcomStatsFirstWeek(now, weeks)
+	activity, err := qtx.CountSatcomActivityByWeek(ctx, auth.CountSatcomActivityByWeekParams{
+		Since:   pgtype.Timestamptz{Time: firstWeek, Valid: true},
+		Company: company,
+	})
+	if err != nil {
+		return stats, err
+	}
+	stats.Weekly = bucketSatcomActivity(firstWeek, weeks, activity)
+
+	down, err := qtx.ListDownSatcomData(ctx, company)
+	if err != nil {
+		return stats, err
+	}
+	maintenance, err := loadSatcomMaintenance(ctx, qtx, company)
+	if err != nil {
+		return stats, err
+	}
+	stats.Down.Entries = make([]model.SatcomDownEntry, 0, len(down))
+	for _, data := range down {
+		entry := model.SatcomDownEntry{
+			ID:          data.ID,
+			Name:        data.Name.String,
+			Company:     data.Company,
+			Category:    data.Category,
+			Type:        data.Type,
+			URL:         data.Url,
+			Maintenance: maintenance.active(data, now) != nil,
+		}
+		if data.LastProbedAt.Valid {
+			entry.LastProbedAt = &data.LastProbedAt.Time
+		}
+		if entry.Maintenance {
+			stats.Down.InMaintenance++
+		}
+		stats.Down.Entries = append(stats.Down.Entries, entry)
+	}
+	stats.Down.Total = len(stats.Down.Entries)
+
+	latency, err := qtx.GetSatcomProbeLatencyStats(ctx, auth.GetSatcomProbeLatencyStatsParams{
+		Since:   pgtype.Timestamptz{Time: now.Add(-window), Valid: true},
+		Company: company,
+	})
+	if err != nil {
+		return stats, err
+	}
+	stats.Latency.Window = window.String()
+	stats.Latency.ByCheckType = make([]model.SatcomCheckLatencyStat, 0, len(latency))
+	var measured int64
+	var totalLatency float64
+	for _, row := range latency {
+		stat := model.SatcomCheckLatencyStat{
+			CheckType:    row.CheckType,
+			Probes:       row.Probes,
+			AvgLatencyMs: row.AvgLatencyMs,
+		}
+		if row.Probes > 0 {
+			stat.UptimePercent = float64(row.Successes) * 100 / float64(row.Probes)
+		}
+		stats.Latency.Probes += row.Probes
+		measured += row.Measured
+		totalLatency += row.AvgLatencyMs * float64(row.Measured)
+		stats.Latency.ByCheckType = append(stats.Latency.ByCheckType, stat)
+	}
+	if measured > 0 {
+		stats.Latency.AvgLatencyMs = totalLatency / float64(measured)
+	}
+	return stats, nil
+}
+
+// satcomStatsFirstWeek is the Monday, in UTC as in CountSatcomActivityByWeek, that starts
+// the oldest of the last weeks weeks, the current one included
+func satcomStatsFirstWeek(now time.Time, weeks int) time.Time {
+	today := now.UTC().Truncate(24 * time.Hour)
+	return today.AddDate(0, 0, -((int(today.Weekday())+6)%7)-7*(weeks-1))
+}
+
+// bucketSatcomActivity lays the weekly counts out over every week from firstWeek on,
+// with zeros for the weeks without activity
+func bucketSatcomActivity(firstWeek time.Time, weeks int, rows []auth.CountSatcomActivityByWeekRow) []model.SatcomWeeklyActivity {
+	byWeek := make(map[time.Time]auth.CountSatcomActivityByWeekRow)
+	for _, row := range rows {
+		byWeek[row.Week.Time.UTC()] = row
+	}
+	activity := make([]model.SatcomWeeklyActivity, 0, weeks)
+	for i := 0; i < weeks; i++ {
+		week := firstWeek.AddDate(0, 0, 7*i)
+		row := byWeek[week]
+		activity = append(activity, model.SatcomWeeklyActivity{
+			Week:          week,
+			Added:         row.Added,
+			Changed:       row.Changed,
+			StatusChanges: row.StatusChanges,
+		})
+	}
+	return activity
+}
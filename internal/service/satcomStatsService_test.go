@@ -0,0 +1,84 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+)
+
+func TestSatcomStatsFirstWeek(t *testing.T) {
+	date := func(s string) time.Time {
+		d, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatalf("parse %s: %v", s, err)
+		}
+		return d
+	}
+	berlin := time.FixedZone("CEST", 2*60*60)
+	tests := []struct {
+		name  string
+		now   time.Time
+		weeks int
+		want  string
+	}{
+		{"monday", date("2026-10-19T08:30:00Z"), 1, "2026-10-19T00:00:00Z"},
+		{"sunday night", date("2026-10-25T23:59:59Z"), 1, "2026-10-19T00:00:00Z"},
+		{"wednesday", date("2026-10-21T12:00:00Z"), 4, "2026-09-28T00:00:00Z"},
+		{"across a year", date("2026-01-01T00:00:00Z"), 2, "2025-12-22T00:00:00Z"},
+		{"local monday is a utc sunday", time.Date(2026, 10, 19, 1, 0, 0, 0, berlin), 1, "2026-10-12T00:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := satcomStatsFirstWeek(tt.now, tt.weeks)
+			if !got.Equal(date(tt.want)) || got.Location() != time.UTC {
+				t.Errorf("satcomStatsFirstWeek(%s, %d) = %s, want %s", tt.now, tt.weeks, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBucketSatcomActivity(t *testing.T) {
+	first := time.Date(2026, 9, 28, 0, 0, 0, 0, time.UTC)
+	week := func(i int, loc *time.Location) pgtype.Timestamptz {
+		return pgtype.Timestamptz{Time: first.AddDate(0, 0, 7*i).In(loc), Valid: true}
+	}
+	local := time.FixedZone("EST", -5*60*60)
+	tests := []struct {
+		name  string
+		weeks int
+		rows  []auth.CountSatcomActivityByWeekRow
+		want  [][3]int64
+	}{
+		{"no activity", 3, nil, [][3]int64{{0, 0, 0}, {0, 0, 0}, {0, 0, 0}}},
+		{"gaps are zero", 4, []auth.CountSatcomActivityByWeekRow{
+			{Week: week(0, time.UTC), Added: 2, Changed: 1},
+			{Week: week(2, time.UTC), StatusChanges: 5},
+		}, [][3]int64{{2, 1, 0}, {0, 0, 0}, {0, 0, 5}, {0, 0, 0}}},
+		{"weeks scanned in another zone", 2, []auth.CountSatcomActivityByWeekRow{
+			{Week: week(1, local), Added: 1, Changed: 2, StatusChanges: 3},
+		}, [][3]int64{{0, 0, 0}, {1, 2, 3}}},
+		{"rows outside the range are dropped", 2, []auth.CountSatcomActivityByWeekRow{
+			{Week: week(-1, time.UTC), Added: 9},
+			{Week: week(2, time.UTC), Added: 9},
+			{Week: week(1, time.UTC), Added: 1},
+		}, [][3]int64{{0, 0, 0}, {1, 0, 0}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := bucketSatcomActivity(first, tt.weeks, tt.rows)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d weeks, want %d", len(got), len(tt.want))
+			}
+			for i, activity := range got {
+				if !activity.Week.Equal(first.AddDate(0, 0, 7*i)) {
+					t.Errorf("week %d starts %s", i, activity.Week)
+				}
+				if counts := [3]int64{activity.Added, activity.Changed, activity.StatusChanges}; counts != tt.want[i] {
+					t.Errorf("week %d = %v, want %v", i, counts, tt.want[i])
+				}
+			}
+		})
+	}
+}
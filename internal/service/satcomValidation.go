@@ -0,0 +1,216 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+)
+
+// Layouts accepted for the legacy date and time fields
+var legacyDateLayouts = []string{"2006-01-02", DATE_FORMAT, "02/01/2006"}
+var legacyTimeLayouts = []string{"15:04:05", "15:04", "3:04 PM", "3:04:05 PM"}
+
+const legacyDateFormat = "2006-01-02"
+const legacyTimeFormat = "15:04:05"
+
+// satcomRecord holds the typed values of a validated SatcomDataInput
+type satcomRecord struct {
+	RecordedAt pgtype.Timestamptz
+	DbPort     pgtype.Int4
+	UiPort     pgtype.Int4
+	Ip         *netip.Addr
+	// Labels and CustomFields are nil when the input leaves them out
+	Labels       json.RawMessage
+	CustomFields json.RawMessage
+}
+
+// validateSatcomInput checks the binding tags and converts the text fields to their
+// column types, collecting every rejected field instead of stopping at the first one
+func (s *RESTService) validateSatcomInput(input *model.SatcomDataInput) (satcomRecord, []model.FieldError) {
+	var record satcomRecord
+	fieldErrs := validateInput(input)
+	rejected := make(map[string]bool)
+	for _, fe := range fieldErrs {
+		rejected[fe.Field] = true
+	}
+
+	if input.RecordedAt != "" {
+		recordedAt, err := time.Parse(time.RFC3339, strings.TrimSpace(input.RecordedAt))
+		if err != nil {
+			fieldErrs = append(fieldErrs, model.FieldError{Field: "recorded_at", Message: "must be an RFC 3339 timestamp"})
+		} else {
+			record.RecordedAt = pgtype.Timestamptz{Time: recordedAt, Valid: true}
+		}
+	} else if input.Date != "" {
+		recordedAt, errs := parseLegacyDateTime(input.Date, input.Time)
+		fieldErrs = append(fieldErrs, errs...)
+		if len(errs) == 0 {
+			record.RecordedAt = pgtype.Timestamptz{Time: recordedAt, Valid: true}
+		}
+	} else {
+		fieldErrs = append(fieldErrs, model.FieldError{Field: "recorded_at", Message: "recorded_at or date is required"})
+	}
+
+	if !rejected["db_port"] {
+		port, err := parsePort(string(input.DbPort))
+		if err != nil {
+			fieldErrs = append(fieldErrs, model.FieldError{Field: "db_port", Message: err.Error()})
+		}
+		record.DbPort = port
+	}
+	if !rejected["ui_port"] {
+		port, err := parsePort(string(input.UiPort))
+		if err != nil {
+			fieldErrs = append(fieldErrs, model.FieldError{Field: "ui_port", Message: err.Error()})
+		}
+		record.UiPort = port
+	}
+	if !rejected["ip"] {
+		addr, err := netip.ParseAddr(strings.TrimSpace(input.IP))
+		if err != nil {
+			fieldErrs = append(fieldErrs, model.FieldError{Field: "ip", Message: "must be an IPv4 or IPv6 address"})
+		} else {
+			record.Ip = &addr
+		}
+	}
+	if !rejected["url"] {
+		u, err := url.Parse(strings.TrimSpace(input.URL))
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			fieldErrs = append(fieldErrs, model.FieldError{Field: "url", Message: "must be an absolute URL"})
+		}
+	}
+	if input.Labels != nil {
+		fieldErrs = append(fieldErrs, validateLabels(input.Labels)...)
+		record.Labels, _ = json.Marshal(input.Labels)
+	}
+	if input.CustomFields != nil {
+		fieldErrs = append(fieldErrs, s.validateCustomFields(input.CustomFields)...)
+		record.CustomFields, _ = json.Marshal(input.CustomFields)
+	}
+	return record, fieldErrs
+}
+
+// parseLegacyDateTime combines the legacy date and time fields into one UTC instant
+func parseLegacyDateTime(dateStr, timeStr string) (time.Time, []model.FieldError) {
+	var date, clock time.Time
+	var fieldErrs []model.FieldError
+	var err error
+	for _, layout := range legacyDateLayouts {
+		if date, err = time.Parse(layout, strings.TrimSpace(dateStr)); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		fieldErrs = append(fieldErrs, model.FieldError{Field: "date", Message: "must be a date such as 2024-01-15"})
+	}
+	if strings.TrimSpace(timeStr) != "" {
+		for _, layout := range legacyTimeLayouts {
+			if clock, err = time.Parse(layout, strings.TrimSpace(timeStr)); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			fieldErrs = append(fieldErrs, model.FieldError{Field: "time", Message: "must be a time such as 10:30:00"})
+		}
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, time.UTC), fieldErrs
+}
+
+func parsePort(str string) (pgtype.Int4, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(str))
+	if err != nil {
+		return pgtype.Int4{}, fmt.Errorf("must be a number")
+	}
+	if port < 1 || port > 65535 {
+		return pgtype.Int4{}, fmt.Errorf("must be between 1 and 65535")
+	}
+	return pgtype.Int4{Int32: int32(port), Valid: true}, nil
+}
+
+// parseIPFilter accepts a single address or a CIDR block
+func parseIPFilter(str string) (netip.Prefix, error) {
+	if strings.Contains(str, "/") {
+		prefix, err := netip.ParsePrefix(str)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(str)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// toSatcomResponse renders a row in the format of the requested API version
+func toSatcomResponse(data auth.CommonSatcomDatum, version int) interface{} {
+	if version >= API_VERSION_2 {
+		response := model.SatcomDataResponseV2{
+			ID:       data.ID,
+			Company:  data.Company,
+			Category: data.Category,
+			Type:     data.Type,
+			URL:      data.Url,
+			Status:   data.Status,
+		}
+		if data.RecordedAt.Valid {
+			recordedAt := data.RecordedAt.Time.UTC()
+			response.RecordedAt = &recordedAt
+		}
+		if data.DbPort.Valid {
+			response.DbPort = &data.DbPort.Int32
+		}
+		if data.UiPort.Valid {
+			response.UiPort = &data.UiPort.Int32
+		}
+		if data.Ip != nil {
+			ip := data.Ip.String()
+			response.IP = &ip
+		}
+		if data.Name.Valid {
+			response.Name = &data.Name.String
+		}
+		response.StatusOverride = data.StatusOverride
+		response.Version = data.Version
+		response.Labels = decodeSatcomLabels(data.Labels)
+		response.CustomFields = decodeSatcomCustomFields(data.CustomFields)
+		if data.LastProbedAt.Valid {
+			lastProbedAt := data.LastProbedAt.Time.UTC()
+			response.LastProbedAt = &lastProbedAt
+		}
+		return response
+	}
+
+	response := model.SatcomDataResponse{
+		ID:       data.ID,
+		Name:     data.Name.String,
+		Company:  data.Company,
+		Category: data.Category,
+		Type:     data.Type,
+		URL:      data.Url,
+		Status:   data.Status,
+
+		Labels:       decodeSatcomLabels(data.Labels),
+		CustomFields: decodeSatcomCustomFields(data.CustomFields),
+	}
+	if data.RecordedAt.Valid {
+		response.Date = data.RecordedAt.Time.UTC().Format(legacyDateFormat)
+		response.Time = data.RecordedAt.Time.UTC().Format(legacyTimeFormat)
+	}
+	if data.DbPort.Valid {
+		response.DbPort = strconv.Itoa(int(data.DbPort.Int32))
+	}
+	if data.UiPort.Valid {
+		response.UiPort = strconv.Itoa(int(data.UiPort.Int32))
+	}
+	if data.Ip != nil {
+		response.IP = data.Ip.String()
+	}
+	return response
+}
@@ -0,0 +1,374 @@
+package service
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+	"github.com/rest/api/internal/util"
+)
+
+// satcomVault encrypts secret values with envelope encryption: every version gets a
+// random data key that encrypts the value, and the master key encrypts the data key.
+// Both are AES-256-GCM bound to the secret id and version, so stored rows cannot be
+// swapped between secrets.
+type satcomVault struct {
+	master       cipher.AEAD
+	keyID        string
+	revealRoles  map[string]bool
+	rotationDays int
+}
+
+// sealedSecret is an encrypted secret value as stored in satcom_secret_versions
+type sealedSecret struct {
+	ciphertext []byte
+	nonce      []byte
+	dataKey    []byte
+	keyNonce   []byte
+	keyID      string
+}
+
+// newSatcomVault loads the master key; a nil vault means the vault is disabled
+func newSatcomVault(conf *model.VaultConfig) (*satcomVault, error) {
+	if conf == nil || !conf.Enabled {
+		return nil, nil
+	}
+	key, err := loadVaultMasterKey(conf)
+	if err != nil {
+		return nil, err
+	}
+	v, err := newVaultMaster(key)
+	if err != nil {
+		return nil, err
+	}
+	v.revealRoles = map[string]bool{}
+	v.rotationDays = conf.RotationDays
+	if v.rotationDays == 0 {
+		v.rotationDays = DEFAULT_SECRET_ROTATION_DAYS
+	} else if v.rotationDays < 0 {
+		v.rotationDays = 0
+	}
+	for _, role := range conf.RevealRoles {
+		role = strings.ToUpper(strings.TrimSpace(role))
+		if role != COMPANY_ROLE_VIEWER && role != COMPANY_ROLE_EDITOR && role != COMPANY_ROLE_APPROVER && role != COMPANY_ROLE_ADMIN {
+			return nil, fmt.Errorf("vault.revealRoles: unknown role %q", role)
+		}
+		v.revealRoles[role] = true
+	}
+	if len(v.revealRoles) == 0 {
+		v.revealRoles[COMPANY_ROLE_ADMIN] = true
+	}
+	_asLogger.Infof("Credential vault enabled (master key %s)", v.keyID)
+	return v, nil
+}
+
+// newVaultMaster builds a vault that only holds the master key, identified by the first
+// eight bytes of its SHA-256 in hex
+func newVaultMaster(key []byte) (*satcomVault, error) {
+	master, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(key)
+	return &satcomVault{master: master, keyID: hex.EncodeToString(sum[:8])}, nil
+}
+
+// loadVaultMasterKey reads the master key from the configured file or else the environment
+func loadVaultMasterKey(conf *model.VaultConfig) ([]byte, error) {
+	var encoded, source string
+	if conf.MasterKeyFile != "" {
+		data, err := os.ReadFile(conf.MasterKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("vault.masterKeyFile: %v", err)
+		}
+		encoded, source = string(data), conf.MasterKeyFile
+	} else {
+		env := conf.MasterKeyEnv
+		if env == "" {
+			env = DEFAULT_VAULT_KEY_ENV
+		}
+		encoded, source = os.Getenv(env), "$"+env
+	}
+	encoded = strings.TrimSpace(encoded)
+	if encoded == "" {
+		return nil, fmt.Errorf("vault master key %s is empty", source)
+	}
+	key, err := decodeVaultKey(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("vault master key %s: %v", source, err)
+	}
+	return key, nil
+}
+
+// decodeVaultKey accepts a 32 byte key as 64 hex digits or as base64
+func decodeVaultKey(encoded string) ([]byte, error) {
+	if len(encoded) == 64 {
+		if key, err := hex.DecodeString(encoded); err == nil {
+			return key, nil
+		}
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		key, err = base64.RawStdEncoding.DecodeString(encoded)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("not hex or base64 encoded")
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("must be 32 bytes, got %d", len(key))
+	}
+	return key, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// secretAAD binds a ciphertext to the secret version it was written for
+func secretAAD(kind string, secretID int32, version int32) []byte {
+	return []byte(fmt.Sprintf("satcom-secret-%s/%d/%d", kind, secretID, version))
+}
+
+// seal encrypts one version of a secret value under a fresh data key
+func (v *satcomVault) seal(secretID int32, version int32, value []byte) (sealedSecret, error) {
+	dataKey, err := randomBytes(32)
+	if err != nil {
+		return sealedSecret{}, err
+	}
+	data, err := newGCM(dataKey)
+	if err != nil {
+		return sealedSecret{}, err
+	}
+	sealed := sealedSecret{keyID: v.keyID}
+	if sealed.nonce, err = randomBytes(data.NonceSize()); err != nil {
+		return sealedSecret{}, err
+	}
+	if sealed.keyNonce, err = randomBytes(v.master.NonceSize()); err != nil {
+		return sealedSecret{}, err
+	}
+	sealed.ciphertext = data.Seal(nil, sealed.nonce, value, secretAAD("value", secretID, version))
+	sealed.dataKey = v.master.Seal(nil, sealed.keyNonce, dataKey, secretAAD("key", secretID, version))
+	return sealed, nil
+}
+
+// open decrypts a stored secret version
+func (v *satcomVault) open(row auth.CommonSatcomSecretVersion) ([]byte, error) {
+	if row.KeyID != v.keyID {
+		return nil, fmt.Errorf("version %d is encrypted with master key %s, the vault holds %s", row.Version, row.KeyID, v.keyID)
+	}
+	dataKey, err := v.master.Open(nil, row.KeyNonce, row.DataKey, secretAAD("key", row.SecretID, row.Version))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt the data key of version %d", row.Version)
+	}
+	data, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	value, err := data.Open(nil, row.Nonce, row.Ciphertext, secretAAD("value", row.SecretID, row.Version))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt version %d", row.Version)
+	}
+	return value, nil
+}
+
+// rewrap re-encrypts the data key of a version written under the master key of from with
+// the master key of v; the value itself is left untouched
+func (v *satcomVault) rewrap(row auth.CommonSatcomSecretVersion, from *satcomVault) (dataKey []byte, keyNonce []byte, err error) {
+	if row.KeyID != from.keyID {
+		return nil, nil, fmt.Errorf("version %d is encrypted with master key %s, not %s", row.Version, row.KeyID, from.keyID)
+	}
+	aad := secretAAD("key", row.SecretID, row.Version)
+	plain, err := from.master.Open(nil, row.KeyNonce, row.DataKey, aad)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to decrypt the data key of secret %d version %d", row.SecretID, row.Version)
+	}
+	if keyNonce, err = randomBytes(v.master.NonceSize()); err != nil {
+		return nil, nil, err
+	}
+	return v.master.Seal(nil, keyNonce, plain, aad), keyNonce, nil
+}
+
+// canReveal reports whether the caller may read secret values in plain text
+func (v *satcomVault) canReveal(scope satcomScope) bool {
+	return scope.all || v.revealRoles[scope.role]
+}
+
+// secretRotationDue returns when a secret has to be rotated; nil when it never has to
+func secretRotationDue(rotationDays pgtype.Int4, rotatedAt pgtype.Timestamptz, defaultDays int) *time.Time {
+	days := defaultDays
+	if rotationDays.Valid {
+		days = int(rotationDays.Int32)
+	}
+	if days <= 0 || !rotatedAt.Valid {
+		return nil
+	}
+	due := rotatedAt.Time.AddDate(0, 0, days)
+	return &due
+}
+
+// SatcomSecretReminder periodically emails a reminder for every secret whose rotation
+// is due, repeating it while the secret stays overdue
+type SatcomSecretReminder struct {
+	dbConn       *util.DBConnectionWrapper
+	interval     time.Duration
+	remindEvery  time.Duration
+	rotationDays int
+	notifyEmails []string
+	mailer       *SmtpService
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewSatcomSecretReminder applies the configuration defaults and builds a reminder
+func NewSatcomSecretReminder(conf model.VaultConfig, dbConn *util.DBConnectionWrapper) *SatcomSecretReminder {
+	r := &SatcomSecretReminder{
+		dbConn:       dbConn,
+		interval:     time.Duration(conf.ReminderIntervalMinutes) * time.Minute,
+		remindEvery:  time.Duration(conf.RemindEveryDays) * 24 * time.Hour,
+		rotationDays: conf.RotationDays,
+		notifyEmails: conf.NotifyEmails,
+		mailer:       &SmtpService{},
+	}
+	if r.interval <= 0 {
+		r.interval = DEFAULT_SECRET_REMINDER_INTERVAL
+	}
+	if r.remindEvery <= 0 {
+		r.remindEvery = DEFAULT_SECRET_REMIND_EVERY
+	}
+	if r.rotationDays == 0 {
+		r.rotationDays = DEFAULT_SECRET_ROTATION_DAYS
+	}
+	return r
+}
+
+// Start looks for due rotations immediately and then on every interval until Stop is called
+func (r *SatcomSecretReminder) Start() {
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			if err := r.RunOnce(context.Background()); err != nil {
+				_asLogger.Errorf("Secret rotation reminder round failed: %v", err)
+			}
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	_asLogger.Infof("Secret rotation reminder started (interval %s, repeat every %s)", r.interval, r.remindEvery)
+}
+
+// Stop ends the background loop and waits for the current round to finish
+func (r *SatcomSecretReminder) Stop() {
+	if r.stop == nil {
+		return
+	}
+	close(r.stop)
+	<-r.done
+	r.stop = nil
+}
+
+// RunOnce reminds about every overdue secret not reminded about within remindEvery
+func (r *SatcomSecretReminder) RunOnce(ctx context.Context) error {
+	qtx := auth.New(r.dbConn.GetPool())
+	secrets, err := qtx.ListSatcomSecretsForRotation(ctx, pgtype.Text{})
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	recipients := map[string][]string{}
+	for _, secret := range secrets {
+		due := secretRotationDue(secret.RotationDays, secret.RotatedAt, r.rotationDays)
+		if due == nil || due.After(now) {
+			continue
+		}
+		if secret.RemindedAt.Valid && secret.RemindedAt.Time.Add(r.remindEvery).After(now) {
+			continue
+		}
+		to, ok := recipients[secret.Company]
+		if !ok {
+			if to, err = r.recipients(ctx, qtx, secret.Company); err != nil {
+				return err
+			}
+			recipients[secret.Company] = to
+		}
+		info := toSatcomSecretDue(secret, due, now)
+		sent := 0
+		for _, recipient := range to {
+			if err := r.mailer.SendSecretRotationMail(recipient, info); err != nil {
+				_asLogger.Errorf("Error sending rotation reminder for secret %d to %s: %v", secret.ID, recipient, err)
+				continue
+			}
+			sent++
+		}
+		if sent == 0 {
+			continue
+		}
+		if err := qtx.MarkSatcomSecretReminded(ctx, secret.ID); err != nil {
+			_asLogger.Errorf("Error marking secret %d as reminded: %v", secret.ID, err)
+		}
+		_asLogger.Infof("Sent rotation reminder for secret %s of satcom data %d to %d recipient(s)", secret.Name, secret.SatcomID, sent)
+	}
+	return nil
+}
+
+// recipients are the configured addresses, else the company's ADMIN members, else the
+// super admins
+func (r *SatcomSecretReminder) recipients(ctx context.Context, qtx *auth.Queries, company string) ([]string, error) {
+	if len(r.notifyEmails) > 0 {
+		return r.notifyEmails, nil
+	}
+	emails, err := qtx.ListCompanyMemberEmails(ctx, auth.ListCompanyMemberEmailsParams{Company: company, Role: COMPANY_ROLE_ADMIN})
+	if err != nil || len(emails) > 0 {
+		return emails, err
+	}
+	return qtx.GetActiveUserEmailsByRole(ctx, ROLE_SUPER_ADMIN)
+}
+
+// toSatcomSecretDue describes a secret listed for rotation; the value is always masked
+func toSatcomSecretDue(row auth.ListSatcomSecretsForRotationRow, due *time.Time, now time.Time) model.SatcomSecret {
+	secret := model.SatcomSecret{
+		ID:          row.ID,
+		SatcomID:    row.SatcomID,
+		Company:     row.Company,
+		Name:        row.Name,
+		Username:    row.Username,
+		Value:       SATCOM_SECRET_MASK,
+		RotatedAt:   row.RotatedAt.Time,
+		RotationDue: due,
+		Overdue:     due != nil && !due.After(now),
+	}
+	if row.RotationDays.Valid {
+		secret.RotationDays = &row.RotationDays.Int32
+	}
+	return secret
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+)
+
+func newTestVault(t *testing.T, fill byte) *satcomVault {
+	t.Helper()
+	v, err := newVaultMaster(bytes.Repeat([]byte{fill}, 32))
+	if err != nil {
+		t.Fatalf("newVaultMaster: %v", err)
+	}
+	return v
+}
+
+func sealedRow(secretID, version int32, sealed sealedSecret) auth.CommonSatcomSecretVersion {
+	return auth.CommonSatcomSecretVersion{
+		SecretID:   secretID,
+		Version:    version,
+		Ciphertext: sealed.ciphertext,
+		Nonce:      sealed.nonce,
+		DataKey:    sealed.dataKey,
+		KeyNonce:   sealed.keyNonce,
+		KeyID:      sealed.keyID,
+	}
+}
+
+func TestSatcomVaultRoundTrip(t *testing.T) {
+	v := newTestVault(t, 1)
+	other := newTestVault(t, 2)
+	value := []byte("s3cr3t-p@ss")
+	sealed, err := v.seal(7, 3, value)
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	if bytes.Contains(sealed.ciphertext, value) {
+		t.Fatal("ciphertext contains the value")
+	}
+	again, err := v.seal(7, 3, value)
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	if bytes.Equal(again.ciphertext, sealed.ciphertext) || bytes.Equal(again.dataKey, sealed.dataKey) {
+		t.Error("sealing twice reused the data key or nonce")
+	}
+
+	tamper := func(b []byte) []byte {
+		b = append([]byte(nil), b...)
+		b[0] ^= 0xff
+		return b
+	}
+	tests := []struct {
+		name    string
+		vault   *satcomVault
+		row     func(auth.CommonSatcomSecretVersion) auth.CommonSatcomSecretVersion
+		wantErr bool
+	}{
+		{"round trip", v, func(r auth.CommonSatcomSecretVersion) auth.CommonSatcomSecretVersion { return r }, false},
+		{"other secret", v, func(r auth.CommonSatcomSecretVersion) auth.CommonSatcomSecretVersion { r.SecretID = 8; return r }, true},
+		{"other version", v, func(r auth.CommonSatcomSecretVersion) auth.CommonSatcomSecretVersion { r.Version = 2; return r }, true},
+		{"other master key", other, func(r auth.CommonSatcomSecretVersion) auth.CommonSatcomSecretVersion { return r }, true},
+		{"key id relabelled", other, func(r auth.CommonSatcomSecretVersion) auth.CommonSatcomSecretVersion { r.KeyID = other.keyID; return r }, true},
+		{"ciphertext tampered", v, func(r auth.CommonSatcomSecretVersion) auth.CommonSatcomSecretVersion {
+			r.Ciphertext = tamper(r.Ciphertext)
+			return r
+		}, true},
+		{"data key tampered", v, func(r auth.CommonSatcomSecretVersion) auth.CommonSatcomSecretVersion {
+			r.DataKey = tamper(r.DataKey)
+			return r
+		}, true},
+		{"nonce swapped", v, func(r auth.CommonSatcomSecretVersion) auth.CommonSatcomSecretVersion {
+			r.Nonce = again.nonce
+			return r
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.vault.open(tt.row(sealedRow(7, 3, sealed)))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("open = %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("open: %v", err)
+			}
+			if !bytes.Equal(got, value) {
+				t.Errorf("open = %q, want %q", got, value)
+			}
+		})
+	}
+}
+
+func TestSatcomVaultRewrap(t *testing.T) {
+	from := newTestVault(t, 1)
+	to := newTestVault(t, 2)
+	sealed, err := from.seal(4, 1, []byte("value"))
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	row := sealedRow(4, 1, sealed)
+	dataKey, keyNonce, err := to.rewrap(row, from)
+	if err != nil {
+		t.Fatalf("rewrap: %v", err)
+	}
+	row.DataKey, row.KeyNonce, row.KeyID = dataKey, keyNonce, to.keyID
+	if got, err := to.open(row); err != nil || string(got) != "value" {
+		t.Errorf("open after rewrap = %q, %v", got, err)
+	}
+	if _, err := from.open(row); err == nil {
+		t.Error("the old master key still opens the rewrapped version")
+	}
+	if _, _, err := to.rewrap(row, from); err == nil {
+		t.Error("rewrapping a version of another master key succeeded")
+	}
+}
+
+func TestDecodeVaultKey(t *testing.T) {
+	key := bytes.Repeat([]byte{0xab}, 32)
+	tests := []struct {
+		name    string
+		encoded string
+		wantErr bool
+	}{
+		{"hex", hex.EncodeToString(key), false},
+		{"base64", "q6urq6urq6urq6urq6urq6urq6urq6urq6urq6urq6s=", false},
+		{"raw base64", "q6urq6urq6urq6urq6urq6urq6urq6urq6urq6urq6s", false},
+		{"short", "q6urq6ur", true},
+		{"garbage", "not a key!", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeVaultKey(tt.encoded)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("decodeVaultKey(%q) succeeded", tt.encoded)
+				}
+				return
+			}
+			if err != nil || !bytes.Equal(got, key) {
+				t.Errorf("decodeVaultKey(%q) = %x, %v", tt.encoded, got, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,838 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+	"github.com/rest/api/internal/util"
+)
+
+const scimContentType = "application/scim+json"
+const scimDefaultCount = 100
+
+var scimFilterRegex = regexp.MustCompile(`(?i)^\s*([a-z]+(?:\.[a-z]+)?)\s+eq\s+(?:"([^"]*)"|(true|false))\s*$`)
+var scimValuePathRegex = regexp.MustCompile(`(?i)^members\[\s*value\s+eq\s+"([^"]*)"\s*\]$`)
+
+// scimResponse carries a SCIM body; SCIM does not use the APIResponse envelope
+type scimResponse struct {
+	status int
+	body   interface{}
+	etag   string
+}
+
+func writeScimResponse(c *gin.Context, resp scimResponse) {
+	if resp.etag != "" {
+		c.Header("ETag", resp.etag)
+	}
+	if resp.body == nil {
+		c.Status(resp.status)
+		return
+	}
+	c.Header("Content-Type", scimContentType)
+	c.JSON(resp.status, resp.body)
+}
+
+func scimError(status int, scimType, detail string) scimResponse {
+	return scimResponse{status: status, body: model.ScimError{
+		Schemas:  []string{model.ScimErrorSchema},
+		ScimType: scimType,
+		Detail:   detail,
+		Status:   strconv.Itoa(status),
+	}}
+}
+
+// checkScimAuth validates the dedicated provisioning bearer token
+func (s *RESTService) checkScimAuth(c *gin.Context) bool {
+	if len(s.scimToken) == 0 {
+		return false
+	}
+	authHeader := c.Request.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		return false
+	}
+	token := []byte(strings.TrimPrefix(authHeader, "Bearer "))
+	return subtle.ConstantTimeCompare(token, s.scimToken) == 1
+}
+
+// scimUserState is the mutable subset of a user that SCIM operations change
+type scimUserState struct {
+	UserName string
+	Email    string
+	Phone    string
+	Role     string
+	Active   bool
+}
+
+func scimStateOf(user auth.CommonUser) scimUserState {
+	return scimUserState{
+		UserName: user.UserName,
+		Email:    user.Email,
+		Phone:    user.Phone,
+		Role:     user.Role,
+		Active:   user.Status == STATUS_ACTIVE,
+	}
+}
+
+func scimUserETag(user auth.CommonUser) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d|%s|%s|%s|%s|%s",
+		user.UserID, user.UserName, user.Email, user.Phone, user.Role, user.Status)))
+	return fmt.Sprintf(`W/"%x"`, sum[:8])
+}
+
+func toScimUser(user auth.CommonUser) model.ScimUser {
+	active := user.Status == STATUS_ACTIVE
+	id := strconv.Itoa(int(user.UserID))
+	scimUser := model.ScimUser{
+		Schemas:  []string{model.ScimUserSchema},
+		ID:       id,
+		UserName: user.UserName,
+		Active:   &active,
+		Emails:   []model.ScimMultiValue{{Value: user.Email, Type: "work", Primary: true}},
+		Roles:    []model.ScimMultiValue{{Value: user.Role, Primary: true}},
+		Groups: []model.ScimMultiValue{{
+			Value:   user.Role,
+			Display: user.Role,
+			Ref:     SCIM_API_BASE + "/Groups/" + user.Role,
+		}},
+		Meta: &model.ScimMeta{
+			ResourceType: "User",
+			Location:     SCIM_API_BASE + "/Users/" + id,
+			Version:      scimUserETag(user),
+		},
+	}
+	if user.CreatedAt.Valid {
+		scimUser.Meta.Created = user.CreatedAt.Time.Format("2006-01-02T15:04:05Z07:00")
+	}
+	if user.Phone != "" {
+		scimUser.PhoneNumbers = []model.ScimMultiValue{{Value: user.Phone, Type: "work"}}
+	}
+	return scimUser
+}
+
+// stateFromScimUser builds the state of a full resource representation (POST/PUT)
+func stateFromScimUser(in model.ScimUser) scimUserState {
+	state := scimUserState{UserName: strings.TrimSpace(in.UserName), Role: ROLE_USER, Active: true}
+	state.Email = primaryValue(in.Emails)
+	state.Phone = primaryValue(in.PhoneNumbers)
+	if role := primaryValue(in.Roles); role != "" {
+		state.Role = strings.ToUpper(role)
+	}
+	if in.Active != nil {
+		state.Active = *in.Active
+	}
+	return state
+}
+
+func primaryValue(values []model.ScimMultiValue) string {
+	for _, v := range values {
+		if v.Primary {
+			return strings.TrimSpace(v.Value)
+		}
+	}
+	if len(values) > 0 {
+		return strings.TrimSpace(values[0].Value)
+	}
+	return ""
+}
+
+func (st scimUserState) status() string {
+	if st.Active {
+		return STATUS_ACTIVE
+	}
+	return STATUS_INACTIVE
+}
+
+func (st scimUserState) validate() *scimResponse {
+	if st.UserName == "" || st.Email == "" {
+		resp := scimError(400, "invalidValue", "userName and a primary email are required")
+		return &resp
+	}
+	if !model.IsValidRoleName(st.Role) {
+		resp := scimError(400, "invalidValue", fmt.Sprintf("unknown role %s", st.Role))
+		return &resp
+	}
+	return nil
+}
+
+// scimRoleChangeDenied rejects role changes into or out of SUPER_ADMIN; super admins are
+// only managed in the application, never by the identity provider
+func scimRoleChangeDenied(from, to string) *scimResponse {
+	if from != to && (from == ROLE_SUPER_ADMIN || to == ROLE_SUPER_ADMIN) {
+		resp := scimError(400, "mutability", fmt.Sprintf("role %s cannot be granted or revoked through SCIM", ROLE_SUPER_ADMIN))
+		return &resp
+	}
+	return nil
+}
+
+// parseScimID parses a user id; ids outside the int4 range match no user
+func parseScimID(str string) (int32, error) {
+	id, err := strconv.ParseInt(str, 10, 32)
+	return int32(id), err
+}
+
+// checkScimUniqueness rejects a state that collides with another user (excludeID is the user being changed)
+func checkScimUniqueness(ctx context.Context, qtx *auth.Queries, st scimUserState, excludeID int32) *scimResponse {
+	lookups := []struct {
+		field  string
+		value  string
+		lookup func(context.Context, string) (auth.CommonUser, error)
+	}{
+		{"userName", st.UserName, qtx.GetUserByUserName},
+		{"email", st.Email, qtx.GetUserByEmail},
+		{"phone", st.Phone, qtx.GetUserByPhone},
+	}
+	for _, l := range lookups {
+		if l.value == "" {
+			continue
+		}
+		if other, err := l.lookup(ctx, l.value); err == nil && other.UserID != excludeID {
+			resp := scimError(409, "uniqueness", fmt.Sprintf("%s %s is already in use", l.field, l.value))
+			return &resp
+		}
+	}
+	return nil
+}
+
+func (s *RESTService) scimLoadUser(ctx context.Context, qtx *auth.Queries, idParam string) (auth.CommonUser, *scimResponse) {
+	id, err := parseScimID(idParam)
+	if err != nil {
+		resp := scimError(404, "", "User not found")
+		return auth.CommonUser{}, &resp
+	}
+	user, err := qtx.GetUserById(ctx, id)
+	if err != nil {
+		resp := scimError(404, "", "User not found")
+		return auth.CommonUser{}, &resp
+	}
+	return user, nil
+}
+
+// ifMatchFails returns true when the request carries an If-Match header that does not match etag
+func ifMatchFails(c *gin.Context, etag string) bool {
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" || ifMatch == "*" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifMatch, ",") {
+		if strings.TrimSpace(candidate) == etag {
+			return false
+		}
+	}
+	return true
+}
+
+// GET /scim/v2/Users - supports filter (userName eq, emails.value eq, id eq), startIndex and count
+func (s *RESTService) scimListUsers(c *gin.Context) scimResponse {
+	ctx := context.Background()
+	qtx := auth.New(s.dbConn.GetPool())
+
+	// The offset is an int4, so the index is clamped before the conversion
+	startIndex, _ := strconv.Atoi(c.DefaultQuery("startIndex", "1"))
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	if startIndex > math.MaxInt32 {
+		startIndex = math.MaxInt32
+	}
+	count, err := strconv.Atoi(c.DefaultQuery("count", strconv.Itoa(scimDefaultCount)))
+	if err != nil || count < 0 {
+		count = scimDefaultCount
+	}
+	if count > MAX_PAGE_LIMIT {
+		count = MAX_PAGE_LIMIT
+	}
+
+	resources := make([]model.ScimUser, 0)
+	if filter := c.Query("filter"); filter != "" {
+		match := scimFilterRegex.FindStringSubmatch(filter)
+		if match == nil {
+			return scimError(400, "invalidFilter", "Only 'userName eq', 'emails.value eq' and 'id eq' filters are supported")
+		}
+		var user auth.CommonUser
+		switch strings.ToLower(match[1]) {
+		case "username":
+			user, err = qtx.GetUserByUserName(ctx, match[2])
+		case "emails.value", "emails":
+			user, err = qtx.GetUserByEmail(ctx, strings.ToLower(match[2]))
+		case "id":
+			var id int32
+			if id, err = parseScimID(match[2]); err == nil {
+				user, err = qtx.GetUserById(ctx, id)
+			}
+		default:
+			return scimError(400, "invalidFilter", fmt.Sprintf("Filtering on %s is not supported", match[1]))
+		}
+		if err == nil && startIndex == 1 && count > 0 {
+			resources = append(resources, toScimUser(user))
+		}
+		total := int64(0)
+		if err == nil {
+			total = 1
+		}
+		return scimResponse{status: 200, body: model.ScimListResponse{
+			Schemas:      []string{model.ScimListSchema},
+			TotalResults: total,
+			StartIndex:   startIndex,
+			ItemsPerPage: len(resources),
+			Resources:    resources,
+		}}
+	}
+
+	total, err := qtx.CountUsers(ctx, auth.CountUsersParams{})
+	if err != nil {
+		_asLogger.Errorf("Error counting users for SCIM: %v", err)
+		return scimError(500, "", "Failed to list users")
+	}
+	if count > 0 {
+		rows, err := qtx.ListUsers(ctx, auth.ListUsersParams{
+			RowLimit:  int32(count),
+			RowOffset: int32(startIndex - 1),
+		})
+		if err != nil {
+			_asLogger.Errorf("Error listing users for SCIM: %v", err)
+			return scimError(500, "", "Failed to list users")
+		}
+		for _, row := range rows {
+			resources = append(resources, toScimUser(auth.CommonUser{
+				UserID:    row.UserID,
+				UserName:  row.UserName,
+				Email:     row.Email,
+				Phone:     row.Phone,
+				Role:      row.Role,
+				Status:    row.Status,
+				CreatedAt: row.CreatedAt,
+			}))
+		}
+	}
+	return scimResponse{status: 200, body: model.ScimListResponse{
+		Schemas:      []string{model.ScimListSchema},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}}
+}
+
+// GET /scim/v2/Users/:id
+func (s *RESTService) scimGetUser(c *gin.Context) scimResponse {
+	qtx := auth.New(s.dbConn.GetPool())
+	user, errResp := s.scimLoadUser(context.Background(), qtx, c.Param("id"))
+	if errResp != nil {
+		return *errResp
+	}
+	etag := scimUserETag(user)
+	if c.GetHeader("If-None-Match") == etag {
+		return scimResponse{status: 304, etag: etag}
+	}
+	return scimResponse{status: 200, body: toScimUser(user), etag: etag}
+}
+
+// POST /scim/v2/Users
+func (s *RESTService) scimCreateUser(c *gin.Context) scimResponse {
+	var input model.ScimUser
+	if !parseInput(c, &input) {
+		return scimError(400, "invalidSyntax", "Invalid SCIM user resource")
+	}
+	state := stateFromScimUser(input)
+	state.Email = strings.ToLower(state.Email)
+	if errResp := state.validate(); errResp != nil {
+		return *errResp
+	}
+	if errResp := scimRoleChangeDenied("", state.Role); errResp != nil {
+		return *errResp
+	}
+
+	ctx := context.Background()
+	qtx := auth.New(s.dbConn.GetPool())
+	if errResp := checkScimUniqueness(ctx, qtx, state, 0); errResp != nil {
+		return *errResp
+	}
+
+	password, pssValid := input.Password, true
+	if password == "" {
+		// Provisioned accounts without a password must reset it before the first login
+		password, pssValid = util.EncodeToString(16), false
+	}
+	err := qtx.ImportUser(ctx, auth.ImportUserParams{
+		UserName: state.UserName,
+		Email:    state.Email,
+		Phone:    state.Phone,
+		Pass:     s.getHashOf(password),
+		PssValid: pssValid,
+		Role:     state.Role,
+		Status:   state.status(),
+	})
+	if err != nil {
+		_asLogger.Errorf("Error provisioning user %s: %v", state.UserName, err)
+		return scimError(500, "", "Failed to create user")
+	}
+	user, err := qtx.GetUserByUserName(ctx, state.UserName)
+	if err != nil {
+		return scimError(500, "", "Failed to read created user")
+	}
+	_asLogger.Infof("SCIM provisioned user %s (%d)", user.UserName, user.UserID)
+	c.Header("Location", SCIM_API_BASE+"/Users/"+strconv.Itoa(int(user.UserID)))
+	return scimResponse{status: 201, body: toScimUser(user), etag: scimUserETag(user)}
+}
+
+// PUT /scim/v2/Users/:id - replaces the user attributes
+func (s *RESTService) scimReplaceUser(c *gin.Context) scimResponse {
+	var input model.ScimUser
+	if !parseInput(c, &input) {
+		return scimError(400, "invalidSyntax", "Invalid SCIM user resource")
+	}
+	return s.scimModifyUser(c, func(current scimUserState) (scimUserState, *scimResponse) {
+		return stateFromScimUser(input), nil
+	})
+}
+
+// PATCH /scim/v2/Users/:id - applies PatchOp operations
+func (s *RESTService) scimPatchUser(c *gin.Context) scimResponse {
+	var input model.ScimPatchRequest
+	if !parseInput(c, &input) || len(input.Operations) == 0 {
+		return scimError(400, "invalidSyntax", "Invalid SCIM PatchOp request")
+	}
+	return s.scimModifyUser(c, func(current scimUserState) (scimUserState, *scimResponse) {
+		for _, op := range input.Operations {
+			if errResp := applyScimUserOp(&current, op); errResp != nil {
+				return current, errResp
+			}
+		}
+		return current, nil
+	})
+}
+
+// scimModifyUser loads the user, checks If-Match, applies the change and persists it in one transaction
+func (s *RESTService) scimModifyUser(c *gin.Context, change func(scimUserState) (scimUserState, *scimResponse)) scimResponse {
+	ctx := context.Background()
+	tx, err := s.dbConn.GetPool().Begin(ctx)
+	if err != nil {
+		return scimError(500, "", "Failed to update user")
+	}
+	defer tx.Rollback(ctx)
+	qtx := auth.New(tx)
+
+	user, errResp := s.scimLoadUser(ctx, qtx, c.Param("id"))
+	if errResp != nil {
+		return *errResp
+	}
+	if ifMatchFails(c, scimUserETag(user)) {
+		return scimError(412, "", "Resource version does not match If-Match")
+	}
+	state, errResp := change(scimStateOf(user))
+	if errResp != nil {
+		return *errResp
+	}
+	state.Email = strings.ToLower(state.Email)
+	if errResp := state.validate(); errResp != nil {
+		return *errResp
+	}
+	if errResp := scimRoleChangeDenied(user.Role, state.Role); errResp != nil {
+		return *errResp
+	}
+	if errResp := checkScimUniqueness(ctx, qtx, state, user.UserID); errResp != nil {
+		return *errResp
+	}
+
+	err = qtx.UpdateUser(ctx, auth.UpdateUserParams{
+		UserName: state.UserName,
+		Email:    state.Email,
+		Phone:    state.Phone,
+		Role:     state.Role,
+		UserID:   user.UserID,
+	})
+	if err == nil {
+		err = qtx.UpdateUserStatus(ctx, auth.UpdateUserStatusParams{Status: state.status(), UserID: user.UserID})
+	}
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+	if err != nil {
+		_asLogger.Errorf("Error updating SCIM user %d: %v", user.UserID, err)
+		return scimError(500, "", "Failed to update user")
+	}
+	if user.Status != state.status() {
+		_asLogger.Infof("SCIM changed status of user %s (%d) to %s", state.UserName, user.UserID, state.status())
+	}
+
+	updated, err := auth.New(s.dbConn.GetPool()).GetUserById(ctx, user.UserID)
+	if err != nil {
+		return scimError(500, "", "Failed to read updated user")
+	}
+	return scimResponse{status: 200, body: toScimUser(updated), etag: scimUserETag(updated)}
+}
+
+// applyScimUserOp applies one PatchOp operation to the user state
+func applyScimUserOp(state *scimUserState, op model.ScimPatchOperation) *scimResponse {
+	opName := strings.ToLower(op.Op)
+	if opName != "add" && opName != "replace" && opName != "remove" {
+		resp := scimError(400, "invalidSyntax", fmt.Sprintf("unsupported op %s", op.Op))
+		return &resp
+	}
+	if op.Path == "" {
+		values, ok := op.Value.(map[string]interface{})
+		if !ok || opName == "remove" {
+			resp := scimError(400, "noTarget", "operation without path requires an object value")
+			return &resp
+		}
+		for attr, value := range values {
+			if errResp := setScimUserAttr(state, opName, attr, value); errResp != nil {
+				return errResp
+			}
+		}
+		return nil
+	}
+	return setScimUserAttr(state, opName, op.Path, op.Value)
+}
+
+func setScimUserAttr(state *scimUserState, opName, path string, value interface{}) *scimResponse {
+	// emails[type eq "work"].value and emails.value all address the single email
+	attr := strings.ToLower(path)
+	if i := strings.IndexAny(attr, "[."); i > 0 {
+		attr = attr[:i]
+	}
+	str := scimStringValue(value)
+	switch attr {
+	case "active":
+		if opName == "remove" {
+			state.Active = false
+			return nil
+		}
+		active, err := strconv.ParseBool(str)
+		if err != nil {
+			resp := scimError(400, "invalidValue", "active must be a boolean")
+			return &resp
+		}
+		state.Active = active
+	case "username":
+		state.UserName = strings.TrimSpace(str)
+	case "emails":
+		if opName == "remove" {
+			resp := scimError(400, "mutability", "email is required and cannot be removed")
+			return &resp
+		}
+		state.Email = strings.TrimSpace(str)
+	case "phonenumbers":
+		if opName == "remove" {
+			str = ""
+		}
+		state.Phone = strings.TrimSpace(str)
+	case "roles":
+		if opName == "remove" || str == "" {
+			state.Role = ROLE_USER
+		} else {
+			state.Role = strings.ToUpper(str)
+		}
+	case "externalid", "displayname", "name":
+		// Not stored, accepted for compatibility with provisioning clients
+	default:
+		resp := scimError(400, "invalidPath", fmt.Sprintf("unsupported attribute %s", path))
+		return &resp
+	}
+	return nil
+}
+
+// scimStringValue extracts a scalar from a PATCH value which may be a scalar or a multi-valued list
+func scimStringValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case map[string]interface{}:
+		return scimStringValue(v["value"])
+	case []interface{}:
+		for _, item := range v {
+			if m, ok := item.(map[string]interface{}); ok && m["primary"] == true {
+				return scimStringValue(m)
+			}
+		}
+		if len(v) > 0 {
+			return scimStringValue(v[0])
+		}
+	}
+	return ""
+}
+
+// DELETE /scim/v2/Users/:id
+func (s *RESTService) scimDeleteUser(c *gin.Context) scimResponse {
+	ctx := context.Background()
+	tx, err := s.dbConn.GetPool().Begin(ctx)
+	if err != nil {
+		return scimError(500, "", "Failed to delete user")
+	}
+	defer tx.Rollback(ctx)
+	qtx := auth.New(tx)
+
+	user, errResp := s.scimLoadUser(ctx, qtx, c.Param("id"))
+	if errResp != nil {
+		return *errResp
+	}
+	if ifMatchFails(c, scimUserETag(user)) {
+		return scimError(412, "", "Resource version does not match If-Match")
+	}
+	// Memberships and linked identities have no foreign key to users and are removed with the user
+	if err := qtx.DeleteUserCompanyMemberships(ctx, user.UserID); err != nil {
+		_asLogger.Errorf("Error deleting company memberships of SCIM user %d: %v", user.UserID, err)
+		return scimError(500, "", "Failed to delete user")
+	}
+	if err := qtx.DeleteUserIdentities(ctx, user.UserID); err != nil {
+		_asLogger.Errorf("Error deleting linked identities of SCIM user %d: %v", user.UserID, err)
+		return scimError(500, "", "Failed to delete user")
+	}
+	if err := qtx.DeleteUser(ctx, user.UserID); err != nil {
+		_asLogger.Errorf("Error deleting SCIM user %d: %v", user.UserID, err)
+		return scimError(500, "", "Failed to delete user")
+	}
+	if err := tx.Commit(ctx); err != nil {
+		_asLogger.Errorf("Error committing deletion of SCIM user %d: %v", user.UserID, err)
+		return scimError(500, "", "Failed to delete user")
+	}
+	_asLogger.Infof("SCIM deprovisioned user %s (%d)", user.UserName, user.UserID)
+	return scimResponse{status: 204}
+}
+
+// ---------------------------- Groups (roles) ----------------------------
+
+func (s *RESTService) scimBuildGroup(ctx context.Context, qtx *auth.Queries, role string, withMembers bool) (model.ScimGroup, error) {
+	group := model.ScimGroup{
+		Schemas:     []string{model.ScimGroupSchema},
+		ID:          role,
+		DisplayName: role,
+		Members:     make([]model.ScimMultiValue, 0),
+	}
+	memberIDs := make([]string, 0)
+	if withMembers {
+		params := auth.ListUsersParams{Role: getSQLString(role), RowLimit: MAX_PAGE_LIMIT}
+		for {
+			rows, err := qtx.ListUsers(ctx, params)
+			if err != nil {
+				return group, err
+			}
+			for _, row := range rows {
+				id := strconv.Itoa(int(row.UserID))
+				memberIDs = append(memberIDs, id)
+				group.Members = append(group.Members, model.ScimMultiValue{
+					Value:   id,
+					Display: row.UserName,
+					Ref:     SCIM_API_BASE + "/Users/" + id,
+				})
+			}
+			if len(rows) < MAX_PAGE_LIMIT {
+				break
+			}
+			params.AfterID = ConvertInt32ToPgInt4(rows[len(rows)-1].UserID)
+		}
+	}
+	sum := sha256.Sum256([]byte(role + "|" + strings.Join(memberIDs, ",")))
+	group.Meta = &model.ScimMeta{
+		ResourceType: "Group",
+		Location:     SCIM_API_BASE + "/Groups/" + role,
+		Version:      fmt.Sprintf(`W/"%x"`, sum[:8]),
+	}
+	return group, nil
+}
+
+// GET /scim/v2/Groups - supports filter displayName eq and excludedAttributes=members
+func (s *RESTService) scimListGroups(c *gin.Context) scimResponse {
+	ctx := context.Background()
+	qtx := auth.New(s.dbConn.GetPool())
+	withMembers := !strings.Contains(strings.ToLower(c.Query("excludedAttributes")), "members")
+
+	roles := model.ValidRoleNames()
+	if filter := c.Query("filter"); filter != "" {
+		match := scimFilterRegex.FindStringSubmatch(filter)
+		if match == nil || !strings.EqualFold(match[1], "displayName") && !strings.EqualFold(match[1], "id") {
+			return scimError(400, "invalidFilter", "Only 'displayName eq' and 'id eq' filters are supported")
+		}
+		roles = []string{}
+		if model.IsValidRoleName(strings.ToUpper(match[2])) {
+			roles = append(roles, strings.ToUpper(match[2]))
+		}
+	}
+	groups := make([]model.ScimGroup, 0, len(roles))
+	for _, role := range roles {
+		group, err := s.scimBuildGroup(ctx, qtx, role, withMembers)
+		if err != nil {
+			_asLogger.Errorf("Error building SCIM group %s: %v", role, err)
+			return scimError(500, "", "Failed to list groups")
+		}
+		groups = append(groups, group)
+	}
+	return scimResponse{status: 200, body: model.ScimListResponse{
+		Schemas:      []string{model.ScimListSchema},
+		TotalResults: int64(len(groups)),
+		StartIndex:   1,
+		ItemsPerPage: len(groups),
+		Resources:    groups,
+	}}
+}
+
+// GET /scim/v2/Groups/:id
+func (s *RESTService) scimGetGroup(c *gin.Context) scimResponse {
+	role := strings.ToUpper(c.Param("id"))
+	if !model.IsValidRoleName(role) {
+		return scimError(404, "", "Group not found")
+	}
+	group, err := s.scimBuildGroup(context.Background(), auth.New(s.dbConn.GetPool()), role, true)
+	if err != nil {
+		return scimError(500, "", "Failed to read group")
+	}
+	if c.GetHeader("If-None-Match") == group.Meta.Version {
+		return scimResponse{status: 304, etag: group.Meta.Version}
+	}
+	return scimResponse{status: 200, body: group, etag: group.Meta.Version}
+}
+
+// PATCH and PUT /scim/v2/Groups/:id - membership changes move users into or out of the role.
+// Users removed from a group fall back to the USER role.
+func (s *RESTService) scimModifyGroup(c *gin.Context, replace bool) scimResponse {
+	role := strings.ToUpper(c.Param("id"))
+	if !model.IsValidRoleName(role) {
+		return scimError(404, "", "Group not found")
+	}
+	if errResp := scimRoleChangeDenied("", role); errResp != nil {
+		return *errResp
+	}
+	var add, remove []string
+	replaceAll := replace
+	if replace {
+		var input model.ScimGroup
+		if !parseInput(c, &input) {
+			return scimError(400, "invalidSyntax", "Invalid SCIM group resource")
+		}
+		for _, member := range input.Members {
+			add = append(add, member.Value)
+		}
+	} else {
+		var input model.ScimPatchRequest
+		if !parseInput(c, &input) || len(input.Operations) == 0 {
+			return scimError(400, "invalidSyntax", "Invalid SCIM PatchOp request")
+		}
+		for _, op := range input.Operations {
+			opName := strings.ToLower(op.Op)
+			path := strings.ToLower(op.Path)
+			switch {
+			case opName == "remove" && scimValuePathRegex.MatchString(op.Path):
+				remove = append(remove, scimValuePathRegex.FindStringSubmatch(op.Path)[1])
+			case path == "members" || path == "":
+				ids := scimMemberIDs(op.Value)
+				switch opName {
+				case "add":
+					add = append(add, ids...)
+				case "remove":
+					if len(ids) == 0 {
+						replaceAll = true
+					}
+					remove = append(remove, ids...)
+				case "replace":
+					replaceAll = true
+					add = append(add, ids...)
+				}
+			case path == "displayname":
+				// Role names are fixed; renames are ignored
+			default:
+				return scimError(400, "invalidPath", fmt.Sprintf("unsupported path %s", op.Path))
+			}
+		}
+	}
+
+	ctx := context.Background()
+	tx, err := s.dbConn.GetPool().Begin(ctx)
+	if err != nil {
+		return scimError(500, "", "Failed to update group")
+	}
+	defer tx.Rollback(ctx)
+	qtx := auth.New(tx)
+
+	current, err := s.scimBuildGroup(ctx, qtx, role, true)
+	if err != nil {
+		return scimError(500, "", "Failed to update group")
+	}
+	if ifMatchFails(c, current.Meta.Version) {
+		return scimError(412, "", "Resource version does not match If-Match")
+	}
+	keep := make(map[string]bool)
+	for _, id := range add {
+		keep[id] = true
+	}
+	if replaceAll {
+		for _, member := range current.Members {
+			if !keep[member.Value] {
+				remove = append(remove, member.Value)
+			}
+		}
+	}
+	if errResp := setUsersRole(ctx, qtx, add, role); errResp != nil {
+		return *errResp
+	}
+	if role != ROLE_USER {
+		if errResp := setUsersRole(ctx, qtx, remove, ROLE_USER); errResp != nil {
+			return *errResp
+		}
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return scimError(500, "", "Failed to update group")
+	}
+
+	group, err := s.scimBuildGroup(ctx, auth.New(s.dbConn.GetPool()), role, true)
+	if err != nil {
+		return scimError(500, "", "Failed to read group")
+	}
+	return scimResponse{status: 200, body: group, etag: group.Meta.Version}
+}
+
+func setUsersRole(ctx context.Context, qtx *auth.Queries, ids []string, role string) *scimResponse {
+	sort.Strings(ids)
+	for _, idStr := range ids {
+		id, err := parseScimID(idStr)
+		var user auth.CommonUser
+		if err == nil {
+			user, err = qtx.GetUserById(ctx, id)
+		}
+		if err == nil {
+			if errResp := scimRoleChangeDenied(user.Role, role); errResp != nil {
+				return errResp
+			}
+			err = qtx.UpdateUserRole(ctx, auth.UpdateUserRoleParams{Role: role, UserID: id})
+		} else if _, isNum := err.(*strconv.NumError); isNum || err == pgx.ErrNoRows {
+			resp := scimError(400, "invalidValue", fmt.Sprintf("unknown member %s", idStr))
+			return &resp
+		}
+		if err != nil {
+			_asLogger.Errorf("Error changing role of user %s: %v", idStr, err)
+			resp := scimError(500, "", "Failed to update group membership")
+			return &resp
+		}
+	}
+	return nil
+}
+
+func scimMemberIDs(value interface{}) []string {
+	ids := make([]string, 0)
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			ids = append(ids, scimStringValue(item))
+		}
+	case map[string]interface{}:
+		if members, ok := v["members"]; ok {
+			return scimMemberIDs(members)
+		}
+		ids = append(ids, scimStringValue(v))
+	}
+	return ids
+}
@@ -86,9 +86,9 @@ func (s *APIServer) Serve(port int) {
 	//TODO: Following to be changed for production
 	router.MaxMultipartMemory = 8 << 21 //16 MB Max file size
 	cnf := cors.Config{
-		AllowMethods:     []string{"PUT", "PATCH", "GET", "POST", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowMethods:     []string{"PUT", "PATCH", "GET", "POST", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "If-Match", "If-None-Match", "Last-Event-ID"},
+		ExposeHeaders:    []string{"Content-Length", "X-Impersonated-By", "ETag"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}
@@ -108,6 +108,8 @@ func (s *APIServer) Serve(port int) {
 		c.JSON(http.StatusMethodNotAllowed, "Unauthozied")
 		c.Abort()
 	})
+	// Requests made with an impersonation token are flagged and audited
+	router.Use(s.authService.impersonationAudit)
 	router.GET("/", func(c *gin.Context) {
 		c.JSON(http.StatusOK, buildResponse(200, true, "Service Available", nil))
 	})
@@ -132,6 +134,7 @@ func (s *APIServer) Serve(port int) {
 	signal.Notify(s.shutdownChannel, syscall.SIGINT, syscall.SIGTERM)
 	<-s.shutdownChannel
 	_ServerLog.Info("Trying to shutdown the server gracefully...")
+	s.authService.Close()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
 	defer cancel()
@@ -3,8 +3,13 @@ package service
 import (
 	"fmt"
 	"github.com/jordan-wright/email"
+	"html"
 	"log"
 	"net/smtp"
+	"strconv"
+	"strings"
+
+	"github.com/rest/api/internal/model"
 
 	"github.com/spf13/viper"
 	gomail "gopkg.in/gomail.v2"
@@ -140,6 +145,202 @@ func (s *SmtpService) SetNewPassWordMail(username string, empname string, tempPa
 	return nil
 }
 
+// SendCertificateWarningMail warns that the TLS certificate of a satcom url expires soon
+func (s *SmtpService) SendCertificateWarningMail(recipient string, cert model.CertificateInfo) error {
+	expiry := ""
+	if cert.NotAfter != nil {
+		expiry = cert.NotAfter.UTC().Format("2006-01-02 15:04 MST")
+	}
+	days := 0
+	if cert.DaysRemaining != nil {
+		days = *cert.DaysRemaining
+	}
+	mail := CustomEmail{
+		Username: recipient,
+		Subject:  fmt.Sprintf("TLS certificate for %s expires in %d days", cert.Host, days),
+		Body: `
+	<!DOCTYPE html>
+	<html>
+	` + EMAIL_DESIGN_HTML + `
+	<body>
+		<div class="container">
+			<div class="content">
+				<p>The TLS certificate served by <b>` + html.EscapeString(cert.URL) + `</b> (` + html.EscapeString(cert.Company) + `) expires in
+				<span class="otp">` + strconv.Itoa(days) + ` days</span>.</p>
+				<p>Expiry: ` + expiry + `<br/>Issuer: ` + html.EscapeString(cert.Issuer) + `<br/>Subject: ` + html.EscapeString(cert.Subject) + `</p>
+				<p>Please renew the certificate before it expires.</p>
+			</div>
+			<div class="footer">
+			<p>This email has sent by  <span style="color:black">system administrator.</span></p>
+			</div>
+		</div>
+	</body>
+	</html>
+	`,
+	}
+	return s.SendEmail(mail)
+}
+
+// SendSecretRotationMail reminds that a credential of a satcom entry is due for rotation
+func (s *SmtpService) SendSecretRotationMail(recipient string, secret model.SatcomSecret) error {
+	due := ""
+	if secret.RotationDue != nil {
+		due = secret.RotationDue.UTC().Format("2006-01-02")
+	}
+	account := ""
+	if secret.Username != "" {
+		account = ` for user <b>` + html.EscapeString(secret.Username) + `</b>`
+	}
+	mail := CustomEmail{
+		Username: recipient,
+		Subject:  fmt.Sprintf("Rotate secret %s of satcom entry %d (%s)", secret.Name, secret.SatcomID, secret.Company),
+		Body: `
+	<!DOCTYPE html>
+	<html>
+	` + EMAIL_DESIGN_HTML + `
+	<body>
+		<div class="container">
+			<div class="content">
+				<p>The secret <span class="otp">` + html.EscapeString(secret.Name) + `</span>` + account + ` of satcom entry <b>` + strconv.Itoa(int(secret.SatcomID)) +
+			`</b> (` + html.EscapeString(secret.Company) + `) was due for rotation on ` + due + `.</p>
+				<p>Last rotated: ` + secret.RotatedAt.UTC().Format("2006-01-02 15:04 MST") + `</p>
+				<p>Please change the credential and store the new value under /api/satcom/` + strconv.Itoa(int(secret.SatcomID)) + `/secrets.</p>
+			</div>
+			<div class="footer">
+			<p>This email has sent by  <span style="color:black">system administrator.</span></p>
+			</div>
+		</div>
+	</body>
+	</html>
+	`,
+	}
+	return s.SendEmail(mail)
+}
+
+// SendNoteMentionMail tells a user that they were mentioned in a note on a satcom entry
+func (s *SmtpService) SendNoteMentionMail(recipient string, note model.SatcomNote, company string, entryName string) error {
+	author := note.CreatedByName
+	if author == "" {
+		author = "A user"
+	}
+	entry := strconv.Itoa(int(note.SatcomID))
+	if entryName != "" {
+		entry += " " + entryName
+	}
+	mail := CustomEmail{
+		Username: recipient,
+		Subject:  fmt.Sprintf("%s mentioned you on satcom entry %s (%s)", author, entry, company),
+		Body: `
+	<!DOCTYPE html>
+	<html>
+	` + EMAIL_DESIGN_HTML + `
+	<body>
+		<div class="container">
+			<div class="content">
+				<p>` + html.EscapeString(author) + ` mentioned you in a note on satcom entry <b>` + html.EscapeString(entry) + `</b> (` + html.EscapeString(company) + `):</p>
+				<p style="white-space: pre-wrap">` + html.EscapeString(note.Body) + `</p>
+				<p>Reply under /api/satcom/` + strconv.Itoa(int(note.SatcomID)) + `/notes.</p>
+			</div>
+			<div class="footer">
+			<p>This email has sent by  <span style="color:black">system administrator.</span></p>
+			</div>
+		</div>
+	</body>
+	</html>
+	`,
+	}
+	return s.SendEmail(mail)
+}
+
+// changeOperationName names the operation of a change request in a sentence
+func changeOperationName(operation string) string {
+	if operation == SATCOM_OP_STATUS {
+		return "status change"
+	}
+	return strings.ToLower(operation)
+}
+
+// SendChangeRequestMail asks an approver to review a change request of a production entry
+func (s *SmtpService) SendChangeRequestMail(recipient string, request model.SatcomChangeRequest) error {
+	requester := request.RequestedByName
+	if requester == "" {
+		requester = "A user"
+	}
+	comment := ""
+	if request.Comment != "" {
+		comment = `<p>Comment: ` + html.EscapeString(request.Comment) + `</p>`
+	}
+	changes := `<p>The entry will be moved to the recycle bin.</p>`
+	if request.Operation != SATCOM_OP_DELETE {
+		changes = `<table cellpadding="4"><tr><th align="left">Field</th><th align="left">Current</th><th align="left">Proposed</th></tr>`
+		for _, change := range request.Changes {
+			changes += `<tr><td>` + html.EscapeString(change.Field) + `</td><td>` + html.EscapeString(fmt.Sprint(change.From)) +
+				`</td><td>` + html.EscapeString(fmt.Sprint(change.To)) + `</td></tr>`
+		}
+		changes += `</table>`
+	}
+	mail := CustomEmail{
+		Username: recipient,
+		Subject:  fmt.Sprintf("Approval requested: %s of satcom entry %d (%s)", request.Operation, request.SatcomID, request.Company),
+		Body: `
+	<!DOCTYPE html>
+	<html>
+	` + EMAIL_DESIGN_HTML + `
+	<body>
+		<div class="container">
+			<div class="content">
+				<p>` + html.EscapeString(requester) + ` requested the ` + changeOperationName(request.Operation) + ` of satcom entry <b>` + strconv.Itoa(int(request.SatcomID)) +
+			`</b> (` + html.EscapeString(request.Company) + `), which needs approval.</p>
+				` + comment + changes + `
+				<p>Approve or return change request <span class="otp">#` + strconv.Itoa(int(request.ID)) + `</span> under /api/satcom/changes.</p>
+			</div>
+			<div class="footer">
+			<p>This email has sent by  <span style="color:black">system administrator.</span></p>
+			</div>
+		</div>
+	</body>
+	</html>
+	`,
+	}
+	return s.SendEmail(mail)
+}
+
+// SendChangeDecisionMail tells the requester that their change request was approved or returned
+func (s *SmtpService) SendChangeDecisionMail(recipient string, request model.SatcomChangeRequest) error {
+	decision := strings.ToLower(request.Status)
+	reviewer := request.ReviewedByName
+	if reviewer == "" {
+		reviewer = "an approver"
+	}
+	comment := ""
+	if request.ReviewComment != "" {
+		comment = `<p>Comment: ` + html.EscapeString(request.ReviewComment) + `</p>`
+	}
+	mail := CustomEmail{
+		Username: recipient,
+		Subject:  fmt.Sprintf("Change request #%d for satcom entry %d was %s", request.ID, request.SatcomID, decision),
+		Body: `
+	<!DOCTYPE html>
+	<html>
+	` + EMAIL_DESIGN_HTML + `
+	<body>
+		<div class="container">
+			<div class="content">
+				<p>Your ` + changeOperationName(request.Operation) + ` of satcom entry <b>` + strconv.Itoa(int(request.SatcomID)) + `</b> (` + html.EscapeString(request.Company) +
+			`) was <span class="otp">` + decision + `</span> by ` + html.EscapeString(reviewer) + `.</p>
+				` + comment + `
+			</div>
+			<div class="footer">
+			<p>This email has sent by  <span style="color:black">system administrator.</span></p>
+			</div>
+		</div>
+	</body>
+	</html>
+	`,
+	}
+	return s.SendEmail(mail)
+}
+
 // TODO: Version 2 of mail service
 type EmailService struct{}
 
@@ -0,0 +1,307 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+	"github.com/rest/api/internal/util"
+)
+
+const userExportChunk = 500
+
+var userCSVHeader = []string{"code", "userName", "email", "phone", "role", "status", "createdAt"}
+
+// /api/auth/users/import - bulk create users from a CSV or JSON document (SUPER_ADMIN only)
+//
+// Query parameters: dryRun=true validates without creating anything,
+// invite=true queues a mail with a temporary password for every row imported without one.
+// All rows are created in a single transaction; any invalid row aborts the whole import.
+// Every row is still checked and reported, see importUserRows.
+func (s *RESTService) importUsers(c *gin.Context) APIResponse {
+	if !s.hasRole(c, ROLE_SUPER_ADMIN) {
+		return BuildResponse403("Only administrators can import users")
+	}
+	dryRun, _ := strconv.ParseBool(c.Query("dryRun"))
+	invite, _ := strconv.ParseBool(c.Query("invite"))
+
+	data, format, err := readUpload(c)
+	if err != nil {
+		return BuildResponse400(err.Error())
+	}
+	var rows []model.UserImportRow
+	switch format {
+	case "csv":
+		rows, err = parseUserCSV(data)
+	case "json":
+		err = json.Unmarshal(data, &rows)
+	default:
+		return BuildResponse400("Unsupported import format, expected csv or json")
+	}
+	if err != nil {
+		return BuildResponse400(fmt.Sprintf("Unable to parse import file: %v", err))
+	}
+	if len(rows) == 0 {
+		return BuildResponse400("Import file contains no users")
+	}
+
+	ctx := context.Background()
+	tx, err := s.dbConn.GetPool().Begin(ctx)
+	if err != nil {
+		_asLogger.Errorf("Error starting import transaction: %v", err)
+		return BuildResponse500("Failed to import users", err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	report, tempPasswords, err := s.importUserRows(ctx, tx, rows, dryRun, invite)
+	if err != nil {
+		_asLogger.Errorf("Error importing users: %v", err)
+		return BuildResponse500("Failed to import users", err.Error())
+	}
+
+	if report.Failed > 0 {
+		return buildResponse(400, false, "Import rejected, no users were created", report)
+	}
+	if dryRun {
+		return BuildResponse200("Import validated successfully", report)
+	}
+	if err = tx.Commit(ctx); err != nil {
+		_asLogger.Errorf("Error committing user import: %v", err)
+		return BuildResponse500("Failed to import users", err.Error())
+	}
+	report.Created = len(rows)
+
+	if invite {
+		for i := range report.Rows {
+			tempPass, isFound := tempPasswords[i]
+			if !isFound {
+				continue
+			}
+			row := rows[i]
+			report.Rows[i].Invited = s.mails.Enqueue("invitation to "+row.Email, func(*SmtpService) error {
+				return SendAccountOpeningEmail(row.UserName, row.Email, "", tempPass)
+			})
+		}
+	}
+	return BuildResponse200("Users imported successfully", report)
+}
+
+// importUserRows validates and creates the rows inside tx and reports the result of each.
+// Every row runs in its own savepoint: a failed insert is rolled back to it, so that the
+// transaction is not aborted and the rows after it are still checked. tempPasswords holds
+// the generated passwords by row index.
+func (s *RESTService) importUserRows(ctx context.Context, tx pgx.Tx, rows []model.UserImportRow, dryRun, invite bool) (model.UserImportReport, map[int]string, error) {
+	report := model.UserImportReport{DryRun: dryRun, Total: len(rows)}
+	tempPasswords := make(map[int]string)
+	seen := map[string]map[string]int{"email": {}, "userName": {}, "phone": {}}
+	for i := range rows {
+		row := &rows[i]
+		normalizeImportRow(row)
+		result := model.UserImportRowResult{Row: i + 1, UserName: row.UserName, Email: row.Email, Status: "OK"}
+
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return report, nil, err
+		}
+		qtx := auth.New(savepoint)
+		result.Errors = validateImportRow(ctx, qtx, row, invite, seen, i+1)
+		if len(result.Errors) == 0 {
+			password, pssValid := row.Password, true
+			if password == "" {
+				password, pssValid = util.EncodeToString(8), false
+				tempPasswords[i] = password
+			}
+			if !dryRun {
+				err = qtx.ImportUser(ctx, auth.ImportUserParams{
+					UserName: row.UserName,
+					Email:    row.Email,
+					Phone:    row.Phone,
+					Pass:     s.getHashOf(password),
+					PssValid: pssValid,
+					Role:     row.Role,
+					Status:   row.Status,
+				})
+				if err != nil {
+					_asLogger.Errorf("Error importing user %s: %v", row.Email, err)
+					result.Errors = append(result.Errors, err.Error())
+				}
+			}
+		}
+
+		if len(result.Errors) > 0 {
+			result.Status = "ERROR"
+			report.Failed++
+			if err := savepoint.Rollback(ctx); err != nil {
+				return report, nil, err
+			}
+		} else if err := savepoint.Commit(ctx); err != nil {
+			return report, nil, err
+		}
+		report.Rows = append(report.Rows, result)
+	}
+	return report, tempPasswords, nil
+}
+
+// /api/auth/users/export - stream the user directory as CSV (SUPER_ADMIN only)
+//
+// Accepts the same q, role and status filters as /api/auth/users.
+func (s *RESTService) exportUsers(c *gin.Context) {
+	if !s.hasRole(c, ROLE_SUPER_ADMIN) {
+		resp := BuildResponse403("Only administrators can export users")
+		c.JSON(resp.StatusCode, resp)
+		return
+	}
+	ctx := context.Background()
+	qtx := auth.New(s.dbConn.GetPool())
+	params := auth.ListUsersParams{
+		Search:   optionalLikeText(strings.TrimSpace(c.Query("q"))),
+		Role:     optionalText(strings.ToUpper(c.Query("role"))),
+		Status:   optionalText(strings.ToUpper(c.Query("status"))),
+		RowLimit: userExportChunk,
+	}
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", `attachment; filename="users.csv"`)
+	c.Status(200)
+	writer := csv.NewWriter(c.Writer)
+	writer.Write(userCSVHeader)
+	for {
+		users, err := qtx.ListUsers(ctx, params)
+		if err != nil {
+			// Headers are already sent, the truncated file is the only signal left
+			_asLogger.Errorf("Error exporting users: %v", err)
+			break
+		}
+		for _, user := range users {
+			writer.Write([]string{
+				strconv.Itoa(int(user.UserID)),
+				util.SpreadsheetText(user.UserName),
+				util.SpreadsheetText(user.Email),
+				util.SpreadsheetText(user.Phone),
+				user.Role,
+				user.Status,
+				user.CreatedAt.Time.Format("2006-01-02T15:04:05Z07:00"),
+			})
+		}
+		writer.Flush()
+		c.Writer.Flush()
+		if len(users) < userExportChunk {
+			break
+		}
+		params.AfterID = ConvertInt32ToPgInt4(users[len(users)-1].UserID)
+	}
+}
+
+// parseUserCSV maps a CSV document with a header row onto import rows
+func parseUserCSV(data []byte) ([]model.UserImportRow, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		key := strings.ToLower(strings.NewReplacer("_", "", " ", "").Replace(strings.TrimSpace(name)))
+		columns[key] = i
+	}
+	get := func(record []string, names ...string) string {
+		for _, name := range names {
+			if i, isFound := columns[name]; isFound && i < len(record) {
+				return util.UnescapeSpreadsheetText(record[i])
+			}
+		}
+		return ""
+	}
+
+	rows := make([]model.UserImportRow, 0)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, model.UserImportRow{
+			UserName: get(record, "username", "name"),
+			Email:    get(record, "email"),
+			Phone:    get(record, "phone"),
+			Role:     get(record, "role"),
+			Status:   get(record, "status"),
+			Password: get(record, "password", "pwd"),
+		})
+	}
+	return rows, nil
+}
+
+func normalizeImportRow(row *model.UserImportRow) {
+	row.Email = strings.ToLower(strings.TrimSpace(row.Email))
+	row.Phone = strings.TrimSpace(row.Phone)
+	row.UserName = strings.TrimSpace(row.UserName)
+	if row.UserName == "" {
+		row.UserName = row.Email
+	}
+	row.Role = strings.ToUpper(strings.TrimSpace(row.Role))
+	if row.Role == "" {
+		row.Role = ROLE_USER
+	}
+	row.Status = strings.ToUpper(strings.TrimSpace(row.Status))
+	if row.Status == "" {
+		row.Status = STATUS_ACTIVE
+	}
+}
+
+// validateImportRow checks one row against the database and the rows before it in the same file
+func validateImportRow(ctx context.Context, qtx *auth.Queries, row *model.UserImportRow, invite bool,
+	seen map[string]map[string]int, rowNo int) []string {
+	errs := make([]string, 0)
+	if row.Email == "" || row.Phone == "" {
+		errs = append(errs, "email and phone are required")
+	}
+	if row.Email != "" && !util.IsEmailValid(row.Email) {
+		errs = append(errs, "invalid email address")
+	}
+	if !model.IsValidRoleName(row.Role) {
+		errs = append(errs, fmt.Sprintf("invalid role %s", row.Role))
+	}
+	if row.Status != STATUS_ACTIVE && row.Status != STATUS_INACTIVE {
+		errs = append(errs, fmt.Sprintf("invalid status %s", row.Status))
+	}
+	if row.Password == "" && !invite {
+		errs = append(errs, "password is required unless invitations are sent")
+	}
+
+	checks := []struct {
+		field  string
+		value  string
+		lookup func(context.Context, string) (auth.CommonUser, error)
+	}{
+		{"email", row.Email, qtx.GetUserByEmail},
+		{"userName", row.UserName, qtx.GetUserByUserName},
+		{"phone", row.Phone, qtx.GetUserByPhone},
+	}
+	for _, check := range checks {
+		if check.value == "" {
+			continue
+		}
+		if prev, isFound := seen[check.field][check.value]; isFound {
+			errs = append(errs, fmt.Sprintf("%s %s duplicates row %d", check.field, check.value, prev))
+			continue
+		}
+		seen[check.field][check.value] = rowNo
+		if _, err := check.lookup(ctx, check.value); err == nil {
+			errs = append(errs, fmt.Sprintf("user with this %s already exists", check.field))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rest/api/internal/model"
+)
+
+// fakeImportDB keeps the users of an import transaction and, like Postgres, refuses every
+// statement after one has failed until the enclosing savepoint is rolled back
+type fakeImportDB struct {
+	users     map[string]bool
+	rejected  map[string]bool
+	aborted   bool
+	savepoint int
+}
+
+var errTxAborted = errors.New("current transaction is aborted, commands ignored until end of transaction block")
+
+// fakeImportTx is the transaction or one of its savepoints. The embedded interface is nil:
+// only the methods used by importUserRows are implemented.
+type fakeImportTx struct {
+	pgx.Tx
+	db      *fakeImportDB
+	pending []string
+	parent  *fakeImportTx
+}
+
+func (tx *fakeImportTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	if tx.db.aborted {
+		return nil, errTxAborted
+	}
+	tx.db.savepoint++
+	return &fakeImportTx{db: tx.db, parent: tx}, nil
+}
+
+func (tx *fakeImportTx) Commit(ctx context.Context) error {
+	if tx.db.aborted {
+		return errTxAborted
+	}
+	tx.parent.pending = append(tx.parent.pending, tx.pending...)
+	return nil
+}
+
+func (tx *fakeImportTx) Rollback(ctx context.Context) error {
+	for _, email := range tx.pending {
+		delete(tx.db.users, email)
+	}
+	tx.db.aborted = false
+	return nil
+}
+
+func (tx *fakeImportTx) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	if tx.db.aborted {
+		return pgconn.CommandTag{}, errTxAborted
+	}
+	email := args[1].(string)
+	if tx.db.rejected[email] {
+		tx.db.aborted = true
+		return pgconn.CommandTag{}, errors.New(`duplicate key value violates unique constraint "users_email_key"`)
+	}
+	tx.db.users[email] = true
+	tx.pending = append(tx.pending, email)
+	return pgconn.NewCommandTag("INSERT 0 1"), nil
+}
+
+func (tx *fakeImportTx) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return nil, errors.New("unexpected query")
+}
+
+func (tx *fakeImportTx) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	if tx.db.aborted {
+		return fakeImportRow{errTxAborted}
+	}
+	if strings.Fields(sql)[2] == "GetUserByEmail" && tx.db.users[args[0].(string)] {
+		return fakeImportRow{nil}
+	}
+	return fakeImportRow{pgx.ErrNoRows}
+}
+
+type fakeImportRow struct {
+	err error
+}
+
+func (row fakeImportRow) Scan(dest ...interface{}) error {
+	return row.err
+}
+
+func TestImportUserRowsContinuesAfterFailedInsert(t *testing.T) {
+	db := &fakeImportDB{
+		users:    map[string]bool{"taken@example.com": true},
+		rejected: map[string]bool{"race@example.com": true},
+	}
+	tx := &fakeImportTx{db: db}
+	rows := []model.UserImportRow{
+		{Email: "first@example.com", Phone: "1", Password: "secret"},
+		// Passes validation but the insert fails, as when another import created it meanwhile
+		{Email: "race@example.com", Phone: "2", Password: "secret"},
+		{Email: "third@example.com", Phone: "3", Password: "secret"},
+		{Email: "taken@example.com", Phone: "4", Password: "secret"},
+		{Email: "fifth@example.com", Phone: "5"},
+	}
+
+	s := &RESTService{}
+	report, tempPasswords, err := s.importUserRows(context.Background(), tx, rows, false, true)
+	if err != nil {
+		t.Fatalf("importUserRows: %v", err)
+	}
+	if report.Failed != 2 || len(report.Rows) != len(rows) {
+		t.Fatalf("report = %+v", report)
+	}
+	wantStatus := []string{"OK", "ERROR", "OK", "ERROR", "OK"}
+	for i, result := range report.Rows {
+		if result.Status != wantStatus[i] {
+			t.Errorf("row %d: status %s, want %s (%v)", result.Row, result.Status, wantStatus[i], result.Errors)
+		}
+		for _, msg := range result.Errors {
+			if strings.Contains(msg, "transaction is aborted") {
+				t.Errorf("row %d reports the aborted transaction of an earlier row: %s", result.Row, msg)
+			}
+		}
+	}
+	if msg := strings.Join(report.Rows[1].Errors, ";"); !strings.Contains(msg, "duplicate key") {
+		t.Errorf("failed insert reported %q", msg)
+	}
+	if msg := strings.Join(report.Rows[3].Errors, ";"); !strings.Contains(msg, "already exists") {
+		t.Errorf("existing user reported %q", msg)
+	}
+	if _, isFound := tempPasswords[4]; !isFound || len(tempPasswords) != 1 {
+		t.Errorf("temporary passwords = %v", tempPasswords)
+	}
+	for _, email := range []string{"first@example.com", "third@example.com", "fifth@example.com"} {
+		if !db.users[email] {
+			t.Errorf("%s was not created", email)
+		}
+	}
+	if db.users["race@example.com"] || db.aborted {
+		t.Errorf("failed row was not rolled back: %+v", db)
+	}
+	if db.savepoint != len(rows) {
+		t.Errorf("%d savepoints, want one per row", db.savepoint)
+	}
+}
+
+func TestImportUserRowsDryRun(t *testing.T) {
+	db := &fakeImportDB{users: map[string]bool{}, rejected: map[string]bool{}}
+	rows := []model.UserImportRow{
+		{Email: "a@example.com", Phone: "1", Password: "secret"},
+		{Email: "A@example.com", Phone: "2", Password: "secret"},
+		{Email: "b@example.com", Phone: "3", Role: "SUPERUSER", Password: "secret"},
+	}
+	report, _, err := (&RESTService{}).importUserRows(context.Background(), &fakeImportTx{db: db}, rows, true, false)
+	if err != nil {
+		t.Fatalf("importUserRows: %v", err)
+	}
+	if !report.DryRun || report.Failed != 2 || report.Rows[0].Status != "OK" {
+		t.Fatalf("report = %+v", report)
+	}
+	if msg := strings.Join(report.Rows[1].Errors, ";"); !strings.Contains(msg, "duplicates row 1") {
+		t.Errorf("duplicate email reported %q", msg)
+	}
+	if len(db.users) != 0 {
+		t.Errorf("dry run created %v", db.users)
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -23,6 +24,9 @@ func (s *RESTService) createUser(c *gin.Context) APIResponse {
 		return BuildResponse400("Invalid input provided")
 	}
 
+	// Emails are stored in lower case, as by the bulk import
+	input.Email = strings.ToLower(strings.TrimSpace(input.Email))
+
 	// Validate required fields
 	if input.Email == "" || input.Password == "" || input.Phone == "" {
 		return BuildResponse400("Email, password, and phone are required")
@@ -56,11 +60,6 @@ func (s *RESTService) createUser(c *gin.Context) APIResponse {
 	if err == nil {
 		return BuildResponse400("User with this phone number already exists")
 	}
-	role := input.Role
-	if role == "" {
-		role = "USER" // Default role
-	}
-
 	// Hash password
 	hashedPassword := s.getHashOf(input.Password)
 
@@ -70,7 +69,8 @@ func (s *RESTService) createUser(c *gin.Context) APIResponse {
 		Email:    input.Email,
 		Phone:    input.Phone,
 		Pass:     hashedPassword,
-		Role:     role,
+		// Self-registered users always start as plain users; only super admins assign roles
+		Role: ROLE_USER,
 	}
 
 	err = qtx.CreateUser(ctx, createParams)
@@ -94,36 +94,47 @@ func (s *RESTService) validateLogin(c *gin.Context) APIResponse {
 	}
 
 	ctx := context.Background()
-	db := s.dbConn.GetPool()
-	qtx := auth.New(db)
-
-	// Try to find user by username, email, or phone
-	user, err := qtx.GetUserByLogin(ctx, input.Login)
-	if err != nil {
-		_asLogger.Errorf("Error getting user: %v", err)
-		return BuildResponse404("Invalid login credentials or password", false)
-	}
 
-	// Check password
-	hashedPassword := s.getHashOf(input.Password)
-	if user.Pass != hashedPassword {
-		return BuildResponse404("Invalid login credentials or password", false)
+	// Try the configured identity providers in order
+	for _, provider := range s.identityProviders {
+		identity, err := provider.Authenticate(ctx, input.Login, input.Password)
+		switch {
+		case err == nil:
+			user, errResp := s.resolveIdentity(ctx, provider, identity)
+			if errResp != nil {
+				return *errResp
+			}
+			return s.buildLoginResponse(user)
+		case errors.Is(err, ErrPasswordExpired):
+			return BuildResponse400("Password is not valid. Please reset your password")
+		case errors.Is(err, ErrInvalidCredentials), errors.Is(err, ErrUnsupportedFlow):
+			continue
+		default:
+			_asLogger.Errorf("Identity provider %s failed: %v", provider.Name(), err)
+		}
 	}
+	return BuildResponse404("Invalid login credentials or password", false)
+}
 
-	// Check if password is valid
-	if !user.PssValid {
-		return BuildResponse400("Password is not valid. Please reset your password")
+// buildLoginResponse issues the JWT for an authenticated user
+func (s *RESTService) buildLoginResponse(user auth.CommonUser) APIResponse {
+	// Deactivated accounts (e.g. deprovisioned through SCIM) cannot log in
+	if user.Status != STATUS_ACTIVE {
+		return BuildResponse403("Account is disabled")
 	}
 
-	// Create JWT token
-	jwtToken := s.createJWTToken(user.UserID, user.Email, user.UserName)
+	// Create JWT token carrying the user's default company
+	companyID, company := s.defaultCompany(context.Background(), user.UserID)
+	jwtToken := s.createJWTToken(user.UserID, user.Email, user.UserName, user.Role, companyID, company)
 
 	response := BuildResponse200("Login successful", map[string]interface{}{
-		"user_id":   user.UserID,
-		"user_name": user.UserName,
-		"email":     user.Email,
-		"role":      user.Role,
-		"token":     jwtToken,
+		"user_id":    user.UserID,
+		"user_name":  user.UserName,
+		"email":      user.Email,
+		"role":       user.Role,
+		"company_id": companyID,
+		"company":    company,
+		"token":      jwtToken,
 		// "phone":     user.Phone,
 	})
 	response.Token = &jwtToken
@@ -179,6 +190,8 @@ func (s *RESTService) updateUser(c *gin.Context) APIResponse {
 		return BuildResponse400("Invalid input provided")
 	}
 
+	input.Email = strings.ToLower(strings.TrimSpace(input.Email))
+
 	// Validate required fields
 	if input.UserID == 0 || input.Email == "" || input.Phone == "" || input.UserName == "" {
 		return BuildResponse400("User ID, email, phone, and username are required")
@@ -219,10 +232,18 @@ func (s *RESTService) updateUser(c *gin.Context) APIResponse {
 		}
 	}
 
-	// Set default role if not provided
+	// Keep the existing role if not provided; changing it takes a super admin
 	role := input.Role
 	if role == "" {
-		role = currentUser.Role // Keep existing role if not provided
+		role = currentUser.Role
+	}
+	if role != currentUser.Role {
+		if !model.IsValidRoleName(role) {
+			return BuildResponse400(fmt.Sprintf("Unknown role %s; valid roles are %s", role, strings.Join(model.ValidRoleNames(), ", ")))
+		}
+		if !s.isSuperAdmin(ctx, qtx, s.currentClaims(c)) {
+			return BuildResponse403("Only super admins can change the role of a user")
+		}
 	}
 
 	// Update user
@@ -243,29 +264,115 @@ func (s *RESTService) updateUser(c *gin.Context) APIResponse {
 	return BuildResponse200("User updated successfully", nil)
 }
 
-// /api/auth/users - get all users (requires authentication)
+// /api/auth/users - list users with paging, search, filters and sorting (requires authentication)
+//
+// Query parameters: q, role, status, sort ([-]id|name|email|phone|role|status|created),
+// limit, page or offset, cursor and fields (comma separated, see model.UserDirectoryFields)
 func (s *RESTService) getAllUsers(c *gin.Context) APIResponse {
+	pq, err := parsePageQuery(c, userSortFields, "id")
+	if err != nil {
+		return BuildResponse400(err.Error())
+	}
+	fields, err := parseUserFields(c.Query("fields"))
+	if err != nil {
+		return BuildResponse400(err.Error())
+	}
+
 	ctx := context.Background()
 	db := s.dbConn.GetPool()
 	qtx := auth.New(db)
 
-	users, err := qtx.GetAllUsers(ctx)
+	search := optionalLikeText(strings.TrimSpace(c.Query("q")))
+	role := optionalText(strings.ToUpper(c.Query("role")))
+	status := optionalText(strings.ToUpper(c.Query("status")))
+
+	users, err := qtx.ListUsersSorted(ctx, auth.ListUsersSortedParams{
+		Search:    search,
+		Role:      role,
+		Status:    status,
+		AfterID:   pq.AfterID,
+		SortBy:    pq.SortBy,
+		SortDesc:  pq.SortDesc,
+		RowLimit:  pq.Limit,
+		RowOffset: pq.Offset,
+	})
 	if err != nil {
 		_asLogger.Errorf("Error getting users: %v", err)
 		return BuildResponse500("Failed to retrieve users", err.Error())
 	}
+	total, err := qtx.CountUsers(ctx, auth.CountUsersParams{
+		Search: search,
+		Role:   role,
+		Status: status,
+	})
+	if err != nil {
+		_asLogger.Errorf("Error counting users: %v", err)
+		return BuildResponse500("Failed to retrieve users", err.Error())
+	}
 
 	// Transform to response format
 	userList := make([]map[string]interface{}, 0, len(users))
 	for _, user := range users {
-		userList = append(userList, map[string]interface{}{
-			"code":  user.UserID,
-			"name":  user.UserName,
-			"email": user.Email,
-		})
+		row := map[string]interface{}{
+			"code":      user.UserID,
+			"name":      user.UserName,
+			"email":     user.Email,
+			"phone":     user.Phone,
+			"role":      user.Role,
+			"status":    user.Status,
+			"createdAt": user.CreatedAt.Time,
+		}
+		if fields != nil {
+			for key := range row {
+				if !fields[key] {
+					delete(row, key)
+				}
+			}
+		}
+		userList = append(userList, row)
+	}
+
+	result := model.PageResult{
+		Items:  userList,
+		Total:  total,
+		Limit:  pq.Limit,
+		Offset: pq.Offset,
+		Page:   pq.Page,
 	}
+	if len(users) == int(pq.Limit) && pq.SortBy == "id" && !pq.SortDesc {
+		result.NextCursor = encodeCursor(users[len(users)-1].UserID)
+	}
+
+	return BuildResponse200("Users retrieved successfully", result)
+}
 
-	return BuildResponse200("Users retrieved successfully", userList)
+var userSortFields = map[string]bool{
+	"id":      true,
+	"name":    true,
+	"email":   true,
+	"phone":   true,
+	"role":    true,
+	"status":  true,
+	"created": true,
+}
+
+// parseUserFields validates the sparse field selection; nil means all fields
+func parseUserFields(raw string) (map[string]bool, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	fields := make(map[string]bool)
+	for _, f := range strings.Split(raw, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if !model.UserDirectoryFields[f] {
+			return nil, fmt.Errorf("unsupported field %s", f)
+		}
+		fields[f] = true
+	}
+	return fields, nil
 }
 
 func (s *RESTService) getHashOf(password string) string {
@@ -273,14 +380,14 @@ func (s *RESTService) getHashOf(password string) string {
 	return fmt.Sprintf("%x", shaBytes)
 }
 
-func (s *RESTService) createJWTToken(userID int32, email, userName string) string {
-	if s.jwtSigningKey == nil {
-		return ""
-	}
+func (s *RESTService) createJWTToken(userID int32, email, userName, role string, companyID int32, company string) string {
 	claim := model.AuthorizationClaims{
-		UserID:   userID,
-		Email:    email,
-		UserName: userName,
+		UserID:    userID,
+		Email:     email,
+		UserName:  userName,
+		Role:      role,
+		CompanyID: companyID,
+		Company:   company,
 		StandardClaims: jwt.StandardClaims{
 			IssuedAt:  time.Now().Unix(),
 			ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
@@ -288,13 +395,20 @@ func (s *RESTService) createJWTToken(userID int32, email, userName string) strin
 			Id:        fmt.Sprintf("%d", userID),
 		},
 	}
+	return s.signClaims(claim)
+}
+
+func (s *RESTService) signClaims(claim model.AuthorizationClaims) string {
+	if s.jwtSigningKey == nil {
+		return ""
+	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claim)
 	tokenStr, err := token.SignedString(s.jwtSigningKey)
 	if err != nil {
 		_asLogger.Error("Error in generating token", err)
 		return ""
 	}
-	_asLogger.Infof("Generated token for user %s", email)
+	_asLogger.Infof("Generated token for user %s", claim.Email)
 
 	return tokenStr
 }
@@ -308,6 +422,16 @@ func (s *RESTService) checkAuth(c *gin.Context) bool {
 		return true
 	}
 
+	// Federated login redirects and callbacks happen before the user holds a token
+	if strings.HasPrefix(url.Path, OIDC_API_BASE+"/") {
+		return true
+	}
+
+	// SCIM provisioning requests carry the provisioning token instead of a JWT
+	if strings.HasPrefix(url.Path, SCIM_API_BASE+"/") {
+		return s.checkScimAuth(c)
+	}
+
 	// If JWT key is not set, allow all
 	if s.jwtSigningKey == nil {
 		return true
@@ -315,12 +439,20 @@ func (s *RESTService) checkAuth(c *gin.Context) bool {
 
 	// Check for JWT token in Authorization header
 	authHeader := c.Request.Header.Get("Authorization")
+	// Browser WebSocket clients cannot set headers, so the change feed takes the token from
+	// the offered subprotocols; tokens in the URL would end up in access logs
+	if len(authHeader) == 0 && url.Path == SATCOM_EVENTS_PATH+"/ws" {
+		if tokenStr := websocketProtocolToken(c.Request.Header.Get("Sec-WebSocket-Protocol")); tokenStr != "" {
+			authHeader = "Bearer " + tokenStr
+		}
+	}
 	if len(authHeader) == 0 || !strings.HasPrefix(authHeader, "Bearer ") {
 		return false
 	}
 
 	tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
-	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+	claims := new(model.AuthorizationClaims)
+	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
@@ -331,6 +463,68 @@ func (s *RESTService) checkAuth(c *gin.Context) bool {
 		return false
 	}
 
-	// Token is valid, allow request
+	if !claimsStillValid(context.Background(), auth.New(s.dbConn.GetPool()), claims) {
+		return false
+	}
+
+	// Token is valid, keep the claims for the handlers and allow request
+	c.Set(CLAIMS_CONTEXT_KEY, claims)
 	return true
 }
+
+// claimsStillValid checks the users of a token against the directory on every request.
+// Tokens of deactivated users stop working immediately, and impersonation tokens also stop
+// when the acting administrator is deactivated or no longer a super admin.
+func claimsStillValid(ctx context.Context, qtx *auth.Queries, claims *model.AuthorizationClaims) bool {
+	status, err := qtx.GetUserStatusById(ctx, claims.UserID)
+	if err != nil || status != STATUS_ACTIVE {
+		return false
+	}
+	if claims.Act == nil {
+		return true
+	}
+	actor, err := qtx.GetUserById(ctx, claims.Act.UserID)
+	return err == nil && actor.Status == STATUS_ACTIVE && actor.Role == ROLE_SUPER_ADMIN
+}
+
+// isSuperAdmin re-reads the role of the caller from common.users instead of trusting the
+// role claim, so that a token issued before a demotion no longer grants super admin rights.
+// When no JWT key is configured authentication is disabled and every caller passes.
+func (s *RESTService) isSuperAdmin(ctx context.Context, qtx *auth.Queries, claims *model.AuthorizationClaims) bool {
+	if s.jwtSigningKey == nil {
+		return true
+	}
+	if claims == nil {
+		return false
+	}
+	user, err := qtx.GetUserById(ctx, claims.UserID)
+	return err == nil && user.Status == STATUS_ACTIVE && user.Role == ROLE_SUPER_ADMIN
+}
+
+// currentClaims returns the JWT claims of the caller, nil if the request was not authenticated
+func (s *RESTService) currentClaims(c *gin.Context) *model.AuthorizationClaims {
+	if v, ok := c.Get(CLAIMS_CONTEXT_KEY); ok {
+		if claims, ok := v.(*model.AuthorizationClaims); ok {
+			return claims
+		}
+	}
+	return nil
+}
+
+// hasRole returns true if the caller holds one of the given roles.
+// When no JWT key is configured authentication is disabled and every role check passes.
+func (s *RESTService) hasRole(c *gin.Context, roles ...string) bool {
+	if s.jwtSigningKey == nil {
+		return true
+	}
+	claims := s.currentClaims(c)
+	if claims == nil {
+		return false
+	}
+	for _, role := range roles {
+		if claims.Role == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	auth "github.com/rest/api/internal/dbmodel/db_query"
+	"github.com/rest/api/internal/model"
+	"github.com/rest/api/internal/util"
+)
+
+// RunRotateVaultKeyCommand implements the "rotate-vault-key" subcommand: it re-encrypts
+// the data keys of every secret version written under the old master key with the master
+// key of the configuration file and returns the process exit code
+func RunRotateVaultKeyCommand(args []string) int {
+	flags := flag.NewFlagSet("rotate-vault-key", flag.ExitOnError)
+	configFile := flags.String("c", "./config.json", "Configuration file holding the new master key")
+	oldKeyFile := flags.String("old-key-file", "", "File holding the old master key")
+	oldKeyEnv := flags.String("old-key-env", "", "Environment variable holding the old master key")
+	dryRun := flags.Bool("dry-run", false, "Only count the versions to re-encrypt")
+	flags.Parse(args)
+	if *oldKeyFile == "" && *oldKeyEnv == "" {
+		fmt.Fprintln(os.Stderr, "rotate-vault-key: -old-key-file or -old-key-env is required")
+		flags.Usage()
+		return 2
+	}
+
+	configBytes, err := os.ReadFile(*configFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Unable to read configuration file:", err)
+		return 1
+	}
+	var conf model.AuthServiceConfig
+	if err := json.Unmarshal(configBytes, &conf); err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid configuration file:", err)
+		return 1
+	}
+	if conf.Vault == nil || !conf.Vault.Enabled {
+		fmt.Fprintln(os.Stderr, "The credential vault is not enabled in", *configFile)
+		return 1
+	}
+	newKey, err := loadVaultMasterKey(conf.Vault)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "New master key:", err)
+		return 1
+	}
+	oldKey, err := loadVaultMasterKey(&model.VaultConfig{MasterKeyFile: *oldKeyFile, MasterKeyEnv: *oldKeyEnv})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Old master key:", err)
+		return 1
+	}
+	to, err := newVaultMaster(newKey)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "New master key:", err)
+		return 1
+	}
+	from, err := newVaultMaster(oldKey)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Old master key:", err)
+		return 1
+	}
+	if from.keyID == to.keyID {
+		fmt.Fprintln(os.Stderr, "The old and the new master key are the same")
+		return 1
+	}
+
+	dbConn := util.NewDBConnectionWrapper(configBytes)
+	if dbConn == nil {
+		fmt.Fprintln(os.Stderr, "Unable to connect to the database")
+		return 1
+	}
+	defer dbConn.Close()
+	count, err := rotateVaultKey(context.Background(), dbConn, from, to, *dryRun)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Master key rotation failed, nothing was changed:", err)
+		return 1
+	}
+	if *dryRun {
+		fmt.Printf("%d secret version(s) are encrypted with master key %s\n", count, from.keyID)
+		return 0
+	}
+	fmt.Printf("Re-encrypted %d secret version(s) from master key %s to %s\n", count, from.keyID, to.keyID)
+	return 0
+}
+
+// rotateVaultKey rewraps every data key held by from in a single transaction, so the
+// stored versions never mix master keys when it fails halfway
+func rotateVaultKey(ctx context.Context, dbConn *util.DBConnectionWrapper, from, to *satcomVault, dryRun bool) (int, error) {
+	tx, err := dbConn.GetPool().Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+	qtx := auth.New(tx)
+
+	rows, err := qtx.ListSatcomSecretVersionsByKey(ctx, from.keyID)
+	if err != nil {
+		return 0, err
+	}
+	if dryRun {
+		return len(rows), nil
+	}
+	for _, row := range rows {
+		dataKey, keyNonce, err := to.rewrap(row, from)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := qtx.UpdateSatcomSecretVersionKey(ctx, auth.UpdateSatcomSecretVersionKeyParams{
+			DataKey:  dataKey,
+			KeyNonce: keyNonce,
+			KeyID:    to.keyID,
+			SecretID: row.SecretID,
+			Version:  row.Version,
+			OldKeyID: from.keyID,
+		}); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return len(rows), nil
+}
@@ -4,10 +4,13 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"mime"
 	"mime/multipart"
 	"net/http"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
@@ -39,32 +42,46 @@ func UploadFileToS3(s *session.Session, file multipart.File, fileHeader *multipa
 	// the file content into a buffer
 	size := fileHeader.Size
 	buffer := make([]byte, size)
-	file.Read(buffer)
+	if _, err := io.ReadFull(file, buffer); err != nil {
+		return "", err
+	}
 
 	// create a unique file name for the file
 	tempFileName := fileName + filepath.Ext(fileHeader.Filename)
 	_awsLogger.Info("UploadFileToS3 ", tempFileName)
-	// config settings: this is where you choose the bucket,
-	// filename, content-type and storage class of the file
-	// you're uploading
-	//input *s3.PutObjectInput
 
+	if err := PutObjectToS3(s, tempFileName, buffer, http.DetectContentType(buffer)); err != nil {
+		return "", err
+	}
+
+	return tempFileName, nil
+}
+
+// PutObjectToS3 stores data under key. Objects are private; hand out PresignGetFromS3
+// urls to read them.
+func PutObjectToS3(s *session.Session, key string, data []byte, contentType string) error {
 	_, err := s3.New(s).PutObject(&s3.PutObjectInput{
 		Bucket:               aws.String(viper.GetViper().GetStringMapString("aws")["s3_bucket"]),
-		Key:                  aws.String(tempFileName),
-		ACL:                  aws.String("public-read"), // could be private if you want it to be access by only authorized users
-		Body:                 bytes.NewReader(buffer),
-		ContentLength:        aws.Int64(int64(size)),
-		ContentType:          aws.String(http.DetectContentType(buffer)),
+		Key:                  aws.String(key),
+		ACL:                  aws.String(s3.ObjectCannedACLPrivate),
+		Body:                 bytes.NewReader(data),
+		ContentLength:        aws.Int64(int64(len(data))),
+		ContentType:          aws.String(contentType),
 		ContentDisposition:   aws.String("inline"),
 		ServerSideEncryption: aws.String("AES256"),
 		StorageClass:         aws.String("INTELLIGENT_TIERING"),
 	})
-	if err != nil {
-		return "", err
-	}
+	return err
+}
 
-	return tempFileName, err
+// PresignGetFromS3 returns a url that downloads the object under key as fileName until it expires
+func PresignGetFromS3(s *session.Session, key string, fileName string, expiry time.Duration) (string, error) {
+	req, _ := s3.New(s).GetObjectRequest(&s3.GetObjectInput{
+		Bucket:                     aws.String(viper.GetViper().GetStringMapString("aws")["s3_bucket"]),
+		Key:                        aws.String(key),
+		ResponseContentDisposition: aws.String(mime.FormatMediaType("attachment", map[string]string{"filename": fileName})),
+	})
+	return req.Presign(expiry)
 }
 
 func GetfromS3(s *session.Session, fileHeader *multipart.FileHeader, path string) (*s3.GetObjectOutput, error) {
@@ -102,7 +119,8 @@ func GetListOfFileFromS3(s *session.Session, path string) ([]string, error) {
 		}
 		return true
 	}); err != nil {
-		_awsLogger.Fatalf("failed to list items in s3 directory: %v", err)
+		_awsLogger.Errorf("failed to list items in s3 directory: %v", err)
+		return nil, err
 	}
 
 	return s3Keys, nil
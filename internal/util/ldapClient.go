@@ -0,0 +1,445 @@
+package util
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Minimal LDAPv3 client (RFC 4511) covering StartTLS, simple bind and search with
+// equality/presence filters, which is all the LDAP identity provider needs.
+
+const (
+	ldapTagInteger     = 0x02
+	ldapTagOctetString = 0x04
+	ldapTagEnumerated  = 0x0a
+	ldapTagBoolean     = 0x01
+	ldapTagSequence    = 0x30
+	ldapTagSet         = 0x31
+
+	ldapBindRequest       = 0x60
+	ldapBindResponse      = 0x61
+	ldapUnbindRequest     = 0x42
+	ldapSearchRequest     = 0x63
+	ldapSearchResultEntry = 0x64
+	ldapSearchResultDone  = 0x65
+	ldapSearchResultRef   = 0x73
+	ldapExtendedRequest   = 0x77
+	ldapExtendedResponse  = 0x78
+	ldapExtendedName      = 0x80
+
+	ldapStartTLSOID = "1.3.6.1.4.1.1466.20037"
+
+	// Largest LDAP message accepted from the server; longer length prefixes are rejected
+	// before anything is allocated
+	ldapMaxMessageSize = 1 << 20
+
+	ldapSimpleAuth      = 0x80
+	ldapFilterEquality  = 0xa3
+	ldapFilterPresent   = 0x87
+	ldapResultSuccess   = 0
+	ldapInvalidCredsErr = 49
+
+	// LDAPScopeBase reads only the base object, LDAPScopeSubtree the whole subtree
+	LDAPScopeBase    = 0
+	LDAPScopeSubtree = 2
+)
+
+// LDAPError is a non-success LDAP result code
+type LDAPError struct {
+	ResultCode int
+	Message    string
+}
+
+func (e *LDAPError) Error() string {
+	return fmt.Sprintf("ldap result code %d: %s", e.ResultCode, e.Message)
+}
+
+// IsLDAPInvalidCredentials returns true if err is an invalidCredentials (49) result
+func IsLDAPInvalidCredentials(err error) bool {
+	ldapErr, ok := err.(*LDAPError)
+	return ok && ldapErr.ResultCode == ldapInvalidCredsErr
+}
+
+// LDAPEntry is one search result entry
+type LDAPEntry struct {
+	DN         string
+	Attributes map[string][]string
+}
+
+// First returns the first value of an attribute (case-insensitive name)
+func (e LDAPEntry) First(name string) string {
+	for key, values := range e.Attributes {
+		if strings.EqualFold(key, name) && len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+// Values returns all values of an attribute (case-insensitive name)
+func (e LDAPEntry) Values(name string) []string {
+	for key, values := range e.Attributes {
+		if strings.EqualFold(key, name) {
+			return values
+		}
+	}
+	return nil
+}
+
+// LDAPConn is a single LDAP connection; it is not safe for concurrent use
+type LDAPConn struct {
+	conn      net.Conn
+	host      string
+	reader    *bufio.Reader
+	messageID int
+	timeout   time.Duration
+}
+
+// DialLDAP connects to an ldap:// or ldaps:// URL
+func DialLDAP(rawURL string, timeout time.Duration, insecureSkipVerify bool) (*LDAPConn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid LDAP url: %w", err)
+	}
+	host := u.Host
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	switch u.Scheme {
+	case "ldap":
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "389")
+		}
+		conn, err = dialer.Dial("tcp", host)
+	case "ldaps":
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "636")
+		}
+		conn, err = tls.DialWithDialer(dialer, "tcp", host, &tls.Config{
+			ServerName:         u.Hostname(),
+			InsecureSkipVerify: insecureSkipVerify,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported LDAP scheme %s", u.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &LDAPConn{conn: conn, host: u.Hostname(), reader: bufio.NewReader(conn), timeout: timeout}, nil
+}
+
+// StartTLS upgrades an ldap:// connection to TLS (RFC 4511 section 4.14). It must be
+// called before the first bind, so that no password crosses the network in the clear.
+func (l *LDAPConn) StartTLS(insecureSkipVerify bool) error {
+	if _, isTLS := l.conn.(*tls.Conn); isTLS {
+		return fmt.Errorf("connection already uses TLS")
+	}
+	l.messageID++
+	req := berEncode(ldapTagSequence,
+		berInt(ldapTagInteger, l.messageID),
+		berEncode(ldapExtendedRequest, berString(ldapExtendedName, ldapStartTLSOID)),
+	)
+	if err := l.write(req); err != nil {
+		return err
+	}
+	op, err := l.readResponse()
+	if err != nil {
+		return err
+	}
+	if op.tag != ldapExtendedResponse {
+		return fmt.Errorf("unexpected LDAP response tag 0x%x", op.tag)
+	}
+	if err := ldapResult(op); err != nil {
+		return fmt.Errorf("starttls refused: %w", err)
+	}
+	tlsConn := tls.Client(l.conn, &tls.Config{
+		ServerName:         l.host,
+		InsecureSkipVerify: insecureSkipVerify,
+	})
+	if l.timeout > 0 {
+		tlsConn.SetDeadline(time.Now().Add(l.timeout))
+	}
+	if err := tlsConn.Handshake(); err != nil {
+		return fmt.Errorf("starttls handshake: %w", err)
+	}
+	l.conn = tlsConn
+	l.reader = bufio.NewReader(tlsConn)
+	return nil
+}
+
+// Close sends an unbind request and closes the connection
+func (l *LDAPConn) Close() error {
+	l.messageID++
+	l.write(berEncode(ldapTagSequence, berInt(ldapTagInteger, l.messageID), []byte{ldapUnbindRequest, 0}))
+	return l.conn.Close()
+}
+
+// Bind performs a simple bind
+func (l *LDAPConn) Bind(dn, password string) error {
+	l.messageID++
+	req := berEncode(ldapTagSequence,
+		berInt(ldapTagInteger, l.messageID),
+		berEncode(ldapBindRequest,
+			berInt(ldapTagInteger, 3),
+			berString(ldapTagOctetString, dn),
+			berString(ldapSimpleAuth, password),
+		),
+	)
+	if err := l.write(req); err != nil {
+		return err
+	}
+	op, err := l.readResponse()
+	if err != nil {
+		return err
+	}
+	if op.tag != ldapBindResponse {
+		return fmt.Errorf("unexpected LDAP response tag 0x%x", op.tag)
+	}
+	return ldapResult(op)
+}
+
+// Search runs a search with either a presence filter (value empty) or an equality filter
+func (l *LDAPConn) Search(baseDN string, scope int, filterAttr, filterValue string, attributes []string) ([]LDAPEntry, error) {
+	var filter []byte
+	if filterValue == "" {
+		filter = berString(ldapFilterPresent, filterAttr)
+	} else {
+		filter = berEncode(ldapFilterEquality,
+			berString(ldapTagOctetString, filterAttr),
+			berString(ldapTagOctetString, filterValue),
+		)
+	}
+	attrs := make([][]byte, 0, len(attributes))
+	for _, attr := range attributes {
+		attrs = append(attrs, berString(ldapTagOctetString, attr))
+	}
+	l.messageID++
+	req := berEncode(ldapTagSequence,
+		berInt(ldapTagInteger, l.messageID),
+		berEncode(ldapSearchRequest,
+			berString(ldapTagOctetString, baseDN),
+			berInt(ldapTagEnumerated, scope),
+			berInt(ldapTagEnumerated, 0),
+			berInt(ldapTagInteger, 2),
+			berInt(ldapTagInteger, int(l.timeout/time.Second)),
+			[]byte{ldapTagBoolean, 1, 0},
+			filter,
+			berEncode(ldapTagSequence, attrs...),
+		),
+	)
+	if err := l.write(req); err != nil {
+		return nil, err
+	}
+
+	entries := make([]LDAPEntry, 0)
+	for {
+		op, err := l.readResponse()
+		if err != nil {
+			return nil, err
+		}
+		switch op.tag {
+		case ldapSearchResultEntry:
+			entry, err := parseLDAPEntry(op)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry)
+		case ldapSearchResultRef:
+			// Referrals are not followed
+		case ldapSearchResultDone:
+			return entries, ldapResult(op)
+		default:
+			return nil, fmt.Errorf("unexpected LDAP response tag 0x%x", op.tag)
+		}
+	}
+}
+
+func (l *LDAPConn) write(data []byte) error {
+	if l.timeout > 0 {
+		l.conn.SetWriteDeadline(time.Now().Add(l.timeout))
+	}
+	_, err := l.conn.Write(data)
+	return err
+}
+
+// readResponse reads one LDAPMessage and returns its protocolOp
+func (l *LDAPConn) readResponse() (berElement, error) {
+	if l.timeout > 0 {
+		l.conn.SetReadDeadline(time.Now().Add(l.timeout))
+	}
+	msg, err := berRead(l.reader, ldapMaxMessageSize)
+	if err != nil {
+		return berElement{}, err
+	}
+	children, err := msg.children()
+	if err != nil || len(children) < 2 {
+		return berElement{}, fmt.Errorf("malformed LDAP message")
+	}
+	return children[1], nil
+}
+
+func ldapResult(op berElement) error {
+	children, err := op.children()
+	if err != nil || len(children) < 3 {
+		return fmt.Errorf("malformed LDAP result")
+	}
+	code := children[0].int()
+	if code == ldapResultSuccess {
+		return nil
+	}
+	return &LDAPError{ResultCode: code, Message: string(children[2].data)}
+}
+
+func parseLDAPEntry(op berElement) (LDAPEntry, error) {
+	children, err := op.children()
+	if err != nil || len(children) < 2 {
+		return LDAPEntry{}, fmt.Errorf("malformed LDAP search entry")
+	}
+	entry := LDAPEntry{DN: string(children[0].data), Attributes: make(map[string][]string)}
+	attrs, err := children[1].children()
+	if err != nil {
+		return entry, err
+	}
+	for _, attr := range attrs {
+		parts, err := attr.children()
+		if err != nil || len(parts) < 2 {
+			return entry, fmt.Errorf("malformed LDAP attribute")
+		}
+		values, err := parts[1].children()
+		if err != nil {
+			return entry, err
+		}
+		name := string(parts[0].data)
+		for _, v := range values {
+			entry.Attributes[name] = append(entry.Attributes[name], string(v.data))
+		}
+	}
+	return entry, nil
+}
+
+// EscapeLDAPDN escapes a value for use inside a distinguished name (RFC 4514)
+func EscapeLDAPDN(value string) string {
+	var b strings.Builder
+	for i, r := range value {
+		switch {
+		case strings.ContainsRune(`,+"\<>;=`, r),
+			r == '#' && i == 0,
+			r == ' ' && (i == 0 || i == len(value)-1):
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r == 0:
+			b.WriteString(`\00`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// ---------------------------- BER encoding ----------------------------
+
+type berElement struct {
+	tag  byte
+	data []byte
+}
+
+func (e berElement) children() ([]berElement, error) {
+	items := make([]berElement, 0)
+	reader := bufio.NewReader(bytes.NewReader(e.data))
+	for {
+		item, err := berRead(reader, len(e.data))
+		if err == io.EOF {
+			return items, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+}
+
+func (e berElement) int() int {
+	value := 0
+	for _, b := range e.data {
+		value = value<<8 | int(b)
+	}
+	return value
+}
+
+// berRead reads one element whose content is at most maxLength bytes long
+func berRead(reader *bufio.Reader, maxLength int) (berElement, error) {
+	tag, err := reader.ReadByte()
+	if err != nil {
+		return berElement{}, err
+	}
+	first, err := reader.ReadByte()
+	if err != nil {
+		return berElement{}, err
+	}
+	length := int(first)
+	if first&0x80 != 0 {
+		count := int(first & 0x7f)
+		if count == 0 || count > 4 {
+			return berElement{}, fmt.Errorf("unsupported BER length")
+		}
+		length = 0
+		for i := 0; i < count; i++ {
+			b, err := reader.ReadByte()
+			if err != nil {
+				return berElement{}, err
+			}
+			length = length<<8 | int(b)
+		}
+	}
+	if length > maxLength {
+		return berElement{}, fmt.Errorf("BER element of %d bytes exceeds the limit of %d", length, maxLength)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return berElement{}, err
+	}
+	return berElement{tag: tag, data: data}, nil
+}
+
+func berLength(n int) []byte {
+	if n < 0x80 {
+		return []byte{byte(n)}
+	}
+	digits := make([]byte, 0, 4)
+	for n > 0 {
+		digits = append([]byte{byte(n)}, digits...)
+		n >>= 8
+	}
+	return append([]byte{0x80 | byte(len(digits))}, digits...)
+}
+
+func berEncode(tag byte, parts ...[]byte) []byte {
+	content := make([]byte, 0)
+	for _, p := range parts {
+		content = append(content, p...)
+	}
+	out := append([]byte{tag}, berLength(len(content))...)
+	return append(out, content...)
+}
+
+func berString(tag byte, value string) []byte {
+	out := append([]byte{tag}, berLength(len(value))...)
+	return append(out, value...)
+}
+
+func berInt(tag byte, value int) []byte {
+	digits := []byte{byte(value)}
+	for v := value >> 8; v > 0; v >>= 8 {
+		digits = append([]byte{byte(v)}, digits...)
+	}
+	if digits[0]&0x80 != 0 {
+		digits = append([]byte{0}, digits...)
+	}
+	return append(append([]byte{tag}, berLength(len(digits))...), digits...)
+}
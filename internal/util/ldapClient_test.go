@@ -0,0 +1,315 @@
+package util
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubLDAPServer answers simple binds against passwords and searches against entries
+type stubLDAPServer struct {
+	listener  net.Listener
+	passwords map[string]string
+	entries   []LDAPEntry
+	// tlsConfig enables StartTLS; binds are refused until it has been used
+	tlsConfig *tls.Config
+}
+
+func newStubLDAPServer(t *testing.T, passwords map[string]string, entries []LDAPEntry) *stubLDAPServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := &stubLDAPServer{listener: listener, passwords: passwords, entries: entries}
+	go srv.serve()
+	t.Cleanup(func() { listener.Close() })
+	return srv
+}
+
+func (srv *stubLDAPServer) url() string {
+	return "ldap://" + srv.listener.Addr().String()
+}
+
+func (srv *stubLDAPServer) serve() {
+	for {
+		conn, err := srv.listener.Accept()
+		if err != nil {
+			return
+		}
+		go srv.handle(conn)
+	}
+}
+
+func (srv *stubLDAPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		msg, err := berRead(reader, ldapMaxMessageSize)
+		if err != nil {
+			return
+		}
+		parts, err := msg.children()
+		if err != nil || len(parts) < 2 {
+			return
+		}
+		id := parts[0].int()
+		op := parts[1]
+		fields, _ := op.children()
+		switch op.tag {
+		case ldapExtendedRequest:
+			if srv.tlsConfig == nil || string(fields[0].data) != ldapStartTLSOID {
+				conn.Write(stubLDAPResult(id, ldapExtendedResponse, ldapProtocolErr))
+				continue
+			}
+			conn.Write(stubLDAPResult(id, ldapExtendedResponse, ldapResultSuccess))
+			tlsConn := tls.Server(conn, srv.tlsConfig)
+			if tlsConn.Handshake() != nil {
+				return
+			}
+			conn, reader = tlsConn, bufio.NewReader(tlsConn)
+		case ldapBindRequest:
+			dn, password := string(fields[1].data), string(fields[2].data)
+			code := ldapInvalidCredsErr
+			if expected, ok := srv.passwords[dn]; ok && expected == password {
+				code = ldapResultSuccess
+			}
+			if _, isTLS := conn.(*tls.Conn); srv.tlsConfig != nil && !isTLS {
+				code = ldapConfidentialityRequired
+			}
+			conn.Write(stubLDAPResult(id, ldapBindResponse, code))
+		case ldapSearchRequest:
+			base, scope := string(fields[0].data), fields[1].int()
+			var attr, value string
+			if fields[6].tag == ldapFilterEquality {
+				ava, _ := fields[6].children()
+				attr, value = string(ava[0].data), string(ava[1].data)
+			}
+			for _, entry := range srv.entries {
+				if scope == LDAPScopeBase && entry.DN != base ||
+					scope == LDAPScopeSubtree && !containsValue(entry.Values(attr), value) {
+					continue
+				}
+				conn.Write(stubLDAPEntry(id, entry))
+			}
+			conn.Write(stubLDAPResult(id, ldapSearchResultDone, ldapResultSuccess))
+		case ldapUnbindRequest:
+			return
+		}
+	}
+}
+
+const (
+	ldapProtocolErr             = 2
+	ldapConfidentialityRequired = 13
+)
+
+// stubTLSConfig holds a self-signed certificate for 127.0.0.1
+func stubTLSConfig(t *testing.T) *tls.Config {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+}
+
+func containsValue(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func stubLDAPResult(id int, tag byte, code int) []byte {
+	return berEncode(ldapTagSequence,
+		berInt(ldapTagInteger, id),
+		berEncode(tag,
+			berInt(ldapTagEnumerated, code),
+			berString(ldapTagOctetString, ""),
+			berString(ldapTagOctetString, "stub"),
+		),
+	)
+}
+
+func stubLDAPEntry(id int, entry LDAPEntry) []byte {
+	attrs := make([][]byte, 0, len(entry.Attributes))
+	for name, values := range entry.Attributes {
+		encoded := make([][]byte, 0, len(values))
+		for _, v := range values {
+			encoded = append(encoded, berString(ldapTagOctetString, v))
+		}
+		attrs = append(attrs, berEncode(ldapTagSequence,
+			berString(ldapTagOctetString, name),
+			berEncode(ldapTagSet, encoded...),
+		))
+	}
+	return berEncode(ldapTagSequence,
+		berInt(ldapTagInteger, id),
+		berEncode(ldapSearchResultEntry,
+			berString(ldapTagOctetString, entry.DN),
+			berEncode(ldapTagSequence, attrs...),
+		),
+	)
+}
+
+const stubDN = "uid=jdoe,ou=people,dc=example,dc=com"
+
+func dialStub(t *testing.T, srv *stubLDAPServer) *LDAPConn {
+	t.Helper()
+	conn, err := DialLDAP(srv.url(), time.Second, false)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestLDAPBind(t *testing.T) {
+	srv := newStubLDAPServer(t, map[string]string{stubDN: "secret"}, nil)
+
+	if err := dialStub(t, srv).Bind(stubDN, "secret"); err != nil {
+		t.Fatalf("bind with the right password: %v", err)
+	}
+	err := dialStub(t, srv).Bind(stubDN, "wrong")
+	if !IsLDAPInvalidCredentials(err) {
+		t.Fatalf("bind with a wrong password returned %v, want invalid credentials", err)
+	}
+}
+
+func TestLDAPStartTLS(t *testing.T) {
+	srv := newStubLDAPServer(t, map[string]string{stubDN: "secret"}, nil)
+	srv.tlsConfig = stubTLSConfig(t)
+
+	if err := dialStub(t, srv).Bind(stubDN, "secret"); err == nil {
+		t.Fatal("bind without StartTLS succeeded")
+	}
+	conn := dialStub(t, srv)
+	// The self-signed certificate is not trusted
+	if err := conn.StartTLS(false); err == nil {
+		t.Fatal("StartTLS accepted an untrusted certificate")
+	}
+	conn = dialStub(t, srv)
+	if err := conn.StartTLS(true); err != nil {
+		t.Fatalf("StartTLS: %v", err)
+	}
+	if err := conn.Bind(stubDN, "secret"); err != nil {
+		t.Fatalf("bind after StartTLS: %v", err)
+	}
+	if err := conn.StartTLS(true); err == nil {
+		t.Error("second StartTLS succeeded")
+	}
+
+	plain := newStubLDAPServer(t, nil, nil)
+	if err := dialStub(t, plain).StartTLS(true); err == nil {
+		t.Error("StartTLS succeeded against a server without TLS")
+	}
+}
+
+func TestLDAPSearch(t *testing.T) {
+	entry := LDAPEntry{DN: stubDN, Attributes: map[string][]string{
+		"uid":          {"jdoe"},
+		"mail":         {"jdoe@example.com"},
+		"employeeType": {"staff", "managers"},
+	}}
+	srv := newStubLDAPServer(t, nil, []LDAPEntry{entry})
+	conn := dialStub(t, srv)
+
+	entries, err := conn.Search("dc=example,dc=com", LDAPScopeSubtree, "uid", "jdoe", []string{"dn"})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(entries) != 1 || entries[0].DN != stubDN {
+		t.Fatalf("search returned %+v", entries)
+	}
+	entries, err = conn.Search(stubDN, LDAPScopeBase, "objectClass", "", []string{"mail", "employeeType"})
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("base search returned %+v, %v", entries, err)
+	}
+	if got := entries[0].First("MAIL"); got != "jdoe@example.com" {
+		t.Errorf("First(MAIL) = %q", got)
+	}
+	if got := entries[0].Values("employeetype"); strings.Join(got, ",") != "staff,managers" {
+		t.Errorf("Values(employeetype) = %v", got)
+	}
+	entries, err = conn.Search("dc=example,dc=com", LDAPScopeSubtree, "uid", "nobody", []string{"dn"})
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("search for an unknown uid returned %+v, %v", entries, err)
+	}
+}
+
+func TestBERLengths(t *testing.T) {
+	for _, n := range []int{0, 127, 128, 255, 256, 70000} {
+		value := strings.Repeat("x", n)
+		reader := bufio.NewReader(strings.NewReader(string(berString(ldapTagOctetString, value))))
+		element, err := berRead(reader, ldapMaxMessageSize)
+		if err != nil || string(element.data) != value {
+			t.Errorf("round trip of %d bytes failed: %v", n, err)
+		}
+	}
+	for _, v := range []int{0, 1, 127, 128, 255, 256, 65535} {
+		reader := bufio.NewReader(strings.NewReader(string(berInt(ldapTagInteger, v))))
+		element, err := berRead(reader, ldapMaxMessageSize)
+		if err != nil || element.int() != v {
+			t.Errorf("round trip of %d returned %d, %v", v, element.int(), err)
+		}
+	}
+}
+
+func TestBERLengthLimit(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+		max   int
+	}{
+		// Four length bytes claiming 2 GB, with no content behind them
+		{"huge length", []byte{ldapTagSequence, 0x84, 0x7f, 0xff, 0xff, 0xff}, ldapMaxMessageSize},
+		{"over the limit", berString(ldapTagOctetString, strings.Repeat("x", 300)), 256},
+	}
+	for _, tc := range tests {
+		if _, err := berRead(bufio.NewReader(bytes.NewReader(tc.input)), tc.max); err == nil || !strings.Contains(err.Error(), "exceeds") {
+			t.Errorf("%s: %v", tc.name, err)
+		}
+	}
+	// A child may not claim more than its parent holds
+	parent := berElement{tag: ldapTagSequence, data: []byte{ldapTagOctetString, 0x82, 0x10, 0x00, 'x'}}
+	if _, err := parent.children(); err == nil {
+		t.Error("oversized child was accepted")
+	}
+}
+
+func TestEscapeLDAPDN(t *testing.T) {
+	tests := map[string]string{
+		"jdoe":        "jdoe",
+		"doe, john":   `doe\, john`,
+		"#admin":      `\#admin`,
+		" padded ":    `\ padded\ `,
+		"a+b=c<d>;\"": `a\+b\=c\<d\>\;\"`,
+	}
+	for in, want := range tests {
+		if got := EscapeLDAPDN(in); got != want {
+			t.Errorf("EscapeLDAPDN(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
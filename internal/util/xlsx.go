@@ -0,0 +1,317 @@
+package util
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Minimal XLSX (Office Open XML spreadsheet) support: reading the cell values of the
+// first worksheet and streaming a single-sheet workbook. Styles, formulas and
+// multiple sheets are not supported.
+
+// Limits of ReadXLSX, keeping a hostile workbook from exhausting memory: the uncompressed
+// size of each part and the highest row and column a cell may refer to
+const (
+	xlsxMaxPartSize = 64 << 20
+	xlsxMaxRows     = 100000
+	xlsxMaxColumns  = 1024
+)
+
+type xlsxText struct {
+	T string `xml:"t"`
+	R []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.R) == 0 {
+		return t.T
+	}
+	var sb strings.Builder
+	for _, run := range t.R {
+		sb.WriteString(run.T)
+	}
+	return sb.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Items []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// ReadXLSX returns the cell values of the first worksheet, one slice per row. Shared and
+// inline strings are resolved, booleans become "true"/"false" and numbers are returned
+// as stored (dates are serial numbers, see ExcelSerialToTime). Missing rows are empty.
+func ReadXLSX(data []byte) ([][]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("not an xlsx file: %v", err)
+	}
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var shared xlsxSharedStrings
+	if f, isFound := files["xl/sharedStrings.xml"]; isFound {
+		if err := decodeZipXML(f, &shared); err != nil {
+			return nil, err
+		}
+	}
+
+	sheetPath := "xl/worksheets/sheet1.xml"
+	var workbook xlsxWorkbook
+	var rels xlsxRelationships
+	if f, isFound := files["xl/workbook.xml"]; isFound && decodeZipXML(f, &workbook) == nil && len(workbook.Sheets) > 0 {
+		if f, isFound := files["xl/_rels/workbook.xml.rels"]; isFound && decodeZipXML(f, &rels) == nil {
+			for _, rel := range rels.Items {
+				if rel.ID != workbook.Sheets[0].RID {
+					continue
+				}
+				if strings.HasPrefix(rel.Target, "/") {
+					sheetPath = strings.TrimPrefix(rel.Target, "/")
+				} else {
+					sheetPath = path.Join("xl", rel.Target)
+				}
+			}
+		}
+	}
+	f, isFound := files[sheetPath]
+	if !isFound {
+		return nil, fmt.Errorf("worksheet %s not found", sheetPath)
+	}
+	var sheet xlsxWorksheet
+	if err := decodeZipXML(f, &sheet); err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, 0, len(sheet.Rows))
+	for _, row := range sheet.Rows {
+		if row.R > xlsxMaxRows || len(rows) >= xlsxMaxRows {
+			return nil, fmt.Errorf("worksheet has more than %d rows", xlsxMaxRows)
+		}
+		// Rows without content may be left out of the file
+		if row.R > len(rows)+1 {
+			rows = append(rows, make([][]string, row.R-1-len(rows))...)
+		}
+		values := make([]string, 0, len(row.Cells))
+		for _, cell := range row.Cells {
+			col := len(values)
+			if cell.Ref != "" {
+				col = columnIndex(cell.Ref)
+			}
+			if col < 0 || col >= xlsxMaxColumns {
+				return nil, fmt.Errorf("cell %s is beyond the limit of %d columns", cell.Ref, xlsxMaxColumns)
+			}
+			if col > len(values) {
+				values = append(values, make([]string, col-len(values))...)
+			}
+			value := cell.Value
+			switch cell.Type {
+			case "s":
+				i, err := strconv.Atoi(cell.Value)
+				if err != nil || i < 0 || i >= len(shared.Items) {
+					return nil, fmt.Errorf("cell %s refers to a missing shared string", cell.Ref)
+				}
+				value = shared.Items[i].String()
+			case "inlineStr":
+				value = cell.Inline.String()
+			case "b":
+				value = strconv.FormatBool(cell.Value == "1")
+			}
+			if col < len(values) {
+				values[col] = value
+			} else {
+				values = append(values, value)
+			}
+		}
+		rows = append(rows, values)
+	}
+	return rows, nil
+}
+
+// decodeZipXML decodes a part of the workbook. The declared size of the part is checked
+// first and the reading is bounded as well, since the declared size may lie.
+func decodeZipXML(f *zip.File, v interface{}) error {
+	if f.UncompressedSize64 > xlsxMaxPartSize {
+		return fmt.Errorf("%s exceeds the limit of %d MB", f.Name, xlsxMaxPartSize>>20)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(io.LimitReader(rc, xlsxMaxPartSize)).Decode(v)
+}
+
+// columnIndex converts the letters of a cell reference such as "AB12" to a zero based column.
+// Columns past xlsxMaxColumns are all reported as xlsxMaxColumns, so long references cannot overflow.
+func columnIndex(ref string) int {
+	col := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+		if col > xlsxMaxColumns {
+			return xlsxMaxColumns
+		}
+	}
+	return col - 1
+}
+
+// columnName converts a zero based column to its letters
+func columnName(col int) string {
+	name := ""
+	for col >= 0 {
+		name = string(rune('A'+col%26)) + name
+		col = col/26 - 1
+	}
+	return name
+}
+
+// ExcelSerialToTime converts a spreadsheet date serial (days since 1899-12-30, the
+// fraction being the time of day) to a UTC time rounded to the second
+func ExcelSerialToTime(serial float64) time.Time {
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	seconds := math.Round(serial * 24 * 60 * 60)
+	return epoch.Add(time.Duration(seconds) * time.Second)
+}
+
+// SpreadsheetText prefixes text that a spreadsheet would evaluate as a formula (starting
+// with =, +, - or @) with a single quote, so that exported values cannot inject formulas
+func SpreadsheetText(text string) string {
+	if text != "" && strings.ContainsRune("=+-@", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+// UnescapeSpreadsheetText undoes SpreadsheetText, so that exported files import unchanged
+func UnescapeSpreadsheetText(text string) string {
+	if len(text) > 1 && text[0] == '\'' && strings.ContainsRune("=+-@", rune(text[1])) {
+		return text[1:]
+	}
+	return text
+}
+
+// XLSXWriter streams a workbook with a single worksheet. Rows are written as they come,
+// so the writer may be an HTTP response.
+type XLSXWriter struct {
+	zw   *zip.Writer
+	w    io.Writer
+	rows int
+}
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+
+const xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`
+
+// NewXLSXWriter writes the workbook parts and opens the worksheet
+func NewXLSXWriter(w io.Writer, sheetName string) (*XLSXWriter, error) {
+	zw := zip.NewWriter(w)
+	var name bytes.Buffer
+	xml.EscapeText(&name, []byte(sheetName))
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="` + name.String() + `" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+	for _, part := range parts {
+		pw, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(pw, part.content); err != nil {
+			return nil, err
+		}
+	}
+	sw, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.WriteString(sw, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	return &XLSXWriter{zw: zw, w: sw}, err
+}
+
+// WriteRow appends a row. Integers and floats become numeric cells, booleans boolean
+// cells, nil an empty cell and everything else an inline string, escaped with SpreadsheetText.
+func (x *XLSXWriter) WriteRow(cells []interface{}) error {
+	x.rows++
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<row r="%d">`, x.rows)
+	for i, cell := range cells {
+		ref := columnName(i) + strconv.Itoa(x.rows)
+		switch v := cell.(type) {
+		case nil:
+			continue
+		case int, int32, int64:
+			fmt.Fprintf(&sb, `<c r="%s"><v>%d</v></c>`, ref, v)
+		case float64:
+			fmt.Fprintf(&sb, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(v, 'f', -1, 64))
+		case bool:
+			b := 0
+			if v {
+				b = 1
+			}
+			fmt.Fprintf(&sb, `<c r="%s" t="b"><v>%d</v></c>`, ref, b)
+		default:
+			var text bytes.Buffer
+			xml.EscapeText(&text, []byte(SpreadsheetText(fmt.Sprint(v))))
+			fmt.Fprintf(&sb, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, text.String())
+		}
+	}
+	sb.WriteString(`</row>`)
+	_, err := io.WriteString(x.w, sb.String())
+	return err
+}
+
+// Close ends the worksheet and the zip archive
+func (x *XLSXWriter) Close() error {
+	if _, err := io.WriteString(x.w, `</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	return x.zw.Close()
+}
@@ -0,0 +1,86 @@
+package util
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// xlsxWithSheet packs a workbook holding only the given sheetData rows
+func xlsxWithSheet(t *testing.T, rows string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` + rows + `</sheetData></worksheet>`))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadXLSX(t *testing.T) {
+	data := xlsxWithSheet(t, `<row r="1"><c r="A1" t="inlineStr"><is><t>a</t></is></c><c r="C1"><v>3</v></c></row>`+
+		`<row r="3"><c r="B3" t="b"><v>1</v></c></row>`)
+	rows, err := ReadXLSX(data)
+	if err != nil {
+		t.Fatalf("ReadXLSX: %v", err)
+	}
+	if len(rows) != 3 || strings.Join(rows[0], ",") != "a,,3" || rows[1] != nil || strings.Join(rows[2], ",") != ",true" {
+		t.Errorf("rows = %q", rows)
+	}
+}
+
+func TestReadXLSXLimits(t *testing.T) {
+	tests := []struct {
+		name string
+		rows string
+	}{
+		{"row beyond the limit", `<row r="100001"><c r="A100001"><v>1</v></c></row>`},
+		{"column beyond the limit", `<row r="1"><c r="AMK1"><v>1</v></c></row>`},
+		{"overflowing column", `<row r="1"><c r="ZZZZZZZZZZZZZZZZZZZZ1"><v>1</v></c></row>`},
+	}
+	for _, tc := range tests {
+		if _, err := ReadXLSX(xlsxWithSheet(t, tc.rows)); err == nil {
+			t.Errorf("%s: no error", tc.name)
+		}
+	}
+
+	// A small archive inflating past the part limit
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, _ := zw.Create("xl/worksheets/sheet1.xml")
+	w.Write([]byte(`<worksheet><sheetData><row r="1"><c r="A1"><v>`))
+	w.Write(bytes.Repeat([]byte("1"), xlsxMaxPartSize))
+	w.Write([]byte(`</v></c></row></sheetData></worksheet>`))
+	zw.Close()
+	if _, err := ReadXLSX(buf.Bytes()); err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Errorf("oversized part: %v", err)
+	}
+}
+
+func TestSpreadsheetText(t *testing.T) {
+	tests := []struct {
+		text, want string
+	}{
+		{"plain", "plain"},
+		{"", ""},
+		{"=HYPERLINK(\"http://x\")", "'=HYPERLINK(\"http://x\")"},
+		{"+1", "'+1"},
+		{"-2+3", "'-2+3"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"a=b", "a=b"},
+	}
+	for _, tc := range tests {
+		got := SpreadsheetText(tc.text)
+		if got != tc.want {
+			t.Errorf("SpreadsheetText(%q) = %q, want %q", tc.text, got, tc.want)
+		}
+		if back := UnescapeSpreadsheetText(got); back != tc.text {
+			t.Errorf("UnescapeSpreadsheetText(%q) = %q, want %q", got, back, tc.text)
+		}
+	}
+}
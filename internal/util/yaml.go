@@ -0,0 +1,105 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// YAMLToJSON converts a YAML document to JSON so it can be decoded with the json tags
+// of the API models. Scalars keep their source text unless tagged as a number, boolean
+// or null, so dates such as 2024-01-15 stay strings instead of becoming timestamps.
+func YAMLToJSON(data []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return []byte("null"), nil
+	}
+	value, err := (&yamlConverter{}).value(doc.Content[0], 0)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
+
+// Limits on the converted document. Aliases are expanded in place, so a few nested
+// anchors could otherwise grow a small document exponentially ("billion laughs").
+const (
+	yamlMaxNodes   = 100000
+	yamlMaxAliases = 1000
+	yamlMaxDepth   = 64
+)
+
+// yamlConverter counts the nodes and alias expansions of one conversion
+type yamlConverter struct {
+	nodes   int
+	aliases int
+}
+
+func (c *yamlConverter) value(node *yaml.Node, depth int) (interface{}, error) {
+	c.nodes++
+	if c.nodes > yamlMaxNodes {
+		return nil, fmt.Errorf("line %d: document has more than %d nodes", node.Line, yamlMaxNodes)
+	}
+	if depth > yamlMaxDepth {
+		return nil, fmt.Errorf("line %d: document is nested deeper than %d levels", node.Line, yamlMaxDepth)
+	}
+	switch node.Kind {
+	case yaml.AliasNode:
+		c.aliases++
+		if c.aliases > yamlMaxAliases {
+			return nil, fmt.Errorf("line %d: document expands more than %d aliases", node.Line, yamlMaxAliases)
+		}
+		return c.value(node.Alias, depth+1)
+	case yaml.MappingNode:
+		object := make(map[string]interface{}, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			if key.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("line %d: mapping keys must be scalars", key.Line)
+			}
+			value, err := c.value(node.Content[i+1], depth+1)
+			if err != nil {
+				return nil, err
+			}
+			object[key.Value] = value
+		}
+		return object, nil
+	case yaml.SequenceNode:
+		list := make([]interface{}, 0, len(node.Content))
+		for _, item := range node.Content {
+			value, err := c.value(item, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		return list, nil
+	}
+
+	switch node.ShortTag() {
+	case "!!null":
+		return nil, nil
+	case "!!bool":
+		var b bool
+		err := node.Decode(&b)
+		return b, err
+	case "!!int":
+		var i int64
+		if err := node.Decode(&i); err != nil {
+			return nil, err
+		}
+		return json.Number(strconv.FormatInt(i, 10)), nil
+	case "!!float":
+		var f float64
+		if err := node.Decode(&f); err != nil {
+			return nil, err
+		}
+		return json.Number(strconv.FormatFloat(f, 'g', -1, 64)), nil
+	}
+	return node.Value, nil
+}
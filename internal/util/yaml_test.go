@@ -0,0 +1,76 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestYAMLToJSON(t *testing.T) {
+	doc := `
+defaults: &defaults
+  category: db
+  port: 5432
+entries:
+  - <<: *defaults
+    url: https://a.example.com
+  - settings: *defaults
+    recorded: 2024-01-15
+    enabled: true
+    ratio: 0.5
+    missing: ~
+`
+	got, err := YAMLToJSON([]byte(doc))
+	if err != nil {
+		t.Fatalf("YAMLToJSON: %v", err)
+	}
+	for _, want := range []string{
+		`"settings":{"category":"db","port":5432}`,
+		`"recorded":"2024-01-15"`,
+		`"enabled":true`,
+		`"ratio":0.5`,
+		`"missing":null`,
+	} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("%s does not contain %s", got, want)
+		}
+	}
+}
+
+func TestYAMLToJSONAliasBomb(t *testing.T) {
+	// Each level references the previous one nine times: 9^9 values once expanded
+	var b strings.Builder
+	b.WriteString("a: &a [\"lol\",\"lol\",\"lol\",\"lol\",\"lol\",\"lol\",\"lol\",\"lol\",\"lol\"]\n")
+	prev := "a"
+	for _, name := range []string{"b", "c", "d", "e", "f", "g", "h", "i"} {
+		refs := strings.TrimSuffix(strings.Repeat("*"+prev+",", 9), ",")
+		fmt.Fprintf(&b, "%s: &%s [%s]\n", name, name, refs)
+		prev = name
+	}
+
+	start := time.Now()
+	_, err := YAMLToJSON([]byte(b.String()))
+	if err == nil {
+		t.Fatal("alias bomb was expanded")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("rejecting the alias bomb took %s", elapsed)
+	}
+}
+
+func TestYAMLToJSONLimits(t *testing.T) {
+	deep := strings.Repeat("[", yamlMaxDepth+2) + strings.Repeat("]", yamlMaxDepth+2)
+	if _, err := YAMLToJSON([]byte(deep)); err == nil || !strings.Contains(err.Error(), "nested deeper") {
+		t.Errorf("deep document returned %v", err)
+	}
+
+	var b strings.Builder
+	b.WriteString("x: &x 1\nlist:\n")
+	for i := 0; i <= yamlMaxAliases; i++ {
+		b.WriteString("  - *x\n")
+	}
+	if _, err := YAMLToJSON([]byte(b.String())); err == nil || !strings.Contains(err.Error(), "aliases") {
+		t.Errorf("too many aliases returned %v", err)
+	}
+}
@@ -13,6 +13,15 @@ import (
 )
 
 func main() {
+	// "apply" syncs the satcom inventory of a running server with a manifest file
+	if len(os.Args) > 1 && os.Args[1] == "apply" {
+		os.Exit(service.RunApplyCommand(os.Args[2:]))
+	}
+	// "rotate-vault-key" re-encrypts the stored secrets with a new vault master key
+	if len(os.Args) > 1 && os.Args[1] == "rotate-vault-key" {
+		os.Exit(service.RunRotateVaultKeyCommand(os.Args[2:]))
+	}
+
 	configFilePath := flag.String("c", "./config.json", "Configuration file")
 	verbose := flag.Bool("v", false, "Verbose")
 	port := flag.Int("port", 7070, "Port to run the server on")